@@ -0,0 +1,19 @@
+is args
+
+# package args gives access to the arguments the program was run with
+# from anywhere in the program, not just main. The first argument is
+# always the name the program was run as.
+
+# the number of arguments, including the program name
+pub func count int = __runtime_argc();
+
+# the argument at index i, or an empty string if there is no such argument
+pub func get(int i) string {
+	if i < 0 || i >= count() {
+		return "";
+	}
+	return __runtime_argv()[i] as string;
+}
+
+# all of the arguments as a slice
+pub func all string[] = __runtime_args();
@@ -2,13 +2,13 @@ is color
 
 include "math"
 
-class RGB {
+pub class RGB {
 	float r;
 	float g;
 	float b;
 }
 
-func new_rgb(float r, float g, float b) RGB {
+pub func new_rgb(float r, float g, float b) RGB {
 	RGB col;
 	col.r = r;
 	col.g = g;
@@ -17,7 +17,7 @@ func new_rgb(float r, float g, float b) RGB {
 }
 
 
-func hsv_to_rgb(float h, float s, float v) RGB {
+pub func hsv_to_rgb(float h, float s, float v) RGB {
 	h = h % 360.0;
 	if s = 0 {
 		return new_rgb(v, v, v);
@@ -43,6 +43,6 @@ func hsv_to_rgb(float h, float s, float v) RGB {
 }
 
 
-func equal(RGB a, RGB b) bool {
+pub func equal(RGB a, RGB b) bool {
 	return a.r = b.r && a.g = b.g && a.b = c.b;
 }
\ No newline at end of file
@@ -8,7 +8,12 @@
 typedef struct {
   long size;
   int alloc_count;
+  // the alignment the block was allocated with, or 0 for xmalloc's
+  int align;
   long alloc_index;
+  // the padding between the start of the real block and the prelude, which
+  // is only non zero for aligned blocks
+  long offset;
 } xmalloc_prelude_t;
 
 typedef struct {
@@ -19,10 +24,34 @@ typedef struct {
 
 #define PRELUDE_SIZE (sizeof(xmalloc_prelude_t))
 
+// xmalloc_allocator_t is the interface every allocation made by the runtime
+// goes through, including class instances, arrays and strings. xmalloc adds
+// its prelude on top, so an allocator only hands out raw blocks.
+// alloc_atomic hands out blocks that never hold pointers, which a garbage
+// collector doesn't have to scan. Allocators without it use alloc.
+typedef struct {
+  const char *name;
+  void *(*alloc)(size_t size);
+  void *(*realloc)(void *ptr, size_t size);
+  void (*free)(void *ptr);
+  void *(*alloc_atomic)(size_t size);
+} xmalloc_allocator_t;
+
+// the default allocator, backed by the garbage collector
+extern xmalloc_allocator_t xmalloc_gc_allocator;
+// an allocator that never reuses memory, reports double frees as they
+// happen and reports every block that was never freed at exit
+extern xmalloc_allocator_t xmalloc_debug_allocator;
+
+void xmalloc_set_allocator(xmalloc_allocator_t *allocator);
+xmalloc_allocator_t *xmalloc_get_allocator();
+
 xmalloc_stat_t xmemstat();
 long xmalloc_size(void *ptr);
 void xfree(void *ptr);
 void *xmalloc(size_t size);
+void *xmalloc_atomic(size_t size);
+void *xmalloc_aligned(size_t size, size_t align);
 void *xcalloc(unsigned count, unsigned size);
 void *xrealloc(void *ptr, size_t newsize);
 
@@ -13,11 +13,11 @@ include "c"
 # handled later on in the clang phase
 
 
-func get_default_file_descriptor(int index) c:FILE* ...
+pub func get_default_file_descriptor(int index) c:FILE* ...
 # Some default file descriptors
-c:FILE* stdin = get_default_file_descriptor(0)
-c:FILE* stdout = get_default_file_descriptor(1)
-c:FILE* stderr = get_default_file_descriptor(2)
+pub c:FILE* stdin = get_default_file_descriptor(0)
+pub c:FILE* stdout = get_default_file_descriptor(1)
+pub c:FILE* stderr = get_default_file_descriptor(2)
 
 
 # func fgets(byte* buf, int len, FILE_DESCRIPTOR* fd) ...
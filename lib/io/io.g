@@ -6,11 +6,17 @@ link "io.c"
 
 
 # Printing bindings
-func print(string format, ...) ...
-func fprintf(FILE* handle, string format, ...) ...
+pub func print(string format, ...) ...
+pub func fprintf(FILE* handle, string format, ...) ...
 
 
-func println(string message) {
+pub func println(string message) {
 	print("%s\n", message);
 }
 
+
+# flush everything printed so far. output is buffered by the runtime
+# and only written when the buffer fills up, or when the program exits
+pub func flush {
+	bflush(-1);
+}
@@ -0,0 +1,634 @@
+#include "../include/runtime.h"
+
+#include <math.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+// the kinds of json values, matching the kind constants in json.g
+enum {
+  JSON_NULL,
+  JSON_BOOL,
+  JSON_NUMBER,
+  JSON_STRING,
+  JSON_ARRAY,
+  JSON_OBJECT,
+};
+
+// nesting deeper than this is rejected instead of overflowing the stack
+#define JSON_MAX_DEPTH 512
+
+// json_value_t is a single json value. Arrays and objects keep their
+// elements in order. Objects are small in practice, so keys are looked up
+// with a linear scan instead of a hash table.
+typedef struct json_value {
+  int kind;
+  int boolean;
+  double number;
+  char *string;
+  long len;
+  long cap;
+  char **keys;
+  struct json_value **items;
+} json_value_t;
+
+static json_value_t *json_new(int kind) {
+  json_value_t *v = xcalloc(1, sizeof(json_value_t));
+  v->kind = kind;
+  return v;
+}
+
+static char *json_strndup(const char *s, long len) {
+  char *d = xmalloc(len + 1);
+  memcpy(d, s, len);
+  d[len] = 0;
+  return d;
+}
+
+static char error_message[256] = "";
+
+char *__json_error() { return json_strndup(error_message, strlen(error_message)); }
+
+json_value_t *__json_new_null() { return json_new(JSON_NULL); }
+
+json_value_t *__json_new_bool(int b) {
+  json_value_t *v = json_new(JSON_BOOL);
+  v->boolean = b != 0;
+  return v;
+}
+
+json_value_t *__json_new_number(double n) {
+  json_value_t *v = json_new(JSON_NUMBER);
+  v->number = n;
+  return v;
+}
+
+json_value_t *__json_new_string(char *s) {
+  json_value_t *v = json_new(JSON_STRING);
+  v->len = strlen(s);
+  v->string = json_strndup(s, v->len);
+  return v;
+}
+
+json_value_t *__json_new_array() { return json_new(JSON_ARRAY); }
+
+json_value_t *__json_new_object() { return json_new(JSON_OBJECT); }
+
+int __json_kind(json_value_t *v) { return v == NULL ? -1 : v->kind; }
+
+int __json_bool(json_value_t *v) {
+  return v != NULL && v->kind == JSON_BOOL && v->boolean;
+}
+
+double __json_number(json_value_t *v) {
+  return v != NULL && v->kind == JSON_NUMBER ? v->number : 0;
+}
+
+char *__json_string(json_value_t *v) {
+  return v != NULL && v->kind == JSON_STRING ? v->string : "";
+}
+
+long __json_len(json_value_t *v) {
+  if (v == NULL) {
+    return 0;
+  }
+  switch (v->kind) {
+  case JSON_STRING:
+  case JSON_ARRAY:
+  case JSON_OBJECT:
+    return v->len;
+  }
+  return 0;
+}
+
+json_value_t *__json_at(json_value_t *v, long i) {
+  if (v == NULL || (v->kind != JSON_ARRAY && v->kind != JSON_OBJECT) ||
+      i < 0 || i >= v->len) {
+    return NULL;
+  }
+  return v->items[i];
+}
+
+char *__json_key_at(json_value_t *v, long i) {
+  if (v == NULL || v->kind != JSON_OBJECT || i < 0 || i >= v->len) {
+    return "";
+  }
+  return v->keys[i];
+}
+
+static long json_find(json_value_t *v, const char *key) {
+  for (long i = 0; i < v->len; i++) {
+    if (strcmp(v->keys[i], key) == 0) {
+      return i;
+    }
+  }
+  return -1;
+}
+
+json_value_t *__json_get(json_value_t *v, char *key) {
+  if (v == NULL || v->kind != JSON_OBJECT) {
+    return NULL;
+  }
+  long i = json_find(v, key);
+  return i < 0 ? NULL : v->items[i];
+}
+
+static void json_grow(json_value_t *v) {
+  if (v->len < v->cap) {
+    return;
+  }
+  v->cap = v->cap == 0 ? 4 : v->cap * 2;
+  v->items = xrealloc(v->items, v->cap * sizeof(json_value_t *));
+  if (v->kind == JSON_OBJECT) {
+    v->keys = xrealloc(v->keys, v->cap * sizeof(char *));
+  }
+}
+
+int __json_push(json_value_t *v, json_value_t *item) {
+  if (v == NULL || v->kind != JSON_ARRAY || item == NULL) {
+    return -1;
+  }
+  json_grow(v);
+  v->items[v->len++] = item;
+  return 0;
+}
+
+int __json_set(json_value_t *v, char *key, json_value_t *item) {
+  if (v == NULL || v->kind != JSON_OBJECT || item == NULL) {
+    return -1;
+  }
+  long i = json_find(v, key);
+  if (i >= 0) {
+    v->items[i] = item;
+    return 0;
+  }
+  json_grow(v);
+  v->keys[v->len] = json_strndup(key, strlen(key));
+  v->items[v->len++] = item;
+  return 0;
+}
+
+// ---- encoding ----
+
+typedef struct {
+  char *data;
+  long len;
+  long cap;
+} json_buffer_t;
+
+static void buf_write(json_buffer_t *b, const char *s, long n) {
+  if (b->len + n + 1 > b->cap) {
+    long cap = b->cap == 0 ? 64 : b->cap;
+    while (b->len + n + 1 > cap) {
+      cap *= 2;
+    }
+    b->data = xrealloc(b->data, cap);
+    b->cap = cap;
+  }
+  memcpy(b->data + b->len, s, n);
+  b->len += n;
+  b->data[b->len] = 0;
+}
+
+static void buf_puts(json_buffer_t *b, const char *s) {
+  buf_write(b, s, strlen(s));
+}
+
+static void buf_putc(json_buffer_t *b, char c) { buf_write(b, &c, 1); }
+
+static void encode_string(json_buffer_t *b, const char *s) {
+  buf_putc(b, '"');
+  for (; *s; s++) {
+    unsigned char c = *s;
+    switch (c) {
+    case '"':
+      buf_puts(b, "\\\"");
+      break;
+    case '\\':
+      buf_puts(b, "\\\\");
+      break;
+    case '\b':
+      buf_puts(b, "\\b");
+      break;
+    case '\f':
+      buf_puts(b, "\\f");
+      break;
+    case '\n':
+      buf_puts(b, "\\n");
+      break;
+    case '\r':
+      buf_puts(b, "\\r");
+      break;
+    case '\t':
+      buf_puts(b, "\\t");
+      break;
+    default:
+      if (c < 0x20) {
+        char esc[8];
+        snprintf(esc, sizeof(esc), "\\u%04x", c);
+        buf_puts(b, esc);
+      } else {
+        buf_putc(b, c);
+      }
+    }
+  }
+  buf_putc(b, '"');
+}
+
+// numbers are written with the fewest digits that read back the same
+static void encode_number(json_buffer_t *b, double n) {
+  if (!isfinite(n)) {
+    buf_puts(b, "null");
+    return;
+  }
+  char num[32];
+  for (int precision = 1; precision <= 17; precision++) {
+    snprintf(num, sizeof(num), "%.*g", precision, n);
+    if (strtod(num, NULL) == n) {
+      break;
+    }
+  }
+  buf_puts(b, num);
+}
+
+static void encode_newline(json_buffer_t *b, int indent, int depth) {
+  if (indent <= 0) {
+    return;
+  }
+  buf_putc(b, '\n');
+  for (int i = 0; i < indent * depth; i++) {
+    buf_putc(b, ' ');
+  }
+}
+
+static void encode_value(json_buffer_t *b, json_value_t *v, int indent,
+                         int depth) {
+  if (v == NULL) {
+    buf_puts(b, "null");
+    return;
+  }
+  switch (v->kind) {
+  case JSON_NULL:
+    buf_puts(b, "null");
+    return;
+  case JSON_BOOL:
+    buf_puts(b, v->boolean ? "true" : "false");
+    return;
+  case JSON_NUMBER:
+    encode_number(b, v->number);
+    return;
+  case JSON_STRING:
+    encode_string(b, v->string);
+    return;
+  }
+
+  int object = v->kind == JSON_OBJECT;
+  buf_putc(b, object ? '{' : '[');
+  for (long i = 0; i < v->len; i++) {
+    if (i > 0) {
+      buf_putc(b, ',');
+    }
+    encode_newline(b, indent, depth + 1);
+    if (object) {
+      encode_string(b, v->keys[i]);
+      buf_puts(b, indent > 0 ? ": " : ":");
+    }
+    encode_value(b, v->items[i], indent, depth + 1);
+  }
+  if (v->len > 0) {
+    encode_newline(b, indent, depth);
+  }
+  buf_putc(b, object ? '}' : ']');
+}
+
+char *__json_encode(json_value_t *v, int indent) {
+  json_buffer_t b = {NULL, 0, 0};
+  encode_value(&b, v, indent, 0);
+  return b.data;
+}
+
+// ---- decoding ----
+
+typedef struct {
+  const char *src;
+  const char *p;
+  int depth;
+  int failed;
+} json_parser_t;
+
+static void parse_fail(json_parser_t *p, const char *what) {
+  if (p->failed) {
+    return;
+  }
+  p->failed = 1;
+  long line = 1, col = 1;
+  for (const char *c = p->src; c < p->p; c++) {
+    if (*c == '\n') {
+      line++;
+      col = 1;
+    } else {
+      col++;
+    }
+  }
+  snprintf(error_message, sizeof(error_message), "%s at line %ld column %ld",
+           what, line, col);
+}
+
+static void skip_space(json_parser_t *p) {
+  while (*p->p == ' ' || *p->p == '\t' || *p->p == '\n' || *p->p == '\r') {
+    p->p++;
+  }
+}
+
+static int parse_hex4(json_parser_t *p, unsigned *out) {
+  unsigned n = 0;
+  for (int i = 0; i < 4; i++) {
+    char c = p->p[i];
+    n <<= 4;
+    if (c >= '0' && c <= '9') {
+      n |= c - '0';
+    } else if (c >= 'a' && c <= 'f') {
+      n |= c - 'a' + 10;
+    } else if (c >= 'A' && c <= 'F') {
+      n |= c - 'A' + 10;
+    } else {
+      parse_fail(p, "invalid unicode escape");
+      return -1;
+    }
+  }
+  p->p += 4;
+  *out = n;
+  return 0;
+}
+
+static void put_utf8(json_buffer_t *b, unsigned c) {
+  char s[4];
+  if (c < 0x80) {
+    s[0] = c;
+    buf_write(b, s, 1);
+  } else if (c < 0x800) {
+    s[0] = 0xC0 | (c >> 6);
+    s[1] = 0x80 | (c & 0x3F);
+    buf_write(b, s, 2);
+  } else if (c < 0x10000) {
+    s[0] = 0xE0 | (c >> 12);
+    s[1] = 0x80 | ((c >> 6) & 0x3F);
+    s[2] = 0x80 | (c & 0x3F);
+    buf_write(b, s, 3);
+  } else {
+    s[0] = 0xF0 | (c >> 18);
+    s[1] = 0x80 | ((c >> 12) & 0x3F);
+    s[2] = 0x80 | ((c >> 6) & 0x3F);
+    s[3] = 0x80 | (c & 0x3F);
+    buf_write(b, s, 4);
+  }
+}
+
+// parse a string literal, the parser is on the opening quote
+static char *parse_string(json_parser_t *p, long *len) {
+  json_buffer_t b = {NULL, 0, 0};
+  buf_write(&b, "", 0);
+  p->p++;
+
+  for (;;) {
+    unsigned char c = *p->p;
+    if (c == '"') {
+      p->p++;
+      *len = b.len;
+      return b.data;
+    }
+    if (c == 0) {
+      parse_fail(p, "unterminated string");
+      return NULL;
+    }
+    if (c < 0x20) {
+      parse_fail(p, "control character in string");
+      return NULL;
+    }
+    if (c != '\\') {
+      buf_putc(&b, c);
+      p->p++;
+      continue;
+    }
+
+    p->p++;
+    char esc = *p->p++;
+    switch (esc) {
+    case '"':
+    case '\\':
+    case '/':
+      buf_putc(&b, esc);
+      break;
+    case 'b':
+      buf_putc(&b, '\b');
+      break;
+    case 'f':
+      buf_putc(&b, '\f');
+      break;
+    case 'n':
+      buf_putc(&b, '\n');
+      break;
+    case 'r':
+      buf_putc(&b, '\r');
+      break;
+    case 't':
+      buf_putc(&b, '\t');
+      break;
+    case 'u': {
+      unsigned code;
+      if (parse_hex4(p, &code) != 0) {
+        return NULL;
+      }
+      // combine utf-16 surrogate pairs into a single code point
+      if (code >= 0xD800 && code <= 0xDBFF) {
+        unsigned low;
+        if (p->p[0] != '\\' || p->p[1] != 'u') {
+          parse_fail(p, "unpaired surrogate in string");
+          return NULL;
+        }
+        p->p += 2;
+        if (parse_hex4(p, &low) != 0) {
+          return NULL;
+        }
+        if (low < 0xDC00 || low > 0xDFFF) {
+          parse_fail(p, "unpaired surrogate in string");
+          return NULL;
+        }
+        code = 0x10000 + ((code - 0xD800) << 10) + (low - 0xDC00);
+      } else if (code >= 0xDC00 && code <= 0xDFFF) {
+        parse_fail(p, "unpaired surrogate in string");
+        return NULL;
+      }
+      put_utf8(&b, code);
+      break;
+    }
+    default:
+      p->p--;
+      parse_fail(p, "invalid escape in string");
+      return NULL;
+    }
+  }
+}
+
+static int is_digit(char c) { return c >= '0' && c <= '9'; }
+
+static json_value_t *parse_number(json_parser_t *p) {
+  const char *start = p->p;
+  if (*p->p == '-') {
+    p->p++;
+  }
+  if (*p->p == '0') {
+    p->p++;
+  } else if (is_digit(*p->p)) {
+    while (is_digit(*p->p)) {
+      p->p++;
+    }
+  } else {
+    parse_fail(p, "invalid number");
+    return NULL;
+  }
+  if (*p->p == '.') {
+    p->p++;
+    if (!is_digit(*p->p)) {
+      parse_fail(p, "invalid number");
+      return NULL;
+    }
+    while (is_digit(*p->p)) {
+      p->p++;
+    }
+  }
+  if (*p->p == 'e' || *p->p == 'E') {
+    p->p++;
+    if (*p->p == '+' || *p->p == '-') {
+      p->p++;
+    }
+    if (!is_digit(*p->p)) {
+      parse_fail(p, "invalid number");
+      return NULL;
+    }
+    while (is_digit(*p->p)) {
+      p->p++;
+    }
+  }
+  char *text = json_strndup(start, p->p - start);
+  return __json_new_number(strtod(text, NULL));
+}
+
+static int parse_literal(json_parser_t *p, const char *word) {
+  long n = strlen(word);
+  if (strncmp(p->p, word, n) != 0) {
+    parse_fail(p, "invalid value");
+    return -1;
+  }
+  p->p += n;
+  return 0;
+}
+
+static json_value_t *parse_value(json_parser_t *p);
+
+static json_value_t *parse_container(json_parser_t *p, int object) {
+  char close = object ? '}' : ']';
+  json_value_t *v = json_new(object ? JSON_OBJECT : JSON_ARRAY);
+
+  if (++p->depth > JSON_MAX_DEPTH) {
+    parse_fail(p, "nesting too deep");
+    return NULL;
+  }
+
+  p->p++;
+  skip_space(p);
+  if (*p->p == close) {
+    p->p++;
+    p->depth--;
+    return v;
+  }
+
+  for (;;) {
+    char *key = NULL;
+    if (object) {
+      skip_space(p);
+      if (*p->p != '"') {
+        parse_fail(p, "expected a string key");
+        return NULL;
+      }
+      long len;
+      key = parse_string(p, &len);
+      if (key == NULL) {
+        return NULL;
+      }
+      skip_space(p);
+      if (*p->p != ':') {
+        parse_fail(p, "expected ':' after object key");
+        return NULL;
+      }
+      p->p++;
+    }
+
+    json_value_t *item = parse_value(p);
+    if (item == NULL) {
+      return NULL;
+    }
+    if (object) {
+      __json_set(v, key, item);
+    } else {
+      __json_push(v, item);
+    }
+
+    skip_space(p);
+    if (*p->p == ',') {
+      p->p++;
+      continue;
+    }
+    if (*p->p == close) {
+      p->p++;
+      p->depth--;
+      return v;
+    }
+    parse_fail(p, object ? "expected ',' or '}' in object"
+                         : "expected ',' or ']' in array");
+    return NULL;
+  }
+}
+
+static json_value_t *parse_value(json_parser_t *p) {
+  skip_space(p);
+  switch (*p->p) {
+  case '{':
+    return parse_container(p, 1);
+  case '[':
+    return parse_container(p, 0);
+  case '"': {
+    json_value_t *v = json_new(JSON_STRING);
+    v->string = parse_string(p, &v->len);
+    return v->string == NULL ? NULL : v;
+  }
+  case 't':
+    return parse_literal(p, "true") == 0 ? __json_new_bool(1) : NULL;
+  case 'f':
+    return parse_literal(p, "false") == 0 ? __json_new_bool(0) : NULL;
+  case 'n':
+    return parse_literal(p, "null") == 0 ? __json_new_null() : NULL;
+  case 0:
+    parse_fail(p, "unexpected end of input");
+    return NULL;
+  }
+  if (*p->p == '-' || is_digit(*p->p)) {
+    return parse_number(p);
+  }
+  parse_fail(p, "invalid value");
+  return NULL;
+}
+
+json_value_t *__json_parse(char *text) {
+  json_parser_t p = {text, text, 0, 0};
+  json_value_t *v = parse_value(&p);
+  if (v == NULL) {
+    return NULL;
+  }
+  skip_space(&p);
+  if (*p.p != 0) {
+    parse_fail(&p, "unexpected data after value");
+    return NULL;
+  }
+  return v;
+}
@@ -0,0 +1,104 @@
+is json
+
+link "json.c"
+
+# package json parses and serializes json. Every json value is a Value*,
+# whose kind is one of the kind constants below. Parsing invalid json or
+# reading a missing element gives a missing value, which json:valid()
+# reports and whose kind is -1. Accessors never fail: reading a value as
+# the wrong kind gives the zero value of that kind instead.
+#
+#     json:Value* v = json:parse("{\"name\": \"geode\", \"tags\": [1, 2]}");
+#     if !json:valid(v) {
+#         println("bad json: %s", json:error());
+#     }
+#     println("%s", json:as_string(json:get(v, "name")));
+
+# Value is an opaque json value, it is implemented in json.c
+pub class Value {}
+
+pub int kind_null = 0
+pub int kind_bool = 1
+pub int kind_number = 2
+pub int kind_string = 3
+pub int kind_array = 4
+pub int kind_object = 5
+
+func __json_error() string ...
+func __json_parse(string text) Value* ...
+func __json_encode(Value* v, int indent) string ...
+func __json_new_null() Value* ...
+func __json_new_bool(int b) Value* ...
+func __json_new_number(float n) Value* ...
+func __json_new_string(string s) Value* ...
+func __json_new_array() Value* ...
+func __json_new_object() Value* ...
+func __json_kind(Value* v) int ...
+func __json_bool(Value* v) int ...
+func __json_number(Value* v) float ...
+func __json_string(Value* v) string ...
+func __json_len(Value* v) long ...
+func __json_at(Value* v, long i) Value* ...
+func __json_key_at(Value* v, long i) string ...
+func __json_get(Value* v, string key) Value* ...
+func __json_push(Value* v, Value* item) int ...
+func __json_set(Value* v, string key, Value* item) int ...
+
+# describe why the last call to json:parse failed
+pub func error string = __json_error();
+
+# parse a json document
+pub func parse(string text) Value* = __json_parse(text);
+
+# serialize a value as compact json
+pub func encode(Value* v) string = __json_encode(v, 0);
+
+# serialize a value as json, indenting nested values by indent spaces
+pub func encode_indent(Value* v, int indent) string = __json_encode(v, indent);
+
+pub func new_null Value* = __json_new_null();
+pub func new_number(float n) Value* = __json_new_number(n);
+pub func new_string(string s) Value* = __json_new_string(s);
+pub func new_array Value* = __json_new_array();
+pub func new_object Value* = __json_new_object();
+
+pub func new_bool(bool b) Value* {
+	if b {
+		return __json_new_bool(1);
+	}
+	return __json_new_bool(0);
+}
+
+# the kind of a value, or -1 if it is missing
+pub func kind(Value* v) int = __json_kind(v);
+
+# valid returns false for the result of a failed parse or lookup
+pub func valid(Value* v) bool = __json_kind(v) >= 0;
+
+pub func is_null(Value* v) bool = __json_kind(v) == kind_null;
+
+pub func as_bool(Value* v) bool = __json_bool(v) != 0;
+pub func as_number(Value* v) float = __json_number(v);
+pub func as_int(Value* v) long = __json_number(v) as long;
+pub func as_string(Value* v) string = __json_string(v);
+
+# the number of elements of an array or object, or bytes of a string
+pub func len(Value* v) long = __json_len(v);
+
+# the i-th element of an array or the i-th value of an object
+pub func at(Value* v, long i) Value* = __json_at(v, i);
+
+# the i-th key of an object, in the order the keys were added
+pub func key_at(Value* v, long i) string = __json_key_at(v, i);
+
+# look up a key of an object
+pub func get(Value* v, string key) Value* = __json_get(v, key);
+
+pub func has(Value* v, string key) bool = __json_kind(__json_get(v, key)) >= 0;
+
+# append an element to an array. returns -1 if v is not an array
+pub func push(Value* v, Value* item) int = __json_push(v, item);
+
+# set a key of an object, replacing any previous value. returns -1 if v
+# is not an object
+pub func set(Value* v, string key, Value* item) int = __json_set(v, key, item);
@@ -3,25 +3,26 @@ is math
 link "math.c"
 
 # Here are alot of external functions that
-# link to the c library
-func acos(float x) float ...
-func asin(float x) float ...
-func atan(float x) float ...
-func cos(float x) float ...
-func sin(float x) float ...
-func tan(float x) float ...
-func log(float x) float ...
-func pow(float x, float y) float ...
-func sqrt(float x) float ...
-func ceil(float x) float ...
-func fabs(float x) float ...
-func floor(float x) float ...
-func fmod(float x, float y) float ...
+# link to the c library, and the ones llvm
+# lowers to instructions itself
+pub func acos(float x) float ...
+pub func asin(float x) float ...
+pub func atan(float x) float ...
+pub func cos(float x) float ...
+pub func sin(float x) float ...
+pub func tan(float x) float ...
+pub func log(float x) float ...
+pub func pow(float x, float y) float ...
+pub func sqrt(float x) float = intrinsics:sqrt(x)
+pub func ceil(float x) float = intrinsics:ceil(x)
+pub func fabs(float x) float = intrinsics:fabs(x)
+pub func floor(float x) float = intrinsics:floor(x)
+pub func fmod(float x, float y) float ...
 
 
-func rand() int ...
-func srand(int seed) ...
+pub func rand() int ...
+pub func srand(int seed) ...
 
-func random() float {
+pub func random() float {
 	return rand() / 2147483647.0
 }
\ No newline at end of file
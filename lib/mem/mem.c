@@ -3,3 +3,7 @@
 #include <stdlib.h>
 
 #include <gc/gc.h>
+
+int mem_is_aligned(void *ptr, long align) {
+  return ((unsigned long)ptr & (align - 1)) == 0;
+}
@@ -4,37 +4,61 @@ link "mem.c"
 
 
 
-func bytes_used long ...
-func blocks_used long ...
+pub func bytes_used long ...
+pub func blocks_used long ...
 
 # garbage collected malloc
-func GC_gcollect() ...
+pub func GC_gcollect() ...
 
-func heap_size() long ...
+pub func heap_size() long ...
 
+# mem:arc_objects returns how many instances of classes made with new are
+# alive in a program built with --arc, or 0 without it
+pub func arc_objects long ...
 
-func size(byte* ptr) long {
+
+pub func size(byte* ptr) long {
 	return xmalloc_size(ptr);
 }
 
-func get(long size) byte* {
+pub func get(long size) byte* {
 	return xmalloc(size);
 }
 
-func resize(byte* ptr, long size) byte* {
+# mem:aligned allocates memory whose address is a multiple of align, which
+# must be a power of two, for simd vectors and c apis that need it. It is
+# freed and resized like memory from mem:get.
+pub func aligned(long size, long align) byte* {
+	return xmalloc_aligned(size, align);
+}
+
+pub func mem_is_aligned(byte* ptr, long align) int ...
+
+# mem:is_aligned returns if an address is a multiple of align, a power of
+# two
+pub func is_aligned(byte* ptr, long align) bool {
+	return mem_is_aligned(ptr, align) != 0
+}
+
+# mem:free releases memory right away instead of waiting for the garbage
+# collector. Programs built with --debug-alloc report double frees and
+# memory that was never freed.
+pub func free(byte* ptr) {
+	xfree(ptr);
+}
+
+pub func resize(byte* ptr, long size) byte* {
 	if size(ptr) < size {
 		return xrealloc(ptr, size)
 	}
 	return ptr
 }
 
-func set(byte* ptr, int size, byte val) {
-	for i = 0; i < size; i += 1 {
-		ptr[i] = val;
-	}
+pub func set(byte* ptr, int size, byte val) {
+	intrinsics:memset(ptr, val, size);
 }
 
-func zero(int size) byte* {
+pub func zero(int size) byte* {
 	data = get(size);
 	set(data, size, 0);
 	return data;
@@ -43,9 +67,17 @@ func zero(int size) byte* {
 
 # mem:collect forces a garbage collector collection and returns
 # the number of bytes that were freed
-func collect() long {
+pub func collect() long {
 	before = mem:heap_size()
 	GC_gcollect()
 	after = mem:heap_size()
 	return before - after
 }
+
+# mem:bytes allocates a zeroed slice of size bytes
+pub func bytes(long size) byte[] {
+	byte[] b;
+	b.data = zero(size);
+	b.len = size;
+	return b;
+}
@@ -0,0 +1,211 @@
+#include "../include/runtime.h"
+
+#include <errno.h>
+#include <netdb.h>
+#include <netinet/in.h>
+#include <stdio.h>
+#include <string.h>
+#include <sys/socket.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+// writing to a closed connection should be reported as an error instead
+// of killing the program with SIGPIPE
+#ifdef MSG_NOSIGNAL
+#define NET_SEND_FLAGS MSG_NOSIGNAL
+#else
+#define NET_SEND_FLAGS 0
+#endif
+
+// the message describing the last error, returned by net:error
+static char error_message[256] = "";
+
+static long fail_errno(void) {
+  snprintf(error_message, sizeof(error_message), "%s", strerror(errno));
+  return -1;
+}
+
+static long fail_message(const char *message) {
+  snprintf(error_message, sizeof(error_message), "%s", message);
+  return -1;
+}
+
+char *__net_error() {
+  size_t len = strlen(error_message);
+  char *message = xmalloc(len + 1);
+  memcpy(message, error_message, len + 1);
+  return message;
+}
+
+static int resolve(char *host, int port, int socktype, int passive,
+                   struct addrinfo **res) {
+  struct addrinfo hints;
+  memset(&hints, 0, sizeof(hints));
+  hints.ai_family = AF_UNSPEC;
+  hints.ai_socktype = socktype;
+  if (passive) {
+    hints.ai_flags = AI_PASSIVE;
+  }
+
+  char service[16];
+  snprintf(service, sizeof(service), "%d", port);
+  const char *node = (host == NULL || host[0] == 0) ? NULL : host;
+
+  int rc = getaddrinfo(node, service, &hints, res);
+  if (rc != 0) {
+    return fail_message(gai_strerror(rc));
+  }
+  return 0;
+}
+
+// open a socket to host:port. passive sockets are bound to the address
+// instead of connected to it. an empty host binds to every address
+static int open_socket(char *host, int port, int socktype, int passive) {
+  struct addrinfo *res;
+  if (resolve(host, port, socktype, passive, &res) != 0) {
+    return -1;
+  }
+
+  int fd = -1;
+  for (struct addrinfo *ai = res; ai != NULL; ai = ai->ai_next) {
+    fd = socket(ai->ai_family, ai->ai_socktype, ai->ai_protocol);
+    if (fd < 0) {
+      continue;
+    }
+    if (passive) {
+      int one = 1;
+      setsockopt(fd, SOL_SOCKET, SO_REUSEADDR, &one, sizeof(one));
+      if (bind(fd, ai->ai_addr, ai->ai_addrlen) == 0) {
+        break;
+      }
+    } else if (connect(fd, ai->ai_addr, ai->ai_addrlen) == 0) {
+      break;
+    }
+    int saved = errno;
+    close(fd);
+    errno = saved;
+    fd = -1;
+  }
+
+  int saved = errno;
+  freeaddrinfo(res);
+  errno = saved;
+
+  if (fd < 0) {
+    return fail_errno();
+  }
+  return fd;
+}
+
+int __net_dial_tcp(char *host, int port) {
+  return open_socket(host, port, SOCK_STREAM, 0);
+}
+
+int __net_listen_tcp(char *host, int port, int backlog) {
+  int fd = open_socket(host, port, SOCK_STREAM, 1);
+  if (fd < 0) {
+    return -1;
+  }
+  if (listen(fd, backlog) != 0) {
+    fail_errno();
+    close(fd);
+    return -1;
+  }
+  return fd;
+}
+
+int __net_accept(int fd) {
+  for (;;) {
+    int conn = accept(fd, NULL, NULL);
+    if (conn >= 0) {
+      return conn;
+    }
+    if (errno != EINTR) {
+      return fail_errno();
+    }
+  }
+}
+
+int __net_dial_udp(char *host, int port) {
+  return open_socket(host, port, SOCK_DGRAM, 0);
+}
+
+int __net_listen_udp(char *host, int port) {
+  return open_socket(host, port, SOCK_DGRAM, 1);
+}
+
+// the local port a socket is bound to, useful after listening on port 0
+int __net_port(int fd) {
+  struct sockaddr_storage addr;
+  socklen_t len = sizeof(addr);
+  if (getsockname(fd, (struct sockaddr *)&addr, &len) != 0) {
+    return fail_errno();
+  }
+  if (addr.ss_family == AF_INET) {
+    return ntohs(((struct sockaddr_in *)&addr)->sin_port);
+  }
+  if (addr.ss_family == AF_INET6) {
+    return ntohs(((struct sockaddr_in6 *)&addr)->sin6_port);
+  }
+  return fail_message("socket is not an internet socket");
+}
+
+long __net_read(int fd, char *buf, long len) {
+  for (;;) {
+    long n = recv(fd, buf, len, 0);
+    if (n >= 0) {
+      return n;
+    }
+    if (errno != EINTR) {
+      return fail_errno();
+    }
+  }
+}
+
+// write all of buf, unless an error happens first
+long __net_write(int fd, char *buf, long len) {
+  long written = 0;
+  while (written < len) {
+    long n = send(fd, buf + written, len - written, NET_SEND_FLAGS);
+    if (n < 0) {
+      if (errno == EINTR) {
+        continue;
+      }
+      return fail_errno();
+    }
+    written += n;
+  }
+  return written;
+}
+
+long __net_write_string(int fd, char *str) {
+  return __net_write(fd, str, strlen(str));
+}
+
+long __net_send_to(int fd, char *host, int port, char *buf, long len) {
+  struct addrinfo *res;
+  if (resolve(host, port, SOCK_DGRAM, 0, &res) != 0) {
+    return -1;
+  }
+  long n = -1;
+  for (struct addrinfo *ai = res; ai != NULL; ai = ai->ai_next) {
+    n = sendto(fd, buf, len, NET_SEND_FLAGS, ai->ai_addr, ai->ai_addrlen);
+    if (n >= 0) {
+      break;
+    }
+  }
+  int saved = errno;
+  freeaddrinfo(res);
+  errno = saved;
+  if (n < 0) {
+    return fail_errno();
+  }
+  return n;
+}
+
+int __net_close(int fd) {
+  if (close(fd) != 0) {
+    return fail_errno();
+  }
+  return 0;
+}
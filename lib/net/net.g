@@ -0,0 +1,78 @@
+is net
+
+link "net.c"
+
+# package net contains tcp and udp client and server wrappers around the c
+# socket api. Sockets and connections are file descriptors. Like the c api,
+# anything that fails returns -1, and net:error() describes what went wrong.
+#
+#     int conn = net:dial("example.com", 80);
+#     if conn < 0 {
+#         println("dial failed: %s", net:error());
+#     }
+
+func __net_error() string ...
+func __net_dial_tcp(string host, int port) int ...
+func __net_listen_tcp(string host, int port, int backlog) int ...
+func __net_accept(int fd) int ...
+func __net_dial_udp(string host, int port) int ...
+func __net_listen_udp(string host, int port) int ...
+func __net_port(int fd) int ...
+func __net_read(int fd, byte* buf, long len) long ...
+func __net_write(int fd, byte* buf, long len) long ...
+func __net_write_string(int fd, string str) long ...
+func __net_send_to(int fd, string host, int port, byte* buf, long len) long ...
+func __net_close(int fd) int ...
+
+# describe the last error returned by a net function
+pub func error string = __net_error();
+
+# open a tcp connection to host:port
+pub func dial(string host, int port) int = __net_dial_tcp(host, port);
+
+# listen for tcp connections on host:port. an empty host listens on every
+# address, and port 0 picks a free port, see net:port
+pub func listen(string host, int port) int = __net_listen_tcp(host, port, 128);
+
+# wait for a connection to a listener and return it
+pub func accept(int listener) int = __net_accept(listener);
+
+# open a udp socket that sends to and receives from host:port
+pub func dial_udp(string host, int port) int = __net_dial_udp(host, port);
+
+# open a udp socket bound to host:port that can receive from anyone
+pub func listen_udp(string host, int port) int = __net_listen_udp(host, port);
+
+# the local port a socket is bound to
+pub func port(int sock) int = __net_port(sock);
+
+# read up to buf.len bytes into buf. returns the number of bytes read,
+# which is 0 once the other end has closed the connection
+pub func read(int conn, byte[] buf) long = __net_read(conn, buf.data, buf.len);
+
+# read up to max bytes as a string. returns an empty string at the end of
+# the connection or if reading fails
+pub func read_string(int conn, int max) string {
+	byte* buf = xmalloc(max + 1);
+	long n = __net_read(conn, buf, max);
+	if n < 0 {
+		n = 0;
+	}
+	buf[n] = 0;
+	string s;
+	s.data = buf;
+	s.len = n;
+	return s;
+}
+
+# write all of data. returns the number of bytes written
+pub func write(int conn, byte[] data) long = __net_write(conn, data.data, data.len);
+
+# write a string, without its null terminator
+pub func write_string(int conn, string s) long = __net_write_string(conn, s);
+
+# send a udp datagram to host:port from an unconnected socket
+pub func send_to(int sock, string host, int port, byte[] data) long = __net_send_to(sock, host, port, data.data, data.len);
+
+# close a socket or connection
+pub func close(int conn) int = __net_close(conn);
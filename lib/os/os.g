@@ -3,4 +3,4 @@ is os
 # package os contains bindings to 
 # a few posix systemcalls
 
-func fork() int ...
\ No newline at end of file
+pub func fork() int ...
\ No newline at end of file
@@ -0,0 +1,242 @@
+#include <pthread.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "../include/xmalloc.h"
+
+// The reference counts of programs built with --arc. Every object new
+// makes is put in a table with its count and the function that releases
+// the references it holds. Pointers that aren't in the table, like those
+// to variables or to memory from xmalloc, are never counted, so retaining
+// and releasing them does nothing.
+//
+// The addresses in the table are hidden from the collector, so an object
+// whose count never went up, like a result that was thrown away, is still
+// collected. Its entry is removed when it is.
+
+typedef void (*arc_drop_t)(void *obj);
+
+typedef struct {
+  // the hidden address of the object, see arc_hide
+  uintptr_t key;
+  // 0 if the slot is empty, 1 if it is used and 2 if it was removed
+  int state;
+  long refs;
+  arc_drop_t drop;
+  // the finalizer of the block before arc_alloc replaced it
+  GC_finalization_proc finalizer;
+  void *finalizer_data;
+} arc_entry_t;
+
+#define ARC_EMPTY 0
+#define ARC_USED 1
+#define ARC_REMOVED 2
+
+static pthread_mutex_t mutex = PTHREAD_MUTEX_INITIALIZER;
+static arc_entry_t *table = NULL;
+static long table_size = 0;
+static long table_used = 0;
+static long live = 0;
+
+// objects whose count reached zero, which are dropped and freed one at a
+// time so freeing a long list doesn't take a deep recursion
+static void **dead = NULL;
+static arc_drop_t *dead_drops = NULL;
+static long dead_count = 0;
+static long dead_size = 0;
+static int draining = 0;
+
+static uintptr_t arc_hide(void *obj) { return ~(uintptr_t)obj; }
+
+static uintptr_t arc_hash(uintptr_t key) {
+  key ^= key >> 33;
+  key *= 0xff51afd7ed558ccdULL;
+  key ^= key >> 33;
+  return key;
+}
+
+// find the slot of an object, or the slot it would be put in
+static arc_entry_t *arc_find(void *obj, int insert) {
+  if (table_size == 0) {
+    return NULL;
+  }
+  uintptr_t key = arc_hide(obj);
+  arc_entry_t *removed = NULL;
+  for (long i = arc_hash(key) & (table_size - 1);;
+       i = (i + 1) & (table_size - 1)) {
+    arc_entry_t *e = &table[i];
+    if (e->state == ARC_EMPTY) {
+      if (!insert) {
+        return NULL;
+      }
+      return removed != NULL ? removed : e;
+    }
+    if (e->state == ARC_REMOVED) {
+      if (removed == NULL) {
+        removed = e;
+      }
+    } else if (e->key == key) {
+      return e;
+    }
+  }
+}
+
+static void arc_grow() {
+  arc_entry_t *old = table;
+  long old_size = table_size;
+  table_size = table_size == 0 ? 64 : table_size * 2;
+  table = calloc(table_size, sizeof(arc_entry_t));
+  if (table == NULL) {
+    fprintf(stderr, "Fatal: memory exhausted (arc table).\n");
+    exit(EXIT_FAILURE);
+  }
+  table_used = 0;
+  for (long i = 0; i < old_size; i++) {
+    if (old[i].state == ARC_USED) {
+      void *obj = (void *)~old[i].key;
+      *arc_find(obj, 1) = old[i];
+      table_used++;
+    }
+  }
+  free(old);
+}
+
+static void arc_remove(arc_entry_t *e) {
+  e->state = ARC_REMOVED;
+  live--;
+}
+
+// runs when the collector frees an object whose count never reached zero
+static void arc_finalize(void *block, void *data) {
+  pthread_mutex_lock(&mutex);
+  arc_entry_t *e = arc_find((char *)block + PRELUDE_SIZE, 0);
+  GC_finalization_proc finalizer = NULL;
+  void *finalizer_data = NULL;
+  if (e != NULL) {
+    finalizer = e->finalizer;
+    finalizer_data = e->finalizer_data;
+    arc_remove(e);
+  }
+  pthread_mutex_unlock(&mutex);
+  if (finalizer != NULL) {
+    finalizer(block, finalizer_data);
+  }
+}
+
+// __arc_alloc allocates an object of a class for new. Its count starts at
+// zero, and goes up as it is stored. drop releases the references it holds
+// before it is freed, and may be NULL.
+void *__arc_alloc(long size, arc_drop_t drop) {
+  void *obj = xmalloc(size);
+  memset(obj, 0, size);
+  GC_finalization_proc finalizer = NULL;
+  void *finalizer_data = NULL;
+  if (xmalloc_get_allocator() == &xmalloc_gc_allocator) {
+    GC_register_finalizer((char *)obj - PRELUDE_SIZE, arc_finalize, NULL,
+                          &finalizer, &finalizer_data);
+  }
+
+  pthread_mutex_lock(&mutex);
+  if ((table_used + 1) * 2 > table_size) {
+    arc_grow();
+  }
+  arc_entry_t *e = arc_find(obj, 1);
+  if (e->state != ARC_USED) {
+    table_used++;
+    live++;
+  }
+  e->key = arc_hide(obj);
+  e->state = ARC_USED;
+  e->refs = 0;
+  e->drop = drop;
+  e->finalizer = finalizer;
+  e->finalizer_data = finalizer_data;
+  pthread_mutex_unlock(&mutex);
+  return obj;
+}
+
+void __arc_retain(void *obj) {
+  if (obj == NULL) {
+    return;
+  }
+  pthread_mutex_lock(&mutex);
+  arc_entry_t *e = arc_find(obj, 0);
+  if (e != NULL) {
+    e->refs++;
+  }
+  pthread_mutex_unlock(&mutex);
+}
+
+static void arc_kill(void *obj, arc_drop_t drop) {
+  if (dead_count == dead_size) {
+    dead_size = dead_size == 0 ? 16 : dead_size * 2;
+    dead = realloc(dead, dead_size * sizeof(void *));
+    dead_drops = realloc(dead_drops, dead_size * sizeof(arc_drop_t));
+    if (dead == NULL || dead_drops == NULL) {
+      fprintf(stderr, "Fatal: memory exhausted (arc release).\n");
+      exit(EXIT_FAILURE);
+    }
+  }
+  dead[dead_count] = obj;
+  dead_drops[dead_count] = drop;
+  dead_count++;
+}
+
+// __arc_release gives up a reference to an object, freeing it when it was
+// the last one
+void __arc_release(void *obj) {
+  if (obj == NULL) {
+    return;
+  }
+  pthread_mutex_lock(&mutex);
+  arc_entry_t *e = arc_find(obj, 0);
+  if (e == NULL || --e->refs > 0) {
+    pthread_mutex_unlock(&mutex);
+    return;
+  }
+  arc_kill(obj, e->drop);
+  arc_remove(e);
+  // the objects the dropped ones point to are freed by the release that
+  // is already freeing them
+  if (draining) {
+    pthread_mutex_unlock(&mutex);
+    return;
+  }
+  draining = 1;
+  while (dead_count > 0) {
+    dead_count--;
+    void *next = dead[dead_count];
+    arc_drop_t drop = dead_drops[dead_count];
+    pthread_mutex_unlock(&mutex);
+    if (drop != NULL) {
+      drop(next);
+    }
+    xfree(next);
+    pthread_mutex_lock(&mutex);
+  }
+  draining = 0;
+  pthread_mutex_unlock(&mutex);
+}
+
+// __arc_disown gives up a reference to an object without freeing it, which
+// is how a function hands the object it returns to its caller
+void __arc_disown(void *obj) {
+  if (obj == NULL) {
+    return;
+  }
+  pthread_mutex_lock(&mutex);
+  arc_entry_t *e = arc_find(obj, 0);
+  if (e != NULL && e->refs > 0) {
+    e->refs--;
+  }
+  pthread_mutex_unlock(&mutex);
+}
+
+long arc_objects() {
+  pthread_mutex_lock(&mutex);
+  long n = live;
+  pthread_mutex_unlock(&mutex);
+  return n;
+}
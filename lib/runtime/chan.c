@@ -0,0 +1,121 @@
+// the chan<T> threads send values to each other through, see runtime.g.
+// gc.h comes first for the same reason as in thread.c
+#define GC_THREADS
+#include <gc/gc.h>
+
+#include <pthread.h>
+#include <stdint.h>
+#include <string.h>
+
+#include "../include/xmalloc.h"
+
+void fatalf(int err, char *fmt, ...);
+
+// A channel is a ring buffer of the values sent to it and not yet received,
+// each size bytes. A chan opened with a capacity of 0 has room for one
+// value, and a send to it waits for the value to be received.
+struct chan {
+  pthread_mutex_t mutex;
+  // signalled when a value is added, or taken, or the chan is closed
+  pthread_cond_t changed;
+  int64_t size;
+  int64_t cap;
+  int64_t slots;
+  int64_t head;
+  int64_t count;
+  // the number of values ever sent and received, which a send to a chan
+  // with no capacity waits on
+  int64_t sent;
+  int64_t received;
+  int closed;
+  char *buf;
+};
+
+struct chan *__runtime_chan_new(int64_t size, int64_t cap) {
+  if (cap < 0) {
+    fatalf(1, "unable to open a chan with a negative capacity %ld", (long)cap);
+  }
+  struct chan *c = xmalloc(sizeof(struct chan));
+  pthread_mutex_init(&c->mutex, NULL);
+  pthread_cond_init(&c->changed, NULL);
+  c->size = size;
+  c->cap = cap;
+  c->slots = cap > 0 ? cap : 1;
+  c->head = 0;
+  c->count = 0;
+  c->sent = 0;
+  c->received = 0;
+  c->closed = 0;
+  // the values can hold pointers, so the buffer is scanned by the collector
+  c->buf = xmalloc(c->slots * size + 1);
+  return c;
+}
+
+static struct chan *chan_check(struct chan *c, const char *op) {
+  if (c == NULL) {
+    fatalf(1, "unable to %s a chan that wasn't opened", op);
+  }
+  return c;
+}
+
+// copy the value at val into the chan, waiting for room for it
+void __runtime_chan_send(struct chan *c, void *val) {
+  chan_check(c, "send to");
+  pthread_mutex_lock(&c->mutex);
+  while (c->count == c->slots && !c->closed) {
+    pthread_cond_wait(&c->changed, &c->mutex);
+  }
+  if (c->closed) {
+    pthread_mutex_unlock(&c->mutex);
+    fatalf(1, "send to a closed chan");
+  }
+  int64_t tail = (c->head + c->count) % c->slots;
+  memcpy(c->buf + tail * c->size, val, c->size);
+  c->count++;
+  int64_t sent = ++c->sent;
+  pthread_cond_broadcast(&c->changed);
+  // without a capacity the sender waits until its value is received
+  while (c->cap == 0 && c->received < sent && !c->closed) {
+    pthread_cond_wait(&c->changed, &c->mutex);
+  }
+  pthread_mutex_unlock(&c->mutex);
+}
+
+// copy the oldest value in the chan to val, waiting for one to be sent.
+// returns 0 once the chan is closed and has no values left, with val zeroed
+int __runtime_chan_recv(struct chan *c, void *val) {
+  chan_check(c, "receive from");
+  pthread_mutex_lock(&c->mutex);
+  while (c->count == 0 && !c->closed) {
+    pthread_cond_wait(&c->changed, &c->mutex);
+  }
+  if (c->count == 0) {
+    pthread_mutex_unlock(&c->mutex);
+    memset(val, 0, c->size);
+    return 0;
+  }
+  char *slot = c->buf + c->head * c->size;
+  memcpy(val, slot, c->size);
+  // so the collector doesn't keep what the value pointed to alive
+  memset(slot, 0, c->size);
+  c->head = (c->head + 1) % c->slots;
+  c->count--;
+  c->received++;
+  pthread_cond_broadcast(&c->changed);
+  pthread_mutex_unlock(&c->mutex);
+  return 1;
+}
+
+// no more values can be sent once a chan is closed. the values already in
+// it can still be received
+void __runtime_chan_close(struct chan *c) {
+  chan_check(c, "close");
+  pthread_mutex_lock(&c->mutex);
+  if (c->closed) {
+    pthread_mutex_unlock(&c->mutex);
+    fatalf(1, "close of a closed chan");
+  }
+  c->closed = 1;
+  pthread_cond_broadcast(&c->changed);
+  pthread_mutex_unlock(&c->mutex);
+}
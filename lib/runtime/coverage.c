@@ -0,0 +1,43 @@
+#include <stdio.h>
+#include <stdlib.h>
+
+// The counters of a program built with --coverage. Every entry is a line of
+// source and the counter of the basic block it was compiled into, so lines
+// in the same block share a counter.
+static long **coverage_counters = NULL;
+static char **coverage_files = NULL;
+static int *coverage_lines = NULL;
+static long coverage_count = 0;
+
+// the file the counts are written to unless GEODE_COVERAGE names another
+#define COVERAGE_DEFAULT_PATH "geode.cov"
+
+// write every line and the number of times it ran as "file:line count",
+// which geode cover reads
+static void coverage_write(void) {
+  char *path = getenv("GEODE_COVERAGE");
+  if (path == NULL || path[0] == '\0') {
+    path = COVERAGE_DEFAULT_PATH;
+  }
+  FILE *out = fopen(path, "w");
+  if (out == NULL) {
+    perror(path);
+    return;
+  }
+  fprintf(out, "geode coverage\n");
+  for (long i = 0; i < coverage_count; i++) {
+    fprintf(out, "%s:%d %ld\n", coverage_files[i], coverage_lines[i],
+            *coverage_counters[i]);
+  }
+  fclose(out);
+}
+
+// register the counters of the program, called by the compiler generated
+// main function when it is built with --coverage
+void __runtime_coverage(long **counters, char **files, int *lines, long n) {
+  coverage_counters = counters;
+  coverage_files = files;
+  coverage_lines = lines;
+  coverage_count = n;
+  atexit(coverage_write);
+}
@@ -0,0 +1,167 @@
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "../include/xmalloc.h"
+
+// The debug allocator wraps every block in a header that records whether
+// it is still live. Freed blocks are never handed back to malloc, so a
+// second free of the same block can always be recognized. Live blocks are
+// kept in a list so the ones that were never freed can be reported at
+// exit. Blocks are not garbage collected while it is in use.
+//
+// Every block starts with xmalloc's prelude, so messages report the size
+// and address the program itself sees.
+
+#define DEBUG_BLOCK_LIVE 0x4c495645  // "LIVE"
+#define DEBUG_BLOCK_FREED 0x46524545 // "FREE"
+
+// the most leaked blocks listed individually at exit
+#define DEBUG_MAX_REPORTED 10
+
+typedef struct debug_block {
+  unsigned magic;
+  long index;
+  size_t size;
+  struct debug_block *prev;
+  struct debug_block *next;
+} debug_block_t;
+
+// blocks handed out must stay aligned like malloc's
+#define DEBUG_HEADER_SIZE ((sizeof(debug_block_t) + 15) & ~(size_t)15)
+
+static pthread_mutex_t mutex = PTHREAD_MUTEX_INITIALIZER;
+static debug_block_t *live = NULL;
+static long next_index = 0;
+static long live_count = 0;
+static size_t live_bytes = 0;
+
+static debug_block_t *debug_header(void *ptr) {
+  return (debug_block_t *)((char *)ptr - DEBUG_HEADER_SIZE);
+}
+
+static void debug_link(debug_block_t *b) {
+  b->magic = DEBUG_BLOCK_LIVE;
+  b->prev = NULL;
+  b->next = live;
+  if (live != NULL) {
+    live->prev = b;
+  }
+  live = b;
+  live_count++;
+  live_bytes += b->size - PRELUDE_SIZE;
+}
+
+static void debug_unlink(debug_block_t *b) {
+  if (b->prev != NULL) {
+    b->prev->next = b->next;
+  } else {
+    live = b->next;
+  }
+  if (b->next != NULL) {
+    b->next->prev = b->prev;
+  }
+  live_count--;
+  live_bytes -= b->size - PRELUDE_SIZE;
+}
+
+// check that a block being freed or resized is live, aborting otherwise
+static void debug_check(debug_block_t *b, void *ptr, const char *op) {
+  if (b->magic == DEBUG_BLOCK_LIVE) {
+    return;
+  }
+  pthread_mutex_unlock(&mutex);
+  if (b->magic == DEBUG_BLOCK_FREED) {
+    fprintf(stderr,
+            "debug allocator: %s of %p, which was already freed "
+            "(allocation #%ld, %zu bytes)\n",
+            op, (char *)ptr + PRELUDE_SIZE, b->index, b->size - PRELUDE_SIZE);
+  } else {
+    fprintf(stderr, "debug allocator: %s of %p, which was not allocated\n",
+            op, (char *)ptr + PRELUDE_SIZE);
+  }
+  abort();
+}
+
+static void *debug_alloc(size_t size) {
+  debug_block_t *b = calloc(1, DEBUG_HEADER_SIZE + size);
+  if (b == NULL) {
+    return NULL;
+  }
+  pthread_mutex_lock(&mutex);
+  b->index = next_index++;
+  b->size = size;
+  debug_link(b);
+  pthread_mutex_unlock(&mutex);
+  return (char *)b + DEBUG_HEADER_SIZE;
+}
+
+static void *debug_realloc(void *ptr, size_t size) {
+  debug_block_t *b = debug_header(ptr);
+  pthread_mutex_lock(&mutex);
+  debug_check(b, ptr, "realloc");
+  pthread_mutex_unlock(&mutex);
+
+  void *resized = debug_alloc(size);
+  if (resized == NULL) {
+    return NULL;
+  }
+  memcpy(resized, ptr, b->size < size ? b->size : size);
+
+  pthread_mutex_lock(&mutex);
+  debug_unlink(b);
+  b->magic = DEBUG_BLOCK_FREED;
+  pthread_mutex_unlock(&mutex);
+  return resized;
+}
+
+static void debug_free(void *ptr) {
+  debug_block_t *b = debug_header(ptr);
+  pthread_mutex_lock(&mutex);
+  debug_check(b, ptr, "free");
+  debug_unlink(b);
+  b->magic = DEBUG_BLOCK_FREED;
+  pthread_mutex_unlock(&mutex);
+}
+
+// report the blocks that are still live when the program exits
+static void debug_report(void) {
+  pthread_mutex_lock(&mutex);
+  if (live_count > 0) {
+    fprintf(stderr,
+            "debug allocator: %ld blocks (%zu bytes) were never freed\n",
+            live_count, live_bytes);
+    int reported = 0;
+    for (debug_block_t *b = live; b != NULL; b = b->next) {
+      if (reported++ == DEBUG_MAX_REPORTED) {
+        fprintf(stderr, "  ...\n");
+        break;
+      }
+      char *ptr = (char *)b + DEBUG_HEADER_SIZE + PRELUDE_SIZE;
+      fprintf(stderr, "  allocation #%ld: %zu bytes at %p\n", b->index,
+              b->size - PRELUDE_SIZE, ptr);
+    }
+  }
+  pthread_mutex_unlock(&mutex);
+}
+
+xmalloc_allocator_t xmalloc_debug_allocator = {"debug", debug_alloc,
+                                               debug_realloc, debug_free};
+
+// switch the runtime to an allocator by name, returning 0 if there is no
+// allocator with that name. It is called by the generated main function
+// before the runtime is initialized when the program is built with
+// --debug-alloc.
+int __runtime_use_allocator(char *name) {
+  if (strcmp(name, xmalloc_gc_allocator.name) == 0) {
+    xmalloc_set_allocator(&xmalloc_gc_allocator);
+    return 1;
+  }
+  if (strcmp(name, xmalloc_debug_allocator.name) == 0) {
+    xmalloc_set_allocator(&xmalloc_debug_allocator);
+    atexit(debug_report);
+    return 1;
+  }
+  return 0;
+}
@@ -0,0 +1,243 @@
+#include <stdint.h>
+#include <string.h>
+#include <unwind.h>
+
+#include "../include/xmalloc.h"
+
+void __runtime_panicf(char *fmt, ...);
+
+// Errors are thrown with throw and caught by try and catch, see
+// pkg/ast/TryCatch.go. They unwind the stack with the unwinder of the
+// system, the one c++ exceptions use: throw raises an exception holding a
+// copy of the error, and the unwinder calls __runtime_personality for
+// every function on the way up to find the first one in a try.
+//
+// The compiler calls the functions in a try with invoke, so their call
+// sites have a landing pad in the table llvm writes for the function, the
+// LSDA. The landing pads of a try catch every error, so the personality
+// only has to find the call site the function is stopped at in the table.
+// The calls of a function with withs or defers outside of a try have
+// cleanup landing pads, which run them and resume unwinding. They don't
+// catch the error, so the search for a try goes on past them.
+
+// the Error class of runtime.g, whose message is a geode string
+struct error {
+  struct {
+    int64_t len;
+    char *data;
+  } message;
+};
+
+// an error being thrown. The unwinder is given a pointer to the header
+struct exception {
+  struct _Unwind_Exception header;
+  struct error err;
+};
+
+// the class of geode exceptions, "GEODERR\0", so exceptions thrown by
+// other languages unwind through geode functions without being caught
+static const uint64_t exception_class = 0x47454f4445525200;
+
+// the pointer encodings of the LSDA, see the DWARF spec
+#define DW_EH_PE_omit 0xff
+#define DW_EH_PE_absptr 0x00
+#define DW_EH_PE_uleb128 0x01
+#define DW_EH_PE_udata2 0x02
+#define DW_EH_PE_udata4 0x03
+#define DW_EH_PE_udata8 0x04
+#define DW_EH_PE_sleb128 0x09
+#define DW_EH_PE_sdata2 0x0a
+#define DW_EH_PE_sdata4 0x0b
+#define DW_EH_PE_sdata8 0x0c
+#define DW_EH_PE_pcrel 0x10
+#define DW_EH_PE_indirect 0x80
+
+static uintptr_t read_uleb128(const uint8_t **p) {
+  uintptr_t result = 0;
+  unsigned shift = 0;
+  uint8_t byte;
+  do {
+    byte = *(*p)++;
+    result |= (uintptr_t)(byte & 0x7f) << shift;
+    shift += 7;
+  } while (byte & 0x80);
+  return result;
+}
+
+static intptr_t read_sleb128(const uint8_t **p) {
+  uintptr_t result = 0;
+  unsigned shift = 0;
+  uint8_t byte;
+  do {
+    byte = *(*p)++;
+    result |= (uintptr_t)(byte & 0x7f) << shift;
+    shift += 7;
+  } while (byte & 0x80);
+  if ((byte & 0x40) && shift < sizeof(result) * 8) {
+    result |= ~(uintptr_t)0 << shift;
+  }
+  return (intptr_t)result;
+}
+
+// read_encoded reads a value of the LSDA written with an encoding. The
+// values are unaligned, so they are copied out with memcpy.
+static uintptr_t read_encoded(const uint8_t **p, uint8_t encoding) {
+  const uint8_t *start = *p;
+  uintptr_t result = 0;
+  switch (encoding & 0x0f) {
+  case DW_EH_PE_absptr:
+    memcpy(&result, *p, sizeof(uintptr_t));
+    *p += sizeof(uintptr_t);
+    break;
+  case DW_EH_PE_uleb128:
+    result = read_uleb128(p);
+    break;
+  case DW_EH_PE_sleb128:
+    result = read_sleb128(p);
+    break;
+  case DW_EH_PE_udata2: {
+    uint16_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  case DW_EH_PE_udata4: {
+    uint32_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  case DW_EH_PE_udata8: {
+    uint64_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  case DW_EH_PE_sdata2: {
+    int16_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  case DW_EH_PE_sdata4: {
+    int32_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  case DW_EH_PE_sdata8: {
+    int64_t v;
+    memcpy(&v, *p, sizeof(v));
+    *p += sizeof(v);
+    result = v;
+    break;
+  }
+  default:
+    __runtime_panicf("unable to unwind, unknown pointer encoding %x", encoding);
+  }
+  if (result != 0 && (encoding & 0x70) == DW_EH_PE_pcrel) {
+    result += (uintptr_t)start;
+  }
+  if (result != 0 && (encoding & DW_EH_PE_indirect)) {
+    result = *(uintptr_t *)result;
+  }
+  return result;
+}
+
+// landing_pad returns the landing pad of the call site a function is
+// stopped at, or 0 if it has none. catches is set if the landing pad is a
+// try's, rather than one that only cleans up
+static uintptr_t landing_pad(struct _Unwind_Context *ctx, int *catches) {
+  const uint8_t *lsda = _Unwind_GetLanguageSpecificData(ctx);
+  if (lsda == NULL) {
+    return 0;
+  }
+  int before;
+  uintptr_t ip = _Unwind_GetIPInfo(ctx, &before);
+  // the ip is the return address, which can be past the end of the call
+  // site of a call that never returns
+  if (!before) {
+    ip--;
+  }
+  uintptr_t func = _Unwind_GetRegionStart(ctx);
+
+  uintptr_t lp_start = func;
+  uint8_t lp_start_encoding = *lsda++;
+  if (lp_start_encoding != DW_EH_PE_omit) {
+    lp_start = read_encoded(&lsda, lp_start_encoding);
+  }
+  uint8_t ttype_encoding = *lsda++;
+  if (ttype_encoding != DW_EH_PE_omit) {
+    read_uleb128(&lsda);
+  }
+  uint8_t site_encoding = *lsda++;
+  uintptr_t table_len = read_uleb128(&lsda);
+  const uint8_t *end = lsda + table_len;
+
+  // the call sites are sorted by their start
+  while (lsda < end) {
+    uintptr_t start = read_encoded(&lsda, site_encoding);
+    uintptr_t len = read_encoded(&lsda, site_encoding);
+    uintptr_t pad = read_encoded(&lsda, site_encoding);
+    // the landing pads without actions only clean up
+    uintptr_t action = read_uleb128(&lsda);
+    if (ip < func + start) {
+      break;
+    }
+    if (ip < func + start + len) {
+      *catches = action != 0;
+      return pad == 0 ? 0 : lp_start + pad;
+    }
+  }
+  return 0;
+}
+
+_Unwind_Reason_Code __runtime_personality(int version, _Unwind_Action actions,
+                                          uint64_t class,
+                                          struct _Unwind_Exception *exception,
+                                          struct _Unwind_Context *ctx) {
+  if (version != 1) {
+    return _URC_FATAL_PHASE1_ERROR;
+  }
+  if (class != exception_class) {
+    return _URC_CONTINUE_UNWIND;
+  }
+  int catches = 0;
+  uintptr_t pad = landing_pad(ctx, &catches);
+  if (pad == 0) {
+    return _URC_CONTINUE_UNWIND;
+  }
+  if (actions & _UA_SEARCH_PHASE) {
+    return catches ? _URC_HANDLER_FOUND : _URC_CONTINUE_UNWIND;
+  }
+  // the landing pad gets the exception and the selector of the clause
+  // that caught it, the only one there is, or 0 for a cleanup
+  _Unwind_SetGR(ctx, __builtin_eh_return_data_regno(0), (uintptr_t)exception);
+  _Unwind_SetGR(ctx, __builtin_eh_return_data_regno(1), catches);
+  _Unwind_SetIP(ctx, pad);
+  return _URC_INSTALL_CONTEXT;
+}
+
+// throw an error. It only returns to the landing pad of the try that
+// catches it, and panics if nothing does
+void __runtime_throw(struct error *err) {
+  struct exception *e =
+      xmalloc_aligned(sizeof(struct exception), _Alignof(struct exception));
+  memset(&e->header, 0, sizeof(e->header));
+  e->header.exception_class = exception_class;
+  e->err = *err;
+  _Unwind_RaiseException(&e->header);
+  __runtime_panicf("uncaught error: %.*s", (int)err->message.len,
+                   err->message.data);
+}
+
+// the error of the exception a landing pad caught, which the collector
+// frees once the error isn't used
+void __runtime_catch(struct exception *e, struct error *err) {
+  *err = e->err;
+}
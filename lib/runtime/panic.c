@@ -0,0 +1,116 @@
+// dladdr and Dl_info are extensions
+#define _GNU_SOURCE
+
+#include <stdarg.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#if defined(__GLIBC__) || defined(__APPLE__)
+#include <dlfcn.h>
+#include <execinfo.h>
+#define PANIC_BACKTRACE 1
+#endif
+
+// Panics end the program with a message and the geode functions that were
+// running, innermost first. They are raised by panic(msg) and by the
+// checks the compiler inserts, like the bounds checks of --bounds-checks.
+// The names of the functions are read from the symbols of the binary, which
+// unix targets export with -rdynamic.
+
+// the status a panic exits with, which is different from exit(1) so the
+// two can be told apart
+#define PANIC_STATUS 2
+
+// the deepest a stack trace goes
+#define PANIC_MAX_FRAMES 64
+
+// demangle writes the geode name of a mangled function, like main:add for
+// _XN4mainN3addTi32Ti64Ri64, see pkg/mangle. It returns 0 if the symbol
+// isn't a geode function.
+static int demangle(const char *sym, char *out, size_t size) {
+  if (strcmp(sym, "main.main") == 0) {
+    snprintf(out, size, "main:main");
+    return 1;
+  }
+  if (strncmp(sym, "_XN", 3) != 0) {
+    return 0;
+  }
+  size_t len = 0;
+  int parts = 0;
+  for (const char *p = sym + 2; *p == 'N';) {
+    char *end;
+    long n = strtol(p + 1, &end, 10);
+    if (end == p + 1 || n <= 0) {
+      return 0;
+    }
+    // names that start with a digit or _ have a _ after their length
+    if (*end == '_') {
+      end++;
+    }
+    if ((long)strlen(end) < n) {
+      return 0;
+    }
+    const char *sep = parts == 0 ? "" : parts == 1 ? ":" : ".";
+    int written = snprintf(out + len, size - len, "%s%.*s", sep, (int)n, end);
+    if (written < 0 || (size_t)written >= size - len) {
+      return 0;
+    }
+    len += written;
+    parts++;
+    p = end + n;
+  }
+  return parts > 0;
+}
+
+// panic_trace prints the geode functions on the stack. Frames of c
+// functions, like the runtime's own, are left out, and the trace ends at
+// main.
+static void panic_trace(void) {
+#ifdef PANIC_BACKTRACE
+  void *frames[PANIC_MAX_FRAMES];
+  int n = backtrace(frames, PANIC_MAX_FRAMES);
+  char name[256];
+  fputs("\nstack trace:\n", stderr);
+  for (int i = 0; i < n; i++) {
+    // the frames are return addresses, which are past the end of a
+    // function that ends in a call that never returns
+    Dl_info info;
+    void *pc = (char *)frames[i] - 1;
+    if (dladdr(pc, &info) == 0 || info.dli_sname == NULL) {
+      continue;
+    }
+    if (strcmp(info.dli_sname, "main") == 0) {
+      break;
+    }
+    if (demangle(info.dli_sname, name, sizeof(name))) {
+      fprintf(stderr, "\t%s\n", name);
+    }
+  }
+#endif
+}
+
+void __runtime_panicf(char *fmt, ...) {
+  // anything printed before the panic should show up before it
+  fflush(stdout);
+  fputs("panic: ", stderr);
+  va_list args;
+  va_start(args, fmt);
+  vfprintf(stderr, fmt, args);
+  va_end(args);
+  fputs("\n", stderr);
+  panic_trace();
+  fflush(stderr);
+  exit(PANIC_STATUS);
+}
+
+void __runtime_panic(char *msg) { __runtime_panicf("%s", msg); }
+
+void panic_bounds(long index, long len, char *file, int line) {
+  __runtime_panicf("%s:%d: index %ld out of range for length %ld", file, line,
+                   index, len);
+}
+
+void panic_nil(char *file, int line) {
+  __runtime_panicf("%s:%d: nil pointer dereference", file, line);
+}
@@ -1,28 +1,139 @@
 #include "../include/runtime.h"
 
 #include "../include/xmalloc.h"
+#include <signal.h>
 #include <stdarg.h>
 #include <stdio.h>
 #include <stdlib.h>
+#include <string.h>
+#include <unistd.h>
 
-void exit_handle(void) { GC_gcollect(); }
+// stdout is fully buffered unless it is a terminal, in which case it is
+// line buffered so interactive output still shows up as it is written.
+// stderr is always line buffered.
+#define RUNTIME_BUFFER_SIZE (64 * 1024)
+static char stdout_buffer[RUNTIME_BUFFER_SIZE];
+static char stderr_buffer[BUFSIZ];
+
+// signals that end the program without running the exit handlers
+static int panic_signals[] = {SIGSEGV, SIGBUS, SIGFPE, SIGILL, SIGABRT};
+
+void exit_handle(void) {
+  fflush(NULL);
+  GC_gcollect();
+}
+
+// flush whatever output is buffered before the program dies, then let the
+// signal kill it as it normally would
+void panic_handle(int sig) {
+  fflush(NULL);
+  signal(sig, SIG_DFL);
+  raise(sig);
+}
 
 void __init_c_runtime() {
   atexit(exit_handle);
   GC_init();
   // GC_enable_incremental();
+
+  setvbuf(stdout, stdout_buffer, isatty(1) ? _IOLBF : _IOFBF,
+          RUNTIME_BUFFER_SIZE);
+  setvbuf(stderr, stderr_buffer, _IOLBF, BUFSIZ);
+
+  for (unsigned i = 0; i < sizeof(panic_signals) / sizeof(int); i++) {
+    signal(panic_signals[i], panic_handle);
+  }
+}
+
+// the arguments the program was run with, set by the compiler generated main
+static int runtime_argc = 0;
+static char **runtime_argv = NULL;
+
+void __runtime_set_args(int argc, char **argv) {
+  runtime_argc = argc;
+  runtime_argv = argv;
+}
+
+int __runtime_argc() { return runtime_argc; }
+
+char **__runtime_argv() { return runtime_argv; }
+
+// return the stdio stream that buffers writes to a file descriptor
+static FILE *buffered_stream(int fd) {
+  switch (fd) {
+  case 1:
+    return stdout;
+  case 2:
+    return stderr;
+  }
+  return NULL;
+}
+
+long bwrite(int fd, char *buf, long nbytes) {
+  FILE *stream = buffered_stream(fd);
+  if (stream == NULL) {
+    return write(fd, buf, nbytes);
+  }
+  return fwrite(buf, 1, nbytes, stream);
+}
+
+int bflush(int fd) {
+  if (fd < 0) {
+    return fflush(NULL);
+  }
+  FILE *stream = buffered_stream(fd);
+  if (stream == NULL) {
+    return 0;
+  }
+  return fflush(stream);
 }
 
 void fatalf(int err, char *fmt, ...) {
+  // anything printed before the error should show up before it
+  fflush(stdout);
   fputs("Error: ", stderr);
   va_list vargs;
   va_start(vargs, fmt);
   vfprintf(stderr, fmt, vargs);
-  printf("\n");
+  fputs("\n", stderr);
   va_end(vargs);
   exit(err);
 }
 
+void __check_spread(long needed, long given, int exact) {
+  if (given < needed || (exact && given != needed)) {
+    fatalf(1, "unable to spread %ld values into %ld arguments", given, needed);
+  }
+}
+
+// the type info of a type, see TypeInfo in runtime.g. its name is a geode
+// string, a length and a pointer to its bytes
+struct type_info {
+  int size;
+  struct {
+    long len;
+    char *data;
+  } name;
+};
+
+// vtables of classes that extend or are extended start with the vtable of
+// the class they extend and their type info. obj is an instance of such a
+// class, which points to its vtable, and it can be cast to the class with
+// the vtable target if it is one or extends it
+void __runtime_check_cast(void ***obj, void **target) {
+  if (obj == NULL) {
+    return;
+  }
+  for (void **vtable = *obj; vtable != NULL; vtable = vtable[0]) {
+    if (vtable == target) {
+      return;
+    }
+  }
+  struct type_info *from = (*obj)[1];
+  struct type_info *to = target[1];
+  fatalf(1, "invalid cast, a %s is not a %s", from->name.data, to->name.data);
+}
+
 char *__runtime_str_format(char *fmt, ...) {
   va_list checkArgs;
   va_start(checkArgs, fmt);
@@ -36,4 +147,135 @@ char *__runtime_str_format(char *fmt, ...) {
   vsnprintf(buffer, size + 1, fmt, args);
   va_end(args);
   return buffer;
-}
\ No newline at end of file
+}
+
+void __runtime_print_format(char *fmt, ...) {
+  va_list args;
+  va_start(args, fmt);
+  vprintf(fmt, args);
+  va_end(args);
+}
+
+// __runtime_cpu_supports returns if the machine running the program has a
+// cpu feature. __builtin_cpu_supports only takes literals, so every feature
+// cpu_supports accepts is listed here. Other architectures support none.
+int __runtime_cpu_supports(char *feature) {
+#if defined(__x86_64__) || defined(__i386__)
+#define CPU_FEATURE(name)                                                      \
+  if (strcmp(feature, name) == 0)                                              \
+    return __builtin_cpu_supports(name) != 0;
+  __builtin_cpu_init();
+  CPU_FEATURE("aes")
+  CPU_FEATURE("avx")
+  CPU_FEATURE("avx2")
+  CPU_FEATURE("avx512f")
+  CPU_FEATURE("bmi")
+  CPU_FEATURE("bmi2")
+  CPU_FEATURE("fma")
+  CPU_FEATURE("pclmul")
+  CPU_FEATURE("popcnt")
+  CPU_FEATURE("sse3")
+  CPU_FEATURE("sse4.1")
+  CPU_FEATURE("sse4.2")
+  CPU_FEATURE("ssse3")
+#undef CPU_FEATURE
+#endif
+  return 0;
+}
+
+// utf8_decode decodes the utf-8 sequence at the start of s, storing its
+// length in width. Invalid sequences, including overlong encodings and
+// surrogates, decode as U+FFFD with a width of 1 so decoding can resume
+// at the next byte.
+static int utf8_decode(const unsigned char *s, long *width) {
+  unsigned char c = s[0];
+  int need, rune, min;
+  if (c < 0x80) {
+    *width = 1;
+    return c;
+  } else if ((c & 0xE0) == 0xC0) {
+    need = 1, rune = c & 0x1F, min = 0x80;
+  } else if ((c & 0xF0) == 0xE0) {
+    need = 2, rune = c & 0x0F, min = 0x800;
+  } else if ((c & 0xF8) == 0xF0) {
+    need = 3, rune = c & 0x07, min = 0x10000;
+  } else {
+    *width = 1;
+    return 0xFFFD;
+  }
+  for (int i = 1; i <= need; i++) {
+    if ((s[i] & 0xC0) != 0x80) {
+      *width = 1;
+      return 0xFFFD;
+    }
+    rune = (rune << 6) | (s[i] & 0x3F);
+  }
+  if (rune < min || rune > 0x10FFFF || (rune >= 0xD800 && rune <= 0xDFFF)) {
+    *width = 1;
+    return 0xFFFD;
+  }
+  *width = need + 1;
+  return rune;
+}
+
+// the rune starting at byte pos of s
+int __runtime_utf8_decode(char *s, long pos) {
+  long width;
+  return utf8_decode((unsigned char *)s + pos, &width);
+}
+
+// the byte offset of the rune after the one starting at byte pos of s
+long __runtime_utf8_next(char *s, long pos) {
+  long width;
+  utf8_decode((unsigned char *)s + pos, &width);
+  return pos + width;
+}
+
+long __runtime_utf8_len(char *s) {
+  long count = 0;
+  for (long pos = 0; s[pos] != 0; pos = __runtime_utf8_next(s, pos)) {
+    count++;
+  }
+  return count;
+}
+
+int __runtime_utf8_valid(char *s) {
+  long width;
+  for (long pos = 0; s[pos] != 0; pos += width) {
+    if (utf8_decode((unsigned char *)s + pos, &width) == 0xFFFD) {
+      // U+FFFD itself is valid when it is spelled out in full
+      if (width != 3) {
+        return 0;
+      }
+    }
+  }
+  return 1;
+}
+
+// encode a rune as a utf-8 string. invalid runes encode as U+FFFD
+char *__runtime_utf8_encode(int rune) {
+  if (rune < 0 || rune > 0x10FFFF || (rune >= 0xD800 && rune <= 0xDFFF)) {
+    rune = 0xFFFD;
+  }
+  char *s = xmalloc(5);
+  if (rune < 0x80) {
+    s[0] = rune;
+    s[1] = 0;
+  } else if (rune < 0x800) {
+    s[0] = 0xC0 | (rune >> 6);
+    s[1] = 0x80 | (rune & 0x3F);
+    s[2] = 0;
+  } else if (rune < 0x10000) {
+    s[0] = 0xE0 | (rune >> 12);
+    s[1] = 0x80 | ((rune >> 6) & 0x3F);
+    s[2] = 0x80 | (rune & 0x3F);
+    s[3] = 0;
+  } else {
+    s[0] = 0xF0 | (rune >> 18);
+    s[1] = 0x80 | ((rune >> 12) & 0x3F);
+    s[2] = 0x80 | ((rune >> 6) & 0x3F);
+    s[3] = 0x80 | (rune & 0x3F);
+    s[4] = 0;
+  }
+  return s;
+}
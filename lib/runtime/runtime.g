@@ -4,12 +4,30 @@ include "c"
 
 link "runtime.c"
 link "xmalloc.c"
+link "debugalloc.c"
+link "coverage.c"
+link "arc.c"
+link "panic.c"
+link "thread.c"
+link "chan.c"
+link "error.c"
 
 # safer, gc friendly memory functions.
 func xmalloc(int size) byte* ...
 func xrealloc(byte* ptr, int size) byte* ...
+# a zeroed block that never holds a pointer, which the gc doesn't scan
+func xmalloc_atomic(int size) byte* ...
+# a block whose address is a multiple of align, a power of two
+func xmalloc_aligned(long size, long align) byte* ...
 func memcpy(byte* dest, byte* src, int length) ...
 func xmalloc_size(byte* ptr) long ...
+func xfree(byte* ptr) ...
+# select the allocator every xmalloc goes through, "gc" or "debug".
+# returns 0 if there is no allocator with that name
+func __runtime_use_allocator(string name) int ...
+# write the counters of a program built with --coverage out at exit, with
+# the file and line of source each of them counts
+func __runtime_coverage(long** counters, byte** files, int* lines, long n) ...
 func __init_c_runtime() ...
 func exit(int status) ...
 func kill(int pid, int status) ...
@@ -20,25 +38,230 @@ func read(int fd, byte* buf, long nbytes) long ...
 
 # binding to the write syscall
 func write(int fd, byte* buf, long nbytes) long ...
-func write'(int fd, byte* msg) long {
-	len = 0
-	while msg[len] != 0 { len += 1 }
-	return write(1, msg, len)
-}
 
+# buffered writes. stdout (1) and stderr (2) are buffered by the runtime
+# and flushed at exit, other file descriptors are written to directly
+func bwrite(int fd, byte* buf, long nbytes) long ...
+# flush the buffered output of a file descriptor, or of all of them if fd is -1
+func bflush(int fd) int ...
+
+func write'(int fd, string msg) long = bwrite(fd, msg.data, msg.len)
 
-func log(byte* msg) long = write'(1, "%s\n"(msg))
-func werr(byte* msg) int = write'(2, msg)
+
+func log(string msg) long = write'(1, "%s\n"(msg))
+func werr(string msg) int = write'(2, msg)
 
 
 func raw_copy(byte* source, int len) byte* {
 	dest = xmalloc(len);
-	memcpy(dest, source, len);
+	intrinsics:memcpy(dest, source, len);
 	return dest;
 }
 
 func __runtime_str_format(string format, ...) string ...
+# prints a format string the compiler has already checked the arguments of
+func __runtime_print_format(string format, ...) ...
+
+# checks that a slice spread into a call has enough values for the
+# arguments it fills. exact is set when the callee is not variadic
+func __check_spread(long needed, long given, int exact) ...
+
+# ends the program with a message and a stack trace, see panic.c. The
+# panic builtin calls it
+func __runtime_panic(string msg) ...
+
+# panics when an index into a slice is out of range, built with
+# --bounds-checks. file and line are where it was indexed
+func panic_bounds(long index, long len, string file, int line) ...
+
+# panics when a nil pointer is dereferenced in a debug build
+func panic_nil(string file, int line) ...
+
+# the reference counts of programs built with --arc. __arc_alloc allocates
+# an instance of a class for new, and drop releases the fields of one
+func __arc_alloc(long size, byte* drop) byte* ...
+func __arc_retain(byte* obj) ...
+func __arc_release(byte* obj) ...
+# gives up a reference without freeing the object, to return it
+func __arc_disown(byte* obj) ...
+
+# throw and catch, see error.c. __runtime_throw raises an error, and the
+# landing pad of a try gets it back from the exception it caught with
+# __runtime_catch. The unwinder calls __runtime_personality to find the
+# landing pads
+func __runtime_throw(Error* err) ...
+func __runtime_catch(byte* exception, Error* err) ...
+func __runtime_personality(int version, int actions, long exceptionClass, byte* exception, byte* ctx) int ...
+
+# checks that a pointer cast with as! points to an instance of the class
+# with the vtable target, or of a class that extends it
+func __runtime_check_cast(byte* obj, byte* target) ...
+
+
+# Thread is a thread started with spawn, which runs a function call on a
+# new thread, as in `Thread* t = spawn work(n)`. join waits for it to end
+# and detach lets it end on its own, and a thread can only be joined or
+# detached once. The threads still running end when main returns. See
+# thread.c
+class Thread {
+	func join {
+		__runtime_thread_join(this);
+	}
+
+	func detach {
+		__runtime_thread_detach(this);
+	}
+}
+
+# run calls the spawned function with the arguments packed into env, see
+# pkg/ast/Spawn.go
+func __runtime_spawn(byte* run, byte* env) Thread* ...
+func __runtime_thread_join(Thread* t) ...
+func __runtime_thread_detach(Thread* t) ...
+
+# the globals are initialized once, even if threads race to do it. begin
+# returns 1 if the caller is to initialize them and then call end
+func __runtime_once_begin(int* state) int ...
+func __runtime_once_end(int* state) ...
+
+# the channels of chan<T>, see chan.c. size is the size of a value and recv
+# returns 0 once the chan is closed and empty
+func __runtime_chan_new(long size, long cap) byte* ...
+func __runtime_chan_send(byte* c, byte* val) ...
+func __runtime_chan_recv(byte* c, byte* val) int ...
+func __runtime_chan_close(byte* c) ...
+
+# if the machine running the program has a cpu feature, see cpu_supports
+func __runtime_cpu_supports(string feature) int ...
+
+
+# the arguments the program was run with. they are handed to the runtime
+# by the c main function the compiler generates, see the args package
+func __runtime_set_args(int argc, byte** argv) ...
+func __runtime_argc() int ...
+func __runtime_argv() byte** ...
+
+# the arguments as strings, which main(string[] args) is called with
+func __runtime_args string[] {
+	int n = __runtime_argc();
+	byte** argv = __runtime_argv();
+	string[] args;
+	args.data = xmalloc(n * sizeof(string) as int);
+	args.len = n;
+	for int i = 0; i < n; i += 1 {
+		args[i] = argv[i] as string;
+	}
+	return args;
+}
+
+# utf-8 decoding. positions are byte offsets into the string, and invalid
+# sequences decode as U+FFFD one byte at a time
+func __runtime_utf8_decode(string s, long pos) rune ...
+func __runtime_utf8_next(string s, long pos) long ...
+func __runtime_utf8_len(string s) long ...
+func __runtime_utf8_valid(string s) int ...
+func __runtime_utf8_encode(rune r) string ...
+
+# strings are a length and a pointer to their bytes, see pkg/ast/String.go.
+# the compiler calls these to make and compare them, and the bytes of the
+# strings they make are followed by a 0 so they can be passed to c
+
+# the string of a c string, which is what c functions return strings as.
+# it is empty if p is nil
+func __runtime_cstring(byte* p) string {
+	string s;
+	if p == nil {
+		return s;
+	}
+	s.data = p;
+	while p[s.len] != 0 {
+		s.len += 1;
+	}
+	return s;
+}
+
+# a + b
+func __runtime_str_concat(string a, string b) string {
+	string s;
+	s.len = a.len + b.len;
+	s.data = xmalloc_atomic((s.len + 1) as int);
+	intrinsics:memcpy(s.data, a.data, a.len);
+	intrinsics:memcpy(&s.data[a.len], b.data, b.len);
+	return s;
+}
+
+# a == b, if they have the same bytes
+func __runtime_str_eq(string a, string b) bool {
+	if a.len != b.len {
+		return false;
+	}
+	for long i = 0; i < a.len; i += 1 {
+		if a.data[i] != b.data[i] {
+			return false;
+		}
+	}
+	return true;
+}
+
+# the bytes of s from start up to, but not including, end, as a new string.
+# panics if they aren't in s
+func __runtime_str_slice(string s, long start, long end) string {
+	if start < 0 || end < start || end > s.len {
+		panic(format("slice [%d:%d] out of range for length %d", start, end, s.len));
+	}
+	string sub;
+	sub.len = end - start;
+	sub.data = xmalloc_atomic((sub.len + 1) as int);
+	intrinsics:memcpy(sub.data, &s.data[start], sub.len);
+	return sub;
+}
+
+# Runes iterates over the runes of a string. It is what a for-each loop
+# over a string uses, as in `for rune r in "héllo" { ... }`
+class Runes {
+	string str
+	long pos
 
+	func done bool {
+		return this.pos >= this.str.len;
+	}
+
+	func next rune {
+		rune r = __runtime_utf8_decode(this.str, this.pos);
+		this.pos = __runtime_utf8_next(this.str, this.pos);
+		return r;
+	}
+}
+
+func __runtime_runes(string s) Runes {
+	Runes it;
+	it.str = s;
+	it.pos = 0;
+	return it;
+}
+
+# IntRange iterates over the integers from start up to, but not including,
+# end. It is the value of a range like `0..10`.
+class IntRange {
+	long cur
+	long end
+
+	func done bool {
+		return this.cur >= this.end;
+	}
+
+	func next long {
+		this.cur += 1;
+		return this.cur - 1;
+	}
+}
+
+func __runtime_range(long start, long end) IntRange {
+	IntRange r;
+	r.cur = start;
+	r.end = end;
+	return r;
+}
 
 func __init_runtime() {
 	# this function doesn't do anything right now, but it does
@@ -57,6 +280,94 @@ class TypeInfo {
 	string name
 }
 
+# vec<T> is the builtin growable array of T. push reallocates data to twice
+# its capacity when it is full. Indexing a vec and iterating over it with a
+# for-each loop work like they do on a slice, see pkg/ast/Vec.go
+class vec<T> {
+	long len
+	long cap
+	T* data
+
+	# add val to the end
+	func push(T val) {
+		if this.len == this.cap {
+			this.cap = this.cap * 2;
+			if this.cap == 0 {
+				this.cap = 4;
+			}
+			this.data = xrealloc(this.data as byte*, (this.cap * sizeof(T)) as int) as T*;
+		}
+		this.data[this.len] = val;
+		this.len += 1;
+	}
+
+	# remove the last element and return it. panics if there isn't one
+	func pop T {
+		if this.len == 0 {
+			panic("pop from an empty vec");
+		}
+		this.len -= 1;
+		return this.data[this.len];
+	}
+}
+
+# chan<T> sends values of T between threads, as in
+#
+#	chan<int> results
+#	results.open(0)
+#	spawn work(results)
+#	int n = <-results
+#
+# `ch <- val` sends a value, waiting while the chan is full, and `<-ch`
+# receives one, waiting until one is sent. A chan has to be opened before
+# it is used, and copies of it are the same chan. See pkg/ast/Chan.go
+class chan<T> {
+	byte* c
+
+	# make the chan, with room for cap values that haven't been received.
+	# with no room, a send waits until its value is received
+	func open(long cap) {
+		this.c = __runtime_chan_new(sizeof(T), cap);
+	}
+
+	func send(T val) {
+		__runtime_chan_send(this.c, &val as byte*);
+	}
+
+	# the values sent before the chan was closed are still received, and
+	# then recv returns the zero T
+	func recv T {
+		T val;
+		__runtime_chan_recv(this.c, &val as byte*);
+		return val;
+	}
+
+	# receive a value into val, or return false once the chan is closed and
+	# has no values left
+	func recv_ok(T* val) bool {
+		return __runtime_chan_recv(this.c, val as byte*) != 0;
+	}
+
+	# no more values can be sent. a chan can only be closed once
+	func close {
+		__runtime_chan_close(this.c);
+	}
+}
+
+# Error is what throw raises and catch catches, as in
+# `try { ... } catch (e Error) { ... }`. throw "msg" raises an Error with
+# that message.
+class Error {
+	string message
+}
+
+# Result is what a function that can fail returns. error is only set
+# when it failed, and `res?` returns it from the calling function.
+class Result<T> {
+	T value
+	string error
+}
+
 
 
 
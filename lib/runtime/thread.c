@@ -0,0 +1,102 @@
+// threads are registered with the garbage collector, which has to stop
+// them and scan their stacks. with GC_THREADS, gc.h makes pthread_create
+// the collector's own
+#define GC_THREADS
+#include <gc/gc.h>
+
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+
+#include "../include/xmalloc.h"
+
+void fatalf(int err, char *fmt, ...);
+
+// A thread started by spawn, see pkg/ast/Spawn.go. The compiler generates
+// a function for each spawn that calls the spawned function with the
+// arguments packed into env, which were evaluated before the thread
+// started. The thread is allocated by the collector and holds on to env,
+// so the arguments are kept alive as long as the thread is.
+struct thread {
+  pthread_t id;
+  void (*run)(void *);
+  void *env;
+  // set once the thread is joined or detached, which can only happen once
+  int released;
+};
+
+static void *thread_start(void *arg) {
+  struct thread *t = arg;
+  t->run(t->env);
+  return NULL;
+}
+
+struct thread *__runtime_spawn(void (*run)(void *), void *env) {
+  struct thread *t = xmalloc(sizeof(struct thread));
+  t->run = run;
+  t->env = env;
+  t->released = 0;
+  int err = pthread_create(&t->id, NULL, thread_start, t);
+  if (err != 0) {
+    fatalf(1, "unable to spawn a thread: %s", strerror(err));
+  }
+  return t;
+}
+
+static void thread_release(struct thread *t, const char *how) {
+  if (t->released) {
+    fatalf(1, "unable to %s a thread that was already joined or detached",
+           how);
+  }
+  t->released = 1;
+}
+
+// wait for a thread to end
+void __runtime_thread_join(struct thread *t) {
+  thread_release(t, "join");
+  int err = pthread_join(t->id, NULL);
+  if (err != 0) {
+    fatalf(1, "unable to join a thread: %s", strerror(err));
+  }
+}
+
+// let a thread end on its own. it still ends with the program
+void __runtime_thread_detach(struct thread *t) {
+  thread_release(t, "detach");
+  pthread_detach(t->id);
+}
+
+// The states of a once, which the compiler zeroes. The globals of a
+// program, or of a library, are initialized in a once, as c code can call
+// into a library from more than one thread at a time.
+enum { ONCE_WAITING, ONCE_RUNNING, ONCE_DONE };
+
+static pthread_mutex_t once_mutex = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t once_cond = PTHREAD_COND_INITIALIZER;
+
+// returns 1 if the caller is to run the once, then call __runtime_once_end.
+// returns 0 if it has been run, waiting for it if another thread is still
+// running it
+int __runtime_once_begin(int *state) {
+  if (__atomic_load_n(state, __ATOMIC_ACQUIRE) == ONCE_DONE) {
+    return 0;
+  }
+  pthread_mutex_lock(&once_mutex);
+  while (*state == ONCE_RUNNING) {
+    pthread_cond_wait(&once_cond, &once_mutex);
+  }
+  int run = *state == ONCE_WAITING;
+  if (run) {
+    *state = ONCE_RUNNING;
+  }
+  pthread_mutex_unlock(&once_mutex);
+  return run;
+}
+
+void __runtime_once_end(int *state) {
+  pthread_mutex_lock(&once_mutex);
+  __atomic_store_n(state, ONCE_DONE, __ATOMIC_RELEASE);
+  pthread_cond_broadcast(&once_cond);
+  pthread_mutex_unlock(&once_mutex);
+}
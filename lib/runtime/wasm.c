@@ -0,0 +1,818 @@
+// The runtime for wasm32-unknown-unknown, which has no c library or system
+// to build the rest of the runtime on. It is linked in place of the c
+// sources of the runtime and the packages it includes, io and mem, and
+// implements the functions they bind with nothing but what the host imports
+// into the module:
+//
+//   geode.write(fd i32, buf i32, len i32) i32   write bytes to a stream
+//   geode.read(fd i32, buf i32, len i32) i32    read bytes from a stream
+//   geode.exit(status i32)                      stop the program
+//
+// The host calls the exported main with no arguments. Memory is never
+// freed, as there is no garbage collector.
+
+#include <stdarg.h>
+#include <stddef.h>
+#include <stdint.h>
+
+#define WASM_IMPORT(name)                                                      \
+  __attribute__((import_module("geode"), import_name(name)))
+
+WASM_IMPORT("write") int host_write(int fd, const char *buf, int len);
+WASM_IMPORT("read") int host_read(int fd, char *buf, int len);
+WASM_IMPORT("exit") __attribute__((noreturn)) void host_exit(int status);
+
+// memory
+
+#define WASM_PAGE_SIZE 65536
+
+// the start of the memory not used by the data and stack of the module,
+// set by the linker
+extern unsigned char __heap_base;
+
+static uintptr_t heap_next = 0;
+
+// what has been allocated, for mem:bytes_used and mem:blocks_used
+static int64_t memory_used = 0;
+static int64_t blocks_allocated = 0;
+
+// blocks start with their size, so they can be reallocated
+typedef struct {
+  int64_t size;
+  int64_t align;
+} block_t;
+
+void *memcpy(void *dest, const void *src, size_t n) {
+  unsigned char *d = dest;
+  const unsigned char *s = src;
+  while (n--) {
+    *d++ = *s++;
+  }
+  return dest;
+}
+
+void *memmove(void *dest, const void *src, size_t n) {
+  unsigned char *d = dest;
+  const unsigned char *s = src;
+  if (d < s) {
+    return memcpy(dest, src, n);
+  }
+  while (n--) {
+    d[n] = s[n];
+  }
+  return dest;
+}
+
+void *memset(void *dest, int c, size_t n) {
+  unsigned char *d = dest;
+  while (n--) {
+    *d++ = c;
+  }
+  return dest;
+}
+
+// bump allocates size bytes aligned to align, growing memory as needed
+static void *heap_alloc(size_t size, size_t align) {
+  if (heap_next == 0) {
+    heap_next = (uintptr_t)&__heap_base;
+  }
+  uintptr_t start = (heap_next + align - 1) & ~(uintptr_t)(align - 1);
+  uintptr_t end = start + size;
+  uintptr_t limit = __builtin_wasm_memory_size(0) * WASM_PAGE_SIZE;
+  if (end > limit) {
+    size_t pages = (end - limit + WASM_PAGE_SIZE - 1) / WASM_PAGE_SIZE;
+    if (__builtin_wasm_memory_grow(0, pages) == (size_t)-1) {
+      host_write(2, "Error: out of memory\n", 21);
+      host_exit(1);
+    }
+  }
+  heap_next = end;
+  return (void *)start;
+}
+
+void *xmalloc_aligned(int64_t size, int64_t align) {
+  if (align < (int64_t)sizeof(block_t)) {
+    align = sizeof(block_t);
+  }
+  // the header sits right before the block, in the padding the alignment
+  // leaves before it
+  char *start = heap_alloc(align + size, align);
+  block_t *b = (block_t *)(start + align) - 1;
+  b->size = size;
+  b->align = align;
+  memset(start + align, 0, size);
+  memory_used += size;
+  blocks_allocated++;
+  return start + align;
+}
+
+void *xmalloc(int size) { return xmalloc_aligned(size, sizeof(block_t)); }
+
+// nothing is collected, so no block is scanned for pointers
+void *xmalloc_atomic(int size) { return xmalloc(size); }
+
+// nothing is freed either, so the references of --arc aren't counted
+void *__arc_alloc(int64_t size, void *drop) { return xmalloc(size); }
+void __arc_retain(void *obj) {}
+void __arc_release(void *obj) {}
+void __arc_disown(void *obj) {}
+int64_t arc_objects() { return 0; }
+
+int64_t xmalloc_size(void *ptr) {
+  if (ptr == NULL) {
+    return 0;
+  }
+  return ((block_t *)ptr - 1)->size;
+}
+
+void *xrealloc(void *ptr, int size) {
+  if (ptr == NULL) {
+    return xmalloc(size);
+  }
+  block_t *b = (block_t *)ptr - 1;
+  if (size <= b->size) {
+    b->size = size;
+    return ptr;
+  }
+  void *grown = xmalloc_aligned(size, b->align);
+  memcpy(grown, ptr, b->size);
+  return grown;
+}
+
+void xfree(void *ptr) {}
+
+// there is only the one allocator
+int __runtime_use_allocator(char *name) { return 0; }
+
+// the statistics mem.g binds, from xmalloc.c and the garbage collector
+
+int64_t bytes_used() { return memory_used; }
+int64_t blocks_used() { return blocks_allocated; }
+int64_t heap_size() {
+  return heap_next == 0 ? 0 : heap_next - (uintptr_t)&__heap_base;
+}
+void GC_gcollect() {}
+
+// mem_is_aligned from mem.c
+int mem_is_aligned(void *ptr, int64_t align) {
+  return ((uintptr_t)ptr & (align - 1)) == 0;
+}
+
+// streams
+
+int64_t write(int fd, char *buf, int64_t nbytes) {
+  return host_write(fd, buf, nbytes);
+}
+
+int64_t read(int fd, char *buf, int64_t nbytes) {
+  return host_read(fd, buf, nbytes);
+}
+
+// the host buffers output if it wants to, so writes go straight to it
+int64_t bwrite(int fd, char *buf, int64_t nbytes) {
+  return host_write(fd, buf, nbytes);
+}
+
+int bflush(int fd) { return 0; }
+
+void exit(int status) { host_exit(status); }
+
+// there are no other processes, so a program can only signal itself, which
+// ends it
+void kill(int pid, int status) { host_exit(128 + status); }
+
+void __init_c_runtime() {}
+
+// arguments
+
+static int runtime_argc = 0;
+static char **runtime_argv = NULL;
+
+void __runtime_set_args(int argc, char **argv) {
+  runtime_argc = argc;
+  runtime_argv = argv;
+}
+
+int __runtime_argc() { return runtime_argc; }
+
+char **__runtime_argv() { return runtime_argv; }
+
+// formatting
+
+// buffer_t is a string being formatted into, grown as it is written
+typedef struct {
+  char *data;
+  int len;
+  int cap;
+} buffer_t;
+
+static void buffer_write(buffer_t *b, const char *s, int n) {
+  if (b->len + n + 1 > b->cap) {
+    int cap = b->cap * 2 + n + 1;
+    char *data = xmalloc(cap);
+    memcpy(data, b->data, b->len);
+    b->data = data;
+    b->cap = cap;
+  }
+  memcpy(b->data + b->len, s, n);
+  b->len += n;
+  b->data[b->len] = 0;
+}
+
+static void buffer_pad(buffer_t *b, char c, int n) {
+  for (int i = 0; i < n; i++) {
+    buffer_write(b, &c, 1);
+  }
+}
+
+static int cstrlen(const char *s) {
+  int n = 0;
+  while (s[n] != 0) {
+    n++;
+  }
+  return n;
+}
+
+// the flags, width and precision of a verb
+typedef struct {
+  int left, plus, space, alt, zero;
+  int width;
+  int precision;
+} spec_t;
+
+// write the digits of a number with its sign and prefix, padded to the
+// width of the spec
+static void format_number(buffer_t *b, spec_t *s, const char *sign,
+                          const char *prefix, const char *digits, int n) {
+  int zeros = 0;
+  if (s->precision > n) {
+    zeros = s->precision - n;
+  }
+  int len = cstrlen(sign) + cstrlen(prefix) + zeros + n;
+  int pad = s->width > len ? s->width - len : 0;
+  if (s->zero && !s->left && s->precision < 0) {
+    zeros += pad;
+    pad = 0;
+  }
+  if (!s->left) {
+    buffer_pad(b, ' ', pad);
+  }
+  buffer_write(b, sign, cstrlen(sign));
+  buffer_write(b, prefix, cstrlen(prefix));
+  buffer_pad(b, '0', zeros);
+  buffer_write(b, digits, n);
+  if (s->left) {
+    buffer_pad(b, ' ', pad);
+  }
+}
+
+static int format_digits(char *out, uint64_t v, int base, int upper) {
+  const char *chars = upper ? "0123456789ABCDEF" : "0123456789abcdef";
+  char tmp[64];
+  int n = 0;
+  do {
+    tmp[n++] = chars[v % base];
+    v /= base;
+  } while (v != 0);
+  for (int i = 0; i < n; i++) {
+    out[i] = tmp[n - 1 - i];
+  }
+  return n;
+}
+
+static const char *sign_of(spec_t *s, int negative) {
+  if (negative) {
+    return "-";
+  }
+  return s->plus ? "+" : s->space ? " " : "";
+}
+
+// write the fixed point digits of a positive double with precision digits
+// after the point, returning how many were written
+static int fixed_digits(char *out, double v, int precision) {
+  double round = 0.5;
+  for (int i = 0; i < precision; i++) {
+    round /= 10;
+  }
+  v += round;
+  uint64_t whole = (uint64_t)v;
+  int n = format_digits(out, whole, 10, 0);
+  double frac = v - (double)whole;
+  if (precision > 0) {
+    out[n++] = '.';
+  }
+  for (int i = 0; i < precision; i++) {
+    frac *= 10;
+    int digit = (int)frac;
+    out[n++] = '0' + digit;
+    frac -= digit;
+  }
+  return n;
+}
+
+// write a positive double as d.ddde+xx
+static int exp_digits(char *out, double v, int precision, int upper) {
+  int exp = 0;
+  if (v != 0) {
+    while (v >= 10) {
+      v /= 10;
+      exp++;
+    }
+    while (v < 1) {
+      v *= 10;
+      exp--;
+    }
+  }
+  int n = fixed_digits(out, v, precision);
+  // rounding can carry into another digit, as in 9.99 to 10.0
+  if (n > 1 && out[1] != '.') {
+    n = fixed_digits(out, v / 10, precision);
+    exp++;
+  }
+  out[n++] = upper ? 'E' : 'e';
+  out[n++] = exp < 0 ? '-' : '+';
+  if (exp < 0) {
+    exp = -exp;
+  }
+  if (exp < 10) {
+    out[n++] = '0';
+  }
+  return n + format_digits(out + n, exp, 10, 0);
+}
+
+// drop the trailing zeros after the point of %g
+static int trim_zeros(char *out, int n) {
+  int point = -1, end = n;
+  for (int i = 0; i < n; i++) {
+    if (out[i] == '.') {
+      point = i;
+    } else if (out[i] == 'e' || out[i] == 'E') {
+      end = i;
+      break;
+    }
+  }
+  if (point < 0) {
+    return n;
+  }
+  int keep = end;
+  while (keep > point + 1 && out[keep - 1] == '0') {
+    keep--;
+  }
+  if (keep == point + 1) {
+    keep = point;
+  }
+  memmove(out + keep, out + end, n - end);
+  return keep + n - end;
+}
+
+static void format_float(buffer_t *b, spec_t *s, char verb, double v) {
+  char digits[512];
+  int n;
+  int negative = v < 0 || (v == 0 && 1 / v < 0);
+  if (negative) {
+    v = -v;
+  }
+  int upper = verb == 'F' || verb == 'E' || verb == 'G';
+  if (v != v) {
+    spec_t plain = *s;
+    plain.zero = 0, plain.precision = -1;
+    format_number(b, &plain, "", "", upper ? "NAN" : "nan", 3);
+    return;
+  }
+  if (v > 1.7976931348623157e308) {
+    spec_t plain = *s;
+    plain.zero = 0, plain.precision = -1;
+    format_number(b, &plain, sign_of(s, negative), "", upper ? "INF" : "inf",
+                  3);
+    return;
+  }
+  int precision = s->precision < 0 ? 6 : s->precision;
+  switch (verb) {
+  case 'f':
+  case 'F':
+    // doubles too big for the fixed point digits are printed as exponents
+    n = v < 1e18 ? fixed_digits(digits, v, precision)
+                 : exp_digits(digits, v, precision, upper);
+    break;
+  case 'e':
+  case 'E':
+    n = exp_digits(digits, v, precision, upper);
+    break;
+  default: {
+    if (precision == 0) {
+      precision = 1;
+    }
+    int exp = 0;
+    for (double m = v; m >= 10; m /= 10) {
+      exp++;
+    }
+    for (double m = v; m != 0 && m < 1; m *= 10) {
+      exp--;
+    }
+    if (exp < -4 || exp >= precision) {
+      n = exp_digits(digits, v, precision - 1, upper);
+    } else {
+      n = fixed_digits(digits, v, precision - 1 - exp);
+    }
+    if (!s->alt) {
+      n = trim_zeros(digits, n);
+    }
+  }
+  }
+  spec_t number = *s;
+  number.precision = -1;
+  format_number(b, &number, sign_of(s, negative), "", digits, n);
+}
+
+// vformat implements the printf verbs the compiler emits, see
+// genFormatArgs in the compiler
+static void vformat(buffer_t *b, const char *fmt, va_list args) {
+  for (const char *p = fmt; *p != 0; p++) {
+    if (*p != '%') {
+      buffer_write(b, p, 1);
+      continue;
+    }
+    p++;
+    spec_t s = {0, 0, 0, 0, 0, 0, -1};
+    for (;; p++) {
+      if (*p == '-') {
+        s.left = 1;
+      } else if (*p == '+') {
+        s.plus = 1;
+      } else if (*p == ' ') {
+        s.space = 1;
+      } else if (*p == '#') {
+        s.alt = 1;
+      } else if (*p == '0') {
+        s.zero = 1;
+      } else {
+        break;
+      }
+    }
+    for (; *p >= '0' && *p <= '9'; p++) {
+      s.width = s.width * 10 + *p - '0';
+    }
+    if (*p == '.') {
+      s.precision = 0;
+      for (p++; *p >= '0' && *p <= '9'; p++) {
+        s.precision = s.precision * 10 + *p - '0';
+      }
+    }
+    int longs = 0;
+    for (; *p == 'l' || *p == 'h' || *p == 'z'; p++) {
+      longs += *p == 'l';
+    }
+
+    char digits[64];
+    int n;
+    switch (*p) {
+    case 'd':
+    case 'i': {
+      int64_t v = longs >= 2 ? va_arg(args, long long) : va_arg(args, int);
+      uint64_t u = v < 0 ? -(uint64_t)v : (uint64_t)v;
+      n = format_digits(digits, u, 10, 0);
+      format_number(b, &s, sign_of(&s, v < 0), "", digits, n);
+      break;
+    }
+    case 'u':
+    case 'x':
+    case 'X':
+    case 'o': {
+      uint64_t v = longs >= 2 ? va_arg(args, unsigned long long)
+                              : va_arg(args, unsigned int);
+      int base = *p == 'u' ? 10 : *p == 'o' ? 8 : 16;
+      n = format_digits(digits, v, base, *p == 'X');
+      const char *prefix = "";
+      if (s.alt && v != 0) {
+        prefix = *p == 'o' ? "0" : *p == 'x' ? "0x" : *p == 'X' ? "0X" : "";
+      }
+      format_number(b, &s, "", prefix, digits, n);
+      break;
+    }
+    case 'p': {
+      uintptr_t v = (uintptr_t)va_arg(args, void *);
+      n = format_digits(digits, v, 16, 0);
+      format_number(b, &s, "", "0x", digits, n);
+      break;
+    }
+    case 'c': {
+      char c = va_arg(args, int);
+      spec_t plain = s;
+      plain.precision = -1;
+      format_number(b, &plain, "", "", &c, 1);
+      break;
+    }
+    case 's': {
+      const char *str = va_arg(args, const char *);
+      if (str == NULL) {
+        str = "(null)";
+      }
+      n = cstrlen(str);
+      if (s.precision >= 0 && s.precision < n) {
+        n = s.precision;
+      }
+      spec_t plain = s;
+      plain.zero = 0, plain.precision = -1;
+      format_number(b, &plain, "", "", str, n);
+      break;
+    }
+    case 'f':
+    case 'F':
+    case 'e':
+    case 'E':
+    case 'g':
+    case 'G':
+      format_float(b, &s, *p, va_arg(args, double));
+      break;
+    case '%':
+      buffer_write(b, "%", 1);
+      break;
+    case 0:
+      return;
+    }
+  }
+}
+
+char *__runtime_str_format(char *fmt, ...) {
+  buffer_t b = {xmalloc(64), 0, 64};
+  va_list args;
+  va_start(args, fmt);
+  vformat(&b, fmt, args);
+  va_end(args);
+  return b.data;
+}
+
+void __runtime_print_format(char *fmt, ...) {
+  buffer_t b = {xmalloc(64), 0, 64};
+  va_list args;
+  va_start(args, fmt);
+  vformat(&b, fmt, args);
+  va_end(args);
+  host_write(1, b.data, b.len);
+}
+
+// print from io.c
+void print(char *fmt, ...) {
+  buffer_t b = {xmalloc(64), 0, 64};
+  va_list args;
+  va_start(args, fmt);
+  vformat(&b, fmt, args);
+  va_end(args);
+  host_write(1, b.data, b.len);
+}
+
+// there is no clock to wait on
+void sleepms(double ms) {}
+
+// checks
+
+void fatalf(int err, char *fmt, ...) {
+  buffer_t b = {xmalloc(64), 0, 64};
+  buffer_write(&b, "Error: ", 7);
+  va_list args;
+  va_start(args, fmt);
+  vformat(&b, fmt, args);
+  va_end(args);
+  buffer_write(&b, "\n", 1);
+  host_write(2, b.data, b.len);
+  host_exit(err);
+}
+
+// there are no symbols to name the functions on the stack with, so a panic
+// only prints its message, see panic.c
+static void panicf(char *fmt, ...) {
+  buffer_t b = {xmalloc(64), 0, 64};
+  buffer_write(&b, "panic: ", 7);
+  va_list args;
+  va_start(args, fmt);
+  vformat(&b, fmt, args);
+  va_end(args);
+  buffer_write(&b, "\n", 1);
+  host_write(2, b.data, b.len);
+  host_exit(2);
+}
+
+void __runtime_panic(char *msg) { panicf("%s", msg); }
+
+void panic_bounds(int64_t index, int64_t len, char *file, int line) {
+  panicf("%s:%d: index %lld out of range for length %lld", file, line, index,
+         len);
+}
+
+void panic_nil(char *file, int line) {
+  panicf("%s:%d: nil pointer dereference", file, line);
+}
+
+// errors, see error.c. there is no unwinder, so the calls in a try are
+// plain calls and an error is never caught
+struct error {
+  struct {
+    int64_t len;
+    char *data;
+  } message;
+};
+
+void __runtime_throw(struct error *err) {
+  panicf("uncaught error: %s", err->message.data);
+}
+void __runtime_catch(void *exception, struct error *err) {}
+int __runtime_personality() { return 0; }
+
+void __check_spread(int64_t needed, int64_t given, int exact) {
+  if (given < needed || (exact && given != needed)) {
+    fatalf(1, "unable to spread %lld values into %lld arguments", given,
+           needed);
+  }
+}
+
+// the type info of a type, see TypeInfo in runtime.g. its name is a geode
+// string, a length and a pointer to its bytes
+struct type_info {
+  int size;
+  struct {
+    int64_t len;
+    char *data;
+  } name;
+};
+
+// see __runtime_check_cast in runtime.c
+void __runtime_check_cast(void ***obj, void **target) {
+  if (obj == NULL) {
+    return;
+  }
+  for (void **vtable = *obj; vtable != NULL; vtable = vtable[0]) {
+    if (vtable == target) {
+      return;
+    }
+  }
+  struct type_info *from = (*obj)[1];
+  struct type_info *to = target[1];
+  fatalf(1, "invalid cast, a %s is not a %s", from->name.data, to->name.data);
+}
+
+// threads
+
+// the module runs on the one thread the host calls it on
+void *__runtime_spawn(void *run, void *env) {
+  fatalf(1, "unable to spawn a thread, wasm has no threads");
+  return NULL;
+}
+void __runtime_thread_join(void *t) {}
+void __runtime_thread_detach(void *t) {}
+
+// so a once is run by the first call, see thread.c
+int __runtime_once_begin(int *state) {
+  if (*state != 0) {
+    return 0;
+  }
+  *state = 1;
+  return 1;
+}
+void __runtime_once_end(int *state) { *state = 2; }
+
+// chans, see chan.c. with one thread a send to a full chan or a receive
+// from an empty one would wait forever
+struct chan {
+  int64_t size, slots, head, count;
+  int closed;
+  char *buf;
+};
+
+struct chan *__runtime_chan_new(int64_t size, int64_t cap) {
+  if (cap < 0) {
+    fatalf(1, "unable to open a chan with a negative capacity %ld", (long)cap);
+  }
+  struct chan *c = xmalloc(sizeof(struct chan));
+  c->size = size;
+  c->slots = cap;
+  c->buf = xmalloc(cap * size + 1);
+  return c;
+}
+
+void __runtime_chan_send(struct chan *c, void *val) {
+  if (c == NULL || c->closed) {
+    fatalf(1, c == NULL ? "unable to send to a chan that wasn't opened"
+                        : "send to a closed chan");
+  }
+  if (c->count == c->slots) {
+    fatalf(1, "send to a full chan would wait forever, wasm has no threads");
+  }
+  memcpy(c->buf + (c->head + c->count) % c->slots * c->size, val, c->size);
+  c->count++;
+}
+
+int __runtime_chan_recv(struct chan *c, void *val) {
+  if (c == NULL) {
+    fatalf(1, "unable to receive from a chan that wasn't opened");
+  }
+  if (c->count == 0) {
+    if (!c->closed) {
+      fatalf(1, "receive from an empty chan would wait forever, wasm has no "
+                "threads");
+    }
+    memset(val, 0, c->size);
+    return 0;
+  }
+  memcpy(val, c->buf + c->head * c->size, c->size);
+  c->head = (c->head + 1) % c->slots;
+  c->count--;
+  return 1;
+}
+
+void __runtime_chan_close(struct chan *c) {
+  if (c == NULL || c->closed) {
+    fatalf(1, c == NULL ? "unable to close a chan that wasn't opened"
+                        : "close of a closed chan");
+  }
+  c->closed = 1;
+}
+
+// features can't be detected from inside the module
+int __runtime_cpu_supports(char *feature) { return 0; }
+
+// utf-8, see runtime.c
+
+static int utf8_decode(const unsigned char *s, int64_t *width) {
+  unsigned char c = s[0];
+  int need, rune, min;
+  if (c < 0x80) {
+    *width = 1;
+    return c;
+  } else if ((c & 0xE0) == 0xC0) {
+    need = 1, rune = c & 0x1F, min = 0x80;
+  } else if ((c & 0xF0) == 0xE0) {
+    need = 2, rune = c & 0x0F, min = 0x800;
+  } else if ((c & 0xF8) == 0xF0) {
+    need = 3, rune = c & 0x07, min = 0x10000;
+  } else {
+    *width = 1;
+    return 0xFFFD;
+  }
+  for (int i = 1; i <= need; i++) {
+    if ((s[i] & 0xC0) != 0x80) {
+      *width = 1;
+      return 0xFFFD;
+    }
+    rune = (rune << 6) | (s[i] & 0x3F);
+  }
+  if (rune < min || rune > 0x10FFFF || (rune >= 0xD800 && rune <= 0xDFFF)) {
+    *width = 1;
+    return 0xFFFD;
+  }
+  *width = need + 1;
+  return rune;
+}
+
+int __runtime_utf8_decode(char *s, int64_t pos) {
+  int64_t width;
+  return utf8_decode((unsigned char *)s + pos, &width);
+}
+
+int64_t __runtime_utf8_next(char *s, int64_t pos) {
+  int64_t width;
+  utf8_decode((unsigned char *)s + pos, &width);
+  return pos + width;
+}
+
+int64_t __runtime_utf8_len(char *s) {
+  int64_t count = 0;
+  for (int64_t pos = 0; s[pos] != 0; pos = __runtime_utf8_next(s, pos)) {
+    count++;
+  }
+  return count;
+}
+
+int __runtime_utf8_valid(char *s) {
+  int64_t width;
+  for (int64_t pos = 0; s[pos] != 0; pos += width) {
+    if (utf8_decode((unsigned char *)s + pos, &width) == 0xFFFD) {
+      if (width != 3) {
+        return 0;
+      }
+    }
+  }
+  return 1;
+}
+
+char *__runtime_utf8_encode(int rune) {
+  if (rune < 0 || rune > 0x10FFFF || (rune >= 0xD800 && rune <= 0xDFFF)) {
+    rune = 0xFFFD;
+  }
+  char *s = xmalloc(5);
+  if (rune < 0x80) {
+    s[0] = rune;
+  } else if (rune < 0x800) {
+    s[0] = 0xC0 | (rune >> 6);
+    s[1] = 0x80 | (rune & 0x3F);
+  } else if (rune < 0x10000) {
+    s[0] = 0xE0 | (rune >> 12);
+    s[1] = 0x80 | ((rune >> 6) & 0x3F);
+    s[2] = 0x80 | (rune & 0x3F);
+  } else {
+    s[0] = 0xF0 | (rune >> 18);
+    s[1] = 0x80 | ((rune >> 12) & 0x3F);
+    s[2] = 0x80 | ((rune >> 6) & 0x3F);
+    s[3] = 0x80 | (rune & 0x3F);
+  }
+  return s;
+}
@@ -31,6 +31,11 @@ static xmalloc_prelude_t *xmalloc_getprelude(void *ptr) {
   return (xmalloc_prelude_t *)(ptr - PRELUDE_SIZE);
 }
 
+// the start of the block the allocator handed out for a pointer
+static void *xmalloc_getreal(void *ptr) {
+  return ptr - PRELUDE_SIZE - xmalloc_getprelude(ptr)->offset;
+}
+
 long xmalloc_size(void *ptr) {
   xmalloc_lock();
   xmalloc_prelude_t *prelude = xmalloc_getprelude(ptr);
@@ -40,63 +45,159 @@ long xmalloc_size(void *ptr) {
 
 long heap_size() { return allocated_before_collect; }
 
-void xfree(void *ptr) {
+// the size counted for a block is passed to the finalizer, as the prelude
+// of an aligned block isn't at its start
+static void xfinalizer(GC_PTR obj, GC_PTR size) {
   xmalloc_lock();
+#ifdef DEBUG_XMALLOC
+  printf("[DEBUG] gc_xfree(%p) -> %ld bytes\n", obj, (long)size);
+#endif
+  allocated_before_collect -= (long)size;
+  xmalloc_unlock();
+}
+
+// count a block the collector handed out, and stop counting it once it
+// is collected
+static void *gc_track(void *ptr, size_t size) {
+  if (ptr != NULL) {
+    GC_register_finalizer(ptr, xfinalizer, (GC_PTR)(size - PRELUDE_SIZE), 0,
+                          0);
+    xmalloc_lock();
+    allocated_before_collect += size - PRELUDE_SIZE;
+    xmalloc_unlock();
+  }
+  return ptr;
+}
+
+static void *gc_alloc(size_t size) { return gc_track(GC_MALLOC(size), size); }
+
+// the collector doesn't clear atomic blocks, xmalloc_atomic does
+static void *gc_alloc_atomic(size_t size) {
+  return gc_track(GC_MALLOC_ATOMIC(size), size);
+}
+
+static void *gc_realloc(void *ptr, size_t size) {
+  return GC_REALLOC(ptr, size);
+}
+
+static void gc_free(void *ptr) { GC_FREE(ptr); }
+
+xmalloc_allocator_t xmalloc_gc_allocator = {"gc", gc_alloc, gc_realloc,
+                                            gc_free, gc_alloc_atomic};
+
+static xmalloc_allocator_t *allocator = &xmalloc_gc_allocator;
+
+// xmalloc_set_allocator replaces the allocator. It must be called before
+// anything is allocated, as blocks can't move between allocators.
+void xmalloc_set_allocator(xmalloc_allocator_t *a) { allocator = a; }
+
+xmalloc_allocator_t *xmalloc_get_allocator() { return allocator; }
+
+void xfree(void *ptr) {
   // Don't free a null pointer
   if (ptr == NULL) {
     return;
   }
-  void *new_ptr = ptr - PRELUDE_SIZE;
+  xmalloc_lock();
+  void *new_ptr = xmalloc_getreal(ptr);
   xmalloc_prelude_t *prelude = xmalloc_getprelude(ptr);
   memoryused -= prelude->size;
 
   blocksallocated--;
-  GC_FREE(new_ptr);
 #ifdef DEBUG_XMALLOC
   printf("[DEBUG] xfree(%p) -> %u bytes\n", ptr, prelude->size);
 #endif
-
   xmalloc_unlock();
+
+  allocator->free(new_ptr);
 }
 
-static void xfinalizer(GC_PTR obj, GC_PTR x) {
+// xmalloc_block gives a block of the allocator its prelude
+static void *xmalloc_block(void *realptr, size_t size) {
   xmalloc_lock();
-  xmalloc_prelude_t *prelude = (xmalloc_prelude_t *)obj;
+  if (realptr == NULL) {
+    fprintf(stderr, "Fatal: memory exhausted (xmalloc of %zu bytes).\n", size);
+    exit(EXIT_FAILURE);
+  }
+
+  memoryused += size;
+  blocksallocated++;
 #ifdef DEBUG_XMALLOC
-  printf("[DEBUG] gc_xfree(%p) -> %u bytes\n", obj, prelude->size);
+  printf("[DEBUG] xmalloc(%u) -> %p\n", size, realptr);
 #endif
-  allocated_before_collect -= prelude->size;
+
+  xmalloc_prelude_t *prelude = realptr;
+  prelude->size = size;
+  prelude->alloc_count = 1;
+  prelude->align = 0;
+  prelude->alloc_index = allocationindex;
+  prelude->offset = 0;
+
+  allocationindex++;
+
   xmalloc_unlock();
+
+  return (void *)(realptr + PRELUDE_SIZE);
 }
 
 void *xmalloc(size_t size) {
+  return xmalloc_block(allocator->alloc(size + PRELUDE_SIZE), size);
+}
 
-  void *realptr = (void *)GC_MALLOC(size + PRELUDE_SIZE);
-  GC_register_finalizer(realptr, xfinalizer, 0, 0, 0);
+// xmalloc_atomic allocates a zeroed block that will never hold a pointer,
+// like the characters of a string or an array of numbers. The collector
+// doesn't scan it, so the numbers in it never keep other blocks alive.
+void *xmalloc_atomic(size_t size) {
+  if (allocator->alloc_atomic == NULL) {
+    return xmalloc(size);
+  }
+  void *realptr = allocator->alloc_atomic(size + PRELUDE_SIZE);
+  void *ptr = xmalloc_block(realptr, size);
+  memset(ptr, 0, size);
+  return ptr;
+}
+
+// xmalloc_aligned allocates a block whose address is a multiple of align,
+// which must be a power of two. The block is padded so the prelude sits
+// right before the aligned address, and can be freed and resized like any
+// other block.
+void *xmalloc_aligned(size_t size, size_t align) {
+  if (align == 0 || (align & (align - 1)) != 0) {
+    fprintf(stderr, "Fatal: alignment %zu is not a power of two.\n", align);
+    exit(EXIT_FAILURE);
+  }
+
+  void *realptr = allocator->alloc(size + PRELUDE_SIZE + align - 1);
 
   xmalloc_lock();
-  allocated_before_collect += size;
   if (realptr == NULL) {
-    fprintf(stderr, "Fatal: memory exhausted (xmalloc of %zu bytes).\n", size);
+    fprintf(stderr,
+            "Fatal: memory exhausted (xmalloc_aligned of %zu bytes).\n",
+            size);
     exit(EXIT_FAILURE);
   }
 
   memoryused += size;
   blocksallocated++;
+
+  size_t start = (size_t)realptr + PRELUDE_SIZE;
+  void *ptr = (void *)((start + align - 1) & ~(align - 1));
 #ifdef DEBUG_XMALLOC
-  printf("[DEBUG] xmalloc(%u) -> %p\n", size, realptr);
+  printf("[DEBUG] xmalloc_aligned(%zu, %zu) -> %p\n", size, align, ptr);
 #endif
 
-  xmalloc_prelude_t *prelude = realptr;
+  xmalloc_prelude_t *prelude = xmalloc_getprelude(ptr);
   prelude->size = size;
   prelude->alloc_count = 1;
+  prelude->align = align;
   prelude->alloc_index = allocationindex;
+  prelude->offset = (void *)prelude - realptr;
 
   allocationindex++;
 
   xmalloc_unlock();
 
-  return (void *)(realptr + PRELUDE_SIZE);
+  return ptr;
 }
 
 void *xrealloc(void *ptr, size_t newsize) {
@@ -113,7 +214,16 @@ void *xrealloc(void *ptr, size_t newsize) {
   xmalloc_prelude_t *prelude = xmalloc_getprelude(ptr);
   size_t oldsize = prelude->size;
 
-  void *newptr = GC_REALLOC(real_ptr, newsize + PRELUDE_SIZE);
+  // The allocator can't keep a block aligned while resizing it, so
+  // aligned blocks are copied to a new one instead
+  if (prelude->align != 0) {
+    void *newptr = xmalloc_aligned(newsize, prelude->align);
+    memcpy(newptr, ptr, oldsize < newsize ? oldsize : newsize);
+    xfree(ptr);
+    return newptr;
+  }
+
+  void *newptr = allocator->realloc(real_ptr, newsize + PRELUDE_SIZE);
   if (newptr == NULL) {
     fprintf(stderr,
             "Fatal: Memory reallocation of %p to %zu bytes from %zu bytes "
@@ -0,0 +1,153 @@
+// GC_THREADS makes gc.h redirect pthread_create, so the collector knows
+// about the dispatcher thread that callbacks run on
+#define GC_THREADS
+#include "../include/runtime.h"
+
+#include <errno.h>
+#include <pthread.h>
+#include <signal.h>
+#include <string.h>
+#include <unistd.h>
+
+typedef void (*signal_callback)(int);
+
+#define MAX_SIGNAL 64
+
+static volatile signal_callback callbacks[MAX_SIGNAL];
+
+// signals are passed from the trampoline to the dispatcher through a pipe
+static int signal_pipe[2] = {-1, -1};
+static pthread_once_t dispatcher_once = PTHREAD_ONCE_INIT;
+static pthread_t dispatcher_thread;
+static int dispatcher_started = 0;
+
+// the number of signals the dispatcher has handled. __signal_raise waits
+// on it so a raised signal has been handled by the time raise returns
+static pthread_mutex_t dispatch_lock = PTHREAD_MUTEX_INITIALIZER;
+static pthread_cond_t dispatch_cond = PTHREAD_COND_INITIALIZER;
+static unsigned long dispatched = 0;
+
+// signal_trampoline runs in signal context, where only async-signal-safe
+// functions can be used. Geode code allocates, so it can't be run here.
+// Instead, the signal number is handed off to the dispatcher thread.
+static void signal_trampoline(int sig) {
+  int saved = errno;
+  unsigned char b = (unsigned char)sig;
+  if (write(signal_pipe[1], &b, 1) < 0) {
+    // nothing can be done about it in signal context
+  }
+  errno = saved;
+}
+
+// signal_dispatcher calls the geode callbacks of signals outside of
+// signal context, one signal at a time
+static void *signal_dispatcher(void *arg) {
+  unsigned char b;
+  for (;;) {
+    ssize_t n = read(signal_pipe[0], &b, 1);
+    if (n < 0 && errno == EINTR) {
+      continue;
+    }
+    if (n <= 0) {
+      return NULL;
+    }
+    signal_callback cb = callbacks[b];
+    if (cb != NULL) {
+      cb(b);
+    }
+    pthread_mutex_lock(&dispatch_lock);
+    dispatched++;
+    pthread_cond_broadcast(&dispatch_cond);
+    pthread_mutex_unlock(&dispatch_lock);
+  }
+}
+
+static void start_dispatcher(void) {
+  if (pipe(signal_pipe) != 0) {
+    return;
+  }
+  if (pthread_create(&dispatcher_thread, NULL, signal_dispatcher, NULL) != 0) {
+    return;
+  }
+  pthread_detach(dispatcher_thread);
+  dispatcher_started = 1;
+}
+
+static int valid_signal(int sig) { return sig > 0 && sig < MAX_SIGNAL; }
+
+int __signal_notify(int sig, void *callback) {
+  if (!valid_signal(sig) || callback == NULL) {
+    return -1;
+  }
+  pthread_once(&dispatcher_once, start_dispatcher);
+  if (!dispatcher_started) {
+    return -1;
+  }
+
+  callbacks[sig] = (signal_callback)callback;
+
+  struct sigaction sa;
+  memset(&sa, 0, sizeof(sa));
+  sa.sa_handler = signal_trampoline;
+  sigemptyset(&sa.sa_mask);
+  sa.sa_flags = SA_RESTART;
+  return sigaction(sig, &sa, NULL);
+}
+
+int __signal_ignore(int sig) {
+  if (!valid_signal(sig)) {
+    return -1;
+  }
+  callbacks[sig] = NULL;
+  return signal(sig, SIG_IGN) == SIG_ERR ? -1 : 0;
+}
+
+int __signal_reset(int sig) {
+  if (!valid_signal(sig)) {
+    return -1;
+  }
+  callbacks[sig] = NULL;
+  return signal(sig, SIG_DFL) == SIG_ERR ? -1 : 0;
+}
+
+int __signal_raise(int sig) {
+  int wait = valid_signal(sig) && callbacks[sig] != NULL &&
+             !pthread_equal(pthread_self(), dispatcher_thread);
+  if (!wait) {
+    return raise(sig);
+  }
+
+  pthread_mutex_lock(&dispatch_lock);
+  unsigned long before = dispatched;
+  pthread_mutex_unlock(&dispatch_lock);
+
+  if (raise(sig) != 0) {
+    return -1;
+  }
+
+  pthread_mutex_lock(&dispatch_lock);
+  while (dispatched == before) {
+    pthread_cond_wait(&dispatch_cond, &dispatch_lock);
+  }
+  pthread_mutex_unlock(&dispatch_lock);
+  return 0;
+}
+
+// signal numbers differ between platforms, so they are looked up by name
+int __signal_number(char *name) {
+  static const struct {
+    const char *name;
+    int sig;
+  } names[] = {
+      {"HUP", SIGHUP},   {"INT", SIGINT},     {"QUIT", SIGQUIT},
+      {"ALRM", SIGALRM}, {"TERM", SIGTERM},   {"USR1", SIGUSR1},
+      {"USR2", SIGUSR2}, {"CHLD", SIGCHLD},   {"PIPE", SIGPIPE},
+      {"CONT", SIGCONT}, {"WINCH", SIGWINCH},
+  };
+  for (unsigned i = 0; i < sizeof(names) / sizeof(names[0]); i++) {
+    if (strcmp(names[i].name, name) == 0) {
+      return names[i].sig;
+    }
+  }
+  return -1;
+}
@@ -0,0 +1,46 @@
+is signal
+
+link "signal.c"
+
+# package signal lets geode functions be called when the program receives
+# a signal. Callbacks don't run in signal context: the runtime passes the
+# signal on to a dispatcher thread which calls them, one at a time, so they
+# are free to allocate, print, exit, etc. A callback takes the signal:
+#
+#     func on_interrupt(int sig) {
+#         io:print("interrupted\n");
+#         exit(1);
+#     }
+#     signal:notify(signal:sigint, &on_interrupt);
+
+pub int sighup = __signal_number("HUP");
+pub int sigint = __signal_number("INT");
+pub int sigquit = __signal_number("QUIT");
+pub int sigalrm = __signal_number("ALRM");
+pub int sigterm = __signal_number("TERM");
+pub int sigusr1 = __signal_number("USR1");
+pub int sigusr2 = __signal_number("USR2");
+pub int sigchld = __signal_number("CHLD");
+pub int sigpipe = __signal_number("PIPE");
+pub int sigcont = __signal_number("CONT");
+pub int sigwinch = __signal_number("WINCH");
+
+func __signal_number(string name) int ...
+func __signal_notify(int sig, byte* callback) int ...
+func __signal_ignore(int sig) int ...
+func __signal_reset(int sig) int ...
+func __signal_raise(int sig) int ...
+
+# call callback whenever the program receives sig, instead of the
+# default action. returns -1 if the callback could not be installed
+pub func notify(int sig, byte* callback) int = __signal_notify(sig, callback);
+
+# ignore sig entirely
+pub func ignore(int sig) int = __signal_ignore(sig);
+
+# restore the default action of sig, removing its callback
+pub func reset(int sig) int = __signal_reset(sig);
+
+# send sig to the program. if it has a callback, raise returns once the
+# callback has finished running
+pub func raise(int sig) int = __signal_raise(sig);
@@ -2,76 +2,54 @@ is str
 
 include "mem" # needed for allocating memory for strings
 
-class String {
+pub class String {
 	byte* data;
 }
 
 
 
 # str:len
-# A basic string length function that loops over the bytes
-# incrementing a counter till it encounters a null byte
-func len(string str) int {
-	int len = 0;
-	while str[len] != 0 {
-		len += 1;
-	}
-	return len;
-}
+# The length of a string in bytes. Strings carry their
+# length, so this doesn't loop over the bytes
+pub func len(string str) int = str.len as int;
 
-# str:eq
-# The equal function goes through several tests
-# before finally returning true. If any of these
-# tests fail along the way, the function will
-# return false.
-# These tests include:
-#   - same length?
-#   - looping over testing each byte for equality
-#     across both strings
-func eq(string a, string b) bool {
-	# cache the size of both strings for later use
-	alen = len(a);
-	blen = len(b);
-
-	# if they arent the same length, they must not be equal
-	if alen != blen {
-		return false;
-	}
+# str:rune_count
+# The number of runes (unicode code points) in a utf-8
+# string, as opposed to str:len which counts bytes.
+# Invalid bytes count as a rune each.
+pub func rune_count(string str) long = __runtime_utf8_len(str);
 
-	# loop over each char and test if they are equal
-	for i = 0; i < alen; i += 1 {
-		# Check if this character isn't equal
-		if a[i] != b[i] {
-			return false;
-		}
-	}
-	return true;
-}
+# str:valid
+# Returns true if the string is valid utf-8
+pub func valid(string str) bool = __runtime_utf8_valid(str) != 0;
+
+# str:rune_at
+# Decode the rune that starts at a byte offset. Use
+# `for rune r in str` to iterate over every rune.
+pub func rune_at(string str, long pos) rune = __runtime_utf8_decode(str, pos);
+
+# str:from_rune
+# Encode a rune as a utf-8 string
+pub func from_rune(rune r) string = __runtime_utf8_encode(r);
+
+# str:eq
+# Returns true if both strings have the same bytes,
+# the same as a == b
+pub func eq(string a, string b) bool = a == b;
 
 
 
 # str:concat
-# concatinate two strings into a single string.
-func concat(string a, string b) string {
-	# cache the size of both strings for later use
-	alen = len(a);
-	blen = len(b);
-	# The final number of usable chars in the new string
-	finalLen = alen + blen;
-	# allocate finalLen+1 bytes as zero so it is zero
-	# delimited
-	string combined = mem:zero(finalLen + 1);
-	# loop over all usable bytes of the new data and
-	# copy over the old strings into the new buffer.
-	for i = 0; i < finalLen; i += 1 {
-		if i < alen {
-			combined[i] = a[i];
-		} else {
-			combined[i] = b[i - alen];
-		}
-	}
-	return combined;
-}
+# concatinate two strings into a single string, the
+# same as a + b
+pub func concat(string a, string b) string = a + b;
+
+
+# str:slice
+# The bytes of a string from start up to, but not
+# including, end as a new string. Panics if they
+# aren't in the string
+pub func slice(string str, long start, long end) string = __runtime_str_slice(str, start, end);
 
 
 # str:hash
@@ -83,7 +61,7 @@ func concat(string a, string b) string {
 # uses xor: hash(i) = hash(i - 1) * 33 ^ str[i]; the magic of number 33
 # (why it works better than many other constants, prime or not) has
 # never been adequately explained.
-func hash(string str) long {
+pub func hash(string str) long {
 	long hash = 5381;
 	size = len(str);
 
@@ -96,24 +74,45 @@ func hash(string str) long {
 
 
 
-# split str by all characters in sset and return
-# a NULL terminated string buffer
-func split(string str, string sset) string* {
-	strSize = info(string).size
-	count = 0
-	splits = mem:get(strSize * count)
-
-
-	string ptr = str;
-	while str[i] {
-		char = str[i]
-
-		# check if the char is in the subset
-		for c = 0; c < len(sset); c += 1 {
-			if 
+# in_set returns true if the byte c is one of the bytes in sset
+func in_set(byte c, string sset) bool {
+	for i = 0; i < sset.len; i += 1 {
+		if sset[i] == c {
+			return true
 		}
 	}
+	return false
+}
 
-
+# split str by all characters in sset and return
+# a buffer of the pieces, ended by an empty string
+# whose data is NULL
+pub func split(string str, string sset) string* {
+	long strSize = sizeof(string)
+	long count = 0
+	long i = 0
+	while i < str.len {
+		if !in_set(str[i], sset) && (i == 0 || in_set(str[i - 1], sset)) {
+			count += 1
+		}
+		i += 1
+	}
+	# the memory is zeroed, so the string after the last piece is empty
+	splits = mem:zero((strSize * (count + 1)) as int) as string*
+
+	long n = 0
+	i = 0
+	while i < str.len {
+		if in_set(str[i], sset) {
+			i += 1
+		} else {
+			long start = i
+			while i < str.len && !in_set(str[i], sset) {
+				i += 1
+			}
+			splits[n] = slice(str, start, i)
+			n += 1
+		}
+	}
 	return splits
-}
\ No newline at end of file
+}
@@ -0,0 +1,87 @@
+// GC_THREADS makes gc.h redirect the pthread functions, as it does in the
+// runtime's thread.c
+#define GC_THREADS
+#include "../include/runtime.h"
+
+#include <errno.h>
+#include <pthread.h>
+#include <string.h>
+
+void fatalf(int err, char *fmt, ...);
+
+// A sync:Mutex and a sync:Cond hold a pointer to their pthread mutex or
+// condition variable, which is made the first time they are used so their
+// zero values are ready to use. Threads racing to make one agree on the
+// first that is stored.
+
+static pthread_mutex_t *mutex_of(pthread_mutex_t **slot) {
+  pthread_mutex_t *m = __atomic_load_n(slot, __ATOMIC_ACQUIRE);
+  if (m != NULL) {
+    return m;
+  }
+  m = xmalloc(sizeof(pthread_mutex_t));
+  // an error checking mutex reports a mutex unlocked by a thread that
+  // didn't lock it, or locked twice by the same thread, instead of hanging
+  pthread_mutexattr_t attr;
+  pthread_mutexattr_init(&attr);
+  pthread_mutexattr_settype(&attr, PTHREAD_MUTEX_ERRORCHECK);
+  pthread_mutex_init(m, &attr);
+  pthread_mutexattr_destroy(&attr);
+  pthread_mutex_t *stored = NULL;
+  if (!__atomic_compare_exchange_n(slot, &stored, m, 0, __ATOMIC_ACQ_REL,
+                                   __ATOMIC_ACQUIRE)) {
+    pthread_mutex_destroy(m);
+    return stored;
+  }
+  return m;
+}
+
+static pthread_cond_t *cond_of(pthread_cond_t **slot) {
+  pthread_cond_t *c = __atomic_load_n(slot, __ATOMIC_ACQUIRE);
+  if (c != NULL) {
+    return c;
+  }
+  c = xmalloc(sizeof(pthread_cond_t));
+  pthread_cond_init(c, NULL);
+  pthread_cond_t *stored = NULL;
+  if (!__atomic_compare_exchange_n(slot, &stored, c, 0, __ATOMIC_ACQ_REL,
+                                   __ATOMIC_ACQUIRE)) {
+    pthread_cond_destroy(c);
+    return stored;
+  }
+  return c;
+}
+
+void __mutex_lock(pthread_mutex_t **slot) {
+  int err = pthread_mutex_lock(mutex_of(slot));
+  if (err == EDEADLK) {
+    fatalf(1, "lock of a mutex the thread already holds");
+  }
+}
+
+int __mutex_try_lock(pthread_mutex_t **slot) {
+  return pthread_mutex_trylock(mutex_of(slot)) == 0;
+}
+
+void __mutex_unlock(pthread_mutex_t **slot) {
+  pthread_mutex_t *m = __atomic_load_n(slot, __ATOMIC_ACQUIRE);
+  if (m == NULL || pthread_mutex_unlock(m) != 0) {
+    fatalf(1, "unlock of a mutex the thread doesn't hold");
+  }
+}
+
+// unlock m and wait to be signalled, locking m again before returning
+void __cond_wait(pthread_cond_t **slot, pthread_mutex_t **m) {
+  pthread_mutex_t *mutex = __atomic_load_n(m, __ATOMIC_ACQUIRE);
+  if (mutex == NULL || pthread_cond_wait(cond_of(slot), mutex) != 0) {
+    fatalf(1, "wait on a cond with a mutex the thread doesn't hold");
+  }
+}
+
+void __cond_signal(pthread_cond_t **slot) {
+  pthread_cond_signal(cond_of(slot));
+}
+
+void __cond_broadcast(pthread_cond_t **slot) {
+  pthread_cond_broadcast(cond_of(slot));
+}
@@ -0,0 +1,79 @@
+is sync
+
+link "sync.c"
+
+# package sync has the locks threads started with spawn share memory with.
+# The zero Mutex is unlocked and the zero Cond has no waiters, so they are
+# ready to use once declared, but they must not be copied once used. Share
+# them through a pointer, or as fields of an instance that is:
+#
+#     class Counter {
+#         sync:Mutex mu
+#         int n
+#     }
+#
+#     func bump(Counter* c) {
+#         with c.mu {
+#             c.n += 1;
+#         }
+#     }
+#
+# `with m { ... }` locks m for the block, and unlocks it at the end of the
+# block or on a return out of it.
+
+func __mutex_lock(byte** m) ...
+func __mutex_try_lock(byte** m) int ...
+func __mutex_unlock(byte** m) ...
+func __cond_wait(byte** c, byte** m) ...
+func __cond_signal(byte** c) ...
+func __cond_broadcast(byte** c) ...
+
+# Mutex is a lock that one thread holds at a time. Locking a mutex the
+# thread already holds, or unlocking one it doesn't, ends the program
+pub class Mutex {
+	byte* m
+
+	# wait until no other thread holds the mutex, then hold it
+	func lock {
+		__mutex_lock(&this.m);
+	}
+
+	# hold the mutex if no thread does, returning false instead of waiting
+	func try_lock bool {
+		return __mutex_try_lock(&this.m) != 0;
+	}
+
+	func unlock {
+		__mutex_unlock(&this.m);
+	}
+}
+
+# Cond is a condition variable, which threads wait on until another thread
+# signals that what they are waiting for may have changed. As a wait can
+# return without a signal, it is waited on in a loop:
+#
+#     with mu {
+#         while queue.len == 0 {
+#             ready.wait(&mu);
+#         }
+#         ...
+#     }
+pub class Cond {
+	byte* c
+
+	# unlock m, which the thread must hold, and wait to be signalled. m is
+	# locked again before wait returns
+	func wait(Mutex* m) {
+		__cond_wait(&this.c, &m.m);
+	}
+
+	# wake one of the threads waiting on the cond
+	func signal {
+		__cond_signal(&this.c);
+	}
+
+	# wake every thread waiting on the cond
+	func broadcast {
+		__cond_broadcast(&this.c);
+	}
+}
@@ -0,0 +1,165 @@
+// Package bitcode writes LLVM IR modules in the binary bitcode format, which
+// tools read faster than the textual assembly.
+//
+// Modules are written in the format of LLVM 5 and later, with a string table
+// for global names. Metadata is not written.
+//
+// References:
+//
+//	http://llvm.org/docs/BitCodeFormat.html
+package bitcode
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/geode-lang/geode/llvm/ir"
+)
+
+// Block IDs.
+const (
+	blockModule         = 8
+	blockParamAttr      = 9
+	blockParamAttrGroup = 10
+	blockConstants      = 11
+	blockFunction       = 12
+	blockType           = 17
+	blockStrtab         = 23
+)
+
+// Module block record codes.
+const (
+	moduleVersion     = 1
+	moduleTriple      = 2
+	moduleDataLayout  = 3
+	moduleSectionName = 5
+	moduleGlobalVar   = 7
+	moduleFunction    = 8
+)
+
+// Attribute block record codes.
+const (
+	paramAttrEntry      = 2
+	paramAttrGroupEntry = 3
+)
+
+// Type block record codes.
+const (
+	typeNumEntry      = 1
+	typeVoid          = 2
+	typeFloat         = 3
+	typeDouble        = 4
+	typeLabel         = 5
+	typeOpaque        = 6
+	typeInteger       = 7
+	typePointer       = 8
+	typeHalf          = 10
+	typeArray         = 11
+	typeVector        = 12
+	typeX86FP80       = 13
+	typeFP128         = 14
+	typePPCFP128      = 15
+	typeMetadata      = 16
+	typeStructAnon    = 18
+	typeStructName    = 19
+	typeStructNamed   = 20
+	typeFunction      = 21
+	typeOpaquePointer = 25
+)
+
+// Constants block record codes.
+const (
+	constSetType       = 1
+	constNull          = 2
+	constUndef         = 3
+	constInteger       = 4
+	constWideInteger   = 5
+	constFloat         = 6
+	constAggregate     = 7
+	constString        = 8
+	constCString       = 9
+	constBinop         = 10
+	constCast          = 11
+	constSelect        = 13
+	constExtractElt    = 14
+	constInsertElt     = 15
+	constShuffleVector = 16
+	constCmp           = 17
+	constInboundsGEP   = 20
+	constInlineAsm     = 30
+)
+
+// Function block record codes.
+const (
+	funcDeclareBlocks = 1
+	funcBinop         = 2
+	funcCast          = 3
+	funcExtractElt    = 6
+	funcInsertElt     = 7
+	funcShuffleVector = 8
+	funcRet           = 10
+	funcBr            = 11
+	funcSwitch        = 12
+	funcInvoke        = 13
+	funcUnreachable   = 15
+	funcPhi           = 16
+	funcAlloca        = 19
+	funcLoad          = 20
+	funcExtractVal    = 26
+	funcInsertVal     = 27
+	funcCmp2          = 28
+	funcVSelect       = 29
+	funcCall          = 34
+	funcFence         = 36
+	funcAtomicRMWOld  = 38
+	funcResume        = 39
+	funcLoadAtomic    = 41
+	funcGEP           = 43
+	funcStore         = 44
+	funcStoreAtomic   = 45
+	funcCmpXchg       = 46
+	funcLandingPad    = 47
+	funcAtomicRMW     = 59
+)
+
+// strtabBlob is the record of the string table
+const strtabBlob = 1
+
+// syncScopeSystem is the synchronization scope of atomic instructions
+const syncScopeSystem = 1
+
+// Encode writes a module to w as bitcode. It fails on values and
+// instructions that have no bitcode representation, unless they implement
+// Lowerer.
+func Encode(w io.Writer, m *ir.Module) error {
+	buf, err := encode(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// encodeError is panicked with by the encoder to stop at the first value
+// it can't encode
+type encodeError struct {
+	err error
+}
+
+// fail stops encoding with an error
+func fail(format string, args ...interface{}) {
+	panic(encodeError{fmt.Errorf(format, args...)})
+}
+
+func encode(m *ir.Module) (buf []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(encodeError)
+			if !ok {
+				panic(r)
+			}
+			err = failure.err
+		}
+	}()
+	return newEncoder(m).encode(), nil
+}
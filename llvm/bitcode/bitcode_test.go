@@ -0,0 +1,168 @@
+package bitcode_test
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/bitcode"
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// newModule returns a module that sums the numbers below n in a loop, whose
+// phi refers to an instruction after it
+func newModule() *ir.Module {
+	i32 := types.I32
+	zero := constant.NewInt(0, i32)
+	one := constant.NewInt(1, i32)
+
+	m := ir.NewModule()
+	total := m.NewGlobalDef("total", zero)
+	n := ir.NewParam("n", i32)
+	sum := m.NewFunction("sum", i32, n)
+	entry := sum.NewBlock("entry")
+	loop := sum.NewBlock("loop")
+	exit := sum.NewBlock("exit")
+	entry.NewBr(loop)
+
+	back := ir.NewIncoming(zero, loop)
+	i := loop.NewPhi(ir.NewIncoming(zero, entry), back)
+	next := loop.NewAdd(i, one)
+	back.X = next
+	loop.NewStore(loop.NewAdd(loop.NewLoad(total), i), total)
+	loop.NewCondBr(loop.NewICmp(ir.IntSLT, next, n), loop, exit)
+
+	exit.NewRet(exit.NewLoad(total))
+	return m
+}
+
+// newInvokeModule returns a module with a function that invokes another,
+// and returns -1 from its landing pad if the call throws, and one that
+// cleans up after the call with a landing pad that resumes unwinding
+func newInvokeModule() *ir.Module {
+	i8ptr := types.NewPointer(types.I8)
+
+	m := ir.NewModule()
+	personality := m.NewFunction("personality", types.I32)
+	personality.Sig.Variadic = true
+	f := m.NewFunction("f", types.I32)
+	g := m.NewFunction("g", types.I32)
+	g.Personality = personality
+	entry := g.NewBlock("entry")
+	ok := g.NewBlock("ok")
+	pad := g.NewBlock("pad")
+	res := entry.NewInvoke(f, nil, ok, pad)
+	ok.NewRet(res)
+	pad.NewLandingPad(types.NewStruct(i8ptr, types.I32), constant.NewNull(i8ptr))
+	pad.NewRet(constant.NewInt(-1, types.I32))
+
+	h := m.NewFunction("h", types.I32)
+	h.Personality = personality
+	entry = h.NewBlock("entry")
+	ok = h.NewBlock("ok")
+	cleanup := h.NewBlock("cleanup")
+	res = entry.NewInvoke(f, nil, ok, cleanup)
+	ok.NewRet(res)
+	lp := cleanup.NewLandingPad(types.NewStruct(i8ptr, types.I32))
+	lp.Cleanup = true
+	cleanup.NewResume(lp)
+	return m
+}
+
+// newAsmModule returns a module with a function that moves its argument to
+// its result with inline assembly
+func newAsmModule() *ir.Module {
+	m := ir.NewModule()
+	x := ir.NewParam("x", types.I32)
+	f := m.NewFunction("f", types.I32, x)
+	entry := f.NewBlock("entry")
+	asm := &ir.InlineAsm{
+		Asm:         "mov $1, $0",
+		Constraints: "=r,r",
+		Typ:         types.NewPointer(types.NewFunc(types.I32, types.NewParam("", types.I32))),
+		SideEffect:  true,
+	}
+	entry.NewRet(entry.NewCall(asm, x))
+	return m
+}
+
+// encode encodes a module, checks the bitcode is well formed and, when
+// llvm is installed, checks its disassembly has every string in want
+func encode(t *testing.T, m *ir.Module, want ...string) {
+	buf := &bytes.Buffer{}
+	if err := bitcode.Encode(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("BC\xC0\xDE")) {
+		t.Fatalf("bitcode starts with %q, not the magic number", buf.Bytes()[:4])
+	}
+	if buf.Len()%4 != 0 {
+		t.Fatalf("bitcode of %d bytes doesn't end on a word", buf.Len())
+	}
+
+	// llvm is the real test of the encoding, when it is installed
+	dis, err := exec.LookPath("llvm-dis")
+	if err != nil {
+		t.Skip("llvm-dis not found")
+	}
+	out, err := disassemble(dis, buf.Bytes())
+	if err != nil && strings.Contains(string(out), "-opaque-pointers") {
+		// llvm before 15 only reads opaque pointers when asked to
+		out, err = disassemble(dis, buf.Bytes(), "-opaque-pointers")
+	}
+	if err != nil {
+		t.Fatalf("llvm-dis: %v\n%s", err, out)
+	}
+	for _, want := range want {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("disassembly has no %q:\n%s", want, out)
+		}
+	}
+}
+
+// disassemble returns the output of llvm-dis for the bitcode
+func disassemble(dis string, bc []byte, flags ...string) ([]byte, error) {
+	cmd := exec.Command(dis, append(flags, "-o", "-")...)
+	cmd.Stdin = bytes.NewReader(bc)
+	return cmd.CombinedOutput()
+}
+
+func TestEncode(t *testing.T) {
+	encode(t, newModule(),
+		"@total = global i32 0",
+		"define i32 @sum(i32 %0)",
+		"phi i32 [ 0, %1 ], [ %4, %2 ]",
+		"icmp slt i32 %4, %0",
+	)
+}
+
+func TestEncodeInvoke(t *testing.T) {
+	encode(t, newInvokeModule(),
+		"define i32 @g() personality i32 (...)* @personality",
+		"invoke i32 @f()",
+		"to label %2 unwind label %3",
+		"landingpad { i8*, i32 }",
+		"catch i8* null",
+		"landingpad { i8*, i32 }\n          cleanup",
+		"resume { i8*, i32 } %4",
+	)
+}
+
+func TestEncodeInlineAsm(t *testing.T) {
+	encode(t, newAsmModule(),
+		`call i32 asm sideeffect "mov $1, $0", "=r,r"(i32 %0)`,
+	)
+}
+
+func TestEncodeOpaquePointers(t *testing.T) {
+	m := newInvokeModule()
+	m.OpaquePointers = true
+	encode(t, m,
+		"define i32 @g() personality ptr @personality",
+		"landingpad { ptr, i32 }",
+		"catch ptr null",
+	)
+}
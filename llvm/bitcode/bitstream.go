@@ -0,0 +1,156 @@
+package bitcode
+
+import "encoding/binary"
+
+// Abbreviation IDs every block has. The IDs after them are defined by the
+// DEFINE_ABBREV records of a block.
+const (
+	abbrevEndBlock       = 0
+	abbrevEnterSubblock  = 1
+	abbrevDefine         = 2
+	abbrevUnabbrevRecord = 3
+	abbrevFirstDefined   = 4
+)
+
+// encodingBlob is the encoding of an abbreviation operand that is a blob
+const encodingBlob = 5
+
+// A bitstream writes the bitstream container format of llvm bitcode. Bits
+// are packed into little endian 32-bit words, the lowest bits first.
+//
+// References:
+//
+//	http://llvm.org/docs/BitCodeFormat.html#bitstream-format
+type bitstream struct {
+	words []uint32
+	// cur holds the bits of the word being written
+	cur    uint32
+	curBit uint
+	// width is the abbreviation ID width of the current block
+	width uint
+	// blocks are the blocks that have been entered and not exited yet
+	blocks []openBlock
+}
+
+// openBlock is a block being written. Its length is filled in when it is
+// exited.
+type openBlock struct {
+	lengthWord int
+	width      uint
+}
+
+// emit writes the low width bits of val, width being at most 32
+func (s *bitstream) emit(val uint32, width uint) {
+	if width == 0 {
+		return
+	}
+	if width < 32 {
+		val &= 1<<width - 1
+	}
+	s.cur |= val << s.curBit
+	if s.curBit+width < 32 {
+		s.curBit += width
+		return
+	}
+	s.words = append(s.words, s.cur)
+	if s.curBit != 0 {
+		s.cur = val >> (32 - s.curBit)
+	} else {
+		s.cur = 0
+	}
+	s.curBit = (s.curBit + width) & 31
+}
+
+// emitVBR writes val as a variable bit rate field with chunks of width bits
+func (s *bitstream) emitVBR(val uint64, width uint) {
+	threshold := uint64(1) << (width - 1)
+	for val >= threshold {
+		s.emit(uint32(val&(threshold-1)|threshold), width)
+		val >>= width - 1
+	}
+	s.emit(uint32(val), width)
+}
+
+// align pads the stream with zeros to the next 32-bit word
+func (s *bitstream) align() {
+	if s.curBit > 0 {
+		s.words = append(s.words, s.cur)
+		s.cur = 0
+		s.curBit = 0
+	}
+}
+
+// enterBlock starts a block whose abbreviation IDs are width bits wide
+func (s *bitstream) enterBlock(id uint64, width uint) {
+	s.emit(abbrevEnterSubblock, s.width)
+	s.emitVBR(id, 8)
+	s.emitVBR(uint64(width), 4)
+	s.align()
+	s.blocks = append(s.blocks, openBlock{lengthWord: len(s.words), width: s.width})
+	// the length in words, filled in by exitBlock
+	s.emit(0, 32)
+	s.width = width
+}
+
+// exitBlock ends the block entered last
+func (s *bitstream) exitBlock() {
+	s.emit(abbrevEndBlock, s.width)
+	s.align()
+	block := s.blocks[len(s.blocks)-1]
+	s.blocks = s.blocks[:len(s.blocks)-1]
+	s.words[block.lengthWord] = uint32(len(s.words) - block.lengthWord - 1)
+	s.width = block.width
+}
+
+// record writes an unabbreviated record
+func (s *bitstream) record(code uint64, ops ...uint64) {
+	s.emit(abbrevUnabbrevRecord, s.width)
+	s.emitVBR(code, 6)
+	s.emitVBR(uint64(len(ops)), 6)
+	for _, op := range ops {
+		s.emitVBR(op, 6)
+	}
+}
+
+// defineBlobAbbrev defines the abbreviation of a record that is a literal
+// code followed by a blob. It returns the ID of the abbreviation.
+func (s *bitstream) defineBlobAbbrev(code uint64) uint32 {
+	s.emit(abbrevDefine, s.width)
+	s.emitVBR(2, 5)
+	// the code is a literal
+	s.emit(1, 1)
+	s.emitVBR(code, 8)
+	s.emit(0, 1)
+	s.emit(encodingBlob, 3)
+	return abbrevFirstDefined
+}
+
+// blobRecord writes a record with an abbreviation defined by
+// defineBlobAbbrev
+func (s *bitstream) blobRecord(abbrev uint32, blob []byte) {
+	s.emit(abbrev, s.width)
+	s.emitVBR(uint64(len(blob)), 6)
+	s.align()
+	for _, b := range blob {
+		s.emit(uint32(b), 8)
+	}
+	s.align()
+}
+
+// appendStream writes the bits of another stream, which has no open blocks
+// and was started at the same bit alignment this stream is at
+func (s *bitstream) appendStream(o *bitstream) {
+	for _, word := range o.words {
+		s.emit(word, 32)
+	}
+	s.emit(o.cur, o.curBit)
+}
+
+// bytes returns the stream, which has to end on a word boundary
+func (s *bitstream) bytes() []byte {
+	buf := make([]byte, 4*len(s.words))
+	for i, word := range s.words {
+		binary.LittleEndian.PutUint32(buf[4*i:], word)
+	}
+	return buf
+}
@@ -0,0 +1,628 @@
+package bitcode
+
+import (
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// writeFunction writes the block of a function's body
+func (e *encoder) writeFunction(s *bitstream, f *ir.Function) {
+	e.function = newValueTable(e.module.next)
+	defer func() { e.function = nil }()
+
+	for _, param := range f.Params() {
+		e.function.add(param)
+	}
+	blocks := make(map[*ir.BasicBlock]uint64, len(f.Blocks))
+	for i, block := range f.Blocks {
+		blocks[block] = uint64(i)
+		if block.Term == nil {
+			fail("block %s of function %s has no terminator", block.Name, f.Name)
+		}
+	}
+	// The constants come before the instructions, which can refer to
+	// instructions after them, so everything is numbered first
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			if lowered := lower(inst); lowered != nil {
+				e.addOperands(lowered)
+			}
+		}
+		e.addOperands(block.Term)
+	}
+	firstInst := e.function.next
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			if v, ok := inst.(value.Value); ok && !types.IsVoid(v.Type()) {
+				e.function.add(v)
+			}
+		}
+		// an invoke has the value its callee returns
+		if v, ok := block.Term.(value.Value); ok && !types.IsVoid(v.Type()) {
+			e.function.add(v)
+		}
+	}
+
+	s.enterBlock(blockFunction, 4)
+	s.record(funcDeclareBlocks, uint64(len(f.Blocks)))
+	e.writeConstants(s, e.function)
+
+	w := &instWriter{encoder: e, blocks: blocks, id: firstInst}
+	for _, block := range f.Blocks {
+		for _, inst := range block.Insts {
+			lowered := lower(inst)
+			if lowered == nil {
+				continue
+			}
+			code, ops := w.inst(lowered)
+			s.record(code, ops...)
+			if v, ok := inst.(value.Value); ok && !types.IsVoid(v.Type()) {
+				w.id++
+			}
+		}
+		code, ops := w.inst(block.Term)
+		s.record(code, ops...)
+		if v, ok := block.Term.(value.Value); ok && !types.IsVoid(v.Type()) {
+			w.id++
+		}
+	}
+	s.exitBlock()
+}
+
+// A Lowerer is an instruction from outside of package ir, which is written
+// as an instruction of package ir that does the same
+type Lowerer interface {
+	// Lower returns the instruction to write in place of this one, or nil
+	// if it has no effect and is left out, like a comment. The instruction
+	// returned is only written, values that use this one keep doing so.
+	Lower() ir.Instruction
+}
+
+// lower returns the instruction to write for an instruction
+func lower(inst ir.Instruction) ir.Instruction {
+	if l, ok := inst.(Lowerer); ok {
+		return l.Lower()
+	}
+	return inst
+}
+
+// addOperands adds the constants an instruction uses to the function
+func (e *encoder) addOperands(inst ir.Instruction) {
+	for _, op := range operands(inst) {
+		if asm, ok := op.(*ir.InlineAsm); ok {
+			e.function.addInlineAsm(asm)
+			continue
+		}
+		c, ok := op.(constant.Constant)
+		if !ok {
+			continue
+		}
+		if _, found := e.module.lookup(c); !found {
+			e.function.addConstant(c)
+		}
+	}
+}
+
+// allocaSize is the number of elements of an alloca that doesn't give one
+var allocaSize = constant.NewInt(1, types.I32)
+
+// operands returns the values an instruction uses, except for blocks
+func operands(inst ir.Instruction) []value.Value {
+	if _, x, y, ok := binaryInst(inst); ok {
+		return []value.Value{x, y}
+	}
+	if _, from, _, ok := castInst(inst); ok {
+		return []value.Value{from}
+	}
+	switch inst := inst.(type) {
+	case *ir.InstAlloca:
+		if inst.NElems == nil {
+			return []value.Value{allocaSize}
+		}
+		return []value.Value{inst.NElems}
+	case *ir.InstLoad:
+		return []value.Value{inst.Src}
+	case *ir.InstStore:
+		return []value.Value{inst.Src, inst.Dst}
+	case *ir.InstCmpXchg:
+		return []value.Value{inst.Ptr, inst.Cmp, inst.New}
+	case *ir.InstAtomicRMW:
+		return []value.Value{inst.Dst, inst.X}
+	case *ir.InstGetElementPtr:
+		return append([]value.Value{inst.Src}, inst.Indices...)
+	case *ir.InstICmp:
+		return []value.Value{inst.X, inst.Y}
+	case *ir.InstFCmp:
+		return []value.Value{inst.X, inst.Y}
+	case *ir.InstPhi:
+		ops := make([]value.Value, len(inst.Incs))
+		for i, inc := range inst.Incs {
+			ops[i] = inc.X
+		}
+		return ops
+	case *ir.InstSelect:
+		return []value.Value{inst.Cond, inst.X, inst.Y}
+	case *ir.InstCall:
+		return append([]value.Value{inst.Callee}, inst.Args...)
+	case *ir.InstLandingPad:
+		ops := make([]value.Value, len(inst.Catches))
+		for i, c := range inst.Catches {
+			ops[i] = c
+		}
+		return ops
+	case *ir.InstExtractValue:
+		return []value.Value{inst.X}
+	case *ir.InstInsertValue:
+		return []value.Value{inst.X, inst.Elem}
+	case *ir.InstExtractElement:
+		return []value.Value{inst.X, inst.Index}
+	case *ir.InstInsertElement:
+		return []value.Value{inst.X, inst.Elem, inst.Index}
+	case *ir.InstShuffleVector:
+		return []value.Value{inst.X, inst.Y, inst.Mask}
+	case *ir.TermRet:
+		if inst.X != nil {
+			return []value.Value{inst.X}
+		}
+	case *ir.TermCondBr:
+		return []value.Value{inst.Cond}
+	case *ir.TermInvoke:
+		return append([]value.Value{inst.Callee}, inst.Args...)
+	case *ir.TermResume:
+		return []value.Value{inst.X}
+	case *ir.TermSwitch:
+		ops := []value.Value{inst.X}
+		for _, c := range inst.Cases {
+			ops = append(ops, c.X)
+		}
+		return ops
+	}
+	return nil
+}
+
+// An instWriter builds the records of the instructions of a function
+type instWriter struct {
+	*encoder
+	blocks map[*ir.BasicBlock]uint64
+	// id is the ID of the instruction being written, if it has a value
+	id uint64
+}
+
+// value returns the operand of a value, which is its ID relative to the
+// instruction's. The reader wraps it around in 32 bits for values after the
+// instruction.
+func (w *instWriter) value(v value.Value) uint64 {
+	return uint64(uint32(w.id - w.valueID(v)))
+}
+
+// typedValue returns the operands of a value and, when it comes after the
+// instruction, its type
+func (w *instWriter) typedValue(v value.Value) []uint64 {
+	if w.valueID(v) >= w.id {
+		return []uint64{w.value(v), w.types.id(v.Type())}
+	}
+	return []uint64{w.value(v)}
+}
+
+// signedValue returns the operand of a value as a signed relative ID
+func (w *instWriter) signedValue(v value.Value) uint64 {
+	return signRotate(int64(w.id) - int64(w.valueID(v)))
+}
+
+// block returns the ID of a block
+func (w *instWriter) block(b *ir.BasicBlock) uint64 {
+	id, found := w.blocks[b]
+	if !found {
+		fail("unable to branch to block %s, it isn't in the function", b.Name)
+	}
+	return id
+}
+
+// inst returns the record of an instruction
+func (w *instWriter) inst(inst ir.Instruction) (uint64, []uint64) {
+	if op, x, y, ok := binaryInst(inst); ok {
+		return funcBinop, append(w.typedValue(x), w.value(y), op)
+	}
+	if op, from, to, ok := castInst(inst); ok {
+		return funcCast, append(w.typedValue(from), w.types.id(to), op)
+	}
+
+	switch inst := inst.(type) {
+	case *ir.InstAlloca:
+		size := inst.NElems
+		if size == nil {
+			size = allocaSize
+		}
+		align := alignment(inst.Align)
+		// the alignment is split around flags, one of which says the type
+		// is the allocated type rather than the pointer
+		packed := align&31 | 1<<6 | align>>5<<8
+		return funcAlloca, []uint64{w.types.id(inst.Elem), w.types.id(size.Type()), w.valueID(size), packed}
+
+	case *ir.InstLoad:
+		ops := append(w.typedValue(inst.Src), w.types.id(inst.Type()), alignment(inst.Align), flag(inst.Volatile))
+		if inst.Ordering != ir.OrderingNone {
+			return funcLoadAtomic, append(ops, uint64(inst.Ordering), syncScopeSystem)
+		}
+		return funcLoad, ops
+
+	case *ir.InstStore:
+		ops := append(w.typedValue(inst.Dst), w.typedValue(inst.Src)...)
+		ops = append(ops, alignment(inst.Align), flag(inst.Volatile))
+		if inst.Ordering != ir.OrderingNone {
+			return funcStoreAtomic, append(ops, uint64(inst.Ordering), syncScopeSystem)
+		}
+		return funcStore, ops
+
+	case *ir.InstFence:
+		return funcFence, []uint64{uint64(inst.Ordering), syncScopeSystem}
+
+	case *ir.InstCmpXchg:
+		ops := append(w.typedValue(inst.Ptr), w.typedValue(inst.Cmp)...)
+		ops = append(ops, w.value(inst.New))
+		// not volatile, success ordering, scope, failure ordering, not weak
+		return funcCmpXchg, append(ops, 0, uint64(inst.Success), syncScopeSystem, uint64(inst.Failure), 0)
+
+	case *ir.InstAtomicRMW:
+		// with opaque pointers, the type of the value can't be taken from
+		// the pointer, so the newer record that has it is written
+		ops := w.typedValue(inst.Dst)
+		code := uint64(funcAtomicRMWOld)
+		if w.types.opaque {
+			ops = append(ops, w.typedValue(inst.X)...)
+			code = funcAtomicRMW
+		} else {
+			ops = append(ops, w.value(inst.X))
+		}
+		return code, append(ops, uint64(inst.Op), 0, uint64(inst.Ordering), syncScopeSystem)
+
+	case *ir.InstGetElementPtr:
+		// the instruction is always inbounds
+		ops := []uint64{1, w.types.id(inst.Elem)}
+		ops = append(ops, w.typedValue(inst.Src)...)
+		for _, index := range inst.Indices {
+			ops = append(ops, w.typedValue(index)...)
+		}
+		return funcGEP, ops
+
+	case *ir.InstICmp:
+		return funcCmp2, append(w.typedValue(inst.X), w.value(inst.Y), intPred(inst.Pred))
+
+	case *ir.InstFCmp:
+		return funcCmp2, append(w.typedValue(inst.X), w.value(inst.Y), floatPred(inst.Pred))
+
+	case *ir.InstPhi:
+		ops := []uint64{w.types.id(inst.Type())}
+		for _, inc := range inst.Incs {
+			ops = append(ops, w.signedValue(inc.X), w.block(inc.Pred))
+		}
+		return funcPhi, ops
+
+	case *ir.InstSelect:
+		ops := append(w.typedValue(inst.X), w.value(inst.Y))
+		return funcVSelect, append(ops, w.typedValue(inst.Cond)...)
+
+	case *ir.InstCall:
+		// no attributes, then the calling convention and a flag saying the
+		// function type is given explicitly
+		ops := []uint64{0, callConv(inst.CallConv)<<1 | 1<<15, w.types.id(inst.Sig)}
+		return funcCall, append(ops, w.callArgs(inst.Callee, inst.Sig, inst.Args)...)
+
+	case *ir.InstLandingPad:
+		ops := []uint64{w.types.id(inst.Typ), flag(inst.Cleanup), uint64(len(inst.Catches))}
+		for _, c := range inst.Catches {
+			// every clause is a catch, rather than a filter
+			ops = append(ops, 0)
+			ops = append(ops, w.typedValue(c)...)
+		}
+		return funcLandingPad, ops
+
+	case *ir.InstExtractValue:
+		return funcExtractVal, append(w.typedValue(inst.X), indices(inst.Indices)...)
+
+	case *ir.InstInsertValue:
+		ops := append(w.typedValue(inst.X), w.typedValue(inst.Elem)...)
+		return funcInsertVal, append(ops, indices(inst.Indices)...)
+
+	case *ir.InstExtractElement:
+		return funcExtractElt, append(w.typedValue(inst.X), w.typedValue(inst.Index)...)
+
+	case *ir.InstInsertElement:
+		ops := append(w.typedValue(inst.X), w.value(inst.Elem))
+		return funcInsertElt, append(ops, w.typedValue(inst.Index)...)
+
+	case *ir.InstShuffleVector:
+		return funcShuffleVector, append(w.typedValue(inst.X), w.value(inst.Y), w.value(inst.Mask))
+
+	case *ir.TermRet:
+		if inst.X == nil {
+			return funcRet, nil
+		}
+		return funcRet, w.typedValue(inst.X)
+
+	case *ir.TermBr:
+		return funcBr, []uint64{w.block(inst.Target)}
+
+	case *ir.TermCondBr:
+		return funcBr, []uint64{w.block(inst.TargetTrue), w.block(inst.TargetFalse), w.value(inst.Cond)}
+
+	case *ir.TermSwitch:
+		ops := []uint64{w.types.id(inst.X.Type()), w.value(inst.X), w.block(inst.TargetDefault)}
+		for _, c := range inst.Cases {
+			ops = append(ops, w.valueID(c.X), w.block(c.Target))
+		}
+		return funcSwitch, ops
+
+	case *ir.TermInvoke:
+		// the flag in the calling convention of an invoke saying the
+		// function type is given is in another place than a call's
+		ops := []uint64{0, callConv(inst.CallConv) | 1<<13, w.block(inst.Normal), w.block(inst.Exception), w.types.id(inst.Sig)}
+		return funcInvoke, append(ops, w.callArgs(inst.Callee, inst.Sig, inst.Args)...)
+
+	case *ir.TermResume:
+		return funcResume, w.typedValue(inst.X)
+
+	case *ir.TermUnreachable:
+		return funcUnreachable, nil
+	}
+	fail("unable to encode instruction %T: %s", inst, inst)
+	return 0, nil
+}
+
+// callArgs returns the operands of the callee and arguments of a call or
+// invoke. The types of the variadic arguments are given, as the signature
+// doesn't have them.
+func (w *instWriter) callArgs(callee value.Value, sig *types.FuncType, args []value.Value) []uint64 {
+	ops := w.typedValue(callee)
+	for i, arg := range args {
+		if i < len(sig.Params) {
+			ops = append(ops, w.value(arg))
+		} else {
+			ops = append(ops, w.typedValue(arg)...)
+		}
+	}
+	return ops
+}
+
+// indices returns the operands of the indices of an aggregate instruction
+func indices(list []int64) []uint64 {
+	ops := make([]uint64, len(list))
+	for i, index := range list {
+		ops[i] = uint64(index)
+	}
+	return ops
+}
+
+// flag returns the operand of a boolean
+func flag(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Binary operation codes. Floating-point operations share the codes of
+// the integer operations, division and remainder the signed ones.
+const (
+	binopAdd  = 0
+	binopSub  = 1
+	binopMul  = 2
+	binopUDiv = 3
+	binopSDiv = 4
+	binopURem = 5
+	binopSRem = 6
+	binopShl  = 7
+	binopLShr = 8
+	binopAShr = 9
+	binopAnd  = 10
+	binopOr   = 11
+	binopXor  = 12
+)
+
+// binaryInst returns the operation and operands of a binary instruction
+func binaryInst(inst ir.Instruction) (uint64, value.Value, value.Value, bool) {
+	switch inst := inst.(type) {
+	case *ir.InstAdd:
+		return binopAdd, inst.X, inst.Y, true
+	case *ir.InstFAdd:
+		return binopAdd, inst.X, inst.Y, true
+	case *ir.InstSub:
+		return binopSub, inst.X, inst.Y, true
+	case *ir.InstFSub:
+		return binopSub, inst.X, inst.Y, true
+	case *ir.InstMul:
+		return binopMul, inst.X, inst.Y, true
+	case *ir.InstFMul:
+		return binopMul, inst.X, inst.Y, true
+	case *ir.InstUDiv:
+		return binopUDiv, inst.X, inst.Y, true
+	case *ir.InstSDiv:
+		return binopSDiv, inst.X, inst.Y, true
+	case *ir.InstFDiv:
+		return binopSDiv, inst.X, inst.Y, true
+	case *ir.InstURem:
+		return binopURem, inst.X, inst.Y, true
+	case *ir.InstSRem:
+		return binopSRem, inst.X, inst.Y, true
+	case *ir.InstFRem:
+		return binopSRem, inst.X, inst.Y, true
+	case *ir.InstShl:
+		return binopShl, inst.X, inst.Y, true
+	case *ir.InstLShr:
+		return binopLShr, inst.X, inst.Y, true
+	case *ir.InstAShr:
+		return binopAShr, inst.X, inst.Y, true
+	case *ir.InstAnd:
+		return binopAnd, inst.X, inst.Y, true
+	case *ir.InstOr:
+		return binopOr, inst.X, inst.Y, true
+	case *ir.InstXor:
+		return binopXor, inst.X, inst.Y, true
+	}
+	return 0, nil, nil, false
+}
+
+// binaryExpr returns the operation and operands of a binary expression
+func binaryExpr(c constant.Constant) (uint64, constant.Constant, constant.Constant, bool) {
+	switch c := c.(type) {
+	case *constant.ExprAdd:
+		return binopAdd, c.X, c.Y, true
+	case *constant.ExprFAdd:
+		return binopAdd, c.X, c.Y, true
+	case *constant.ExprSub:
+		return binopSub, c.X, c.Y, true
+	case *constant.ExprFSub:
+		return binopSub, c.X, c.Y, true
+	case *constant.ExprMul:
+		return binopMul, c.X, c.Y, true
+	case *constant.ExprFMul:
+		return binopMul, c.X, c.Y, true
+	case *constant.ExprUDiv:
+		return binopUDiv, c.X, c.Y, true
+	case *constant.ExprSDiv:
+		return binopSDiv, c.X, c.Y, true
+	case *constant.ExprFDiv:
+		return binopSDiv, c.X, c.Y, true
+	case *constant.ExprURem:
+		return binopURem, c.X, c.Y, true
+	case *constant.ExprSRem:
+		return binopSRem, c.X, c.Y, true
+	case *constant.ExprFRem:
+		return binopSRem, c.X, c.Y, true
+	case *constant.ExprShl:
+		return binopShl, c.X, c.Y, true
+	case *constant.ExprLShr:
+		return binopLShr, c.X, c.Y, true
+	case *constant.ExprAShr:
+		return binopAShr, c.X, c.Y, true
+	case *constant.ExprAnd:
+		return binopAnd, c.X, c.Y, true
+	case *constant.ExprOr:
+		return binopOr, c.X, c.Y, true
+	case *constant.ExprXor:
+		return binopXor, c.X, c.Y, true
+	}
+	return 0, nil, nil, false
+}
+
+// Cast operation codes.
+const (
+	castTrunc         = 0
+	castZExt          = 1
+	castSExt          = 2
+	castFPToUI        = 3
+	castFPToSI        = 4
+	castUIToFP        = 5
+	castSIToFP        = 6
+	castFPTrunc       = 7
+	castFPExt         = 8
+	castPtrToInt      = 9
+	castIntToPtr      = 10
+	castBitCast       = 11
+	castAddrSpaceCast = 12
+)
+
+// castInst returns the operation, operand and type of a conversion
+// instruction
+func castInst(inst ir.Instruction) (uint64, value.Value, types.Type, bool) {
+	switch inst := inst.(type) {
+	case *ir.InstTrunc:
+		return castTrunc, inst.From, inst.To, true
+	case *ir.InstZExt:
+		return castZExt, inst.From, inst.To, true
+	case *ir.InstSExt:
+		return castSExt, inst.From, inst.To, true
+	case *ir.InstFPTrunc:
+		return castFPTrunc, inst.From, inst.To, true
+	case *ir.InstFPExt:
+		return castFPExt, inst.From, inst.To, true
+	case *ir.InstFPToUI:
+		return castFPToUI, inst.From, inst.To, true
+	case *ir.InstFPToSI:
+		return castFPToSI, inst.From, inst.To, true
+	case *ir.InstUIToFP:
+		return castUIToFP, inst.From, inst.To, true
+	case *ir.InstSIToFP:
+		return castSIToFP, inst.From, inst.To, true
+	case *ir.InstPtrToInt:
+		return castPtrToInt, inst.From, inst.To, true
+	case *ir.InstIntToPtr:
+		return castIntToPtr, inst.From, inst.To, true
+	case *ir.InstBitCast:
+		return castBitCast, inst.From, inst.To, true
+	case *ir.InstAddrSpaceCast:
+		return castAddrSpaceCast, inst.From, inst.To, true
+	}
+	return 0, nil, nil, false
+}
+
+// castExpr returns the operation and operand of a conversion expression,
+// whose type is the type of the expression
+func castExpr(c constant.Constant) (uint64, constant.Constant, bool) {
+	switch c := c.(type) {
+	case *constant.ExprTrunc:
+		return castTrunc, c.From, true
+	case *constant.ExprZExt:
+		return castZExt, c.From, true
+	case *constant.ExprSExt:
+		return castSExt, c.From, true
+	case *constant.ExprFPTrunc:
+		return castFPTrunc, c.From, true
+	case *constant.ExprFPExt:
+		return castFPExt, c.From, true
+	case *constant.ExprFPToUI:
+		return castFPToUI, c.From, true
+	case *constant.ExprFPToSI:
+		return castFPToSI, c.From, true
+	case *constant.ExprUIToFP:
+		return castUIToFP, c.From, true
+	case *constant.ExprSIToFP:
+		return castSIToFP, c.From, true
+	case *constant.ExprPtrToInt:
+		return castPtrToInt, c.From, true
+	case *constant.ExprIntToPtr:
+		return castIntToPtr, c.From, true
+	case *constant.ExprBitCast:
+		return castBitCast, c.From, true
+	case *constant.ExprAddrSpaceCast:
+		return castAddrSpaceCast, c.From, true
+	}
+	return 0, nil, false
+}
+
+// intPred returns the encoding of an integer predicate, which follow the
+// floating-point ones from 32
+func intPred(pred ir.IntPred) uint64 {
+	return uint64(pred-ir.IntEQ) + 32
+}
+
+// floatPreds are the encodings of the floating-point predicates
+var floatPreds = map[ir.FloatPred]uint64{
+	ir.FloatFalse: 0,
+	ir.FloatOEQ:   1,
+	ir.FloatOGT:   2,
+	ir.FloatOGE:   3,
+	ir.FloatOLT:   4,
+	ir.FloatOLE:   5,
+	ir.FloatONE:   6,
+	ir.FloatORD:   7,
+	ir.FloatUNO:   8,
+	ir.FloatUEQ:   9,
+	ir.FloatUGT:   10,
+	ir.FloatUGE:   11,
+	ir.FloatULT:   12,
+	ir.FloatULE:   13,
+	ir.FloatUNE:   14,
+	ir.FloatTrue:  15,
+}
+
+// floatPred returns the encoding of a floating-point predicate
+func floatPred(pred ir.FloatPred) uint64 {
+	code, found := floatPreds[pred]
+	if !found {
+		fail("unable to encode floating-point predicate %s", pred)
+	}
+	return code
+}
@@ -0,0 +1,403 @@
+package bitcode
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// An encoder writes one module. Values are numbered the way the bitcode
+// reader numbers them as it reads the module: globals, then functions,
+// then the constants of global initializers. Inside a function, its
+// parameters, constants and instructions follow those.
+type encoder struct {
+	m *ir.Module
+
+	types typeTable
+
+	// strtab holds the names of globals and functions
+	strtab bytes.Buffer
+
+	// module holds the values of the module and function holds the values
+	// of the function being written
+	module   *valueTable
+	function *valueTable
+
+	// attrLists maps functions to the ID of their attribute list
+	attrLists map[*ir.Function]uint64
+	// attrGroups are the function attribute groups, each sorted by key
+	attrGroups [][][2]string
+}
+
+func newEncoder(m *ir.Module) *encoder {
+	return &encoder{
+		m:         m,
+		types:     newTypeTable(m.OpaquePointers),
+		attrLists: make(map[*ir.Function]uint64),
+	}
+}
+
+// encode returns the bitcode of the module
+func (e *encoder) encode() []byte {
+	// Everything after the type table is written first, because writing it
+	// is what finds every type the table needs
+	body := &bitstream{width: 3}
+	e.writeModuleBody(body)
+
+	// abbreviation IDs outside of blocks are 2 bits wide
+	s := &bitstream{width: 2}
+	s.emit('B', 8)
+	s.emit('C', 8)
+	s.emit(0x0, 4)
+	s.emit(0xC, 4)
+	s.emit(0xE, 4)
+	s.emit(0xD, 4)
+
+	s.enterBlock(blockModule, 3)
+	// version 2 has relative value IDs and names in a string table
+	s.record(moduleVersion, 2)
+	e.writeAttributes(s)
+	e.types.write(s)
+	s.appendStream(body)
+	s.exitBlock()
+
+	s.enterBlock(blockStrtab, 3)
+	s.blobRecord(s.defineBlobAbbrev(strtabBlob), e.strtab.Bytes())
+	s.exitBlock()
+	return s.bytes()
+}
+
+// writeModuleBody writes the module block after the type table
+func (e *encoder) writeModuleBody(s *bitstream) {
+	if e.m.TargetTriple != "" {
+		s.record(moduleTriple, chars(e.m.TargetTriple)...)
+	}
+	if e.m.DataLayout != "" {
+		s.record(moduleDataLayout, chars(e.m.DataLayout)...)
+	}
+
+	sections := make(map[string]uint64)
+	for _, global := range e.m.Globals {
+		if global.Section != "" && sections[global.Section] == 0 {
+			s.record(moduleSectionName, chars(global.Section)...)
+			sections[global.Section] = uint64(len(sections) + 1)
+		}
+	}
+
+	// globals and functions are numbered first, in the order of their
+	// records, then the constants their initializers use
+	e.module = newValueTable(0)
+	for _, global := range e.m.Globals {
+		e.module.add(global)
+	}
+	for _, f := range e.m.Funcs {
+		e.module.add(f)
+	}
+	for _, global := range e.m.Globals {
+		if global.Init != nil {
+			e.module.addConstant(global.Init)
+		}
+	}
+	for _, f := range e.m.Funcs {
+		if f.Personality != nil {
+			e.module.addConstant(f.Personality)
+		}
+	}
+
+	for _, global := range e.m.Globals {
+		init := uint64(0)
+		if global.Init != nil {
+			init = e.valueID(global.Init) + 1
+		}
+		flags := uint64(2) | uint64(global.Typ.AddrSpace)<<2
+		if global.IsConst {
+			flags |= 1
+		}
+		ops := e.name(global.Name)
+		ops = append(ops,
+			e.types.id(global.Content),
+			flags,
+			init,
+			linkage(global.Linkage),
+			alignment(global.Align),
+			sections[global.Section],
+			uint64(global.Visibility),
+		)
+		s.record(moduleGlobalVar, ops...)
+	}
+
+	for _, f := range e.m.Funcs {
+		proto := uint64(0)
+		if len(f.Blocks) == 0 {
+			proto = 1
+		}
+		personality := uint64(0)
+		if f.Personality != nil && proto == 0 {
+			personality = e.valueID(f.Personality) + 1
+		}
+		ops := e.name(f.Name)
+		ops = append(ops,
+			e.types.id(f.Sig),
+			callConv(f.CallConv),
+			proto,
+			linkage(f.Linkage),
+			e.attrList(f),
+			0, // alignment
+			0, // section
+			uint64(f.Visibility),
+			0, // gc
+			0, // unnamed_addr
+			0, // prologue data
+			0, // dll storage class
+			0, // comdat
+			0, // prefix data
+			personality,
+		)
+		s.record(moduleFunction, ops...)
+	}
+
+	e.writeConstants(s, e.module)
+
+	for _, f := range e.m.Funcs {
+		if len(f.Blocks) > 0 {
+			e.writeFunction(s, f)
+		}
+	}
+}
+
+// name adds a name to the string table, returning its offset and size
+func (e *encoder) name(name string) []uint64 {
+	offset := e.strtab.Len()
+	e.strtab.WriteString(name)
+	return []uint64{uint64(offset), uint64(len(name))}
+}
+
+// attrList returns the ID of the attribute list of a function, or 0 if it
+// has no attributes
+func (e *encoder) attrList(f *ir.Function) uint64 {
+	if len(f.Attrs) == 0 {
+		return 0
+	}
+	group := make([][2]string, 0, len(f.Attrs))
+	for key, val := range f.Attrs {
+		group = append(group, [2]string{key, val})
+	}
+	sort.Slice(group, func(i, j int) bool { return group[i][0] < group[j][0] })
+	e.attrGroups = append(e.attrGroups, group)
+	id := uint64(len(e.attrGroups))
+	e.attrLists[f] = id
+	return id
+}
+
+// writeAttributes writes the attribute groups and the attribute lists that
+// use them. Every list is a single group of function attributes.
+func (e *encoder) writeAttributes(s *bitstream) {
+	if len(e.attrGroups) == 0 {
+		return
+	}
+	// the index of the attributes of the function itself, rather than of
+	// its return value or a parameter
+	const functionIndex = 0xFFFFFFFF
+
+	s.enterBlock(blockParamAttrGroup, 3)
+	for i, group := range e.attrGroups {
+		ops := []uint64{uint64(i + 1), functionIndex}
+		for _, attr := range group {
+			// a string attribute with a value
+			ops = append(ops, 4)
+			ops = append(ops, chars(attr[0])...)
+			ops = append(ops, 0)
+			ops = append(ops, chars(attr[1])...)
+			ops = append(ops, 0)
+		}
+		s.record(paramAttrGroupEntry, ops...)
+	}
+	s.exitBlock()
+
+	s.enterBlock(blockParamAttr, 3)
+	for i := range e.attrGroups {
+		s.record(paramAttrEntry, uint64(i+1))
+	}
+	s.exitBlock()
+}
+
+// valueID returns the ID of a value in the function being written or the
+// module
+func (e *encoder) valueID(v value.Value) uint64 {
+	if e.function != nil {
+		if id, found := e.function.lookup(v); found {
+			return id
+		}
+	}
+	if id, found := e.module.lookup(v); found {
+		return id
+	}
+	fail("unable to encode %T value %s, it isn't in the module", v, v.Ident())
+	return 0
+}
+
+// writeConstants writes the constants of a value table
+func (e *encoder) writeConstants(s *bitstream, table *valueTable) {
+	if len(table.constants) == 0 {
+		return
+	}
+	s.enterBlock(blockConstants, 4)
+	current := ^uint64(0)
+	for _, c := range table.constants {
+		if typ := e.types.id(c.Type()); typ != current {
+			s.record(constSetType, typ)
+			current = typ
+		}
+		code, ops := e.constant(c)
+		s.record(code, ops...)
+	}
+	s.exitBlock()
+}
+
+// constant returns the record of a constant
+func (e *encoder) constant(c constant.Constant) (uint64, []uint64) {
+	switch c := c.(type) {
+	case *constant.Int:
+		return intRecord(c)
+	case *constant.Float:
+		return constFloat, []uint64{floatBits(c)}
+	case *constant.Null, *constant.ZeroInitializer:
+		return constNull, nil
+	case *constant.Undef:
+		return constUndef, nil
+	case *constant.Array:
+		if len(c.Elems) == 0 {
+			return constNull, nil
+		}
+		if str, ok := byteString(c); ok {
+			if n := len(str); n > 1 && str[n-1] == 0 {
+				return constCString, str[:n-1]
+			}
+			return constString, str
+		}
+		return constAggregate, e.ids(c.Elems)
+	case *constant.Struct:
+		if len(c.Fields) == 0 {
+			return constNull, nil
+		}
+		return constAggregate, e.ids(c.Fields)
+	case *constant.Vector:
+		return constAggregate, e.ids(c.Elems)
+	case *constant.Slice:
+		return constAggregate, []uint64{e.valueID(c.Data), e.valueID(c.Len)}
+	case *constant.ExprGetElementPtr:
+		ops := []uint64{e.types.id(c.Elem), e.types.id(c.Src.Type()), e.valueID(c.Src)}
+		for _, index := range c.Indices {
+			ops = append(ops, e.types.id(index.Type()), e.valueID(index))
+		}
+		return constInboundsGEP, ops
+	case *constant.ExprICmp:
+		return constCmp, []uint64{e.types.id(c.X.Type()), e.valueID(c.X), e.valueID(c.Y), intPred(ir.IntPred(c.Pred))}
+	case *constant.ExprFCmp:
+		return constCmp, []uint64{e.types.id(c.X.Type()), e.valueID(c.X), e.valueID(c.Y), floatPred(ir.FloatPred(c.Pred))}
+	case *constant.ExprSelect:
+		return constSelect, []uint64{e.valueID(c.Cond), e.valueID(c.X), e.valueID(c.Y)}
+	case *constant.ExprExtractElement:
+		return constExtractElt, []uint64{e.types.id(c.X.Type()), e.valueID(c.X), e.types.id(c.Index.Type()), e.valueID(c.Index)}
+	case *constant.ExprInsertElement:
+		return constInsertElt, []uint64{e.valueID(c.X), e.valueID(c.Elem), e.types.id(c.Index.Type()), e.valueID(c.Index)}
+	case *constant.ExprShuffleVector:
+		return constShuffleVector, []uint64{e.valueID(c.X), e.valueID(c.Y), e.valueID(c.Mask)}
+	case inlineAsm:
+		// the signature, the flags, of which only has side effects is set,
+		// then the assembly and the constraints, each after its length
+		ops := []uint64{e.types.id(c.Typ.(*types.PointerType).Elem), flag(c.SideEffect)}
+		ops = append(ops, uint64(len(c.Asm)))
+		ops = append(ops, chars(c.Asm)...)
+		ops = append(ops, uint64(len(c.Constraints)))
+		return constInlineAsm, append(ops, chars(c.Constraints)...)
+	}
+	if op, x, y, ok := binaryExpr(c); ok {
+		return constBinop, []uint64{op, e.valueID(x), e.valueID(y)}
+	}
+	if op, from, ok := castExpr(c); ok {
+		return constCast, []uint64{op, e.types.id(from.Type()), e.valueID(from)}
+	}
+	fail("unable to encode constant %T %s", c, c.Ident())
+	return 0, nil
+}
+
+// ids returns the IDs of constants
+func (e *encoder) ids(cs []constant.Constant) []uint64 {
+	ids := make([]uint64, len(cs))
+	for i, c := range cs {
+		ids[i] = e.valueID(c)
+	}
+	return ids
+}
+
+// linkage returns the encoding of a linkage
+func linkage(l ir.Linkage) uint64 {
+	switch l {
+	case ir.LinkageNone, ir.LinkageExternal:
+		return 0
+	case ir.LinkageAppending:
+		return 2
+	case ir.LinkageInternal:
+		return 3
+	case ir.LinkageExternWeak:
+		return 7
+	case ir.LinkageCommon:
+		return 8
+	case ir.LinkagePrivate:
+		return 9
+	case ir.LinkageAvailableExternally:
+		return 12
+	case ir.LinkageWeak:
+		return 16
+	case ir.LinkageWeakODR:
+		return 17
+	case ir.LinkageLinkOnce:
+		return 18
+	case ir.LinkageLinkOnceODR:
+		return 19
+	}
+	fail("unable to encode linkage %s", l)
+	return 0
+}
+
+// callConv returns the encoding of a calling convention
+func callConv(cc ir.CallConv) uint64 {
+	switch cc {
+	case ir.CallConvNone, ir.CallConvC:
+		return 0
+	case ir.CallConvFast:
+		return 8
+	case ir.CallConvCold:
+		return 9
+	}
+	fail("unable to encode calling convention %s", cc)
+	return 0
+}
+
+// alignment returns the encoding of an alignment in bytes, which is its
+// log2 plus one, or 0 if it isn't given
+func alignment(align int) uint64 {
+	if align <= 0 {
+		return 0
+	}
+	encoded := uint64(1)
+	for align > 1 {
+		align >>= 1
+		encoded++
+	}
+	return encoded
+}
+
+// chars returns the operands of a record that holds a string
+func chars(s string) []uint64 {
+	ops := make([]uint64, len(s))
+	for i := 0; i < len(s); i++ {
+		ops[i] = uint64(s[i])
+	}
+	return ops
+}
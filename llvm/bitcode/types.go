@@ -0,0 +1,247 @@
+package bitcode
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// A typeTable numbers the types of a module. Types are only compared by
+// their llvm representation, so the names of type aliases and the sign of
+// integers don't make types distinct.
+type typeTable struct {
+	types []types.Type
+	// opaque is set when the module has opaque pointers, which are all the
+	// same type
+	opaque bool
+	// ids maps the keys of types to their IDs. A named struct being
+	// numbered maps to -1.
+	ids map[string]int
+}
+
+func newTypeTable(opaque bool) typeTable {
+	return typeTable{opaque: opaque, ids: make(map[string]int)}
+}
+
+// id returns the ID of a type, adding it and the types it contains to the
+// table if it isn't in it yet
+func (t *typeTable) id(typ types.Type) uint64 {
+	t.add(typ)
+	return uint64(t.ids[typeKey(typ, t.opaque)])
+}
+
+// add adds a type to the table after the types it contains, which the
+// reader has to know first. Named structs can be referred to before they
+// are defined, which is what breaks the cycles of recursive types.
+func (t *typeTable) add(typ types.Type) {
+	key := typeKey(typ, t.opaque)
+	if _, found := t.ids[key]; found {
+		return
+	}
+	if s, ok := structOf(typ); ok && s.Identified() {
+		t.ids[key] = -1
+	}
+	for _, sub := range subtypes(typ, t.opaque) {
+		t.add(sub)
+	}
+	// a type that contains a named struct which contains it was added
+	// while adding the struct
+	if id, found := t.ids[key]; found && id >= 0 {
+		return
+	}
+	t.ids[key] = len(t.types)
+	t.types = append(t.types, typ)
+}
+
+// write writes the type table block
+func (t *typeTable) write(s *bitstream) {
+	s.enterBlock(blockType, 4)
+	s.record(typeNumEntry, uint64(len(t.types)))
+	for _, typ := range t.types {
+		t.writeType(s, typ)
+	}
+	s.exitBlock()
+}
+
+// writeType writes the record of one type
+func (t *typeTable) writeType(s *bitstream, typ types.Type) {
+	if st, ok := structOf(typ); ok {
+		if st.Identified() {
+			s.record(typeStructName, chars(st.Name)...)
+			if st.Opaque {
+				s.record(typeOpaque, 0)
+				return
+			}
+			s.record(typeStructNamed, append([]uint64{0}, t.idList(st.Fields)...)...)
+			return
+		}
+		s.record(typeStructAnon, append([]uint64{0}, t.idList(st.Fields)...)...)
+		return
+	}
+
+	switch typ := typ.(type) {
+	case *types.VoidType:
+		s.record(typeVoid)
+	case *types.LabelType:
+		s.record(typeLabel)
+	case *types.MetadataType:
+		s.record(typeMetadata)
+	case *types.IntType:
+		s.record(typeInteger, uint64(typ.Size))
+	case *types.FloatType:
+		s.record(floatTypeCode(typ))
+	case *types.PointerType:
+		if t.opaque {
+			s.record(typeOpaquePointer, uint64(typ.AddrSpace))
+			return
+		}
+		s.record(typePointer, t.id(typ.Elem), uint64(typ.AddrSpace))
+	case *types.ArrayType:
+		s.record(typeArray, uint64(typ.Len), t.id(typ.Elem))
+	case *types.VectorType:
+		s.record(typeVector, uint64(typ.Len), t.id(typ.Elem))
+	case *types.FuncType:
+		ops := []uint64{0, t.id(typ.Ret)}
+		if typ.Variadic {
+			ops[0] = 1
+		}
+		for _, param := range typ.Params {
+			ops = append(ops, t.id(param.Typ))
+		}
+		s.record(typeFunction, ops...)
+	default:
+		fail("unable to encode type %T %s", typ, typ)
+	}
+}
+
+// idList returns the IDs of a list of types
+func (t *typeTable) idList(list []types.Type) []uint64 {
+	ids := make([]uint64, len(list))
+	for i, typ := range list {
+		ids[i] = t.id(typ)
+	}
+	return ids
+}
+
+// floatTypeCode returns the record code of a floating-point type
+func floatTypeCode(t *types.FloatType) uint64 {
+	switch t.Kind {
+	case types.FloatKindIEEE_16:
+		return typeHalf
+	case types.FloatKindIEEE_32:
+		return typeFloat
+	case types.FloatKindIEEE_64:
+		return typeDouble
+	case types.FloatKindIEEE_128:
+		return typeFP128
+	case types.FloatKindDoubleExtended_80:
+		return typeX86FP80
+	case types.FloatKindDoubleDouble_128:
+		return typePPCFP128
+	}
+	fail("unable to encode floating-point type %s", t)
+	return 0
+}
+
+// structOf returns the struct a type is, slices being structs of their
+// data and length
+func structOf(t types.Type) (*types.StructType, bool) {
+	switch t := t.(type) {
+	case *types.StructType:
+		return t, true
+	case *types.SliceType:
+		return &t.StructType, true
+	}
+	return nil, false
+}
+
+// subtypes returns the types a type is made of, which pointers aren't if
+// they are opaque
+func subtypes(t types.Type, opaque bool) []types.Type {
+	if s, ok := structOf(t); ok {
+		return s.Fields
+	}
+	switch t := t.(type) {
+	case *types.PointerType:
+		if !opaque {
+			return []types.Type{t.Elem}
+		}
+	case *types.ArrayType:
+		return []types.Type{t.Elem}
+	case *types.VectorType:
+		return []types.Type{t.Elem}
+	case *types.FuncType:
+		subs := []types.Type{t.Ret}
+		for _, param := range t.Params {
+			subs = append(subs, param.Typ)
+		}
+		return subs
+	}
+	return nil
+}
+
+// typeKey returns a string that is the same for types llvm sees as the same
+func typeKey(t types.Type, opaque bool) string {
+	buf := &bytes.Buffer{}
+	writeTypeKey(buf, t, opaque)
+	return buf.String()
+}
+
+func writeTypeKey(buf *bytes.Buffer, t types.Type, opaque bool) {
+	if s, ok := structOf(t); ok {
+		if s.Identified() {
+			fmt.Fprintf(buf, "%%%s", s.Name)
+			return
+		}
+		buf.WriteString("{")
+		for i, field := range s.Fields {
+			if i != 0 {
+				buf.WriteString(",")
+			}
+			writeTypeKey(buf, field, opaque)
+		}
+		buf.WriteString("}")
+		return
+	}
+
+	switch t := t.(type) {
+	case *types.IntType:
+		fmt.Fprintf(buf, "i%d", t.Size)
+	case *types.FloatType:
+		buf.WriteString(t.Kind.String())
+	case *types.PointerType:
+		if opaque {
+			buf.WriteString("ptr")
+		} else {
+			writeTypeKey(buf, t.Elem, opaque)
+			buf.WriteString("*")
+		}
+		if t.AddrSpace != 0 {
+			fmt.Fprintf(buf, " addrspace(%d)", t.AddrSpace)
+		}
+	case *types.ArrayType:
+		fmt.Fprintf(buf, "[%d x ", t.Len)
+		writeTypeKey(buf, t.Elem, opaque)
+		buf.WriteString("]")
+	case *types.VectorType:
+		fmt.Fprintf(buf, "<%d x ", t.Len)
+		writeTypeKey(buf, t.Elem, opaque)
+		buf.WriteString(">")
+	case *types.FuncType:
+		writeTypeKey(buf, t.Ret, opaque)
+		buf.WriteString("(")
+		for i, param := range t.Params {
+			if i != 0 {
+				buf.WriteString(",")
+			}
+			writeTypeKey(buf, param.Typ, opaque)
+		}
+		if t.Variadic {
+			buf.WriteString(",...")
+		}
+		buf.WriteString(")")
+	default:
+		buf.WriteString(t.Def())
+	}
+}
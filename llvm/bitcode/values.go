@@ -0,0 +1,209 @@
+package bitcode
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/geode-lang/geode/llvm/internal/floats"
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// A valueTable numbers the values of the module or of a function
+type valueTable struct {
+	ids  map[value.Value]uint64
+	next uint64
+	// constants are the constants in the table, in the order of their IDs
+	constants []constant.Constant
+	// scalars maps the keys of simple constants to their IDs, so the many
+	// copies of the same constant share one
+	scalars map[string]uint64
+}
+
+// newValueTable returns a table whose first value has the ID first
+func newValueTable(first uint64) *valueTable {
+	return &valueTable{
+		ids:     make(map[value.Value]uint64),
+		next:    first,
+		scalars: make(map[string]uint64),
+	}
+}
+
+// add gives a value the next ID
+func (t *valueTable) add(v value.Value) {
+	t.ids[v] = t.next
+	t.next++
+}
+
+// lookup returns the ID of a value in the table
+func (t *valueTable) lookup(v value.Value) (uint64, bool) {
+	if id, found := t.ids[v]; found {
+		return id, true
+	}
+	if key, ok := scalarKey(v); ok {
+		id, found := t.scalars[key]
+		return id, found
+	}
+	return 0, false
+}
+
+// addConstant adds a constant to the table after the constants it is made
+// of. Globals, functions and constants that are already numbered are
+// skipped.
+func (t *valueTable) addConstant(c constant.Constant) {
+	switch c.(type) {
+	case *ir.Global, *ir.Function:
+		return
+	}
+	if _, found := t.lookup(c); found {
+		return
+	}
+	for _, op := range constantOperands(c) {
+		t.addConstant(op)
+	}
+	if key, ok := scalarKey(c); ok {
+		t.scalars[key] = t.next
+	}
+	t.constants = append(t.constants, c)
+	t.add(c)
+}
+
+// inlineAsm is the inline assembly a call calls. It isn't a constant in
+// package ir, but bitcode has it in the constants of the function.
+type inlineAsm struct {
+	*ir.InlineAsm
+}
+
+// Immutable implements constant.Constant
+func (inlineAsm) Immutable() {}
+
+// addInlineAsm adds inline assembly to the table, numbered as itself
+func (t *valueTable) addInlineAsm(asm *ir.InlineAsm) {
+	if _, found := t.ids[asm]; found {
+		return
+	}
+	t.constants = append(t.constants, inlineAsm{asm})
+	t.add(asm)
+}
+
+// constantOperands returns the constants a constant is made of
+func constantOperands(c constant.Constant) []constant.Constant {
+	switch c := c.(type) {
+	case *constant.Array:
+		if _, ok := byteString(c); ok {
+			return nil
+		}
+		return c.Elems
+	case *constant.Struct:
+		return c.Fields
+	case *constant.Vector:
+		return c.Elems
+	case *constant.Slice:
+		ops := []constant.Constant{c.Len}
+		if data, ok := c.Data.(constant.Constant); ok {
+			ops = append(ops, data)
+		}
+		return ops
+	case *constant.ExprGetElementPtr:
+		return append([]constant.Constant{c.Src}, c.Indices...)
+	case *constant.ExprICmp:
+		return []constant.Constant{c.X, c.Y}
+	case *constant.ExprFCmp:
+		return []constant.Constant{c.X, c.Y}
+	case *constant.ExprSelect:
+		return []constant.Constant{c.Cond, c.X, c.Y}
+	case *constant.ExprExtractElement:
+		return []constant.Constant{c.X, c.Index}
+	case *constant.ExprInsertElement:
+		return []constant.Constant{c.X, c.Elem, c.Index}
+	case *constant.ExprShuffleVector:
+		return []constant.Constant{c.X, c.Y, c.Mask}
+	}
+	if _, x, y, ok := binaryExpr(c); ok {
+		return []constant.Constant{x, y}
+	}
+	if _, from, ok := castExpr(c); ok {
+		return []constant.Constant{from}
+	}
+	return nil
+}
+
+// scalarKey returns a key that is the same for equal simple constants. The
+// key has the element types of pointers, even when they are opaque, which
+// only keeps a few equal constants from being shared.
+func scalarKey(v value.Value) (string, bool) {
+	switch v.(type) {
+	case *constant.Int, *constant.Float, *constant.Null, *constant.Undef, *constant.ZeroInitializer:
+		return typeKey(v.Type(), false) + " " + v.Ident(), true
+	}
+	return "", false
+}
+
+// intRecord returns the record of an integer constant. Its value is written
+// sign rotated, in 64-bit words when it is wider than that.
+func intRecord(c *constant.Int) (uint64, []uint64) {
+	x := new(big.Int).Set(c.X)
+	if c.Typ.Size <= 64 {
+		// values are truncated to the width of the type, so an unsigned
+		// value that doesn't fit an int64 is written as the same bits
+		if !x.IsInt64() {
+			x.SetUint64(x.Uint64())
+			return constInteger, []uint64{signRotate(int64(x.Uint64()))}
+		}
+		return constInteger, []uint64{signRotate(x.Int64())}
+	}
+
+	words := (c.Typ.Size + 63) / 64
+	// the two's complement bits of the value
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(words*64))
+	x.Mod(x, mod)
+	ops := make([]uint64, words)
+	mask := new(big.Int).SetUint64(math.MaxUint64)
+	for i := range ops {
+		word := new(big.Int).Rsh(x, uint(i*64))
+		ops[i] = signRotate(int64(word.And(word, mask).Uint64()))
+	}
+	return constWideInteger, ops
+}
+
+// signRotate returns a signed value with its sign moved to the lowest bit
+func signRotate(v int64) uint64 {
+	if v < 0 {
+		return uint64(-v)<<1 | 1
+	}
+	return uint64(v) << 1
+}
+
+// floatBits returns the bits of a floating-point constant
+func floatBits(c *constant.Float) uint64 {
+	x, _ := c.X.Float64()
+	switch c.Typ.Kind {
+	case types.FloatKindIEEE_16:
+		f, _ := floats.NewFloat16FromFloat64(x)
+		return uint64(f.Bits())
+	case types.FloatKindIEEE_32:
+		return uint64(math.Float32bits(float32(x)))
+	case types.FloatKindIEEE_64:
+		return math.Float64bits(x)
+	}
+	fail("unable to encode floating-point constant of type %s", c.Typ)
+	return 0
+}
+
+// byteString returns the bytes of an array of i8 integers
+func byteString(c *constant.Array) ([]uint64, bool) {
+	if t, ok := c.Typ.Elem.(*types.IntType); !ok || t.Size != 8 {
+		return nil, false
+	}
+	str := make([]uint64, len(c.Elems))
+	for i, elem := range c.Elems {
+		b, ok := elem.(*constant.Int)
+		if !ok {
+			return nil, false
+		}
+		str[i] = uint64(byte(b.X.Int64()))
+	}
+	return str, true
+}
@@ -0,0 +1,78 @@
+// === [ Atomic orderings and operations ] =====================================
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#ordering
+//    http://llvm.org/docs/LangRef.html#atomicrmw-instruction
+
+package ir
+
+import "fmt"
+
+// AtomicOrdering represents the set of orderings of atomic memory accesses.
+type AtomicOrdering uint
+
+// Atomic orderings.
+const (
+	OrderingNone      AtomicOrdering = iota // not atomic.
+	OrderingUnordered                       // unordered
+	OrderingMonotonic                       // monotonic
+	OrderingAcquire                         // acquire
+	OrderingRelease                         // release
+	OrderingAcqRel                          // acq_rel
+	OrderingSeqCst                          // seq_cst
+)
+
+// String returns the LLVM syntax representation of the atomic ordering.
+func (o AtomicOrdering) String() string {
+	m := map[AtomicOrdering]string{
+		OrderingUnordered: "unordered",
+		OrderingMonotonic: "monotonic",
+		OrderingAcquire:   "acquire",
+		OrderingRelease:   "release",
+		OrderingAcqRel:    "acq_rel",
+		OrderingSeqCst:    "seq_cst",
+	}
+	if s, ok := m[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown atomic ordering %d", uint(o))
+}
+
+// AtomicOp represents the set of operations of the atomicrmw instruction.
+type AtomicOp uint
+
+// Atomic read-modify-write operations.
+const (
+	AtomicOpXchg AtomicOp = iota // xchg
+	AtomicOpAdd                  // add
+	AtomicOpSub                  // sub
+	AtomicOpAnd                  // and
+	AtomicOpNand                 // nand
+	AtomicOpOr                   // or
+	AtomicOpXor                  // xor
+	AtomicOpMax                  // max
+	AtomicOpMin                  // min
+	AtomicOpUMax                 // umax
+	AtomicOpUMin                 // umin
+)
+
+// String returns the LLVM syntax representation of the atomic operation.
+func (op AtomicOp) String() string {
+	m := map[AtomicOp]string{
+		AtomicOpXchg: "xchg",
+		AtomicOpAdd:  "add",
+		AtomicOpSub:  "sub",
+		AtomicOpAnd:  "and",
+		AtomicOpNand: "nand",
+		AtomicOpOr:   "or",
+		AtomicOpXor:  "xor",
+		AtomicOpMax:  "max",
+		AtomicOpMin:  "min",
+		AtomicOpUMax: "umax",
+		AtomicOpUMin: "umin",
+	}
+	if s, ok := m[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown atomic operation %d", uint(op))
+}
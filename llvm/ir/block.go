@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/enc"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
@@ -307,6 +308,30 @@ func (block *BasicBlock) NewStore(src, dst value.Value) *InstStore {
 	return inst
 }
 
+// NewFence appends a new fence instruction to the basic block based on the
+// given atomic ordering.
+func (block *BasicBlock) NewFence(ordering AtomicOrdering) *InstFence {
+	inst := NewFence(ordering)
+	block.AppendInst(inst)
+	return inst
+}
+
+// NewCmpXchg appends a new cmpxchg instruction to the basic block based on the
+// given address, compare value, new value and atomic orderings.
+func (block *BasicBlock) NewCmpXchg(ptr, cmp, new value.Value, success, failure AtomicOrdering) *InstCmpXchg {
+	inst := NewCmpXchg(ptr, cmp, new, success, failure)
+	block.AppendInst(inst)
+	return inst
+}
+
+// NewAtomicRMW appends a new atomicrmw instruction to the basic block based on
+// the given operation, address, operand and atomic ordering.
+func (block *BasicBlock) NewAtomicRMW(op AtomicOp, dst, x value.Value, ordering AtomicOrdering) *InstAtomicRMW {
+	inst := NewAtomicRMW(op, dst, x, ordering)
+	block.AppendInst(inst)
+	return inst
+}
+
 // NewGetElementPtr appends a new getelementptr instruction to the basic block
 // based on the given source address and element indices.
 func (block *BasicBlock) NewGetElementPtr(src value.Value, indices ...value.Value) *InstGetElementPtr {
@@ -472,6 +497,14 @@ func (block *BasicBlock) NewCall(callee value.Value, args ...value.Value) *InstC
 	return inst
 }
 
+// NewLandingPad appends a new landingpad instruction to the basic block based
+// on the given result type and type infos of catch clauses.
+func (block *BasicBlock) NewLandingPad(typ types.Type, catches ...constant.Constant) *InstLandingPad {
+	inst := NewLandingPad(typ, catches...)
+	block.AppendInst(inst)
+	return inst
+}
+
 // --- [ Terminators ] ---------------------------------------------------------
 
 // NewRet sets the terminator of the basic block to a new ret terminator based
@@ -507,6 +540,22 @@ func (block *BasicBlock) NewSwitch(x value.Value, targetDefault *BasicBlock, cas
 	return term
 }
 
+// NewInvoke sets the terminator of the basic block to a new invoke terminator
+// based on the given callee, function arguments and target branches.
+func (block *BasicBlock) NewInvoke(callee value.Value, args []value.Value, normal, exception *BasicBlock) *TermInvoke {
+	term := NewInvoke(callee, args, normal, exception)
+	block.SetTerm(term)
+	return term
+}
+
+// NewResume sets the terminator of the basic block to a new resume terminator
+// based on the given exception.
+func (block *BasicBlock) NewResume(x value.Value) *TermResume {
+	term := NewResume(x)
+	block.SetTerm(term)
+	return term
+}
+
 // NewUnreachable sets the terminator of the basic block to a new unreachable
 // terminator.
 func (block *BasicBlock) NewUnreachable() *TermUnreachable {
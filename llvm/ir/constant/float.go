@@ -158,12 +158,10 @@ func (c *Float) Ident() string {
 			x, _ := c.X.Float64()
 			f16, _ := floats.NewFloat16FromFloat64(x)
 			return "0xH" + f16.String()
-		case types.FloatKindIEEE_32:
-			// TODO: Implement.
-			panic(fmt.Errorf("support for floating-point kind %v not yet implemented", kind))
-		case types.FloatKindIEEE_64:
-			// TODO: Implement.
-			panic(fmt.Errorf("support for floating-point kind %v not yet implemented", kind))
+		case types.FloatKindIEEE_32, types.FloatKindIEEE_64:
+			// Single precision constants are written as the double they
+			// widen to.
+			return fmt.Sprintf("0x%016X", math.Float64bits(c.Float64()))
 		default:
 			panic(fmt.Errorf("support for floating-point kind %v not yet implemented", kind))
 		}
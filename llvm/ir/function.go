@@ -8,11 +8,13 @@ package ir
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/geode-lang/geode/llvm/enc"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/metadata"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
@@ -37,6 +39,15 @@ type Function struct {
 	Sig *types.FuncType
 	// Calling convention.
 	CallConv CallConv
+	// Linkage type and visibility style.
+	Linkage    Linkage
+	Visibility Visibility
+	// String function attributes (e.g. "target-cpu"="haswell"), mapped from
+	// key to value.
+	Attrs map[string]string
+	// Personality function, which the unwinder calls to find the landing pads
+	// of the function; or nil if it has none.
+	Personality constant.Constant
 	// Basic blocks of the function; or nil if defined externally.
 	Blocks []*BasicBlock
 	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
@@ -55,6 +66,7 @@ func NewFunction(name string, ret types.Type, params ...*types.Param) *Function
 		Name:     name,
 		Typ:      typ,
 		Sig:      sig,
+		Attrs:    make(map[string]string),
 		Metadata: make(map[string]*metadata.Metadata),
 	}
 }
@@ -130,13 +142,27 @@ func (f *Function) String() string {
 	}
 	sig.WriteString(")")
 
+	// Function attributes.
+	keys := make([]string, 0, len(f.Attrs))
+	for key := range f.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(sig, ` "%s"="%s"`, enc.EscapeString(key), enc.EscapeString(f.Attrs[key]))
+	}
+
+	if f.Personality != nil && len(f.Blocks) > 0 {
+		fmt.Fprintf(sig, " personality %s %s", f.Personality.Type(), f.Personality.Ident())
+	}
+
 	// Metadata.
 	md := metadataString(f.Metadata, "")
 
 	// Function definition.
 	if len(f.Blocks) > 0 {
 		buf := &bytes.Buffer{}
-		fmt.Fprintf(buf, "define%s %s%s {\n", callconv, sig, md)
+		fmt.Fprintf(buf, "define%s%s %s%s {\n", linkageString(f.Linkage, f.Visibility), callconv, sig, md)
 		for _, block := range f.Blocks {
 			fmt.Fprintln(buf, block)
 		}
@@ -146,7 +172,7 @@ func (f *Function) String() string {
 	}
 
 	// External function declaration.
-	return fmt.Sprintf("declare%s%s %s", md, callconv, sig)
+	return fmt.Sprintf("declare%s%s%s %s", md, linkageString(f.Linkage, f.Visibility), callconv, sig)
 }
 
 // Params returns the parameters of the function.
@@ -233,6 +259,10 @@ func assignIDs(f *Function) {
 			// Assign local IDs to unnamed local variables.
 			setName(n)
 		}
+		// Invoke terminators have a value too.
+		if n, ok := block.Term.(value.Named); ok && !n.Type().Equal(types.Void) {
+			setName(n)
+		}
 	}
 }
 
@@ -355,6 +385,9 @@ type InlineAsm struct {
 	Constraints string
 	// Function signature or return type of the inline assembly.
 	Typ types.Type
+	// SideEffect is set if the assembly has effects not visible in its
+	// constraints, so it is never removed or moved.
+	SideEffect bool
 }
 
 // Type returns the type of the value.
@@ -364,5 +397,9 @@ func (asm *InlineAsm) Type() types.Type {
 
 // Ident returns the identifier associated with the value.
 func (asm *InlineAsm) Ident() string {
-	return fmt.Sprintf("asm %q, %q", asm.Asm, asm.Constraints)
+	sideeffect := ""
+	if asm.SideEffect {
+		sideeffect = " sideeffect"
+	}
+	return fmt.Sprintf(`asm%s "%s", "%s"`, sideeffect, enc.EscapeString(asm.Asm), enc.EscapeString(asm.Constraints))
 }
@@ -35,6 +35,13 @@ type Global struct {
 	Init constant.Constant
 	// Immutability of the global variable.
 	IsConst bool
+	// Linkage type and visibility style.
+	Linkage    Linkage
+	Visibility Visibility
+	// Section the global variable is placed in; or empty for the default.
+	Section string
+	// Alignment in bytes; or 0 if not specified.
+	Align int
 	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
 	// global.
 	Metadata map[string]*metadata.Metadata
@@ -106,22 +113,36 @@ func (global *Global) String() string {
 		fmt.Fprintf(addrspace, " addrspace(%d)", global.Typ.AddrSpace)
 	}
 
+	section := ""
+	if len(global.Section) > 0 {
+		section = fmt.Sprintf(", section %q", global.Section)
+	}
+
 	if global.Init != nil {
 		// Global variable definition.
-		return fmt.Sprintf("%s =%s %s %s %s%s",
+		return fmt.Sprintf("%s =%s%s %s %s %s%s%s%s",
 			global.Ident(),
+			linkageString(global.Linkage, global.Visibility),
 			addrspace,
 			imm,
 			global.Init.Type(),
 			global.Init.Ident(),
+			section,
+			alignString(global.Align),
 			md)
 
 	}
 	// External global variable declaration.
-	return fmt.Sprintf("%s = external%s %s %s%s",
+	linkage := global.Linkage
+	if linkage == LinkageNone {
+		linkage = LinkageExternal
+	}
+	return fmt.Sprintf("%s =%s%s %s %s%s%s",
 		global.Ident(),
+		linkageString(linkage, global.Visibility),
 		addrspace,
 		imm,
 		global.Content,
+		alignString(global.Align),
 		md)
 }
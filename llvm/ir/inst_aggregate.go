@@ -200,6 +200,11 @@ func aggregateElemType(t types.Type, indices []int64) (types.Type, error) {
 			return nil, errors.Errorf("invalid index (%d); exceeds struct field count (%d)", index, len(t.Fields))
 		}
 		return aggregateElemType(t.Fields[index], indices[1:])
+	case *types.SliceType:
+		if index >= int64(len(t.Fields)) {
+			return nil, errors.Errorf("invalid index (%d); exceeds slice field count (%d)", index, len(t.Fields))
+		}
+		return aggregateElemType(t.Fields[index], indices[1:])
 	default:
 		return nil, errors.Errorf("invalid aggregate value type; expected *types.ArrayType or *types.StructType, got %T", t)
 	}
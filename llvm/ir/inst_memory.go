@@ -33,6 +33,8 @@ type InstAlloca struct {
 	Elem types.Type
 	// Number of elements; or nil if one element.
 	NElems value.Value
+	// Alignment in bytes; or 0 if not specified.
+	Align int
 	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
 	// instruction.
 	Metadata map[string]*metadata.Metadata
@@ -73,16 +75,18 @@ func (inst *InstAlloca) SetName(name string) {
 func (inst *InstAlloca) String() string {
 	md := metadataString(inst.Metadata, ",")
 	if inst.NElems != nil {
-		return fmt.Sprintf("%s = alloca %s, %s %s%s",
+		return fmt.Sprintf("%s = alloca %s, %s %s%s%s",
 			inst.Ident(),
 			inst.Elem,
 			inst.NElems.Type(),
 			inst.NElems.Ident(),
+			alignString(inst.Align),
 			md)
 	}
-	return fmt.Sprintf("%s = alloca %s%s",
+	return fmt.Sprintf("%s = alloca %s%s%s",
 		inst.Ident(),
 		inst.Elem,
+		alignString(inst.Align),
 		md)
 }
 
@@ -111,6 +115,12 @@ type InstLoad struct {
 	Typ types.Type
 	// Source address.
 	Src value.Value
+	// Volatile memory access.
+	Volatile bool
+	// Atomic ordering; or OrderingNone if not atomic.
+	Ordering AtomicOrdering
+	// Alignment in bytes; or 0 if not specified.
+	Align int
 	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
 	// instruction.
 	Metadata map[string]*metadata.Metadata
@@ -153,11 +163,16 @@ func (inst *InstLoad) SetName(name string) {
 // String returns the LLVM syntax representation of the instruction.
 func (inst *InstLoad) String() string {
 	md := metadataString(inst.Metadata, ",")
-	return fmt.Sprintf("%s = load %s, %s %s%s",
+	atomic, ordering := atomicString(inst.Ordering)
+	return fmt.Sprintf("%s = load%s%s %s, %s %s%s%s%s",
 		inst.Ident(),
+		atomic,
+		volatileString(inst.Volatile),
 		inst.Type(),
 		inst.Src.Type(),
 		inst.Src.Ident(),
+		ordering,
+		alignString(inst.Align),
 		md)
 }
 
@@ -184,6 +199,12 @@ type InstStore struct {
 	Src value.Value
 	// Destination address.
 	Dst value.Value
+	// Volatile memory access.
+	Volatile bool
+	// Atomic ordering; or OrderingNone if not atomic.
+	Ordering AtomicOrdering
+	// Alignment in bytes; or 0 if not specified.
+	Align int
 	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
 	// instruction.
 	Metadata map[string]*metadata.Metadata
@@ -202,11 +223,16 @@ func NewStore(src, dst value.Value) *InstStore {
 // String returns the LLVM syntax representation of the instruction.
 func (inst *InstStore) String() string {
 	md := metadataString(inst.Metadata, ",")
-	return fmt.Sprintf("store %s %s, %s %s%s",
+	atomic, ordering := atomicString(inst.Ordering)
+	return fmt.Sprintf("store%s%s %s %s, %s %s%s%s%s",
+		atomic,
+		volatileString(inst.Volatile),
 		inst.Src.Type(),
 		inst.Src.Ident(),
 		inst.Dst.Type(),
 		inst.Dst.Ident(),
+		ordering,
+		alignString(inst.Align),
 		md)
 }
 
@@ -222,10 +248,244 @@ func (inst *InstStore) SetParent(parent *BasicBlock) {
 
 // --- [ fence ] ---------------------------------------------------------------
 
+// InstFence represents a fence instruction.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#fence-instruction
+type InstFence struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Atomic ordering.
+	Ordering AtomicOrdering
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// instruction.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewFence returns a new fence instruction based on the given atomic
+// ordering.
+func NewFence(ordering AtomicOrdering) *InstFence {
+	return &InstFence{
+		Ordering: ordering,
+		Metadata: make(map[string]*metadata.Metadata),
+	}
+}
+
+// String returns the LLVM syntax representation of the instruction.
+func (inst *InstFence) String() string {
+	md := metadataString(inst.Metadata, ",")
+	return fmt.Sprintf("fence %s%s", inst.Ordering, md)
+}
+
+// GetParent returns the parent basic block of the instruction.
+func (inst *InstFence) GetParent() *BasicBlock {
+	return inst.Parent
+}
+
+// SetParent sets the parent basic block of the instruction.
+func (inst *InstFence) SetParent(parent *BasicBlock) {
+	inst.Parent = parent
+}
+
 // --- [ cmpxchg ] -------------------------------------------------------------
 
+// InstCmpXchg represents a cmpxchg instruction. Its result is a struct of the
+// value loaded and whether it was replaced.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#cmpxchg-instruction
+type InstCmpXchg struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Name of the local variable associated with the instruction.
+	Name string
+	// Type of the instruction.
+	Typ *types.StructType
+	// Address to compare and exchange.
+	Ptr value.Value
+	// Value to compare against.
+	Cmp value.Value
+	// New value to store if the comparison succeeds.
+	New value.Value
+	// Atomic ordering if the comparison succeeds.
+	Success AtomicOrdering
+	// Atomic ordering if the comparison fails.
+	Failure AtomicOrdering
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// instruction.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewCmpXchg returns a new cmpxchg instruction based on the given address,
+// compare value, new value and atomic orderings.
+func NewCmpXchg(ptr, cmp, new value.Value, success, failure AtomicOrdering) *InstCmpXchg {
+	return &InstCmpXchg{
+		Typ:      types.NewStruct(cmp.Type(), types.I1),
+		Ptr:      ptr,
+		Cmp:      cmp,
+		New:      new,
+		Success:  success,
+		Failure:  failure,
+		Metadata: make(map[string]*metadata.Metadata),
+	}
+}
+
+// Type returns the type of the instruction.
+func (inst *InstCmpXchg) Type() types.Type {
+	return inst.Typ
+}
+
+// Ident returns the identifier associated with the instruction.
+func (inst *InstCmpXchg) Ident() string {
+	return enc.Local(inst.Name)
+}
+
+// GetName returns the name of the local variable associated with the
+// instruction.
+func (inst *InstCmpXchg) GetName() string {
+	return inst.Name
+}
+
+// SetName sets the name of the local variable associated with the instruction.
+func (inst *InstCmpXchg) SetName(name string) {
+	inst.Name = name
+}
+
+// String returns the LLVM syntax representation of the instruction.
+func (inst *InstCmpXchg) String() string {
+	md := metadataString(inst.Metadata, ",")
+	return fmt.Sprintf("%s = cmpxchg %s %s, %s %s, %s %s %s %s%s",
+		inst.Ident(),
+		inst.Ptr.Type(),
+		inst.Ptr.Ident(),
+		inst.Cmp.Type(),
+		inst.Cmp.Ident(),
+		inst.New.Type(),
+		inst.New.Ident(),
+		inst.Success,
+		inst.Failure,
+		md)
+}
+
+// GetParent returns the parent basic block of the instruction.
+func (inst *InstCmpXchg) GetParent() *BasicBlock {
+	return inst.Parent
+}
+
+// SetParent sets the parent basic block of the instruction.
+func (inst *InstCmpXchg) SetParent(parent *BasicBlock) {
+	inst.Parent = parent
+}
+
 // --- [ atomicrmw ] -----------------------------------------------------------
 
+// InstAtomicRMW represents an atomicrmw instruction. Its result is the value
+// at the address before it was modified.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#atomicrmw-instruction
+type InstAtomicRMW struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Name of the local variable associated with the instruction.
+	Name string
+	// Operation.
+	Op AtomicOp
+	// Address to modify.
+	Dst value.Value
+	// Operand.
+	X value.Value
+	// Atomic ordering.
+	Ordering AtomicOrdering
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// instruction.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewAtomicRMW returns a new atomicrmw instruction based on the given
+// operation, address, operand and atomic ordering.
+func NewAtomicRMW(op AtomicOp, dst, x value.Value, ordering AtomicOrdering) *InstAtomicRMW {
+	return &InstAtomicRMW{
+		Op:       op,
+		Dst:      dst,
+		X:        x,
+		Ordering: ordering,
+		Metadata: make(map[string]*metadata.Metadata),
+	}
+}
+
+// Type returns the type of the instruction.
+func (inst *InstAtomicRMW) Type() types.Type {
+	return inst.X.Type()
+}
+
+// Ident returns the identifier associated with the instruction.
+func (inst *InstAtomicRMW) Ident() string {
+	return enc.Local(inst.Name)
+}
+
+// GetName returns the name of the local variable associated with the
+// instruction.
+func (inst *InstAtomicRMW) GetName() string {
+	return inst.Name
+}
+
+// SetName sets the name of the local variable associated with the instruction.
+func (inst *InstAtomicRMW) SetName(name string) {
+	inst.Name = name
+}
+
+// String returns the LLVM syntax representation of the instruction.
+func (inst *InstAtomicRMW) String() string {
+	md := metadataString(inst.Metadata, ",")
+	return fmt.Sprintf("%s = atomicrmw %s %s %s, %s %s %s%s",
+		inst.Ident(),
+		inst.Op,
+		inst.Dst.Type(),
+		inst.Dst.Ident(),
+		inst.X.Type(),
+		inst.X.Ident(),
+		inst.Ordering,
+		md)
+}
+
+// GetParent returns the parent basic block of the instruction.
+func (inst *InstAtomicRMW) GetParent() *BasicBlock {
+	return inst.Parent
+}
+
+// SetParent sets the parent basic block of the instruction.
+func (inst *InstAtomicRMW) SetParent(parent *BasicBlock) {
+	inst.Parent = parent
+}
+
+// atomicString returns the atomic keyword and ordering of an atomic load or
+// store, or empty strings if it isn't atomic.
+func atomicString(ordering AtomicOrdering) (string, string) {
+	if ordering == OrderingNone {
+		return "", ""
+	}
+	return " atomic", " " + ordering.String()
+}
+
+// volatileString returns the volatile keyword of a memory access, or an
+// empty string if it isn't volatile.
+func volatileString(volatile bool) string {
+	if volatile {
+		return " volatile"
+	}
+	return ""
+}
+
+// alignString returns the alignment of a memory access, or an empty string if
+// it isn't specified.
+func alignString(align int) string {
+	if align == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", align %d", align)
+}
+
 // --- [ getelementptr ] -------------------------------------------------------
 
 // InstGetElementPtr represents a getelementptr instruction.
@@ -281,6 +541,12 @@ func NewGetElementPtr(src value.Value, indices ...value.Value) *InstGetElementPt
 				panic(fmt.Errorf("invalid index type for structure element; expected *constant.Int, got %T", index))
 			}
 			e = t.Fields[idx.Int64()]
+		case *types.SliceType:
+			idx, ok := index.(*constant.Int)
+			if !ok {
+				panic(fmt.Errorf("invalid index type for slice element; expected *constant.Int, got %T", index))
+			}
+			e = t.Fields[idx.Int64()]
 		default:
 			panic(fmt.Errorf("support for indexing element type %T not yet implemented", e))
 		}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/enc"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/metadata"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
@@ -571,6 +572,90 @@ func (inst *InstCall) SetParent(parent *BasicBlock) {
 
 // --- [ landingpad ] ----------------------------------------------------------
 
+// InstLandingPad represents a landingpad instruction, which starts the block
+// an invoke unwinds to. Its result is the exception being thrown and the
+// selector of the clause that caught it.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#landingpad-instruction
+type InstLandingPad struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Name of the local variable associated with the instruction.
+	Name string
+	// Type of the instruction.
+	Typ types.Type
+	// Cleanup is set when the landing pad is entered even if no clause
+	// catches the exception.
+	Cleanup bool
+	// Type infos of the catch clauses; a null type info catches every
+	// exception.
+	Catches []constant.Constant
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// instruction.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewLandingPad returns a new landingpad instruction based on the given result
+// type and type infos of catch clauses.
+func NewLandingPad(typ types.Type, catches ...constant.Constant) *InstLandingPad {
+	return &InstLandingPad{
+		Typ:      typ,
+		Catches:  catches,
+		Metadata: make(map[string]*metadata.Metadata),
+	}
+}
+
+// Type returns the type of the instruction.
+func (inst *InstLandingPad) Type() types.Type {
+	return inst.Typ
+}
+
+// Ident returns the identifier associated with the instruction.
+func (inst *InstLandingPad) Ident() string {
+	return enc.Local(inst.Name)
+}
+
+// GetName returns the name of the local variable associated with the
+// instruction.
+func (inst *InstLandingPad) GetName() string {
+	return inst.Name
+}
+
+// SetName sets the name of the local variable associated with the instruction.
+func (inst *InstLandingPad) SetName(name string) {
+	inst.Name = name
+}
+
+// String returns the LLVM syntax representation of the instruction.
+func (inst *InstLandingPad) String() string {
+	clauses := &bytes.Buffer{}
+	if inst.Cleanup {
+		clauses.WriteString(" cleanup")
+	}
+	for _, c := range inst.Catches {
+		fmt.Fprintf(clauses, " catch %s %s",
+			c.Type(),
+			c.Ident())
+	}
+	md := metadataString(inst.Metadata, ",")
+	return fmt.Sprintf("%s = landingpad %s%s%s",
+		inst.Ident(),
+		inst.Typ,
+		clauses,
+		md)
+}
+
+// GetParent returns the parent basic block of the instruction.
+func (inst *InstLandingPad) GetParent() *BasicBlock {
+	return inst.Parent
+}
+
+// SetParent sets the parent basic block of the instruction.
+func (inst *InstLandingPad) SetParent(parent *BasicBlock) {
+	inst.Parent = parent
+}
+
 // --- [ catchpad ] ------------------------------------------------------------
 
 // --- [ cleanuppad ] ----------------------------------------------------------
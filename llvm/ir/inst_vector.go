@@ -205,9 +205,12 @@ func NewShuffleVector(x, y, mask value.Value) *InstShuffleVector {
 	}
 }
 
-// Type returns the type of the instruction.
+// Type returns the type of the instruction. It has the elements of the
+// vectors shuffled and the length of the mask.
 func (inst *InstShuffleVector) Type() types.Type {
-	return inst.Mask.Type()
+	x := inst.X.Type().(*types.VectorType)
+	mask := inst.Mask.Type().(*types.VectorType)
+	return types.NewVector(x.Elem, mask.Len)
 }
 
 // Ident returns the identifier associated with the instruction.
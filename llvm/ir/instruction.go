@@ -65,6 +65,9 @@ import "fmt"
 //    *ir.InstAlloca          (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstAlloca)
 //    *ir.InstLoad            (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstLoad)
 //    *ir.InstStore           (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstStore)
+//    *ir.InstFence           (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstFence)
+//    *ir.InstCmpXchg         (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstCmpXchg)
+//    *ir.InstAtomicRMW       (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstAtomicRMW)
 //    *ir.InstGetElementPtr   (https://godoc.org/github.com/geode-lang/geode/llvm/ir#InstGetElementPtr)
 //
 // Conversion instructions
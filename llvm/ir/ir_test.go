@@ -46,6 +46,9 @@ var (
 	_ ir.Instruction = &ir.InstAlloca{}
 	_ ir.Instruction = &ir.InstLoad{}
 	_ ir.Instruction = &ir.InstStore{}
+	_ ir.Instruction = &ir.InstFence{}
+	_ ir.Instruction = &ir.InstCmpXchg{}
+	_ ir.Instruction = &ir.InstAtomicRMW{}
 	_ ir.Instruction = &ir.InstGetElementPtr{}
 	// Conversion instructions
 	_ ir.Instruction = &ir.InstTrunc{}
@@ -67,6 +70,7 @@ var (
 	_ ir.Instruction = &ir.InstPhi{}
 	_ ir.Instruction = &ir.InstSelect{}
 	_ ir.Instruction = &ir.InstCall{}
+	_ ir.Instruction = &ir.InstLandingPad{}
 )
 
 // Validate that the relevant types satisfy the ir.Terminator interface.
@@ -76,6 +80,7 @@ var (
 	_ ir.Terminator = &ir.TermBr{}
 	_ ir.Terminator = &ir.TermCondBr{}
 	_ ir.Terminator = &ir.TermSwitch{}
+	_ ir.Terminator = &ir.TermInvoke{}
 	_ ir.Terminator = &ir.TermUnreachable{}
 )
 
@@ -114,6 +119,8 @@ var (
 	// Memory instructions
 	_ value.Named = &ir.InstAlloca{}
 	_ value.Named = &ir.InstLoad{}
+	_ value.Named = &ir.InstCmpXchg{}
+	_ value.Named = &ir.InstAtomicRMW{}
 	_ value.Named = &ir.InstGetElementPtr{}
 	// Conversion instructions
 	_ value.Named = &ir.InstTrunc{}
@@ -135,6 +142,9 @@ var (
 	_ value.Named = &ir.InstPhi{}
 	_ value.Named = &ir.InstSelect{}
 	_ value.Named = &ir.InstCall{}
+	_ value.Named = &ir.InstLandingPad{}
+	// Terminators
+	_ value.Named = &ir.TermInvoke{}
 )
 
 // Validate that the relevant types satisfy the ir.MetadataNode interface.
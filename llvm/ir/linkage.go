@@ -0,0 +1,86 @@
+// === [ Linkage and visibility ] ==============================================
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#linkage-types
+//    http://llvm.org/docs/LangRef.html#visibility-styles
+
+package ir
+
+import "fmt"
+
+// Linkage represents the set of linkage types of global values.
+type Linkage uint
+
+// Linkage types.
+const (
+	LinkageNone                Linkage = iota // no linkage specified, the same as external.
+	LinkageAppending                          // appending
+	LinkageAvailableExternally                // available_externally
+	LinkageCommon                             // common
+	LinkageExternal                           // external
+	LinkageExternWeak                         // extern_weak
+	LinkageInternal                           // internal
+	LinkageLinkOnce                           // linkonce
+	LinkageLinkOnceODR                        // linkonce_odr
+	LinkagePrivate                            // private
+	LinkageWeak                               // weak
+	LinkageWeakODR                            // weak_odr
+)
+
+// String returns the LLVM syntax representation of the linkage type.
+func (l Linkage) String() string {
+	m := map[Linkage]string{
+		LinkageAppending:           "appending",
+		LinkageAvailableExternally: "available_externally",
+		LinkageCommon:              "common",
+		LinkageExternal:            "external",
+		LinkageExternWeak:          "extern_weak",
+		LinkageInternal:            "internal",
+		LinkageLinkOnce:            "linkonce",
+		LinkageLinkOnceODR:         "linkonce_odr",
+		LinkagePrivate:             "private",
+		LinkageWeak:                "weak",
+		LinkageWeakODR:             "weak_odr",
+	}
+	if s, ok := m[l]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown linkage %d", uint(l))
+}
+
+// Visibility represents the set of visibility styles of global values.
+type Visibility uint
+
+// Visibility styles.
+const (
+	VisibilityDefault   Visibility = iota // default
+	VisibilityHidden                      // hidden
+	VisibilityProtected                   // protected
+)
+
+// String returns the LLVM syntax representation of the visibility style.
+func (v Visibility) String() string {
+	m := map[Visibility]string{
+		VisibilityDefault:   "default",
+		VisibilityHidden:    "hidden",
+		VisibilityProtected: "protected",
+	}
+	if s, ok := m[v]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown visibility %d", uint(v))
+}
+
+// linkageString returns the linkage and visibility of a global value as
+// they are written before its type, with a leading space, or nothing if
+// both are the default.
+func linkageString(linkage Linkage, visibility Visibility) string {
+	s := ""
+	if linkage != LinkageNone {
+		s += " " + linkage.String()
+	}
+	if visibility != VisibilityDefault {
+		s += " " + visibility.String()
+	}
+	return s
+}
@@ -41,6 +41,28 @@ type Metadata struct {
 	ID string
 	// Metadata nodes.
 	Nodes []Node
+	// Kind of a specialized metadata node, like DILocation; or empty if
+	// metadata tuple. Specialized nodes have fields instead of nodes.
+	Kind string
+	// Fields of a specialized metadata node.
+	Fields []Field
+	// Distinct nodes are never merged with other nodes of the same contents.
+	Distinct bool
+}
+
+// NewSpecialized returns a new specialized metadata node of the given kind,
+// like DILocation, with the given fields.
+func NewSpecialized(kind string, fields ...Field) *Metadata {
+	return &Metadata{Kind: kind, Fields: fields}
+}
+
+// Field is a field of a specialized metadata node.
+type Field struct {
+	// Field name.
+	Name string
+	// Field value, as it is written in LLVM IR; like 3, !"x", !4 or
+	// DW_ATE_signed.
+	Val string
 }
 
 // Type returns the type of the metadata.
@@ -59,6 +81,20 @@ func (md *Metadata) Ident() string {
 // Def returns the LLVM syntax representation of the definition of the metadata.
 func (md *Metadata) Def() string {
 	buf := &bytes.Buffer{}
+	if md.Distinct {
+		buf.WriteString("distinct ")
+	}
+	if len(md.Kind) > 0 {
+		fmt.Fprintf(buf, "!%s(", md.Kind)
+		for i, field := range md.Fields {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(buf, "%s: %s", field.Name, field.Val)
+		}
+		buf.WriteString(")")
+		return buf.String()
+	}
 	buf.WriteString("!{")
 	for i, node := range md.Nodes {
 		if i != 0 {
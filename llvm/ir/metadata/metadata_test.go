@@ -1,9 +1,30 @@
 package metadata_test
 
-import "github.com/geode-lang/geode/llvm/ir/metadata"
+import (
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+)
 
 // Validate that the relevant types satisfy the metadata.Node interface.
 var (
 	_ metadata.Node = &metadata.Metadata{}
 	_ metadata.Node = &metadata.String{}
 )
+
+func TestSpecializedDef(t *testing.T) {
+	md := metadata.NewSpecialized("DILocation",
+		metadata.Field{Name: "line", Val: "3"},
+		metadata.Field{Name: "scope", Val: "!4"})
+	if got, want := md.Def(), "!DILocation(line: 3, scope: !4)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	md.Distinct = true
+	if got, want := md.Def(), "distinct !DILocation(line: 3, scope: !4)"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	md.ID = "5"
+	if got, want := md.Ident(), "!5"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
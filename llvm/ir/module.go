@@ -6,6 +6,12 @@
 //    http://llvm.org/docs/LangRef.html#module-structure
 
 // Package ir declares the types used to represent LLVM IR modules.
+//
+// It is a fork of an early version of github.com/llir/llvm, which geode's
+// codegen is still written against. Modules can be printed with opaque
+// pointers for LLVM 17 and later, see types.WithOpaquePointers, but moving
+// to upstream llir/llvm, with its changed value API and metadata model, is
+// still to be done.
 package ir
 
 import (
@@ -35,6 +41,10 @@ type Module struct {
 	NamedMetadata []*metadata.Named
 	// Metadata of the module.
 	Metadata []*metadata.Metadata
+	// OpaquePointers makes the module use the opaque `ptr` type of LLVM 15
+	// and later, which no longer accept typed pointers, in place of every
+	// pointer type.
+	OpaquePointers bool
 }
 
 // NewModule returns a new LLVM IR module.
@@ -44,6 +54,16 @@ func NewModule() *Module {
 
 // String returns the LLVM syntax representation of the module.
 func (m *Module) String() string {
+	var s string
+	types.WithOpaquePointers(m.OpaquePointers, func() {
+		s = m.def()
+	})
+	return s
+}
+
+// def returns the LLVM syntax representation of the module, with pointer
+// types printed as they are set to be.
+func (m *Module) def() string {
 	buf := &bytes.Buffer{}
 	if len(m.DataLayout) > 0 {
 		fmt.Fprintf(buf, "target datalayout = %q\n", m.DataLayout)
@@ -9,8 +9,10 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/geode-lang/geode/llvm/enc"
 	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
 
@@ -26,6 +28,8 @@ import (
 //    *ir.TermBr            (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermBr)
 //    *ir.TermCondBr        (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermCondBr)
 //    *ir.TermSwitch        (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermSwitch)
+//    *ir.TermInvoke        (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermInvoke)
+//    *ir.TermResume        (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermResume)
 //    *ir.TermUnreachable   (https://godoc.org/github.com/geode-lang/geode/llvm/ir#TermUnreachable)
 type Terminator interface {
 	Instruction
@@ -296,8 +300,182 @@ func NewCase(x *constant.Int, target *BasicBlock) *Case {
 
 // --- [ invoke ] --------------------------------------------------------------
 
+// TermInvoke represents an invoke terminator, a call that continues in the
+// normal block when the callee returns and in the exception block, which
+// starts with a landingpad, when an exception unwinds out of it.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#invoke-instruction
+type TermInvoke struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Name of the local variable associated with the terminator.
+	Name string
+	// Callee, which may have the same underlying types as the callee of a
+	// call instruction.
+	Callee value.Value
+	// Callee signature.
+	Sig *types.FuncType
+	// Function arguments.
+	Args []value.Value
+	// Calling convention.
+	CallConv CallConv
+	// Target branch when the callee returns.
+	Normal *BasicBlock
+	// Target branch when an exception is thrown.
+	Exception *BasicBlock
+	// Successors basic blocks.
+	Successors []*BasicBlock
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// terminator.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewInvoke returns a new invoke terminator based on the given callee,
+// function arguments and target branches.
+func NewInvoke(callee value.Value, args []value.Value, normal, exception *BasicBlock) *TermInvoke {
+	typ, ok := callee.Type().(*types.PointerType)
+	if !ok {
+		panic(fmt.Errorf("invalid callee type, expected *types.PointerType, got %T", callee.Type()))
+	}
+	sig, ok := typ.Elem.(*types.FuncType)
+	if !ok {
+		panic(fmt.Errorf("invalid callee signature type, expected *types.FuncType, got %T", typ.Elem))
+	}
+	successors := []*BasicBlock{normal, exception}
+	return &TermInvoke{
+		Callee:     callee,
+		Sig:        sig,
+		Args:       args,
+		Normal:     normal,
+		Exception:  exception,
+		Successors: successors,
+		Metadata:   make(map[string]*metadata.Metadata),
+	}
+}
+
+// Type returns the type of the terminator.
+func (term *TermInvoke) Type() types.Type {
+	return term.Sig.Ret
+}
+
+// Ident returns the identifier associated with the terminator.
+func (term *TermInvoke) Ident() string {
+	return enc.Local(term.Name)
+}
+
+// GetName returns the name of the local variable associated with the
+// terminator.
+func (term *TermInvoke) GetName() string {
+	return term.Name
+}
+
+// SetName sets the name of the local variable associated with the terminator.
+func (term *TermInvoke) SetName(name string) {
+	term.Name = name
+}
+
+// String returns the LLVM syntax representation of the terminator.
+func (term *TermInvoke) String() string {
+	ident := &bytes.Buffer{}
+	if !term.Type().Equal(types.Void) {
+		fmt.Fprintf(ident, "%s = ", term.Ident())
+	}
+	callconv := &bytes.Buffer{}
+	if term.CallConv != CallConvNone {
+		fmt.Fprintf(callconv, " %s", term.CallConv)
+	}
+	// Print callee signature instead of return type for variadic callees.
+	ret := term.Sig.Ret.String()
+	if term.Sig.Variadic {
+		ret = term.Sig.String()
+	}
+	args := &bytes.Buffer{}
+	for i, arg := range term.Args {
+		if i != 0 {
+			args.WriteString(", ")
+		}
+		fmt.Fprintf(args, "%s %s",
+			arg.Type(),
+			arg.Ident())
+	}
+	md := metadataString(term.Metadata, ",")
+	return fmt.Sprintf("%sinvoke%s %s %s(%s) to label %s unwind label %s%s",
+		ident,
+		callconv,
+		ret,
+		term.Callee.Ident(),
+		args,
+		term.Normal.Ident(),
+		term.Exception.Ident(),
+		md)
+}
+
+// GetParent returns the parent basic block of the terminator.
+func (term *TermInvoke) GetParent() *BasicBlock {
+	return term.Parent
+}
+
+// SetParent sets the parent basic block of the terminator.
+func (term *TermInvoke) SetParent(parent *BasicBlock) {
+	term.Parent = parent
+}
+
+// Succs returns the successor basic blocks of the terminator.
+func (term *TermInvoke) Succs() []*BasicBlock {
+	return term.Successors
+}
+
 // --- [ resume ] --------------------------------------------------------------
 
+// TermResume represents a resume terminator, which continues unwinding an
+// exception a landingpad stopped at.
+//
+// References:
+//    http://llvm.org/docs/LangRef.html#resume-instruction
+type TermResume struct {
+	// Parent basic block.
+	Parent *BasicBlock
+	// Exception, the value of the landingpad.
+	X value.Value
+	// Map from metadata identifier (e.g. !dbg) to metadata associated with the
+	// terminator.
+	Metadata map[string]*metadata.Metadata
+}
+
+// NewResume returns a new resume terminator based on the given exception.
+func NewResume(x value.Value) *TermResume {
+	return &TermResume{
+		X:        x,
+		Metadata: make(map[string]*metadata.Metadata),
+	}
+}
+
+// String returns the LLVM syntax representation of the terminator.
+func (term *TermResume) String() string {
+	md := metadataString(term.Metadata, ",")
+	return fmt.Sprintf("resume %s %s%s",
+		term.X.Type(),
+		term.X.Ident(),
+		md)
+}
+
+// GetParent returns the parent basic block of the terminator.
+func (term *TermResume) GetParent() *BasicBlock {
+	return term.Parent
+}
+
+// SetParent sets the parent basic block of the terminator.
+func (term *TermResume) SetParent(parent *BasicBlock) {
+	term.Parent = parent
+}
+
+// Succs returns the successor basic blocks of the terminator.
+func (term *TermResume) Succs() []*BasicBlock {
+	// resume terminators have no successors.
+	return nil
+}
+
 // --- [ catchswitch ] ---------------------------------------------------------
 
 // --- [ catchret ] ------------------------------------------------------------
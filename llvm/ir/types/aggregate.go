@@ -137,10 +137,18 @@ func (t *StructType) Equal(u Type) bool {
 		if t.Identified() || u.Identified() {
 			return t.Name == u.Name
 		}
-		// Literal struct types are uniqued by structural identity.
+		// Literal struct types are uniqued by structural identity. When both
+		// name their fields, the names are part of it.
 		if len(t.Fields) != len(u.Fields) {
 			return false
 		}
+		if len(t.Names) > 0 && len(u.Names) > 0 {
+			for i, name := range t.Names {
+				if i >= len(u.Names) || u.Names[i] != name {
+					return false
+				}
+			}
+		}
 		for i, tf := range t.Fields {
 			uf := u.Fields[i]
 			if !tf.Equal(uf) {
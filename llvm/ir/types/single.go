@@ -7,6 +7,8 @@ package types
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/geode-lang/geode/llvm/enc"
 )
@@ -22,6 +24,9 @@ type IntType struct {
 	Name string
 	// Bit size.
 	Size int
+	// Unsigned integers are the same type to llvm, but are compared,
+	// divided and widened without their sign.
+	Unsigned bool
 }
 
 // NewInt returns a new integer type based on the given bit size.
@@ -29,6 +34,12 @@ func NewInt(size int) *IntType {
 	return &IntType{Size: size}
 }
 
+// NewUnsignedInt returns a new unsigned integer type based on the given bit
+// size.
+func NewUnsignedInt(size int) *IntType {
+	return &IntType{Size: size, Unsigned: true}
+}
+
 // String returns the LLVM syntax representation of the type.
 func (t *IntType) String() string {
 	if len(t.Name) > 0 {
@@ -45,7 +56,7 @@ func (t *IntType) Def() string {
 // Equal reports whether t and u are of equal type.
 func (t *IntType) Equal(u Type) bool {
 	if u, ok := u.(*IntType); ok {
-		return t.Size == u.Size
+		return t.Size == u.Size && t.Unsigned == u.Unsigned
 	}
 	return false
 }
@@ -167,6 +178,27 @@ func (kind FloatKind) Size() int {
 
 // --- [ pointer ] -------------------------------------------------------------
 
+// opaquePointers makes every pointer type print as the opaque `ptr` type of
+// LLVM 15 and later, which no longer accept typed pointers, while fn of
+// WithOpaquePointers runs. Pointer types still keep their element type,
+// which instructions print explicitly.
+var (
+	opaqueMu       sync.Mutex
+	opaquePointers atomic.Bool
+)
+
+// WithOpaquePointers calls fn with pointer types printing as `ptr` if
+// opaque is set, as a module with opaque pointers is printed. Calls are
+// run one at a time, so modules with and without them can be printed at
+// once.
+func WithOpaquePointers(opaque bool, fn func()) {
+	opaqueMu.Lock()
+	defer opaqueMu.Unlock()
+	opaquePointers.Store(opaque)
+	defer opaquePointers.Store(false)
+	fn()
+}
+
 // PointerType represents a pointer type.
 //
 // References:
@@ -195,6 +227,12 @@ func (t *PointerType) String() string {
 
 // Def returns the LLVM syntax representation of the definition of the type.
 func (t *PointerType) Def() string {
+	if opaquePointers.Load() {
+		if t.AddrSpace != 0 {
+			return fmt.Sprintf("ptr addrspace(%d)", t.AddrSpace)
+		}
+		return "ptr"
+	}
 	if t.AddrSpace != 0 {
 		return fmt.Sprintf("%s addrspace(%d)*", t.Elem, t.AddrSpace)
 	}
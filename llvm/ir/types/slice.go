@@ -18,9 +18,12 @@ type SliceType struct {
 
 // NewSlice returns a new struct type based on the given struct fields.
 func NewSlice(elem Type) *SliceType {
-	return &SliceType{
+	t := &SliceType{
 		Elem: elem,
 	}
+	t.Fields = []Type{NewPointer(elem), I64}
+	t.Names = []string{"data", "len"}
+	return t
 }
 
 // String returns the LLVM syntax representation of the type.
@@ -82,7 +85,7 @@ func (t *SliceType) Identified() bool {
 // ByteCount returns the byte size of the type.
 func (t *SliceType) ByteCount() int {
 	var size int
-	size += t.Elem.ByteCount()
+	size += NewPointer(t.Elem).ByteCount()
 	size += I64.ByteCount()
 	return size
 }
@@ -52,6 +52,16 @@ var (
 	I64 = NewInt(64)
 	// I128 represents the `i128` integer type.
 	I128 = NewInt(128)
+	// U8 represents the `i8` integer type, without a sign.
+	U8 = NewUnsignedInt(8)
+	// U16 represents the `i16` integer type, without a sign.
+	U16 = NewUnsignedInt(16)
+	// U32 represents the `i32` integer type, without a sign.
+	U32 = NewUnsignedInt(32)
+	// U64 represents the `i64` integer type, without a sign.
+	U64 = NewUnsignedInt(64)
+	// U128 represents the `i128` integer type, without a sign.
+	U128 = NewUnsignedInt(128)
 	// Half represents the `half` floating-point type.
 	Half = &FloatType{Kind: FloatKindIEEE_16}
 	// Float represents the `float` floating-point type.
@@ -107,6 +117,14 @@ func IsInt(t Type) bool {
 	return ok
 }
 
+// IsUnsigned reports whether the given type is an unsigned integer type.
+func IsUnsigned(t Type) bool {
+	if t, ok := t.(*IntType); ok {
+		return t.Unsigned
+	}
+	return false
+}
+
 // IsFloat reports whether the given type is a floating-point type.
 func IsFloat(t Type) bool {
 	_, ok := t.(*FloatType)
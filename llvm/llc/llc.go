@@ -0,0 +1,138 @@
+// Package llc compiles LLVM IR modules into native object files with llc,
+// optimizing them with opt first.
+//
+// The module is piped to the tools as bitcode, so no intermediate file is
+// written and no compiler driver is involved. The tools are looked up in
+// LLC and OPT, then on the PATH by their name or one of their versioned
+// names.
+package llc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/bitcode"
+	"github.com/geode-lang/geode/llvm/ir"
+)
+
+// Options are the options an object file is compiled with.
+type Options struct {
+	// Target is the target triple to compile for. The triple of the module
+	// is used when it is empty, and llc's default target when both are.
+	Target string
+	// Optimize is the optimization level, from 0 to 3, or s or z to
+	// optimize for size. Levels above 0 run the optimization pipeline of
+	// opt, which inlines, promotes allocas to registers, numbers values
+	// and removes dead code, before llc compiles the module.
+	Optimize string
+}
+
+// versions are the llvm versions the tools are installed with a suffix
+// for, newest first
+var versions = []string{"18", "17", "16", "15", "14", "13", "12", "11"}
+
+// find returns the path of an llvm tool
+func find(name, env string) (string, error) {
+	if path := os.Getenv(env); path != "" {
+		return path, nil
+	}
+	names := []string{name}
+	for _, version := range versions {
+		names = append(names, name+"-"+version)
+	}
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found, install llvm or set %s to its path", name, env)
+}
+
+// Path returns the path of the llc to run.
+func Path() (string, error) {
+	return find("llc", "LLC")
+}
+
+// OptPath returns the path of the opt to run.
+func OptPath() (string, error) {
+	return find("opt", "OPT")
+}
+
+// CodegenLevel returns the level llc compiles with for an optimization
+// level. llc has no size levels, which optimize the code like level 2.
+func CodegenLevel(level string) string {
+	switch level {
+	case "1", "2", "3":
+		return level
+	case "s", "z":
+		return "2"
+	}
+	return "0"
+}
+
+// optimizes returns whether an optimization level runs opt
+func optimizes(level string) bool {
+	return level != "" && level != "0"
+}
+
+// llcArgs returns the flags llc is run with to compile an object file to
+// out, reading bitcode from stdin
+func llcArgs(opts Options, out string) []string {
+	args := []string{
+		"-O" + CodegenLevel(opts.Optimize),
+		"-filetype=obj",
+		// executables are position independent by default on most systems
+		"-relocation-model=pic",
+	}
+	if opts.Target != "" {
+		args = append(args, "-mtriple="+opts.Target)
+	}
+	return append(args, "-o", out, "-")
+}
+
+// Compile compiles a module into an object file at out.
+func Compile(m *ir.Module, out string, opts Options) error {
+	llc, err := Path()
+	if err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	if err := bitcode.Encode(buf, m); err != nil {
+		return err
+	}
+	input := buf.Bytes()
+
+	if optimizes(opts.Optimize) {
+		opt, err := OptPath()
+		if err != nil {
+			return err
+		}
+		args := []string{"-O" + opts.Optimize}
+		if opts.Target != "" {
+			args = append(args, "-mtriple="+opts.Target)
+		}
+		if input, err = run(opt, append(args, "-o", "-", "-"), input); err != nil {
+			return err
+		}
+	}
+
+	_, err = run(llc, llcArgs(opts, out), input)
+	return err
+}
+
+// run runs a tool with its standard input read from stdin, returning its
+// standard output
+func run(tool string, args []string, stdin []byte) ([]byte, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(tool, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run command `%s %s`: `%s`\n\n%s", tool, strings.Join(args, " "), err, stderr)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package llc_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/llc"
+)
+
+func TestCompile(t *testing.T) {
+	if _, err := llc.Path(); err != nil {
+		t.Skip(err)
+	}
+	m := ir.NewModule()
+	m.TargetTriple = "x86_64-unknown-linux-gnu"
+	answer := m.NewFunction("answer", types.I32)
+	answer.NewBlock("").NewRet(constant.NewInt(42, types.I32))
+
+	dir, err := ioutil.TempDir("", "llc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "answer.o")
+	if err := llc.Compile(m, out, llc.Options{Optimize: "2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(obj, []byte("\x7fELF")) {
+		t.Fatalf("object file starts with %q, not the elf magic number", obj[:4])
+	}
+	if !bytes.Contains(obj, []byte("answer")) {
+		t.Error("object file has no symbol answer")
+	}
+}
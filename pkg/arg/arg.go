@@ -2,35 +2,57 @@ package arg
 
 import (
 	"os"
+	"strings"
 
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
+// DefaultOutput is the binary programs are built into without --output
+const DefaultOutput = "a.out"
+
 // Primary globally valid commands and arguments
 var (
 	App                   = kingpin.New("geode", "Compiler for the Geode Programming Language").Author("Nick Wanninger")
-	BuildOutput           = App.Flag("output", "Output binary name.").Short('o').Default("a.out").String()
-	Optimize              = App.Flag("optimize", "Enable full optimization").Short('O').Default("0").Int()
+	BuildOutput           = App.Flag("output", "Output binary name.").Short('o').Default(DefaultOutput).String()
+	Optimize              = App.Flag("optimize", "Optimization level, from 0 to 3 or s to optimize for size. -O2 and -Os are the same as --optimize=2 and --optimize=s").Short('O').Default("0").Enum("0", "1", "2", "3", "s")
 	PrintVerbose          = App.Flag("verbose", "Enable verbose printing").Short('v').Bool()
 	StopAfterCompilation  = App.Flag("no-binary", "Stop after compilation").Short('c').Bool()
 	DisableEmission       = App.Flag("no-emission", "Disable emission and only run through the syntax checking process").Bool()
 	DisableRuntime        = App.Flag("no-runtime", "Disable calls to the runtime. Warning: garbage collector, etc will be gone. Most standard libraries will not work.").Bool()
+	DebugAlloc            = App.Flag("debug-alloc", "Use the debug allocator, which reports double frees and memory that was never freed").Bool()
 	DisableStringDataCopy = App.Flag("no-dynamic-strings", "Disable the dynamic string copy and replace with static/constant .data section pointers").Bool()
+	Target                = App.Flag("target", "Target triple to build for. Sets the data layout, sizes and link flags. Defaults to the target of the installed clang").String()
+	TrimPath              = App.Flag("trimpath", "Remove absolute paths and timestamps from the output, so the same sources always build the same binary").Bool()
+	OpaquePointers        = App.Flag("opaque-pointers", "Emit llvm ir with opaque pointers, as required by llvm 17 and later").Bool()
+	Toolchain             = App.Flag("toolchain", "Toolchain to assemble and link with: clang, llc, wasm or zig. Picked from the target by default").String()
 	LinkerArgs            = App.Flag("linker-args", "Arguments to pass clang when linking object files").String()
+	Library               = App.Flag("lib", "Build the package as a static or shared library instead of a binary, with a geode interface of its @export functions that programs can include without the source. The output is the directory they are written to, lib<package> by default").Enum("static", "shared")
 	EmitASM               = App.Flag("asm", "Emit the asm of the program to the current directory. (will not produce binary)").Bool()
+	EmitBitcode           = App.Flag("bitcode", "Write the program as llvm bitcode rather than textual ir before building it. Faster for the tools to read, but without debug information").Bool()
+	DirectObject          = App.Flag("direct-obj", "Compile the program to an object file with llc directly rather than through the toolchain, which only links it. Not used with --debug, --asm, --llvm or --obj").Bool()
 	EmitLLVM              = App.Flag("llvm", "Emit the llvm of the program to the current directory. (will not produce binary)").Bool()
 	ShowLLVM              = App.Flag("show-llvm", "Print the llvm to stdout for debugging codegen").Short('S').Bool()
 	EmitObject            = App.Flag("obj", "Emit the object file of the program to the current directory. (will not produce binary)").Bool()
+	EmitDeps              = App.Flag("emit-deps", "Write a makefile of every file the output depends on next to it, with a .d extension").Bool()
+	EmitAST               = App.Flag("emit-ast", "Print the ast of every file the program parses, after its includes are resolved, as json and stop before compiling it").Bool()
+	EmitSymbols           = App.Flag("emit-symbols", "Print every function and type the program declares as json, with the symbols the functions were compiled to, and stop before emitting it").Bool()
 	DumpScopeTree         = App.Flag("dump-scope-tree", "Dump a tree representation of the scope to stdout").Bool()
+	DumpScopes            = App.Flag("dump-scopes", "Dump the scope tree with declared variables, their types and packages after analysis").Bool()
+	Warnings              = App.Flag("warning", "Turn on a warning with -W<name> or off with -Wno-<name>, from deprecated, narrowing, shadow and unused. -Wall turns on every warning and -Werror makes them errors").Short('W').Strings()
+	DiagFormat            = App.Flag("diag-format", "Format to print errors and warnings in, text or json. json prints them as one array for editors and other tools to read").Default("text").Enum("text", "json")
+	SearchPaths           = App.Flag("search-path", "Add a directory to search for included packages in, after the ones in GEODE_PATH. Can be given more than once").Short('I').Strings()
 	ClangFlags            = App.Flag("clang-flags", "flags to pass into the clang compiler/linker").String()
-	EnableDebug           = App.Flag("debug", "(NOT WORKING) Enable debug information").Short('g').Bool()
+	Coverage              = App.Flag("coverage", "Count how many times each line of the program runs. The counts are written to geode.cov, or the file in GEODE_COVERAGE, when it exits and are read by geode cover").Bool()
+	BoundsChecks          = App.Flag("bounds-checks", "Check every index into a slice against its length, ending the program with the index and where it was if it is out of range. On by default with --debug").Bool()
+	ARC                   = App.Flag("arc", "Count the references to instances of classes made with new, freeing them as soon as the last one goes away instead of waiting for the garbage collector. Fields marked @weak aren't counted, to break cycles").Bool()
+	EnableDebug           = App.Flag("debug", "Generate dwarf debug information, so gdb and lldb can step through the source and show locals").Short('g').Bool()
 )
 
 // Global arguments accessable throughout the program
 var (
 	VersionCMD = App.Command("version", "Display the version")
 
-	BuildCMD   = App.Command("build", "Build an executable.")
+	BuildCMD   = App.Command("build", "Build an executable. Flags that aren't given are read from the geode.toml of the project, if it has one.")
 	BuildInput = BuildCMD.Arg("input", "Geode source file or package").Default(".").String()
 
 	RunCMD   = App.Command("run", "Build and run an executable, clean up afterwards").Default()
@@ -46,11 +68,48 @@ var (
 
 	InfoCMD   = App.Command("info", "Get information about a program (does not compile, just lexes and parses)")
 	InfoInput = InfoCMD.Arg("input", "Geode source file or package").String()
+
+	CompDBCMD   = App.Command("compdb", "Print a compilation database of every file a build compiles, in the compile_commands.json format")
+	CompDBInput = CompDBCMD.Arg("input", "Geode source file or package").Default(".").String()
+
+	CoverCMD      = App.Command("cover", "Print how much of each file a program built with --coverage ran, from the counts it wrote")
+	CoverCounts   = CoverCMD.Arg("counts", "Counts written by the program").Default("geode.cov").String()
+	CoverAnnotate = CoverCMD.Flag("annotate", "Print every line of source with the number of times it ran").Bool()
+
+	DemangleCMD     = App.Command("demangle", "Translate mangled symbol names back to geode signatures. Filters stdin when no symbols are given")
+	DemangleSymbols = DemangleCMD.Arg("symbols", "Mangled symbol names").Strings()
 )
 
 // Parse returns the kingpin command returned by kingpin.MustParse
 func Parse() string {
-	return kingpin.MustParse(App.Parse(os.Args[1:]))
+	return kingpin.MustParse(App.Parse(splitJoinedFlags(os.Args[1:])))
+}
+
+// joinedFlags are the short flags that are written as one word with their
+// value, like -Wall, -Wno-unused, -O2 and -Ilib
+var joinedFlags = []string{"-W", "-O", "-I"}
+
+// splitJoinedFlags splits flags written as one word with their value into
+// the flag and its value, which is how kingpin takes them
+func splitJoinedFlags(args []string) []string {
+	split := make([]string, 0, len(args))
+	for i, a := range args {
+		// the arguments after -- are passed to the program as they are
+		if a == "--" {
+			return append(split, args[i:]...)
+		}
+		split = append(split, splitJoinedFlag(a)...)
+	}
+	return split
+}
+
+func splitJoinedFlag(a string) []string {
+	for _, flag := range joinedFlags {
+		if strings.HasPrefix(a, flag) && len(a) > len(flag) {
+			return []string{flag, a[len(flag):]}
+		}
+	}
+	return []string{a}
 }
 
 // Commands related to the pkg subcommand
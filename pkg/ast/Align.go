@@ -0,0 +1,62 @@
+package ast
+
+import (
+	"strconv"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// alignAttribute places a variable, global or every instance of a class at
+// an address that is a multiple of a power of two, as in `@align(32) f32x8 v`.
+// It is needed for simd loads and c apis that require more alignment than
+// a type has on its own. Memory on the heap is aligned with mem:aligned.
+const alignAttribute = "align"
+
+// parseAlignAttribute takes @align out of a list of attributes, returning
+// the alignment it sets (or 0 if it isn't there) and the other attributes
+func parseAlignAttribute(attrs Attributes, tok lexer.Token) (int, Attributes) {
+	rest := make(Attributes, 0, len(attrs))
+	align := 0
+	for _, attr := range attrs {
+		if attr.Name != alignAttribute {
+			rest = append(rest, attr)
+			continue
+		}
+		if len(attr.Args) != 1 {
+			syntaxFail(tok, "@align takes the alignment in bytes, as in @align(16)")
+		}
+		n, err := strconv.Atoi(attr.Args[0])
+		if err != nil || n <= 0 || n&(n-1) != 0 {
+			syntaxFail(tok, "The alignment in @align must be a power of two, not %s", attr.Args[0])
+		}
+		align = n
+	}
+	return align, rest
+}
+
+// alignVariable sets the alignment of the variable a statement declares
+func alignVariable(node Node, align int, tok lexer.Token) Node {
+	switch n := node.(type) {
+	case VariableDefnNode:
+		n.Align = align
+		return n
+	case BinaryNode:
+		if defn, ok := n.Left.(VariableDefnNode); ok && n.OP == "=" {
+			defn.Align = align
+			n.Left = defn
+			return n
+		}
+	}
+	syntaxFail(tok, "@align can only be attached to variable declarations with a type")
+	return nil
+}
+
+// alignment returns the alignment of a variable of a type, the larger of
+// the one given to the variable and the one given to its class
+func (p *Program) alignment(t types.Type, align int) int {
+	if s, ok := t.(*types.StructType); ok && p.typeAlignments[s] > align {
+		return p.typeAlignments[s]
+	}
+	return align
+}
@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// Anonymous structs are struct types written out where they are used,
+// without declaring a class, like `{int x, int y} point`. They can be the
+// type of variables, fields and arguments.
+//
+// The name of an anonymous struct spells out its fields, see
+// parseStructType, so the type is found from the name like any other. It
+// is an unnamed llvm struct, so two anonymous structs are the same type
+// when they have the same fields with the same names.
+
+// anonymousStruct returns the type of an anonymous struct from its name.
+// The types of the fields are named relative to where the struct is used.
+func (p *Program) anonymousStruct(name string) (types.Type, error) {
+	fields := NewQuickParser(name).parseStructFields()
+
+	fieldTypes := make([]types.Type, len(fields))
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		for _, other := range names[:i] {
+			if other == field.Name {
+				return nil, fmt.Errorf("anonymous struct %s has two fields named %s", name, field.Name)
+			}
+		}
+		t, err := field.Type.GetType(p)
+		if err != nil {
+			return nil, err
+		}
+		fieldTypes[i] = t
+		names[i] = field.Name
+	}
+
+	st := types.NewStruct(fieldTypes...)
+	st.Names = names
+	return st, nil
+}
+
+// anonymousStructName spells out the fields of an anonymous struct, as it
+// is written in geode
+func (p *Program) anonymousStructName(st *types.StructType) string {
+	fields := make([]string, len(st.Fields))
+	for i, field := range st.Fields {
+		fields[i] = fmt.Sprintf("%s %s", p.typeName(field), st.Names[i])
+	}
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
@@ -0,0 +1,419 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// With --arc, instances of classes made with new are reference counted
+// as well as garbage collected, and are freed as soon as the last
+// reference to them goes away rather than when the collector next runs.
+//
+// The compiler counts the references held by local variables, arguments,
+// globals and the fields of classes. Storing a pointer to an instance in
+// one of them retains the new instance and releases the one it replaces,
+// and the locals and arguments of a function are released when it
+// returns. The instance a function returns is handed to its caller without
+// being freed. When an instance is freed, the references its fields hold
+// are released too.
+//
+// Two instances that point to each other are never freed by their counts,
+// so the reference back is marked with @weak, which isn't counted:
+//
+//	class Node {
+//		Node* next
+//		@weak Node* prev
+//	}
+//
+// The collector still frees what the counts miss, like cycles without a
+// @weak field and instances only kept in slices, which are never released.
+// The counts themselves are kept by the runtime, see lib/runtime/arc.c.
+
+// weakAttribute marks a field of a class that doesn't keep the instance it
+// points to alive with --arc
+const weakAttribute = "weak"
+
+// parseWeakAttribute takes @weak out of a list of attributes, returning if
+// it was there and the other attributes
+func parseWeakAttribute(attrs Attributes, tok lexer.Token) (bool, Attributes) {
+	rest := make(Attributes, 0, len(attrs))
+	weak := false
+	for _, attr := range attrs {
+		if attr.Name != weakAttribute {
+			rest = append(rest, attr)
+			continue
+		}
+		if len(attr.Args) != 0 {
+			syntaxFail(tok, "@weak doesn't take any arguments")
+		}
+		weak = true
+	}
+	return weak, rest
+}
+
+// arcEnabled returns if instances of classes are reference counted
+func (p *Program) arcEnabled() bool {
+	return *arg.ARC && !p.NoRuntime
+}
+
+// arc is the state of the --arc reference counting
+type arc struct {
+	// weak holds the fields of each class marked with @weak
+	weak map[*types.StructType]map[string]bool
+	// locals are the variables of each function released when it returns,
+	// and counted holds all of them
+	locals  map[*ir.Function][]*ir.InstAlloca
+	counted map[*ir.InstAlloca]bool
+	// temporaries are the locals of each function holding the results of
+	// the statements being generated, see arcTemporary
+	temporaries map[*ir.Function][]*ir.InstAlloca
+	// drops are the functions that release the fields of each class
+	drops map[*types.StructType]*ir.Function
+}
+
+func (p *Program) arcState() *arc {
+	if p.arc == nil {
+		p.arc = &arc{
+			weak:        make(map[*types.StructType]map[string]bool),
+			locals:      make(map[*ir.Function][]*ir.InstAlloca),
+			counted:     make(map[*ir.InstAlloca]bool),
+			temporaries: make(map[*ir.Function][]*ir.InstAlloca),
+			drops:       make(map[*types.StructType]*ir.Function),
+		}
+	}
+	return p.arc
+}
+
+// arcCounted returns if values of a type are counted references, which
+// are pointers to classes
+func (p *Program) arcCounted(t types.Type) bool {
+	if !p.arcEnabled() {
+		return false
+	}
+	ptr, ok := t.(*types.PointerType)
+	if !ok {
+		return false
+	}
+	st, ok := ptr.Elem.(*types.StructType)
+	return ok && strings.HasPrefix(st.Name, "class.")
+}
+
+// arcHolds returns if a value of a type holds a counted reference that
+// has to be released when it is dropped. The payload of a union is stored
+// as integers, so the references in it are never released.
+func (p *Program) arcHolds(t types.Type) bool {
+	if p.arcCounted(t) {
+		return true
+	}
+	st, ok := t.(*types.StructType)
+	if !ok || strings.HasPrefix(st.Name, "union.") {
+		return false
+	}
+	for _, field := range st.Fields {
+		if p.arcHolds(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// setWeakFields records the fields of a class marked with @weak, which
+// include the ones of the class it extends
+func (p *Program) setWeakFields(st *types.StructType, fields []VariableDefnNode) {
+	if !p.arcEnabled() {
+		return
+	}
+	a := p.arcState()
+	weak := make(map[string]bool)
+	if parent := p.classParents[st]; parent != nil {
+		for name := range a.weak[parent] {
+			weak[name] = true
+		}
+	}
+	for _, f := range fields {
+		if f.Weak {
+			weak[f.Name.String()] = true
+		}
+	}
+	a.weak[st] = weak
+}
+
+// arcWeak returns if a field of a struct is marked with @weak
+func (p *Program) arcWeak(st *types.StructType, field string) bool {
+	return p.arcState().weak[st][field]
+}
+
+func (p *Program) arcCall(name string, val value.Value) error {
+	block := p.Compiler.CurrentBlock()
+	ptr := block.NewBitCast(val, types.NewPointer(types.I8))
+	_, err := p.NewRuntimeFunctionCall(name, ptr)
+	return err
+}
+
+// arcStore stores a value at an address, retaining it and releasing the
+// reference it replaces if it is counted. Variables the compiler makes for
+// itself may not have been stored to yet, so what they held isn't
+// released.
+func (p *Program) arcStore(val, ptr value.Value) error {
+	block := p.Compiler.CurrentBlock()
+	if !p.arcCounted(val.Type()) {
+		block.NewStore(val, ptr)
+		return nil
+	}
+	if alloc, isLocal := ptr.(*ir.InstAlloca); isLocal && !p.arcState().counted[alloc] {
+		if err := p.arcCall("__arc_retain", val); err != nil {
+			return err
+		}
+		block.NewStore(val, ptr)
+		return nil
+	}
+	old := block.NewLoad(ptr)
+	if err := p.arcCall("__arc_retain", val); err != nil {
+		return err
+	}
+	block.NewStore(val, ptr)
+	return p.arcCall("__arc_release", old)
+}
+
+// arcRetain retains a value stored somewhere it is never released from,
+// like an element of a slice, so it lives until the collector frees it
+func (p *Program) arcRetain(val value.Value) error {
+	if !p.arcCounted(val.Type()) {
+		return nil
+	}
+	return p.arcCall("__arc_retain", val)
+}
+
+// arcDeclare makes a local variable of the current function a counted
+// reference, which is released when the function returns. It starts out
+// nil, as the first store releases what it held before.
+func (p *Program) arcDeclare(alloc *ir.InstAlloca) {
+	if !p.arcCounted(alloc.Elem) {
+		return
+	}
+	fn := p.Compiler.CurrentFunc()
+	fn.Blocks[0].NewStore(constant.NewNull(alloc.Elem.(*types.PointerType)), alloc)
+	a := p.arcState()
+	a.locals[fn] = append(a.locals[fn], alloc)
+	a.counted[alloc] = true
+}
+
+// arcParam retains an argument of the current function, which is released
+// when it returns
+func (p *Program) arcParam(alloc *ir.InstAlloca, val value.Value) error {
+	if !p.arcCounted(alloc.Elem) {
+		return nil
+	}
+	if err := p.arcCall("__arc_retain", val); err != nil {
+		return err
+	}
+	a := p.arcState()
+	fn := p.Compiler.CurrentFunc()
+	a.locals[fn] = append(a.locals[fn], alloc)
+	a.counted[alloc] = true
+	return nil
+}
+
+// arcReturn releases the locals and arguments of the current function
+// before it returns. The references it returns are retained first, so they
+// aren't freed with the locals that point to them, and are handed to the
+// caller with a count that doesn't include the function's.
+func (p *Program) arcReturn(ret value.Value) error {
+	if !p.arcEnabled() {
+		return nil
+	}
+	locals := p.arcLocals()
+	if len(locals) == 0 {
+		return nil
+	}
+	var returned []value.Value
+	if ret != nil {
+		returned = p.arcReferences(ret)
+	}
+	for _, ref := range returned {
+		if err := p.arcCall("__arc_retain", ref); err != nil {
+			return err
+		}
+	}
+	if err := p.arcRelease(locals); err != nil {
+		return err
+	}
+	for _, ref := range returned {
+		if err := p.arcCall("__arc_disown", ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arcLocals returns the locals and arguments of the current function that
+// are released when it returns
+func (p *Program) arcLocals() []*ir.InstAlloca {
+	if !p.arcEnabled() {
+		return nil
+	}
+	return p.arcState().locals[p.Compiler.CurrentFunc()]
+}
+
+// arcRelease releases the references held by locals, as the function they
+// are in returns or an error unwinds through it
+func (p *Program) arcRelease(locals []*ir.InstAlloca) error {
+	for _, local := range locals {
+		ref := p.Compiler.CurrentBlock().NewLoad(local)
+		if err := p.arcCall("__arc_release", ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// arcTemporary keeps a counted result that nothing may hold, like one that
+// is thrown away or that a field is read from, in a local of its own until
+// the statement it is made in is done, when it is released. It returns the
+// local, or nil if the value isn't counted.
+func (p *Program) arcTemporary(val value.Value) (*ir.InstAlloca, error) {
+	if val == nil || !p.arcCounted(val.Type()) {
+		return nil, nil
+	}
+	fn := p.Compiler.CurrentFunc()
+	alloc := createBlockAlloca(fn, val.Type(), "")
+	p.arcDeclare(alloc)
+	if err := p.arcStore(val, alloc); err != nil {
+		return nil, err
+	}
+	a := p.arcState()
+	a.temporaries[fn] = append(a.temporaries[fn], alloc)
+	return alloc, nil
+}
+
+// arcTemporaries returns the number of temporaries of the current function
+// that haven't been released, which the ones a statement makes come after
+func (p *Program) arcTemporaries() int {
+	if !p.arcEnabled() {
+		return 0
+	}
+	return len(p.arcState().temporaries[p.Compiler.CurrentFunc()])
+}
+
+// arcReleaseTemporaries releases the temporaries made since there were from
+// of them, once the statement they were made in is done. The ones a return
+// skips are released with the other locals.
+func (p *Program) arcReleaseTemporaries(from int) error {
+	if !p.arcEnabled() {
+		return nil
+	}
+	a := p.arcState()
+	fn := p.Compiler.CurrentFunc()
+	temporaries := a.temporaries[fn]
+	if len(temporaries) <= from {
+		return nil
+	}
+	a.temporaries[fn] = temporaries[:from]
+	if p.Compiler.CurrentBlock().Term != nil {
+		return nil
+	}
+	for _, temporary := range temporaries[from:] {
+		if err := p.arcRelease([]*ir.InstAlloca{temporary}); err != nil {
+			return err
+		}
+		p.Compiler.CurrentBlock().NewStore(constant.NewNull(temporary.Elem.(*types.PointerType)), temporary)
+	}
+	return nil
+}
+
+// arcReferences returns the counted references in a value, like the
+// fields of a result
+func (p *Program) arcReferences(val value.Value) []value.Value {
+	if p.arcCounted(val.Type()) {
+		return []value.Value{val}
+	}
+	if !p.arcHolds(val.Type()) {
+		return nil
+	}
+	var refs []value.Value
+	for i := range val.Type().(*types.StructType).Fields {
+		field := p.Compiler.CurrentBlock().NewExtractValue(val, []int64{int64(i)})
+		refs = append(refs, p.arcReferences(field)...)
+	}
+	return refs
+}
+
+// arcDrop returns the function that releases the references held by the
+// fields of an instance of a class before it is freed, or nil if it holds
+// none
+func (p *Program) arcDrop(st *types.StructType) (*ir.Function, error) {
+	a := p.arcState()
+	if drop, found := a.drops[st]; found {
+		return drop, nil
+	}
+	if !p.arcHolds(st) {
+		a.drops[st] = nil
+		return nil, nil
+	}
+
+	obj := ir.NewParam("obj", types.NewPointer(types.I8))
+	drop := p.Module.NewFunction(fmt.Sprintf("__arc_drop.%s", st.Name), types.Void, obj)
+	drop.Linkage = ir.LinkageInternal
+	// a class that points to itself releases with its own drop
+	a.drops[st] = drop
+
+	// calling into the runtime for the first time compiles the function,
+	// which replaces p.Compiler, so it is only popped from once it's done
+	block := drop.NewBlock("entry")
+	p.Compiler.PushFunc(drop)
+	p.Compiler.PushBlock(block)
+	this := block.NewBitCast(obj, types.NewPointer(st))
+	err := p.arcDropFields(st, this, a.weak[st])
+	p.Compiler.CurrentBlock().NewRet(nil)
+	p.Compiler.PopBlock()
+	p.Compiler.PopFunc()
+	if err != nil {
+		return nil, err
+	}
+	return drop, nil
+}
+
+// arcDropFields releases the references in the fields of the struct at
+// ptr, other than the weak ones
+func (p *Program) arcDropFields(st *types.StructType, ptr value.Value, weak map[string]bool) error {
+	zero := constant.NewInt(0, types.I32)
+	for i, field := range st.Fields {
+		if !p.arcHolds(field) || (i < len(st.Names) && weak[st.Names[i]]) {
+			continue
+		}
+		block := p.Compiler.CurrentBlock()
+		fieldPtr := block.NewGetElementPtr(ptr, zero, constant.NewInt(int64(i), types.I32))
+		if p.arcCounted(field) {
+			if err := p.arcCall("__arc_release", block.NewLoad(fieldPtr)); err != nil {
+				return err
+			}
+			continue
+		}
+		inner := field.(*types.StructType)
+		if err := p.arcDropFields(inner, fieldPtr, p.arcState().weak[inner]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genArcAlloc allocates an instance of a class for new, which the runtime
+// counts the references to
+func (p *Program) genArcAlloc(st *types.StructType, size value.Value) (value.Value, error) {
+	drop, err := p.arcDrop(st)
+	if err != nil {
+		return nil, err
+	}
+	var dropPtr value.Value = constant.NewNull(types.NewPointer(types.I8))
+	if drop != nil {
+		dropPtr = constant.NewBitCast(drop, types.NewPointer(types.I8))
+	}
+	return p.NewRuntimeFunctionCall("__arc_alloc", size, dropPtr)
+}
@@ -21,6 +21,9 @@ type ArrayNode struct {
 // NameString implements Node.NameString
 func (n ArrayNode) NameString() string { return "ArrayNode" }
 
+// MarshalJSON implements json.Marshaler for ArrayNode
+func (n ArrayNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // GenAccess -
 func (n ArrayNode) GenAccess(prog *Program) (value.Value, error) {
 	return n.Codegen(prog)
@@ -59,15 +62,22 @@ func (n ArrayNode) Codegen(prog *Program) (value.Value, error) {
 		typ = types.NewPointer(values[0].Type())
 	}
 
-	itemType := typ.(*types.PointerType).Elem
+	ptrType, isPtr := typ.(*types.PointerType)
+	if !isPtr {
+		return nil, n.Errorf(ErrType, "unable to make an array literal a %s, only pointers are made from them", prog.typeName(typ))
+	}
+	itemType := ptrType.Elem
 
 	arrayType := types.NewArray(itemType, int64(n.Length))
 
-	// arrayLength := int64(itemType.ByteCount() * n.Length)
 	var alloca value.Value
 	// alloca = block.NewAlloca(arrayType)
 
-	length := constant.NewInt(int64(n.Length*arrayType.ByteCount()), types.I32)
+	arraySize, err := prog.sizeOf(arrayType)
+	if err != nil {
+		return nil, n.Diagnose(err)
+	}
+	length := constant.NewInt(arraySize, types.I32)
 
 	dyn, err := prog.NewRuntimeFunctionCall("xmalloc", length)
 	if err != nil {
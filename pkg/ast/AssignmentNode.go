@@ -21,6 +21,9 @@ type AssignmentNode struct {
 // NameString implements Node.NameString
 func (n AssignmentNode) NameString() string { return "AssignmentNode" }
 
+// MarshalJSON implements json.Marshaler for AssignmentNode
+func (n AssignmentNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 func (n AssignmentNode) String() string {
 	return fmt.Sprintf("%s <- %s", n.Assignee, n.Value)
 }
@@ -33,6 +36,12 @@ func (n AssignmentNode) GenAccess(prog *Program) (value.Value, error) {
 // Codegen implements Node.Codegen for AssignmentNode
 func (n AssignmentNode) Codegen(prog *Program) (value.Value, error) {
 	var err error
+	// the object a field is assigned in is generated once
+	if dot, isDot := n.Assignee.(DotReference); isDot && !dot.isStatic(prog) {
+		if n.Assignee, err = dot.evaluated(prog); err != nil {
+			return nil, err
+		}
+	}
 	targetType, _ := n.Assignee.Type(prog)
 	prog.Compiler.PushType(targetType)
 
@@ -41,7 +50,14 @@ func (n AssignmentNode) Codegen(prog *Program) (value.Value, error) {
 		return nil, err
 	}
 
+	if targetType != nil {
+		if err := prog.checkOptionalCast(val, targetType); err != nil {
+			return nil, err
+		}
+	}
+
 	if targetType != nil && !types.Equal(val.Type(), targetType) {
+		prog.warnNarrowing(n.Token, val, targetType)
 		val, err = createTypeCast(prog, val, targetType)
 		if err != nil {
 			return nil, err
@@ -49,6 +65,8 @@ func (n AssignmentNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	// fmt.Println(val)
-	n.Assignee.GenAssign(prog, val)
+	if _, err := n.Assignee.GenAssign(prog, val); err != nil {
+		return nil, err
+	}
 	return val, nil
 }
@@ -0,0 +1,204 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// atomicNamespace is the namespace the atomic operations are builtins in.
+// Each takes a pointer to an integer (or to a pointer, for load, store and
+// exchange) and an optional memory ordering as a string literal, which is
+// "seq_cst" when it is left out.
+//
+//	atomic:load(p, order)                         the value at p
+//	atomic:store(p, v, order)                     stores v at p
+//	atomic:exchange(p, v, order)                  stores v, returns the old value
+//	atomic:fetch_add(p, v, order)                 adds v, returns the old value
+//	atomic:fetch_sub(p, v, order)                 (also fetch_and, fetch_or, fetch_xor)
+//	atomic:compare_exchange(p, old, new, order)   stores new if p holds old, returns
+//	                                              if it did
+//	atomic:fence(order)                           orders the memory accesses around it
+//
+// The orderings are those of c11: "relaxed", "acquire", "release",
+// "acq_rel" and "seq_cst".
+const atomicNamespace = "atomic"
+
+// atomicOrderings maps the orderings atomic builtins take to llvm's
+var atomicOrderings = map[string]ir.AtomicOrdering{
+	"relaxed": ir.OrderingMonotonic,
+	"acquire": ir.OrderingAcquire,
+	"release": ir.OrderingRelease,
+	"acq_rel": ir.OrderingAcqRel,
+	"seq_cst": ir.OrderingSeqCst,
+}
+
+// atomicRMWOps are the builtins lowered to atomicrmw
+var atomicRMWOps = map[string]ir.AtomicOp{
+	"exchange":  ir.AtomicOpXchg,
+	"fetch_add": ir.AtomicOpAdd,
+	"fetch_sub": ir.AtomicOpSub,
+	"fetch_and": ir.AtomicOpAnd,
+	"fetch_or":  ir.AtomicOpOr,
+	"fetch_xor": ir.AtomicOpXor,
+}
+
+// atomicOperands are the number of operands the atomic builtins other than
+// the atomicrmw ones take, not counting the ordering
+var atomicOperands = map[string]int{
+	"fence":            0,
+	"load":             1,
+	"store":            2,
+	"compare_exchange": 3,
+}
+
+// atomicBuiltin returns the name of the atomic operation a call is to, if
+// it is one. A function declared in a package named atomic takes priority.
+func atomicBuiltin(prog *Program, n FunctionCallNode) (string, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return "", false
+	}
+	namespace, name := ParseName(ident.String())
+	if namespace != atomicNamespace {
+		return "", false
+	}
+	_, declared := prog.Functions[ident.String()]
+	return name, !declared
+}
+
+// genAtomic generates one of the atomic builtins
+func genAtomic(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	val, err := genAtomicOp(prog, name, n)
+	if err != nil {
+		return nil, n.Errorf(ErrInvalid, "%s:%s: %s", atomicNamespace, name, err)
+	}
+	return val, nil
+}
+
+func genAtomicOp(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	block := prog.Compiler.CurrentBlock()
+
+	operands, ok := atomicOperands[name]
+	if _, isRMW := atomicRMWOps[name]; isRMW {
+		operands, ok = 2, true
+	}
+	if !ok {
+		names := make([]string, 0, len(atomicOperands)+len(atomicRMWOps))
+		for name := range atomicOperands {
+			names = append(names, name)
+		}
+		for name := range atomicRMWOps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown atomic operation, expected one of %s", strings.Join(names, ", "))
+	}
+
+	if len(n.Args) != operands && len(n.Args) != operands+1 {
+		return nil, fmt.Errorf("takes %d arguments and an optional ordering, given %d", operands, len(n.Args))
+	}
+	ordering := ir.OrderingSeqCst
+	if len(n.Args) == operands+1 {
+		order, ok := n.Args[operands].(StringNode)
+		if !ok {
+			return nil, fmt.Errorf("the ordering must be a string literal")
+		}
+		if ordering, ok = atomicOrderings[order.Value]; !ok {
+			return nil, fmt.Errorf("unknown ordering %q, expected one of relaxed, acquire, release, acq_rel, seq_cst", order.Value)
+		}
+	}
+
+	if name == "fence" {
+		if ordering == ir.OrderingMonotonic {
+			return nil, fmt.Errorf("a fence can't be relaxed")
+		}
+		block.NewFence(ordering)
+		return nil, nil
+	}
+
+	args := make([]value.Value, 0, operands)
+	for _, arg := range n.Args[:operands] {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, fmt.Errorf("argument %s is not accessable (has no readable value)", arg)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, val)
+	}
+
+	ptr, ok := args[0].Type().(*types.PointerType)
+	if !ok {
+		return nil, fmt.Errorf("expects a pointer, given %s", args[0].Type())
+	}
+	elem := ptr.Elem
+	align, err := atomicAlign(elem, name == "load" || name == "store" || name == "exchange")
+	if err != nil {
+		return nil, err
+	}
+
+	// the values are converted to the type pointed to
+	for i := 1; i < len(args); i++ {
+		if args[i], err = createTypeCast(prog, args[i], elem); err != nil {
+			return nil, err
+		}
+	}
+
+	switch name {
+	case "load":
+		if ordering == ir.OrderingRelease || ordering == ir.OrderingAcqRel {
+			return nil, fmt.Errorf("a load can't have %s ordering", ordering)
+		}
+		load := block.NewLoad(args[0])
+		load.Ordering = ordering
+		load.Align = align
+		return load, nil
+
+	case "store":
+		if ordering == ir.OrderingAcquire || ordering == ir.OrderingAcqRel {
+			return nil, fmt.Errorf("a store can't have %s ordering", ordering)
+		}
+		store := block.NewStore(args[1], args[0])
+		store.Ordering = ordering
+		store.Align = align
+		return nil, nil
+
+	case "compare_exchange":
+		// the ordering of a failed exchange is that of the load it does
+		failure := ordering
+		switch ordering {
+		case ir.OrderingAcqRel:
+			failure = ir.OrderingAcquire
+		case ir.OrderingRelease:
+			failure = ir.OrderingMonotonic
+		}
+		xchg := block.NewCmpXchg(args[0], args[1], args[2], ordering, failure)
+		return block.NewExtractValue(xchg, []int64{1}), nil
+	}
+
+	return block.NewAtomicRMW(atomicRMWOps[name], args[0], args[1], ordering), nil
+}
+
+// atomicAlign returns the alignment of an atomic access to a type, which is
+// its size. Only integers of a whole number of bytes, and pointers where
+// allowPointers is set, can be accessed atomically.
+func atomicAlign(t types.Type, allowPointers bool) (int, error) {
+	switch t := t.(type) {
+	case *types.IntType:
+		if t.Size >= 8 && t.Size <= 64 && t.Size&(t.Size-1) == 0 {
+			return t.Size / 8, nil
+		}
+	case *types.PointerType:
+		if allowPointers {
+			return 8, nil
+		}
+	}
+	return 0, fmt.Errorf("can't be used on a pointer to %s", t)
+}
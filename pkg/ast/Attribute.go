@@ -0,0 +1,134 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// Attribute is a compiler directive attached to a declaration. It is
+// written before the declaration as `@name` or `@name("arg", ...)`
+type Attribute struct {
+	Name string
+	Args []string
+}
+
+func (a Attribute) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "@%s", a.Name)
+	if len(a.Args) > 0 {
+		fmt.Fprintf(buff, "(%s)", strings.Join(a.Args, ", "))
+	}
+	return buff.String()
+}
+
+// Attributes is a list of attributes on a single declaration
+type Attributes []Attribute
+
+// Has returns if an attribute with the given name is in the list
+func (a Attributes) Has(name string) bool {
+	_, found := a.Get(name)
+	return found
+}
+
+// Get returns the first attribute with the given name
+func (a Attributes) Get(name string) (Attribute, bool) {
+	for _, attr := range a {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// parseAttributes parses a run of attributes. The parser is left on the
+// first token after the last attribute.
+func (p *Parser) parseAttributes() Attributes {
+	attrs := make(Attributes, 0)
+
+	for p.token.Is(lexer.TokAttribute) {
+		attr := Attribute{}
+		attr.Name = strings.TrimPrefix(p.token.Value, "@")
+		if attr.Name == "" {
+			syntaxFail(p.token, "Attributes must have a name")
+		}
+		p.Next()
+
+		if p.token.Is(lexer.TokLeftParen) {
+			for p.Next(); !p.token.Is(lexer.TokRightParen); p.Next() {
+				switch p.token.Type {
+				case lexer.TokComma:
+					continue
+				case lexer.TokString:
+					attr.Args = append(attr.Args, strings.Trim(p.token.Value, "\""))
+				case lexer.TokIdent, lexer.TokType, lexer.TokNumber:
+					attr.Args = append(attr.Args, p.token.Value)
+				default:
+					syntaxFail(p.token, "Invalid argument to attribute @%s", attr.Name)
+				}
+			}
+			p.Next()
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	return attrs
+}
+
+// parseAttributedStmt parses a list of attributes and the
+// declaration they are attached to
+func (p *Parser) parseAttributedStmt() Node {
+	start := p.token
+	matches, attrs := p.targetConditions(p.parseAttributes(), start)
+
+	if p.token.Is(lexer.TokNamespace) && !matches {
+		onlyAllowAttributes(attrs, start, "files")
+		n := p.parseNamespace()
+		p.excludeFile()
+		return n
+	}
+	if !matches {
+		return p.parseExcluded(start)
+	}
+	// declarations with only conditions are parsed as they are without them
+	if len(attrs) == 0 {
+		return p.parseTopLevelStmt()
+	}
+
+	switch p.token.Type {
+	case lexer.TokFuncDefn:
+		fn := p.parseFunctionNode()
+		fn.Attributes = attrs
+		end := p.Peek(-1)
+		p.warnings.allow(attrs, start, &end)
+		return fn
+
+	case lexer.TokNamespace:
+		// attributes on the namespace apply to the whole file
+		onlyAllowAttributes(attrs, start, "files")
+		p.warnings.allow(attrs, start, nil)
+		return p.parseNamespace()
+
+	case lexer.TokClassDefn, lexer.TokType:
+		align, attrs := parseAlignAttribute(attrs, start)
+		onlyAllowAttributes(attrs, start, "classes and globals")
+		node := p.parseTopLevelStmt()
+		end := p.Peek(-1)
+		p.warnings.allow(attrs, start, &end)
+		switch n := node.(type) {
+		case ClassNode:
+			n.Align = align
+			return n
+		case GlobalVariableDeclNode:
+			n.Align = align
+			return n
+		}
+		return node
+	}
+
+	syntaxFail(p.token, "Attributes can only be attached to function declarations")
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
@@ -13,10 +14,13 @@ func createCmp(blk *ir.BasicBlock, i ir.IntPred, f ir.FloatPred, t types.Type, l
 
 	var val value.Value
 
-	if types.IsInt(t) {
+	if types.IsInt(scalarType(t)) {
+		if types.IsUnsigned(scalarType(t)) {
+			i = unsignedComparisons[i]
+		}
 		val = blk.NewICmp(i, left, right)
 	}
-	if types.IsFloat(t) {
+	if types.IsFloat(scalarType(t)) {
 		val = blk.NewFCmp(f, left, right)
 	}
 
@@ -27,7 +31,10 @@ func createCmp(blk *ir.BasicBlock, i ir.IntPred, f ir.FloatPred, t types.Type, l
 func CreateBinaryOp(intstr, fltstr string, blk *ir.BasicBlock, t types.Type, left, right value.Value) value.Value {
 
 	var val *GeodeBinaryInstr
-	if types.IsInt(t) {
+	if types.IsInt(scalarType(t)) {
+		if unsigned, found := unsignedOperators[intstr]; found && types.IsUnsigned(scalarType(t)) {
+			intstr = unsigned
+		}
 		val = NewGeodeBinaryInstr(intstr, left, right)
 	} else {
 		val = NewGeodeBinaryInstr(fltstr, left, right)
@@ -38,6 +45,9 @@ func CreateBinaryOp(intstr, fltstr string, blk *ir.BasicBlock, t types.Type, lef
 	return val
 }
 
+// numericalBinaryOperator is the instruction an operator is on integers
+// and on floats. Bitwise operators have no float instruction, as they
+// only work on integers.
 type numericalBinaryOperator struct {
 	I string
 	F string
@@ -54,11 +64,11 @@ var binaryOperatorTypeMap = map[string]numericalBinaryOperator{
 	"*":  {"mul", "fmul"},
 	"/":  {"sdiv", "fdiv"},
 	"%":  {"srem", "frem"},
-	">>": {"lshr", "lshr"},
-	"<<": {"shl", "shl"},
-	"||": {"or", "or"},
-	"&&": {"and", "and"},
-	"^":  {"xor", "xor"},
+	">>": {"lshr", ""},
+	"<<": {"shl", ""},
+	"^":  {"xor", ""},
+	"&":  {"and", ""},
+	"|":  {"or", ""},
 }
 
 var booleanComparisonOperatorMap = map[string]comparisonOperation{
@@ -70,6 +80,24 @@ var booleanComparisonOperatorMap = map[string]comparisonOperation{
 	"<=": {ir.IntSLE, ir.FloatOLE},
 }
 
+// unsignedOperators are the instructions used instead of the ones in
+// binaryOperatorTypeMap on unsigned integers
+var unsignedOperators = map[string]string{
+	"sdiv": "udiv",
+	"srem": "urem",
+}
+
+// unsignedComparisons are the predicates used instead of the ones in
+// booleanComparisonOperatorMap on unsigned integers
+var unsignedComparisons = map[ir.IntPred]ir.IntPred{
+	ir.IntEQ:  ir.IntEQ,
+	ir.IntNE:  ir.IntNE,
+	ir.IntSGT: ir.IntUGT,
+	ir.IntSGE: ir.IntUGE,
+	ir.IntSLT: ir.IntULT,
+	ir.IntSLE: ir.IntULE,
+}
+
 // BinaryNode is a representation of a binary operation
 type BinaryNode struct {
 	NodeType
@@ -90,28 +118,45 @@ func (n BinaryNode) String() string {
 // NameString implements Node.NameString
 func (n BinaryNode) NameString() string { return "BinaryNode" }
 
+// MarshalJSON implements json.Marshaler for BinaryNode
+func (n BinaryNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // GenAccess implements Accessable.GenAccess
 func (n BinaryNode) GenAccess(prog *Program) (value.Value, error) {
 	return n.Codegen(prog)
 }
 
+// compoundOperators maps each compound assignment to the binary operator
+// it applies, so `a += b` is compiled as `a = a + b`
+var compoundOperators = map[string]string{
+	"+=":  "+",
+	"-=":  "-",
+	"*=":  "*",
+	"/=":  "/",
+	"%=":  "%",
+	"<<=": "<<",
+	">>=": ">>",
+	"&=":  "&",
+	"|=":  "|",
+	"^=":  "^",
+}
+
 // CodegenCompoundOperator generates a compound operator expression
 func CodegenCompoundOperator(prog *Program, left, right Node, compop string) (value.Value, error) {
-	var op string
 	var ok bool
 
-	switch compop {
-	case "+=":
-		op = "+"
-	case "-=":
-		op = "-"
-	case "*=":
-		op = "*"
-	case "/=":
-		op = "/"
-	default:
+	op, found := compoundOperators[compop]
+	if !found {
 		return nil, fmt.Errorf("unknown compound assignment %q", compop)
+	}
 
+	// the object a field is read from and assigned in is generated once
+	if dot, isDot := left.(DotReference); isDot && !dot.isStatic(prog) {
+		evaluated, err := dot.evaluated(prog)
+		if err != nil {
+			return nil, err
+		}
+		left = evaluated
 	}
 
 	n := AssignmentNode{}
@@ -150,12 +195,19 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 		a.Assignee = lhs
 		a.Value = rhs
 		a.NodeType = nodeAssignment
+		a.TokenReference = n.TokenReference
 		return a.Codegen(prog)
 	}
 
-	switch n.OP {
-	case "+=", "-=", "*=", "/=":
+	if _, isCompound := compoundOperators[n.OP]; isCompound {
 		return CodegenCompoundOperator(prog, n.Left, n.Right, n.OP)
+	}
+
+	if n.OP == "<-" {
+		return genChanMethod(prog, n.TokenReference, n.Left, chanSendMethod, n.Right)
+	}
+
+	switch n.OP {
 	case "+", "-":
 		add := AddSubNode{}
 		add.Left = n.Left
@@ -164,11 +216,12 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 		add.TokenReference = n.TokenReference
 		add.NodeType = nodeBinary
 		return add.Codegen(prog)
+	case "&&", "||":
+		return n.genShortCircuit(prog)
 	}
 
 	if n.Left == nil || n.Right == nil {
-		n.SyntaxError()
-		return nil, fmt.Errorf("invalid binary expression")
+		return nil, n.Errorf(ErrInvalid, "invalid binary expression")
 	}
 	// Generate the left and right nodes
 	l, err := n.Left.Codegen(prog)
@@ -180,15 +233,28 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 		return nil, err
 	}
 
+	if val, overloaded, err := genOperatorCall(prog, n.OP, l, r); overloaded || err != nil {
+		if err != nil {
+			return nil, n.Diagnose(err)
+		}
+		return val, nil
+	}
+
+	if err := checkVectorOperands(l, r); err != nil {
+		return nil, n.Diagnose(err)
+	}
+
+	// pointer arithmetic gives a pointer, comparing pointers gives a bool
+	_, isComparison := booleanComparisonOperatorMap[n.OP]
 	mustCastToPtr := false
 	var finalPointerType types.Type
 
-	if types.IsPointer(l.Type()) {
+	if types.IsPointer(l.Type()) && !isComparison {
 		mustCastToPtr = true
 		finalPointerType = l.Type()
 	}
 
-	if types.IsPointer(r.Type()) {
+	if types.IsPointer(r.Type()) && !isComparison {
 		mustCastToPtr = true
 		finalPointerType = r.Type()
 	}
@@ -198,8 +264,13 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 	l, r, t, resultcast := binaryCast(prog, l, r)
 
 	if l == nil || r == nil {
-		n.SyntaxError()
-		return nil, fmt.Errorf("an operand to a binary operation `%s` was nil and failed to generate", n.OP)
+		return nil, n.Errorf(ErrInvalid, "an operand to a binary operation `%s` was nil and failed to generate", n.OP)
+	}
+
+	if resultcast == nil {
+		if folded, ok := foldConstantValues(n.OP, l, r, t); ok {
+			return folded, nil
+		}
 	}
 
 	blk := prog.Compiler.CurrentBlock()
@@ -207,6 +278,9 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 	var value value.Value
 
 	if op, valid := binaryOperatorTypeMap[n.OP]; valid {
+		if op.F == "" && !types.IsInt(scalarType(t)) {
+			return nil, n.Errorf(ErrType, "operator %s only works on integers, not %s", n.OP, t)
+		}
 		value = CreateBinaryOp(op.I, op.F, blk, t, l, r)
 	}
 
@@ -218,7 +292,7 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 		return nil, fmt.Errorf("invalid binary operator %s", n.OP)
 	}
 
-	if resultcast != nil {
+	if resultcast != nil && !isComparison {
 		value, _ = createTypeCast(prog, value, resultcast)
 	}
 
@@ -230,8 +304,109 @@ func (n BinaryNode) Codegen(prog *Program) (value.Value, error) {
 
 }
 
+// genShortCircuit generates `a && b` or `a || b`, which only evaluates b
+// if a doesn't already decide the result, so `p != nil && p.ok` never
+// reads through a nil pointer. The result is a bool.
+func (n BinaryNode) genShortCircuit(prog *Program) (value.Value, error) {
+	if n.Left == nil || n.Right == nil {
+		return nil, n.Errorf(ErrInvalid, "invalid binary expression")
+	}
+	// the value of the expression when the left side decides it
+	and := n.OP == "&&"
+	short := constant.NewInt(1, types.I1)
+	if and {
+		short = constant.NewInt(0, types.I1)
+	}
+
+	l, err := n.Left.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	lcond, err := truthValue(prog, l)
+	if err != nil {
+		return nil, n.Left.Diagnose(err)
+	}
+
+	// a constant left side decides at compile time if the right side is
+	// evaluated at all
+	if c, ok := lcond.(*constant.Int); ok {
+		if (c.X.Sign() != 0) != and {
+			return short, nil
+		}
+		r, err := n.Right.Codegen(prog)
+		if err != nil {
+			return nil, err
+		}
+		return truthValue(prog, r)
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	rightBlk := block.Parent.NewBlock(mangleName("logic.rhs"))
+	endBlk := block.Parent.NewBlock(mangleName("logic.end"))
+	if and {
+		block.NewCondBr(lcond, rightBlk, endBlk)
+	} else {
+		block.NewCondBr(lcond, endBlk, rightBlk)
+	}
+
+	prog.Compiler.PushBlock(rightBlk)
+	r, err := n.Right.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	rcond, err := truthValue(prog, r)
+	if err != nil {
+		return nil, n.Right.Diagnose(err)
+	}
+	// the right side may have branched, so the phi comes from the block
+	// it ended in
+	rightEnd := prog.Compiler.CurrentBlock()
+	rightEnd.NewBr(endBlk)
+
+	prog.Compiler.PushBlock(endBlk)
+	return endBlk.NewPhi(ir.NewIncoming(short, block), ir.NewIncoming(rcond, rightEnd)), nil
+}
+
+// truthValue returns if a value is true as a bool. Numbers are true when
+// they aren't zero, and pointers and strings when they aren't nil.
+func truthValue(prog *Program, v value.Value) (value.Value, error) {
+	if isString(v.Type()) {
+		v = prog.stringData(v)
+	}
+	block := prog.Compiler.CurrentBlock()
+	switch t := v.Type().(type) {
+	case *types.IntType:
+		if t.Size == 1 {
+			return v, nil
+		}
+		// a new constant, as casts retype constants in place
+		if c, ok := v.(*constant.Int); ok {
+			if c.X.Sign() == 0 {
+				return constant.NewInt(0, types.I1), nil
+			}
+			return constant.NewInt(1, types.I1), nil
+		}
+		return block.NewICmp(ir.IntNE, v, constant.NewInt(0, t)), nil
+	case *types.FloatType:
+		return block.NewFCmp(ir.FloatUNE, v, constant.NewFloat(0, t)), nil
+	case *types.PointerType:
+		return block.NewICmp(ir.IntNE, v, constant.NewNull(t)), nil
+	}
+	return nil, fmt.Errorf("%s can't be used as a condition", v.Type())
+}
+
 func binaryCast(prog *Program, left, right value.Value) (value.Value, value.Value, types.Type, types.Type) {
 
+	// a scalar used with a vector is copied into every element
+	if vec, ok := left.Type().(*types.VectorType); ok {
+		right, _ = createTypeCast(prog, right, vec)
+		return left, right, vec, nil
+	}
+	if vec, ok := right.Type().(*types.VectorType); ok {
+		left, _ = createTypeCast(prog, left, vec)
+		return left, right, vec, nil
+	}
+
 	var resultcast types.Type
 	if types.IsPointer(left.Type()) {
 		left = prog.Compiler.CurrentBlock().NewPtrToInt(left, types.I64)
@@ -253,6 +428,12 @@ func binaryCast(prog *Program, left, right value.Value) (value.Value, value.Valu
 	leftPrec := prog.CastPrecidence(lt)
 	rightPrec := prog.CastPrecidence(rt)
 
+	// an unsigned operand makes the operation unsigned when both sides are
+	// as wide, as in c
+	if leftPrec == rightPrec && types.IsUnsigned(lt) {
+		leftPrec++
+	}
+
 	if leftPrec > rightPrec {
 		casted = lt
 		right, _ = createTypeCast(prog, right, lt)
@@ -290,6 +471,9 @@ func (n AddSubNode) String() string {
 // NameString implements Node.NameString
 func (n AddSubNode) NameString() string { return "AddSubNode" }
 
+// MarshalJSON implements json.Marshaler for AddSubNode
+func (n AddSubNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // GenAccess implements Accessable.GenAccess
 func (n AddSubNode) GenAccess(prog *Program) (value.Value, error) {
 	return n.Codegen(prog)
@@ -314,11 +498,31 @@ func (n AddSubNode) Codegen(prog *Program) (value.Value, error) {
 	if err != nil {
 		return nil, err
 	}
+	op := "+"
+	if n.Sub {
+		op = "-"
+	}
+	if val, overloaded, err := genOperatorCall(prog, op, l, r); overloaded || err != nil {
+		if err != nil {
+			return nil, n.Diagnose(err)
+		}
+		return val, nil
+	}
+	if err := checkVectorOperands(l, r); err != nil {
+		return nil, n.Diagnose(err)
+	}
+
 	// TODO: handle unsigned numbers... (maybe)
 	left, right, t, resultcast := binaryCast(prog, l, r)
 
+	if resultcast == nil {
+		if folded, ok := foldConstantValues(op, left, right, t); ok {
+			return folded, nil
+		}
+	}
+
 	// float add/sub operations on numeric types are prefixed with 'f'
-	if types.IsFloat(t) {
+	if types.IsFloat(scalarType(t)) {
 		opname = "f" + opname
 	}
 
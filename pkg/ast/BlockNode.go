@@ -19,18 +19,38 @@ type BlockNode struct {
 // NameString implements Node.NameString
 func (n BlockNode) NameString() string { return "BlockNode" }
 
+// MarshalJSON implements json.Marshaler for BlockNode
+func (n BlockNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for BlockNode
 func (n BlockNode) Codegen(prog *Program) (value.Value, error) {
 	prog.ScopeDown(n.Token)
 
 	for _, node := range n.Nodes {
 
-		_, err := node.Codegen(prog)
+		prog.debugStatementStart(node)
+		prog.coverStatement(node)
+		temporaries := prog.arcTemporaries()
+		val, err := node.Codegen(prog)
 		if err != nil {
-			return nil, err
+			// errors that aren't placed anywhere more precise are placed
+			// at the statement
+			return nil, node.Diagnose(err)
+		}
+		// the result of a call made for what it does is thrown away
+		switch node.(type) {
+		case FunctionCallNode, NewNode:
+			if _, err := prog.arcTemporary(val); err != nil {
+				return nil, node.Diagnose(err)
+			}
+		}
+		if err := prog.arcReleaseTemporaries(temporaries); err != nil {
+			return nil, node.Diagnose(err)
 		}
+		prog.debugStatementEnd()
 
-		if _, isReturn := node.(ReturnNode); isReturn {
+		// nothing after a return or a panic is ever run
+		if block := prog.Compiler.CurrentBlock(); block != nil && block.Term != nil {
 			break
 		}
 	}
@@ -41,22 +61,17 @@ func (n BlockNode) Codegen(prog *Program) (value.Value, error) {
 	return prog.Compiler.CurrentBlock(), nil
 }
 
-var blockindentdepth = 0
-
 func (n BlockNode) String() string {
 
 	buff := &bytes.Buffer{}
 
 	fmt.Fprintf(buff, "{\n")
 
-	blockindentdepth++
-
+	// the lines of nested blocks are indented once by each block around them
 	for _, node := range n.Nodes {
-		fmt.Fprintf(buff, "%s%s\n", strings.Repeat("\t", blockindentdepth), node)
+		fmt.Fprintf(buff, "\t%s\n", strings.Replace(fmt.Sprint(node), "\n", "\n\t", -1))
 	}
 
-	blockindentdepth--
-
-	fmt.Fprintf(buff, "%s}", strings.Repeat("\t", blockindentdepth))
+	fmt.Fprintf(buff, "}")
 	return buff.String()
 }
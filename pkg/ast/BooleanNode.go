@@ -18,6 +18,9 @@ type BooleanNode struct {
 // NameString implements Node.NameString
 func (n BooleanNode) NameString() string { return "BooleanNode" }
 
+// MarshalJSON implements json.Marshaler for BooleanNode
+func (n BooleanNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for BooleanNode
 func (n BooleanNode) Codegen(prog *Program) (value.Value, error) {
 	options := map[string]int64{
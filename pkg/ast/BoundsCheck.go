@@ -0,0 +1,75 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/util"
+)
+
+// boundsChecks returns if indexes are checked against the length of what
+// they index. They are with --bounds-checks, and in debug builds.
+func (p *Program) boundsChecks() bool {
+	return (*arg.BoundsChecks || *arg.EnableDebug) && !p.NoRuntime
+}
+
+// checkBounds checks the index of a subscript against the length of the
+// slice, string or vec it indexes, calling panic_bounds in the runtime
+// when it is out of range. Pointers don't know their length, so they are
+// never checked. The code after the check is generated in the block it
+// continues in.
+func (n SubscriptNode) checkBounds(prog *Program, src, idx value.Value) error {
+	if !prog.boundsChecks() || (!types.IsSlice(src.Type()) && !isString(src.Type()) && !isVec(src.Type())) {
+		return nil
+	}
+	idxType, ok := idx.Type().(*types.IntType)
+	if !ok {
+		return nil
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	var length value.Value
+	if isString(src.Type()) {
+		length = prog.stringLen(src)
+	} else if isVec(src.Type()) {
+		length = prog.vecLen(src)
+	} else {
+		length = block.NewExtractValue(src, []int64{1})
+	}
+	lenType := length.Type().(*types.IntType)
+	if idxType.Size < lenType.Size {
+		if idxType.Unsigned {
+			idx = block.NewZExt(idx, lenType)
+		} else {
+			idx = block.NewSExt(idx, lenType)
+		}
+	}
+
+	// a negative index is a huge one when compared unsigned
+	var outside value.Value
+	if idxType.Size > lenType.Size {
+		outside = block.NewICmp(ir.IntUGE, idx, block.NewZExt(length, idxType))
+		idx = block.NewTrunc(idx, lenType)
+	} else {
+		outside = block.NewICmp(ir.IntUGE, idx, length)
+	}
+
+	parentFunc := block.Parent
+	failBlk := parentFunc.NewBlock(mangleName("bounds.fail"))
+	okBlk := parentFunc.NewBlock(mangleName("bounds.ok"))
+	block.NewCondBr(outside, failBlk, okBlk)
+
+	prog.Compiler.PushBlock(failBlk)
+	file := formatStringConstant(prog, util.TrimPath(n.Token.SourcePath()))
+	line := constant.NewInt(int64(n.Token.Line), types.I32)
+	if _, err := prog.NewRuntimeFunctionCall("panic_bounds", idx, length, file, line); err != nil {
+		return err
+	}
+	failBlk.NewUnreachable()
+	prog.Compiler.PopBlock()
+
+	prog.Compiler.PushBlock(okBlk)
+	return nil
+}
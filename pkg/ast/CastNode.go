@@ -3,22 +3,30 @@ package ast
 import (
 	"fmt"
 
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
 
-// CastNode is a structure around a typecast expression
+// CastNode is a structure around a typecast expression, `x as T`. A
+// checked cast, `x as! T*`, casts a pointer to an instance of a class to
+// a pointer to a class that extends it, and checks that the instance is
+// one while the program runs.
 type CastNode struct {
 	NodeType
 	TokenReference
 
-	Source Node
-	Type   TypeNode
+	Source  Node
+	Type    TypeNode
+	Checked bool
 }
 
 // NameString implements Node.NameString
 func (n CastNode) NameString() string { return "CastNode" }
 
+// MarshalJSON implements json.Marshaler for CastNode
+func (n CastNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // GenAccess implements Accessable.Access for CastNode
 func (n CastNode) GenAccess(prog *Program) (value.Value, error) {
 	return n.Codegen(prog)
@@ -38,9 +46,48 @@ func (n CastNode) Codegen(prog *Program) (value.Value, error) {
 	for i := 0; i < n.Type.PointerLevel; i++ {
 		t = types.NewPointer(t)
 	}
+	if n.Checked {
+		if err := n.check(prog, src, t); err != nil {
+			return nil, n.Diagnose(err)
+		}
+	}
+	if str, isString, err := prog.stringCast(src, t); isString {
+		if err != nil {
+			return nil, n.Diagnose(err)
+		}
+		return str, nil
+	}
 	return createTypeCast(prog, src, t)
 }
 
+// check generates the check of a checked cast of src to the type t
+func (n CastNode) check(prog *Program, src value.Value, t types.Type) error {
+	from, fromPtr := src.Type().(*types.PointerType)
+	to, toPtr := t.(*types.PointerType)
+	if !fromPtr || !toPtr || prog.virtualClassOf(from.Elem) == nil || prog.virtualClassOf(to.Elem) == nil {
+		return fmt.Errorf("as! casts pointers to classes that extend or are extended, unable to cast %s to %s", src.Type(), t)
+	}
+
+	// a cast to the class or one it extends always works
+	if types.Equal(from, to) || prog.isUpcast(from, to) {
+		return nil
+	}
+	if !prog.isUpcast(to, from) {
+		return fmt.Errorf("unable to cast %s to %s, it doesn't extend %s", src.Type(), t, from.Elem)
+	}
+
+	vtable, err := prog.classVTable(prog.virtualClassOf(to.Elem))
+	if err != nil {
+		return err
+	}
+	obj := prog.Compiler.CurrentBlock().NewBitCast(src, types.NewPointer(types.I8))
+	_, err = prog.NewRuntimeFunctionCall("__runtime_check_cast", obj, constant.NewBitCast(vtable, types.NewPointer(types.I8)))
+	return err
+}
+
 func (n CastNode) String() string {
+	if n.Checked {
+		return fmt.Sprintf("%s as! %s", n.Source, n.Type)
+	}
 	return fmt.Sprintf("%s as %s", n.Source, n.Type)
 }
@@ -0,0 +1,77 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// chan<T> sends values of T between threads. It is the runtime class
+// chan<T>, the struct `{c}` of the channel chan.c makes when it is opened,
+// so copies of a chan are the same chan. `ch <- val` sends a value with the
+// chan's send method and `<-ch` receives one with recv, both of which wait
+// while the chan is full or empty.
+const chanClass = "chan"
+
+// the methods of a chan the operators call
+const (
+	chanSendMethod = "send"
+	chanRecvMethod = "recv"
+)
+
+// isChan returns if a type is an instance of chan<T>
+func isChan(t types.Type) bool {
+	st, isStruct := t.(*types.StructType)
+	return isStruct && len(st.Fields) == 1 && strings.HasPrefix(st.Name, fmt.Sprintf("class.runtime:%sI", chanClass))
+}
+
+// genChanMethod calls a method of the chan ch is. The chan is stored in a
+// hidden variable first, like the source of a for-each loop, so it has an
+// address to call the method on and is only evaluated once.
+func genChanMethod(prog *Program, at TokenReference, ch Node, method string, args ...Node) (value.Value, error) {
+	name := mangleName("__chan")
+	prog.ScopeDown(at.Token)
+
+	tmp := VariableDefnNode{}
+	tmp.Token = at.Token
+	tmp.NodeType = nodeVariableDecl
+	tmp.Name = NewIdentNode(name)
+	tmp.NeedsInference = true
+	tmp.HasValue = true
+	tmp.Body = ch
+	if _, err := tmp.Codegen(prog); err != nil {
+		return nil, err
+	}
+
+	item, _ := prog.Scope.Find([]string{name})
+	t := item.Value().Type().(*types.PointerType).Elem
+	if !isChan(t) {
+		return nil, at.Errorf(ErrType, "<- needs a chan, given %s", prog.typeName(t))
+	}
+
+	dot := DotReference{}
+	dot.Token = at.Token
+	dot.NodeType = nodeDot
+	dot.Base = NewIdentNode(name)
+	dot.Field = NewIdentNode(method)
+
+	call := FunctionCallNode{}
+	call.Token = at.Token
+	call.NodeType = nodeFunctionCall
+	call.Name = dot
+	call.Args = args
+	val, err := call.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	return val, prog.ScopeUp()
+}
+
+// isChanRecv returns if a token is the <- of a receive that starts a
+// statement, which throws away the value received
+func isChanRecv(tok lexer.Token) bool {
+	return tok.Is(lexer.TokOper) && tok.Value == "<-"
+}
@@ -1,14 +1,12 @@
 package ast
 
 import (
-	"bytes"
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
 	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
-	"github.com/geode-lang/geode/pkg/util/color"
 )
 
 // ClassNode -
@@ -20,11 +18,33 @@ type ClassNode struct {
 	Name      string
 	Methods   []FunctionNode
 	Variables []VariableDefnNode
+	Pub       bool // exported from its package with pub
+	// Align is the alignment every instance is given with @align, or 0
+	Align int
+
+	// Implements are the names given after `is`, the interfaces the class
+	// implements and the class it extends
+	Implements []string
+
+	// StaticMethods and StaticFields are the members declared with static,
+	// compiled as the functions and globals named `Class.member`
+	StaticMethods []FunctionNode
+	StaticFields  []GlobalVariableDeclNode
+
+	// TypeParams are the names given in `<...>` after the name of a
+	// generic class. Generic classes are only compiled when instantiated.
+	TypeParams []string
+	// TypeBindings are the types the type parameters stand for in an
+	// instance of a generic class
+	TypeBindings map[string]types.Type
 }
 
 // NameString implements Node.NameString
 func (n ClassNode) NameString() string { return "ClassNode" }
 
+// MarshalJSON implements json.Marshaler for ClassNode
+func (n ClassNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // VerifyCorrectness checks if a class will cause any problems when we pass it off to clang
 // some problems might include the following:
 // -  class Foo {
@@ -37,6 +57,10 @@ func (n ClassNode) NameString() string { return "ClassNode" }
 //        Foo b;
 //    }
 func (n ClassNode) VerifyCorrectness(prog *Program) error {
+	if len(n.TypeParams) > 0 {
+		return nil
+	}
+
 	found, err := prog.FindType(n.Name)
 	if err != nil {
 		return err
@@ -71,10 +95,7 @@ func (n ClassNode) VerifyCorrectness(prog *Program) error {
 			structT := ty.(*types.StructType)
 
 			if contains, _, _ := structContainsTypeAnywhere(structT, base, structT); contains {
-				buff := &bytes.Buffer{}
-				fmt.Fprintf(buff, "* class %s has a field %s of type %s which eventually back references %s (would consume 'infinite' stack memory)\n", color.Green(n.Name), color.Blue(fieldName), color.Red(t), color.Green(n.Name))
-				fmt.Fprintf(buff, "  Either change %s to a pointer or remove the back-reference from %s\n\n", color.Blue(fieldName), color.Red(t))
-				return fmt.Errorf("%s", buff)
+				return fmt.Errorf("class %s has a field %s of type %s which eventually refers back to %s, change %s to a pointer", n.Name, fieldName, t, n.Name, fieldName)
 			}
 		}
 	}
@@ -100,26 +121,57 @@ func (n ClassNode) String() string {
 	return fmt.Sprintf("class %s {}", n.Name)
 }
 
-// Declare a class type
+// staticMembers returns the static members of the class as the top level
+// functions and globals they are compiled as. They are as visible as the
+// class is.
+func (n ClassNode) staticMembers() []Node {
+	members := make([]Node, 0, len(n.StaticMethods)+len(n.StaticFields))
+	for _, fn := range n.StaticMethods {
+		fn.Name.Value = fmt.Sprintf("%s.%s", n.Name, fn.Name)
+		fn.Pub = n.Pub
+		members = append(members, fn)
+	}
+	for _, field := range n.StaticFields {
+		field.Name.Value = fmt.Sprintf("%s.%s", n.Name, field.Name)
+		field.Pub = n.Pub
+		members = append(members, field)
+	}
+	return members
+}
+
+// Declare a class type. Every class is declared as an opaque struct before
+// any of them are defined, so classes can refer to each other in any order.
 func (n ClassNode) Declare(prog *Program) (value.Value, error) {
+	if len(n.TypeParams) > 0 {
+		return nil, nil
+	}
+
 	structDefn := types.NewStruct()
+	structDefn.Opaque = true
 
 	name := fmt.Sprintf("class.%s:%s", prog.Scope.PackageName, n.Name)
 	structDefn.SetName(name)
 
 	prog.Module.NewType(n.Name, structDefn)
+	if n.Align != 0 {
+		prog.typeAlignments[structDefn] = n.Align
+	}
 
 	scopeName := n.Name
 	if prog.Package.Name != "runtime" {
 		scopeName = fmt.Sprintf("%s:%s", prog.Scope.PackageName, n.Name)
 	}
 	prog.Scope.GetRoot().RegisterType(scopeName, structDefn, -1)
+	prog.Scope.GetRoot().SetTypeVisibility(scopeName, prog.Package.Name, visibilityOf(n.Pub))
 
 	return nil, nil
 }
 
 // Codegen implements Node.Codegen for ClassNode
 func (n ClassNode) Codegen(prog *Program) (value.Value, error) {
+	if len(n.TypeParams) > 0 {
+		return nil, nil
+	}
 
 	found, err := prog.FindType(n.Name)
 	if err != nil {
@@ -133,6 +185,19 @@ func (n ClassNode) Codegen(prog *Program) (value.Value, error) {
 
 	names := map[string]bool{}
 
+	// The fields of the class it extends come first, and the first class
+	// in a hierarchy starts with the pointer to the vtable
+	if parent := prog.classParents[structDefn]; parent != nil {
+		fields = append(fields, parent.Fields...)
+		fieldnames = append(fieldnames, parent.Names...)
+		for _, name := range parent.Names {
+			names[name] = true
+		}
+	} else if prog.extendedClasses[structDefn] {
+		fields = append(fields, types.NewPointer(types.I8))
+		fieldnames = append(fieldnames, vtableField)
+	}
+
 	for _, f := range n.Variables {
 		name := f.Name.String()
 		if _, found := names[name]; found {
@@ -161,6 +226,8 @@ func (n ClassNode) Codegen(prog *Program) (value.Value, error) {
 
 	structDefn.Fields = fields
 	structDefn.Names = fieldnames
+	structDefn.Opaque = false
+	prog.setWeakFields(structDefn, n.Variables)
 
 	// methodBaseArgs := []VariableDefnNode{thisArg}
 	for _, fn := range n.Methods {
@@ -168,7 +235,8 @@ func (n ClassNode) Codegen(prog *Program) (value.Value, error) {
 		// Prepend the "this" argument to the function
 		fn.Args = append([]FunctionArg{thisArg}, fn.Args...)
 		fn.Name.Value = fmt.Sprintf("%s:%s.%s", prog.Package.Name, n.Name, fn.Name)
-		fn.Package = n.Package
+		fn.Package = prog.Package
+		fn.TypeBindings = n.TypeBindings
 
 		if _, found := names[fn.Name.String()]; found {
 			return nil, fmt.Errorf("class '%s' has two fields/methods named '%s'", n.Name, fn.Name)
@@ -178,9 +246,40 @@ func (n ClassNode) Codegen(prog *Program) (value.Value, error) {
 		prog.RegisterFunction(fn.Name.Value, fn)
 	}
 
+	for _, fn := range n.StaticMethods {
+		if names[fn.Name.String()] || n.hasMethod(fn.Name.String()) {
+			return nil, fmt.Errorf("class '%s' has two fields/methods named '%s'", n.Name, fn.Name)
+		}
+	}
+	for _, field := range n.StaticFields {
+		if names[field.Name.String()] || n.hasMethod(field.Name.String()) {
+			return nil, fmt.Errorf("class '%s' has two fields/methods named '%s'", n.Name, field.Name)
+		}
+	}
+
+	if prog.classParents[structDefn] != nil || prog.extendedClasses[structDefn] {
+		if err := prog.declareVirtualClass(n, structDefn); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := prog.implementInterfaces(n, structDefn); err != nil {
+		return nil, err
+	}
+
 	return nil, nil
 }
 
+// hasMethod reports if the class has a method with a name
+func (n ClassNode) hasMethod(name string) bool {
+	for _, fn := range n.Methods {
+		if fn.Name.String() == name {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateClassConstruction creates a function call to a class's constructor if it exists.
 func GenerateClassConstruction(name string, typ types.Type, s *Scope, c *Compiler, args []value.Value) value.Value {
 	alloc := c.CurrentBlock().NewAlloca(typ)
@@ -194,6 +293,7 @@ func GenerateClassConstruction(name string, typ types.Type, s *Scope, c *Compile
 func NewClassInstance(prog *Program, stct *types.StructType, fields map[string]value.Value) value.Value {
 
 	alloc := prog.Compiler.CurrentBlock().NewAlloca(stct)
+	alloc.Align = prog.alignment(stct, 0)
 
 	for field, value := range fields {
 		GenStructFieldAssignment(prog, alloc, field, value)
@@ -0,0 +1,343 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/arg"
+)
+
+// Cleanup runs a peephole pass over every function in the module. The
+// code generator emits blocks freely, like the else block every if gets,
+// so this removes what is left over: branches that can only go one way,
+// unreachable and empty blocks, blocks that can be merged into their only
+// predecessor, and allocas that are only ever stored to. The allocas of
+// variables that are only loaded and stored become ssa values. It doesn't
+// change what the program does, only how much ir it takes to say it.
+func (p *Program) Cleanup() {
+	for _, fn := range p.Module.Funcs {
+		cleanupFunction(fn)
+	}
+}
+
+func cleanupFunction(fn *ir.Function) {
+	if len(fn.Blocks) == 0 {
+		return
+	}
+	// a function with an unterminated block is invalid already, and
+	// rewriting it would only make the error harder to find
+	for _, block := range fn.Blocks {
+		if block.Term == nil {
+			return
+		}
+	}
+
+	for cleanupFoldBranches(fn) || cleanupUnreachableBlocks(fn) || cleanupEmptyBlocks(fn) || cleanupMergeBlocks(fn) {
+	}
+	for cleanupDeadAllocas(fn) {
+	}
+	// debuggers find variables in their allocas
+	if !*arg.EnableDebug {
+		cleanupPromoteAllocas(fn)
+	}
+}
+
+// cleanupFoldBranches turns conditional branches that always go to the
+// same block into unconditional ones
+func cleanupFoldBranches(fn *ir.Function) bool {
+	changed := false
+	for _, block := range fn.Blocks {
+		br, ok := block.Term.(*ir.TermCondBr)
+		if !ok {
+			continue
+		}
+		target := br.TargetTrue
+		if br.TargetTrue != br.TargetFalse {
+			cond, isConst := br.Cond.(*constant.Int)
+			if !isConst || blockHasPhis(br.TargetTrue) || blockHasPhis(br.TargetFalse) {
+				continue
+			}
+			if cond.X.Sign() == 0 {
+				target = br.TargetFalse
+			}
+		}
+		block.NewBr(target)
+		changed = true
+	}
+	return changed
+}
+
+// cleanupUnreachableBlocks removes every block that can't be reached from
+// the entry block
+func cleanupUnreachableBlocks(fn *ir.Function) bool {
+	reachable := map[*ir.BasicBlock]bool{fn.Blocks[0]: true}
+	work := []*ir.BasicBlock{fn.Blocks[0]}
+	for len(work) > 0 {
+		block := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, succ := range block.Term.Succs() {
+			if !reachable[succ] {
+				reachable[succ] = true
+				work = append(work, succ)
+			}
+		}
+	}
+
+	if len(reachable) == len(fn.Blocks) {
+		return false
+	}
+
+	blocks := fn.Blocks[:0]
+	for _, block := range fn.Blocks {
+		if reachable[block] {
+			blocks = append(blocks, block)
+		}
+	}
+	fn.Blocks = blocks
+
+	// phis can't have incoming values from blocks that are gone
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			phi, ok := inst.(*ir.InstPhi)
+			if !ok {
+				break
+			}
+			incs := phi.Incs[:0]
+			for _, inc := range phi.Incs {
+				if reachable[inc.Pred] {
+					incs = append(incs, inc)
+				}
+			}
+			phi.Incs = incs
+		}
+	}
+	return true
+}
+
+// cleanupEmptyBlocks makes the predecessors of blocks that only branch on
+// to another block branch there directly. The empty blocks are then
+// unreachable and get removed.
+func cleanupEmptyBlocks(fn *ir.Function) bool {
+	preds := blockPredecessors(fn)
+	changed := false
+	for _, block := range fn.Blocks[1:] {
+		br, ok := block.Term.(*ir.TermBr)
+		if !ok || len(block.Insts) > 0 || br.Target == block || blockHasPhis(br.Target) {
+			continue
+		}
+		for _, pred := range preds[block] {
+			retargetBranch(pred.Term, block, br.Target)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// cleanupMergeBlocks merges a block into its only predecessor when that
+// predecessor always branches to it
+func cleanupMergeBlocks(fn *ir.Function) bool {
+	preds := blockPredecessors(fn)
+	for _, block := range fn.Blocks {
+		br, ok := block.Term.(*ir.TermBr)
+		if !ok {
+			continue
+		}
+		succ := br.Target
+		if succ == block || succ == fn.Blocks[0] || len(preds[succ]) != 1 || blockHasPhis(succ) {
+			continue
+		}
+
+		for _, inst := range succ.Insts {
+			block.AppendInst(inst)
+		}
+		block.SetTerm(succ.Term)
+
+		// phis further on now come from the merged block
+		for _, next := range succ.Term.Succs() {
+			for _, inst := range next.Insts {
+				phi, ok := inst.(*ir.InstPhi)
+				if !ok {
+					break
+				}
+				for _, inc := range phi.Incs {
+					if inc.Pred == succ {
+						inc.Pred = block
+					}
+				}
+			}
+		}
+
+		removeBlock(fn, succ)
+		return true
+	}
+	return false
+}
+
+// cleanupDeadAllocas removes allocas that are never read, along with the
+// stores to them. A volatile or atomic store has to happen even if nothing
+// reads it, so it keeps its alloca.
+func cleanupDeadAllocas(fn *ir.Function) bool {
+	allocas := make(map[*ir.InstAlloca]bool)
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstAlloca:
+				allocas[inst] = true
+			case *LLVMRaw, *LLVMIdent:
+				// raw ir can refer to anything by name
+				return false
+			}
+		}
+	}
+
+	// any use other than being stored to keeps an alloca alive
+	markUsed := func(operands []value.Value) {
+		for _, op := range operands {
+			if alloca, ok := op.(*ir.InstAlloca); ok {
+				delete(allocas, alloca)
+			}
+		}
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			if store, ok := inst.(*ir.InstStore); ok && !store.Volatile && store.Ordering == ir.OrderingNone {
+				markUsed([]value.Value{store.Src})
+				continue
+			}
+			markUsed(instructionOperands(inst))
+		}
+		markUsed(instructionOperands(block.Term))
+	}
+
+	if len(allocas) == 0 {
+		return false
+	}
+
+	for _, block := range fn.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstAlloca:
+				if allocas[inst] {
+					continue
+				}
+			case *ir.InstStore:
+				if alloca, ok := inst.Dst.(*ir.InstAlloca); ok && allocas[alloca] {
+					continue
+				}
+			}
+			insts = append(insts, inst)
+		}
+		block.Insts = insts
+	}
+	return true
+}
+
+var valueType = reflect.TypeOf((*value.Value)(nil)).Elem()
+
+// instructionOperands returns the values an instruction uses. They are
+// found from its fields, so the compiler's own instructions are covered
+// as well as the ones from the ir package.
+func instructionOperands(inst interface{}) []value.Value {
+	operands := make([]value.Value, 0)
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		switch {
+		case v.Type() == valueType:
+			if !v.IsNil() {
+				op := v.Interface().(value.Value)
+				// values passed as metadata, like the allocas given to
+				// llvm.dbg.declare, are still used
+				if md, isMetadata := op.(*metadata.Value); isMetadata {
+					op = md.X
+				}
+				operands = append(operands, op)
+			}
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct && !v.IsNil():
+			// incoming values of phis are behind a pointer
+			if _, isBlock := v.Interface().(*ir.BasicBlock); !isBlock {
+				visit(v.Elem())
+			}
+		case v.Kind() == reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					visit(v.Field(i))
+				}
+			}
+		}
+	}
+	visit(reflect.ValueOf(inst).Elem())
+	return operands
+}
+
+// blockPredecessors returns the blocks that branch to each block
+func blockPredecessors(fn *ir.Function) map[*ir.BasicBlock][]*ir.BasicBlock {
+	preds := make(map[*ir.BasicBlock][]*ir.BasicBlock)
+	for _, block := range fn.Blocks {
+		seen := make(map[*ir.BasicBlock]bool)
+		for _, succ := range block.Term.Succs() {
+			if !seen[succ] {
+				seen[succ] = true
+				preds[succ] = append(preds[succ], block)
+			}
+		}
+	}
+	return preds
+}
+
+// blockHasPhis returns if a block starts with phi instructions, which
+// depend on which block was branched from
+func blockHasPhis(block *ir.BasicBlock) bool {
+	if len(block.Insts) == 0 {
+		return false
+	}
+	_, ok := block.Insts[0].(*ir.InstPhi)
+	return ok
+}
+
+// retargetBranch makes a terminator branch to `to` wherever it branched to
+// `from` before
+func retargetBranch(term ir.Terminator, from, to *ir.BasicBlock) {
+	swap := func(b *ir.BasicBlock) *ir.BasicBlock {
+		if b == from {
+			return to
+		}
+		return b
+	}
+	switch term := term.(type) {
+	case *ir.TermBr:
+		term.Target = swap(term.Target)
+		term.Successors = []*ir.BasicBlock{term.Target}
+	case *ir.TermCondBr:
+		term.TargetTrue = swap(term.TargetTrue)
+		term.TargetFalse = swap(term.TargetFalse)
+		term.Successors = []*ir.BasicBlock{term.TargetTrue, term.TargetFalse}
+	case *ir.TermSwitch:
+		term.TargetDefault = swap(term.TargetDefault)
+		term.Successors = []*ir.BasicBlock{term.TargetDefault}
+		for _, c := range term.Cases {
+			c.Target = swap(c.Target)
+			term.Successors = append(term.Successors, c.Target)
+		}
+	case *ir.TermInvoke:
+		term.Normal = swap(term.Normal)
+		term.Exception = swap(term.Exception)
+		term.Successors = []*ir.BasicBlock{term.Normal, term.Exception}
+	}
+}
+
+func removeBlock(fn *ir.Function, block *ir.BasicBlock) {
+	for i, b := range fn.Blocks {
+		if b == block {
+			fn.Blocks = append(fn.Blocks[:i], fn.Blocks[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// countMemory returns the number of allocas and stores in a function
+func countMemory(fn *ir.Function) (allocas, stores int) {
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			switch inst.(type) {
+			case *ir.InstAlloca:
+				allocas++
+			case *ir.InstStore:
+				stores++
+			}
+		}
+	}
+	return allocas, stores
+}
+
+func TestCleanupDeadAllocas(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   func(*ir.InstStore)
+		load    bool
+		removed bool
+	}{
+		{"never read", func(*ir.InstStore) {}, false, true},
+		{"read", func(*ir.InstStore) {}, true, false},
+		{"volatile store", func(s *ir.InstStore) { s.Volatile = true }, false, false},
+		{"atomic store", func(s *ir.InstStore) { s.Ordering = ir.OrderingSeqCst }, false, false},
+	}
+	for _, test := range tests {
+		fn := ir.NewFunction("f", types.Void)
+		block := fn.NewBlock("entry")
+		alloca := block.NewAlloca(types.I32)
+		test.store(block.NewStore(constant.NewInt(1, types.I32), alloca))
+		if test.load {
+			block.NewLoad(alloca)
+		}
+		block.NewRet(nil)
+
+		changed := cleanupDeadAllocas(fn)
+		if changed != test.removed {
+			t.Errorf("%s: cleanupDeadAllocas changed the function = %v, want %v", test.name, changed, test.removed)
+		}
+		want := 1
+		if test.removed {
+			want = 0
+		}
+		if allocas, stores := countMemory(fn); allocas != want || stores != want {
+			t.Errorf("%s: %d allocas and %d stores left, want %d of each", test.name, allocas, stores, want)
+		}
+	}
+}
+
+func TestCleanupFoldBranches(t *testing.T) {
+	tests := []struct {
+		name string
+		cond func(c value.Value) value.Value
+		// same branches to the same block both ways
+		same bool
+		phi  bool
+		// want is the block the entry branches to, or "" if it still
+		// branches both ways
+		want string
+	}{
+		{"true", func(value.Value) value.Value { return constant.True }, false, false, "then"},
+		{"false", func(value.Value) value.Value { return constant.False }, false, false, "else"},
+		{"same target", func(c value.Value) value.Value { return c }, true, false, "then"},
+		{"unknown", func(c value.Value) value.Value { return c }, false, false, ""},
+		// the phi needs the edge from the entry to both blocks
+		{"target with phi", func(value.Value) value.Value { return constant.True }, false, true, ""},
+	}
+	for _, test := range tests {
+		c := ir.NewParam("c", types.I1)
+		fn := ir.NewFunction("f", types.Void, c)
+		entry := fn.NewBlock("entry")
+		then := fn.NewBlock("then")
+		els := fn.NewBlock("else")
+		if test.same {
+			els = then
+		}
+		entry.NewCondBr(test.cond(c), then, els)
+		if test.phi {
+			then.NewPhi(ir.NewIncoming(constant.NewInt(1, types.I32), entry))
+		}
+		then.NewRet(nil)
+		els.NewRet(nil)
+
+		changed := cleanupFoldBranches(fn)
+		if changed != (test.want != "") {
+			t.Errorf("%s: cleanupFoldBranches changed the function = %v, want %v", test.name, changed, test.want != "")
+		}
+		br, isBr := entry.Term.(*ir.TermBr)
+		switch {
+		case test.want == "" && isBr:
+			t.Errorf("%s: folded to a branch to %s", test.name, br.Target.Name)
+		case test.want != "" && !isBr:
+			t.Errorf("%s: not folded, want a branch to %s", test.name, test.want)
+		case test.want != "" && br.Target.Name != test.want:
+			t.Errorf("%s: folded to a branch to %s, want %s", test.name, br.Target.Name, test.want)
+		}
+	}
+}
+
+func TestCleanupMergeBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		// build adds the blocks after the entry, which branches to next
+		build func(fn *ir.Function, entry, next *ir.BasicBlock)
+		want  []string
+	}{
+		{"only predecessor", func(fn *ir.Function, entry, next *ir.BasicBlock) {
+			next.NewRet(nil)
+		}, []string{"entry"}},
+		{"two predecessors", func(fn *ir.Function, entry, next *ir.BasicBlock) {
+			loop := fn.NewBlock("loop")
+			next.NewBr(loop)
+			loop.NewCondBr(fn.Params()[0], next, fn.NewBlock("exit"))
+			fn.Blocks[len(fn.Blocks)-1].NewRet(nil)
+		}, []string{"entry", "next", "exit"}},
+		// a phi after the merged block comes from the block it is merged into
+		{"later phi", func(fn *ir.Function, entry, next *ir.BasicBlock) {
+			exit := fn.NewBlock("exit")
+			other := fn.NewBlock("other")
+			next.NewCondBr(fn.Params()[0], exit, other)
+			other.NewBr(exit)
+			exit.NewPhi(ir.NewIncoming(constant.NewInt(1, types.I32), next), ir.NewIncoming(constant.NewInt(2, types.I32), other))
+			exit.NewRet(nil)
+		}, []string{"entry", "exit", "other"}},
+	}
+	for _, test := range tests {
+		fn := ir.NewFunction("f", types.Void, ir.NewParam("c", types.I1))
+		entry := fn.NewBlock("entry")
+		next := fn.NewBlock("next")
+		entry.NewBr(next)
+		next.NewAlloca(types.I32)
+		test.build(fn, entry, next)
+
+		for cleanupMergeBlocks(fn) {
+		}
+		names := []string{}
+		for _, block := range fn.Blocks {
+			names = append(names, block.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("%s: got the blocks %v, want %v", test.name, names, test.want)
+		}
+		if allocas, _ := countMemory(fn); allocas != 1 {
+			t.Errorf("%s: %d allocas left, want the 1 of the merged block", test.name, allocas)
+		}
+		for _, block := range fn.Blocks {
+			for _, inst := range block.Insts {
+				if phi, isPhi := inst.(*ir.InstPhi); isPhi && phi.Incs[0].Pred != fn.Blocks[0] {
+					t.Errorf("%s: the phi comes from %s, want entry", test.name, phi.Incs[0].Pred.Name)
+				}
+			}
+		}
+	}
+}
+
+// The blocks the code generator leaves for an if on a constant are
+// cleaned up down to the one that is run
+func TestCleanupFunction(t *testing.T) {
+	fn := ir.NewFunction("f", types.I32)
+	entry := fn.NewBlock("entry")
+	then := fn.NewBlock("then")
+	els := fn.NewBlock("else")
+	merge := fn.NewBlock("merge")
+	result := entry.NewAlloca(types.I32)
+	entry.NewCondBr(constant.True, then, els)
+	then.NewStore(constant.NewInt(1, types.I32), result)
+	then.NewBr(merge)
+	els.NewStore(constant.NewInt(2, types.I32), result)
+	els.NewBr(merge)
+	merge.NewRet(merge.NewLoad(result))
+
+	cleanupFunction(fn)
+	if len(fn.Blocks) != 1 {
+		t.Fatalf("%d blocks left, want 1:\n%s", len(fn.Blocks), fn)
+	}
+	if allocas, stores := countMemory(fn); allocas != 0 || stores != 0 {
+		t.Errorf("%d allocas and %d stores left, want the value returned directly:\n%s", allocas, stores, fn)
+	}
+	var x *constant.Int
+	if ret, isRet := fn.Blocks[0].Term.(*ir.TermRet); isRet {
+		x, _ = ret.X.(*constant.Int)
+	}
+	if x == nil || x.X.Int64() != 1 {
+		t.Errorf("got %s, want ret i32 1", fn.Blocks[0].Term)
+	}
+}
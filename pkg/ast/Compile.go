@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// Source is the code Compile compiles, the package at Path, which is a
+// directory or a file in one. With Text, Path is only where the code is
+// said to be: the package is just that text, and the packages it includes
+// are found from Path's directory.
+type Source struct {
+	Path string
+	Text string
+}
+
+// CompileOptions are how Compile compiles a program. The flags in pkg/arg
+// are read as they are by the geode command, and have their zero values
+// unless they are set.
+type CompileOptions struct {
+	// Target is the platform to compile for, a generic 64-bit one if nil
+	Target *Target
+	// Toolchain preprocesses the c headers included with include_c. If it
+	// is nil, the toolchain of the target is found when one is included.
+	Toolchain Toolchain
+	// Sources are where the packages are read from, disk if they are nil.
+	// The package at the path of the source is read from them too.
+	Sources SourceProvider
+	// Passes are run on the packages before they are compiled, as with
+	// AddPass
+	Passes []ProgramPass
+	// SearchPaths are directories to find included packages in, as with
+	// AddSearchPath
+	SearchPaths []string
+	// NoRuntime compiles the program without the runtime package
+	NoRuntime bool
+	// NoMain compiles a program without a main function, like a library,
+	// which only its @export functions are compiled from
+	NoMain bool
+	// OpaquePointers compiles the program to a module with opaque pointers,
+	// as with --opaque-pointers
+	OpaquePointers bool
+	// Warnings are the -W flags the warnings are given with, like "all"
+	// and "no-unused"
+	Warnings []string
+}
+
+// Compile compiles a program to an llvm module, for programs that embed
+// the compiler. Where the geode command prints what is wrong and exits,
+// Compile returns the errors and warnings of the program as diagnostics,
+// and an error if it failed, which is all the module is nil for. An error
+// without a diagnostic is one the compile couldn't go on after, like a
+// file that couldn't be read. Nothing is printed while it runs.
+//
+// The compile is stopped when the context is done, as when a language
+// server starts another compile of code that changed. Only the error of
+// the context is returned then, and the half compiled program is thrown
+// away.
+//
+// Each call compiles its own program, which keeps the state of the
+// compile, so Compile can be called from more than one goroutine at once.
+// The flags in pkg/arg are shared by all of them, and can't be set while
+// one runs.
+func Compile(ctx context.Context, source Source, options CompileOptions) (*ir.Module, []*Diagnostic, error) {
+	p := NewProgram()
+	err := p.compileSource(ctx, source, options)
+	// the compile can fail in any way once it is stopped
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	diagnostics := p.Diagnostics()
+
+	errors := 0
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			errors++
+		}
+	}
+	if err == nil && errors == 1 {
+		err = fmt.Errorf("1 error")
+	} else if err == nil && errors > 1 {
+		err = fmt.Errorf("%d errors", errors)
+	}
+	if err != nil {
+		return nil, diagnostics, err
+	}
+	p.Cleanup()
+	return p.Module, diagnostics, nil
+}
+
+// compileSource parses and compiles the program of Compile. The errors
+// of the program are collected as its diagnostics, and those that stop
+// the compile are returned too.
+func (p *Program) compileSource(ctx context.Context, source Source, options CompileOptions) (failed error) {
+	// syntax errors in the function bodies parsed while compiling, which
+	// exitOnSyntaxError leaves for Compile, stop the compile too. Any other
+	// panic is a bug in the compiler, which fails the compile rather than
+	// the program that embeds it.
+	defer func() {
+		if r := recover(); r != nil {
+			if bail, isBail := r.(syntaxBail); isBail {
+				p.diagnostics = append(p.diagnostics, bail.err)
+				return
+			}
+			d := newError(lexer.Token{}, ErrInternal, "internal compiler error: %v", r)
+			p.diagnostics = append(p.diagnostics, d)
+			failed = d
+		}
+	}()
+
+	if options.Target != nil {
+		if err := p.SetTarget(options.Target); err != nil {
+			return err
+		}
+	}
+	p.Toolchain = options.Toolchain
+	p.Sources = options.Sources
+	p.OpaquePointers = options.OpaquePointers
+	p.NoRuntime = options.NoRuntime
+	if err := p.SetWarningFlags(options.Warnings); err != nil {
+		return err
+	}
+	for _, dir := range options.SearchPaths {
+		p.AddSearchPath(dir)
+	}
+	for _, pass := range options.Passes {
+		p.AddPass(pass)
+	}
+	if !p.NoRuntime {
+		if err := p.ParseDep(ctx, "", "runtime"); err != nil {
+			return err
+		}
+	}
+
+	p.Entry = source.Path
+	var err error
+	if source.Text != "" {
+		err = p.ParseText(ctx, source.Text, source.Path)
+	} else if _, err = p.sources().Stat(source.Path); err == nil {
+		err = p.ParsePath(ctx, source.Path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.Congeal(ctx); err != nil {
+		p.Diagnose(err)
+		return nil
+	}
+	if !options.NoMain {
+		main, err := p.CompileEntrypoint()
+		if err != nil {
+			p.Diagnose(err)
+			return nil
+		}
+		if main == nil {
+			return fmt.Errorf("no function `main` found in compilation")
+		}
+	}
+	for _, compile := range []func() error{p.CompileExports, p.CompileCoverage, p.CheckNils} {
+		if err := compile(); err != nil {
+			p.Diagnose(err)
+			return nil
+		}
+	}
+	return nil
+}
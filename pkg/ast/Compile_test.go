@@ -0,0 +1,272 @@
+package ast
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/geode-lang/geode/llvm/ir"
+)
+
+// compileText compiles the program main.g in /proj, with no GEODE_PATH
+// and the standard library of the repository
+func compileText(t *testing.T, text string, options CompileOptions) (*ir.Module, []*Diagnostic, error) {
+	t.Helper()
+	lib, err := filepath.Abs("../../lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", lib)
+	return Compile(context.Background(), Source{Path: "/proj/main.g", Text: text}, options)
+}
+
+// hasFunc returns if a module defines or declares a function
+func hasFunc(m *ir.Module, name string) bool {
+	for _, f := range m.Funcs {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompileNoRuntime(t *testing.T) {
+	const text = "is main\n\nfunc main int {\n\treturn 0;\n}\n"
+	tests := []struct {
+		noRuntime bool
+		want      bool
+	}{
+		{false, true},
+		{true, false},
+	}
+	for _, test := range tests {
+		m, diagnostics, err := compileText(t, text, CompileOptions{NoRuntime: test.noRuntime})
+		if err != nil {
+			t.Fatalf("NoRuntime %v: %s %v", test.noRuntime, err, diagnostics)
+		}
+		if got := hasFunc(m, "__init_runtime"); got != test.want {
+			t.Errorf("NoRuntime %v: __init_runtime in module is %v, want %v", test.noRuntime, got, test.want)
+		}
+	}
+}
+
+// Compiles share no state, so the warnings of one aren't given again in
+// the next
+func TestCompileSequential(t *testing.T) {
+	const unused = "is main\n\nfunc main int {\n\tint left = 1;\n\treturn 0;\n}\n"
+	const clean = "is main\n\nfunc main int {\n\treturn 0;\n}\n"
+
+	_, diagnostics, err := compileText(t, unused, CompileOptions{NoRuntime: true})
+	if err != nil {
+		t.Fatalf("%s %v", err, diagnostics)
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "left is never used") {
+		t.Fatalf("got %v, want the warning that left is never used", diagnostics)
+	}
+
+	_, diagnostics, err = compileText(t, clean, CompileOptions{NoRuntime: true})
+	if err != nil {
+		t.Fatalf("%s %v", err, diagnostics)
+	}
+	if len(diagnostics) != 0 {
+		t.Errorf("the second compile gave %v, want no diagnostics", diagnostics)
+	}
+}
+
+// A program that can't be compiled is returned as an error, where the
+// geode command would exit, so the test goes on to check it
+func TestCompileErrors(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+
+	_, _, err := Compile(context.Background(), Source{Path: "/proj/missing.g"}, CompileOptions{NoRuntime: true})
+	if err == nil {
+		t.Error("compiled a file that doesn't exist")
+	}
+
+	tests := []struct {
+		text string
+		code string
+	}{
+		{"is main\n\nfunc main int {\n\treturn (1 + 2;\n}\n", ErrSyntax},
+		{"is main\n\nfunc main int {\n\treturn nothere;\n}\n", ErrUndefined},
+	}
+	for _, test := range tests {
+		_, diagnostics, err := Compile(context.Background(), Source{Path: "/proj/main.g", Text: test.text}, CompileOptions{NoRuntime: true})
+		if err == nil {
+			t.Errorf("compiled %q", test.text)
+			continue
+		}
+		found := false
+		for _, d := range diagnostics {
+			found = found || d.Code == test.code
+		}
+		if !found {
+			t.Errorf("%q: got %s %v, want a %s diagnostic", test.text, err, diagnostics, test.code)
+		}
+	}
+}
+
+// cancelPass cancels the compile it is run in
+type cancelPass struct{ cancel context.CancelFunc }
+
+func (c cancelPass) Name() string { return "cancel" }
+
+func (c cancelPass) Run(prog *Program, pkg *Package, nodes []Node) ([]Node, error) {
+	c.cancel()
+	return nodes, nil
+}
+
+func TestCompileCancelled(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	source := Source{Path: "/proj/main.g", Text: "is main\n\nfunc main int {\n\treturn 0;\n}\n"}
+
+	// cancelled before it starts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m, diagnostics, err := Compile(ctx, source, CompileOptions{NoRuntime: true})
+	if err != context.Canceled || m != nil || diagnostics != nil {
+		t.Errorf("got %v %v %v, want only context.Canceled", m, diagnostics, err)
+	}
+
+	// cancelled while it runs
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	m, diagnostics, err = Compile(ctx, source, CompileOptions{NoRuntime: true, Passes: []ProgramPass{cancelPass{cancel}}})
+	if err != context.Canceled || m != nil || diagnostics != nil {
+		t.Errorf("got %v %v %v, want only context.Canceled", m, diagnostics, err)
+	}
+}
+
+// The buffers of an editor laid over the files on disk: main.g is changed
+// and extra.g is new, and util is only on disk
+func TestCompileFSSources(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+
+	buffers := fstest.MapFS{
+		"app/main.g":  {Data: []byte("is main\n\ninclude \"util\"\n\nfunc main int {\n\treturn util:twice(extra());\n}\n")},
+		"app/extra.g": {Data: []byte("is main\n\nfunc extra int {\n\treturn 21;\n}\n")},
+	}
+	disk := fstest.MapFS{
+		"app/main.g":      {Data: []byte("is main\n\nfunc main int {\n\treturn (;\n}\n")},
+		"app/util/util.g": {Data: []byte("is util\n\npub func twice(int x) int {\n\treturn x * 2;\n}\n")},
+	}
+	sources := OverlaySources(FSSources("/proj", buffers), FSSources("/proj", disk))
+
+	m, diagnostics, err := Compile(context.Background(), Source{Path: "/proj/app/main.g"}, CompileOptions{Sources: sources, NoRuntime: true})
+	if err != nil {
+		t.Fatalf("%s %v", err, diagnostics)
+	}
+	for _, name := range []string{"main", "extra", "twice"} {
+		found := false
+		for _, f := range m.Funcs {
+			found = found || strings.Contains(f.Name, name)
+		}
+		if !found {
+			t.Errorf("no function %s in the module", name)
+		}
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		err   bool
+	}{
+		{"/proj/app/main.g", false, false},
+		{"/proj/app/extra.g", false, false},
+		{"/proj/app/util", true, false},
+		{"/proj/app/missing.g", false, true},
+		// nothing outside of the root exists
+		{"/other/app/main.g", false, true},
+		{"/proj/../app/main.g", false, true},
+	}
+	for _, test := range tests {
+		info, err := sources.Stat(test.path)
+		if (err != nil) != test.err {
+			t.Errorf("Stat(%q): got error %v, want error %v", test.path, err, test.err)
+			continue
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Stat(%q): got %v, want fs.ErrNotExist", test.path, err)
+		}
+		if err == nil && info.IsDir() != test.isDir {
+			t.Errorf("Stat(%q): got dir %v, want %v", test.path, info.IsDir(), test.isDir)
+		}
+	}
+
+	entries, err := sources.ReadDir("/proj/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got, want := strings.Join(names, " "), "extra.g main.g util"; got != want {
+		t.Errorf("ReadDir: got %s, want %s", got, want)
+	}
+}
+
+// bumpPass gives the empty function bump a body that sets x
+type bumpPass struct{ runs int }
+
+func (b *bumpPass) Name() string { return "bump" }
+
+func (b *bumpPass) Run(prog *Program, pkg *Package, nodes []Node) ([]Node, error) {
+	b.runs++
+	for i, node := range nodes {
+		fn, isFunc := node.(FunctionNode)
+		if !isFunc || fn.Name.String() != "bump" {
+			continue
+		}
+		fn.Body = QuickParseFunction("func bump { x = 20; }").(FunctionNode).ParseBody()
+		fn.BodyParser = nil
+		nodes[i] = fn
+	}
+	return nodes, nil
+}
+
+// failPass fails on every package
+type failPass struct{}
+
+func (failPass) Name() string { return "fail" }
+
+func (failPass) Run(prog *Program, pkg *Package, nodes []Node) ([]Node, error) {
+	return nil, errors.New("no")
+}
+
+func TestCompilePasses(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	source := Source{Path: "/proj/main.g", Text: "is main\n\nint x = 10\n\nfunc bump {}\n\nfunc main int {\n\tbump();\n\treturn x;\n}\n"}
+
+	bump := &bumpPass{}
+	m, diagnostics, err := Compile(context.Background(), source, CompileOptions{NoRuntime: true, Passes: []ProgramPass{bump}})
+	if err != nil {
+		t.Fatalf("%s %v", err, diagnostics)
+	}
+	if bump.runs != 1 {
+		t.Errorf("the pass ran %d times on the one package", bump.runs)
+	}
+	var body string
+	for _, f := range m.Funcs {
+		if strings.Contains(f.Name, "bump") {
+			body = f.String()
+		}
+	}
+	if !strings.Contains(body, "store i32 20") {
+		t.Errorf("bump doesn't set x to 20:\n%s", body)
+	}
+
+	_, diagnostics, err = Compile(context.Background(), source, CompileOptions{NoRuntime: true, Passes: []ProgramPass{failPass{}}})
+	if want := "pass fail failed on /proj/main.g: no"; err == nil || len(diagnostics) != 1 || diagnostics[0].Message != want {
+		t.Errorf("got %v %v, want %s", err, diagnostics, want)
+	}
+}
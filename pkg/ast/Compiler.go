@@ -79,10 +79,14 @@ func (c *Compiler) NewComment(comment string) {
 
 }
 
+// genInBlock generates code in a block, then goes back to the block it was
+// called in. The code may push the blocks it continues in, as branches and
+// short circuiting operators do, so every block pushed since is popped.
 func (c *Compiler) genInBlock(blk *ir.BasicBlock, fn func() error) error {
+	depth := len(c.blocks)
 	c.PushBlock(blk)
 	err := fn()
-	c.PopBlock()
+	c.blocks = c.blocks[:depth]
 	return err
 }
 
@@ -93,9 +97,14 @@ func (c *Compiler) PushType(t types.Type) {
 	c.typestacklock.Unlock()
 }
 
-// PopType removes an Item from the top of the stack
+// PopType removes an Item from the top of the stack, or returns nil if
+// the stack is empty
 func (c *Compiler) PopType() (item types.Type) {
 	c.typestacklock.Lock()
+	if len(c.typeStack) == 0 {
+		c.typestacklock.Unlock()
+		return nil
+	}
 	item = c.typeStack[len(c.typeStack)-1]
 	c.typeStack = c.typeStack[0 : len(c.typeStack)-1]
 	c.typestacklock.Unlock()
@@ -0,0 +1,493 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// Limits on compile time evaluation so a runaway @comptime function
+// reports an error instead of hanging the compiler
+const (
+	comptimeMaxSteps = 1 << 20
+	comptimeMaxDepth = 512
+)
+
+// comptimeVar is a local variable inside a compile time call
+type comptimeVar struct {
+	val interface{}
+	typ types.Type
+}
+
+// comptimeFrame is the set of locals of a single compile time call
+type comptimeFrame map[string]*comptimeVar
+
+// ComptimeInterpreter evaluates calls to functions marked @comptime while
+// the program is being compiled. Values are represented the same way as in
+// FoldConstant, either int64 or float64, and strings as a string, so
+// hashes of string literals can be worked out at compile time too. Comptime
+// functions must be pure: they may only read constant globals and call
+// other @comptime functions.
+type ComptimeInterpreter struct {
+	prog  *Program
+	depth int
+	steps int
+}
+
+// NewComptimeInterpreter returns a new interpreter for a program
+func NewComptimeInterpreter(prog *Program) *ComptimeInterpreter {
+	return &ComptimeInterpreter{prog: prog}
+}
+
+// Call evaluates a function with already evaluated arguments
+func (c *ComptimeInterpreter) Call(fn *FunctionNode, args []interface{}) (interface{}, error) {
+	name := fn.Name.String()
+
+	if fn.External || fn.Variadic {
+		return nil, fmt.Errorf("function %s cannot be evaluated at compile time", name)
+	}
+	if len(args) != len(fn.Args) {
+		return nil, fmt.Errorf("incorrect number of arguments passed to function %q. Expected %d, given %d", name, len(fn.Args), len(args))
+	}
+
+	c.depth++
+	defer func() { c.depth-- }()
+	if c.depth > comptimeMaxDepth {
+		return nil, fmt.Errorf("compile time evaluation of %s exceeded the maximum recursion depth of %d", name, comptimeMaxDepth)
+	}
+
+	// Evaluate the function from the point of view of its own package
+	prog := c.prog
+	previousPackage := prog.Package
+	previousScope := prog.Scope
+	root := prog.Scope.GetRoot()
+	previousPackageName := root.PackageName
+	defer func() {
+		prog.Package = previousPackage
+		prog.Scope = previousScope
+		root.PackageName = previousPackageName
+	}()
+	prog.Scope = root
+	if fn.Package != nil {
+		prog.Package = fn.Package
+		root.PackageName = fn.Package.Name
+	}
+
+	frame := make(comptimeFrame)
+	for i, a := range fn.Args {
+		t, err := prog.FindType(a.Type.Name)
+		if err != nil || a.Type.PointerLevel > 0 {
+			return nil, fmt.Errorf("argument %s of %s has a type that cannot be used at compile time", a.Name, name)
+		}
+		val, err := comptimeConvert(args[i], t)
+		if err != nil {
+			return nil, err
+		}
+		frame[a.Name] = &comptimeVar{val, t}
+	}
+
+	returned, val, err := c.exec(frame, fn.ParseBody())
+	if err != nil {
+		return nil, err
+	}
+	if !returned || val == nil {
+		return nil, fmt.Errorf("function %s did not return a value at compile time", name)
+	}
+
+	retType, err := prog.FindType(fn.ReturnType.Name)
+	if err != nil {
+		return nil, err
+	}
+	return comptimeConvert(val, retType)
+}
+
+// exec runs a statement. It returns true and the returned value if
+// the statement executed a return.
+func (c *ComptimeInterpreter) exec(frame comptimeFrame, node Node) (bool, interface{}, error) {
+	c.steps++
+	if c.steps > comptimeMaxSteps {
+		return false, nil, fmt.Errorf("compile time evaluation exceeded %d steps", comptimeMaxSteps)
+	}
+
+	switch n := node.(type) {
+	case BlockNode:
+		for _, stmt := range n.Nodes {
+			returned, val, err := c.exec(frame, stmt)
+			if err != nil || returned {
+				return returned, val, err
+			}
+		}
+		return false, nil, nil
+
+	case ReturnNode:
+		if n.Value == nil {
+			return true, nil, nil
+		}
+		val, err := c.eval(frame, n.Value)
+		return true, val, err
+
+	case IfNode:
+		cond, err := c.evalCondition(frame, n.If)
+		if err != nil {
+			return false, nil, err
+		}
+		if cond {
+			return c.exec(frame, n.Then)
+		}
+		if n.Else != nil {
+			return c.exec(frame, n.Else)
+		}
+		return false, nil, nil
+
+	case MatchNode:
+		subject, err := c.eval(frame, n.Value)
+		if err != nil {
+			return false, nil, err
+		}
+		for _, arm := range n.Arms {
+			for _, node := range arm.Values {
+				val, err := c.eval(frame, node)
+				if err != nil {
+					return false, nil, err
+				}
+				eq, ok := foldBinary("==", subject, val)
+				if !ok {
+					return false, nil, fmt.Errorf("unable to match %s at compile time", node)
+				}
+				if eq != int64(0) {
+					return c.exec(frame, arm.Body)
+				}
+			}
+		}
+		if n.Else != nil {
+			return c.exec(frame, n.Else)
+		}
+		return false, nil, nil
+
+	case WhileNode:
+		for {
+			cond, err := c.evalCondition(frame, n.If)
+			if err != nil || !cond {
+				return false, nil, err
+			}
+			returned, val, err := c.exec(frame, n.Body)
+			if err != nil || returned {
+				return returned, val, err
+			}
+		}
+
+	case ForNode:
+		if _, err := c.eval(frame, n.Init); err != nil {
+			return false, nil, err
+		}
+		for {
+			cond, err := c.evalCondition(frame, n.Cond)
+			if err != nil || !cond {
+				return false, nil, err
+			}
+			returned, val, err := c.exec(frame, n.Body)
+			if err != nil || returned {
+				return returned, val, err
+			}
+			if _, err := c.eval(frame, n.Step); err != nil {
+				return false, nil, err
+			}
+		}
+	}
+
+	_, err := c.eval(frame, node)
+	return false, nil, err
+}
+
+func (c *ComptimeInterpreter) evalCondition(frame comptimeFrame, node Node) (bool, error) {
+	val, err := c.eval(frame, node)
+	if err != nil {
+		return false, err
+	}
+	switch v := val.(type) {
+	case int64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	}
+	return false, fmt.Errorf("condition %s has no value at compile time", node)
+}
+
+// eval evaluates an expression
+func (c *ComptimeInterpreter) eval(frame comptimeFrame, node Node) (interface{}, error) {
+	c.steps++
+	if c.steps > comptimeMaxSteps {
+		return nil, fmt.Errorf("compile time evaluation exceeded %d steps", comptimeMaxSteps)
+	}
+
+	switch n := node.(type) {
+	case IntNode, FloatNode, CharNode, BooleanNode:
+		val, ok := FoldConstant(c.prog, n)
+		if !ok {
+			return nil, fmt.Errorf("%s is too big to evaluate at compile time", n)
+		}
+		return val, nil
+
+	case StringNode:
+		return n.Value, nil
+
+	case *SubscriptNode:
+		return c.index(frame, *n)
+
+	case IdentNode:
+		if v, found := frame[n.Value]; found {
+			return v.val, nil
+		}
+		if val, ok := FoldConstant(c.prog, n); ok {
+			return val, nil
+		}
+		return nil, fmt.Errorf("%s is not a local or a constant global and cannot be read at compile time", n)
+
+	case VariableDefnNode:
+		t, err := c.prog.FindType(n.Typ.Name)
+		if !n.NeedsInference && (err != nil || n.Typ.PointerLevel > 0) {
+			return nil, fmt.Errorf("variable %s has a type that cannot be used at compile time", n.Name)
+		}
+		var val interface{} = int64(0)
+		if n.HasValue {
+			if val, err = c.eval(frame, n.Body); err != nil {
+				return nil, err
+			}
+		}
+		if n.NeedsInference {
+			t = comptimeInferType(val)
+		}
+		if val, err = comptimeConvert(val, t); err != nil {
+			return nil, err
+		}
+		frame[n.Name.String()] = &comptimeVar{val, t}
+		return val, nil
+
+	case CastNode:
+		val, err := c.eval(frame, n.Source)
+		if err != nil {
+			return nil, err
+		}
+		t, err := c.prog.FindType(n.Type.Name)
+		if err != nil || n.Type.PointerLevel > 0 {
+			return nil, fmt.Errorf("cannot cast to %s at compile time", n.Type)
+		}
+		return comptimeConvert(val, t)
+
+	case UnaryNode:
+		val, err := c.eval(frame, n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		switch v := val.(type) {
+		case int64:
+			switch n.Operator {
+			case "-":
+				return -v, nil
+			case "!":
+				if v == 0 {
+					return int64(1), nil
+				}
+				return int64(0), nil
+			case "~":
+				return ^v, nil
+			}
+		case float64:
+			if n.Operator == "-" {
+				return -v, nil
+			}
+		}
+		return nil, fmt.Errorf("unary operator %s cannot be evaluated at compile time", n.Operator)
+
+	case IncDecNode:
+		before, err := c.eval(frame, n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		after, err := c.assign(frame, n.compound())
+		if err != nil || n.Prefix {
+			return after, err
+		}
+		return before, nil
+
+	case BinaryNode:
+		if _, isCompound := compoundOperators[n.OP]; isCompound || n.OP == "=" {
+			return c.assign(frame, n)
+		}
+		l, err := c.eval(frame, n.Left)
+		if err != nil {
+			return nil, err
+		}
+		// && and || don't evaluate their right side if the left decides
+		if li, isInt := l.(int64); isInt && (n.OP == "&&" && li == 0 || n.OP == "||" && li != 0) {
+			val, _ := foldBinary(n.OP, l, l)
+			return val, nil
+		}
+		r, err := c.eval(frame, n.Right)
+		if err != nil {
+			return nil, err
+		}
+		val, ok := foldBinary(n.OP, l, r)
+		if !ok {
+			return nil, fmt.Errorf("unable to evaluate %s %s %s at compile time", n.Left, n.OP, n.Right)
+		}
+		return val, nil
+
+	case FunctionCallNode:
+		callee, ok := n.Name.(IdentNode)
+		if !ok {
+			return nil, fmt.Errorf("only plain function calls can be made at compile time")
+		}
+		fn := c.prog.LookupFunctionNode(callee.String())
+		if fn == nil {
+			return nil, fmt.Errorf("unknown function %q", callee)
+		}
+		if !fn.Attributes.Has("comptime") {
+			return nil, fmt.Errorf("function %s must be marked @comptime to be called at compile time", callee)
+		}
+		args := make([]interface{}, 0, len(n.Args))
+		for _, arg := range n.Args {
+			val, err := c.eval(frame, arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, val)
+		}
+		return c.Call(fn, args)
+	}
+
+	return nil, fmt.Errorf("%s cannot be evaluated at compile time", node.NameString())
+}
+
+// index evaluates reading a byte of a string. Like at runtime, the byte
+// after the last one is the 0 that terminates the string.
+func (c *ComptimeInterpreter) index(frame comptimeFrame, n SubscriptNode) (interface{}, error) {
+	source, isNode := n.Source.(Node)
+	index, isIndexNode := n.Index.(Node)
+	if !isNode || !isIndexNode {
+		return nil, fmt.Errorf("unable to index at compile time")
+	}
+	src, err := c.eval(frame, source)
+	if err != nil {
+		return nil, err
+	}
+	str, isString := src.(string)
+	if !isString {
+		return nil, fmt.Errorf("only strings can be indexed at compile time, not %s", n.Source)
+	}
+	idx, err := c.eval(frame, index)
+	if err != nil {
+		return nil, err
+	}
+	i, isInt := idx.(int64)
+	if !isInt {
+		return nil, fmt.Errorf("index %s of %s is not an integer", n.Index, n.Source)
+	}
+	if i < 0 || i > int64(len(str)) {
+		return nil, fmt.Errorf("index %d is out of range of string %q at compile time", i, str)
+	}
+	if i == int64(len(str)) {
+		return int64(0), nil
+	}
+	return int64(str[i]), nil
+}
+
+// assign evaluates an assignment or a compound assignment to a local
+func (c *ComptimeInterpreter) assign(frame comptimeFrame, n BinaryNode) (interface{}, error) {
+	var target *comptimeVar
+
+	switch l := n.Left.(type) {
+	case VariableDefnNode:
+		if _, err := c.eval(frame, l); err != nil {
+			return nil, err
+		}
+		target = frame[l.Name.String()]
+	case IdentNode:
+		target = frame[l.Value]
+		if variable, err := l.lookup(c.prog); target == nil && n.OP == "=" && variable == nil && err == nil {
+			// Assigning to an unknown name declares a new local
+			val, err := c.eval(frame, n.Right)
+			if err != nil {
+				return nil, err
+			}
+			frame[l.Value] = &comptimeVar{val, comptimeInferType(val)}
+			return val, nil
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("only local variables can be assigned at compile time, %s is not one", n.Left)
+	}
+
+	val, err := c.eval(frame, n.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.OP != "=" {
+		var ok bool
+		val, ok = foldBinary(compoundOperators[n.OP], target.val, val)
+		if !ok {
+			return nil, fmt.Errorf("unable to evaluate %s %s %s at compile time", n.Left, n.OP, n.Right)
+		}
+	}
+
+	if target.val, err = comptimeConvert(val, target.typ); err != nil {
+		return nil, err
+	}
+	return target.val, nil
+}
+
+// comptimeInferType returns the type a variable initialized with val
+// would be given
+func comptimeInferType(val interface{}) types.Type {
+	switch val.(type) {
+	case float64:
+		return types.Double
+	case string:
+		return stringType
+	}
+	return types.I64
+}
+
+// comptimeConvert converts a compile time value into the representation
+// a value of type t would have at runtime
+func comptimeConvert(val interface{}, t types.Type) (interface{}, error) {
+	switch t := t.(type) {
+	case *types.StructType:
+		if str, ok := val.(string); ok && isString(t) {
+			return str, nil
+		}
+		return nil, fmt.Errorf("unable to convert %v to %s at compile time", val, t)
+
+	case *types.IntType:
+		var i int64
+		switch v := val.(type) {
+		case int64:
+			i = v
+		case float64:
+			i = int64(v)
+		default:
+			return nil, fmt.Errorf("unable to convert %v to %s at compile time", val, t)
+		}
+		if t.Size == 1 {
+			return i & 1, nil
+		}
+		if t.Size < 64 {
+			shift := uint(64 - t.Size)
+			i = i << shift >> shift
+		}
+		return i, nil
+
+	case *types.FloatType:
+		f, ok := toFloat(val)
+		if !ok {
+			return nil, fmt.Errorf("unable to convert %v to %s at compile time", val, t)
+		}
+		if t.Kind == types.FloatKindIEEE_32 {
+			f = float64(float32(f))
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("type %s cannot be used at compile time", t)
+}
@@ -0,0 +1,118 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// The attributes that compile a declaration only for some targets. They
+// take the names of operating systems or architectures, and the declaration
+// is compiled if the target is any of them, as in `@os(linux, darwin)` or
+// `@arch(wasm32)`. A name with a leading `!` matches every target but that
+// one, as in `@os("!unknown")`. Given both, the target has to match both.
+// On the namespace of a file they apply to the whole file, so a package
+// can have a file for each platform.
+const (
+	osAttribute   = "os"
+	archAttribute = "arch"
+)
+
+// targetConditions takes @os and @arch out of a list of attributes,
+// returning if the target matches them and the other attributes. A parser
+// without a target, like the ones made for small snippets of generated
+// code, matches every condition.
+func (p *Parser) targetConditions(attrs Attributes, tok lexer.Token) (bool, Attributes) {
+	rest := make(Attributes, 0, len(attrs))
+	matches := true
+	for _, attr := range attrs {
+		var actual string
+		switch attr.Name {
+		case osAttribute:
+			if p.target != nil {
+				actual = p.target.OS
+			}
+		case archAttribute:
+			if p.target != nil {
+				actual = p.target.Arch
+			}
+		default:
+			rest = append(rest, attr)
+			continue
+		}
+
+		if len(attr.Args) == 0 {
+			syntaxFail(tok, "@%s needs the names to compile for, as in @%s(%s)", attr.Name, attr.Name, conditionExample[attr.Name])
+		}
+		if p.target != nil && !conditionMatches(attr.Args, actual) {
+			matches = false
+		}
+	}
+	return matches, rest
+}
+
+var conditionExample = map[string]string{
+	osAttribute:   "linux, darwin",
+	archAttribute: "x86_64",
+}
+
+// conditionMatches returns if the os or architecture of the target is one
+// of the names of a condition, or isn't one of its negated names
+func conditionMatches(names []string, actual string) bool {
+	matched := false
+	positive := false
+	for _, name := range names {
+		if strings.HasPrefix(name, "!") {
+			if strings.TrimPrefix(name, "!") == actual {
+				return false
+			}
+			continue
+		}
+		positive = true
+		if name == actual {
+			matched = true
+		}
+	}
+	return matched || !positive
+}
+
+// excludedDecl is what a declaration the target doesn't match parses to.
+// The parser drops it, so it is never declared or compiled.
+type excludedDecl struct {
+	NodeType
+	TokenReference
+}
+
+func (n excludedDecl) String() string {
+	return fmt.Sprintf("excluded declaration at %d:%d", n.Token.Line, n.Token.Column)
+}
+
+// NameString implements Node.NameString
+func (n excludedDecl) NameString() string { return "excludedDecl" }
+
+// MarshalJSON implements json.Marshaler for excludedDecl
+func (n excludedDecl) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+// Codegen implements Node.Codegen for excludedDecl
+func (n excludedDecl) Codegen(prog *Program) (value.Value, error) { return nil, nil }
+
+// parseExcluded parses a declaration the target doesn't match, so its
+// syntax is still checked, and drops it
+func (p *Parser) parseExcluded(start lexer.Token) Node {
+	p.parseTopLevelStmt()
+	n := excludedDecl{}
+	n.NodeType = nodeExcluded
+	n.Token = start
+	return n
+}
+
+// excludeFile drops the rest of a file whose namespace the target doesn't
+// match, and what was parsed before it. The file still gives its package
+// a name, but declares nothing.
+func (p *Parser) excludeFile() {
+	p.topLevelNodes = p.topLevelNodes[:0]
+	p.tokenIndex = len(p.tokens)
+	p.move(0)
+}
@@ -0,0 +1,430 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// assignmentOperators are the operators that, when following an identifier,
+// write to the storage the identifier names.
+var assignmentOperators = map[string]bool{
+	"=":   true,
+	"<-":  true,
+	":=":  true,
+	"+=":  true,
+	"-=":  true,
+	"*=":  true,
+	"/=":  true,
+	"%=":  true,
+	"<<=": true,
+	">>=": true,
+	"&=":  true,
+	"|=":  true,
+	"^=":  true,
+	"++":  true,
+	"--":  true,
+}
+
+// FindReassignedGlobals walks the token stream of every function body
+// in the program and returns the set of names that are either assigned
+// to or have their address taken. The names are stored without their
+// namespace, which makes the check conservative: if any package writes
+// to a name, every global with that name is considered mutable. The
+// bodies rewritten by a ProgramPass have no tokens, so every name in them
+// is.
+func (p *Program) FindReassignedGlobals() map[string]bool {
+	mutated := make(map[string]bool)
+
+	for _, fn := range p.Functions {
+		if fn.BodyParser == nil {
+			markNamedGlobals(fn.Body, mutated)
+			continue
+		}
+
+		toks := fn.BodyParser.tokens
+		for i, tok := range toks {
+			if !tok.Is(lexer.TokIdent) {
+				continue
+			}
+			_, name := ParseName(tok.Value)
+
+			// static fields of classes are named like `Counter.count`
+			start := i
+			if i > 1 && toks[i-1].Is(lexer.TokDot) && toks[i-2].Is(lexer.TokType) {
+				_, class := ParseName(toks[i-2].Value)
+				name = fmt.Sprintf("%s.%s", class, name)
+				start = i - 2
+			}
+
+			if i+1 < len(toks) && toks[i+1].Is(lexer.TokOper) && assignmentOperators[toks[i+1].Value] {
+				mutated[name] = true
+			}
+
+			if start > 0 && toks[start-1].Is(lexer.TokOper) && (toks[start-1].Value == "&" || toks[start-1].Value == "++" || toks[start-1].Value == "--") {
+				mutated[name] = true
+			}
+		}
+	}
+
+	return mutated
+}
+
+// FoldConstant attempts to evaluate a node at compile time. It returns
+// an int64 or a float64 and true if the node could be folded, and false
+// if evaluation needs to be deferred until runtime.
+func FoldConstant(prog *Program, node Node) (interface{}, bool) {
+	switch n := node.(type) {
+	case IntNode:
+		// 128 bit literals are left to llvm
+		if n.Big != nil {
+			return nil, false
+		}
+		return n.Value, true
+
+	case FloatNode:
+		return n.Value, true
+
+	case CharNode:
+		return int64(n.Value), true
+
+	case BooleanNode:
+		if n.Value == "true" {
+			return int64(1), true
+		}
+		return int64(0), true
+
+	case SizeofNode:
+		val, err := n.Value(prog)
+		if err != nil {
+			return nil, false
+		}
+		return val, true
+
+	case IdentNode:
+		ref := n.Alloca(prog)
+		if _, val, isMember := prog.enumMember(n.Value); isMember && ref == nil {
+			return val, true
+		}
+		glob, ok := ref.(*ir.Global)
+		if !ok {
+			return nil, false
+		}
+		c, found := prog.ConstGlobals[glob]
+		if !found {
+			return nil, false
+		}
+		switch c := c.(type) {
+		case *constant.Int:
+			return c.X.Int64(), true
+		case *constant.Float:
+			f, _ := c.X.Float64()
+			return f, true
+		}
+
+	case UnaryNode:
+		val, ok := FoldConstant(prog, n.Operand)
+		if !ok {
+			return nil, false
+		}
+		switch n.Operator {
+		case "-":
+			switch v := val.(type) {
+			case int64:
+				return -v, true
+			case float64:
+				return -v, true
+			}
+		case "!":
+			if v, isInt := val.(int64); isInt {
+				if v == 0 {
+					return int64(1), true
+				}
+				return int64(0), true
+			}
+		case "~":
+			if v, isInt := val.(int64); isInt {
+				return ^v, true
+			}
+		}
+
+	case BinaryNode:
+		l, ok := FoldConstant(prog, n.Left)
+		if !ok {
+			return nil, false
+		}
+		r, ok := FoldConstant(prog, n.Right)
+		if !ok {
+			return nil, false
+		}
+		return foldBinary(n.OP, l, r)
+
+	case FunctionCallNode:
+		val, err := foldComptimeCall(prog, n)
+		if err != nil || val == nil {
+			return nil, false
+		}
+		// strings are only constants at compile time, they are copied
+		// when the call is compiled
+		if _, isString := val.(string); isString {
+			return nil, false
+		}
+		return val, true
+	}
+
+	return nil, false
+}
+
+// foldBinary applies a binary operator to two folded values. If either
+// operand is a float, both are treated as floats, mirroring binaryCast.
+func foldBinary(op string, l, r interface{}) (interface{}, bool) {
+	li, lIsInt := l.(int64)
+	ri, rIsInt := r.(int64)
+
+	boolInt := func(b bool) interface{} {
+		if b {
+			return int64(1)
+		}
+		return int64(0)
+	}
+
+	if lIsInt && rIsInt {
+		switch op {
+		case "+":
+			return li + ri, true
+		case "-":
+			return li - ri, true
+		case "*":
+			return li * ri, true
+		case "/":
+			if ri == 0 {
+				return nil, false
+			}
+			return li / ri, true
+		case "%":
+			if ri == 0 {
+				return nil, false
+			}
+			return li % ri, true
+		case "<<":
+			return li << uint64(ri), true
+		case ">>":
+			return int64(uint64(li) >> uint64(ri)), true
+		case "||":
+			return boolInt(li != 0 || ri != 0), true
+		case "&&":
+			return boolInt(li != 0 && ri != 0), true
+		case "^":
+			return li ^ ri, true
+		case "&":
+			return li & ri, true
+		case "|":
+			return li | ri, true
+		case "==":
+			return boolInt(li == ri), true
+		case "!=":
+			return boolInt(li != ri), true
+		case "<":
+			return boolInt(li < ri), true
+		case "<=":
+			return boolInt(li <= ri), true
+		case ">":
+			return boolInt(li > ri), true
+		case ">=":
+			return boolInt(li >= ri), true
+		}
+		return nil, false
+	}
+
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if !lok || !rok {
+		return nil, false
+	}
+
+	switch op {
+	case "+":
+		return lf + rf, true
+	case "-":
+		return lf - rf, true
+	case "*":
+		return lf * rf, true
+	case "/":
+		return lf / rf, true
+	case "==":
+		return boolInt(lf == rf), true
+	case "!=":
+		return boolInt(lf != rf), true
+	case "<":
+		return boolInt(lf < rf), true
+	case "<=":
+		return boolInt(lf <= rf), true
+	case ">":
+		return boolInt(lf > rf), true
+	case ">=":
+		return boolInt(lf >= rf), true
+	}
+	return nil, false
+}
+
+// foldComptimeCall evaluates a call to a @comptime function. It returns
+// nil and no error if the callee is not a @comptime function, or if an
+// argument isn't known while compiling, where it is called at runtime.
+func foldComptimeCall(prog *Program, n FunctionCallNode) (interface{}, error) {
+	callee, ok := n.Name.(IdentNode)
+	if !ok {
+		return nil, nil
+	}
+	fn := prog.LookupFunctionNode(callee.String())
+	if fn == nil || !fn.Attributes.Has("comptime") {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(n.Args))
+	for _, arg := range n.Args {
+		if str, isString := arg.(StringNode); isString {
+			args = append(args, str.Value)
+			continue
+		}
+		val, ok := FoldConstant(prog, arg)
+		if !ok {
+			return nil, nil
+		}
+		args = append(args, val)
+	}
+
+	return NewComptimeInterpreter(prog).Call(fn, args)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// NewFoldedConstant materializes a folded value as an llvm constant of type t
+func NewFoldedConstant(val interface{}, t types.Type) (constant.Constant, error) {
+	switch v := val.(type) {
+	case int64:
+		if types.IsInt(t) {
+			return constant.NewInt(v, t), nil
+		}
+		if types.IsFloat(t) {
+			return roundFloat(constant.NewFloat(float64(v), t)), nil
+		}
+	case float64:
+		if types.IsFloat(t) {
+			return roundFloat(constant.NewFloat(v, t)), nil
+		}
+		if types.IsInt(t) {
+			return constant.NewInt(int64(v), t), nil
+		}
+	}
+	return nil, fmt.Errorf("unable to represent folded value %v as type %s", val, t)
+}
+
+// copyConstant returns a fresh copy of a folded constant. createTypeCast
+// retypes integer and float constants in place, so a folded global must
+// never hand out the constant it stores.
+func copyConstant(c constant.Constant) constant.Constant {
+	switch c := c.(type) {
+	case *constant.Int:
+		n := constant.NewInt(0, c.Typ)
+		n.X.Set(c.X)
+		return n
+	case *constant.Float:
+		n := constant.NewFloat(0, c.Typ)
+		n.X.Set(c.X)
+		return n
+	}
+	return c
+}
+
+// constantNumber returns the value of an integer or float constant as an
+// int64 or a float64
+func constantNumber(v value.Value) (interface{}, bool) {
+	switch c := v.(type) {
+	case *constant.Int:
+		if !c.X.IsInt64() {
+			return nil, false
+		}
+		return c.X.Int64(), true
+	case *constant.Float:
+		f, _ := c.X.Float64()
+		return f, true
+	}
+	return nil, false
+}
+
+// foldConstantValues applies a binary operator to two constant operands
+// at compile time, so a constant expression never emits an instruction.
+// t is the type binaryCast gave the operands. It returns false if either
+// operand isn't a constant or the result can't be computed here.
+func foldConstantValues(op string, l, r value.Value, t types.Type) (constant.Constant, bool) {
+	lv, ok := constantNumber(l)
+	if !ok {
+		return nil, false
+	}
+	rv, ok := constantNumber(r)
+	if !ok {
+		return nil, false
+	}
+
+	// constants are folded as int64s, which order and divide unsigned
+	// values with their top bit set as negative numbers
+	_, isComparison := booleanComparisonOperatorMap[op]
+	if types.IsUnsigned(t) && (isComparison || op == "/" || op == "%") {
+		return nil, false
+	}
+
+	if it, isInt := t.(*types.IntType); isInt && (op == "<<" || op == ">>") {
+		li, ri := lv.(int64), rv.(int64)
+		if ri < 0 || ri >= int64(it.Size) {
+			return nil, false
+		}
+		// lshr shifts in zeros from the top of the type, not of an int64
+		if it.Size < 64 {
+			lv = li & (1<<uint64(it.Size) - 1)
+		}
+	}
+
+	val, ok := foldBinary(op, lv, rv)
+	if !ok {
+		return nil, false
+	}
+	if isComparison {
+		t = types.I1
+	}
+	c, err := NewFoldedConstant(val, t)
+	if err != nil {
+		return nil, false
+	}
+	return wrapConstant(c), true
+}
+
+// wrapConstant wraps an integer constant around to the width of its type,
+// as the instruction it replaces would have. Unsigned values are zero
+// extended, so they widen to the same value a zext of them would.
+func wrapConstant(c constant.Constant) constant.Constant {
+	i, isInt := c.(*constant.Int)
+	if !isInt {
+		return c
+	}
+	size := i.Typ.Size
+	if size <= 1 || size >= 64 || !i.X.IsInt64() {
+		return c
+	}
+	if i.Typ.Unsigned {
+		return constant.NewInt(i.X.Int64()&(1<<size-1), i.Typ)
+	}
+	shift := uint64(64 - size)
+	return constant.NewInt(i.X.Int64()<<shift>>shift, i.Typ)
+}
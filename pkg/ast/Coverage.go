@@ -0,0 +1,140 @@
+package ast
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/util"
+)
+
+// coverageRegisterName is the function the c main function calls to hand
+// the counters of a program built with --coverage to the runtime. Its body
+// is only compiled by CompileCoverage, once every counter is known.
+const coverageRegisterName = "__coverage_register"
+
+// coverageLine is a line of source that a coverage counter counts
+type coverageLine struct {
+	counter *ir.Global
+	file    string
+	line    int
+}
+
+// coverage is the state of the coverage instrumentation of a program. Every
+// basic block gets a counter the first time a statement is compiled into
+// it, which counts the lines of every statement in the block.
+type coverage struct {
+	counters map[*ir.BasicBlock]*ir.Global
+	lines    []coverageLine
+	seen     map[coverageLine]bool
+	register *ir.Function
+}
+
+// coverStatement counts a statement that is about to be compiled, with the
+// counter of the block it is compiled into. The standard library is not
+// instrumented.
+func (p *Program) coverStatement(node Node) {
+	if !*arg.Coverage || p.Compiler.CurrentFunc() == nil {
+		return
+	}
+	n, ok := node.(tokenNode)
+	if !ok {
+		return
+	}
+	tok := n.token()
+	path := tok.SourcePath()
+	if path == "" || strings.HasPrefix(path, util.StdLibDir()+string(filepath.Separator)) {
+		return
+	}
+	block := p.Compiler.CurrentBlock()
+	// nothing after a return is ever run
+	if block == nil || block.Term != nil {
+		return
+	}
+
+	c := p.coverageState()
+	counter, found := c.counters[block]
+	if !found {
+		counter = p.Module.NewGlobalDef(fmt.Sprintf("__coverage.%d", len(c.counters)), constant.NewInt(0, types.I64))
+		counter.Linkage = ir.LinkageInternal
+		c.counters[block] = counter
+		count := block.NewLoad(counter)
+		block.NewStore(block.NewAdd(count, constant.NewInt(1, types.I64)), counter)
+	}
+
+	line := coverageLine{counter, util.TrimPath(path), tok.Line}
+	if !c.seen[line] {
+		c.seen[line] = true
+		c.lines = append(c.lines, line)
+	}
+}
+
+// coverageRegister returns the function that registers the counters of the
+// program with the runtime, so they are written out at exit
+func (p *Program) coverageRegister() *ir.Function {
+	c := p.coverageState()
+	if c.register == nil {
+		c.register = p.Module.NewFunction(coverageRegisterName, types.Void)
+		c.register.Linkage = ir.LinkageInternal
+	}
+	return c.register
+}
+
+func (p *Program) coverageState() *coverage {
+	if p.coverage == nil {
+		p.coverage = &coverage{
+			counters: make(map[*ir.BasicBlock]*ir.Global),
+			seen:     make(map[coverageLine]bool),
+		}
+	}
+	return p.coverage
+}
+
+// CompileCoverage compiles the function that registers the counters of a
+// program built with --coverage. It has to be called after everything else
+// is compiled, as the counters are only known then. The runtime is given a
+// table of the file and line of every line a counter counts.
+func (p *Program) CompileCoverage() error {
+	if !*arg.Coverage || p.coverage == nil || p.coverage.register == nil {
+		return nil
+	}
+	c := p.coverage
+	block := c.register.NewBlock("entry")
+	p.Compiler.PushFunc(c.register)
+	defer p.Compiler.PopFunc()
+	p.Compiler.PushBlock(block)
+	defer p.Compiler.PopBlock()
+
+	if len(c.lines) > 0 {
+		counters := make([]constant.Constant, len(c.lines))
+		files := make([]constant.Constant, len(c.lines))
+		lines := make([]constant.Constant, len(c.lines))
+		for i, line := range c.lines {
+			counters[i] = line.counter
+			files[i] = formatStringConstant(p, line.file).(constant.Constant)
+			lines[i] = constant.NewInt(int64(line.line), types.I32)
+		}
+		table := func(name string, elems []constant.Constant) constant.Constant {
+			global := p.Module.NewGlobalDef(name, constant.NewArray(elems...))
+			global.Linkage = ir.LinkageInternal
+			global.IsConst = true
+			zero := constant.NewInt(0, types.I32)
+			return constant.NewGetElementPtr(global, zero, zero)
+		}
+		n := constant.NewInt(int64(len(c.lines)), types.I64)
+		_, err := p.NewRuntimeFunctionCall("__runtime_coverage",
+			table("__coverage_counters", counters),
+			table("__coverage_files", files),
+			table("__coverage_lines", lines),
+			n)
+		if err != nil {
+			return err
+		}
+	}
+	block.NewRet(nil)
+	return nil
+}
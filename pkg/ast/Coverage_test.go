@@ -0,0 +1,76 @@
+package ast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/pkg/arg"
+)
+
+const coverageSource = `is main
+
+func main int {
+	int total = 0;
+	for int i = 0; i < 3; i++ {
+		total = total + i;
+	}
+	if total > 100 {
+		return 1;
+	}
+	return total;
+}
+`
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		coverage bool
+		// lines are the lines counted, which are handed to the runtime
+		lines []int64
+	}{
+		{false, nil},
+		// the header of the for loop is counted with the block before it
+		{true, []int64{4, 5, 6, 8, 9, 11}},
+	}
+	for _, test := range tests {
+		*arg.Coverage = test.coverage
+		m, diagnostics, err := compileText(t, coverageSource, CompileOptions{})
+		*arg.Coverage = false
+		if err != nil {
+			t.Fatalf("%s %v", err, diagnostics)
+		}
+
+		var lines []int64
+		for _, global := range m.Globals {
+			if global.Name != "__coverage_lines" {
+				continue
+			}
+			for _, elem := range global.Init.(*constant.Array).Elems {
+				lines = append(lines, elem.(*constant.Int).X.Int64())
+			}
+		}
+		if !reflect.DeepEqual(lines, test.lines) {
+			t.Errorf("coverage %v: counted the lines %v, want %v", test.coverage, lines, test.lines)
+		}
+
+		// every block of main counts that it ran first thing
+		for _, f := range m.Funcs {
+			if f.Name != "main.main" {
+				continue
+			}
+			for _, block := range f.Blocks {
+				counted := false
+				if len(block.Insts) > 0 {
+					load, isLoad := block.Insts[0].(*ir.InstLoad)
+					counted = isLoad && strings.HasPrefix(load.Src.Ident(), "@__coverage.")
+				}
+				// the cond block of the for loop starts with its phis
+				if counted != test.coverage && !strings.HasSuffix(block.Name, "_cond") {
+					t.Errorf("coverage %v: block %s counted = %v", test.coverage, block.Name, counted)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// DataLayout holds the alignments, in bits, an llvm data layout string
+// gives each kind of type, by the size of the type in bits
+type DataLayout struct {
+	PointerSize  int
+	PointerAlign int
+	Ints         map[int]int
+	Floats       map[int]int
+	Vectors      map[int]int
+	// AggregateAlign is the least alignment of structs
+	AggregateAlign int
+}
+
+// ParseDataLayout parses the specifications in a data layout string over
+// the defaults llvm uses for the ones it leaves out
+func ParseDataLayout(layout string) (*DataLayout, error) {
+	dl := &DataLayout{
+		PointerSize:  64,
+		PointerAlign: 64,
+		Ints:         map[int]int{1: 8, 8: 8, 16: 16, 32: 32, 64: 32},
+		Floats:       map[int]int{16: 16, 32: 32, 64: 64, 128: 128},
+		Vectors:      map[int]int{64: 64, 128: 128},
+	}
+
+	for _, spec := range strings.Split(layout, "-") {
+		if spec == "" {
+			continue
+		}
+		kind := spec[:1]
+		fields := strings.Split(spec[1:], ":")
+		nums := make([]int, 0, len(fields))
+		for _, f := range fields {
+			// a left out address space, as in p:32:32, is the default one
+			if f == "" {
+				f = "0"
+			}
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				nums = nil
+				break
+			}
+			nums = append(nums, n)
+		}
+
+		switch kind {
+		case "i", "f", "v":
+			if len(nums) < 2 {
+				return nil, fmt.Errorf("invalid data layout specification %q", spec)
+			}
+			table := map[string]map[int]int{"i": dl.Ints, "f": dl.Floats, "v": dl.Vectors}[kind]
+			table[nums[0]] = nums[1]
+		case "p":
+			// the address space comes before the size, as in p0:64:64
+			if strings.HasPrefix(spec, "p:") || strings.HasPrefix(spec, "p0:") {
+				if len(nums) < 3 {
+					return nil, fmt.Errorf("invalid data layout specification %q", spec)
+				}
+				dl.PointerSize, dl.PointerAlign = nums[1], nums[2]
+			}
+		case "a":
+			if len(nums) >= 2 {
+				dl.AggregateAlign = nums[1]
+			}
+		}
+	}
+	return dl, nil
+}
+
+// sizeOf returns the number of bytes between consecutive values of a type
+// in an array, which is what has to be allocated for one
+func (p *Program) sizeOf(t types.Type) (int64, error) {
+	switch t := t.(type) {
+	case *types.IntType:
+		return alignTo(int64(t.Size+7)/8, p.mustAlignOf(t)), nil
+	case *types.FloatType:
+		return alignTo(int64(t.Kind.Size()), p.mustAlignOf(t)), nil
+	case *types.PointerType:
+		return int64(p.layout.PointerSize / 8), nil
+	case *types.VectorType:
+		elem, err := p.sizeOf(t.Elem)
+		if err != nil {
+			return 0, err
+		}
+		return alignTo(elem*t.Len, p.mustAlignOf(t)), nil
+	case *types.ArrayType:
+		elem, err := p.sizeOf(t.Elem)
+		if err != nil {
+			return 0, err
+		}
+		return elem * t.Len, nil
+	case *types.SliceType:
+		return p.sizeOf(&t.StructType)
+	case *types.StructType:
+		var size int64
+		for _, field := range t.Fields {
+			fieldSize, err := p.sizeOf(field)
+			if err != nil {
+				return 0, err
+			}
+			size = alignTo(size, p.mustAlignOf(field)) + fieldSize
+		}
+		return alignTo(size, p.mustAlignOf(t)), nil
+	}
+	return 0, fmt.Errorf("type %s has no size", t)
+}
+
+// alignOf returns the alignment of a type in bytes
+func (p *Program) alignOf(t types.Type) (int64, error) {
+	switch t := t.(type) {
+	case *types.IntType:
+		return int64(layoutAlign(p.layout.Ints, t.Size) / 8), nil
+	case *types.FloatType:
+		bits := t.Kind.Size() * 8
+		if t.Kind == types.FloatKindDoubleExtended_80 {
+			bits = 80
+		}
+		return int64(layoutAlign(p.layout.Floats, bits) / 8), nil
+	case *types.PointerType:
+		return int64(p.layout.PointerAlign / 8), nil
+	case *types.VectorType:
+		elem, err := p.sizeOf(t.Elem)
+		if err != nil {
+			return 0, err
+		}
+		bits := int(elem*t.Len) * 8
+		if align, found := p.layout.Vectors[bits]; found {
+			return int64(align / 8), nil
+		}
+		// vectors without an alignment in the layout are aligned to
+		// their size, rounded up to a power of two
+		align := int64(1)
+		for align < elem*t.Len {
+			align *= 2
+		}
+		return align, nil
+	case *types.ArrayType:
+		return p.alignOf(t.Elem)
+	case *types.SliceType:
+		return p.alignOf(&t.StructType)
+	case *types.StructType:
+		align := int64(p.layout.AggregateAlign / 8)
+		if align < 1 {
+			align = 1
+		}
+		for _, field := range t.Fields {
+			fieldAlign, err := p.alignOf(field)
+			if err != nil {
+				return 0, err
+			}
+			if fieldAlign > align {
+				align = fieldAlign
+			}
+		}
+		if int64(p.typeAlignments[t]) > align {
+			align = int64(p.typeAlignments[t])
+		}
+		return align, nil
+	}
+	return 0, fmt.Errorf("type %s has no alignment", t)
+}
+
+// mustAlignOf returns the alignment of a type sizeOf already knows has one
+func (p *Program) mustAlignOf(t types.Type) int64 {
+	align, _ := p.alignOf(t)
+	return align
+}
+
+// layoutAlign returns the alignment a data layout table gives a size in
+// bits. Sizes that aren't in it take the alignment of the next larger
+// size, or of the largest one if there is none.
+func layoutAlign(table map[int]int, bits int) int {
+	if align, found := table[bits]; found {
+		return align
+	}
+	best, largest := -1, -1
+	for size := range table {
+		if size > bits && (best < 0 || size < best) {
+			best = size
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	if best < 0 {
+		best = largest
+	}
+	return table[best]
+}
+
+// alignTo rounds n up to a multiple of align
+func alignTo(n, align int64) int64 {
+	if align <= 1 {
+		return n
+	}
+	return (n + align - 1) / align * align
+}
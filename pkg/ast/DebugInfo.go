@@ -0,0 +1,247 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/debug"
+	"github.com/geode-lang/geode/pkg/lexer"
+	"github.com/geode-lang/geode/pkg/util"
+)
+
+// debugStatement is a statement being compiled, whose location is given
+// to the instructions compiled for it
+type debugStatement struct {
+	token lexer.Token
+	scope *metadata.Metadata
+}
+
+// tokenNode is a node that knows the token it starts at
+type tokenNode interface {
+	token() lexer.Token
+}
+
+// debugInfo returns the builder of the debug information of the program,
+// or nil if it is built without. It is made on first use, as the module
+// is only made once the program is congealed.
+func (p *Program) debugInfo() *debug.Builder {
+	if !*arg.EnableDebug {
+		return nil
+	}
+	if p.debug == nil {
+		p.debug = debug.NewBuilder(p.Module, util.TrimPath(p.Entry), "geode")
+	}
+	return p.debug
+}
+
+// debugScopeDown gives the scope just stepped into a lexical block, if it
+// is in a function with debug information
+func (p *Program) debugScopeDown(tok lexer.Token) {
+	d := p.debugInfo()
+	if d == nil || p.Scope.Parent == nil || p.Scope.Parent.DebugInfo == nil || tok.SourcePath() == "" {
+		return
+	}
+	p.Scope.DebugInfo = d.LexicalBlock(tok.DebugFileInfo(p.Scope.Parent.DebugInfo))
+}
+
+// debugFunction gives a function being compiled its subprogram, which its
+// scope and the scopes in it are inside of. The statements of the function
+// that encloses it are set aside until debugFunctionEnd.
+func (p *Program) debugFunction(n FunctionNode, fn *ir.Function) []debugStatement {
+	d := p.debugInfo()
+	if d == nil || n.Token.SourcePath() == "" {
+		return nil
+	}
+	ret := p.debugType(fn.Sig.Ret)
+	params := make([]*metadata.Metadata, len(fn.Params()))
+	for i, param := range fn.Params() {
+		params[i] = p.debugType(param.Type())
+	}
+	p.Scope.DebugInfo = d.Subprogram(fn, n.Name.String(), n.Token.DebugFileInfo(nil), ret, params)
+
+	enclosing := p.debugStatements
+	p.debugStatements = nil
+	return enclosing
+}
+
+// debugFunctionEnd gives the instructions of a function that no statement
+// claimed, like those of its prelude, the location of the function
+func (p *Program) debugFunctionEnd(n FunctionNode, fn *ir.Function, enclosing []debugStatement) {
+	sp, found := fn.Metadata["dbg"]
+	if p.debugInfo() == nil || !found {
+		return
+	}
+	p.debugLocate(fn, debugStatement{n.Token, sp})
+	p.debugStatements = enclosing
+}
+
+// debugDeclare tells the debugger where a local variable lives. Parameters
+// are numbered from 1 by arg, which is 0 for other variables.
+func (p *Program) debugDeclare(name string, arg int, tok lexer.Token, alloca *ir.InstAlloca) {
+	d := p.debugInfo()
+	if d == nil || p.Scope.DebugInfo == nil || tok.SourcePath() == "" {
+		return
+	}
+	pos := tok.DebugFileInfo(p.Scope.DebugInfo)
+	variable := d.LocalVariable(name, arg, pos, p.debugType(alloca.Elem))
+	d.Declare(p.Compiler.CurrentBlock(), alloca, variable, d.Location(pos))
+}
+
+// debugStatementStart is called as a statement starts being compiled. The
+// instructions compiled so far belong to the statement it is in.
+func (p *Program) debugStatementStart(node Node) {
+	if p.debugInfo() == nil || p.Scope.DebugInfo == nil {
+		return
+	}
+	fn := p.Compiler.CurrentFunc()
+	if len(p.debugStatements) > 0 {
+		p.debugLocate(fn, p.debugStatements[len(p.debugStatements)-1])
+	}
+	stmt := debugStatement{scope: p.Scope.DebugInfo}
+	if n, ok := node.(tokenNode); ok && n.token().SourcePath() != "" {
+		stmt.token = n.token()
+	} else if len(p.debugStatements) > 0 {
+		// statements made by the compiler are part of the one around them
+		stmt = p.debugStatements[len(p.debugStatements)-1]
+	}
+	p.debugStatements = append(p.debugStatements, stmt)
+}
+
+// debugStatementEnd gives the instructions compiled for a statement its
+// location
+func (p *Program) debugStatementEnd() {
+	if p.debugInfo() == nil || len(p.debugStatements) == 0 {
+		return
+	}
+	stmt := p.debugStatements[len(p.debugStatements)-1]
+	p.debugStatements = p.debugStatements[:len(p.debugStatements)-1]
+	if stmt.token.SourcePath() != "" {
+		p.debugLocate(p.Compiler.CurrentFunc(), stmt)
+	}
+}
+
+// debugLocate gives every instruction of a function that has no location
+// yet the location of a statement. llvm requires calls in functions with
+// debug information to have one, so every instruction is given one.
+func (p *Program) debugLocate(fn *ir.Function, stmt debugStatement) {
+	if fn == nil || fn.Metadata["dbg"] == nil {
+		return
+	}
+	loc := p.debugInfo().Location(stmt.token.DebugFileInfo(stmt.scope))
+	locate := func(inst interface{}) {
+		field := reflect.ValueOf(inst).Elem().FieldByName("Metadata")
+		if !field.IsValid() {
+			return
+		}
+		md := field.Interface().(map[string]*metadata.Metadata)
+		if md != nil && md["dbg"] == nil {
+			md["dbg"] = loc
+		}
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			locate(inst)
+		}
+		if block.Term != nil {
+			locate(block.Term)
+		}
+	}
+}
+
+// debugType returns the debug information of a type, or nil for void
+func (p *Program) debugType(t types.Type) *metadata.Metadata {
+	if md, found := p.debugTypes[t]; found {
+		return md
+	}
+	d := p.debugInfo()
+	bits := func(t types.Type) int64 {
+		size, _ := p.sizeOf(t)
+		return size * 8
+	}
+
+	var md *metadata.Metadata
+	switch t := t.(type) {
+	case *types.VoidType:
+		return nil
+	case *types.IntType:
+		name, encoding := debugIntName(t)
+		md = d.BasicType(name, int64(t.Size+7)/8*8, encoding)
+	case *types.FloatType:
+		// a geode float is a double
+		name := t.String()
+		switch t.Kind {
+		case types.FloatKindIEEE_32:
+			name = "f32"
+		case types.FloatKindIEEE_64:
+			name = "float"
+		}
+		md = d.BasicType(name, int64(t.Kind.Size()*8), "DW_ATE_float")
+	case *types.PointerType:
+		// the pointer is cached before what it points to is described, so
+		// structs that point to themselves refer back to it
+		md = d.PointerType(int64(p.layout.PointerSize))
+		p.debugTypes[t] = md
+		d.SetPointee(md, p.debugType(t.Elem))
+		return md
+	case *types.ArrayType:
+		md = d.ArrayType(p.debugType(t.Elem), t.Len, bits(t))
+	case *types.SliceType:
+		md = p.debugStruct(t.String(), &t.StructType, []string{"data", "len"})
+	case *types.StructType:
+		name := strings.TrimPrefix(strings.TrimPrefix(t.Name, "class."), "union.")
+		md = p.debugStruct(name, t, t.Names)
+	default:
+		md = d.BasicType(t.String(), bits(t), "DW_ATE_unsigned")
+	}
+	p.debugTypes[t] = md
+	return md
+}
+
+// debugStruct returns the debug information of a struct, with the names of
+// its fields
+func (p *Program) debugStruct(name string, t *types.StructType, names []string) *metadata.Metadata {
+	d := p.debugInfo()
+	size, _ := p.sizeOf(t)
+	align, _ := p.alignOf(t)
+	st := d.StructType(name, size*8, align*8)
+	p.debugTypes[t] = st
+
+	members := make([]debug.Member, len(t.Fields))
+	var offset int64
+	for i, field := range t.Fields {
+		fieldSize, _ := p.sizeOf(field)
+		offset = alignTo(offset, p.mustAlignOf(field))
+		name := fmt.Sprintf("field%d", i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		members[i] = debug.Member{Name: name, Type: p.debugType(field), Size: fieldSize * 8, Offset: offset * 8}
+		offset += fieldSize
+	}
+	d.SetMembers(st, members)
+	return st
+}
+
+// debugIntName returns the geode name of an integer type and its dwarf
+// encoding
+func debugIntName(t *types.IntType) (string, string) {
+	names := map[int]string{1: "bool", 8: "byte", 16: "short", 32: "int", 64: "long", 128: "i128", 256: "large", 512: "huge"}
+	name, found := names[t.Size]
+	if !found {
+		name = fmt.Sprintf("i%d", t.Size)
+	}
+	switch {
+	case t.Size == 1:
+		return name, "DW_ATE_boolean"
+	case t.Unsigned:
+		return fmt.Sprintf("u%d", t.Size), "DW_ATE_unsigned"
+	case t.Size == 8:
+		return name, "DW_ATE_signed_char"
+	}
+	return name, "DW_ATE_signed"
+}
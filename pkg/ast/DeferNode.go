@@ -0,0 +1,113 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// DeferNode defers an expression, like `defer free(buf)`, to be run when
+// the function it is in returns. Deferred expressions are run before every
+// return that comes after the defer, last deferred first, and are only
+// evaluated when they run.
+//
+// A defer can only be in the outermost block of a function, so every
+// return after it is sure to have run past it and the variables it uses
+// are always in scope.
+type DeferNode struct {
+	NodeType
+	TokenReference
+
+	Expr Node
+}
+
+// functionDefers are the expressions deferred in a function
+type functionDefers struct {
+	// scope is the scope of the function's arguments, the parent of the
+	// scope of its outermost block
+	scope *Scope
+	// body is the scope of the outermost block, that deferred expressions
+	// are compiled in
+	body  *Scope
+	exprs []Node
+	// unlocks are the unlocks of the with statements being generated,
+	// innermost last, which a return runs before the deferred expressions
+	unlocks []Node
+	// pads are the landing pads that clean up after the calls that throw,
+	// by the cleanups they run, see cleanupPad
+	pads map[cleanupKey]*ir.BasicBlock
+	// cleaning is set while a landing pad is generated, whose calls don't
+	// clean up again when they throw
+	cleaning bool
+}
+
+// cleanupKey is the withs held and the expressions deferred at a call,
+// which a landing pad of the call cleans up, along with the counted locals
+// declared with --arc. The innermost with held is named by its unlock, as
+// the withs around it are always the same.
+type cleanupKey struct {
+	unlock string
+	defers int
+	locals int
+}
+
+// cleanups returns the key of the cleanups of a call made now
+func (d *functionDefers) cleanups() cleanupKey {
+	key := cleanupKey{defers: len(d.exprs)}
+	if len(d.unlocks) > 0 {
+		key.unlock = d.unlocks[len(d.unlocks)-1].String()
+	}
+	return key
+}
+
+// NameString implements Node.NameString
+func (n DeferNode) NameString() string { return "DeferNode" }
+
+// MarshalJSON implements json.Marshaler for DeferNode
+func (n DeferNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n DeferNode) String() string {
+	return fmt.Sprintf("defer %s", n.Expr)
+}
+
+// Codegen implements Node.Codegen for DeferNode. Nothing is generated
+// until the function returns.
+func (n DeferNode) Codegen(prog *Program) (value.Value, error) {
+	defers, found := prog.deferred[prog.Compiler.CurrentFunc()]
+	if !found || prog.Scope.Parent != defers.scope {
+		return nil, n.Errorf(ErrInvalid, "defer can only be used in the outermost block of a function")
+	}
+	defers.body = prog.Scope
+	defers.exprs = append(defers.exprs, n.Expr)
+	return nil, nil
+}
+
+// genDeferred generates the expressions deferred so far in the current
+// function, before it returns, after unlocking the locks held by the with
+// statements it returns from
+func (p *Program) genDeferred() error {
+	defers, found := p.deferred[p.Compiler.CurrentFunc()]
+	if !found {
+		return nil
+	}
+	for i := len(defers.unlocks) - 1; i >= 0; i-- {
+		if _, err := defers.unlocks[i].Codegen(p); err != nil {
+			return err
+		}
+	}
+	if len(defers.exprs) == 0 {
+		return nil
+	}
+
+	previousScope := p.Scope
+	defer func() { p.Scope = previousScope }()
+	p.Scope = defers.body
+
+	for i := len(defers.exprs) - 1; i >= 0; i-- {
+		if _, err := defers.exprs[i].Codegen(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
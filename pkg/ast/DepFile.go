@@ -0,0 +1,71 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dependencies returns every file that went into building the program:
+// the geode sources it parsed, the c sources it links and the c headers it
+// includes. The paths are absolute and sorted. Directories aren't listed,
+// as make would rebuild the program whenever anything in one changed, like
+// the object files written next to the sources.
+func (p *Program) Dependencies() []string {
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		seen[path] = true
+	}
+
+	for _, file := range p.ParsedFiles {
+		add(file)
+	}
+	for _, file := range p.CLinkages {
+		add(file)
+	}
+	for _, header := range p.cHeaders {
+		for _, file := range header.files {
+			add(file)
+		}
+	}
+
+	deps := make([]string, 0, len(seen))
+	for dep := range seen {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// WriteDepFile writes a makefile rule saying target depends on every
+// dependency of the program, like the .d files `cc -MD -MP` writes. Every
+// dependency also gets an empty rule, so make doesn't fail when one of
+// them is deleted.
+func (p *Program) WriteDepFile(path, target string) error {
+	deps := p.Dependencies()
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s:", escapeMakePath(target))
+	for _, dep := range deps {
+		fmt.Fprintf(buf, " \\\n  %s", escapeMakePath(dep))
+	}
+	buf.WriteString("\n")
+	for _, dep := range deps {
+		fmt.Fprintf(buf, "\n%s:\n", escapeMakePath(dep))
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0666)
+}
+
+// escapeMakePath escapes the characters make treats specially in a path
+func escapeMakePath(path string) string {
+	path = strings.Replace(path, "$", "$$", -1)
+	path = strings.Replace(path, "#", "\\#", -1)
+	return strings.Replace(path, " ", "\\ ", -1)
+}
@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEscapeMakePath(t *testing.T) {
+	tests := []struct{ path, want string }{
+		{"/proj/main.g", "/proj/main.g"},
+		{"/my proj/main.g", `/my\ proj/main.g`},
+		{"/proj/#1/$main.g", `/proj/\#1/$$main.g`},
+	}
+	for _, test := range tests {
+		if got := escapeMakePath(test.path); got != test.want {
+			t.Errorf("escapeMakePath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestWriteDepFile(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	p := NewProgram()
+	p.NoRuntime = true
+	p.Sources = FSSources("/my proj", fstest.MapFS{
+		"main.g":      {Data: []byte("is main\n\ninclude \"util\"\n\nfunc main int = util:zero();\n")},
+		"util/util.g": {Data: []byte("is util\n\nlink \"util.c\"\n\npub func zero int = 0;\n")},
+	})
+	if err := p.ParsePath(context.Background(), "/my proj/main.g"); err != nil {
+		t.Fatal(err)
+	}
+
+	// only files are prerequisites, not the directories they are in
+	path := filepath.Join(t.TempDir(), "main.d")
+	if err := p.WriteDepFile(path, "/my proj/main"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `/my\ proj/main: \
+  /my\ proj/main.g \
+  /my\ proj/util/util.c \
+  /my\ proj/util/util.g
+
+/my\ proj/main.g:
+
+/my\ proj/util/util.c:
+
+/my\ proj/util/util.g:
+`
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
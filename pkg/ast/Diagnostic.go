@@ -0,0 +1,248 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/lexer"
+	"github.com/geode-lang/geode/pkg/util/color"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// Severity is how serious a diagnostic is
+type Severity int
+
+const (
+	// SeverityError diagnostics stop the program from compiling
+	SeverityError Severity = iota
+	// SeverityWarning diagnostics are problems that don't
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// The codes of the errors the compiler gives. Warnings use the name of the
+// warning as their code, which is what @allow takes.
+const (
+	// ErrSyntax is given for code that can't be parsed
+	ErrSyntax = "E0001"
+	// ErrInvalid is given for code that parses but can't be compiled
+	ErrInvalid = "E0002"
+	// ErrType is given for values of the wrong type
+	ErrType = "E0003"
+	// ErrUndefined is given for names that aren't declared
+	ErrUndefined = "E0004"
+	// ErrVisibility is given for uses of declarations that aren't pub
+	ErrVisibility = "E0005"
+	// ErrConstant is given for values that have to be known at compile time
+	// and aren't
+	ErrConstant = "E0006"
+	// ErrInternal is given when the compiler fails on code it should have
+	// compiled or reported an error for
+	ErrInternal = "E0007"
+)
+
+// Diagnostic is an error or warning about the code at a token. It is an
+// error, so it can be returned up through codegen to where it is collected
+// on the program.
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	// Token is where the diagnostic is, it spans the source of the token.
+	// Diagnostics that aren't about any code have the zero token.
+	Token   lexer.Token
+	Message string
+}
+
+// newError returns an error diagnostic at a token
+func newError(tok lexer.Token, code string, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{SeverityError, code, tok, fmt.Sprintf(format, args...)}
+}
+
+// diagnose places an error at a token, unless it already has a place
+func diagnose(tok lexer.Token, err error) error {
+	if d, is := err.(*Diagnostic); is {
+		if d.Token.SourcePath() != "" {
+			return d
+		}
+		placed := *d
+		placed.Token = tok
+		return &placed
+	}
+	return newError(tok, ErrInvalid, "%s", err)
+}
+
+func (d *Diagnostic) Error() string {
+	return d.Message
+}
+
+func (d *Diagnostic) String() string {
+	if d.Token.SourcePath() == "" {
+		return fmt.Sprintf("%s[%s]: %s", d.Severity, d.Code, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s[%s]: %s", filepath.Clean(d.Token.SourcePath()), d.Token.Line, d.Severity, d.Code, d.Message)
+}
+
+// MarshalJSON implements json.Marshaler for Diagnostic. Columns count from
+// 1 in runes. Diagnostics that aren't about any code have no file, line or
+// column.
+func (d *Diagnostic) MarshalJSON() ([]byte, error) {
+	out := struct {
+		File     string `json:"file,omitempty"`
+		Line     int    `json:"line,omitempty"`
+		Column   int    `json:"column,omitempty"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Severity string `json:"severity"`
+	}{
+		Code:     d.Code,
+		Message:  d.Message,
+		Severity: d.Severity.String(),
+	}
+	if d.Token.SourcePath() != "" {
+		out.File = filepath.Clean(d.Token.SourcePath())
+		out.Line = d.Token.Line
+		out.Column = d.Token.StartColumn()
+	}
+	return json.Marshal(out)
+}
+
+// Render returns the diagnostic as it is printed, with the line of source
+// it is about and a caret under its token
+func (d *Diagnostic) Render() string {
+	buf := &bytes.Buffer{}
+
+	title := fmt.Sprintf("%s[%s]", d.Severity, d.Code)
+	if d.Severity == SeverityWarning {
+		title = color.Yellow(title)
+	} else {
+		title = color.Red(title)
+	}
+	fmt.Fprintf(buf, "%s: %s\n", title, d.Message)
+
+	line, start, end := d.Token.Excerpt()
+	if d.Token.SourcePath() == "" {
+		return buf.String()
+	}
+	number := fmt.Sprintf("%d", d.Token.Line)
+	gutter := strings.Repeat(" ", len(number))
+	fmt.Fprintf(buf, "%s%s %s:%d:%d\n", gutter, color.Blue("-->"), filepath.Clean(d.Token.SourcePath()), d.Token.Line, start+1)
+	fmt.Fprintf(buf, "%s %s\n", gutter, color.Blue("|"))
+	fmt.Fprintf(buf, "%s %s %s\n", color.Blue(number), color.Blue("|"), line)
+	caret := strings.Repeat(" ", start) + strings.Repeat("^", end-start)
+	if d.Severity == SeverityWarning {
+		caret = color.Yellow(caret)
+	} else {
+		caret = color.Red(caret)
+	}
+	fmt.Fprintf(buf, "%s %s %s\n", gutter, color.Blue("|"), caret)
+	return buf.String()
+}
+
+// Diagnose collects an error the program failed to compile with. Errors
+// that aren't diagnostics are kept without a place in the source.
+func (p *Program) Diagnose(err error) {
+	p.diagnostics = append(p.diagnostics, diagnose(lexer.Token{}, err).(*Diagnostic))
+}
+
+// syntaxErrors is the error of parsing code with syntax errors, which are
+// already collected as diagnostics of the program
+type syntaxErrors int
+
+func (n syntaxErrors) Error() string {
+	if n == 1 {
+		return "1 syntax error"
+	}
+	return fmt.Sprintf("%d syntax errors", int(n))
+}
+
+// Fail collects the error the program failed to compile with, reports the
+// diagnostics of the program and stops the compile
+func (p *Program) Fail(err error) {
+	if _, collected := err.(syntaxErrors); !collected {
+		p.Diagnose(err)
+	}
+	p.ReportDiagnostics()
+	if !jsonDiagnostics() {
+		fmt.Println(color.Red("Failed to Compile"))
+	}
+	os.Exit(1)
+}
+
+// Diagnostics returns the errors and the warnings of the program, once
+// each, ordered by where they are in the source
+func (p *Program) Diagnostics() []*Diagnostic {
+	all := append([]*Diagnostic{}, p.diagnostics...)
+	severity := SeverityWarning
+	if p.warningsAreErrors {
+		severity = SeverityError
+	}
+	for _, w := range p.Warnings() {
+		all = append(all, &Diagnostic{severity, w.Name, w.Token, w.Message})
+	}
+
+	seen := make(map[string]bool)
+	result := make([]*Diagnostic, 0, len(all))
+	for _, d := range all {
+		if seen[d.String()] {
+			continue
+		}
+		seen[d.String()] = true
+		result = append(result, d)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Token, result[j].Token
+		if a.SourcePath() != b.SourcePath() {
+			return a.SourcePath() < b.SourcePath()
+		}
+		return a.Pos < b.Pos
+	})
+	return result
+}
+
+// ReportDiagnostics prints the diagnostics of the program and returns how
+// many of them are errors
+func (p *Program) ReportDiagnostics() int {
+	return printDiagnostics(p.Diagnostics())
+}
+
+// jsonDiagnostics reports if diagnostics are printed as json for tools to
+// read, with --diag-format=json. Nothing else is printed about them then,
+// the exit status tells if the compile failed.
+func jsonDiagnostics() bool {
+	return *arg.DiagFormat == "json"
+}
+
+// printDiagnostics prints diagnostics in the format picked on the command
+// line and returns how many of them are errors. As json they are printed
+// as one array, which is empty if there are none.
+func printDiagnostics(diagnostics []*Diagnostic) int {
+	errors := 0
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			errors++
+		}
+	}
+
+	if jsonDiagnostics() {
+		out, _ := json.Marshal(diagnostics)
+		log.Printf("%s\n", out)
+		return errors
+	}
+	for _, d := range diagnostics {
+		log.Printf("%s\n", d.Render())
+	}
+	return errors
+}
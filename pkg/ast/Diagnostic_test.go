@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+func TestDiagnosticJSON(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+
+	source := Source{Path: "/proj/main.g", Text: "is main\n\nfunc main int {\n\treturn 1 + nothere;\n}\n"}
+	_, diagnostics, err := Compile(context.Background(), source, CompileOptions{NoRuntime: true})
+	if err == nil {
+		t.Fatal("compiled a program with an undefined identifier")
+	}
+	var undefined *Diagnostic
+	for _, d := range diagnostics {
+		if d.Code == ErrUndefined {
+			undefined = d
+		}
+	}
+	if undefined == nil {
+		t.Fatalf("no %s diagnostic in %v", ErrUndefined, diagnostics)
+	}
+
+	tests := []struct {
+		name string
+		d    *Diagnostic
+		want string
+	}{
+		// columns count from 1
+		{"placed", undefined, `{"file":"/proj/main.g","line":4,"column":13,"code":"E0004","message":"nothere is not defined","severity":"error"}`},
+		// a diagnostic that isn't about any code has no place
+		{"unplaced", newError(lexer.Token{}, ErrInternal, "out of memory"), `{"code":"E0007","message":"out of memory","severity":"error"}`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.d)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -21,9 +21,117 @@ func (n DotReference) String() string {
 	return fmt.Sprintf("%s.%s", n.Base, n.Field)
 }
 
-// BaseType returns the type of the base struct to a class
+// static returns the name a reference to a static member of a class, like
+// `Counter.count`, is declared with. It is only one if the base is the name
+// of a class rather than a variable.
+func (n DotReference) static(prog *Program) (IdentNode, bool) {
+	base, isIdent := n.Base.(IdentNode)
+	if !isIdent {
+		return IdentNode{}, false
+	}
+	if variable, err := base.lookup(prog); variable != nil || err != nil {
+		return IdentNode{}, false
+	}
+	if found, err := prog.FindType(base.Value); err == nil && !types.IsStruct(found) {
+		return IdentNode{}, false
+	}
+	member := NewIdentNode(fmt.Sprintf("%s.%s", base.Value, n.Field))
+	member.Token = n.Token
+	member.NodeType = nodeIdent
+	return member, true
+}
+
+// isStatic reports if the reference is to a static member of a class
+func (n DotReference) isStatic(prog *Program) bool {
+	_, isStatic := n.static(prog)
+	return isStatic
+}
+
+// evaluated returns the reference with a base that has to be generated, like
+// the call in `make(4).value`, generated once and replaced by its address, so
+// the base isn't generated again each time its type or address is needed
+func (n DotReference) evaluated(prog *Program) (DotReference, error) {
+	switch base := n.Base.(type) {
+	case IdentNode, addressNode:
+		return n, nil
+	case DotReference:
+		evaluated, err := base.evaluated(prog)
+		if err != nil {
+			return n, err
+		}
+		n.Base = evaluated
+		return n, nil
+	}
+	var addr, val value.Value
+	var err error
+	if sub, isSubscript := n.Base.(SubscriptNode); isSubscript {
+		addr, val, err = sub.genElement(prog)
+	} else {
+		val, err = n.Base.GenAccess(prog)
+	}
+	if err != nil {
+		return n, err
+	}
+	if addr == nil {
+		if val == nil {
+			return n, n.Errorf(ErrInvalid, "%s has no value to access %s of", n.Base, n.Field)
+		}
+		// with --arc, a result nothing else holds is released once the
+		// statement is done with it
+		temporary, err := prog.arcTemporary(val)
+		if err != nil {
+			return n, err
+		}
+		if temporary != nil {
+			addr = temporary
+		} else {
+			addr = createBlockAlloca(prog.Compiler.CurrentFunc(), val.Type(), "")
+			prog.Compiler.CurrentBlock().NewStore(val, addr)
+		}
+	}
+	n.Base = addressNode{n.Base, addr}
+	return n, nil
+}
+
+// addressNode is the base of a field access once it has been generated. It
+// refers to the address the value of its node is stored at.
+type addressNode struct {
+	node Reference
+	addr value.Value
+}
+
+func (n addressNode) String() string { return n.node.String() }
+
+// Alloca returns the address the value is stored at
+func (n addressNode) Alloca(prog *Program) value.Value { return n.addr }
+
+// Load loads the value from its address
+func (n addressNode) Load(block *ir.BasicBlock, prog *Program) *ir.InstLoad {
+	return block.NewLoad(n.addr)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n addressNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Load(prog.Compiler.CurrentBlock(), prog), nil
+}
+
+// GenAssign implements Assignable.GenAssign
+func (n addressNode) GenAssign(prog *Program, assignment value.Value, options ...AssignableOption) (value.Value, error) {
+	return nil, fmt.Errorf("unable to assign to %s, it isn't a variable", n.node)
+}
+
+// Type implements Assignable.Type
+func (n addressNode) Type(prog *Program) (types.Type, error) {
+	return n.addr.Type().(*types.PointerType).Elem, nil
+}
+
+// BaseType returns the type of the base struct to a class, or nil if the
+// base isn't defined
 func (n DotReference) BaseType(prog *Program) types.Type {
 	base := n.Base.Alloca(prog)
+	if base == nil {
+		return nil
+	}
 	baseType := base.Type()
 	for types.IsPointer(baseType) {
 		baseType = baseType.(*types.PointerType).Elem
@@ -31,6 +139,28 @@ func (n DotReference) BaseType(prog *Program) types.Type {
 	return baseType
 }
 
+// checkBase checks that the base is defined and that none of the pointers
+// followed to get to the field are optional
+func (n DotReference) checkBase(prog *Program) error {
+	t, err := n.Base.Type(prog)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return n.Errorf(ErrUndefined, "%s is not defined", n.Base)
+	}
+	for types.IsPointer(t) {
+		if err := prog.checkDereference(t, n.Base); err != nil {
+			return n.Diagnose(err)
+		}
+		t = t.(*types.PointerType).Elem
+	}
+	if union := prog.unionOf(t); union != nil {
+		return n.Errorf(ErrInvalid, "unable to access %s of union %s, its fields can only be read by matching on it", n.Field, union.Name)
+	}
+	return nil
+}
+
 // BaseAddr returns the true address of the base, be it through loads, etc...
 func (n DotReference) BaseAddr(prog *Program) value.Value {
 	var val value.Value
@@ -49,6 +179,16 @@ func (n DotReference) BaseAddr(prog *Program) value.Value {
 
 // GetFunc implements Callable.GetFunc
 func (n DotReference) GetFunc(prog *Program, argTypes []types.Type) (*ir.Function, []value.Value, error) {
+	if member, isStatic := n.static(prog); isStatic {
+		return member.GetFunc(prog, argTypes)
+	}
+	n, err := n.evaluated(prog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := n.checkBase(prog); err != nil {
+		return nil, nil, err
+	}
 
 	class := n.BaseType(prog)
 
@@ -83,11 +223,39 @@ func (n DotReference) GetFunc(prog *Program, argTypes []types.Type) (*ir.Functio
 	return fn, args, err
 }
 
-// Alloca returns the nearest alloca instruction in this scope with the given name
+// field returns the struct the field is in and the index of the field in
+// it, or an error if the base has no field of the name
+func (n DotReference) field(prog *Program) (*types.StructType, int, error) {
+	baseType := n.BaseType(prog)
+	if baseType == nil {
+		return nil, 0, n.Errorf(ErrUndefined, "%s is not defined", n.Base)
+	}
+	structType, hasFields := fieldsOf(baseType)
+	if !hasFields {
+		return nil, 0, n.Errorf(ErrType, "unable to access field %s of %s of type %s, only classes have fields", n.Field, n.Base, prog.typeName(baseType))
+	}
+	index := structType.FieldIndex(n.Field.String())
+	if index < 0 {
+		return nil, 0, n.Errorf(ErrType, "%s has no field %s", prog.typeName(baseType), n.Field)
+	}
+	return structType, index, nil
+}
+
+// Alloca returns the nearest alloca instruction in this scope with the given
+// name, or nil if the base has no such field
 func (n DotReference) Alloca(prog *Program) value.Value {
+	if member, isStatic := n.static(prog); isStatic {
+		return member.Alloca(prog)
+	}
+	n, err := n.evaluated(prog)
+	if err != nil {
+		return nil
+	}
+	_, index, err := n.field(prog)
+	if err != nil {
+		return nil
+	}
 	base := n.Base.Alloca(prog)
-	index := 0
-	baseType := n.BaseType(prog)
 
 	// An allocation is always a pointer, so we need to figure out what it is pointing to
 	// here, I coerce base's type into a *PointerType and pull the Elem type out of it.
@@ -101,8 +269,6 @@ func (n DotReference) Alloca(prog *Program) value.Value {
 	if types.IsPointer(elemType) {
 		base = prog.Compiler.CurrentBlock().NewLoad(base)
 	}
-	structType := baseType.(*types.StructType)
-	index = structType.FieldIndex(n.Field.String())
 
 	zero := constant.NewInt(0, types.I32)
 	fieldOffset := constant.NewInt(int64(index), types.I32)
@@ -114,6 +280,9 @@ func (n DotReference) Alloca(prog *Program) value.Value {
 // NameString implements Node.NameString
 func (n DotReference) NameString() string { return "DotReference" }
 
+// MarshalJSON implements json.Marshaler for DotReference
+func (n DotReference) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen
 func (n DotReference) Codegen(prog *Program) (value.Value, error) {
 	return n.GenAccess(prog)
@@ -121,7 +290,17 @@ func (n DotReference) Codegen(prog *Program) (value.Value, error) {
 
 // Load returns a load instruction on a named reference with the given name
 func (n DotReference) Load(block *ir.BasicBlock, prog *Program) *ir.InstLoad {
-	target := n.Alloca(prog).(*ir.InstGetElementPtr)
+	if !n.isStatic(prog) {
+		evaluated, err := n.evaluated(prog)
+		if err != nil {
+			return nil
+		}
+		n = evaluated
+	}
+	target, isField := n.Alloca(prog).(*ir.InstGetElementPtr)
+	if !isField {
+		return nil
+	}
 	t, _ := n.Type(prog)
 	target.Typ = types.NewPointer(t)
 	return block.NewLoad(target)
@@ -129,19 +308,73 @@ func (n DotReference) Load(block *ir.BasicBlock, prog *Program) *ir.InstLoad {
 
 // GenAssign implements Assignable.GenAssign
 func (n DotReference) GenAssign(prog *Program, assignment value.Value, options ...AssignableOption) (value.Value, error) {
+	if member, isStatic := n.static(prog); isStatic {
+		return member.GenAssign(prog, assignment, options...)
+	}
+	n, err := n.evaluated(prog)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.checkBase(prog); err != nil {
+		return nil, err
+	}
+	structType, _, err := n.field(prog)
+	if err != nil {
+		return nil, err
+	}
 	target := n.Alloca(prog)
-	prog.Compiler.CurrentBlock().NewStore(assignment, target)
+	if prog.arcWeak(structType, n.Field.String()) {
+		prog.Compiler.CurrentBlock().NewStore(assignment, target)
+		return assignment, nil
+	}
+	if err := prog.arcStore(assignment, target); err != nil {
+		return nil, err
+	}
 	return assignment, nil
 }
 
 // GenAccess implements Accessable.GenAccess
 func (n DotReference) GenAccess(prog *Program) (value.Value, error) {
+	if member, isStatic := n.static(prog); isStatic {
+		return member.GenAccess(prog)
+	}
+	n, err := n.evaluated(prog)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.checkBase(prog); err != nil {
+		return nil, err
+	}
+	if _, _, err := n.field(prog); err != nil {
+		return nil, err
+	}
 	return n.Load(prog.Compiler.CurrentBlock(), prog), nil
 }
 
 // Type implements Assignable.Type
 func (n DotReference) Type(prog *Program) (types.Type, error) {
-	baseType := n.BaseType(prog).(*types.StructType)
-	index := baseType.FieldIndex(n.Field.String())
-	return baseType.Fields[index], nil
+	if member, isStatic := n.static(prog); isStatic {
+		return member.Type(prog)
+	}
+	n, err := n.evaluated(prog)
+	if err != nil {
+		return nil, err
+	}
+	structType, index, err := n.field(prog)
+	if err != nil {
+		return nil, err
+	}
+	return structType.Fields[index], nil
+}
+
+// fieldsOf returns the struct layout of a type that has named fields.
+// A slice is laid out as the struct { data, len }.
+func fieldsOf(t types.Type) (*types.StructType, bool) {
+	switch t := t.(type) {
+	case *types.SliceType:
+		return &t.StructType, true
+	case *types.StructType:
+		return t, true
+	}
+	return nil, false
 }
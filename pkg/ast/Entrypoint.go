@@ -0,0 +1,121 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/arg"
+)
+
+// userMainName is the name the main function of a program is compiled
+// under. The c main function is generated by the compiler and calls it.
+const userMainName = "main.main"
+
+// debugAllocatorName is the runtime allocator --debug-alloc switches to
+const debugAllocatorName = "debug"
+
+// CompileEntrypoint compiles the main function of the program as well as
+// the real c main function that wraps it. The wrapper initializes the
+// runtime, hands argv to it so the args package can read it, and calls
+// main. If there is no main function, nil is returned.
+func (p *Program) CompileEntrypoint() (*ir.Function, error) {
+	userMain, err := p.GetFunction("main", FunctionCompilationOptions{})
+	if err != nil || userMain == nil {
+		return nil, err
+	}
+
+	ret := userMain.Sig.Ret
+	if !types.IsInt(ret) && !types.Equal(ret, types.Void) {
+		return nil, fmt.Errorf("main must return an integer or nothing, not %s", ret)
+	}
+
+	argc := ir.NewParam("argc", types.I32)
+	argv := ir.NewParam("argv", types.NewPointer(types.NewPointer(types.I8)))
+	entry := p.Module.NewFunction("main", types.I32, argc, argv)
+
+	block := entry.NewBlock("main_entry")
+	p.Compiler.PushFunc(entry)
+	defer p.Compiler.PopFunc()
+	p.Compiler.PushBlock(block)
+	defer p.Compiler.PopBlock()
+
+	if !p.NoRuntime {
+		// the allocator has to be picked before the runtime allocates anything
+		if *arg.DebugAlloc {
+			name := formatStringConstant(p, debugAllocatorName)
+			if _, err := p.NewRuntimeFunctionCall("__runtime_use_allocator", name); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.NewRuntimeFunctionCall("__init_runtime"); err != nil {
+			return nil, err
+		}
+		if _, err := p.NewRuntimeFunctionCall("__runtime_set_args", argc, argv); err != nil {
+			return nil, err
+		}
+		if *arg.Coverage {
+			block.NewCall(p.coverageRegister())
+		}
+	}
+
+	args, err := entrypointArgs(p, userMain, argc, argv)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Compiler.NewComment("User Code:")
+	var status value.Value = block.NewCall(userMain, args...)
+
+	if types.Equal(ret, types.Void) {
+		block.NewRet(constant.NewInt(0, types.I32))
+		return entry, nil
+	}
+
+	status, err = createTypeCast(p, status, types.I32)
+	if err != nil {
+		return nil, err
+	}
+	block.NewRet(status)
+	return entry, nil
+}
+
+// entrypointArgs returns the arguments to call main with. main can take
+// no arguments, the program arguments as a slice of strings, as in
+// `func main(string[] args) int`, or the c arguments argc and argv.
+func entrypointArgs(p *Program, userMain *ir.Function, argc, argv *types.Param) ([]value.Value, error) {
+	params := userMain.Params()
+	argsType := types.NewSlice(stringType)
+
+	switch {
+	case len(params) == 0:
+		return nil, nil
+
+	// the runtime makes the strings of the c arguments
+	case len(params) == 1 && types.Equal(params[0].Type(), argsType):
+		if p.NoRuntime {
+			return nil, fmt.Errorf("main can't take a string[] with --no-runtime, take (int argc, byte** argv) instead")
+		}
+		args, err := p.NewRuntimeFunctionCall("__runtime_args")
+		if err != nil {
+			return nil, err
+		}
+		return []value.Value{args}, nil
+
+	case len(params) <= 2 && types.IsInt(params[0].Type()):
+		count, err := createTypeCast(p, argc, params[0].Type())
+		if err != nil {
+			return nil, err
+		}
+		if len(params) == 1 {
+			return []value.Value{count}, nil
+		}
+		if types.Equal(params[1].Type(), argv.Type()) {
+			return []value.Value{count, argv}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("main must take no arguments, a string[] or (int argc, byte** argv), not %s", userMain.Sig)
+}
@@ -0,0 +1,155 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// EnumMember is a single named value of an enum
+type EnumMember struct {
+	TokenReference
+
+	Name string
+	// Value is the expression the member was given, or nil if it is
+	// one more than the member before it
+	Value Node
+}
+
+// EnumNode is an enum declaration. An enum is a type backed by an int,
+// with a name for each of the values it is meant to hold. The members
+// are accessed as `Color:Red`, and number up from zero or from the value
+// given to the member before them.
+type EnumNode struct {
+	NodeType
+	TokenReference
+
+	Package *Package
+	Name    string
+	Members []EnumMember
+	Pub     bool // exported from its package with pub
+
+	// Values are the values of the members by name, filled in when the
+	// enum is declared
+	Values map[string]int64
+}
+
+// NameString implements Node.NameString
+func (n EnumNode) NameString() string { return "EnumNode" }
+
+// MarshalJSON implements json.Marshaler for EnumNode
+func (n EnumNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n EnumNode) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "enum %s {", n.Name)
+	for i, m := range n.Members {
+		if i > 0 {
+			buff.WriteString(",")
+		}
+		fmt.Fprintf(buff, " %s", m.Name)
+		if m.Value != nil {
+			fmt.Fprintf(buff, " = %s", m.Value)
+		}
+	}
+	buff.WriteString(" }")
+	return buff.String()
+}
+
+// Declare registers the enum's type and works out the values of its members
+func (n EnumNode) Declare(prog *Program) (value.Value, error) {
+	scopeName := n.Name
+	if prog.Package.Name != "runtime" {
+		scopeName = fmt.Sprintf("%s:%s", prog.Scope.PackageName, n.Name)
+	}
+	if _, exists := prog.Enums[scopeName]; exists {
+		return nil, n.Errorf(ErrInvalid, "enum %s is declared more than once", n.Name)
+	}
+
+	n.Values = make(map[string]int64, len(n.Members))
+	next := int64(0)
+	for _, m := range n.Members {
+		if _, exists := n.Values[m.Name]; exists {
+			return nil, m.Errorf(ErrInvalid, "enum %s has two members named %s", n.Name, m.Name)
+		}
+		if m.Value != nil {
+			val, ok := FoldConstant(prog, m.Value)
+			i, isInt := val.(int64)
+			if !ok || !isInt {
+				return nil, m.Errorf(ErrConstant, "the value of %s:%s must be a constant integer", n.Name, m.Name)
+			}
+			next = i
+		}
+		if next != int64(int32(next)) {
+			return nil, m.Errorf(ErrType, "the value %d of %s:%s doesn't fit in an int", next, n.Name, m.Name)
+		}
+		n.Values[m.Name] = next
+		next++
+	}
+
+	n.Package = prog.Package
+	prog.Enums[scopeName] = &n
+
+	// the enum is an alias so int is never reported as the enum
+	prog.Scope.GetRoot().RegisterTypeAlias(scopeName, "int")
+	prog.Scope.GetRoot().SetTypeVisibility(scopeName, prog.Package.Name, visibilityOf(n.Pub))
+	return nil, nil
+}
+
+// Codegen implements Node.Codegen for EnumNode
+func (n EnumNode) Codegen(prog *Program) (value.Value, error) { return nil, nil }
+
+// memberNames returns the names of the members, in the order they were
+// declared
+func (n *EnumNode) memberNames() []string {
+	names := make([]string, len(n.Members))
+	for i, m := range n.Members {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// lookupEnum returns the enum declared with a name. The enums of the
+// current package are searched first, then the pub enums of every other
+// package.
+func (p *Program) lookupEnum(name string) *EnumNode {
+	if enum, found := p.Enums[fmt.Sprintf("%s:%s", p.Scope.PackageName, name)]; found {
+		return enum
+	}
+	if enum, found := p.Enums[name]; found && p.enumVisible(enum) {
+		return enum
+	}
+	names := make([]string, 0, len(p.Enums))
+	for scopeName := range p.Enums {
+		names = append(names, scopeName)
+	}
+	sort.Strings(names)
+	for _, scopeName := range names {
+		if _, nm := ParseName(scopeName); nm == name && p.enumVisible(p.Enums[scopeName]) {
+			return p.Enums[scopeName]
+		}
+	}
+	return nil
+}
+
+func (p *Program) enumVisible(enum *EnumNode) bool {
+	return visibleFrom(visibilityOf(enum.Pub), enum.Package.Name, p.Scope.PackageName)
+}
+
+// enumMember returns the enum a name like `Color:Red` is a member of and
+// the member's value
+func (p *Program) enumMember(name string) (*EnumNode, int64, bool) {
+	if p.Enums == nil || !strings.Contains(name, separator) {
+		return nil, 0, false
+	}
+	enumName, member := ParseName(name)
+	enum := p.lookupEnum(enumName)
+	if enum == nil {
+		return nil, 0, false
+	}
+	val, found := enum.Values[member]
+	return enum, val, found
+}
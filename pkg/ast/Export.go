@@ -0,0 +1,129 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// The attributes that control which functions a binary or shared library
+// exposes. @export functions are part of the api of a library: they are
+// compiled even if nothing in the program calls them, and the linker has
+// to keep them. They use the c calling convention and take and return
+// strings as a char*, like the c functions declared with ..., so c code
+// can call them. @export("name") also gives the function the symbol name
+// instead of its mangled name, for c code to declare it by. @hidden
+// functions are never visible outside of the binary or library they are
+// linked into. @symbol("name") links an external function to a symbol
+// with another name than its own, like the mangled names of the functions
+// a library exports.
+const (
+	exportAttribute = "export"
+	hiddenAttribute = "hidden"
+	symbolAttribute = "symbol"
+)
+
+// usedGlobalName is the llvm global that lists the symbols dead code
+// elimination has to keep
+const usedGlobalName = "llvm.used"
+
+// checkVisibilityAttributes makes sure the visibility attributes of a
+// function make sense together
+func (n FunctionNode) checkVisibilityAttributes() error {
+	export := n.Attributes.Has(exportAttribute)
+	if export && n.Attributes.Has(hiddenAttribute) {
+		return fmt.Errorf("function %s can't be both @export and @hidden", n.Name)
+	}
+	if export && n.External {
+		return fmt.Errorf("external function %s can't be exported, it is defined elsewhere", n.Name)
+	}
+	if export && (n.HasUnknownType || n.IsMethod || n.Variadic) {
+		return fmt.Errorf("function %s must have only known argument types and not be a method or variadic to be exported", n.Name)
+	}
+	if exp, found := n.Attributes.Get(exportAttribute); found && (len(exp.Args) > 1 || len(exp.Args) == 1 && !isCIdent(exp.Args[0])) {
+		return fmt.Errorf("@export on function %s can only name one symbol, which must be a c identifier", n.Name)
+	}
+	if sym, found := n.Attributes.Get(symbolAttribute); found && (!n.External || len(sym.Args) != 1) {
+		return fmt.Errorf("@symbol on function %s must name one symbol, and only external functions can have one", n.Name)
+	}
+	return nil
+}
+
+// symbolName returns the symbol an external function links to, if it is
+// given one with @symbol, or that an exported function is given with
+// @export("name")
+func (n FunctionNode) symbolName() (string, bool) {
+	if exp, found := n.Attributes.Get(exportAttribute); found && !n.External && len(exp.Args) == 1 {
+		return exp.Args[0], true
+	}
+	sym, found := n.Attributes.Get(symbolAttribute)
+	if !found || !n.External || len(sym.Args) != 1 {
+		return "", false
+	}
+	return sym.Args[0], true
+}
+
+// isCIdent returns if a name can be the name of a c function
+func isCIdent(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// applyVisibilityAttributes sets the linkage and visibility of the llvm
+// function compiled from a function node
+func (n FunctionNode) applyVisibilityAttributes(fn *ir.Function) error {
+	if err := n.checkVisibilityAttributes(); err != nil {
+		return err
+	}
+	switch {
+	case n.Attributes.Has(exportAttribute):
+		fn.Linkage = ir.LinkageExternal
+		fn.Visibility = ir.VisibilityDefault
+		fn.CallConv = ir.CallConvC
+	case n.Attributes.Has(hiddenAttribute):
+		fn.Visibility = ir.VisibilityHidden
+	}
+	return nil
+}
+
+// CompileExports compiles every @export function, whether anything calls
+// it or not, and adds them to llvm.used so neither llvm nor the linker
+// removes them as dead code.
+func (p *Program) CompileExports() error {
+	names := make([]string, 0)
+	for name, node := range p.Functions {
+		if node.Attributes.Has(exportAttribute) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	used := make([]constant.Constant, 0, len(names))
+	exported := make(map[string]string)
+	for _, name := range names {
+		fn, err := p.GetFunction(name, FunctionCompilationOptions{})
+		if err != nil {
+			return err
+		}
+		if other, found := exported[fn.Name]; found {
+			return fmt.Errorf("functions %s and %s are both exported as %s", other, name, fn.Name)
+		}
+		exported[fn.Name] = name
+		used = append(used, constant.NewBitCast(fn, types.NewPointer(types.I8)))
+	}
+
+	global := p.Module.NewGlobalDef(usedGlobalName, constant.NewArray(used...))
+	global.Linkage = ir.LinkageAppending
+	global.Section = "llvm.metadata"
+	return nil
+}
@@ -199,7 +199,7 @@ type NumberComponent struct {
 func (c *NumberComponent) ConstructNode(prev Node) (Node, error) {
 	n, err := GetNumberNodeFromString(c.Value)
 	if err != nil {
-		return nil, err
+		return nil, newError(c.token, ErrSyntax, "%s", err)
 	}
 	if n == nil {
 		return nil, fmt.Errorf("unable to get number type from number component's value")
@@ -335,13 +335,7 @@ func (c *StringComponent) Ident() string {
 
 // ConstructNode returns the ast node for the expression component
 func (c *StringComponent) ConstructNode(prev Node) (Node, error) {
-	n := StringNode{}
-	n.Token = c.token
-	n.NodeType = nodeString
-	val := c.Value[1 : len(c.Value)-1]
-	escaped, _ := UnescapeString(val)
-	n.Value = escaped
-	return n, nil
+	return newStringNode(c.token)
 }
 
 // =========================== ParenthesisComponent ===========================
@@ -389,6 +383,26 @@ func (c *BooleanComponent) ConstructNode(prev Node) (Node, error) {
 	return n, nil
 }
 
+// =========================== NilComponent ===========================
+
+// NilComponent is an expression component for nil
+type NilComponent struct {
+	componentChainNode
+}
+
+// Ident implements ExpComponent.Ident
+func (c *NilComponent) Ident() string {
+	return "nil"
+}
+
+// ConstructNode returns the ast node for the expression component
+func (c *NilComponent) ConstructNode(prev Node) (Node, error) {
+	n := NilNode{}
+	n.Token = c.token
+	n.NodeType = nodeNil
+	return n, nil
+}
+
 // =========================== CharComponent ===========================
 
 // CharComponent is an expression component for numbers
@@ -405,14 +419,7 @@ func (c *CharComponent) Ident() string {
 
 // ConstructNode returns the ast node for the expression component
 func (c *CharComponent) ConstructNode(prev Node) (Node, error) {
-
-	n := CharNode{}
-	n.Token = c.token
-	n.NodeType = nodeBool
-
-	n.Value = []rune(c.Value)[1]
-
-	return n, nil
+	return newCharNode(c.token)
 }
 
 // =========================== TypeInfoComponent ===========================
@@ -438,3 +445,53 @@ func (c *TypeInfoComponent) ConstructNode(prev Node) (Node, error) {
 	n.T = c.Type
 	return n, nil
 }
+
+// =========================== SizeofComponent ===========================
+
+// SizeofComponent is an expression component for sizeof and alignof
+type SizeofComponent struct {
+	componentChainNode
+
+	Type  TypeNode
+	Align bool
+}
+
+// Ident implements ExpComponent.Ident
+func (c *SizeofComponent) Ident() string {
+	node, _ := c.ConstructNode(nil)
+	return fmt.Sprintf("%s", node)
+}
+
+// ConstructNode returns the ast node for the expression component
+func (c *SizeofComponent) ConstructNode(prev Node) (Node, error) {
+	n := SizeofNode{}
+	n.Token = c.token
+	n.NodeType = nodeSizeof
+	n.T = c.Type
+	n.Align = c.Align
+	return n, nil
+}
+
+// =========================== NewComponent ===========================
+
+// NewComponent is an expression component for new
+type NewComponent struct {
+	componentChainNode
+
+	Type TypeNode
+}
+
+// Ident implements ExpComponent.Ident
+func (c *NewComponent) Ident() string {
+	node, _ := c.ConstructNode(nil)
+	return fmt.Sprintf("%s", node)
+}
+
+// ConstructNode returns the ast node for the expression component
+func (c *NewComponent) ConstructNode(prev Node) (Node, error) {
+	n := NewNode{}
+	n.Token = c.token
+	n.NodeType = nodeNew
+	n.T = c.Type
+	return n, nil
+}
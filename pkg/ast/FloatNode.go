@@ -14,13 +14,21 @@ type FloatNode struct {
 	TokenReference
 
 	Value float64
+	// Type is the type given by the suffix of the literal, or nil
+	Type *types.FloatType
 }
 
 // NameString implements Node.NameString
 func (n FloatNode) NameString() string { return "FloatNode" }
 
+// MarshalJSON implements json.Marshaler for FloatNode
+func (n FloatNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for FloatNode
 func (n FloatNode) Codegen(prog *Program) (value.Value, error) {
+	if n.Type != nil {
+		return roundFloat(constant.NewFloat(n.Value, n.Type)), nil
+	}
 	return constant.NewFloat(n.Value, types.Double), nil
 }
 
@@ -0,0 +1,217 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// The methods a value must have to be iterated over in a for-each loop.
+// `done` returns true once there are no values left, and `next` returns
+// the current value and advances the iterator.
+const (
+	iteratorDoneMethod = "done"
+	iteratorNextMethod = "next"
+)
+
+// runesIteratorFunc returns the runtime's rune iterator over a string.
+// A for-each loop over a string iterates over its runes.
+const runesIteratorFunc = "__runtime_runes"
+
+// ForEachNode is a for-each loop, example: `for int x in nums { ... }`.
+// The source is copied into a hidden iterator before the loop starts,
+// so the loop always gets a fresh iterator, even when the source is a
+// named variable. A pointer source is iterated in place, except for
+// strings, which are iterated over rune by rune. Slices, vecs and array
+// literals are iterated over by index instead of with an iterator.
+type ForEachNode struct {
+	NodeType
+	TokenReference
+
+	Index  int
+	Elem   VariableDefnNode
+	Source Node
+	Body   BlockNode
+}
+
+func (n ForEachNode) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "for %s in %s %s", n.Elem, n.Source, n.Body)
+	return buff.String()
+}
+
+// NameString implements Node.NameString
+func (n ForEachNode) NameString() string { return "ForEachNode" }
+
+// MarshalJSON implements json.Marshaler for ForEachNode
+func (n ForEachNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+// Codegen implements Node.Codegen for ForEachNode. The source is stored in
+// a hidden variable first, as the loop depends on its type.
+func (n ForEachNode) Codegen(prog *Program) (value.Value, error) {
+	srcName := fmt.Sprintf("__src.%d", n.Index)
+
+	src := VariableDefnNode{}
+	src.Token = n.Token
+	src.NodeType = nodeVariableDecl
+	src.Name = NewIdentNode(srcName)
+	src.NeedsInference = true
+	src.HasValue = true
+	src.Body = n.Source
+
+	prog.ScopeDown(n.Token)
+	if _, err := src.Codegen(prog); err != nil {
+		return nil, err
+	}
+
+	var source Node = NewIdentNode(srcName)
+	item, _ := prog.Scope.Find([]string{srcName})
+	srcType := item.Value().Type().(*types.PointerType).Elem
+
+	var loop ForNode
+	if arr, isArray := n.Source.(ArrayNode); isArray {
+		length := IntNode{}
+		length.Token = n.Token
+		length.NodeType = nodeInt
+		length.Value = int64(arr.Length)
+		loop = n.DesugarIndexed(srcName, length)
+	} else if types.IsSlice(srcType) || isVec(srcType) {
+		length := DotReference{}
+		length.Token = n.Token
+		length.NodeType = nodeDot
+		length.Base = NewIdentNode(srcName)
+		length.Field = NewIdentNode("len")
+		loop = n.DesugarIndexed(srcName, length)
+	} else {
+		if isString(srcType) {
+			runes := FunctionCallNode{}
+			runes.Token = n.Token
+			runes.NodeType = nodeFunctionCall
+			runes.Name = NewIdentNode(runesIteratorFunc)
+			runes.Args = []Node{source}
+			source = runes
+		}
+		loop = n.Desugar(source)
+	}
+
+	if _, err := loop.Codegen(prog); err != nil {
+		return nil, err
+	}
+	return nil, prog.ScopeUp()
+}
+
+// Desugar returns the for loop a for-each loop over source is equivalent
+// to, where source is the already evaluated source of the loop:
+//
+//	for let __iter.N = source; !__iter.N.done(); {
+//	    T x = __iter.N.next()
+//	    ...
+//	}
+func (n ForEachNode) Desugar(source Node) ForNode {
+	iterName := fmt.Sprintf("__iter.%d", n.Index)
+
+	method := func(name string) FunctionCallNode {
+		dot := DotReference{}
+		dot.Token = n.Token
+		dot.NodeType = nodeDot
+		dot.Base = NewIdentNode(iterName)
+		dot.Field = NewIdentNode(name)
+
+		call := FunctionCallNode{}
+		call.Token = n.Token
+		call.NodeType = nodeFunctionCall
+		call.Name = dot
+		return call
+	}
+
+	iter := VariableDefnNode{}
+	iter.Token = n.Token
+	iter.NodeType = nodeVariableDecl
+	iter.Name = NewIdentNode(iterName)
+	iter.NeedsInference = true
+	iter.HasValue = true
+	iter.Body = source
+
+	cond := UnaryNode{}
+	cond.Token = n.Token
+	cond.NodeType = nodeUnary
+	cond.Operator = "!"
+	cond.Operand = method(iteratorDoneMethod)
+
+	elem := n.Elem
+	elem.HasValue = true
+	elem.Body = method(iteratorNextMethod)
+
+	body := n.Body
+	body.Nodes = append([]Node{elem}, n.Body.Nodes...)
+
+	loop := ForNode{}
+	loop.Token = n.Token
+	loop.NodeType = nodeFor
+	loop.Index = n.Index
+	loop.Init = iter
+	loop.Cond = cond
+	loop.Body = body
+	return loop
+}
+
+// DesugarIndexed returns the for loop a for-each loop over the slice, vec
+// or array stored in the variable src is equivalent to:
+//
+//	for let __idx.N = 0; __idx.N < length; __idx.N++ {
+//	    T x = src[__idx.N]
+//	    ...
+//	}
+func (n ForEachNode) DesugarIndexed(src string, length Node) ForNode {
+	idxName := fmt.Sprintf("__idx.%d", n.Index)
+
+	zero := IntNode{}
+	zero.Token = n.Token
+	zero.NodeType = nodeInt
+
+	idx := VariableDefnNode{}
+	idx.Token = n.Token
+	idx.NodeType = nodeVariableDecl
+	idx.Name = NewIdentNode(idxName)
+	idx.NeedsInference = true
+	idx.HasValue = true
+	idx.Body = zero
+
+	cond := BinaryNode{}
+	cond.Token = n.Token
+	cond.NodeType = nodeBinary
+	cond.OP = "<"
+	cond.Left = NewIdentNode(idxName)
+	cond.Right = length
+
+	step := IncDecNode{}
+	step.Token = n.Token
+	step.NodeType = nodeIncDec
+	step.Operand = NewIdentNode(idxName)
+	step.Op = "++"
+
+	at := SubscriptNode{}
+	at.Token = n.Token
+	at.NodeType = nodeSubscript
+	at.Source = NewIdentNode(src)
+	at.Index = NewIdentNode(idxName)
+
+	elem := n.Elem
+	elem.HasValue = true
+	elem.Body = at
+
+	body := n.Body
+	body.Nodes = append([]Node{elem}, n.Body.Nodes...)
+
+	loop := ForNode{}
+	loop.Token = n.Token
+	loop.NodeType = nodeFor
+	loop.Index = n.Index
+	loop.Init = idx
+	loop.Cond = cond
+	loop.Step = step
+	loop.Body = body
+	return loop
+}
@@ -32,6 +32,9 @@ func (n ForNode) String() string {
 // NameString implements Node.NameString
 func (n ForNode) NameString() string { return "ForNode" }
 
+// MarshalJSON implements json.Marshaler for ForNode
+func (n ForNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for ForNode
 func (n ForNode) Codegen(prog *Program) (value.Value, error) {
 
@@ -43,6 +46,8 @@ func (n ForNode) Codegen(prog *Program) (value.Value, error) {
 	var err error
 	var predicate value.Value
 	var condBlk *ir.BasicBlock
+	var condEnd *ir.BasicBlock
+	var stepEnd *ir.BasicBlock
 	var bodyBlk *ir.BasicBlock
 	var bodyGenBlk *ir.BasicBlock
 	var endBlk *ir.BasicBlock
@@ -50,19 +55,25 @@ func (n ForNode) Codegen(prog *Program) (value.Value, error) {
 
 	condBlk = parentFunc.NewBlock(namePrefix + "cond")
 
-	n.Init.Codegen(prog)
+	if _, err := n.Init.Codegen(prog); err != nil {
+		return nil, err
+	}
 
-	parentBlock.NewBr(condBlk)
+	prog.Compiler.CurrentBlock().NewBr(condBlk)
 
 	err = prog.Compiler.genInBlock(condBlk, func() error {
-		predicate, _ = n.Cond.Codegen(prog)
+		predicate, err = n.Cond.Codegen(prog)
+		if err != nil {
+			return err
+		}
 		one := constant.NewInt(1, types.I1)
 
 		c, err := createTypeCast(prog, predicate, types.I1)
 		if err != nil {
 			return err
 		}
-		predicate = condBlk.NewICmp(ir.IntEQ, one, c)
+		condEnd = prog.Compiler.CurrentBlock()
+		predicate = condEnd.NewICmp(ir.IntEQ, one, c)
 		return nil
 	})
 
@@ -94,20 +105,25 @@ func (n ForNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	err = prog.Compiler.genInBlock(stepBlk, func() error {
-		scp := prog.Scope
-		_, err := n.Step.Codegen(prog)
-		prog.Scope = scp
-		return err
+		if n.Step != nil {
+			scp := prog.Scope
+			if _, err := n.Step.Codegen(prog); err != nil {
+				return err
+			}
+			prog.Scope = scp
+		}
+		stepEnd = prog.Compiler.CurrentBlock()
+		return nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	stepBlk.BranchIfNoTerminator(condBlk)
+	stepEnd.BranchIfNoTerminator(condBlk)
 	endBlk = parentFunc.NewBlock(namePrefix + "end")
 	prog.Compiler.PushBlock(endBlk)
-	condBlk.NewCondBr(predicate, bodyBlk, endBlk)
+	condEnd.NewCondBr(predicate, bodyBlk, endBlk)
 
 	if err := prog.ScopeUp(); err != nil {
 		return nil, err
@@ -0,0 +1,279 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// The formatting builtins. `println(fmt, ...)` prints a formatted line and
+// `format(fmt, ...)` returns a formatted string. The format string must be
+// a literal so the compiler can check every verb against the type of its
+// argument and convert the argument to what the runtime expects, instead
+// of passing it through c varargs as is.
+const (
+	builtinPrintln = "println"
+	builtinFormat  = "format"
+)
+
+// formatBuiltin returns the name of the formatting builtin a call is to, if
+// it is one. A function declared with the same name always takes priority.
+func formatBuiltin(prog *Program, n FunctionCallNode) (string, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return "", false
+	}
+	name := ident.String()
+	if name != builtinPrintln && name != builtinFormat {
+		return "", false
+	}
+	return name, prog.LookupFunctionNode(name) == nil
+}
+
+// genFormatBuiltin generates a call to one of the formatting builtins
+func genFormatBuiltin(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	if len(n.Args) == 0 {
+		return nil, n.Errorf(ErrInvalid, "%s requires a format string", name)
+	}
+	format, ok := n.Args[0].(StringNode)
+	formatArgs := n.Args[1:]
+
+	// an interpolated string is its own format string
+	if interp, isInterp := n.Args[0].(StringInterpolationNode); isInterp && len(formatArgs) == 0 {
+		format.Token = interp.Token
+		format.Value, formatArgs = interp.formatSpec()
+		ok = true
+	}
+	if !ok {
+		return nil, n.Args[0].Errorf(ErrConstant, "the format string passed to %s must be a string literal", name)
+	}
+
+	spec, args, err := genFormatArgs(prog, format.Value, formatArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeFunc := "__runtime_str_format"
+	if name == builtinPrintln {
+		runtimeFunc = "__runtime_print_format"
+		spec += "\n"
+	}
+
+	formatNode := StringNode{}
+	formatNode.Token = format.Token
+	formatNode.NodeType = nodeString
+	formatNode.Value = spec
+	str, err := formatNode.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	call, err := prog.NewRuntimeFunctionCall(runtimeFunc, append([]value.Value{str}, args...)...)
+	if err != nil || name == builtinPrintln {
+		return call, err
+	}
+	return prog.stringFromC(call)
+}
+
+// genFormatArgs checks a format string against its arguments. It returns
+// the equivalent c format string and the arguments converted to the types
+// the c format string expects.
+//
+// Supported verbs:
+//
+//	%d %i        signed integers
+//	%u %x %X %o  integers, printed as unsigned
+//	%c           characters
+//	%f %e %g     floats (and their uppercase forms)
+//	%s           strings
+//	%p           pointers
+//	%t           bools, printed as true or false
+//	%v           any of the above, using the default verb for its type
+//	%%           a literal percent sign
+//
+// Verbs can have the flags, width and precision that printf allows.
+func genFormatArgs(prog *Program, format string, nodes []Node) (string, []value.Value, error) {
+	spec := &bytes.Buffer{}
+	args := make([]value.Value, 0, len(nodes))
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			spec.WriteByte(c)
+			continue
+		}
+
+		// read the flags, width and precision up to the verb
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0123456789.", format[i]) >= 0 {
+			i++
+		}
+		if i >= len(format) {
+			return "", nil, fmt.Errorf("format string %q ends in an incomplete verb", format)
+		}
+		modifiers := format[start+1 : i]
+		verb := format[i]
+
+		if verb == '%' {
+			spec.WriteString("%%")
+			continue
+		}
+
+		if len(args) >= len(nodes) {
+			return "", nil, fmt.Errorf("format string %q has more verbs than the %d arguments it is given", format, len(nodes))
+		}
+		node := nodes[len(args)]
+
+		ac, isAccessable := node.(Accessable)
+		if !isAccessable {
+			return "", nil, node.Errorf(ErrInvalid, "argument %s is not accessable (has no readable value)", node)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return "", nil, err
+		}
+
+		cverb, arg, err := genFormatVerb(prog, verb, val)
+		if err != nil {
+			return "", nil, node.Errorf(ErrInvalid, "%%%c in format string %q: %s", verb, format, err)
+		}
+
+		fmt.Fprintf(spec, "%%%s%s", modifiers, cverb)
+		args = append(args, arg)
+	}
+
+	if len(args) != len(nodes) {
+		return "", nil, nodes[len(args)].Errorf(ErrInvalid, "format string %q has %d verbs but is given %d arguments", format, len(args), len(nodes))
+	}
+
+	return spec.String(), args, nil
+}
+
+// genFormatVerb checks that a value can be printed with a verb, returning
+// the c conversion for the verb and the value converted to match it
+func genFormatVerb(prog *Program, verb byte, val value.Value) (string, value.Value, error) {
+	t := val.Type()
+
+	if verb == 'v' {
+		switch {
+		case types.Equal(t, types.I1):
+			verb = 't'
+		case types.IsInt(t):
+			verb = 'd'
+		case types.IsFloat(t):
+			verb = 'g'
+		case isString(t), isBytePtr(t):
+			verb = 's'
+		case types.IsPointer(t):
+			verb = 'p'
+		default:
+			return "", nil, fmt.Errorf("unable to format a value of type %s", t)
+		}
+	}
+
+	switch verb {
+	case 'd', 'i':
+		if !types.IsInt(t) {
+			return "", nil, fmt.Errorf("expects an integer, given %s", t)
+		}
+		if t.(*types.IntType).Size > 64 {
+			return "", nil, fmt.Errorf("can't format integers of more than 64 bits, given %s", t)
+		}
+		arg, err := formatWidenInt(prog, val, types.I64, !types.Equal(t, types.I1))
+		// long long, as long is only 32 bits on some targets, like wasm
+		return "ll" + string(verb), arg, err
+
+	case 'u', 'x', 'X', 'o':
+		if !types.IsInt(t) {
+			return "", nil, fmt.Errorf("expects an integer, given %s", t)
+		}
+		if t.(*types.IntType).Size > 64 {
+			return "", nil, fmt.Errorf("can't format integers of more than 64 bits, given %s", t)
+		}
+		arg, err := formatWidenInt(prog, val, types.I64, false)
+		return "ll" + string(verb), arg, err
+
+	case 'c':
+		if !types.IsInt(t) {
+			return "", nil, fmt.Errorf("expects a character, given %s", t)
+		}
+		arg, err := formatWidenInt(prog, val, types.I32, false)
+		return "c", arg, err
+
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if !types.IsFloat(t) {
+			return "", nil, fmt.Errorf("expects a float, given %s", t)
+		}
+		arg, err := createTypeCast(prog, formatCopy(val), types.Double)
+		return string(verb), arg, err
+
+	case 's':
+		// a byte* is printed as the c string it points to
+		if isString(t) {
+			return "s", prog.stringData(val), nil
+		}
+		if !isBytePtr(t) {
+			return "", nil, fmt.Errorf("expects a string, given %s", t)
+		}
+		return "s", val, nil
+
+	case 'p':
+		if !types.IsPointer(t) {
+			return "", nil, fmt.Errorf("expects a pointer, given %s", t)
+		}
+		arg, err := createTypeCast(prog, val, types.NewPointer(types.I8))
+		return "p", arg, err
+
+	case 't':
+		if !types.Equal(t, types.I1) {
+			return "", nil, fmt.Errorf("expects a bool, given %s", t)
+		}
+		yes := formatStringConstant(prog, "true")
+		no := formatStringConstant(prog, "false")
+		return "s", prog.Compiler.CurrentBlock().NewSelect(val, yes, no), nil
+	}
+
+	return "", nil, fmt.Errorf("unknown verb")
+}
+
+// formatWidenInt converts an integer to the type t, sign or zero extending
+// it. Integers narrower than t are always widened as the runtime reads a
+// full t from the varargs.
+func formatWidenInt(prog *Program, val value.Value, t *types.IntType, signed bool) (value.Value, error) {
+	in := val.Type().(*types.IntType)
+	if in.Size < t.Size && !signed {
+		if c, isConst := val.(*constant.Int); isConst {
+			return constant.NewZExt(c, t), nil
+		}
+		return prog.Compiler.CurrentBlock().NewZExt(val, t), nil
+	}
+	return createTypeCast(prog, formatCopy(val), t)
+}
+
+// formatCopy copies constants, as createTypeCast retypes them in place
+func formatCopy(val value.Value) value.Value {
+	if c, isConst := val.(constant.Constant); isConst {
+		return copyConstant(c)
+	}
+	return val
+}
+
+// formatStringConstant returns a pointer to a constant string that is never
+// copied, to be selected between by a bool verb.
+func formatStringConstant(prog *Program, s string) value.Value {
+	str, found := prog.StringDefs[s]
+	if !found {
+		str = prog.Compiler.Module.NewGlobalDef(fmt.Sprintf(".str.%X", prog.strIndex), newCharArray(s))
+		prog.strIndex++
+		str.IsConst = true
+		str.Immutable()
+		prog.StringDefs[s] = str
+	}
+	zero := constant.NewInt(0, types.I32)
+	return constant.NewGetElementPtr(str, zero, zero)
+}
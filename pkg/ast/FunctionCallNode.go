@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
@@ -25,6 +26,9 @@ type FunctionCallNode struct {
 // NameString implements Node.NameString
 func (n FunctionCallNode) NameString() string { return "FunctionCallNode" }
 
+// MarshalJSON implements json.Marshaler for FunctionCallNode
+func (n FunctionCallNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 func (n FunctionCallNode) String() string {
 	buff := &bytes.Buffer{}
 
@@ -51,31 +55,153 @@ func (n FunctionCallNode) Codegen(prog *Program) (value.Value, error) {
 	// var name string
 	var err error
 
+	// Calls to @comptime functions are evaluated by the compiler and
+	// replaced with their result
+	if val, err := foldComptimeCall(prog, n); err != nil || val != nil {
+		if err != nil {
+			return nil, n.Diagnose(err)
+		}
+		if str, isString := val.(string); isString {
+			return StringNode{Value: str}.Codegen(prog)
+		}
+		fn := prog.LookupFunctionNode(n.Name.(IdentNode).String())
+		retType, err := prog.FindType(fn.ReturnType.Name)
+		if err != nil {
+			return nil, err
+		}
+		return NewFoldedConstant(val, retType)
+	}
+
+	if name, isBuiltin := formatBuiltin(prog, n); isBuiltin {
+		return genFormatBuiltin(prog, name, n)
+	}
+
+	if name, isIntrinsic := intrinsicBuiltin(prog, n); isIntrinsic {
+		return genIntrinsic(prog, name, n)
+	}
+
+	if name, isSimd := simdBuiltin(prog, n); isSimd {
+		return genSimd(prog, name, n)
+	}
+
+	if name, isAtomic := atomicBuiltin(prog, n); isAtomic {
+		return genAtomic(prog, name, n)
+	}
+
+	if name, isVolatile := volatileBuiltin(prog, n); isVolatile {
+		return genVolatile(prog, name, n)
+	}
+
+	if cpuSupportsBuiltin(prog, n) {
+		return genCPUSupports(prog, n)
+	}
+
+	if asmBuiltin(prog, n) {
+		return genAsm(prog, n)
+	}
+
+	if panicBuiltin(prog, n) {
+		return genPanic(prog, n)
+	}
+
+	if union, tag, isVariant := unionConstructor(prog, n); isVariant {
+		return prog.genUnionValue(union, tag, n.Args, n)
+	}
+
+	// methods of interface values are called through their vtable
+	if dot, isDot := n.Name.(DotReference); isDot && !dot.isStatic(prog) {
+		// the object the method is called on is generated once
+		dot, err := dot.evaluated(prog)
+		if err != nil {
+			return nil, err
+		}
+		n.Name = dot
+		if iface := prog.interfaceOf(dot.BaseType(prog)); iface != nil {
+			return genInterfaceCall(prog, iface, dot, n)
+		}
+		// and so are methods of classes that extend or are extended
+		if class := prog.virtualClassOf(dot.BaseType(prog)); class != nil && class.slot(dot.Field.String()) >= 0 {
+			return genVirtualCall(prog, class, dot, n)
+		}
+	}
+
+	callee, arguments, err := n.genCallArgs(prog)
+	if err != nil {
+		return nil, err
+	}
+	call, err := prog.genCall(callee, arguments...)
+	if err != nil {
+		return nil, err
+	}
+	return prog.cResult(callee, call)
+}
+
+// genCallArgs finds the function a call is to and generates the arguments
+// it is called with, converted to the types of its parameters
+func (n FunctionCallNode) genCallArgs(prog *Program) (*ir.Function, []value.Value, error) {
 	args := []value.Value{}
 	argTypes := []types.Type{}
 
-	for _, arg := range n.Args {
+	// Geode variadic functions take their trailing arguments packed into a slice
+	fn := n.calleeNode(prog)
+	variadicIndex := -1
+	if fn != nil {
+		variadicIndex = fn.VariadicArgIndex()
+	}
+
+	for i, arg := range n.Args {
+
+		if spread, isSpread := arg.(SpreadNode); isSpread {
+			if i != len(n.Args)-1 {
+				return nil, nil, spread.Errorf(ErrInvalid, "spread argument %s must be the last argument of a call", spread)
+			}
+			spreadArgs, err := genSpreadArgs(prog, fn, i, spread)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, val := range spreadArgs {
+				args = append(args, val)
+				argTypes = append(argTypes, val.Type())
+			}
+			break
+		}
+
+		if i == variadicIndex {
+			break
+		}
 
 		if ac, isAccessable := arg.(Accessable); isAccessable {
 			val, err := ac.GenAccess(prog)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			args = append(args, val)
 			argTypes = append(argTypes, val.Type())
 			if args[len(args)-1] == nil {
-				return nil, fmt.Errorf("argument to function %q failed to generate code", n.Name)
+				return nil, nil, fmt.Errorf("argument to function %q failed to generate code", n.Name)
 			}
 		} else {
-			arg.SyntaxError()
-			return nil, fmt.Errorf("argument to function call to '%s' is not accessable (has no readable value). Node type %s", n.Name, arg.Kind())
+			return nil, nil, arg.Errorf(ErrInvalid, "argument to function call to '%s' is not accessable (has no readable value). Node type %s", n.Name, arg.Kind())
+		}
+	}
+
+	if variadicIndex >= 0 && len(n.Args) >= variadicIndex && len(args) == variadicIndex {
+		variadicType, err := fn.Args[variadicIndex].Type.GetType(prog)
+		if err != nil {
+			return nil, nil, err
 		}
+		slice, err := genVariadicSlice(prog, variadicType.(*types.SliceType), n.Args[variadicIndex:])
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, slice)
+		argTypes = append(argTypes, slice.Type())
 	}
 
 	callee, prependingArgs, err := n.Name.GetFunc(prog, argTypes)
 	if err != nil {
-		return nil, err
+		return nil, nil, n.Diagnose(err)
 	}
 	if prependingArgs != nil {
 		args = append(prependingArgs, args...)
@@ -88,13 +214,17 @@ func (n FunctionCallNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	if callee == nil {
-		return nil, fmt.Errorf("unknown function %q referenced at %s", n.Name, n.Token.FileInfo())
+		return nil, nil, n.Errorf(ErrUndefined, "function %s is not defined", n.Name)
 	}
 
+	// strings are passed to c functions as their bytes
+	args = prog.cArgs(callee, args)
+
 	// Attempt to typecast all the args into the correct type
 	for i, exp := range callee.Sig.Params {
 		t := exp.Type()
 
+		prog.warnNarrowing(n.Token, args[i], t)
 		args[i], _ = createTypeCast(prog, args[i], t)
 	}
 
@@ -111,7 +241,7 @@ func (n FunctionCallNode) Codegen(prog *Program) (value.Value, error) {
 				if !types.Equal(arg.Type(), types.I32) {
 					c, err := createTypeCast(prog, arg, types.I32)
 					if err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 					arguments = append(arguments, c)
 					continue
@@ -123,7 +253,7 @@ func (n FunctionCallNode) Codegen(prog *Program) (value.Value, error) {
 				if !types.Equal(arg.Type(), types.Double) {
 					c, err := createTypeCast(prog, arg, types.Double)
 					if err != nil {
-						return nil, err
+						return nil, nil, err
 					}
 					arguments = append(arguments, c)
 					continue
@@ -133,14 +263,218 @@ func (n FunctionCallNode) Codegen(prog *Program) (value.Value, error) {
 		arguments = append(arguments, arg)
 	}
 
-	return prog.Compiler.CurrentBlock().NewCall(callee, arguments...), nil
+	return callee, arguments, nil
+}
+
+// calleeNode returns the declaration of the function being called if
+// it is called by name
+func (n FunctionCallNode) calleeNode(prog *Program) *FunctionNode {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return nil
+	}
+	return prog.LookupFunctionNode(ident.String())
+}
+
+// genSpreadArgs expands a slice or an array into the arguments of fn
+// starting at the argument index start. Each element is checked against
+// the type of the argument it is passed as. If fn is variadic, the values
+// left over after the fixed arguments are forwarded as its variadic slice.
+func genSpreadArgs(prog *Program, fn *FunctionNode, start int, spread SpreadNode) ([]value.Value, error) {
+	if fn == nil || fn.Variadic {
+		return nil, spread.Errorf(ErrInvalid, "arguments can only be spread into a call to a geode function")
+	}
+
+	ac, isAccessable := spread.Value.(Accessable)
+	if !isAccessable {
+		return nil, spread.Errorf(ErrInvalid, "spread argument %s is not accessable (has no readable value)", spread.Value)
+	}
+
+	// the number of values an array literal holds is known while compiling
+	staticLength := -1
+	if arr, isArray := spread.Value.(ArrayNode); isArray {
+		staticLength = arr.Length
+
+		// Array literals take their element type from the argument they fill
+		var elem types.Type
+		if start < len(fn.Args) && !fn.isGenericType(fn.Args[start].Type) {
+			t, err := fn.Args[start].Type.GetType(prog)
+			if err != nil {
+				return nil, err
+			}
+			if slice, isSlice := t.(*types.SliceType); isSlice {
+				t = slice.Elem
+			}
+			elem = types.NewPointer(t)
+		}
+		prog.Compiler.PushType(elem)
+	}
+
+	src, err := ac.GenAccess(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	block := prog.Compiler.CurrentBlock()
+
+	var data value.Value
+	var length value.Value
+	var elemType types.Type
+
+	switch t := src.Type().(type) {
+	case *types.SliceType:
+		data = block.NewExtractValue(src, []int64{0})
+		length = block.NewExtractValue(src, []int64{1})
+		elemType = t.Elem
+	case *types.PointerType:
+		// a pointer doesn't know how many values it points to, only an
+		// array literal does
+		if staticLength < 0 {
+			return nil, spread.Errorf(ErrType, "unable to spread %s of type %s, only slices and array literals can be spread", spread.Value, prog.typeName(t))
+		}
+		data = src
+		elemType = t.Elem
+	default:
+		return nil, spread.Errorf(ErrType, "unable to spread %s of type %s, only slices and arrays can be spread", spread.Value, src.Type())
+	}
+
+	variadicIndex := fn.VariadicArgIndex()
+	end := len(fn.Args)
+	if variadicIndex >= 0 {
+		end = variadicIndex
+	}
+	needed := end - start
+	if needed < 0 {
+		return nil, spread.Errorf(ErrInvalid, "too many arguments passed to function %q before spread argument %s", fn.Name, spread)
+	}
+
+	if staticLength >= 0 && (staticLength < needed || (variadicIndex < 0 && staticLength != needed)) {
+		return nil, spread.Errorf(ErrInvalid, "unable to spread %d values into %d arguments of function %q", staticLength, needed, fn.Name)
+	}
+
+	if length != nil && (needed > 0 || variadicIndex < 0) && !prog.NoRuntime {
+		exact := int64(0)
+		if variadicIndex < 0 {
+			exact = 1
+		}
+		neededVal := constant.NewInt(int64(needed), types.I64)
+		_, err := prog.NewRuntimeFunctionCall("__check_spread", neededVal, length, constant.NewInt(exact, types.I32))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]value.Value, 0, needed+1)
+	for i := 0; i < needed; i++ {
+		param := fn.Args[start+i]
+		if !fn.isGenericType(param.Type) {
+			expected, err := param.Type.GetType(prog)
+			if err != nil {
+				return nil, err
+			}
+			if !types.Equal(expected, elemType) && !typesAreLooselyEqual(expected, elemType) {
+				return nil, spread.Errorf(ErrType, "unable to spread %s into function %q: element %d has type %s, argument %s expects %s", spread.Value, fn.Name, i, elemType, param.Name, expected)
+			}
+		}
+		offset := block.NewGetElementPtr(data, constant.NewInt(int64(i), types.I64))
+		values = append(values, block.NewLoad(offset))
+	}
+
+	// Forward the rest of the values as the variadic argument
+	if variadicIndex >= 0 {
+		variadicType, err := fn.Args[variadicIndex].Type.GetType(prog)
+		if err != nil {
+			return nil, err
+		}
+		sliceType := variadicType.(*types.SliceType)
+		if !types.Equal(sliceType.Elem, elemType) {
+			return nil, spread.Errorf(ErrType, "unable to spread %s of %s into variadic argument %s of type %s", spread.Value, elemType, fn.Args[variadicIndex].Name, sliceType)
+		}
+
+		var restLength value.Value
+		if length != nil {
+			restLength = block.NewSub(length, constant.NewInt(int64(needed), types.I64))
+		} else if staticLength >= 0 {
+			restLength = constant.NewInt(int64(staticLength-needed), types.I64)
+		} else {
+			return nil, spread.Errorf(ErrInvalid, "unable to spread %s into variadic argument %s, the number of values it holds is unknown", spread.Value, fn.Args[variadicIndex].Name)
+		}
+		rest := block.NewGetElementPtr(data, constant.NewInt(int64(needed), types.I64))
+
+		zero := constant.NewInt(0, types.I32)
+		slice := block.NewAlloca(sliceType)
+		block.NewStore(rest, block.NewGetElementPtr(slice, zero, constant.NewInt(0, types.I32)))
+		block.NewStore(restLength, block.NewGetElementPtr(slice, zero, constant.NewInt(1, types.I32)))
+		values = append(values, block.NewLoad(slice))
+	}
+
+	return values, nil
+}
+
+// genVariadicSlice packs the trailing arguments of a call into a slice
+// of the given type
+func genVariadicSlice(prog *Program, t *types.SliceType, nodes []Node) (value.Value, error) {
+	block := prog.Compiler.CurrentBlock()
+
+	values := make([]value.Value, 0, len(nodes))
+	for _, node := range nodes {
+		ac, isAccessable := node.(Accessable)
+		if _, isSpread := node.(SpreadNode); isSpread || !isAccessable {
+			return nil, node.Errorf(ErrInvalid, "invalid variadic argument %s", node)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		val, err = createTypeCast(prog, val, t.Elem)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+	}
+
+	elemPtr := types.NewPointer(t.Elem)
+	var data value.Value = constant.NewNull(elemPtr)
+
+	if len(values) > 0 {
+		if prog.NoRuntime {
+			buf := block.NewAlloca(types.NewArray(t.Elem, int64(len(values))))
+			zero := constant.NewInt(0, types.I64)
+			data = block.NewGetElementPtr(buf, zero, zero)
+		} else {
+			elemSize, err := prog.sizeOf(t.Elem)
+			if err != nil {
+				return nil, err
+			}
+			size := constant.NewInt(int64(len(values))*elemSize, types.I32)
+			buf, err := prog.genAlloc(t.Elem, size)
+			if err != nil {
+				return nil, err
+			}
+			data = block.NewBitCast(buf, elemPtr)
+		}
+		for i, val := range values {
+			offset := block.NewGetElementPtr(data, constant.NewInt(int64(i), types.I64))
+			block.NewStore(val, offset)
+		}
+	}
+
+	return newSliceValue(block, t, data, constant.NewInt(int64(len(values)), types.I64)), nil
+}
+
+// newSliceValue builds a slice value out of a pointer to its data and its length
+func newSliceValue(block *ir.BasicBlock, t *types.SliceType, data, length value.Value) value.Value {
+	zero := constant.NewInt(0, types.I32)
+	slice := block.NewAlloca(t)
+	block.NewStore(data, block.NewGetElementPtr(slice, zero, constant.NewInt(0, types.I32)))
+	block.NewStore(length, block.NewGetElementPtr(slice, zero, constant.NewInt(1, types.I32)))
+	return block.NewLoad(slice)
 }
 
 // Alloca implements Reference.Alloca
 func (n FunctionCallNode) Alloca(prog *Program) value.Value {
 	val, err := n.Codegen(prog)
 	if err != nil {
-		fmt.Println(err)
 		return nil
 	}
 
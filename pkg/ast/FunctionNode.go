@@ -5,9 +5,9 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
-	"github.com/geode-lang/geode/pkg/arg"
 )
 
 // FuncDeclKeywordType lets the compiler keep track of
@@ -24,6 +24,20 @@ const (
 type FunctionArg struct {
 	Type TypeNode
 	Name string
+
+	// Variadic is set on the last argument of a geode variadic function,
+	// ex: `int nums...`. Its type is a slice of the declared element type
+	// and calls pack any trailing arguments into it
+	Variadic bool
+}
+
+func (a FunctionArg) String() string {
+	if a.Variadic {
+		elem := a.Type
+		elem.Modifiers = elem.Modifiers[:len(elem.Modifiers)-1]
+		return fmt.Sprintf("%s %s...", elem, a.Name)
+	}
+	return fmt.Sprintf("%s %s", a.Type, a.Name)
 }
 
 // FunctionNode is the representation of some function. It has methods
@@ -45,8 +59,14 @@ type FunctionNode struct {
 	DeclKeyword    FuncDeclKeywordType
 	ImplicitReturn bool
 	HasUnknownType bool
-	Package        *Package
-	IsMethod       bool
+	TypeParams     []string // the names given in `<...>` after the name
+	// TypeBindings are the types bound to the type parameters of the
+	// generic class instance a method belongs to
+	TypeBindings map[string]types.Type
+	Package      *Package
+	IsMethod     bool
+	Attributes   Attributes
+	Pub          bool // exported from its package with pub
 
 	// A cache so we can remember the name of the function to codegen
 	// This is because between the Program.GetFunction, where we
@@ -67,19 +87,63 @@ type FunctionNode struct {
 // NameString implements Node.NameString
 func (n FunctionNode) NameString() string { return "FunctionNode" }
 
+// MarshalJSON implements json.Marshaler for FunctionNode
+func (n FunctionNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+// Signature returns the declaration of the function without its body,
+// like `func add(int a, int b) int`
+func (n FunctionNode) Signature() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "func %s(", n.Name)
+	for i, arg := range n.Args {
+		fmt.Fprintf(buff, "%s", arg)
+		if i < len(n.Args)-1 || n.Variadic {
+			fmt.Fprintf(buff, ", ")
+		}
+	}
+	if n.Variadic {
+		fmt.Fprintf(buff, "...")
+	}
+	fmt.Fprintf(buff, ") %s", n.ReturnType)
+	return buff.String()
+}
+
+// ParseBody returns the body of the function. Bodies are parsed from their
+// BodyParser when the function is compiled, so this parses it if the
+// function has one.
+func (n FunctionNode) ParseBody() BlockNode {
+	if n.BodyParser == nil {
+		return n.Body
+	}
+	// Parsing consumes the parser, so build the body from a fresh fork
+	parser := n.BodyParser.Fork()
+	parser.reset()
+	return parser.parseBlockStmt()
+}
+
+// VariadicArgIndex returns the index of the argument that trailing
+// arguments are packed into, or -1 if the function is not a geode
+// variadic function
+func (n FunctionNode) VariadicArgIndex() int {
+	if len(n.Args) > 0 && n.Args[len(n.Args)-1].Variadic {
+		return len(n.Args) - 1
+	}
+	return -1
+}
+
 // Arguments returns some FunctionNode's arguments
 func (n FunctionNode) Arguments(prog *Program) ([]*types.Param, []types.Type, error) {
 	funcArgs := make([]*types.Param, 0)
 	argTypes := make([]types.Type, 0)
 	for _, arg := range n.Args {
-		found, _ := prog.FindType(arg.Type.Name)
+		found, err := prog.FindType(arg.Type.Name)
 		if found == nil {
 			if n.HasUnknownType {
 				funcArgs = append(funcArgs, nil)
 				argTypes = append(argTypes, nil)
 				continue
 			} else {
-				return nil, nil, fmt.Errorf("unable to find type with name %q for function %s (%s)", arg.Type.Name, n.Name, n.Token.FileInfo())
+				return nil, nil, fmt.Errorf("unable to find type with name %q for function %s (%s): %s", arg.Type.Name, n.Name, n.Token.FileInfo(), err)
 			}
 		}
 		ty, err := arg.Type.GetType(prog)
@@ -120,7 +184,36 @@ func (n FunctionNode) Declare(prog *Program) (*ir.Function, error) {
 		return nil, err
 	}
 
-	function := prog.Compiler.Module.NewFunction(namestring, ty, funcArgs...)
+	// c functions, and the exported functions c calls, take and return
+	// strings as a char*
+	geodeRet := ty
+	cABI := n.External || n.Attributes.Has(exportAttribute)
+	if cABI {
+		for _, param := range funcArgs {
+			param.Typ = cType(param.Typ)
+		}
+		ty = cType(ty)
+	}
+
+	var function *ir.Function
+	if n.External {
+		function, err = prog.declaredCFunction(namestring, ty, geodeRet, funcArgs, n.Variadic)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if function == nil {
+		function = prog.Compiler.Module.NewFunction(namestring, ty, funcArgs...)
+		if cABI {
+			prog.declareCFunction(function, geodeRet)
+		}
+	}
+	if err := n.applyVisibilityAttributes(function); err != nil {
+		return nil, err
+	}
+	if err := n.applyTargetAttributes(function); err != nil {
+		return nil, err
+	}
 
 	prog.Compiler.PushFunc(function)
 	defer prog.Compiler.PopFunc()
@@ -184,8 +277,7 @@ func (n FunctionNode) Codegen(prog *Program) (value.Value, error) {
 
 	checkerr := n.Check(prog)
 	if checkerr != nil {
-		n.SyntaxError()
-		return nil, fmt.Errorf("check error: %s", checkerr.Error())
+		return nil, n.Errorf(ErrInvalid, "check error: %s", checkerr.Error())
 	}
 
 	namestring := n.Name.String()
@@ -210,23 +302,47 @@ func (n FunctionNode) Codegen(prog *Program) (value.Value, error) {
 		// Construct the prelude of this function
 		// The prelude contains information about
 		// initializing the runtime.
-		createInitializationPrelude(prog, n)
+		enclosing := prog.debugFunction(n, function)
+		defer prog.debugFunctionEnd(n, function, enclosing)
+
+		if err := createInitializationPrelude(prog, n); err != nil {
+			return nil, err
+		}
 		if len(function.Params()) > 0 {
 			// prog.Compiler.CurrentBlock().AppendInst(NewLLVMComment(n.Name.String() + " arguments:"))
 		}
-		for _, arg := range function.Params() {
-			alloc := prog.Compiler.CurrentBlock().NewAlloca(arg.Type())
-			prog.Compiler.CurrentBlock().NewStore(arg, alloc)
+		_, argTypes, err := n.Arguments(prog)
+		if err != nil {
+			return nil, err
+		}
+		for i, arg := range function.Params() {
+			// the strings c passes to exported functions are its char*
+			var val value.Value = arg
+			if prog.isCFunction(function) && isString(argTypes[i]) {
+				if val, err = prog.stringFromC(arg); err != nil {
+					return nil, err
+				}
+			}
+			alloc := prog.Compiler.CurrentBlock().NewAlloca(val.Type())
+			prog.Compiler.CurrentBlock().NewStore(val, alloc)
+			if err := prog.arcParam(alloc, val); err != nil {
+				return nil, err
+			}
 			// Set the scope item
 			scItem := NewVariableScopeItem(arg.Name, alloc, PrivateVisibility)
 			prog.Scope.Add(scItem)
+			prog.debugDeclare(arg.Name, i+1, n.Token, alloc)
 		}
 		// Gen the body of the function
+		// The body is parsed again for every variant of a generic function
 		if n.BodyParser != nil {
+			n.BodyParser.reset()
 			n.Body = n.BodyParser.parseBlockStmt()
 		}
 		var block *ir.BasicBlock
 		var ok bool
+		prog.deferred[function] = &functionDefers{scope: prog.Scope, pads: make(map[cleanupKey]*ir.BasicBlock)}
+		defer delete(prog.deferred, function)
 		gen, err := n.Body.Codegen(prog)
 		if err != nil {
 			return nil, err
@@ -244,9 +360,15 @@ func (n FunctionNode) Codegen(prog *Program) (value.Value, error) {
 				return nil, err
 			}
 			if retType.Equal(types.Void) {
+				if err := prog.genDeferred(); err != nil {
+					return nil, err
+				}
+				if err := prog.arcReturn(nil); err != nil {
+					return nil, err
+				}
 				// Automatically return void from the function
 				// new ret interpets a nil value as returning void
-				block.NewRet(nil)
+				prog.Compiler.CurrentBlock().NewRet(nil)
 			} else {
 				return nil, fmt.Errorf("Function %s does not end in a return statement", namestring)
 			}
@@ -261,47 +383,73 @@ func (n FunctionNode) Codegen(prog *Program) (value.Value, error) {
 	return function, nil
 }
 
-func createInitializationPrelude(prog *Program, n FunctionNode) {
+func createInitializationPrelude(prog *Program, n FunctionNode) error {
 
 	// if the user disabled the runtime, we should just not do anything special
 	// with preludes or whatnot.
-	if *arg.DisableRuntime {
-		return
+	if prog.NoRuntime {
+		return nil
+	}
+	if prog.Compiler.CurrentFunc().Name == "__init_runtime" {
+		prog.Compiler.NewComment("Runtime Prelude:")
+		return prog.compileInitializations()
 	}
-	if prog.Compiler.CurrentFunc().Name == "main" {
 
-		prog.NewRuntimeFunctionCall("__init_runtime")
+	// if prog.Compiler.CurrentFunc().Name == "init"
+	return nil
+}
 
-		prog.Compiler.NewComment("User Code:")
+// compileInitializations compiles the initialization of the globals and the
+// calls to the package init functions into the current block. They are run
+// once, in __runtime_once_begin and __runtime_once_end, as c code can call
+// the init of a library from more than one thread at a time. The code after
+// them is generated in the block they continue in.
+func (prog *Program) compileInitializations() error {
+	if len(prog.Initializations) == 0 && len(prog.InitFunctions) == 0 {
+		return nil
 	}
 
-	if prog.Compiler.CurrentFunc().Name == "__init_runtime" {
-		prog.Compiler.NewComment("Runtime Prelude:")
+	state := prog.Module.NewGlobalDef(mangleName(".init.once"), constant.NewInt(0, types.I32))
+	run, err := prog.NewRuntimeFunctionCall("__runtime_once_begin", state)
+	if err != nil {
+		return err
+	}
+	block := prog.Compiler.CurrentBlock()
+	initBlk := block.Parent.NewBlock(mangleName("init.run"))
+	doneBlk := block.Parent.NewBlock(mangleName("init.done"))
+	block.NewCondBr(block.NewICmp(ir.IntNE, run, constant.NewInt(0, types.I32)), initBlk, doneBlk)
+	prog.Compiler.PushBlock(initBlk)
+
+	if len(prog.Initializations) > 0 {
+		prog.Compiler.NewComment("Global Initializations:")
+		for _, init := range prog.Initializations {
+			if _, err := init.Codegen(prog); err != nil {
+				return err
+			}
+		}
+	}
 
-		if len(prog.Initializations) > 0 {
-			prog.Compiler.NewComment("Global Initializations:")
-			for _, init := range prog.Initializations {
-				init.Codegen(prog)
+	if len(prog.InitFunctions) > 0 {
+		prog.Compiler.NewComment("Package Initializations:")
+		for _, name := range prog.InitFunctions {
+			if _, err := prog.NewRuntimeFunctionCall(name); err != nil {
+				return err
 			}
 		}
 	}
 
-	// if prog.Compiler.CurrentFunc().Name == "init"
+	if _, err := prog.NewRuntimeFunctionCall("__runtime_once_end", state); err != nil {
+		return err
+	}
+	prog.Compiler.CurrentBlock().NewBr(doneBlk)
+	prog.Compiler.PopBlock()
+	prog.Compiler.PushBlock(doneBlk)
+	return nil
 }
 
 func (n FunctionNode) String() string {
 	buff := &bytes.Buffer{}
-	fmt.Fprintf(buff, "func %s(", n.Name)
-	for i, arg := range n.Args {
-		fmt.Fprintf(buff, "%s", arg)
-		if i < len(n.Args)-1 || n.Variadic {
-			fmt.Fprintf(buff, ", ")
-		}
-	}
-	if n.Variadic {
-		fmt.Fprintf(buff, "...")
-	}
-	fmt.Fprintf(buff, ") %s ", n.ReturnType)
+	fmt.Fprintf(buff, "%s ", n.Signature())
 	if n.External {
 		fmt.Fprintf(buff, "...")
 	} else {
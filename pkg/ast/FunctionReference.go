@@ -0,0 +1,34 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// genFunctionReference returns the address of a named function, as in
+// `&handler`. Geode has no function types, so the address is an opaque
+// byte* that can only be handed to c code to be called back. The function
+// is compiled with the argument types it is declared with, and c calls it
+// with its c equivalent, ex: `func handler(int sig)` is `void (*)(int)`.
+func genFunctionReference(prog *Program, ident IdentNode) (value.Value, error) {
+	fn := prog.LookupFunctionNode(ident.String())
+	if fn == nil {
+		return nil, ident.Errorf(ErrInvalid, "unable to take the address of %s, it is not a variable or a function", ident)
+	}
+	if fn.HasUnknownType || fn.IsMethod {
+		return nil, ident.Errorf(ErrInvalid, "unable to take the address of %s, only plain functions without unknown types can be referenced", ident)
+	}
+
+	_, argTypes, err := fn.Arguments(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	callee, _, err := ident.GetFunc(prog, argTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	return constant.NewBitCast(callee, types.NewPointer(types.I8)), nil
+}
@@ -0,0 +1,238 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/mangle"
+)
+
+// typeParams returns the names of the type parameters of a function. They
+// are the names given in `<...>` after the function's name, and the names
+// of argument types marked unknown, like `T? val`.
+func (n FunctionNode) typeParams() []string {
+	params := append([]string{}, n.TypeParams...)
+	for _, arg := range n.Args {
+		if arg.Type.Unknown && !n.isTypeParamName(params, arg.Type.Name) {
+			params = append(params, arg.Type.Name)
+		}
+	}
+	return params
+}
+
+func (n FunctionNode) isTypeParamName(params []string, name string) bool {
+	for _, param := range params {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenericType reports if a type in the function's signature is one
+// of its type parameters, with or without modifiers
+func (n FunctionNode) isGenericType(t TypeNode) bool {
+	return n.isTypeParamName(n.typeParams(), t.Name)
+}
+
+// bindTypeParams infers the type each type parameter stands for from the
+// types of the arguments the function is called with. A type parameter
+// used by more than one argument must be given the same type by each of
+// them, though numbers of different types are widened to the largest.
+func (n FunctionNode) bindTypeParams(prog *Program, argTypes []types.Type) (map[string]types.Type, error) {
+	bindings := make(map[string]types.Type)
+	for i, arg := range n.Args {
+		if i >= len(argTypes) || !n.isGenericType(arg.Type) {
+			continue
+		}
+
+		given := argTypes[i]
+		for m := len(arg.Type.Modifiers) - 1; m >= 0; m-- {
+			switch arg.Type.Modifiers[m] {
+			case ModifierPointer:
+				ptr, ok := given.(*types.PointerType)
+				if !ok {
+					return nil, fmt.Errorf("unable to pass %s as argument %s of type %s to function %s", argTypes[i], arg.Name, arg.Type, n.Name)
+				}
+				given = ptr.Elem
+			case ModifierSlice:
+				slice, ok := given.(*types.SliceType)
+				if !ok {
+					return nil, fmt.Errorf("unable to pass %s as argument %s of type %s to function %s", argTypes[i], arg.Name, arg.Type, n.Name)
+				}
+				given = slice.Elem
+			}
+		}
+
+		bound, found := bindings[arg.Type.Name]
+		if !found || types.Equal(bound, given) {
+			bindings[arg.Type.Name] = given
+			continue
+		}
+		if typesAreLooselyEqual(bound, given) {
+			if prog.CastPrecidence(given) > prog.CastPrecidence(bound) {
+				bindings[arg.Type.Name] = given
+			}
+			continue
+		}
+		return nil, fmt.Errorf("type parameter %s of function %s is given both %s and %s", arg.Type.Name, n.Name, bound, given)
+	}
+
+	for _, param := range n.typeParams() {
+		if _, found := bindings[param]; !found {
+			return nil, fmt.Errorf("unable to infer type parameter %s of function %s from its arguments", param, n.Name)
+		}
+	}
+	return bindings, nil
+}
+
+// registerTypeBindings makes the type parameters of a function name the
+// types they are bound to in the scope it is compiled in. The bindings
+// are aliases so the bound types are never reported by the parameter name.
+func (p *Program) registerTypeBindings(bindings map[string]types.Type) {
+	for name, t := range bindings {
+		binding := NewScopeType(name, t, p.CastPrecidence(t))
+		binding.Alias = true
+		p.Scope.Types[name] = binding
+	}
+}
+
+// instantiateClass returns the struct type of an instance of a generic
+// class, named like `List<int>`. Each combination of type arguments is
+// compiled into its own struct type, with its own methods, the first time
+// it is used. Later uses, from any package, get the same instance.
+func (p *Program) instantiateClass(name string) (types.Type, error) {
+	base, argNames, err := splitTypeArgs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// the type arguments are named relative to where the instance is used
+	args := make([]types.Type, len(argNames))
+	for i, argName := range argNames {
+		args[i], err = p.findTypeArg(argName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var class *ClassNode
+	for _, path := range p.GetTypeSearchPaths(base) {
+		if cls, found := p.Classes[path]; found && len(cls.TypeParams) > 0 {
+			class = cls
+			break
+		}
+	}
+	if class == nil {
+		return nil, fmt.Errorf("unable to find generic class %q", base)
+	}
+	if !visibleFrom(visibilityOf(class.Pub), class.Package.Name, p.Scope.PackageName) {
+		return nil, privateError("class", base, class.Package.Name, p.Scope.PackageName)
+	}
+	if len(args) != len(class.TypeParams) {
+		return nil, fmt.Errorf("class %s takes %d type arguments, given %d in %s", class.Name, len(class.TypeParams), len(args), name)
+	}
+
+	instance := *class
+	instance.Name = mangle.Instance(class.Name, args)
+	instance.TypeParams = nil
+	instance.TypeBindings = make(map[string]types.Type, len(args))
+	for i, param := range class.TypeParams {
+		instance.TypeBindings[param] = args[i]
+	}
+
+	key := instance.Name
+	if class.Package.Name != "runtime" {
+		key = fmt.Sprintf("%s:%s", class.Package.Name, instance.Name)
+	}
+	if structType, found := p.ClassInstances[key]; found {
+		return structType, nil
+	}
+
+	// The instance is compiled in the package of the class, with the type
+	// parameters bound in a scope of their own
+	previousPackage := p.Package
+	previousScope := p.Scope
+	root := p.Scope.GetRoot()
+	previousPackageName := root.PackageName
+	defer func() {
+		p.Package = previousPackage
+		p.Scope = previousScope
+		root.PackageName = previousPackageName
+	}()
+
+	p.Package = class.Package
+	p.Scope = root
+	root.PackageName = class.Package.Name
+	p.ScopeDown(class.Token)
+	p.registerTypeBindings(instance.TypeBindings)
+
+	if _, err := instance.Declare(p); err != nil {
+		return nil, err
+	}
+	found, err := p.FindType(instance.Name)
+	if err != nil {
+		return nil, err
+	}
+	// the instance is cached before its fields are resolved, so it can
+	// have fields that point to itself
+	p.ClassInstances[key] = found.(*types.StructType)
+
+	if err := instance.VerifyCorrectness(p); err != nil {
+		return nil, err
+	}
+	if _, err := instance.Codegen(p); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// findTypeArg resolves a type argument of a generic class, which can have
+// pointer and slice modifiers
+func (p *Program) findTypeArg(name string) (types.Type, error) {
+	modifiers := make([]TypeModifier, 0)
+	for {
+		if strings.HasSuffix(name, "*") {
+			name = strings.TrimSuffix(name, "*")
+			modifiers = append([]TypeModifier{ModifierPointer}, modifiers...)
+			continue
+		}
+		if strings.HasSuffix(name, "[]") {
+			name = strings.TrimSuffix(name, "[]")
+			modifiers = append([]TypeModifier{ModifierSlice}, modifiers...)
+			continue
+		}
+		break
+	}
+	return TypeNode{Name: name, Modifiers: modifiers}.GetType(p)
+}
+
+// splitTypeArgs splits the name of a generic class instance, like
+// `Map<string, List<int>>`, into the class name and its type arguments
+func splitTypeArgs(name string) (string, []string, error) {
+	open := strings.Index(name, "<")
+	if open < 0 || !strings.HasSuffix(name, ">") {
+		return "", nil, fmt.Errorf("invalid generic type name %q", name)
+	}
+
+	args := make([]string, 0)
+	depth := 0
+	start := open + 1
+	inner := name[:len(name)-1]
+	for i := start; i < len(inner); i++ {
+		switch inner[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	args = append(args, strings.TrimSpace(inner[start:]))
+	return name[:open], args, nil
+}
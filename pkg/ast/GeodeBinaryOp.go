@@ -113,3 +113,47 @@ func (inst *GeodeBinaryInstr) GetParent() *ir.BasicBlock {
 func (inst *GeodeBinaryInstr) SetParent(parent *ir.BasicBlock) {
 	inst.Parent = parent
 }
+
+// Lower returns the llvm instruction of the operator, for writing bitcode.
+func (inst *GeodeBinaryInstr) Lower() ir.Instruction {
+	switch inst.Operator {
+	case "add":
+		return ir.NewAdd(inst.X, inst.Y)
+	case "fadd":
+		return ir.NewFAdd(inst.X, inst.Y)
+	case "sub":
+		return ir.NewSub(inst.X, inst.Y)
+	case "fsub":
+		return ir.NewFSub(inst.X, inst.Y)
+	case "mul":
+		return ir.NewMul(inst.X, inst.Y)
+	case "fmul":
+		return ir.NewFMul(inst.X, inst.Y)
+	case "udiv":
+		return ir.NewUDiv(inst.X, inst.Y)
+	case "sdiv":
+		return ir.NewSDiv(inst.X, inst.Y)
+	case "fdiv":
+		return ir.NewFDiv(inst.X, inst.Y)
+	case "urem":
+		return ir.NewURem(inst.X, inst.Y)
+	case "srem":
+		return ir.NewSRem(inst.X, inst.Y)
+	case "frem":
+		return ir.NewFRem(inst.X, inst.Y)
+	case "shl":
+		return ir.NewShl(inst.X, inst.Y)
+	case "lshr":
+		return ir.NewLShr(inst.X, inst.Y)
+	case "ashr":
+		return ir.NewAShr(inst.X, inst.Y)
+	case "and":
+		return ir.NewAnd(inst.X, inst.Y)
+	case "or":
+		return ir.NewOr(inst.X, inst.Y)
+	case "xor":
+		return ir.NewXor(inst.X, inst.Y)
+	}
+	// the bitcode encoder reports instructions it doesn't know
+	return inst
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
-	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
 
@@ -16,8 +15,12 @@ type GlobalVariableDeclNode struct {
 
 	Type     TypeNode
 	External bool
+	Pub      bool // exported from its package with pub
+	Const    bool // declared with const, so its value is known at compile time
 	Name     IdentNode
 	Body     Node
+	// Align is the alignment given with @align, or 0
+	Align int
 
 	GlobalDecl *ir.Global
 	Package    *Package
@@ -26,6 +29,9 @@ type GlobalVariableDeclNode struct {
 // NameString implements Node.NameString
 func (n GlobalVariableDeclNode) NameString() string { return "GlobalVariableDeclNode" }
 
+// MarshalJSON implements json.Marshaler for GlobalVariableDeclNode
+func (n GlobalVariableDeclNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Declare a global variable declaration
 func (n GlobalVariableDeclNode) Declare(prog *Program) (value.Value, error) {
 	var name string
@@ -36,14 +42,46 @@ func (n GlobalVariableDeclNode) Declare(prog *Program) (value.Value, error) {
 		name = fmt.Sprintf("%s:%s", prog.Package.Name, n.Name)
 	}
 
+	// The type of a constant declared with := is the type of its value
+	if n.Const && n.Type.Name == "" {
+		n.Type.Name = "long"
+		if val, ok := FoldConstant(prog, n.Body); ok {
+			if _, isFloat := val.(float64); isFloat {
+				n.Type.Name = "float"
+			}
+		}
+	}
+
 	varType, err := n.Type.GetType(prog)
 	if err != nil {
 		return nil, err
 	}
 
-	init := constant.NewZeroInitializer(varType)
+	init, err := prog.classZeroValue(varType)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the initial value can be computed at compile time, there is no need
+	// to defer the initialization to the runtime prelude.
+	folded := false
+	if n.Body != nil {
+		if val, ok := FoldConstant(prog, n.Body); ok {
+			c, err := NewFoldedConstant(val, varType)
+			if err == nil {
+				// wrapped like a store of the value to a local would be
+				init = wrapConstant(c)
+				folded = true
+			}
+		}
+	}
+
+	if n.Const && !folded {
+		return nil, n.Errorf(ErrConstant, "the value of const %s isn't known at compile time", n.Name)
+	}
 
 	decl := prog.Module.NewGlobalDef(name, init)
+	decl.Align = prog.alignment(varType, n.Align)
 
 	if !n.External {
 		decl.Name = MangleVariableName(name)
@@ -53,10 +91,24 @@ func (n GlobalVariableDeclNode) Declare(prog *Program) (value.Value, error) {
 	n.Package = prog.Package
 
 	scopeName := fmt.Sprintf("%s:%s", prog.Package.Name, n.Name)
+
+	// A constant initialized global that nothing writes to is a constant.
+	// Loads from it get folded in IdentNode.GenAccess, and marking it as
+	// constant in the IR lets llvm take care of the rest.
+	if folded && !n.External && (n.Const || !prog.ReassignedGlobals[n.Name.Value]) {
+		decl.IsConst = true
+		prog.ConstGlobals[decl] = init
+	}
+	if n.Const {
+		prog.constants[decl] = true
+	}
+
 	n.Name.Value = scopeName
-	prog.Scope.GetRoot().Add(NewVariableScopeItem(scopeName, decl, PublicVisibility))
+	prog.Scope.GetRoot().Add(NewVariableScopeItem(scopeName, decl, visibilityOf(n.Pub)))
 
-	prog.RegisterGlobalVariableInitialization(&n)
+	if !folded {
+		prog.RegisterGlobalVariableInitialization(&n)
+	}
 
 	return decl, nil
 }
@@ -94,6 +146,9 @@ func (n GlobalVariableDeclNode) Codegen(prog *Program) (value.Value, error) {
 
 func (n GlobalVariableDeclNode) String() string {
 	buff := &bytes.Buffer{}
+	if n.Const {
+		fmt.Fprintf(buff, "const ")
+	}
 	fmt.Fprintf(buff, "%s %s", n.Type, n.Name)
 
 	if !n.External {
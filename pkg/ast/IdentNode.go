@@ -1,17 +1,12 @@
 package ast
 
 import (
-	"bytes"
 	"fmt"
 
-	"github.com/geode-lang/geode/pkg/arg"
-
 	"github.com/geode-lang/geode/llvm/ir"
-	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
-	"github.com/geode-lang/geode/pkg/util/color"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 // NameType is a type to notate what kind of name a IdentNode is
@@ -46,6 +41,9 @@ func NewIdentNode(name string) IdentNode {
 // NameString implements Node.NameString
 func (n IdentNode) NameString() string { return "IdentNode" }
 
+// MarshalJSON implements json.Marshaler for IdentNode
+func (n IdentNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // GetFunc implements Callable.GetFunc
 func (n IdentNode) GetFunc(prog *Program, argTypes []types.Type) (*ir.Function, []value.Value, error) {
 
@@ -68,17 +66,32 @@ func (n IdentNode) String() string {
 	return n.Value
 }
 
-// Alloca returns the nearest alloca instruction in this scope with the given name
+// Alloca returns the nearest alloca instruction in this scope with the given name.
+// The variable counts as used. It is nil if there is no variable the code
+// can use with the name.
 func (n IdentNode) Alloca(prog *Program) value.Value {
+	alloc, err := n.lookup(prog)
+	if err != nil {
+		return nil
+	}
+	if local, ok := alloc.(*ir.InstAlloca); ok {
+		prog.useVariable(local)
+	}
+	return alloc
+}
+
+// lookup returns the nearest alloca instruction in this scope with the
+// given name, without counting as a use of the variable. It is nil if there
+// is none, and an error if there is one the code can't use, like a global
+// another package didn't mark pub.
+func (n IdentNode) lookup(prog *Program) (value.Value, error) {
 
 	searchPaths := make([]string, 0)
 	searchPaths = append(searchPaths, n.Value)
 	searchPaths = append(searchPaths, fmt.Sprintf("%s:%s", prog.Package.Name, n.Value))
 
 	if prog.Scope == nil {
-		n.SyntaxError()
-		fmt.Println(n)
-		return nil
+		return nil, n.Errorf(ErrInvalid, "%s is used outside of a scope", n)
 	}
 	scopeitem, found := prog.Scope.Find(searchPaths)
 
@@ -90,19 +103,21 @@ func (n IdentNode) Alloca(prog *Program) value.Value {
 		// log.Fatal("Unable to find named reference %s, search paths: [%s]\n", n, strings.Join(searchPaths, ", "))
 
 		// If it is not found, I need to create a new node. Assignment will never fail when assigning to
-		return nil
+		return nil, nil
 	}
 
 	if alloc, success = scopeitem.(VariableScopeItem).Value().(*ir.InstAlloca); success {
-		return alloc
+		return alloc, nil
 	}
 
 	if alloc, success = scopeitem.(VariableScopeItem).Value().(*ir.Global); success {
-		return alloc
+		if pkg, _ := ParseName(scopeitem.Name()); !visibleFrom(scopeitem.Visibility(), pkg, prog.Package.Name) {
+			return nil, n.Diagnose(privateError("global", n.Value, pkg, prog.Package.Name))
+		}
+		return alloc, nil
 	}
 
-	log.Fatal("Unknown Type in VariableScopeItem in search paths: [%s]\n", searchPaths)
-	return nil
+	return nil, n.Errorf(ErrInvalid, "%s is not a variable", n)
 }
 
 // Load returns a load instruction on a named reference with the given name
@@ -116,18 +131,38 @@ func (n IdentNode) Load(block *ir.BasicBlock, prog *Program) *ir.InstLoad {
 
 // GenAssign implements Assignable.GenAssign
 func (n IdentNode) GenAssign(prog *Program, assignment value.Value, options ...AssignableOption) (value.Value, error) {
-	alloca := n.Alloca(prog)
+	alloca, err := n.lookup(prog)
+	if err != nil {
+		return nil, err
+	}
 
-	if alloca == nil {
-		alloca = prog.Compiler.CurrentBlock().NewAlloca(assignment.Type())
-		prog.Scope.Add(NewVariableScopeItem(n.Value, alloca, PublicVisibility))
+	if glob, ok := alloca.(*ir.Global); ok && prog.constants[glob] {
+		return nil, n.Errorf(ErrInvalid, "unable to assign to const %s", n.Value)
 	}
-	store := prog.Compiler.CurrentBlock().NewStore(assignment, alloca)
 
-	if *arg.EnableDebug {
-		md := &metadata.Metadata{}
-		md.Add(metadata.NewRaw(n.Token.DILocation(prog.Scope.DebugInfo)))
-		store.Metadata["dbg"] = md
+	if alloca == nil {
+		if _, _, isMember := prog.enumMember(n.Value); isMember {
+			return nil, n.Errorf(ErrInvalid, "unable to assign to enum member %s", n.Value)
+		}
+		if _, _, isVariant := prog.unionVariant(n.Value); isVariant {
+			return nil, n.Errorf(ErrInvalid, "unable to assign to union variant %s", n.Value)
+		}
+		var local *ir.InstAlloca
+		if prog.arcCounted(assignment.Type()) {
+			// counted locals are made nil when the function is entered, so
+			// they are allocated there too
+			local = createBlockAlloca(prog.Compiler.CurrentFunc(), assignment.Type(), n.Value)
+		} else {
+			local = prog.Compiler.CurrentBlock().NewAlloca(assignment.Type())
+		}
+		local.Align = prog.alignment(assignment.Type(), 0)
+		prog.Scope.Add(NewVariableScopeItem(n.Value, local, PublicVisibility))
+		prog.declareVariable(n.Value, n.Token, local)
+		prog.arcDeclare(local)
+		alloca = local
+	}
+	if err := prog.arcStore(assignment, alloca); err != nil {
+		return nil, err
 	}
 
 	return assignment, nil
@@ -140,30 +175,39 @@ func (n IdentNode) Codegen(prog *Program) (value.Value, error) {
 
 // GenAccess implements Accessable.GenAccess
 func (n IdentNode) GenAccess(prog *Program) (value.Value, error) {
+	if _, err := n.lookup(prog); err != nil {
+		return nil, err
+	}
+	// Loads from constant globals are replaced with the constant itself
+	if glob, ok := n.Alloca(prog).(*ir.Global); ok {
+		if c, found := prog.ConstGlobals[glob]; found {
+			return copyConstant(c), nil
+		}
+	}
+
 	load := n.Load(prog.Compiler.CurrentBlock(), prog)
 	if load == nil {
-
-		buff := &bytes.Buffer{}
-		fmt.Fprintf(buff, "* unable to load/access value for identifier %s\n", color.Red(n.Value))
-
-		meant, dist := prog.Scope.GetSimilarName(n.Value)
-		if dist >= 0.2 {
-			fmt.Fprintf(buff, "  Maybe you meant %s", color.Green(meant))
-			if typ, found := prog.Scope.Find([]string{meant}); found {
-				ptr := typ.Value().Type().(*types.PointerType)
-				fmt.Fprintf(buff, " (%s)", ptr.Elem)
-			}
-			buff.WriteString("?\n")
+		if _, val, isMember := prog.enumMember(n.Value); isMember {
+			return constant.NewInt(val, types.I32), nil
+		}
+		if union, tag, isVariant := prog.unionVariant(n.Value); isVariant {
+			return prog.genUnionValue(union, tag, nil, n)
 		}
 
-		return nil, fmt.Errorf("%s", buff)
+		if meant, dist := prog.Scope.GetSimilarName(n.Value); dist >= similarNameDistance {
+			return nil, n.Errorf(ErrUndefined, "%s is not defined, did you mean %s?", n.Value, meant)
+		}
+		return nil, n.Errorf(ErrUndefined, "%s is not defined", n.Value)
 	}
 	return load, nil
 }
 
 // Type implements Assignable.Type
 func (n IdentNode) Type(prog *Program) (types.Type, error) {
-	ref := n.Alloca(prog)
+	ref, err := n.lookup(prog)
+	if err != nil {
+		return nil, err
+	}
 
 	if alloca, success := ref.(*ir.InstAlloca); success {
 		return alloca.Elem, nil
@@ -0,0 +1,97 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// IncDecNode is an increment or a decrement, like `i++` or `--i`. It is
+// compiled as the compound assignment `i += 1`. The prefix forms give the
+// new value and the postfix forms give the value from before.
+type IncDecNode struct {
+	NodeType
+	TokenReference
+
+	Operand Node
+	Op      string // "++" or "--"
+	Prefix  bool
+}
+
+// NameString implements Node.NameString
+func (n IncDecNode) NameString() string { return "IncDecNode" }
+
+// MarshalJSON implements json.Marshaler for IncDecNode
+func (n IncDecNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n IncDecNode) String() string {
+	if n.Prefix {
+		return fmt.Sprintf("%s%s", n.Op, n.Operand)
+	}
+	return fmt.Sprintf("%s%s", n.Operand, n.Op)
+}
+
+// compound returns the compound assignment the node is compiled as
+func (n IncDecNode) compound() BinaryNode {
+	one := IntNode{}
+	one.Token = n.Token
+	one.NodeType = nodeInt
+	one.Value = 1
+
+	assign := BinaryNode{}
+	assign.Token = n.Token
+	assign.NodeType = nodeBinary
+	assign.Left = n.Operand
+	assign.Right = one
+	assign.OP = "+="
+	if n.Op == "--" {
+		assign.OP = "-="
+	}
+	return assign
+}
+
+// Codegen implements Node.Codegen for IncDecNode
+func (n IncDecNode) Codegen(prog *Program) (value.Value, error) {
+	var before value.Value
+	if !n.Prefix {
+		val, err := n.Operand.Codegen(prog)
+		if err != nil {
+			return nil, err
+		}
+		before = val
+	}
+
+	after, err := n.compound().Codegen(prog)
+	if err != nil {
+		return nil, n.Diagnose(err)
+	}
+
+	if n.Prefix {
+		return after, nil
+	}
+	return before, nil
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n IncDecNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
+
+func isIncDec(tok lexer.Token) bool {
+	return tok.Is(lexer.TokOper) && (tok.Value == "++" || tok.Value == "--")
+}
+
+// parseIncDec wraps an expression in the ++ or -- after it, if any
+func (p *Parser) parseIncDec(n Node) Node {
+	if n == nil || !isIncDec(p.token) {
+		return n
+	}
+	inc := IncDecNode{}
+	inc.Token = p.token
+	inc.NodeType = nodeIncDec
+	inc.Operand = n
+	inc.Op = p.token.Value
+	p.Next()
+	return inc
+}
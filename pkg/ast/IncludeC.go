@@ -0,0 +1,400 @@
+package ast
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/geode-lang/geode/pkg/cheader"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// `include_c "stdio.h"` generates the bindings to a c library from its
+// header, instead of them being written out by hand as functions declared
+// with .... The header is preprocessed by the toolchain for the target and
+// its declarations parsed with pkg/cheader into a package named after the
+// header, so stdio.h declares stdio:printf, the class stdio:FILE and the
+// constant stdio:EOF.
+//
+// The functions keep their c names. Those renamed with an asm label, and
+// those whose names geode can't use, like ones that start with a capital
+// letter or are keywords, are declared with @symbol. The enum constants
+// and the macros that are integers are constants. Structs are classes
+// named after their typedef or tag, with the first letter capitalized, so
+// struct tm is stdio:Tm.
+//
+// A char* is a byte*, which strings are passed to c functions as, and
+// pointers to what geode has no type for, like functions, are byte*.
+// Unions, and structs geode can't lay out the same way as c, like those
+// with arrays or bitfields, are classes with no fields, used through
+// pointers. Functions that take or return a struct by value, or a type
+// geode has no equivalent of, like long double and va_list, are left out,
+// as are global variables, function-like macros and names starting with
+// an underscore, which are the c library's own.
+//
+// A header is only included once. Its package is parsed from the generated
+// source at a path that doesn't exist, under cHeaderDir.
+
+// cHeaderDir is the directory the packages of c headers are parsed at
+const cHeaderDir = "<c>"
+
+// cHeader is a header included with include_c
+type cHeader struct {
+	// path is the path its package was parsed at
+	path string
+	// files are the header and every header it included
+	files []string
+}
+
+// includeC parses the package of the c header included from the package
+// in the directory base, returning the path of the package. The directory
+// it is in doesn't exist, so packages depend on the path itself, which
+// ReduceToDir reduces to the directory.
+func (p *Program) includeC(ctx context.Context, base, header string) (string, error) {
+	include := fmt.Sprintf("<%s>", header)
+	// headers next to the package are included as they are
+	if local, err := filepath.Abs(filepath.Join(base, header)); err == nil && !filepath.IsAbs(header) {
+		if _, err := os.Stat(local); err == nil {
+			include = fmt.Sprintf("%q", local)
+		}
+	}
+	if h, found := p.cHeaders[include]; found {
+		return h.path, nil
+	}
+
+	parsed, err := p.preprocessC(include)
+	if err != nil {
+		return "", err
+	}
+	name := cHeaderPackage(header)
+	path := filepath.Join(cHeaderDir, header, name+".g")
+	p.cHeaders[include] = &cHeader{path, parsed.Files}
+	if err := p.parsePackage(ctx, newCBinder(p, parsed).bindings(name, header), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// preprocessC runs the preprocessor of the toolchain over a source that
+// includes a header, and parses what it outputs
+func (p *Program) preprocessC(include string) (*cheader.Header, error) {
+	toolchain := p.Toolchain
+	if toolchain == nil {
+		var err error
+		toolchain, err = SelectToolchain("", ToolchainOptions{Target: p.Target.Triple, Freestanding: p.Target.Freestanding()})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "geode-include-c")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "header.c")
+	out := filepath.Join(dir, "header.i")
+	if err := ioutil.WriteFile(src, []byte(fmt.Sprintf("#include %s\n", include)), 0666); err != nil {
+		return nil, err
+	}
+	if err := toolchain.PreprocessC(src, out); err != nil {
+		return nil, err
+	}
+	text, err := ioutil.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	return cheader.Parse(string(text)), nil
+}
+
+// cHeaderPackage returns the name of the package of a header, its file
+// name without the extension and anything a package name can't contain.
+// Names that are keywords or types start with a c, so string.h is cstring.
+func cHeaderPackage(header string) string {
+	base := strings.ToLower(strings.TrimSuffix(filepath.Base(header), filepath.Ext(header)))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r == '_' {
+			return r
+		}
+		return -1
+	}, base)
+	if name == "" || lexer.IsReserved(name) {
+		return "c" + name
+	}
+	return name
+}
+
+// cBinder writes the geode declarations of a parsed header
+type cBinder struct {
+	header *cheader.Header
+	// long and ulong are the types of c's long, which is as wide as a
+	// pointer on every target geode supports
+	long, ulong string
+	// classes are the names of the classes of the records, and laidOut
+	// whether they are laid out like the records are
+	classes map[*cheader.Record]string
+	laidOut map[*cheader.Record]bool
+	// names are the names of the classes and constants declared, and
+	// funcs those of the functions
+	names map[string]bool
+	funcs map[string]bool
+}
+
+func newCBinder(p *Program, header *cheader.Header) *cBinder {
+	b := &cBinder{
+		header:  header,
+		long:    "long",
+		ulong:   "u64",
+		classes: make(map[*cheader.Record]string),
+		laidOut: make(map[*cheader.Record]bool),
+		names:   make(map[string]bool),
+		funcs:   make(map[string]bool),
+	}
+	if p.layout.PointerSize == 32 {
+		b.long, b.ulong = "int", "u32"
+	}
+	for _, rec := range header.Records {
+		if name := b.className(rec); name != "" {
+			b.classes[rec] = name
+		}
+	}
+	return b
+}
+
+// bindings returns the source of the package of the header
+func (b *cBinder) bindings(pkg, header string) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# the bindings include_c generated from %s\n", header)
+	fmt.Fprintf(buf, "is %s\n\n", pkg)
+
+	for _, rec := range b.header.Records {
+		name, named := b.classes[rec]
+		if !named {
+			continue
+		}
+		if !b.layout(rec) {
+			fmt.Fprintf(buf, "pub class %s {}\n", name)
+			continue
+		}
+		fmt.Fprintf(buf, "pub class %s {\n", name)
+		for _, field := range rec.Fields {
+			t, _ := b.fieldType(field.Type)
+			fmt.Fprintf(buf, "\t%s %s\n", t, cIdent(field.Name))
+		}
+		fmt.Fprintf(buf, "}\n")
+	}
+
+	for _, c := range b.header.Constants {
+		if b.names[c.Name] || !cUsableName(c.Name) || c.Unsigned && c.Value < 0 {
+			continue
+		}
+		b.names[c.Name] = true
+		t := "int"
+		if c.Value < math.MinInt32 || c.Value > math.MaxInt32 {
+			t = "long"
+		}
+		fmt.Fprintf(buf, "pub const %s %s = %d\n", t, c.Name, c.Value)
+	}
+
+	for _, fn := range b.header.Functions {
+		if decl, ok := b.function(fn); ok {
+			buf.WriteString(decl)
+		}
+	}
+	return buf.String()
+}
+
+// className returns the name of the class of a record, or "" for records
+// without a name, like the anonymous structs in other structs
+func (b *cBinder) className(rec *cheader.Record) string {
+	candidates := make([]string, 0, len(rec.Typedefs)+1)
+	for _, typedef := range rec.Typedefs {
+		if !strings.HasPrefix(typedef, "_") {
+			candidates = append(candidates, typedef)
+		}
+	}
+	candidates = append(candidates, rec.Tag)
+	candidates = append(candidates, rec.Typedefs...)
+
+	for _, candidate := range candidates {
+		candidate = strings.TrimLeft(candidate, "_")
+		if candidate == "" {
+			continue
+		}
+		name := strings.ToUpper(candidate[:1]) + candidate[1:]
+		unique := name
+		for i := 2; b.names[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", name, i)
+		}
+		b.names[unique] = true
+		return unique
+	}
+	return ""
+}
+
+// layout returns if the class of a record can have its fields, laid out
+// the way c lays them out
+func (b *cBinder) layout(rec *cheader.Record) bool {
+	if laidOut, found := b.laidOut[rec]; found {
+		return laidOut
+	}
+	b.laidOut[rec] = false
+	if !rec.Defined || rec.Union || len(rec.Fields) == 0 {
+		return false
+	}
+	for _, field := range rec.Fields {
+		if field.Name == "" || field.Bits >= 0 {
+			return false
+		}
+		if _, ok := b.fieldType(field.Type); !ok {
+			return false
+		}
+	}
+	b.laidOut[rec] = true
+	return true
+}
+
+// fieldType returns the geode type of a field, which can be a struct
+func (b *cBinder) fieldType(t *cheader.Type) (string, bool) {
+	if t.Kind == cheader.Struct {
+		name, named := b.classes[t.Record]
+		return name, named && b.layout(t.Record)
+	}
+	return b.typeName(t)
+}
+
+// typeName returns the geode type of a c type that can be passed to and
+// returned from a function
+func (b *cBinder) typeName(t *cheader.Type) (string, bool) {
+	switch t.Kind {
+	case cheader.Void:
+		return "void", true
+	case cheader.Bool, cheader.UChar:
+		// _Bool is passed as a byte, which geode's bool isn't
+		return "u8", true
+	case cheader.Char, cheader.SChar:
+		return "byte", true
+	case cheader.Short:
+		return "short", true
+	case cheader.UShort:
+		return "u16", true
+	case cheader.Int, cheader.Enum:
+		return "int", true
+	case cheader.UInt:
+		return "u32", true
+	case cheader.Long:
+		return b.long, true
+	case cheader.ULong:
+		return b.ulong, true
+	case cheader.LongLong:
+		return "long", true
+	case cheader.ULongLong:
+		return "u64", true
+	case cheader.Int128:
+		return "i128", true
+	case cheader.UInt128:
+		return "u128", true
+	case cheader.Float:
+		return "f32", true
+	case cheader.Double:
+		return "float", true
+	case cheader.Pointer:
+		return b.pointerName(t.Elem), true
+	}
+	return "", false
+}
+
+// pointerName returns the geode type of a pointer to a c type
+func (b *cBinder) pointerName(elem *cheader.Type) string {
+	switch elem.Kind {
+	case cheader.Void, cheader.Char, cheader.SChar, cheader.UChar:
+		return "byte*"
+	case cheader.Pointer:
+		return b.pointerName(elem.Elem) + "*"
+	case cheader.Struct, cheader.Union:
+		if name, named := b.classes[elem.Record]; named {
+			return name + "*"
+		}
+		return "byte*"
+	}
+	if name, ok := b.typeName(elem); ok {
+		return name + "*"
+	}
+	return "byte*"
+}
+
+// function returns the declaration of a function, if it can be declared
+func (b *cBinder) function(fn *cheader.Function) (string, bool) {
+	if strings.HasPrefix(fn.Name, "_") {
+		return "", false
+	}
+	ret, ok := b.typeName(fn.Type.Elem)
+	if !ok {
+		return "", false
+	}
+	params := make([]string, 0, len(fn.Type.Params)+1)
+	used := make(map[string]bool)
+	for i, param := range fn.Type.Params {
+		t, ok := b.typeName(param.Type)
+		if !ok {
+			return "", false
+		}
+		name := cIdent(strings.TrimLeft(param.Name, "_"))
+		if name == "" || used[name] {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		used[name] = true
+		params = append(params, fmt.Sprintf("%s %s", t, name))
+	}
+	if fn.Type.Variadic {
+		params = append(params, "...")
+	}
+
+	name := fn.Name
+	symbol := fn.Label
+	if !cUsableName(name) || unicode.IsUpper([]rune(name)[0]) {
+		name = cIdent(strings.ToLower(name[:1]) + name[1:])
+		if symbol == "" {
+			symbol = fn.Name
+		}
+	}
+	if b.funcs[name] {
+		return "", false
+	}
+	b.funcs[name] = true
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("pub ")
+	if symbol != "" {
+		fmt.Fprintf(buf, "@symbol(%q) ", symbol)
+	}
+	fmt.Fprintf(buf, "func %s(%s)", name, strings.Join(params, ", "))
+	if ret != "void" {
+		fmt.Fprintf(buf, " %s", ret)
+	}
+	buf.WriteString(" ...\n")
+	return buf.String(), true
+}
+
+// cUsableName returns if a c name can be used in geode as it is
+func cUsableName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "_") && !lexer.IsReserved(name)
+}
+
+// cIdent returns a c name as a geode identifier. Keywords and names that
+// would be types get an underscore.
+func cIdent(name string) string {
+	switch {
+	case lexer.IsReserved(name):
+		return name + "_"
+	case name != "" && unicode.IsUpper([]rune(name)[0]):
+		return "_" + name
+	}
+	return name
+}
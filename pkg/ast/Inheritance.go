@@ -0,0 +1,383 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// A class can extend one other class by naming it after `is`, along with
+// the interfaces it implements:
+//
+//     class Dog is Animal, Named { ... }
+//
+// The fields of the parent are laid out first, so a pointer to an instance
+// of the class can be used as a pointer to an instance of its parent. A
+// class that extends another or is extended starts with a pointer to the
+// vtable of its class, and its methods are called through it, so a method
+// overridden in a child is called even through a pointer to the parent.
+//
+// The vtable pointer is set when an instance is declared. Instances that
+// are allocated some other way have to be copied from a declared one.
+
+// vtableField is the name of the field holding the vtable of an instance
+const vtableField = "__vtable"
+
+// A vtable starts with the vtable of the class the class extends, or nil,
+// and the type info of the class, which `as!` checks casts with. The
+// methods come after them.
+const vtableHeader = 2
+
+// virtualClass is a class that extends another class or is extended
+type virtualClass struct {
+	Name   string // the name of the class in the scope
+	Type   *types.StructType
+	Parent *virtualClass
+	// Slots are the names of the methods in the vtable, the parent's first,
+	// and Methods is the name of the function each of them calls
+	Slots   []string
+	Methods map[string]string
+	VTable  *ir.Global
+	// Virtual are the functions that call a method through the vtable of
+	// the instance they are given, for the vtables of interfaces
+	Virtual map[string]*ir.Function
+}
+
+// slot returns the index of a method in the class's vtable
+func (c *virtualClass) slot(name string) int {
+	for i, slot := range c.Slots {
+		if slot == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// orderClasses finds the class each class extends and orders the classes
+// so every class comes after the class it extends, as it is laid out
+// after it, and after the classes it has fields of, as their layout is
+// part of its own. Fields that point to classes don't order them, so
+// classes can point to each other.
+func (p *Program) orderClasses(nodes []*PackagedNode) ([]*PackagedNode, error) {
+	byType := map[*types.StructType]*PackagedNode{}
+	typeOf := map[*PackagedNode]*types.StructType{}
+	for _, node := range nodes {
+		cls := node.Node.(ClassNode)
+		if len(cls.TypeParams) > 0 {
+			continue
+		}
+		node.SetupContext()
+		found, err := p.FindType(cls.Name)
+		if err != nil {
+			return nil, err
+		}
+		byType[found.(*types.StructType)] = node
+		typeOf[node] = found.(*types.StructType)
+	}
+
+	contains := map[*PackagedNode][]*PackagedNode{}
+	for _, node := range nodes {
+		t, found := typeOf[node]
+		if !found {
+			continue
+		}
+		cls := node.Node.(ClassNode)
+		node.SetupContext()
+		for _, f := range cls.Variables {
+			// a field with a bad type is reported when the class is defined
+			ft, err := f.Typ.GetType(p)
+			if err != nil {
+				continue
+			}
+			for _, st := range containedStructs(ft) {
+				if field := byType[st]; field != nil {
+					contains[node] = append(contains[node], field)
+				}
+			}
+		}
+		for _, name := range cls.Implements {
+			if p.lookupInterface(name) != nil {
+				continue
+			}
+			found, _ := p.FindType(name)
+			parent, isStruct := found.(*types.StructType)
+			if !isStruct || byType[parent] == nil {
+				continue
+			}
+			if p.classParents[t] != nil {
+				return nil, cls.Errorf(ErrInvalid, "class %s can only extend one class", cls.Name)
+			}
+			p.classParents[t] = parent
+			p.extendedClasses[parent] = true
+		}
+	}
+
+	ordered := make([]*PackagedNode, 0, len(nodes))
+	visiting := map[*PackagedNode]bool{}
+	visited := map[*PackagedNode]bool{}
+	var visit func(node *PackagedNode, field bool) error
+	visit = func(node *PackagedNode, field bool) error {
+		if visited[node] {
+			return nil
+		}
+		if visiting[node] {
+			if field {
+				return node.Node.Errorf(ErrInvalid, "class %s contains itself, a field can only point to it", node.Node.(ClassNode).Name)
+			}
+			return node.Node.Errorf(ErrInvalid, "class %s extends itself", node.Node.(ClassNode).Name)
+		}
+		visiting[node] = true
+		if parent := p.classParents[typeOf[node]]; parent != nil {
+			if err := visit(byType[parent], false); err != nil {
+				return err
+			}
+		}
+		for _, contained := range contains[node] {
+			if err := visit(contained, true); err != nil {
+				return err
+			}
+		}
+		visited[node] = true
+		ordered = append(ordered, node)
+		return nil
+	}
+	for _, node := range nodes {
+		if err := visit(node, false); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// containedStructs returns the structs a value of a type holds, and not
+// just points to
+func containedStructs(t types.Type) []*types.StructType {
+	switch t := t.(type) {
+	case *types.StructType:
+		return []*types.StructType{t}
+	case *types.ArrayType:
+		return containedStructs(t.Elem)
+	}
+	return nil
+}
+
+// declareVirtualClass records the vtable slots of a class in a hierarchy,
+// once its methods are registered
+func (p *Program) declareVirtualClass(class ClassNode, structDefn *types.StructType) error {
+	name, err := p.Scope.FindTypeName(structDefn)
+	if err != nil {
+		return err
+	}
+	c := &virtualClass{
+		Name:    name,
+		Type:    structDefn,
+		Methods: map[string]string{},
+		Virtual: map[string]*ir.Function{},
+	}
+	if parent := p.classParents[structDefn]; parent != nil {
+		c.Parent = p.virtualClasses[parent]
+		c.Slots = append(c.Slots, c.Parent.Slots...)
+		for slot, fn := range c.Parent.Methods {
+			c.Methods[slot] = fn
+		}
+	}
+	for _, fn := range class.Methods {
+		method := fn.Name.String()
+		if c.slot(method) < 0 {
+			c.Slots = append(c.Slots, method)
+		}
+		c.Methods[method] = fmt.Sprintf("%s:%s.%s", p.Package.Name, class.Name, method)
+	}
+	p.virtualClasses[structDefn] = c
+	return nil
+}
+
+// virtualClassOf returns the class in a hierarchy a type is, or nil
+func (p *Program) virtualClassOf(t types.Type) *virtualClass {
+	s, ok := t.(*types.StructType)
+	if !ok {
+		return nil
+	}
+	return p.virtualClasses[s]
+}
+
+// isUpcast reports if from is a pointer to an instance of a class that
+// extends the class to points to
+func (p *Program) isUpcast(from, to types.Type) bool {
+	fromPtr, ok := from.(*types.PointerType)
+	if !ok {
+		return false
+	}
+	toPtr, ok := to.(*types.PointerType)
+	if !ok {
+		return false
+	}
+	class, ok := fromPtr.Elem.(*types.StructType)
+	if !ok {
+		return false
+	}
+	for parent := p.classParents[class]; parent != nil; parent = p.classParents[parent] {
+		if types.Equal(parent, toPtr.Elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// classVTable returns the vtable of a class in a hierarchy. It is built
+// the first time an instance of the class is declared, compiling the
+// methods in it.
+func (p *Program) classVTable(c *virtualClass) (*ir.Global, error) {
+	if c.VTable != nil {
+		return c.VTable, nil
+	}
+
+	slotType := types.NewPointer(types.I8)
+	var parent constant.Constant = constant.NewNull(slotType)
+	if c.Parent != nil {
+		vtable, err := p.classVTable(c.Parent)
+		if err != nil {
+			return nil, err
+		}
+		parent = constant.NewBitCast(vtable, slotType)
+	}
+	_, name := ParseName(c.Name)
+	info, err := p.typeInfoGlobal(c.Type, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]constant.Constant, 0, vtableHeader+len(c.Slots))
+	fields = append(fields, parent, constant.NewBitCast(info, slotType))
+	for _, slot := range c.Slots {
+		fn, err := p.GetFunction(c.Methods[slot], FunctionCompilationOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		// an override is called through the vtable of the parent, so its
+		// signature has to match the method it overrides
+		if c.Parent != nil && c.Parent.slot(slot) >= 0 && c.Parent.Methods[slot] != c.Methods[slot] {
+			overridden, err := p.GetFunction(c.Parent.Methods[slot], FunctionCompilationOptions{})
+			if err != nil {
+				return nil, err
+			}
+			matches := types.Equal(fn.Sig.Ret, overridden.Sig.Ret) && len(fn.Sig.Params) == len(overridden.Sig.Params)
+			for i := 1; matches && i < len(fn.Sig.Params); i++ {
+				matches = types.Equal(fn.Sig.Params[i].Typ, overridden.Sig.Params[i].Typ)
+			}
+			if !matches {
+				return nil, fmt.Errorf("method %s of class %s has the signature %s, the method it overrides in class %s has %s", slot, c.Name, fn.Sig, c.Parent.Name, overridden.Sig)
+			}
+		}
+		fields = append(fields, constant.NewBitCast(fn, slotType))
+	}
+
+	c.VTable = p.Module.NewGlobalDef(fmt.Sprintf("vtable.%s", c.Name), constant.NewArray(fields...))
+	return c.VTable, nil
+}
+
+// classZeroValue returns the value an instance of a type is declared
+// with. Instances of classes in a hierarchy point to their vtable.
+func (p *Program) classZeroValue(t types.Type) (constant.Constant, error) {
+	c := p.virtualClassOf(t)
+	if c == nil {
+		return constant.NewZeroInitializer(t), nil
+	}
+	vtable, err := p.classVTable(c)
+	if err != nil {
+		return nil, err
+	}
+	fields := []constant.Constant{constant.NewBitCast(vtable, c.Type.Fields[0])}
+	for _, field := range c.Type.Fields[1:] {
+		fields = append(fields, constant.NewZeroInitializer(field))
+	}
+	init := constant.NewStruct(fields...)
+	init.Typ = c.Type
+	return init, nil
+}
+
+// genVirtualCall calls a method of an instance of a class in a hierarchy
+// through the vtable it points to
+func genVirtualCall(prog *Program, class *virtualClass, dot DotReference, call FunctionCallNode) (value.Value, error) {
+	name := dot.Field.String()
+	index := class.slot(name)
+
+	// the method the call resolves to without the vtable has the types
+	// the arguments are passed as
+	fn, err := prog.GetFunction(class.Methods[name], FunctionCompilationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sig := fn.Sig
+	if len(call.Args) != len(sig.Params)-1 {
+		return nil, call.Errorf(ErrInvalid, "method %s of class %s takes %d arguments, given %d", name, class.Name, len(sig.Params)-1, len(call.Args))
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	this := dot.BaseAddr(prog)
+	zero := constant.NewInt(0, types.I32)
+	vtable := block.NewLoad(block.NewGetElementPtr(this, zero, zero))
+	slots := block.NewBitCast(vtable, types.NewPointer(types.NewPointer(types.I8)))
+	method := block.NewLoad(block.NewGetElementPtr(slots, constant.NewInt(int64(vtableHeader+index), types.I32)))
+	callee := block.NewBitCast(method, fn.Type())
+
+	args := []value.Value{block.NewBitCast(this, sig.Params[0].Typ)}
+	for i, arg := range call.Args {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, arg.Errorf(ErrInvalid, "argument to method %s is not accessable (has no readable value). Node type %s", name, arg.Kind())
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		val, err = createTypeCast(prog, val, sig.Params[i+1].Typ)
+		if err != nil {
+			return nil, arg.Diagnose(err)
+		}
+		args = append(args, val)
+	}
+
+	return prog.genCall(callee, args...)
+}
+
+// virtualMethod returns a function that calls a method of a class in a
+// hierarchy through the vtable of the instance it is given, like a call to
+// the method does
+func (p *Program) virtualMethod(c *virtualClass, name string) (*ir.Function, error) {
+	if fn, found := c.Virtual[name]; found {
+		return fn, nil
+	}
+	fn, err := p.GetFunction(c.Methods[name], FunctionCompilationOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]*types.Param, len(fn.Sig.Params))
+	args := make([]value.Value, len(fn.Sig.Params))
+	for i, param := range fn.Sig.Params {
+		params[i] = ir.NewParam(param.Name, param.Typ)
+		args[i] = params[i]
+	}
+	virtual := p.Module.NewFunction(fmt.Sprintf("virtual.%s.%s", c.Name, name), fn.Sig.Ret, params...)
+	virtual.Linkage = ir.LinkageInternal
+
+	block := virtual.NewBlock("entry")
+	this := block.NewBitCast(params[0], types.NewPointer(c.Type))
+	zero := constant.NewInt(0, types.I32)
+	vtable := block.NewLoad(block.NewGetElementPtr(this, zero, zero))
+	slots := block.NewBitCast(vtable, types.NewPointer(types.NewPointer(types.I8)))
+	method := block.NewLoad(block.NewGetElementPtr(slots, constant.NewInt(int64(vtableHeader+c.slot(name)), types.I32)))
+	call := block.NewCall(block.NewBitCast(method, fn.Type()), args...)
+	if types.Equal(fn.Sig.Ret, types.Void) {
+		block.NewRet(nil)
+	} else {
+		block.NewRet(call)
+	}
+	c.Virtual[name] = virtual
+	return virtual, nil
+}
@@ -0,0 +1,177 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// builtinAsm is the builtin that inlines assembly into a function. It takes
+// the template of the assembly, its outputs, its inputs and the registers
+// and memory it clobbers, like the extended asm of gcc:
+//
+//	asm("syscall", ["=\{rax}"(ret)], ["\{rax}"(60), "\{rdi}"(code)], ["rcx", "r11", "memory"])
+//
+// The template is in the syntax of the target's assembler, with $0, $1 and
+// so on naming the outputs and then the inputs. Each output is an llvm
+// constraint starting with '=' and the variable the result is stored in,
+// each input is a constraint and the value passed in. The '{' of a register
+// constraint is escaped so it isn't interpolated. The template, the
+// constraints and the clobbers must be literals. The asm is assumed to have
+// side effects, so it is never removed or moved. Its value is that of its
+// first output, and it has none without outputs.
+const builtinAsm = "asm"
+
+// asmOperand is an output or input of an asm
+type asmOperand struct {
+	constraint string
+	node       Node
+}
+
+// asmBuiltin returns if a call is to asm. A function declared with the same
+// name always takes priority.
+func asmBuiltin(prog *Program, n FunctionCallNode) bool {
+	ident, ok := n.Name.(IdentNode)
+	if !ok || ident.String() != builtinAsm {
+		return false
+	}
+	return prog.LookupFunctionNode(builtinAsm) == nil
+}
+
+// genAsm generates the inline asm call of a call to asm
+func genAsm(prog *Program, n FunctionCallNode) (value.Value, error) {
+	if len(n.Args) != 4 {
+		return nil, n.Errorf(ErrInvalid, "%s takes 4 arguments (template, outputs, inputs, clobbers), given %d", builtinAsm, len(n.Args))
+	}
+	template, ok := n.Args[0].(StringNode)
+	if !ok {
+		return nil, n.Args[0].Errorf(ErrConstant, "the template passed to %s must be a string literal", builtinAsm)
+	}
+	outputs, err := asmOperands(n.Args[1], "outputs")
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := asmOperands(n.Args[2], "inputs")
+	if err != nil {
+		return nil, err
+	}
+	clobbers, err := asmClobbers(n.Args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make([]string, 0, len(outputs)+len(inputs)+len(clobbers))
+	outputTypes := make([]types.Type, 0, len(outputs))
+	for _, out := range outputs {
+		if !strings.HasPrefix(out.constraint, "=") {
+			return nil, out.node.Errorf(ErrInvalid, "the constraint %q of an output of %s must start with '='", out.constraint, builtinAsm)
+		}
+		as, isAssignable := out.node.(Assignable)
+		if !isAssignable {
+			return nil, out.node.Errorf(ErrInvalid, "output %s of %s is not assignable", out.node, builtinAsm)
+		}
+		typ, err := as.Type(prog)
+		if err != nil {
+			return nil, err
+		}
+		if typ == nil {
+			return nil, out.node.Errorf(ErrUndefined, "output %s of %s must be declared before it", out.node, builtinAsm)
+		}
+		constraints = append(constraints, out.constraint)
+		outputTypes = append(outputTypes, typ)
+	}
+
+	args := make([]value.Value, 0, len(inputs))
+	params := make([]*types.Param, 0, len(inputs))
+	for _, in := range inputs {
+		if strings.HasPrefix(in.constraint, "=") || strings.HasPrefix(in.constraint, "~") {
+			return nil, in.node.Errorf(ErrInvalid, "the constraint %q of an input of %s can't start with '=' or '~'", in.constraint, builtinAsm)
+		}
+		ac, isAccessable := in.node.(Accessable)
+		if !isAccessable {
+			return nil, in.node.Errorf(ErrInvalid, "input %s of %s is not accessable (has no readable value)", in.node, builtinAsm)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, in.constraint)
+		args = append(args, val)
+		params = append(params, types.NewParam("", val.Type()))
+	}
+	constraints = append(constraints, clobbers...)
+
+	var ret types.Type = types.Void
+	switch len(outputTypes) {
+	case 0:
+	case 1:
+		ret = outputTypes[0]
+	default:
+		ret = types.NewStruct(outputTypes...)
+	}
+
+	asm := &ir.InlineAsm{}
+	asm.Asm = template.Value
+	asm.Constraints = strings.Join(constraints, ",")
+	asm.Typ = types.NewPointer(types.NewFunc(ret, params...))
+	asm.SideEffect = true
+	call := prog.Compiler.CurrentBlock().NewCall(asm, args...)
+
+	if len(outputs) == 0 {
+		return call, nil
+	}
+	var first value.Value
+	for i, out := range outputs {
+		var val value.Value = call
+		if len(outputs) > 1 {
+			val = prog.Compiler.CurrentBlock().NewExtractValue(call, []int64{int64(i)})
+		}
+		if _, err := out.node.(Assignable).GenAssign(prog, val); err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = val
+		}
+	}
+	return first, nil
+}
+
+// asmOperands parses the outputs or inputs of an asm, which are an array
+// literal of constraints each followed by the operand in parentheses
+func asmOperands(node Node, what string) ([]asmOperand, error) {
+	arr, ok := node.(ArrayNode)
+	if !ok {
+		return nil, node.Errorf(ErrInvalid, "the %s of %s must be an array literal, as in [\"=r\"(x)]", what, builtinAsm)
+	}
+	operands := make([]asmOperand, 0, len(arr.Elements))
+	for _, el := range arr.Elements {
+		op, ok := el.(StringFormatNode)
+		if !ok || len(op.Args) != 1 {
+			return nil, el.Errorf(ErrInvalid, "each of the %s of %s must be a constraint and an operand, as in \"r\"(x)", what, builtinAsm)
+		}
+		operands = append(operands, asmOperand{op.Format.Value, op.Args[0]})
+	}
+	return operands, nil
+}
+
+// asmClobbers parses the clobbers of an asm into llvm constraints. A
+// clobber is the name of a register, or "memory" if the asm reads or
+// writes memory its operands don't point to.
+func asmClobbers(node Node) ([]string, error) {
+	arr, ok := node.(ArrayNode)
+	if !ok {
+		return nil, node.Errorf(ErrInvalid, "the clobbers of %s must be an array literal, as in [\"memory\"]", builtinAsm)
+	}
+	clobbers := make([]string, 0, len(arr.Elements))
+	for _, el := range arr.Elements {
+		str, ok := el.(StringNode)
+		if !ok {
+			return nil, el.Errorf(ErrConstant, "the clobbers of %s must be string literals", builtinAsm)
+		}
+		clobbers = append(clobbers, fmt.Sprintf("~{%s}", str.Value))
+	}
+	return clobbers, nil
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"math/big"
 	"strconv"
 
 	"github.com/geode-lang/geode/llvm/ir/constant"
@@ -15,18 +16,57 @@ type IntNode struct {
 	Accessable
 
 	Value int64
+	// Big is the value of a literal too big for a long, which is a 128 bit
+	// integer, or nil
+	Big *big.Int
+	// Type is the type given by the suffix of the literal, or nil
+	Type *types.IntType
 }
 
 // NameString implements Node.NameString
 func (n IntNode) NameString() string { return "IntNode" }
 
+// MarshalJSON implements json.Marshaler for IntNode
+func (n IntNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for IntNode
 func (n IntNode) Codegen(prog *Program) (value.Value, error) {
 	// return llvm.ConstInt(llvm.Int64Type(), , true)
-	return constant.NewInt(n.Value, types.I64), nil
+	t := n.Type
+	if t == nil {
+		t = types.I64
+		if n.Big != nil {
+			t = types.I128
+		}
+	}
+	if n.Big != nil {
+		c := constant.NewInt(0, t)
+		c.X.Set(n.Big)
+		return c, nil
+	}
+	return constant.NewInt(n.Value, t), nil
+}
+
+// fits returns if the value of the literal can be held by an integer type
+func (n IntNode) fits(t *types.IntType) bool {
+	v := n.Big
+	if v == nil {
+		v = big.NewInt(n.Value)
+	}
+	if t.Unsigned {
+		return v.Sign() >= 0 && v.BitLen() <= t.Size
+	}
+	if v.Sign() < 0 {
+		// -x fits when x-1 does
+		v = new(big.Int).Not(v)
+	}
+	return v.BitLen() < t.Size
 }
 
 func (n IntNode) String() string {
+	if n.Big != nil {
+		return n.Big.String()
+	}
 	return strconv.FormatInt(n.Value, 10)
 }
 
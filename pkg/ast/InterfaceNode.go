@@ -0,0 +1,309 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// InterfaceNode is an interface declaration. An interface is a set of
+// methods, and a pointer to an instance of any class that implements them
+// can be stored in a value of the interface's type. Calls to the methods
+// of an interface value are dispatched at runtime through a vtable.
+//
+// An interface value is the pointer to the instance, as a byte*, and a
+// pointer to the vtable of its class for the interface. A vtable is a
+// global holding the class's method for each method of the interface.
+type InterfaceNode struct {
+	NodeType
+	TokenReference
+
+	Package *Package
+	Name    string
+	Methods []FunctionNode
+	Pub     bool // exported from its package with pub
+
+	// Type is the struct an interface value is stored in and VTable is
+	// the struct of its vtables, both created when it is declared
+	Type   *types.StructType
+	VTable *types.StructType
+}
+
+// NameString implements Node.NameString
+func (n InterfaceNode) NameString() string { return "InterfaceNode" }
+
+// MarshalJSON implements json.Marshaler for InterfaceNode
+func (n InterfaceNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n InterfaceNode) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "interface %s {", n.Name)
+	for _, m := range n.Methods {
+		fmt.Fprintf(buff, " func %s;", m.Name)
+	}
+	buff.WriteString(" }")
+	return buff.String()
+}
+
+// Declare registers the interface's type. The methods are filled in by
+// Codegen, once the classes they can refer to are declared.
+func (n InterfaceNode) Declare(prog *Program) (value.Value, error) {
+	scopeName := n.Name
+	if prog.Package.Name != "runtime" {
+		scopeName = fmt.Sprintf("%s:%s", prog.Scope.PackageName, n.Name)
+	}
+	if _, exists := prog.Interfaces[scopeName]; exists {
+		return nil, n.Errorf(ErrInvalid, "interface %s is declared more than once", n.Name)
+	}
+
+	n.VTable = types.NewStruct()
+	n.VTable.SetName(fmt.Sprintf("vtable.%s:%s", prog.Scope.PackageName, n.Name))
+	prog.Module.NewType(n.VTable.Name, n.VTable)
+
+	n.Type = types.NewStruct(types.NewPointer(types.I8), types.NewPointer(n.VTable))
+	n.Type.SetName(fmt.Sprintf("interface.%s:%s", prog.Scope.PackageName, n.Name))
+	n.Type.Names = []string{"data", "vtable"}
+	prog.Module.NewType(n.Type.Name, n.Type)
+
+	n.Package = prog.Package
+	prog.Interfaces[scopeName] = &n
+	prog.interfaceTypes[n.Type] = &n
+	prog.Scope.GetRoot().RegisterType(scopeName, n.Type, -1)
+	prog.Scope.GetRoot().SetTypeVisibility(scopeName, prog.Package.Name, visibilityOf(n.Pub))
+	return nil, nil
+}
+
+// Codegen implements Node.Codegen for InterfaceNode. It fills in the
+// vtable with the type of every method, which take the instance they
+// are called on as a byte*.
+func (n InterfaceNode) Codegen(prog *Program) (value.Value, error) {
+	iface := prog.lookupInterface(n.Name)
+
+	names := map[string]bool{}
+	for _, m := range n.Methods {
+		name := m.Name.String()
+		if names[name] {
+			return nil, m.Errorf(ErrInvalid, "interface %s has two methods named %s", n.Name, name)
+		}
+		names[name] = true
+
+		params, _, err := m.Arguments(prog)
+		if err != nil {
+			return nil, err
+		}
+		ret, err := m.ReturnType.GetType(prog)
+		if err != nil {
+			return nil, err
+		}
+		params = append([]*types.Param{types.NewParam("this", types.NewPointer(types.I8))}, params...)
+		iface.VTable.Fields = append(iface.VTable.Fields, types.NewPointer(types.NewFunc(ret, params...)))
+		iface.VTable.Names = append(iface.VTable.Names, name)
+	}
+	return nil, nil
+}
+
+// methodIndex returns the index of a method in the interface's vtable
+func (n *InterfaceNode) methodIndex(name string) int {
+	for i, m := range n.Methods {
+		if m.Name.String() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// lookupInterface returns the interface declared with a name, searched
+// for like any other type
+func (p *Program) lookupInterface(name string) *InterfaceNode {
+	for _, path := range p.GetTypeSearchPaths(name) {
+		if iface, found := p.Interfaces[path]; found {
+			return iface
+		}
+	}
+	return nil
+}
+
+// interfaceOf returns the interface a type is the value of, or nil
+func (p *Program) interfaceOf(t types.Type) *InterfaceNode {
+	s, ok := t.(*types.StructType)
+	if !ok {
+		return nil
+	}
+	return p.interfaceTypes[s]
+}
+
+// implementInterfaces checks that a class has a method for every method of
+// the interfaces it says it implements, and records that it does
+func (p *Program) implementInterfaces(class ClassNode, structDefn *types.StructType) error {
+	methods := map[string]bool{}
+	for _, fn := range class.Methods {
+		methods[fn.Name.String()] = true
+	}
+
+	for _, name := range class.Implements {
+		iface := p.lookupInterface(name)
+		// the class it extends is named along with the interfaces
+		if found, _ := p.FindType(name); iface == nil && found != nil && found == p.classParents[structDefn] {
+			continue
+		}
+		if iface == nil {
+			return class.Errorf(ErrType, "class %s implements %s, which isn't an interface", class.Name, name)
+		}
+		for _, m := range iface.Methods {
+			if !methods[m.Name.String()] {
+				return class.Errorf(ErrType, "class %s doesn't implement interface %s, it has no method %s", class.Name, iface.Name, m.Name)
+			}
+		}
+		p.implementations[structDefn] = append(p.implementations[structDefn], iface)
+	}
+	return nil
+}
+
+// convertsToInterface reports if a value of type from can be stored in a
+// value of type to, because to is an interface and from is a pointer to
+// a class that implements it or extends a class that does
+func (p *Program) convertsToInterface(from, to types.Type) bool {
+	iface := p.interfaceOf(to)
+	if iface == nil {
+		return false
+	}
+	ptr, ok := from.(*types.PointerType)
+	if !ok {
+		return false
+	}
+	class, ok := ptr.Elem.(*types.StructType)
+	if !ok {
+		return false
+	}
+	for ; class != nil; class = p.classParents[class] {
+		for _, impl := range p.implementations[class] {
+			if impl == iface {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// genInterfaceValue stores a pointer to an instance of a class in a value
+// of an interface the class implements
+func (p *Program) genInterfaceValue(in value.Value, iface *InterfaceNode) (value.Value, error) {
+	if !p.convertsToInterface(in.Type(), iface.Type) {
+		return nil, fmt.Errorf("unable to use %s as interface %s, only pointers to classes that implement it can be", in.Type(), iface.Name)
+	}
+	class := in.Type().(*types.PointerType).Elem.(*types.StructType)
+
+	vtable, err := p.vtable(class, iface)
+	if err != nil {
+		return nil, err
+	}
+
+	block := p.Compiler.CurrentBlock()
+	data := block.NewBitCast(in, types.NewPointer(types.I8))
+	val := block.NewInsertValue(constant.NewUndef(iface.Type), data, []int64{0})
+	return block.NewInsertValue(val, vtable, []int64{1}), nil
+}
+
+// vtable returns the vtable of a class for an interface it implements. It
+// is built the first time the class is used as the interface, compiling
+// the methods in it. The methods of a class in a hierarchy are called
+// through the vtable of the instance, so an override in a child is called.
+func (p *Program) vtable(class *types.StructType, iface *InterfaceNode) (*ir.Global, error) {
+	key := fmt.Sprintf("%s %s", class.Name, iface.Type.Name)
+	if vtable, found := p.vtables[key]; found {
+		return vtable, nil
+	}
+
+	className, err := p.Scope.FindTypeName(class)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]constant.Constant, 0, len(iface.Methods))
+	for i, m := range iface.Methods {
+		slot := iface.VTable.Fields[i]
+		sig := slot.(*types.PointerType).Elem.(*types.FuncType)
+
+		argTypes := []types.Type{types.NewPointer(class)}
+		for _, param := range sig.Params[1:] {
+			argTypes = append(argTypes, param.Typ)
+		}
+		var fn *ir.Function
+		if c := p.virtualClassOf(class); c != nil && c.slot(m.Name.String()) >= 0 {
+			fn, err = p.virtualMethod(c, m.Name.String())
+		} else {
+			fn, err = p.FindFunction([]string{fmt.Sprintf("%s.%s", className, m.Name)}, argTypes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("class %s doesn't implement method %s of interface %s: %s", className, m.Name, iface.Name, err)
+		}
+
+		// the method is called through the vtable, so its signature has
+		// to match exactly
+		matches := types.Equal(fn.Sig.Ret, sig.Ret) && len(fn.Sig.Params) == len(sig.Params)
+		for j := 1; matches && j < len(sig.Params); j++ {
+			matches = types.Equal(fn.Sig.Params[j].Typ, sig.Params[j].Typ)
+		}
+		if !matches {
+			return nil, fmt.Errorf("method %s of class %s has the signature %s, interface %s expects %s", m.Name, className, fn.Sig, iface.Name, sig)
+		}
+		fields = append(fields, constant.NewBitCast(fn, slot))
+	}
+
+	init := constant.NewStruct(fields...)
+	init.Typ = iface.VTable
+	vtable := p.Module.NewGlobalDef(fmt.Sprintf("vtable.%s.%s", className, iface.Name), init)
+	p.vtables[key] = vtable
+	return vtable, nil
+}
+
+// genInterfaceCall calls a method of an interface value through the
+// vtable it holds
+func genInterfaceCall(prog *Program, iface *InterfaceNode, dot DotReference, call FunctionCallNode) (value.Value, error) {
+	name := dot.Field.String()
+	index := iface.methodIndex(name)
+	if index < 0 {
+		methods := make([]string, 0, len(iface.Methods))
+		for _, m := range iface.Methods {
+			methods = append(methods, m.Name.String())
+		}
+		sort.Strings(methods)
+		return nil, call.Errorf(ErrUndefined, "interface %s has no method %s, it has %v", iface.Name, name, methods)
+	}
+
+	sig := iface.VTable.Fields[index].(*types.PointerType).Elem.(*types.FuncType)
+	if len(call.Args) != len(sig.Params)-1 {
+		return nil, call.Errorf(ErrInvalid, "method %s of interface %s takes %d arguments, given %d", name, iface.Name, len(sig.Params)-1, len(call.Args))
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	base := dot.BaseAddr(prog)
+	zero := constant.NewInt(0, types.I32)
+	data := block.NewLoad(block.NewGetElementPtr(base, zero, zero))
+	vtable := block.NewLoad(block.NewGetElementPtr(base, zero, constant.NewInt(1, types.I32)))
+	method := block.NewLoad(block.NewGetElementPtr(vtable, zero, constant.NewInt(int64(index), types.I32)))
+
+	args := []value.Value{data}
+	for i, arg := range call.Args {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, arg.Errorf(ErrInvalid, "argument to method %s is not accessable (has no readable value). Node type %s", name, arg.Kind())
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		val, err = createTypeCast(prog, val, sig.Params[i+1].Typ)
+		if err != nil {
+			return nil, arg.Diagnose(err)
+		}
+		args = append(args, val)
+	}
+
+	return prog.genCall(method, args...)
+}
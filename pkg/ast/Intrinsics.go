@@ -0,0 +1,279 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// intrinsicNamespace is the namespace the llvm intrinsics are builtins in.
+// `intrinsics:sqrt(x)` is a call to the llvm.sqrt intrinsic of the type of
+// x. The intrinsics are checked like any other call, but are overloaded on
+// the types of their arguments where llvm allows it. They are also builtins
+// in the llvm namespace, as in `llvm:sqrt(x)`.
+//
+//	intrinsics:memcpy(dest, src, len)   copies len bytes, the buffers can't overlap
+//	intrinsics:memmove(dest, src, len)  copies len bytes, the buffers can overlap
+//	intrinsics:memset(dest, b, len)     sets len bytes of dest to the byte b
+//	intrinsics:ctpop(x)                 the number of bits set in the integer x
+//	intrinsics:ctlz(x)                  the number of leading zero bits of x
+//	intrinsics:cttz(x)                  the number of trailing zero bits of x
+//	intrinsics:bswap(x)                 x with its bytes in reverse order
+//	intrinsics:sqrt(x)                  the square root of the float x
+//	intrinsics:fabs(x)                  the absolute value of x
+//	intrinsics:floor(x)                 x rounded down (also ceil, trunc, round)
+//	intrinsics:pow(x, y)                x to the power of y
+//	intrinsics:sin(x)                   the sine of x (also cos, exp, log)
+//	intrinsics:copysign(x, y)           x with the sign of y
+//	intrinsics:minnum(x, y)             the smaller of x and y (also maxnum)
+//	intrinsics:fma(a, b, c)             a * b + c, rounded once
+//	intrinsics:expect(x, v)             x, telling the optimizer it is likely v
+//
+// The float intrinsics llvm can't lower to instructions of the target
+// become calls to the c library.
+const intrinsicNamespace = "intrinsics"
+
+// llvmNamespace is the other namespace the intrinsics are builtins in
+const llvmNamespace = "llvm"
+
+// intrinsic is the signature of a builtin intrinsic
+type intrinsic struct {
+	// the names of the arguments, for error messages
+	args []string
+	// gen checks the arguments and generates the call
+	gen func(prog *Program, args []value.Value) (value.Value, error)
+}
+
+var intrinsics = map[string]intrinsic{
+	"memcpy":   {[]string{"dest", "src", "len"}, genMemIntrinsic("memcpy")},
+	"memmove":  {[]string{"dest", "src", "len"}, genMemIntrinsic("memmove")},
+	"memset":   {[]string{"dest", "byte", "len"}, genMemIntrinsic("memset")},
+	"ctpop":    {[]string{"x"}, genBitIntrinsic("ctpop")},
+	"ctlz":     {[]string{"x"}, genBitIntrinsic("ctlz")},
+	"cttz":     {[]string{"x"}, genBitIntrinsic("cttz")},
+	"bswap":    {[]string{"x"}, genBitIntrinsic("bswap")},
+	"sqrt":     {[]string{"x"}, genFloatIntrinsic("sqrt")},
+	"fabs":     {[]string{"x"}, genFloatIntrinsic("fabs")},
+	"floor":    {[]string{"x"}, genFloatIntrinsic("floor")},
+	"ceil":     {[]string{"x"}, genFloatIntrinsic("ceil")},
+	"trunc":    {[]string{"x"}, genFloatIntrinsic("trunc")},
+	"round":    {[]string{"x"}, genFloatIntrinsic("round")},
+	"pow":      {[]string{"x", "y"}, genFloatIntrinsic("pow")},
+	"sin":      {[]string{"x"}, genFloatIntrinsic("sin")},
+	"cos":      {[]string{"x"}, genFloatIntrinsic("cos")},
+	"exp":      {[]string{"x"}, genFloatIntrinsic("exp")},
+	"log":      {[]string{"x"}, genFloatIntrinsic("log")},
+	"copysign": {[]string{"x", "y"}, genFloatIntrinsic("copysign")},
+	"minnum":   {[]string{"x", "y"}, genFloatIntrinsic("minnum")},
+	"maxnum":   {[]string{"x", "y"}, genFloatIntrinsic("maxnum")},
+	"fma":      {[]string{"a", "b", "c"}, genFloatIntrinsic("fma")},
+	"expect":   {[]string{"x", "expected"}, genExpectIntrinsic},
+}
+
+// intrinsicBuiltin returns the name of the intrinsic a call is to, if it
+// is one. A function declared in a package named intrinsics or llvm takes
+// priority.
+func intrinsicBuiltin(prog *Program, n FunctionCallNode) (string, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return "", false
+	}
+	namespace, name := ParseName(ident.String())
+	if namespace != intrinsicNamespace && namespace != llvmNamespace {
+		return "", false
+	}
+	_, declared := prog.Functions[ident.String()]
+	return name, !declared
+}
+
+// genIntrinsic generates a call to one of the builtin intrinsics
+func genIntrinsic(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	namespace, _ := ParseName(n.Name.(IdentNode).String())
+	in, ok := intrinsics[name]
+	if !ok {
+		names := make([]string, 0, len(intrinsics))
+		for name := range intrinsics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, n.Errorf(ErrUndefined, "unknown intrinsic %s:%s, expected one of %s", namespace, name, strings.Join(names, ", "))
+	}
+	if len(n.Args) != len(in.args) {
+		return nil, n.Errorf(ErrInvalid, "%s:%s takes %d arguments (%s), given %d", namespace, name, len(in.args), strings.Join(in.args, ", "), len(n.Args))
+	}
+
+	args := make([]value.Value, 0, len(n.Args))
+	for _, arg := range n.Args {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, arg.Errorf(ErrInvalid, "argument %s is not accessable (has no readable value)", arg)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, val)
+	}
+
+	val, err := in.gen(prog, args)
+	if err != nil {
+		return nil, n.Errorf(ErrInvalid, "%s:%s: %s", namespace, name, err)
+	}
+	return val, nil
+}
+
+// genMemIntrinsic returns the generator of llvm.memcpy, llvm.memmove or
+// llvm.memset. They take any pointer and any integer for the length.
+func genMemIntrinsic(name string) func(*Program, []value.Value) (value.Value, error) {
+	return func(prog *Program, args []value.Value) (value.Value, error) {
+		block := prog.Compiler.CurrentBlock()
+		bytePtr := types.NewPointer(types.I8)
+
+		dest := args[0]
+		if !types.IsPointer(dest.Type()) {
+			return nil, fmt.Errorf("dest must be a pointer, given %s", dest.Type())
+		}
+		dest = block.NewBitCast(dest, bytePtr)
+
+		var src value.Value
+		var err error
+		if name != "memset" {
+			if !types.IsPointer(args[1].Type()) {
+				return nil, fmt.Errorf("src must be a pointer, given %s", args[1].Type())
+			}
+			src = block.NewBitCast(args[1], bytePtr)
+		} else {
+			if !types.IsInt(args[1].Type()) {
+				return nil, fmt.Errorf("byte must be an integer, given %s", args[1].Type())
+			}
+			if src, err = createTypeCast(prog, args[1], types.I8); err != nil {
+				return nil, err
+			}
+		}
+
+		if !types.IsInt(args[2].Type()) {
+			return nil, fmt.Errorf("len must be an integer, given %s", args[2].Type())
+		}
+		length, err := createTypeCast(prog, args[2], types.I64)
+		if err != nil {
+			return nil, err
+		}
+
+		overloads := []types.Type{dest.Type(), types.I64}
+		if name != "memset" {
+			overloads = []types.Type{dest.Type(), src.Type(), types.I64}
+		}
+		fn := declareIntrinsic(prog, name, overloads, types.Void, dest.Type(), src.Type(), types.I64, types.I1)
+		return block.NewCall(fn, dest, src, length, constant.False), nil
+	}
+}
+
+// genBitIntrinsic returns the generator of an intrinsic that counts or
+// reorders the bits of an integer of any width
+func genBitIntrinsic(name string) func(*Program, []value.Value) (value.Value, error) {
+	return func(prog *Program, args []value.Value) (value.Value, error) {
+		x := args[0]
+		if !types.IsInt(x.Type()) {
+			return nil, fmt.Errorf("x must be an integer, given %s", x.Type())
+		}
+		if name == "bswap" && x.Type().(*types.IntType).Size%16 != 0 {
+			return nil, fmt.Errorf("x must have a whole, even number of bytes, given %s", x.Type())
+		}
+		block := prog.Compiler.CurrentBlock()
+		if name == "ctlz" || name == "cttz" {
+			// a zero has as many leading or trailing zeros as it has
+			// bits, instead of being undefined
+			fn := declareIntrinsic(prog, name, []types.Type{x.Type()}, x.Type(), x.Type(), types.I1)
+			return block.NewCall(fn, x, constant.False), nil
+		}
+		fn := declareIntrinsic(prog, name, []types.Type{x.Type()}, x.Type(), x.Type())
+		return block.NewCall(fn, x), nil
+	}
+}
+
+// genFloatIntrinsic returns the generator of a float intrinsic. The
+// arguments are converted to the type of the first.
+func genFloatIntrinsic(name string) func(*Program, []value.Value) (value.Value, error) {
+	return func(prog *Program, args []value.Value) (value.Value, error) {
+		typ := args[0].Type()
+		if !types.IsFloat(typ) {
+			return nil, fmt.Errorf("expects floats, given %s", typ)
+		}
+		params := make([]types.Type, len(args))
+		for i, arg := range args {
+			if !types.IsFloat(arg.Type()) && !types.IsInt(arg.Type()) {
+				return nil, fmt.Errorf("expects floats, given %s", arg.Type())
+			}
+			converted, err := createTypeCast(prog, arg, typ)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = converted
+			params[i] = typ
+		}
+		fn := declareIntrinsic(prog, name, []types.Type{typ}, typ, params...)
+		return prog.Compiler.CurrentBlock().NewCall(fn, args...), nil
+	}
+}
+
+// genExpectIntrinsic generates a call to llvm.expect. The expected value is
+// converted to the type of x.
+func genExpectIntrinsic(prog *Program, args []value.Value) (value.Value, error) {
+	x := args[0]
+	if !types.IsInt(x.Type()) {
+		return nil, fmt.Errorf("x must be an integer or bool, given %s", x.Type())
+	}
+	if !types.IsInt(args[1].Type()) {
+		return nil, fmt.Errorf("expected must be an integer or bool, given %s", args[1].Type())
+	}
+	expected, err := createTypeCast(prog, args[1], x.Type())
+	if err != nil {
+		return nil, err
+	}
+	fn := declareIntrinsic(prog, "expect", []types.Type{x.Type()}, x.Type(), x.Type(), x.Type())
+	return prog.Compiler.CurrentBlock().NewCall(fn, x, expected), nil
+}
+
+// declareIntrinsic returns the declaration of an overload of an intrinsic,
+// adding it to the module the first time it is used. The overload is named
+// after the types it is overloaded on, as in llvm.ctpop.i32.
+func declareIntrinsic(prog *Program, name string, overloads []types.Type, ret types.Type, params ...types.Type) *ir.Function {
+	full := "llvm." + name
+	for _, t := range overloads {
+		full += "." + intrinsicTypeSuffix(t, prog.Module.OpaquePointers)
+	}
+
+	for _, fn := range prog.Module.Funcs {
+		if fn.Name == full {
+			return fn
+		}
+	}
+
+	fnParams := make([]*types.Param, len(params))
+	for i, param := range params {
+		fnParams[i] = ir.NewParam("", param)
+	}
+	return prog.Module.NewFunction(full, ret, fnParams...)
+}
+
+// intrinsicTypeSuffix returns how a type is written in the name of an
+// overloaded intrinsic, in a module with opaque pointers or not
+func intrinsicTypeSuffix(t types.Type, opaque bool) string {
+	switch t := t.(type) {
+	case *types.IntType:
+		return fmt.Sprintf("i%d", t.Size)
+	case *types.FloatType:
+		return fmt.Sprintf("f%d", t.ByteCount()*8)
+	case *types.PointerType:
+		if opaque {
+			return "p0"
+		}
+		return "p0" + intrinsicTypeSuffix(t.Elem, opaque)
+	}
+	return t.String()
+}
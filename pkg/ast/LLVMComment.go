@@ -65,3 +65,8 @@ func (inst *LLVMComment) GetParent() *ir.BasicBlock {
 func (inst *LLVMComment) SetParent(parent *ir.BasicBlock) {
 	inst.Parent = parent
 }
+
+// Lower leaves the comment out of bitcode, which has no comments.
+func (inst *LLVMComment) Lower() ir.Instruction {
+	return nil
+}
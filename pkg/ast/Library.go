@@ -0,0 +1,188 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/util"
+)
+
+// LibraryKind is the kind of library `geode build --lib` builds
+type LibraryKind string
+
+// The kinds of library that can be built
+const (
+	StaticLibrary LibraryKind = "static"
+	SharedLibrary LibraryKind = "shared"
+)
+
+// Extension returns the extension of a library of the kind on a target
+func (k LibraryKind) Extension(target *Target) string {
+	if k == StaticLibrary {
+		return ".a"
+	}
+	if strings.Contains(target.Triple, "darwin") || strings.Contains(target.Triple, "macos") {
+		return ".dylib"
+	}
+	return ".so"
+}
+
+// Library is the package a program is built into as a library, and what
+// it exports
+type Library struct {
+	Package *Package
+	// Kind is the kind of library it is built as
+	Kind LibraryKind
+
+	functions []*FunctionNode
+	classes   []*ClassNode
+	init      *ir.Function
+}
+
+// Name returns the name of the package of the library
+func (l *Library) Name() string {
+	return l.Package.Name
+}
+
+// FileName returns the name of the file the library is built into, like
+// libmath.a
+func (l *Library) FileName(target *Target) string {
+	return "lib" + l.Name() + l.Kind.Extension(target)
+}
+
+// libraryInitPrefix is the prefix of the function that initializes the
+// globals and packages of a library. Nothing runs the __init_runtime of a
+// library, so the package its interface declares calls it from its init.
+const libraryInitPrefix = "__init_library."
+
+// CompileLibrary compiles the program as a library of the package it was
+// built from. Only the @export functions of the package are visible
+// outside of it: everything else, including the library's own copy of the
+// runtime, is made internal so it can't clash with the program it is
+// linked into. It has to be called after the exports are compiled.
+func (p *Program) CompileLibrary(kind LibraryKind) (*Library, error) {
+	dir, err := filepath.Abs(p.ReduceToDir(p.Entry))
+	if err != nil {
+		return nil, err
+	}
+	lib := &Library{Kind: kind}
+
+	names := make([]string, 0, len(p.Functions))
+	for name := range p.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn := p.Functions[name]
+		if fn.Package == nil || p.packageDir(fn.Package) != dir {
+			continue
+		}
+		lib.Package = fn.Package
+		if fn.Attributes.Has(exportAttribute) {
+			lib.functions = append(lib.functions, fn)
+		}
+	}
+	if len(lib.functions) == 0 {
+		return nil, fmt.Errorf("the library in %s exports no functions, mark the ones it should with @export", dir)
+	}
+	// classes that extend others or implement interfaces would need those
+	// too, so only plain classes are part of the interface
+	names = names[:0]
+	for name := range p.Classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cls := p.Classes[name]
+		if cls.Package != nil && p.packageDir(cls.Package) == dir && len(cls.TypeParams) == 0 && len(cls.Implements) == 0 {
+			lib.classes = append(lib.classes, cls)
+		}
+	}
+
+	lib.init = p.Module.NewFunction(libraryInitPrefix+lib.Name(), types.Void)
+	p.Compiler.PushFunc(lib.init)
+	err = p.Compiler.genInBlock(lib.init.NewBlock("entry"), func() error {
+		if err := p.compileInitializations(); err != nil {
+			return err
+		}
+		p.Compiler.CurrentBlock().NewRet(nil)
+		return nil
+	})
+	p.Compiler.PopFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range p.Module.Funcs {
+		if len(fn.Blocks) > 0 && fn.Linkage != ir.LinkageExternal && fn != lib.init {
+			fn.Linkage = ir.LinkageInternal
+		}
+	}
+	for _, global := range p.Module.Globals {
+		if global.Init != nil && global.Linkage == ir.LinkageNone {
+			global.Linkage = ir.LinkageInternal
+		}
+	}
+	return lib, nil
+}
+
+// Linkages returns the c sources a library is built with. The runtime's are
+// left out, as the program the library is linked into has them already.
+func (l *Library) Linkages(p *Program) []string {
+	runtime := util.StdLibFile("runtime")
+	linkages := make([]string, 0, len(p.CLinkages))
+	for _, path := range p.CLinkages {
+		if filepath.Dir(path) != runtime {
+			linkages = append(linkages, path)
+		}
+	}
+	return linkages
+}
+
+// WriteInterface writes the geode interface of a library into the directory
+// it is built in. It is the package of the library with only the
+// declarations of what it exports, and links the library, so programs can
+// include the directory without the library's source.
+func (l *Library) WriteInterface(dir string, target *Target) error {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "# the interface of %s, generated by geode build --lib.\n", l.FileName(target))
+	fmt.Fprintf(buff, "# including this directory links the library.\n")
+	fmt.Fprintf(buff, "is %s\n\n", l.Name())
+	fmt.Fprintf(buff, "link %q\n", l.FileName(target))
+
+	for _, cls := range l.classes {
+		fmt.Fprintf(buff, "\n")
+		if cls.Pub {
+			fmt.Fprintf(buff, "pub ")
+		}
+		fmt.Fprintf(buff, "class %s {\n", cls.Name)
+		for _, field := range cls.Variables {
+			fmt.Fprintf(buff, "\t%s %s\n", field.Typ, field.Name)
+		}
+		fmt.Fprintf(buff, "}\n")
+	}
+
+	fmt.Fprintf(buff, "\n")
+	for _, fn := range l.functions {
+		args := make([]string, len(fn.Args))
+		for i, arg := range fn.Args {
+			args[i] = arg.String()
+		}
+		fmt.Fprintf(buff, "pub @%s(%q) func %s(%s) %s ...\n", symbolAttribute, fn.NameCache, fn.Name, strings.Join(args, ", "), fn.ReturnType)
+	}
+
+	fmt.Fprintf(buff, "\n@%s(%q) func __init_library() ...\n", symbolAttribute, l.init.Name)
+	fmt.Fprintf(buff, "func init {\n\t__init_library()\n}\n")
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, l.Name()+".g"), buff.Bytes(), 0644)
+}
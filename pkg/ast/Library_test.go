@@ -0,0 +1,163 @@
+package ast
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/geode-lang/geode/llvm/ir"
+)
+
+const librarySource = `is mathlib
+
+pub class Vec {
+	int x
+	int y
+}
+
+int base = 40
+
+func helper(int x) int = x + base;
+
+pub @export func add(int a, int b) int = helper(a) + b;
+
+pub @export("vec_sum") func sum(Vec* v) int = v.x + v.y;
+`
+
+const libraryInterface = `# the interface of libmathlib.a, generated by geode build --lib.
+# including this directory links the library.
+is mathlib
+
+link "libmathlib.a"
+
+pub class Vec {
+	int x
+	int y
+}
+
+pub @symbol("_XN7mathlibN3addTi32Ti32Ri32") func add(int a, int b) int ...
+pub @symbol("vec_sum") func sum(Vec* v) int ...
+
+@symbol("__init_library.mathlib") func __init_library() ...
+func init {
+	__init_library()
+}
+`
+
+func TestLibraryFileName(t *testing.T) {
+	tests := []struct {
+		kind   LibraryKind
+		triple string
+		want   string
+	}{
+		{StaticLibrary, "x86_64-pc-linux-gnu", "libmathlib.a"},
+		{StaticLibrary, "x86_64-apple-darwin19", "libmathlib.a"},
+		{SharedLibrary, "x86_64-pc-linux-gnu", "libmathlib.so"},
+		{SharedLibrary, "x86_64-apple-darwin19", "libmathlib.dylib"},
+		{SharedLibrary, "arm64-apple-macos11", "libmathlib.dylib"},
+	}
+	for _, test := range tests {
+		target, err := LookupTarget(test.triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lib := &Library{Package: &Package{Name: "mathlib"}, Kind: test.kind}
+		if got := lib.FileName(target); got != test.want {
+			t.Errorf("%s library for %s: got %s, want %s", test.kind, test.triple, got, test.want)
+		}
+	}
+}
+
+// A library is built with its interface, which a program can include
+// instead of its source
+func TestCompileLibrary(t *testing.T) {
+	stdlib, err := filepath.Abs("../../lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", stdlib)
+	ctx := context.Background()
+	target, err := LookupTarget("x86_64-pc-linux-gnu")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewProgram()
+	p.Sources = OverlaySources(FSSources("/proj", fstest.MapFS{"mathlib/mathlib.g": {Data: []byte(librarySource)}}), DiskSources)
+	if err := p.ParseDep(ctx, "", "runtime"); err != nil {
+		t.Fatal(err)
+	}
+	p.Entry = "/proj/mathlib"
+	if err := p.ParsePath(ctx, p.Entry); err != nil {
+		t.Fatalf("%s %v", err, p.Diagnostics())
+	}
+	if _, err := p.Congeal(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CompileExports(); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := p.CompileLibrary(StaticLibrary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the exports and the init of the library are visible outside it
+	linkages := map[string]ir.Linkage{
+		"_XN7mathlibN3addTi32Ti32Ri32": ir.LinkageExternal,
+		"vec_sum":                      ir.LinkageExternal,
+		"_XN7mathlibN6helperTi32Ri32":  ir.LinkageInternal,
+		"__init_library.mathlib":       ir.LinkageNone,
+		"__init_runtime":               ir.LinkageInternal,
+	}
+	for _, f := range p.Module.Funcs {
+		if want, found := linkages[f.Name]; found && f.Linkage != want {
+			t.Errorf("%s has linkage %s, want %s", f.Name, f.Linkage, want)
+		}
+	}
+	for _, global := range p.Module.Globals {
+		if global.Name == "_VN7mathlibN4base" && global.Linkage != ir.LinkageInternal {
+			t.Errorf("the global base has linkage %s, want internal", global.Linkage)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "mathlib")
+	if err := lib.WriteInterface(dir, target); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, "mathlib.g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != libraryInterface {
+		t.Errorf("got the interface\n%s\nwant\n%s", written, libraryInterface)
+	}
+
+	// the program calls the library by the symbols in its interface
+	app := fstest.MapFS{"app/main.g": {Data: []byte("is main\n\ninclude \"mathlib\"\n\nfunc main int {\n\treturn mathlib:add(1, 2);\n}\n")}}
+	m, diagnostics, err := Compile(ctx, Source{Path: "/proj/app/main.g"}, CompileOptions{
+		Target:      target,
+		Sources:     OverlaySources(FSSources("/proj", app), DiskSources),
+		SearchPaths: []string{filepath.Dir(dir)},
+	})
+	if err != nil {
+		t.Fatalf("%s %v", err, diagnostics)
+	}
+	for _, name := range []string{"_XN7mathlibN3addTi32Ti32Ri32", "__init_library.mathlib"} {
+		found := false
+		for _, f := range m.Funcs {
+			if f.Name == name {
+				found = true
+				if len(f.Blocks) > 0 {
+					t.Errorf("%s is compiled into the program, want it declared", name)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("%s is not declared in the program", name)
+		}
+	}
+}
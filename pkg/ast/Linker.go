@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/geode-lang/geode/pkg/arg"
@@ -30,7 +31,9 @@ type Linker struct {
 	target      CompileTarget
 	buildDir    string
 	objectPaths []string
-	optimize    int
+	toolchain   Toolchain
+	// library is set when a library is built instead of a binary
+	library bool
 }
 
 // NewLinker constructs a linker with an outpu
@@ -75,157 +78,111 @@ func (l *Linker) SetOutput(path string) {
 	l.output = path
 }
 
-// SetOptimize -
-func (l *Linker) SetOptimize(o int) {
-	l.optimize = o
+// SetLibrary makes the linker build a library, of the kind in the options
+// of its toolchain, instead of a binary
+func (l *Linker) SetLibrary(library bool) {
+	l.library = library
+}
+
+// SetToolchain sets the toolchain that builds the output
+func (l *Linker) SetToolchain(t Toolchain) {
+	l.toolchain = t
 }
 
 // Cleanup removes all the
 func (l *Linker) Cleanup() {
 	for _, objFile := range l.objectPaths {
 		ext := filepath.Ext(objFile)
-		// We only remove ll and s files.
-		if ext == ".ll" || ext == ".s" {
+		// We only remove ll, bc and s files.
+		if ext == ".ll" || ext == ".bc" || ext == ".s" {
 			os.Remove(objFile)
 		}
 
 	}
 }
 
-// Run a list of objects through a linker and build
-// into a single outfile with the given target
-func (l *Linker) Run() {
-	linker := "clang"
-	linkArgs := make([]string, 0)
-
-	optString := fmt.Sprintf("-O%d", l.optimize)
+// CObjectPath returns the path in the build directory a c source file is
+// compiled to
+func CObjectPath(buildDir, src string) string {
+	outbase := path.Join(buildDir, src)
+	return strings.TrimSuffix(outbase, filepath.Ext(outbase)) + ".o"
+}
 
-	if l.optimize > 0 && l.optimize <= 3 {
-		linkArgs = append(linkArgs, optString)
+// isLibrary returns if a path linked into a binary is a static or shared
+// library
+func isLibrary(path string) bool {
+	switch filepath.Ext(path) {
+	case ".a", ".so", ".dylib":
+		return true
 	}
+	return false
+}
 
-	filename := l.output
-
+// Run a list of objects through the linker's toolchain and build
+// into a single outfile with the given target
+func (l *Linker) Run() error {
 	hadAlternateEmission := false
 
-	if *arg.EmitASM {
-		hadAlternateEmission = true
-		log.Timed("Assembly Generation", func() {
-			// We want to only write intel syntax. AT&T Sucks
-			asmArgs := append(linkArgs, "-S", "-masm=intel", "-Wno-everything")
-			// Compile each of the objects to a .s file.
-			for _, obj := range l.objectPaths {
-				// We only want to leave user generated files in the filesystem
-				if strings.HasSuffix(obj, ".ll") {
-					// ext := path.Ext(obj)
-					out := path.Base(strings.Replace(obj, path.Ext(obj), ".s", -1))
-					util.RunCommandStr(linker, append(asmArgs, "-o", out, obj)...)
-				}
-			}
-		})
-
-	}
-
-	if *arg.EmitLLVM {
+	emit := func(enabled bool, name string, format EmitFormat) {
+		if !enabled {
+			return
+		}
 		hadAlternateEmission = true
-
-		log.Timed("LLVM Generation", func() {
-			// Compile each of the objects to a .s file.
+		log.Timed(name, func() {
 			for _, obj := range l.objectPaths {
 				// We only want to leave user generated files in the filesystem
-				if strings.HasSuffix(obj, ".ll") {
-					out := path.Base(strings.Replace(obj, path.Ext(obj), ".ll", -1))
-
-					res, err := util.RunCommandStr(linker, append(linkArgs, "-S", "-emit-llvm", "-o", out, obj)...)
-					if err != nil {
-						log.Error("Failed with llvm generation:\n")
-						fmt.Println(res)
+				if ext := path.Ext(obj); ext == ".ll" || ext == ".bc" {
+					out := path.Base(strings.Replace(obj, path.Ext(obj), format.Extension(), -1))
+					if err := l.toolchain.Emit(format, obj, out); err != nil {
+						log.Error("Failed with %s:\n%s\n", strings.ToLower(name), err)
 					}
 				}
 			}
 		})
 	}
 
-	if *arg.EmitObject {
-		hadAlternateEmission = true
-
-		log.Timed("Object File Generation", func() {
-			// Compile each of the objects to a .s file.
-			for _, obj := range l.objectPaths {
-
-				// We only want to leave user generated files in the filesystem
-				if strings.HasSuffix(obj, ".ll") {
+	emit(*arg.EmitASM, "Assembly Generation", EmitAssembly)
+	emit(*arg.EmitLLVM, "LLVM Generation", EmitLLVM)
+	emit(*arg.EmitObject, "Object File Generation", EmitObject)
 
-					out := path.Base(strings.Replace(obj, path.Ext(obj), ".o", -1))
-					util.RunCommandStr(linker, append(linkArgs, "-c", "-o", out, obj)...)
-				}
-			}
-		})
+	if hadAlternateEmission {
+		return nil
 	}
 
-	linkArgs = append(linkArgs, "--std=c99", "-lm", "-lc", "-lgc", "-pthread", "-DREDIRECT_MALLOC=xmalloc", "-DIGNORE_FREE")
-
-	if !hadAlternateEmission {
-		for i, obj := range l.objectPaths {
-			outbase := path.Join(l.buildDir, obj)
-
-			extension := filepath.Ext(outbase)
-			if extension == ".ll" {
-			}
-
-			if extension == ".a" {
-
-			}
-
-			if extension == ".c" {
-				outbase = outbase[0 : len(outbase)-len(extension)]
+	for i, obj := range l.objectPaths {
+		if filepath.Ext(obj) == ".c" {
+			objFile := CObjectPath(l.buildDir, obj)
+			outbase := strings.TrimSuffix(objFile, ".o")
+			cachefile := outbase + ".cache"
 
-				cachefile := outbase + ".cache"
-				objFile := outbase + ".o"
+			// objects built by another toolchain, for another target, with
+			// other paths in them or with other flags can't be reused
+			hash := fmt.Sprintf("%s %s %s %t %s", util.HashFile(obj), l.toolchain.Name(), l.toolchain.Target(), util.TrimPaths, strings.Join(l.toolchain.CompileCCommand("", ""), " "))
 
-				hash := util.HashFile(obj)
+			cachedat, err := ioutil.ReadFile(cachefile)
+			if err != nil || strings.Compare(string(cachedat), hash) != 0 {
 
-				cachedat, err := ioutil.ReadFile(cachefile)
-				if err != nil || strings.Compare(string(cachedat), hash) != 0 {
+				os.MkdirAll(path.Dir(outbase), os.ModePerm)
 
-					os.MkdirAll(path.Dir(outbase), os.ModePerm)
-
-					// fmt.Printf("\tCC\t%s\n", path.Base(obj))
-					// the file doesnt exist, we need to compile it
-					out, err := util.RunCommand("clang", "-O3", "--std=c99", "-c", "-o", objFile, obj)
-					if err != nil {
-						log.Fatal("(%s) %s\n", err, string(out))
-					}
-					ioutil.WriteFile(cachefile, []byte(hash), os.ModePerm)
+				// the file doesnt exist, we need to compile it
+				if err := l.toolchain.CompileC(obj, objFile); err != nil {
+					return err
 				}
-				l.objectPaths[i] = objFile
+				ioutil.WriteFile(cachefile, []byte(hash), os.ModePerm)
 			}
-
-		}
-
-		// return
-
-		// Append input files to the end of the command
-		linkArgs = append(linkArgs, l.objectPaths...)
-
-		if *arg.EnableDebug {
-			linkArgs = append(linkArgs, "-g")
+			l.objectPaths[i] = objFile
 		}
+	}
 
-		// set the output filename
-		linkArgs = append(linkArgs, "-o", filename)
-
-		if *arg.ClangFlags != "" {
-			userArgs := strings.Split(*arg.ClangFlags, " ")
-			linkArgs = append(linkArgs, userArgs...)
-		}
+	// libraries are only searched for what the inputs before them use, so
+	// they are linked after everything else
+	sort.SliceStable(l.objectPaths, func(i, j int) bool {
+		return !isLibrary(l.objectPaths[i]) && isLibrary(l.objectPaths[j])
+	})
 
-		out, err := util.RunCommand(linker, linkArgs...)
-		if err != nil {
-			log.Fatal("failed to run command `%s %s`: `%s`\n\n%s",
-				linker, strings.Join(linkArgs, " "),
-				err.Error(), string(out))
-		}
+	link := l.toolchain.Link
+	if l.library {
+		link = l.toolchain.LinkLibrary
 	}
-
+	return link(l.objectPaths, l.output)
 }
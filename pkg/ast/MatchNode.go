@@ -0,0 +1,297 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// MatchArm is one arm of a match statement
+type MatchArm struct {
+	TokenReference
+
+	Values []Node
+	Body   Node
+}
+
+// MatchNode is a match statement. It runs the body of the first arm with
+// a value equal to the matched value, or the else arm if none are. There
+// is no fallthrough between arms. A match on a union runs the arm of the
+// variant it holds, see UnionNode.
+type MatchNode struct {
+	NodeType
+	TokenReference
+
+	Value Node
+	Arms  []MatchArm
+	Else  Node
+	Index int
+}
+
+func (n MatchNode) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "match %s {", n.Value)
+	for _, arm := range n.Arms {
+		for i, val := range arm.Values {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			fmt.Fprintf(buff, " %s", val)
+		}
+		fmt.Fprintf(buff, " %s", arm.Body)
+	}
+	if n.Else != nil {
+		fmt.Fprintf(buff, " else %s", n.Else)
+	}
+	buff.WriteString(" }")
+	return buff.String()
+}
+
+// NameString implements Node.NameString
+func (n MatchNode) NameString() string { return "MatchNode" }
+
+// MarshalJSON implements json.Marshaler for MatchNode
+func (n MatchNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+// Codegen implements Node.Codegen for MatchNode. When the matched value is
+// an integer and every arm's values are integer constants the match is a
+// single llvm switch, which llvm turns into a jump table where it can.
+// Otherwise the arms are compared one after another, in order. A match on
+// a union is a switch on its tag.
+func (n MatchNode) Codegen(prog *Program) (value.Value, error) {
+	subject, err := n.Value.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	if types.IsVector(subject.Type()) {
+		return nil, n.Errorf(ErrInvalid, "unable to match on vector %s", subject.Type())
+	}
+
+	if err := n.checkExhaustive(prog); err != nil {
+		return nil, err
+	}
+
+	namePrefix := fmt.Sprintf("match.%d.", n.Index)
+	parentBlock := prog.Compiler.CurrentBlock()
+	parentFunc := parentBlock.Parent
+
+	var cases []matchCase
+	var isSwitch bool
+	// a union is switched on by its tag, and kept in memory so the arms
+	// can bind its fields
+	union := prog.unionOf(subject.Type())
+	var unionArms []unionArm
+	var unionAlloc *ir.InstAlloca
+	if union != nil {
+		cases, unionArms, err = n.unionCases(prog, union)
+		isSwitch = true
+		unionAlloc = createBlockAlloca(parentFunc, subject.Type(), "")
+		parentBlock.NewStore(subject, unionAlloc)
+		subject = parentBlock.NewExtractValue(subject, []int64{0})
+	} else {
+		cases, isSwitch, err = n.switchCases(prog, subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the first and last block of every arm, so they can branch to the end
+	armBlks := make([]*ir.BasicBlock, len(n.Arms))
+	armGenBlks := make([]*ir.BasicBlock, len(n.Arms))
+	for i, arm := range n.Arms {
+		armBlks[i] = parentFunc.NewBlock(mangleName(fmt.Sprintf("%sarm.%d", namePrefix, i)))
+		err := prog.Compiler.genInBlock(armBlks[i], func() error {
+			if union != nil {
+				prog.ScopeDown(arm.Token)
+				if err := prog.bindUnionFields(union, unionArms[i], unionAlloc); err != nil {
+					return err
+				}
+			}
+			gen, gerr := arm.Body.Codegen(prog)
+			if gerr != nil {
+				return gerr
+			}
+			armGenBlks[i] = gen.(*ir.BasicBlock)
+			if union != nil {
+				return prog.ScopeUp()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	elseBlk := parentFunc.NewBlock(mangleName(namePrefix + "else"))
+	var elseGenBlk *ir.BasicBlock
+	err = prog.Compiler.genInBlock(elseBlk, func() error {
+		if n.Else != nil {
+			gen, gerr := n.Else.Codegen(prog)
+			if gerr != nil {
+				return gerr
+			}
+			elseGenBlk, _ = gen.(*ir.BasicBlock)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if isSwitch {
+		for _, c := range cases {
+			c.Target = armBlks[c.arm]
+		}
+		switchCases := make([]*ir.Case, len(cases))
+		for i, c := range cases {
+			switchCases[i] = c.Case
+		}
+		parentBlock.NewSwitch(subject, elseBlk, switchCases...)
+	} else if err := n.genComparisons(prog, subject, parentBlock, armBlks, elseBlk); err != nil {
+		return nil, err
+	}
+
+	endBlk := parentFunc.NewBlock(mangleName(namePrefix + "end"))
+	prog.Compiler.PushBlock(endBlk)
+
+	for i := range n.Arms {
+		armBlks[i].BranchIfNoTerminator(endBlk)
+		armGenBlks[i].BranchIfNoTerminator(endBlk)
+	}
+	elseBlk.BranchIfNoTerminator(endBlk)
+	if elseGenBlk != nil {
+		elseGenBlk.BranchIfNoTerminator(endBlk)
+	}
+
+	return endBlk, nil
+}
+
+// checkExhaustive makes sure a match on the members of an enum that has
+// no else arm has an arm for every member
+func (n MatchNode) checkExhaustive(prog *Program) error {
+	if n.Else != nil {
+		return nil
+	}
+
+	var enum *EnumNode
+	covered := make(map[int64]bool)
+	for _, arm := range n.Arms {
+		for _, val := range arm.Values {
+			ident, ok := val.(IdentNode)
+			if !ok {
+				return nil
+			}
+			e, v, isMember := prog.enumMember(ident.Value)
+			if !isMember || (enum != nil && e != enum) {
+				return nil
+			}
+			enum = e
+			covered[v] = true
+		}
+	}
+	if enum == nil {
+		return nil
+	}
+
+	missing := make([]string, 0)
+	for _, name := range enum.memberNames() {
+		if !covered[enum.Values[name]] {
+			missing = append(missing, fmt.Sprintf("%s:%s", enum.Name, name))
+		}
+	}
+	if len(missing) > 0 {
+		return n.Errorf(ErrInvalid, "match on enum %s doesn't handle %s. Add arms for them or an else arm", enum.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// matchCase is a case of the switch a match is compiled to, and the arm
+// it belongs to
+type matchCase struct {
+	*ir.Case
+	arm int
+}
+
+// switchCases returns the cases of the switch a match can be compiled to,
+// or false if it has to be compiled to comparisons instead
+func (n MatchNode) switchCases(prog *Program, subject value.Value) ([]matchCase, bool, error) {
+	typ, ok := subject.Type().(*types.IntType)
+	if !ok {
+		return nil, false, nil
+	}
+
+	cases := make([]matchCase, 0)
+	seen := make(map[int64]bool)
+	for i, arm := range n.Arms {
+		for _, val := range arm.Values {
+			folded, ok := FoldConstant(prog, val)
+			if !ok {
+				return nil, false, nil
+			}
+			c, ok := folded.(int64)
+			if !ok {
+				return nil, false, nil
+			}
+			if seen[c] {
+				return nil, false, val.Errorf(ErrInvalid, "value %d is matched by more than one arm", c)
+			}
+			seen[c] = true
+			cases = append(cases, matchCase{ir.NewCase(constant.NewInt(c, typ), nil), i})
+		}
+	}
+	return cases, true, nil
+}
+
+// genComparisons compares the matched value with the values of every arm
+// in order, branching to the first arm with an equal value
+func (n MatchNode) genComparisons(prog *Program, subject value.Value, blk *ir.BasicBlock, armBlks []*ir.BasicBlock, elseBlk *ir.BasicBlock) error {
+	parentFunc := blk.Parent
+	namePrefix := fmt.Sprintf("match.%d.", n.Index)
+
+	for i, arm := range n.Arms {
+		next := elseBlk
+		if i < len(n.Arms)-1 {
+			next = parentFunc.NewBlock(mangleName(fmt.Sprintf("%stest.%d", namePrefix, i+1)))
+		}
+
+		err := prog.Compiler.genInBlock(blk, func() error {
+			var matched value.Value
+			for _, val := range arm.Values {
+				v, err := val.Codegen(prog)
+				if err != nil {
+					return err
+				}
+				if types.IsVector(v.Type()) {
+					return val.Errorf(ErrInvalid, "unable to match on vector %s", v.Type())
+				}
+				l, r, t, _ := binaryCast(prog, subject, v)
+				if l == nil || r == nil {
+					return val.Errorf(ErrType, "unable to compare %s with %s in match", subject.Type(), v.Type())
+				}
+				cur := prog.Compiler.CurrentBlock()
+				eq := createCmp(cur, ir.IntEQ, ir.FloatOEQ, t, l, r)
+				if matched == nil {
+					matched = eq
+				} else {
+					matched = cur.NewOr(matched, eq)
+				}
+			}
+			prog.Compiler.CurrentBlock().NewCondBr(matched, armBlks[i], next)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		blk = next
+	}
+
+	if len(n.Arms) == 0 {
+		blk.NewBr(elseBlk)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// NewNode is `new(T)`, which allocates a T with the garbage collector and
+// returns a pointer to it. The memory is zeroed, and is reclaimed once
+// nothing points to it anymore. With --arc, instances of classes are also
+// reference counted, see Arc.go.
+type NewNode struct {
+	NodeType
+	TokenReference
+
+	T TypeNode
+}
+
+// NameString implements Node.NameString
+func (n NewNode) NameString() string { return "NewNode" }
+
+// MarshalJSON implements json.Marshaler for NewNode
+func (n NewNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n NewNode) String() string {
+	return fmt.Sprintf("new(%s)", n.T)
+}
+
+// Codegen implements Node.Codegen for NewNode
+func (n NewNode) Codegen(prog *Program) (value.Value, error) {
+	if prog.NoRuntime {
+		return nil, n.Errorf(ErrInvalid, "new allocates with the runtime, which is disabled by --no-runtime")
+	}
+	t, err := n.T.GetType(prog)
+	if err != nil {
+		return nil, n.Diagnose(err)
+	}
+	if types.Equal(t, types.Void) {
+		return nil, n.Errorf(ErrType, "unable to allocate a void")
+	}
+	size, err := prog.sizeOf(t)
+	if err != nil {
+		return nil, n.Diagnose(err)
+	}
+	var buf value.Value
+	if st, isClass := t.(*types.StructType); isClass && prog.arcCounted(types.NewPointer(t)) {
+		buf, err = prog.genArcAlloc(st, constant.NewInt(size, types.I64))
+	} else {
+		buf, err = prog.genAlloc(t, constant.NewInt(size, types.I32))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return prog.Compiler.CurrentBlock().NewBitCast(buf, types.NewPointer(t)), nil
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n NewNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
+
+// genAlloc allocates memory for values of a type with the garbage
+// collector. Memory that can't hold a pointer is allocated with
+// xmalloc_atomic, which the collector never scans, so numbers in it that
+// happen to look like addresses don't keep other memory alive.
+func (p *Program) genAlloc(t types.Type, size value.Value) (value.Value, error) {
+	name := "xmalloc"
+	if !typeHasPointers(t) {
+		name = "xmalloc_atomic"
+	}
+	call, err := p.NewRuntimeFunctionCall(name, size)
+	if err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// typeHasPointers returns if a value of a type can hold a pointer the
+// garbage collector has to follow. The payload of a union is stored as
+// integers, so unions are assumed to hold one.
+func typeHasPointers(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.PointerType, *types.FuncType:
+		return true
+	case *types.SliceType:
+		return true
+	case *types.ArrayType:
+		return typeHasPointers(t.Elem)
+	case *types.VectorType:
+		return typeHasPointers(t.Elem)
+	case *types.StructType:
+		if strings.HasPrefix(t.Name, "union.") {
+			return true
+		}
+		for _, field := range t.Fields {
+			if typeHasPointers(field) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/debug"
+)
+
+// CheckNils is the instrumentation pass of debug builds. Every load and
+// store through a pointer is guarded by a comparison against nil, and a nil
+// pointer calls panic_nil in the runtime with the file and line of the
+// statement that dereferenced it rather than crashing with a segfault.
+// Pointers to variables and globals can't be nil and aren't checked, and a
+// pointer is only checked once in a block. It runs once everything is
+// compiled, over the functions with debug information, as their
+// instructions know where they came from.
+func (p *Program) CheckNils() error {
+	d := p.debugInfo()
+	if d == nil || p.NoRuntime {
+		return nil
+	}
+	for _, fn := range p.Module.Funcs {
+		if fn.Metadata["dbg"] == nil {
+			continue
+		}
+		if err := p.checkNils(d, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Program) checkNils(d *debug.Builder, fn *ir.Function) error {
+	p.Compiler.PushFunc(fn)
+	defer p.Compiler.PopFunc()
+
+	// a block is split at every check, and the pointers checked before the
+	// split are still checked in the block it continues in
+	carried := make(map[*ir.BasicBlock]map[value.Value]bool)
+	for i := 0; i < len(fn.Blocks); i++ {
+		block := fn.Blocks[i]
+		checked := carried[block]
+		if checked == nil {
+			checked = make(map[value.Value]bool)
+		}
+		for j, inst := range block.Insts {
+			ptr, md := dereferenced(inst)
+			base := nilCheckBase(ptr)
+			if base == nil || checked[base] {
+				continue
+			}
+			pos, found := d.Position(md["dbg"])
+			if !found {
+				continue
+			}
+			checked[base] = true
+			rest, err := p.splitNilCheck(fn, i, j, base, md["dbg"], pos)
+			if err != nil {
+				return err
+			}
+			carried[rest] = checked
+			break
+		}
+	}
+	return nil
+}
+
+// dereferenced returns the pointer an instruction loads or stores through,
+// and the metadata of the instruction
+func dereferenced(inst ir.Instruction) (value.Value, map[string]*metadata.Metadata) {
+	switch inst := inst.(type) {
+	case *ir.InstLoad:
+		return inst.Src, inst.Metadata
+	case *ir.InstStore:
+		return inst.Dst, inst.Metadata
+	case *ir.InstAtomicRMW:
+		return inst.Dst, inst.Metadata
+	case *ir.InstCmpXchg:
+		return inst.Ptr, inst.Metadata
+	}
+	return nil, nil
+}
+
+// nilCheckBase returns the pointer that has to be checked before another
+// is dereferenced, which is the one it is offset from or cast from. A
+// field of a nil pointer isn't at address 0, but is still a nil pointer
+// dereference. It is nil if the pointer can't be nil.
+func nilCheckBase(ptr value.Value) value.Value {
+	for {
+		switch v := ptr.(type) {
+		case nil:
+			return nil
+		case *ir.InstGetElementPtr:
+			ptr = v.Src
+		case *ir.InstBitCast:
+			ptr = v.From
+		case *constant.ExprGetElementPtr:
+			ptr = v.Src
+		case *constant.ExprBitCast:
+			ptr = v.From
+		case *ir.InstAlloca, *ir.Global:
+			return nil
+		case *constant.Null:
+			return v
+		case constant.Constant:
+			return nil
+		default:
+			if _, isPointer := ptr.Type().(*types.PointerType); !isPointer {
+				return nil
+			}
+			return ptr
+		}
+	}
+}
+
+// splitNilCheck splits the ith block of a function before its jth
+// instruction, which is only run if base isn't nil. The block it continues
+// in is returned.
+func (p *Program) splitNilCheck(fn *ir.Function, i, j int, base value.Value, loc *metadata.Metadata, pos debug.FileInfo) (*ir.BasicBlock, error) {
+	block := fn.Blocks[i]
+	okBlk := ir.NewBlock(mangleName("nil.ok"))
+	failBlk := ir.NewBlock(mangleName("nil.fail"))
+	okBlk.Parent = fn
+	failBlk.Parent = fn
+
+	rest := append([]ir.Instruction{}, block.Insts[j:]...)
+	block.Insts = block.Insts[:j]
+	for _, inst := range rest {
+		okBlk.AppendInst(inst)
+	}
+	okBlk.SetTerm(block.Term)
+
+	// phis further on now come from the block the rest continues in
+	for _, next := range okBlk.Term.Succs() {
+		for _, inst := range next.Insts {
+			phi, ok := inst.(*ir.InstPhi)
+			if !ok {
+				break
+			}
+			for _, inc := range phi.Incs {
+				if inc.Pred == block {
+					inc.Pred = okBlk
+				}
+			}
+		}
+	}
+
+	isNil := block.NewICmp(ir.IntEQ, base, constant.NewNull(base.Type().(*types.PointerType)))
+	isNil.Metadata["dbg"] = loc
+	br := block.NewCondBr(isNil, failBlk, okBlk)
+	br.Metadata["dbg"] = loc
+
+	p.Compiler.PushBlock(failBlk)
+	defer p.Compiler.PopBlock()
+	file := formatStringConstant(p, pos.Path)
+	call, err := p.NewRuntimeFunctionCall("panic_nil", file, constant.NewInt(int64(pos.Line), types.I32))
+	if err != nil {
+		return nil, err
+	}
+	call.Metadata["dbg"] = loc
+	failBlk.NewUnreachable().Metadata["dbg"] = loc
+
+	blocks := append([]*ir.BasicBlock{}, fn.Blocks[:i+1]...)
+	blocks = append(blocks, okBlk, failBlk)
+	fn.Blocks = append(blocks, fn.Blocks[i+1:]...)
+	return okBlk, nil
+}
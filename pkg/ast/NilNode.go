@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir/constant"
-	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
 
@@ -17,9 +16,12 @@ type NilNode struct {
 // NameString implements Node.NameString
 func (n NilNode) NameString() string { return "NilNode" }
 
+// MarshalJSON implements json.Marshaler for NilNode
+func (n NilNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for NilNode
 func (n NilNode) Codegen(prog *Program) (value.Value, error) {
-	return constant.NewNull(types.NewPointer(types.I8)), nil
+	return constant.NewNull(prog.nilType), nil
 }
 
 // GenAccess implements Accessable.GenAccess
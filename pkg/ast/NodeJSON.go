@@ -0,0 +1,200 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// The ast is marshaled to json for editors and other tools, and to debug
+// the parser with --emit-ast. A node is an object with the kind of node
+// it is, where it is and its fields, named in snake case:
+//
+//	{"node": "IntNode", "file": "main.g", "line": 3, "column": 9, "value": 1, ...}
+//
+// Nodes the parser made up, which have no token, have no file, line or
+// column. The bodies of functions are parsed for it, and the state the
+// compiler keeps on nodes, like their packages and the ir of functions,
+// is left out. llvm types are written as they are in ir.
+
+var (
+	typeType         = reflect.TypeOf((*types.Type)(nil)).Elem()
+	marshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	tokenRefType     = reflect.TypeOf(TokenReference{})
+	functionNodeType = reflect.TypeOf(FunctionNode{})
+	// compilerState are the types of fields that aren't part of the code
+	compilerState = map[reflect.Type]bool{
+		reflect.TypeOf((*Package)(nil)): true,
+		reflect.TypeOf((*Parser)(nil)):  true,
+	}
+)
+
+// marshalNode implements json.Marshaler for the nodes of the ast
+func marshalNode(n Node) ([]byte, error) {
+	return marshalStruct(reflect.ValueOf(n))
+}
+
+// marshalStruct marshals a node, or a struct in one, as an object of its
+// fields
+func marshalStruct(v reflect.Value) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	field := func(name string, data []byte) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%q:%s", name, data)
+	}
+
+	if n, isNode := v.Interface().(Node); isNode {
+		name, _ := json.Marshal(n.NameString())
+		field("node", name)
+	}
+	if ref, found := v.Type().FieldByName(tokenRefType.Name()); found && ref.Type == tokenRefType {
+		tok := v.FieldByIndex(ref.Index).Interface().(TokenReference).Token
+		if tok.SourcePath() != "" {
+			file, _ := json.Marshal(filepath.Clean(tok.SourcePath()))
+			field("file", file)
+			field("line", []byte(fmt.Sprint(tok.Line)))
+			field("column", []byte(fmt.Sprint(tok.StartColumn())))
+		}
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.PkgPath != "" || f.Anonymous || isCompilerState(f.Type) {
+			continue
+		}
+		value := v.Field(i)
+		if v.Type() == functionNodeType && f.Name == "Body" {
+			value = reflect.ValueOf(v.Interface().(FunctionNode).ParseBody())
+		}
+		data, err := marshalValue(value)
+		if err != nil {
+			return nil, err
+		}
+		field(snakeCase(f.Name), data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalValue marshals the value of a field of a node
+func marshalValue(v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			if v.Kind() == reflect.Slice {
+				return []byte("[]"), nil
+			}
+			return []byte("null"), nil
+		}
+	}
+	if v.Type().Implements(typeType) {
+		return json.Marshal(v.Interface().(types.Type).String())
+	}
+	if v.Type().Implements(marshalerType) {
+		return json.Marshal(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return marshalValue(v.Elem())
+	case reflect.Struct:
+		return marshalStruct(v)
+	case reflect.Slice, reflect.Array:
+		elems := make([]json.RawMessage, v.Len())
+		for i := range elems {
+			data, err := marshalValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = data
+		}
+		return json.Marshal(elems)
+	case reflect.Map:
+		elems := make(map[string]json.RawMessage, v.Len())
+		for _, key := range v.MapKeys() {
+			data, err := marshalValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			elems[fmt.Sprint(key.Interface())] = data
+		}
+		return json.Marshal(elems)
+	}
+	return json.Marshal(v.Interface())
+}
+
+// isCompilerState returns if fields of a type are state of the compiler,
+// like the package of a node or the ir it was compiled to
+func isCompilerState(t reflect.Type) bool {
+	if compilerState[t] || t.Implements(valueType) {
+		return true
+	}
+	if t.Kind() == reflect.Map || t.Kind() == reflect.Slice {
+		return t.Elem().Implements(valueType)
+	}
+	return false
+}
+
+// snakeCase returns the name of a field in snake case, like type_params
+// for TypeParams and c_linkage for CLinkage
+func snakeCase(name string) string {
+	runes := []rune(name)
+	buf := &strings.Builder{}
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				buf.WriteByte('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// MarshalJSON implements json.Marshaler for TypeNode, which is written as
+// it is in the source
+func (n TypeNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.String())
+}
+
+// WriteAST writes the nodes of every file the program parsed to w as
+// json, for --emit-ast. The files are in an array sorted by their paths,
+// like {"file": "main.g", "package": "main", "nodes": [...]}.
+func (p *Program) WriteAST(w io.Writer) error {
+	paths := make([]string, 0, len(p.Packages))
+	for path := range p.Packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	type fileAST struct {
+		File    string `json:"file"`
+		Package string `json:"package"`
+		Nodes   []Node `json:"nodes"`
+	}
+	files := make([]fileAST, len(paths))
+	for i, path := range paths {
+		pkg := p.Packages[path]
+		files[i] = fileAST{filepath.Clean(path), pkg.Name, pkg.Nodes}
+	}
+
+	data, err := json.MarshalIndent(files, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,66 @@
+package ast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteAST(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	p := NewProgram()
+	if err := p.ParseText(context.Background(), "is main\n\nfunc main int {\n\treturn 1;\n}\n", "/proj/main.g"); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := p.WriteAST(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	type node struct {
+		Node       string `json:"node"`
+		File       string `json:"file"`
+		Line       int    `json:"line"`
+		Column     int    `json:"column"`
+		ReturnType string `json:"return_type"`
+		Body       *struct {
+			Node  string `json:"node"`
+			Nodes []node `json:"nodes"`
+		} `json:"body"`
+	}
+	var files []struct {
+		File    string `json:"file"`
+		Package string `json:"package"`
+		Nodes   []node `json:"nodes"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &files); err != nil {
+		t.Fatalf("%s in\n%s", err, buf)
+	}
+	if len(files) != 1 || files[0].File != "/proj/main.g" || files[0].Package != "main" || len(files[0].Nodes) != 2 {
+		t.Fatalf("got %+v, want the two nodes of main in /proj/main.g", files)
+	}
+	fn := files[0].Nodes[1]
+	if fn.Node != "FunctionNode" || fn.File != "/proj/main.g" || fn.Line != 3 || fn.Column != 1 || fn.ReturnType != "int" {
+		t.Errorf("got %+v, want the function main at 3:1 returning int", fn)
+	}
+	if fn.Body == nil || fn.Body.Node != "BlockNode" || len(fn.Body.Nodes) != 1 || fn.Body.Nodes[0].Node != "ReturnNode" || fn.Body.Nodes[0].Line != 4 {
+		t.Errorf("got the body %+v, want a block of a return on line 4", fn.Body)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Name", "name"},
+		{"TypeParams", "type_params"},
+		{"CLinkage", "c_linkage"},
+		{"IsMethod", "is_method"},
+		{"ID", "id"},
+	}
+	for _, test := range tests {
+		if got := snakeCase(test.name); got != test.want {
+			t.Errorf("snakeCase(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -22,17 +22,33 @@ type TokenReference struct {
 	Token lexer.Token
 }
 
+func (t TokenReference) token() lexer.Token {
+	return t.Token
+}
+
 // SyntaxError -
 func (t TokenReference) SyntaxError() {
 
 	t.Token.SyntaxError()
 }
 
+// Errorf returns an error at the node with the code of the kind of error
+func (t TokenReference) Errorf(code string, format string, args ...interface{}) error {
+	return newError(t.Token, code, format, args...)
+}
+
+// Diagnose places an error at the node, unless it already has a place
+func (t TokenReference) Diagnose(err error) error {
+	return diagnose(t.Token, err)
+}
+
 // Node -
 type Node interface {
 	fmt.Stringer
 	Kind() NodeType
 	SyntaxError()
+	Errorf(code string, format string, args ...interface{}) error
+	Diagnose(err error) error
 	NameString() string
 	Codegen(*Program) (value.Value, error)
 }
@@ -53,6 +69,18 @@ const (
 	nodeIf                    = "nodeIf"
 	nodeWhile                 = "nodeWhile"
 	nodeFor                   = "nodeFor"
+	nodeForEach               = "nodeForEach"
+	nodeMatch                 = "nodeMatch"
+	nodeEnum                  = "nodeEnum"
+	nodeUnion                 = "nodeUnion"
+	nodeInterface             = "nodeInterface"
+	nodeDefer                 = "nodeDefer"
+	nodeWith                  = "nodeWith"
+	nodeTryCatch              = "nodeTryCatch"
+	nodeThrow                 = "nodeThrow"
+	nodeTry                   = "nodeTry"
+	nodeIncDec                = "nodeIncDec"
+	nodeRange                 = "nodeRange"
 	nodeUnary                 = "nodeUnary"
 	nodeBinary                = "nodeBinary"
 	nodeFnCall                = "nodeFnCall"
@@ -69,12 +97,18 @@ const (
 	nodeArray                 = "nodeArray"
 	nodeDot                   = "nodeDot"
 	nodeTypeInfo              = "nodeTypeInfo"
+	nodeSizeof                = "nodeSizeof"
+	nodeNew                   = "nodeNew"
+	nodeSpawn                 = "nodeSpawn"
 	nodeCast                  = "nodeCast"
 	nodeBool                  = "nodeBool"
 	nodeGlobalDecl            = "nodeGlobalDecl"
 	nodeNil                   = "nodeNil"
 	nodeIdent                 = "nodeIdent"
 	nodeStringFormat          = "nodeStringFormat"
+	nodeStringInterpolation   = "nodeStringInterpolation"
+	nodeSpread                = "nodeSpread"
+	nodeExcluded              = "nodeExcluded"
 )
 
 //
@@ -88,6 +122,8 @@ type CharNode struct {
 	TokenReference
 
 	Value rune
+	// Rune is set for chars that aren't one byte, which are runes
+	Rune bool
 }
 
 func (n CharNode) String() string {
@@ -99,6 +135,9 @@ func (n CharNode) String() string {
 // NameString implements Node.NameString
 func (n CharNode) NameString() string { return "CharNode" }
 
+// MarshalJSON implements json.Marshaler for CharNode
+func (n CharNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // IfNode is an if statement representation
 type IfNode struct {
 	NodeType
@@ -108,6 +147,9 @@ type IfNode struct {
 	Then  Node
 	Else  Node
 	Index int
+
+	// Let is the name `if let` binds the checked pointer to, if it is one
+	Let string
 }
 
 func (n IfNode) String() string {
@@ -123,6 +165,9 @@ func (n IfNode) String() string {
 // NameString implements Node.NameString
 func (n IfNode) NameString() string { return "IfNode" }
 
+// MarshalJSON implements json.Marshaler for IfNode
+func (n IfNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 //
 // UnaryNode is a unary operation representation.
 // Example:
@@ -146,11 +191,15 @@ func (n UnaryNode) String() string {
 // NameString implements Node.NameString
 func (n UnaryNode) NameString() string { return "UnaryNode" }
 
+// MarshalJSON implements json.Marshaler for UnaryNode
+func (n UnaryNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // DependencyNode is a way of representing the need to include
 // a dependency or multiple dependencies. It also works to link
 // a c program as well. Paths contains a list of paths to the dependencies
 // that the user entered into the statement. These paths are not resolved
-// and may not contain a geode source file.
+// and may not contain a geode source file. With CHeader, the paths are c
+// headers that bindings are generated from, see IncludeC.go.
 //
 // Example:
 //    Paths = ["io"]
@@ -162,6 +211,7 @@ type DependencyNode struct {
 
 	Paths    []string
 	CLinkage bool
+	CHeader  bool
 }
 
 func (n DependencyNode) String() string {
@@ -169,6 +219,8 @@ func (n DependencyNode) String() string {
 
 	if n.CLinkage {
 		fmt.Fprintf(buff, "link ")
+	} else if n.CHeader {
+		fmt.Fprintf(buff, "include_c ")
 	} else {
 		fmt.Fprintf(buff, "include ")
 	}
@@ -186,6 +238,9 @@ func (n DependencyNode) String() string {
 // NameString implements Node.NameString
 func (n DependencyNode) NameString() string { return "DependencyNode" }
 
+// MarshalJSON implements json.Marshaler for DependencyNode
+func (n DependencyNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // ReferenceType is how we go about accessing a variable. Do we just
 // want the value, or do we want to assign to it
 type ReferenceType int
@@ -218,6 +273,9 @@ func (n ReturnNode) String() string {
 // NameString implements Node.NameString
 func (n ReturnNode) NameString() string { return "ReturnNode" }
 
+// MarshalJSON implements json.Marshaler for ReturnNode
+func (n ReturnNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // WhileNode is a while loop representationvbnm,bvbnm
 type WhileNode struct {
 	NodeType
@@ -237,6 +295,9 @@ func (n WhileNode) String() string {
 // NameString implements Node.NameString
 func (n WhileNode) NameString() string { return "WhileNode" }
 
+// MarshalJSON implements json.Marshaler for WhileNode
+func (n WhileNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // NamespaceNode -
 type NamespaceNode struct {
 	NodeType
@@ -254,6 +315,9 @@ func (n NamespaceNode) String() string {
 // NameString implements Node.NameString
 func (n NamespaceNode) NameString() string { return "NamespaceNode" }
 
+// MarshalJSON implements json.Marshaler for NamespaceNode
+func (n NamespaceNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // TypeModifier is something like *, [], ?, etc...
 type TypeModifier byte
 
@@ -262,6 +326,7 @@ const (
 	ModifierPointer TypeModifier = iota
 	ModifierSlice
 	ModifierUnknown
+	ModifierOptional
 )
 
 // TypeNode -
@@ -285,6 +350,8 @@ func (n TypeNode) String() string {
 			fmt.Fprintf(buff, "*")
 		case ModifierSlice:
 			fmt.Fprintf(buff, "[]")
+		case ModifierOptional:
+			fmt.Fprintf(buff, "?")
 		}
 	}
 
@@ -308,6 +375,12 @@ func (n TypeNode) GetType(prog *Program) (types.Type, error) {
 				ty = types.NewSlice(ty)
 			case ModifierUnknown:
 				//
+			case ModifierOptional:
+				ptr, isPointer := ty.(*types.PointerType)
+				if !isPointer {
+					return nil, fmt.Errorf("only pointers can be optional, %q isn't one", n)
+				}
+				ty = prog.newOptionalType(ptr.Elem)
 			default:
 				return nil, fmt.Errorf("unknown type modifier %d on type %q", mod, n)
 			}
@@ -0,0 +1,116 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// operatorMethods maps the operators a class can overload to the names of
+// the methods that overload them. A class overloads `+` by declaring a
+// method `func op+(Vec* other) Vec*`, which is named op_add so it can be
+// mangled like any other method. `a + b` calls `a.op_add(b)` when a is a
+// pointer to the class.
+var operatorMethods = map[string]string{
+	"+":  "op_add",
+	"-":  "op_sub",
+	"*":  "op_mul",
+	"/":  "op_div",
+	"%":  "op_rem",
+	"==": "op_eq",
+	"!=": "op_ne",
+	"<":  "op_lt",
+	"<=": "op_le",
+	">":  "op_gt",
+	">=": "op_ge",
+	"[]": "op_index",
+}
+
+// parseOperatorName parses the operator after `op` in the name of an
+// operator method and returns the name of the method
+func (p *Parser) parseOperatorName() string {
+	op := p.token.Value
+	if p.token.Is(lexer.TokLeftBrace) {
+		p.Next()
+		p.requires(lexer.TokRightBrace)
+		op = "[]"
+	} else if !p.token.Is(lexer.TokOper) {
+		syntaxFail(p.token, "expected an operator after op in a method name")
+	}
+
+	name, found := operatorMethods[op]
+	if !found {
+		syntaxFail(p.token, "the operator %s can't be overloaded", op)
+	}
+	p.Next()
+	return name
+}
+
+// findOperatorMethod returns the method overloading an operator for the
+// operands it is given, or nil if the left operand isn't a pointer to a
+// class that overloads it
+func (p *Program) findOperatorMethod(op string, left, right types.Type) (*ir.Function, error) {
+	method, found := operatorMethods[op]
+	if !found {
+		return nil, nil
+	}
+	ptr, ok := left.(*types.PointerType)
+	if !ok {
+		return nil, nil
+	}
+	class, ok := ptr.Elem.(*types.StructType)
+	if !ok {
+		return nil, nil
+	}
+	className, err := p.Scope.FindTypeName(class)
+	if err != nil {
+		return nil, nil
+	}
+
+	searchNames := []string{
+		fmt.Sprintf("%s.%s", className, method),
+		fmt.Sprintf("runtime:%s.%s", className, method),
+	}
+	declared := false
+	for _, name := range searchNames {
+		if _, found := p.Functions[name]; found {
+			declared = true
+		}
+	}
+	if !declared {
+		return nil, nil
+	}
+
+	fn, err := p.FindFunction(searchNames, []types.Type{left, right})
+	if err != nil {
+		return nil, err
+	}
+	if len(fn.Sig.Params) != 2 {
+		return nil, fmt.Errorf("op%s of class %s must take exactly one argument", op, className)
+	}
+	return fn, nil
+}
+
+// genOperatorCall calls the method overloading an operator, if the left
+// operand is a class that overloads it, or the runtime function of an
+// operator on strings. It reports if it made a call.
+func genOperatorCall(prog *Program, op string, left, right value.Value) (value.Value, bool, error) {
+	if op != "[]" {
+		if val, isString, err := prog.genStringOperator(op, left, right); isString {
+			return val, true, err
+		}
+	}
+	fn, err := prog.findOperatorMethod(op, left.Type(), right.Type())
+	if err != nil || fn == nil {
+		return nil, false, err
+	}
+	right, err = createTypeCast(prog, right, fn.Sig.Params[1].Typ)
+	if err != nil {
+		return nil, false, err
+	}
+	call, err := prog.genCall(fn, left, right)
+	return call, true, err
+}
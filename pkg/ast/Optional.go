@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// Optional pointers are pointers that can be nil, written `Node*?`. A
+// plain pointer can't be given nil, and an optional pointer can't be
+// dereferenced until it is checked with `if let node = maybe { ... }`,
+// which binds the pointer as a plain one in the then block.
+//
+// The ? binds to the pointer before it, so `T?` on its own still marks
+// the type of a generic function's argument as a type parameter.
+//
+// An optional pointer is the same llvm type as a plain pointer. Each one
+// is its own *types.PointerType, made when its type is resolved, and is
+// recorded in Program.optionalTypes. Values keep the type they are loaded
+// or returned as, so the checks know an optional pointer wherever it ends
+// up.
+
+// newOptionalType returns a new optional pointer to elem
+func (p *Program) newOptionalType(elem types.Type) *types.PointerType {
+	t := types.NewPointer(elem)
+	p.optionalTypes[t] = true
+	return t
+}
+
+// isOptional reports if a type is an optional pointer
+func (p *Program) isOptional(t types.Type) bool {
+	ptr, ok := t.(*types.PointerType)
+	return ok && p.optionalTypes[ptr]
+}
+
+// convertsToOptional reports if a value of type from can be given to an
+// optional pointer of type to, because it is nil
+func (p *Program) convertsToOptional(from, to types.Type) bool {
+	return p.isOptional(to) && from == types.Type(p.nilType)
+}
+
+// checkOptionalCast checks that a value can be converted to a type. nil
+// can only be given to an optional pointer, and an optional pointer can't
+// be used as a plain pointer to the same type without being checked.
+func (p *Program) checkOptionalCast(in value.Value, to types.Type) error {
+	if !types.IsPointer(to) || p.isOptional(to) {
+		return nil
+	}
+	if in.Type() == types.Type(p.nilType) {
+		return fmt.Errorf("unable to use nil as %s, only optional pointers (like %s?) can be nil", to, to)
+	}
+	if p.isOptional(in.Type()) && types.Equal(in.Type(), to) {
+		return fmt.Errorf("unable to use an optional %s as a plain pointer, check it with `if let` first", in.Type())
+	}
+	return nil
+}
+
+// checkDereference checks that a pointer of type t can be dereferenced
+// to get at what, because it isn't an optional pointer
+func (p *Program) checkDereference(t types.Type, what interface{}) error {
+	if p.isOptional(t) {
+		return fmt.Errorf("unable to dereference optional %s %s, check it with `if let` first", t, what)
+	}
+	return nil
+}
+
+// genLetBinding declares the variable `if let` binds, as a plain pointer,
+// in the scope of the then block. The value must already be checked.
+func (n IfNode) genLetBinding(prog *Program, val value.Value) {
+	ptr := val.Type().(*types.PointerType)
+	checked := types.NewPointer(ptr.Elem)
+
+	block := prog.Compiler.CurrentBlock()
+	alloc := block.NewAlloca(checked)
+	block.NewStore(block.NewBitCast(val, checked), alloc)
+	prog.Scope.Add(NewVariableScopeItem(n.Let, alloc, PrivateVisibility))
+}
@@ -4,15 +4,6 @@ import (
 	"github.com/geode-lang/geode/pkg/lexer"
 )
 
-// RuntimePackage is the global runtime package
-// var RuntimePackage *Package
-var dependencyMap map[string]*Package
-
-func init() {
-	// RuntimePackage = GetRuntime()
-	dependencyMap = make(map[string]*Package)
-}
-
 // Package packages up information around a set of nodes
 type Package struct {
 	// fmt.Stringer
@@ -55,7 +46,7 @@ func (p *Package) HasAccessToPackage(name string) bool {
 
 	for path, pkg := range p.Program.Packages {
 		for _, dpath := range p.DependencyPaths {
-			if ReduceToDir(path) == ReduceToDir(dpath) && pkg.Name == name {
+			if p.Program.ReduceToDir(path) == p.Program.ReduceToDir(dpath) && pkg.Name == name {
 				return true
 			}
 
@@ -0,0 +1,128 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isPackageInit returns if a function is an init function of its package.
+// init functions run before main and can't be called by name.
+func isPackageInit(fn FunctionNode, pkg *Package) bool {
+	return fn.Name.String() == "init" && pkg.Name != "runtime" && !fn.IsMethod
+}
+
+// registerPackageInit registers a package's init function under a name
+// unique to it, as a package can have more than one
+func (p *Program) registerPackageInit(fn FunctionNode, pkg *Package) error {
+	if len(fn.Args) > 0 || fn.ReturnType.Name != "void" || fn.External {
+		return fn.Errorf(ErrInvalid, "init function in package %s must take no arguments, return nothing and have a body", pkg.Name)
+	}
+
+	dir := p.packageDir(pkg)
+	fn.Name = NewIdentNode(fmt.Sprintf("init.%d", len(p.packageInits[dir])))
+	name := fmt.Sprintf("%s:%s", pkg.Name, fn.Name)
+
+	p.RegisterFunction(name, fn)
+	p.packageInits[dir] = append(p.packageInits[dir], name)
+	return nil
+}
+
+// packageDir returns the directory a package was loaded from. Packages are
+// parsed per file, but dependencies are declared on whole directories. The
+// first of its files is used, so the same directory is found every time.
+func (p *Program) packageDir(pkg *Package) string {
+	paths := make([]string, 0, len(pkg.Files))
+	for path := range pkg.Files {
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	sort.Strings(paths)
+	return p.ReduceToDir(paths[0])
+}
+
+// OrderPackageInits returns the registered names of every package init
+// function, ordered so that the init functions of a package run after
+// those of every package it depends on. Within a package they run in the
+// order they were declared. A dependency cycle between packages that
+// define init functions is an error, as there is no valid order to them.
+func (p *Program) OrderPackageInits() ([]string, error) {
+	// Build the dependency graph between package directories
+	deps := make(map[string][]string)
+	names := make(map[string]string)
+	for _, pkg := range p.Packages {
+		dir := p.packageDir(pkg)
+		names[dir] = pkg.Name
+		for _, dpath := range pkg.DependencyPaths {
+			if dpath != dir {
+				deps[dir] = append(deps[dir], dpath)
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(names))
+	for dir := range names {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	stack := make([]string, 0)
+	order := make([]string, 0)
+
+	var visit func(dir string) error
+	visit = func(dir string) error {
+		switch state[dir] {
+		case visited:
+			return nil
+		case visiting:
+			// Find where the cycle starts on the stack
+			start := 0
+			for i, d := range stack {
+				if d == dir {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, stack[start:]...), dir)
+
+			hasInit := false
+			path := make([]string, 0, len(cycle))
+			for _, d := range cycle {
+				hasInit = hasInit || len(p.packageInits[d]) > 0
+				path = append(path, names[d])
+			}
+			if hasInit {
+				return fmt.Errorf("package initialization cycle: %s", strings.Join(path, " -> "))
+			}
+			return nil
+		}
+
+		state[dir] = visiting
+		stack = append(stack, dir)
+		for _, dep := range deps[dir] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[dir] = visited
+
+		order = append(order, p.packageInits[dir]...)
+		return nil
+	}
+
+	for _, dir := range dirs {
+		if err := visit(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// initProgram compiles /proj/main.g with the packages in the files, which
+// are in /proj too
+func initProgram(t *testing.T, files map[string]string) (*Program, error) {
+	t.Helper()
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	fsys := fstest.MapFS{}
+	for path, text := range files {
+		fsys[path] = &fstest.MapFile{Data: []byte(text)}
+	}
+	p := NewProgram()
+	p.NoRuntime = true
+	p.Sources = FSSources("/proj", fsys)
+	ctx := context.Background()
+	if err := p.ParsePath(ctx, "/proj/main.g"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := p.Congeal(ctx)
+	return p, err
+}
+
+func TestPackageInitOrder(t *testing.T) {
+	p, err := initProgram(t, map[string]string{
+		"main.g":     "is main\n\ninclude \"zeta\"\ninclude \"alpha\"\n\nfunc init {}\n\nfunc init {}\n\nfunc main int = 0;\n",
+		"zeta/z.g":   "is zeta\n\ninclude \"../alpha\"\n\nfunc init {}\n",
+		"alpha/a.g":  "is alpha\n\nfunc init {}\n",
+		"alpha/b.g":  "is alpha\n\nfunc init {}\n",
+		"unused/u.g": "is unused\n\nfunc init {}\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a package runs its inits after those of the packages it includes,
+	// in the order they are declared. Packages that aren't included don't
+	// run theirs.
+	want := []string{"alpha:init.0", "alpha:init.1", "zeta:init.0", "main:init.0", "main:init.1"}
+	if !reflect.DeepEqual(p.InitFunctions, want) {
+		t.Errorf("got %v, want %v", p.InitFunctions, want)
+	}
+}
+
+func TestPackageInitCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{"init in cycle", map[string]string{
+			"main.g": "is main\n\ninclude \"a\"\n\nfunc main int = 0;\n",
+			"a/a.g":  "is a\n\ninclude \"../b\"\n\nfunc init {}\n",
+			"b/b.g":  "is b\n\ninclude \"../a\"\n",
+		}, "package initialization cycle: a -> b -> a"},
+		// packages can include each other if neither has an init to order
+		{"no init in cycle", map[string]string{
+			"main.g": "is main\n\ninclude \"a\"\n\nfunc init {}\n\nfunc main int = 0;\n",
+			"a/a.g":  "is a\n\ninclude \"../b\"\n",
+			"b/b.g":  "is b\n\ninclude \"../a\"\n",
+		}, ""},
+	}
+	for _, test := range tests {
+		_, err := initProgram(t, test.files)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.name, err)
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("%s: got error %v, want %s", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// builtinPanic ends the program with a message and a stack trace of the
+// geode functions that were running, as in `panic("unreachable")`. It exits
+// with status 2 without running the expressions deferred so far. Nothing
+// after a panic is run, so a function doesn't have to return after one.
+// The checks the compiler inserts, like the bounds checks of
+// --bounds-checks, panic the same way. See lib/runtime/panic.c.
+const builtinPanic = "panic"
+
+// panicBuiltin returns if a call is to panic. A function named panic takes
+// priority.
+func panicBuiltin(prog *Program, n FunctionCallNode) bool {
+	ident, ok := n.Name.(IdentNode)
+	if !ok || ident.String() != builtinPanic {
+		return false
+	}
+	return prog.LookupFunctionNode(builtinPanic) == nil
+}
+
+// genPanic generates a call to panic
+func genPanic(prog *Program, n FunctionCallNode) (value.Value, error) {
+	if prog.NoRuntime {
+		return nil, n.Errorf(ErrInvalid, "%s is part of the runtime, which is disabled by --no-runtime", builtinPanic)
+	}
+	if len(n.Args) != 1 {
+		return nil, n.Errorf(ErrInvalid, "%s takes a message, given %d arguments", builtinPanic, len(n.Args))
+	}
+	msg, err := n.Args[0].Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	if !isString(msg.Type()) {
+		return nil, n.Args[0].Errorf(ErrType, "the message passed to %s must be a string, given %s", builtinPanic, prog.typeName(msg.Type()))
+	}
+	call, err := prog.NewRuntimeFunctionCall("__runtime_panic", msg)
+	if err != nil {
+		return nil, err
+	}
+	prog.Compiler.CurrentBlock().NewUnreachable()
+	return call, nil
+}
@@ -2,13 +2,17 @@ package ast
 
 import (
 	"fmt"
+	"os"
+	"sync/atomic"
 
 	"github.com/geode-lang/geode/pkg/info"
+	"github.com/geode-lang/geode/pkg/util/log"
 
 	"github.com/geode-lang/geode/pkg/lexer"
 )
 
-var parserid = 0
+// parserid numbers the parsers, for their errors
+var parserid int32
 
 // ParseContext is a wrapper around information that allows the parser to understand the world
 // around it. This will contain the program that is currently running, etc.
@@ -28,6 +32,16 @@ type Parser struct {
 	isFork             bool
 	forkParent         *Parser
 	ID                 int
+
+	// diagnostics are the syntax errors the parser and its forks
+	// recovered from
+	diagnostics *[]*Diagnostic
+
+	// target is the platform @os and @arch are checked against
+	target *Target
+	// warnings are where the warnings and @allow suppressions found while
+	// parsing go
+	warnings *warningLog
 }
 
 // NewQuickParser is used to lex and build a parser from tokens quickly
@@ -45,9 +59,10 @@ func NewParser() *Parser {
 		tokens:             make([]lexer.Token, 0),
 		topLevelNodes:      make([]Node, 0),
 		binaryOpPrecedence: parserOpPrec,
-		ID:                 parserid,
+		ID:                 int(atomic.AddInt32(&parserid, 1)) - 1,
+		diagnostics:        new([]*Diagnostic),
+		warnings:           &warningLog{},
 	}
-	parserid++
 
 	return p
 }
@@ -63,6 +78,9 @@ func (p *Parser) Fork() *Parser {
 	n.token = p.token
 	n.tokens = p.tokens
 	n.token = p.token
+	n.diagnostics = p.diagnostics
+	n.target = p.target
+	n.warnings = p.warnings
 	return n
 }
 
@@ -83,36 +101,61 @@ func (p *Parser) reset() {
 }
 
 var parserOpPrec = map[string]int{
-	"=":  0,
-	"+=": 0,
-	"-=": 0,
-	"*=": 0,
-	"/=": 0,
-	"||": 1,
-	"&&": 1,
-	"^":  1,
-	"==": 2,
-	"!=": 2,
-	"<":  10,
-	"<=": 10,
-	">":  10,
-	">=": 10,
-	">>": 15,
-	"<<": 15,
-	"+":  20,
-	"-":  20,
-	"*":  40,
-	"/":  40,
-	"%":  40,
+	"=":   0,
+	"+=":  0,
+	"-=":  0,
+	"*=":  0,
+	"/=":  0,
+	"%=":  0,
+	"<<=": 0,
+	">>=": 0,
+	"&=":  0,
+	"|=":  0,
+	"^=":  0,
+	"<-":  0,
+	"||":  1,
+	"&&":  1,
+	"==":  2,
+	"!=":  2,
+	"..":  5,
+	"<":   10,
+	"<=":  10,
+	">":   10,
+	">=":  10,
+	"|":   11,
+	"^":   12,
+	"&":   13,
+	">>":  15,
+	"<<":  15,
+	"+":   20,
+	"-":   20,
+	"*":   40,
+	"/":   40,
+	"%":   40,
 }
 
 // Parse creates and runs a new lexer, that returns the
-// chan that the nodes will be passed through with
-func Parse(tokens []lexer.Token) []Node {
+// chan that the nodes will be passed through with, and the syntax errors
+// it recovered from. Declarations with @os or @arch are only kept if the
+// target matches them.
+func Parse(tokens []lexer.Token, target *Target) ([]Node, []*Diagnostic) {
+	return parseTokens(tokens, target, &warningLog{})
+}
+
+// parseTokens parses tokens as Parse does, giving the warnings in them to
+// a log. The parsers of the function bodies keep giving them to it.
+func parseTokens(tokens []lexer.Token, target *Target, warnings *warningLog) ([]Node, []*Diagnostic) {
 	p := NewParser()
+	p.target = target
+	p.warnings = warnings
 
 	// prime the next token for use by reading from the token channel (easier than handling in .next())
 	for _, t := range tokens {
+		// the lexer stops at code it can't lex with an error token, and
+		// the code before it is cut off wherever that is
+		if t.Type == lexer.TokError {
+			return nil, []*Diagnostic{newError(t, ErrSyntax, "%s", t.Value)}
+		}
 		if t.Type != lexer.TokWhitespace && t.Type != lexer.TokComment {
 			p.tokens = append(p.tokens, t)
 		}
@@ -120,7 +163,7 @@ func Parse(tokens []lexer.Token) []Node {
 
 	p.move(0)
 	p.parse()
-	return p.topLevelNodes
+	return p.topLevelNodes, *p.diagnostics
 }
 
 // Context returns the context of a parser
@@ -136,9 +179,16 @@ func (p *Parser) Context() *ParseContext {
 
 func (p *Parser) parse() {
 	for p.token.Type > 0 {
-		topLevelNode := p.parseTopLevelStmt()
+		topLevelNode, failed := p.parseRecovering(p.parseTopLevelStmt, p.skipDeclaration)
+		if failed {
+			continue
+		}
+		if _, excluded := topLevelNode.(excludedDecl); excluded {
+			continue
+		}
 		if topLevelNode != nil {
 			p.topLevelNodes = append(p.topLevelNodes, topLevelNode)
+			p.checkBodies([]Node{topLevelNode})
 
 			info.AddNode(topLevelNode)
 		} else {
@@ -151,9 +201,129 @@ func (p *Parser) requires(t lexer.TokenType) {
 	if p.token.Is(t) {
 		return
 	}
+	syntaxFail(p.token, "Required token '%s' is missing. Has '%s' instead.", t.String(), p.token.Type.String())
+}
+
+// Function bodies are only parsed when the function is compiled, so a
+// body is parsed once up front to report the syntax errors in it, even if
+// the function is never called.
+func (p *Parser) checkBodies(nodes []Node) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case FunctionNode:
+			if n.BodyParser != nil {
+				body := n.BodyParser.Fork()
+				body.reset()
+				p.parseRecovering(func() Node { return body.parseBlockStmt() }, func(int, int) {})
+			}
+		case ClassNode:
+			p.checkBodies(functionNodes(n.Methods))
+			p.checkBodies(functionNodes(n.StaticMethods))
+		}
+	}
+}
+
+func functionNodes(fns []FunctionNode) []Node {
+	nodes := make([]Node, len(fns))
+	for i, fn := range fns {
+		nodes[i] = fn
+	}
+	return nodes
+}
+
+// syntaxBail is panicked with by syntaxFail to abandon what is being
+// parsed, and recovered where the parser can pick up again
+type syntaxBail struct {
+	err *Diagnostic
+}
 
-	p.token.SyntaxError()
-	p.Errorf("Required token '%s' is missing. Has '%s' instead.\n", t.String(), p.token.Type.String())
+// syntaxFail gives a syntax error at a token and abandons the statement
+// or declaration being parsed. The parser picks up again after it, so all
+// the syntax errors in a file are reported at once.
+func syntaxFail(tok lexer.Token, format string, a ...interface{}) {
+	panic(syntaxBail{newError(tok, ErrSyntax, format, a...)})
+}
+
+// parseRecovering parses with parse. If it fails with a syntax error, the
+// error is kept and skip is called with the index of the token parsing
+// started at and the line of the error, to move past the rest of what
+// was being parsed.
+func (p *Parser) parseRecovering(parse func() Node, skip func(start, line int)) (node Node, failed bool) {
+	start := p.tokenIndex
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		bail, isBail := r.(syntaxBail)
+		if !isBail {
+			panic(r)
+		}
+		*p.diagnostics = append(*p.diagnostics, bail.err)
+		skip(start, bail.err.Token.Line)
+		node, failed = nil, true
+	}()
+	return parse(), false
+}
+
+// skipStatement moves to the next statement after a syntax error, the
+// first one on a line after the error outside of any braces. It stops at
+// the brace closing the block, so the block still ends there.
+func (p *Parser) skipStatement(start, line int) {
+	if p.tokenIndex == start {
+		p.Next()
+	}
+	depth := 0
+	for p.token.Type > 0 {
+		switch {
+		case p.token.Is(lexer.TokLeftCurly):
+			depth++
+		case p.token.Is(lexer.TokRightCurly):
+			if depth == 0 {
+				return
+			}
+			depth--
+		case depth == 0 && p.token.Line > line:
+			return
+		}
+		p.Next()
+	}
+}
+
+// declarationStarts are the tokens a top level declaration can start with
+var declarationStarts = []lexer.TokenType{
+	lexer.TokNamespace, lexer.TokDependency, lexer.TokClassDefn, lexer.TokEnumDefn,
+	lexer.TokUnionDefn, lexer.TokInterfaceDefn, lexer.TokFuncDefn, lexer.TokAttribute, lexer.TokPub,
+	lexer.TokConst, lexer.TokType,
+}
+
+// skipDeclaration moves to the next top level declaration after a syntax
+// error, the first token after it that could start one and isn't indented
+func (p *Parser) skipDeclaration(start, line int) {
+	if p.tokenIndex == start {
+		p.Next()
+	}
+	for p.token.Type > 0 {
+		if p.token.Line > line && p.token.Is(declarationStarts...) && p.token.StartsLine() {
+			return
+		}
+		p.Next()
+	}
+}
+
+// exitOnSyntaxError ends the compile if code parsed outside of Parse, as
+// while compiling, has a syntax error
+func exitOnSyntaxError() {
+	if r := recover(); r != nil {
+		if bail, isBail := r.(syntaxBail); isBail {
+			printDiagnostics([]*Diagnostic{bail.err})
+			if jsonDiagnostics() {
+				os.Exit(1)
+			}
+			log.Fatal("1 syntax error\n")
+		}
+		panic(r)
+	}
 }
 
 // Back walks the parser back one token
@@ -216,14 +386,25 @@ func (p *Parser) parseTopLevelStmt() Node {
 		return p.parseDependencyStmt()
 	case lexer.TokClassDefn:
 		return p.parseClassDefn()
+	case lexer.TokEnumDefn:
+		return p.parseEnumDefn()
+	case lexer.TokUnionDefn:
+		return p.parseUnionDefn()
+	case lexer.TokInterfaceDefn:
+		return p.parseInterfaceDefn()
 	case lexer.TokFuncDefn:
 		return p.parseFunctionNode()
+	case lexer.TokAttribute:
+		return p.parseAttributedStmt()
+	case lexer.TokPub:
+		return p.parsePubStmt()
+	case lexer.TokConst:
+		return p.parseConstDecl()
 	case lexer.TokType:
 		node := p.parseGlobalVariableDecl()
 		return node
 	}
-	p.token.SyntaxError()
-	p.Errorf("Invalid syntax in root\n")
+	syntaxFail(p.token, "Invalid syntax in root")
 	return nil
 }
 
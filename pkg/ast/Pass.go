@@ -0,0 +1,101 @@
+package ast
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// ProgramPass is a pass over the nodes of every package of a program, run
+// after they are parsed and before they are compiled. Passes let programs
+// that embed the compiler rewrite the code it compiles without forking it,
+// like lowering a construct of their own to geode, injecting
+// instrumentation into functions or turning the calls of a dsl into code.
+//
+// The bodies of functions are parsed when the functions are compiled. A
+// pass rewrites one by parsing it with FunctionNode.ParseBody, then setting
+// Body to the rewritten body and BodyParser to nil, so it is compiled as it
+// is.
+type ProgramPass interface {
+	// Name names the pass in the errors it fails with
+	Name() string
+	// Run returns the nodes a package is compiled from in place of the ones
+	// it was parsed to, or the error the compile fails with
+	Run(prog *Program, pkg *Package, nodes []Node) ([]Node, error)
+}
+
+// AddPass adds a pass Congeal runs on the program. Passes are run in the
+// order they are added, each on every package before the next one runs.
+func (p *Program) AddPass(pass ProgramPass) {
+	p.passes = append(p.passes, pass)
+}
+
+// runPasses runs the passes of the program on its packages, in the order
+// the packages are compiled in
+func (p *Program) runPasses() error {
+	dirs := make([]string, 0, len(p.Packages))
+	for dir := range p.Packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, pass := range p.passes {
+		for _, dir := range dirs {
+			if err := p.ctx.Err(); err != nil {
+				return err
+			}
+			pkg := p.Packages[dir]
+			nodes, err := pass.Run(p, pkg, pkg.Nodes)
+			if _, isDiagnostic := err.(*Diagnostic); isDiagnostic {
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("pass %s failed on %s: %s", pass.Name(), filepath.Clean(dir), err)
+			}
+			pkg.Nodes = nodes
+		}
+	}
+	return nil
+}
+
+// markNamedGlobals marks every name used in a node as a global that could
+// be reassigned. The bodies passes rewrite have no tokens to be scanned by
+// FindReassignedGlobals, so every global they name is taken to be written.
+func markNamedGlobals(node Node, mutated map[string]bool) {
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Interface:
+			if !v.IsNil() {
+				visit(v.Elem())
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		case reflect.Struct:
+			switch n := v.Interface().(type) {
+			case lexer.Token:
+				return
+			case IdentNode:
+				_, name := ParseName(n.Value)
+				mutated[name] = true
+			case DotReference:
+				// static fields of classes are named like `Counter.count`
+				_, class := ParseName(fmt.Sprint(n.Base))
+				_, field := ParseName(fmt.Sprint(n.Field))
+				mutated[fmt.Sprintf("%s.%s", class, field)] = true
+			}
+			// the parser and package of a function aren't part of the code
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" && v.Field(i).Kind() != reflect.Ptr {
+					visit(v.Field(i))
+				}
+			}
+		}
+	}
+	visit(reflect.ValueOf(node))
+}
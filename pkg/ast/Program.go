@@ -2,21 +2,29 @@ package ast
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"path/filepath"
 
+	"github.com/geode-lang/geode/llvm/bitcode"
 	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/metadata"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/llvm/llc"
 	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/debug"
+	"github.com/geode-lang/geode/pkg/fetch"
 	"github.com/geode-lang/geode/pkg/lexer"
+	"github.com/geode-lang/geode/pkg/preprocessor"
 	"github.com/geode-lang/geode/pkg/util"
 	"github.com/geode-lang/geode/pkg/util/log"
 )
@@ -24,21 +32,134 @@ import (
 // Program is a wrapper for information used
 // in codegen and dependency resolution
 type Program struct {
-	Scope           *Scope
-	Compiler        *Compiler
-	Module          *ir.Module
-	ParsedFiles     []string
-	Packages        map[string]*Package
-	Package         *Package // the currently active package
-	CLinkages       []string
-	Entry           string
-	TargetTripple   string
+	Scope       *Scope
+	Compiler    *Compiler
+	Module      *ir.Module
+	ParsedFiles []string
+	Packages    map[string]*Package
+	Package     *Package // the currently active package
+	CLinkages   []string
+	// Toolchain preprocesses the c headers included with include_c. Without
+	// one, they are preprocessed by the default toolchain of the target.
+	Toolchain Toolchain
+	// Sources are where the packages of the program are read from, which
+	// is disk without them
+	Sources       SourceProvider
+	cHeaders      map[string]*cHeader
+	Entry         string
+	TargetTripple string
+	// Target is the platform the program is compiled for
+	Target *Target
+	// OpaquePointers compiles the program to a module with opaque pointers,
+	// as llvm 17 and later require
+	OpaquePointers bool
+	// NoRuntime compiles the program without the runtime package, as with
+	// --no-runtime
+	NoRuntime       bool
 	TypePrecidences map[types.Type]int
 	Functions       map[string]*FunctionNode
 	Classes         map[string]*ClassNode
+	// ClassInstances are the struct types of the instances of generic
+	// classes, by the mangled name of the instance
+	ClassInstances  map[string]*types.StructType
+	Enums           map[string]*EnumNode
+	Unions          map[string]*UnionNode
+	Interfaces      map[string]*InterfaceNode
 	Initializations []*GlobalVariableDeclNode
 	StringDefs      map[string]*ir.Global
 	TypeInfoDefs    map[string]*TypeInfoDeclaration
+
+	// ConstGlobals maps globals that are constant initialized and never
+	// reassigned to their value, so loads from them can be folded.
+	ConstGlobals      map[*ir.Global]constant.Constant
+	ReassignedGlobals map[string]bool
+	// constants are the globals declared with const
+	constants map[*ir.Global]bool
+
+	// InitFunctions are the registered names of the init functions of
+	// every package, in the order they are run before main
+	InitFunctions []string
+	packageInits  map[string][]string
+
+	// interfaceTypes maps the types of interface values to the interfaces,
+	// implementations maps the types of classes to the interfaces they
+	// implement, and vtables holds the vtables of classes by the class
+	// and interface
+	interfaceTypes  map[*types.StructType]*InterfaceNode
+	implementations map[*types.StructType][]*InterfaceNode
+	vtables         map[string]*ir.Global
+
+	// unionTypes maps the types of unions to the unions
+	unionTypes map[*types.StructType]*UnionNode
+
+	// classParents maps the types of classes to the classes they extend,
+	// extendedClasses holds the classes that are extended, and
+	// virtualClasses holds every class in a hierarchy
+	classParents    map[*types.StructType]*types.StructType
+	extendedClasses map[*types.StructType]bool
+	virtualClasses  map[*types.StructType]*virtualClass
+
+	// optionalTypes are the pointer types that are optional, and nilType
+	// is the type of nil
+	optionalTypes map[*types.PointerType]bool
+	nilType       *types.PointerType
+
+	// deferred holds the expressions deferred in the functions being
+	// compiled, to be run before each of their returns
+	deferred map[*ir.Function]*functionDefers
+	// tries are the try blocks being generated in each function, innermost
+	// last
+	tries map[*ir.Function][]*tryBlock
+
+	// passes are the passes Congeal runs on the packages, see ProgramPass
+	passes []ProgramPass
+
+	// ctx is the context the program is compiled in, the one Congeal is
+	// given. GetFunction stops compiling functions once it is done.
+	ctx context.Context
+
+	// The local variables declared in the program and the ones that are
+	// read, to warn about the rest
+	declaredVariables []declaredVariable
+	usedVariables     map[*ir.InstAlloca]bool
+
+	// diagnostics are the errors the program failed to compile with
+	diagnostics []*Diagnostic
+	// warnings are the warnings given in the program, and enabledWarnings
+	// and warningsAreErrors are how they are reported, as set with -W
+	warnings          *warningLog
+	enabledWarnings   map[string]bool
+	warningsAreErrors bool
+
+	// typeAlignments are the alignments classes are given with @align
+	typeAlignments map[*types.StructType]int
+
+	// layout is the data layout of the target, which sizeof and alignof
+	// are worked out from
+	layout *DataLayout
+
+	// debug builds the debug information of the program, see debugInfo
+	debug *debug.Builder
+	// debugTypes are the debug information of the types described so far
+	debugTypes map[types.Type]*metadata.Metadata
+	// debugStatements are the statements being compiled in the current
+	// function, innermost last
+	debugStatements []debugStatement
+
+	// coverage is the state of the --coverage instrumentation
+	coverage *coverage
+	// arc is the state of the --arc reference counting
+	arc *arc
+	// cFunctions are the functions declared with ..., which take and
+	// return strings as a char*. The ones that return a string are true.
+	cFunctions map[*ir.Function]bool
+	// searchPaths are the directories added to search for packages in
+	searchPaths []string
+	// resolvedVersions are the versions the packages in versioned
+	// directories resolved to, by their names
+	resolvedVersions map[string]string
+	// strIndex numbers the globals of constant strings
+	strIndex int
 }
 
 // NewProgram creates a program and returns a pointer to it
@@ -49,9 +170,23 @@ func NewProgram() *Program {
 	p.Compiler = &Compiler{}
 	p.Module = ir.NewModule()
 	p.Packages = make(map[string]*Package)
+	p.cHeaders = make(map[string]*cHeader)
 	p.Initializations = make([]*GlobalVariableDeclNode, 0)
 	p.StringDefs = make(map[string]*ir.Global, 0)
 	p.TypeInfoDefs = make(map[string]*TypeInfoDeclaration, 0)
+	p.ConstGlobals = make(map[*ir.Global]constant.Constant)
+	p.constants = make(map[*ir.Global]bool)
+	p.usedVariables = make(map[*ir.InstAlloca]bool)
+	p.warnings = &warningLog{}
+	p.enabledWarnings = make(map[string]bool)
+	for _, name := range defaultWarnings {
+		p.enabledWarnings[name] = true
+	}
+	p.resolvedVersions = make(map[string]string)
+	p.typeAlignments = make(map[*types.StructType]int)
+	p.debugTypes = make(map[types.Type]*metadata.Metadata)
+	p.SetTarget(GenericTarget(""))
+	p.ctx = context.Background()
 
 	p.TypePrecidences = make(map[types.Type]int)
 	p.TypePrecidences[types.I1] = 1
@@ -59,6 +194,13 @@ func NewProgram() *Program {
 	p.TypePrecidences[types.I16] = 3
 	p.TypePrecidences[types.I32] = 4
 	p.TypePrecidences[types.I64] = 5
+	p.TypePrecidences[types.U8] = 2
+	p.TypePrecidences[types.U16] = 3
+	p.TypePrecidences[types.U32] = 4
+	p.TypePrecidences[types.U64] = 5
+	p.TypePrecidences[types.I128] = 6
+	p.TypePrecidences[types.U128] = 6
+	p.TypePrecidences[types.Float] = 10
 	p.TypePrecidences[types.Double] = 11
 	p.TypePrecidences[types.NewPointer(types.I8)] = 0
 	p.TypePrecidences[types.Void] = 0
@@ -79,44 +221,39 @@ func (p *Program) ScopeUp() error {
 func (p *Program) ScopeDown(tok lexer.Token) {
 
 	p.Scope = p.Scope.SpawnChild()
-
-	if *arg.EnableDebug {
-		md := &metadata.Named{}
-		md.Name = fmt.Sprintf("scope_%d", p.Scope.Index)
-		p.Module.NamedMetadata = append(p.Module.NamedMetadata, md)
-		p.Scope.DebugInfo = md
-		// md.Metadata
-	}
+	p.debugScopeDown(tok)
 }
 
 // ParsePath parses from some some path and handles
 // everything required to get a final compiled program from some
 // basic source location
-func (p *Program) ParsePath(dir string) {
+func (p *Program) ParsePath(ctx context.Context, dir string) error {
 
 	// Determine if the path is a directory or not.
-	if isDir, _ := PathIsDir(dir); !isDir {
+	if isDir, _ := p.PathIsDir(dir); !isDir {
 		// The path isn't a directory, so we just pull the base of the file
 		dir = filepath.Dir(dir)
 	}
 
-	dir = ReduceToDir(dir)
+	dir = p.ReduceToDir(dir)
 
 	absEntry, err := filepath.Abs(dir)
 
 	if err != nil {
-		log.Fatal("Error with parsing entry location\n")
+		return err
 	}
 
 	files, err := p.ParseDir(absEntry)
 	if err != nil {
-		fmt.Println(err)
-		log.Fatal("Error parsing folder for geode source files\n")
+		return err
 	}
 
 	for _, file := range files {
-		p.ParseFile(file)
+		if err := p.ParseFile(ctx, file); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // CanParse helps decide whether or not to parse a file based on previously parsed files
@@ -131,13 +268,7 @@ func (p *Program) CanParse(file string) bool {
 
 // ParseDir parses a directory for all package information
 func (p *Program) ParseDir(path string) ([]string, error) {
-	fd, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer fd.Close()
-
-	list, err := fd.Readdir(-1)
+	list, err := p.sources().ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
@@ -160,32 +291,48 @@ func (p *Program) ParseDir(path string) ([]string, error) {
 
 // ParseText takes some code and the path it was located at and
 // adds it to the Program
-func (p *Program) ParseText(code string, path string) {
-
-	// pp := preprocessor.New()
-	// code, _ = pp.Run(code)
-
+func (p *Program) ParseText(ctx context.Context, code string, path string) error {
 	p.ParsedFiles = append(p.ParsedFiles, path)
+	return p.parsePackage(ctx, code, path)
+}
+
+// parsePackage parses some code into a package of the program, and the
+// packages it depends on. Syntax errors are collected as diagnostics of
+// the program, and returned as syntaxErrors. Once the context is done,
+// no more packages are parsed.
+func (p *Program) parsePackage(ctx context.Context, code string, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	src, err := lexer.NewSourcefile(path)
 	if err != nil {
-		fmt.Println(err)
-		log.Fatal("Error creating Sourcefile context for file at %q\n", path)
+		return err
 	}
+	src.Path = path
 	src.LoadString(code)
 
-	tokens := lexer.Lex(src)
+	tokens, macroErrors := preprocessor.New().Run(lexer.Lex(src))
 
-	nodes := Parse(tokens)
+	diagnostics := make([]*Diagnostic, 0, len(macroErrors))
+	for _, err := range macroErrors {
+		diagnostics = append(diagnostics, newError(err.Token, ErrSyntax, "%s", err.Message))
+	}
+	nodes, parseDiagnostics := parseTokens(tokens, p.Target, p.warnings)
+	diagnostics = append(diagnostics, parseDiagnostics...)
+	if len(diagnostics) > 0 {
+		p.diagnostics = append(p.diagnostics, diagnostics...)
+		return syntaxErrors(len(diagnostics))
+	}
 
 	name, err := NamespaceFromNodes(nodes)
 	if err != nil {
-		log.Fatal("Unable to decide on namespace for file %q", filepath.Clean(path))
+		return fmt.Errorf("unable to decide on namespace for file %q: %s", filepath.Clean(path), err)
 	}
 
 	r, _ := regexp.Compile("[a-z_]+")
 
 	if !r.MatchString(name) {
-		log.Error("Invalid Namespace name %q. Namespaces can only contain lowercase letters and underscores\n", name)
+		return fmt.Errorf("invalid namespace name %q, namespaces can only contain lowercase letters and underscores", name)
 	}
 
 	newPkg := NewPackage(name, p)
@@ -202,39 +349,82 @@ func (p *Program) ParseText(code string, path string) {
 		base := filepath.Dir(path)
 		dep := node.(DependencyNode)
 		for _, depPath := range dep.Paths {
+			if dep.CHeader {
+				header, err := p.includeC(ctx, base, depPath)
+				if err != nil {
+					return fmt.Errorf("unable to include the c header %q: %s", depPath, err)
+				}
+				newPkg.DependencyPaths = append(newPkg.DependencyPaths, header)
+				continue
+			}
+			resolved, err := p.ResolveDepPath(ctx, base, depPath)
+			if err != nil {
+				return err
+			}
 			if dep.CLinkage {
-				p.CLinkages = append(p.CLinkages, ResolveDepPath(base, depPath))
-			} else {
-				newPkg.DependencyPaths = append(newPkg.DependencyPaths, ReduceToDir(ResolveDepPath(base, depPath)))
-				p.ParseDep(base, depPath)
+				p.linkC(newPkg, resolved)
+				continue
+			}
+			newPkg.DependencyPaths = append(newPkg.DependencyPaths, p.ReduceToDir(resolved))
+			if err := p.parseDep(ctx, resolved); err != nil {
+				return err
 			}
 		}
 
 	}
+	return nil
+}
+
+// shimmedPackages are the runtime and the packages it includes, whose c
+// sources are replaced by the runtime shim of freestanding targets
+var shimmedPackages = map[string]bool{"runtime": true, "io": true, "mem": true}
+
+// linkC adds a c source to the ones linked into the program. On targets
+// with a runtime shim, the shim is linked in place of the sources the
+// runtime needs, as they need the c library.
+func (p *Program) linkC(pkg *Package, path string) {
+	if shimmedPackages[pkg.Name] && p.Target.Freestanding() {
+		path = util.StdLibFile(filepath.Join("runtime", p.Target.RuntimeShim))
+	}
+	for _, linked := range p.CLinkages {
+		if linked == path {
+			return
+		}
+	}
+	p.CLinkages = append(p.CLinkages, path)
 }
 
 // ParseFile will parse the contents of the file at some path into a Package
-func (p *Program) ParseFile(path string) {
-	bytes, err := ioutil.ReadFile(path)
+func (p *Program) ParseFile(ctx context.Context, path string) error {
+	bytes, err := p.sources().ReadFile(path)
 	if err != nil {
-		log.Fatal("%s\n", err)
+		return err
 	}
 
-	p.ParseText(string(bytes), path)
+	return p.ParseText(ctx, string(bytes), path)
 }
 
 // ParseDep will parse any dependency relative to the current base
-func (p *Program) ParseDep(base, path string) {
-	depPath := ResolveDepPath(base, path)
-	if p.CanParse(depPath) {
+func (p *Program) ParseDep(ctx context.Context, base, path string) error {
+	depPath, err := p.ResolveDepPath(ctx, base, path)
+	if err != nil {
+		return err
+	}
+	return p.parseDep(ctx, depPath)
+}
 
-		p.ParsePath(depPath)
+// parseDep parses a dependency at the path it resolved to, unless it
+// already has been
+func (p *Program) parseDep(ctx context.Context, depPath string) error {
+	if !p.CanParse(depPath) {
+		return nil
 	}
+	return p.ParsePath(ctx, depPath)
 }
 
 // ReduceToDir takes a path and reduces it down into its directory
-func ReduceToDir(path string) string {
-	if isDir, err := PathIsDir(path); !isDir || err != nil {
+func (p *Program) ReduceToDir(path string) string {
+	if isDir, err := p.PathIsDir(path); !isDir || err != nil {
 		path = filepath.Dir(path)
 	}
 	return path
@@ -246,19 +436,73 @@ func (p *Program) RegisterFunction(name string, fn FunctionNode) {
 	p.Functions[name] = &fn
 }
 
-// Congeal sets the programs module to one with nodes filled out
-func (p *Program) Congeal() (*ir.Module, error) {
+// Congeal sets the programs module to one with nodes filled out, after
+// running the passes added with AddPass on the packages. The compile is
+// stopped when the context is done, and the rest of it, like
+// CompileEntrypoint, is compiled in the same context. A compile that is
+// stopped returns the error of the context, and leaves the program half
+// compiled, so it can't be used again.
+func (p *Program) Congeal(ctx context.Context) (*ir.Module, error) {
+	p.ctx = ctx
+	module, err := p.congeal()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return module, err
+}
+
+func (p *Program) congeal() (*ir.Module, error) {
 	var err error
+	if err := p.runPasses(); err != nil {
+		return nil, err
+	}
 	p.Module = ir.NewModule()
+	p.Module.OpaquePointers = p.OpaquePointers
 
 	nodes := make([]*PackagedNode, 0)
 
 	p.Functions = make(map[string]*FunctionNode)
 	p.Classes = make(map[string]*ClassNode)
+	p.ClassInstances = make(map[string]*types.StructType)
+	p.Enums = make(map[string]*EnumNode)
+	p.Unions = make(map[string]*UnionNode)
+	p.unionTypes = make(map[*types.StructType]*UnionNode)
+	p.Interfaces = make(map[string]*InterfaceNode)
+	p.interfaceTypes = make(map[*types.StructType]*InterfaceNode)
+	p.implementations = make(map[*types.StructType][]*InterfaceNode)
+	p.vtables = make(map[string]*ir.Global)
+	p.classParents = make(map[*types.StructType]*types.StructType)
+	p.extendedClasses = make(map[*types.StructType]bool)
+	p.virtualClasses = make(map[*types.StructType]*virtualClass)
+	p.deferred = make(map[*ir.Function]*functionDefers)
+	p.tries = make(map[*ir.Function][]*tryBlock)
+	p.optionalTypes = make(map[*types.PointerType]bool)
+	p.nilType = types.NewPointer(types.I8)
+	p.cFunctions = make(map[*ir.Function]bool)
+	p.Module.NewType(stringType.Name, stringType)
 	p.Compiler = NewCompiler(p)
+	p.packageInits = make(map[string][]string)
+
+	// packages are visited in a fixed order so the same program always
+	// compiles to the same ir
+	dirs := make([]string, 0, len(p.Packages))
+	for dir := range p.Packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
 
-	for _, pkg := range p.Packages {
-		for _, node := range pkg.Nodes {
+	for _, dir := range dirs {
+		pkg := p.Packages[dir]
+		for _, node := range withStaticMembers(pkg.Nodes) {
+
+			if fn, is := node.(FunctionNode); is && isPackageInit(fn, pkg) {
+				fn.Package = pkg
+				if err := p.registerPackageInit(fn, pkg); err != nil {
+					return nil, err
+				}
+				nodes = append(nodes, PackageNode(node, pkg, p))
+				continue
+			}
 
 			if fn, is := node.(FunctionNode); is {
 				name := fmt.Sprintf("%s:%s", pkg.Name, fn.Name)
@@ -282,30 +526,91 @@ func (p *Program) Congeal() (*ir.Module, error) {
 		}
 	}
 
-	for _, node := range FilterPackagedNodes(nodes, nodeClass) {
+	p.InitFunctions, err = p.OrderPackageInits()
+	if err != nil {
+		return nil, err
+	}
+
+	// enums come first, as classes can have fields of enum types
+	for _, node := range FilterPackagedNodes(nodes, nodeEnum) {
 		node.SetupContext()
-		_, err = node.Node.(ClassNode).Declare(p)
+		_, err = node.Node.(EnumNode).Declare(p)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// Codegen the types/classes
-	for _, node := range FilterPackagedNodes(nodes, nodeClass) {
+	for _, node := range FilterPackagedNodes(nodes, nodeInterface) {
 		node.SetupContext()
-		err := node.Node.(ClassNode).VerifyCorrectness(p)
-		util.EatError(err)
-		_, err = node.Node.(ClassNode).Codegen(p)
+		_, err = node.Node.(InterfaceNode).Declare(p)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	for _, node := range FilterPackagedNodes(nodes, nodeClass) {
+		node.SetupContext()
+		_, err = node.Node.(ClassNode).Declare(p)
+		if err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+	}
+
+	for _, node := range FilterPackagedNodes(nodes, nodeUnion) {
+		node.SetupContext()
+		_, err = node.Node.(UnionNode).Declare(p)
+		if err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+	}
+
+	// the methods of interfaces can take and return classes
+	for _, node := range FilterPackagedNodes(nodes, nodeInterface) {
+		node.SetupContext()
+		_, err = node.Node.(InterfaceNode).Codegen(p)
+		if err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+	}
+
+	// Codegen the types/classes, each after the class it extends
+	classes, err := p.orderClasses(FilterPackagedNodes(nodes, nodeClass))
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range classes {
+		node.SetupContext()
+		if err := node.Node.(ClassNode).VerifyCorrectness(p); err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+		_, err := node.Node.(ClassNode).Codegen(p)
+		if err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+	}
+
+	// unions are laid out once the classes they can hold are
+	for _, node := range FilterPackagedNodes(nodes, nodeUnion) {
+		node.SetupContext()
+		_, err = node.Node.(UnionNode).Codegen(p)
+		if err != nil {
+			return nil, node.Node.Diagnose(err)
+		}
+	}
+	if err := p.layoutUnions(); err != nil {
+		return nil, err
+	}
+
+	// Globals that are never written to after their initialization can be
+	// folded into their uses, so find out which ones are written to first.
+	p.ReassignedGlobals = p.FindReassignedGlobals()
+	p.ConstGlobals = make(map[*ir.Global]constant.Constant)
+
 	for _, pnode := range FilterPackagedNodes(nodes, nodeGlobalDecl) {
 		pnode.SetupContext()
 		_, err = pnode.Node.(GlobalVariableDeclNode).Declare(p)
 		if err != nil {
-			return nil, err
+			return nil, pnode.Node.Diagnose(err)
 		}
 	}
 
@@ -350,11 +655,20 @@ func (p *Program) RegisterGlobalVariableInitialization(node *GlobalVariableDeclN
 
 // FindType returns an llvm type based on the current state of the program and a name
 func (p *Program) FindType(name string) (types.Type, error) {
+	if strings.HasPrefix(name, "{") {
+		return p.anonymousStruct(name)
+	}
+	if strings.Contains(name, "<") {
+		return p.instantiateClass(name)
+	}
 	paths := p.GetTypeSearchPaths(name)
 	found := p.Scope.FindType(paths...)
 	if found != nil {
 		return found.Type, nil
 	}
+	if private := p.Scope.FindPrivateType(paths...); private != nil {
+		return nil, privateError("type", private.Name, private.Package, p.Scope.PackageName)
+	}
 	err := fmt.Errorf("unable to find type %q in the scope. search paths: [%s]", name, strings.Join(paths, ", "))
 	return nil, err
 }
@@ -381,10 +695,33 @@ func (p *Program) GetTypeSearchPaths(base string) []string {
 	return names
 }
 
+// LookupFunctionNode finds the declaration of a function by the name it
+// is referenced with from the current package, without compiling it
+func (p *Program) LookupFunctionNode(name string) *FunctionNode {
+	ns, nm := ParseName(name)
+	if ns == "" {
+		ns = p.Scope.PackageName
+	}
+	searchNames := []string{
+		fmt.Sprintf("%s:%s", ns, nm),
+		fmt.Sprintf("%s:%s", p.Package.Name, nm),
+		nm,
+	}
+	for _, search := range searchNames {
+		if fn, found := p.Functions[search]; found {
+			return fn
+		}
+	}
+	return nil
+}
+
 // FindFunction searches for a function with a searchName searchpath and the types it is being called with
 func (p *Program) FindFunction(searchNames []string, argTypes []types.Type) (*ir.Function, error) {
 	// var err error
 	for _, name := range searchNames {
+		if node, exists := p.Functions[name]; exists && !p.functionVisible(node) {
+			return nil, privateError("function", name, node.Package.Name, p.Package.Name)
+		}
 		compOpts := FunctionCompilationOptions{}
 		compOpts.ArgTypes = argTypes
 		callee, err := p.GetFunction(name, compOpts)
@@ -397,14 +734,25 @@ func (p *Program) FindFunction(searchNames []string, argTypes []types.Type) (*ir
 		}
 	}
 
-	return nil, fmt.Errorf("unable to find function with names %s", searchNames)
+	// the same name can be searched more than once
+	names := make([]string, 0, len(searchNames))
+	for _, name := range searchNames {
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+	}
+	return nil, newError(lexer.Token{}, ErrUndefined, "function %s is not defined", strings.Join(names, " or "))
 }
 
 // GetFunction takes a funciton node, detects if it is already compiled or not
-// if it isnt compiled, it will codegen, otherwise it will return the compiled one
+// if it isnt compiled, it will codegen, otherwise it will return the compiled one.
+// Once the context of the compile is done it returns the context's error.
 func (p *Program) GetFunction(name string, options FunctionCompilationOptions) (*ir.Function, error) {
 
 	var err error
+	if err := p.ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// Save the program state
 	previousPackage := p.Package
@@ -435,6 +783,21 @@ func (p *Program) GetFunction(name string, options FunctionCompilationOptions) (
 
 	// p.Compiler = NewCompiler(p)
 
+	if node.TypeBindings != nil {
+		p.registerTypeBindings(node.TypeBindings)
+	}
+
+	// The type parameters of a generic function are bound in the scope it
+	// is compiled in, so its signature and body see the types it was
+	// called with
+	if node.HasUnknownType && options.ArgTypes != nil && !node.Variadic {
+		bindings, err := node.bindTypeParams(p, options.ArgTypes)
+		if err != nil {
+			return nil, err
+		}
+		p.registerTypeBindings(bindings)
+	}
+
 	_, rawTypes, err := node.Arguments(p)
 
 	if err != nil {
@@ -455,37 +818,43 @@ func (p *Program) GetFunction(name string, options FunctionCompilationOptions) (
 
 	}
 
+	// Variants are keyed by the mangled name, which holds the package of
+	// the function and the types it was instantiated with, so every
+	// package calling a generic function with the same types shares
+	// one instantiation of it
 	if node.Variants == nil {
 		node.Variants = make(map[string]*ir.Function)
 	}
 
 	correctTypes := make([]types.Type, 0, len(rawTypes))
 
+	// Without argument types, as when an @export function is compiled, the
+	// function is compiled for the types it declares
+	if options.ArgTypes == nil && !node.Variadic && !node.HasUnknownType {
+		correctTypes = rawTypes
+	}
+
 	if options.ArgTypes != nil && !node.Variadic {
 
 		for i, expected := range rawTypes {
 
-			nodeParamType := node.Args[i].Type
 			given := options.ArgTypes[i]
-			unknown := nodeParamType.Unknown
 
-			if (expected != nil && given != nil) && !types.Equal(expected, given) && !typesAreLooselyEqual(given, expected) && !unknown {
+			if (expected != nil && given != nil) && !types.Equal(expected, given) && !typesAreLooselyEqual(given, expected) && !p.convertsToInterface(given, expected) && !p.convertsToOptional(given, expected) && !p.isUpcast(given, expected) && !(node.External && convertsToC(given, expected)) {
 				return nil, fmt.Errorf("incorrect type passed into function %s. given: %q, expected: %q", node.Name, given, expected)
 			}
 
-			if unknown {
-				// Handling unknown types's scope definition on call
-				p.Scope.RegisterType(node.Args[i].Type.Name, given, 0)
-				correctTypes = append(correctTypes, given)
-			} else {
-				correctTypes = append(correctTypes, expected)
-			}
+			correctTypes = append(correctTypes, expected)
 		}
 	}
 
 	var compiledVal *ir.Function
 
-	if node.Nomangle {
+	if name == "main" {
+		node.NameCache = userMainName
+	} else if sym, found := node.symbolName(); found {
+		node.NameCache = sym
+	} else if node.Nomangle {
 		node.NameCache = node.Name.Value
 	} else {
 		node.NameCache = node.MangledName(p, correctTypes)
@@ -497,13 +866,13 @@ func (p *Program) GetFunction(name string, options FunctionCompilationOptions) (
 
 		node.Variants[node.NameCache], err = node.Declare(p)
 		if err != nil {
-			return nil, err
+			return nil, node.Diagnose(err)
 		}
 		node.Compiled = true
 		if !node.External {
 			gen, err := node.Codegen(p)
 			if err != nil {
-				return nil, err
+				return nil, node.Diagnose(err)
 			}
 
 			node.Variants[node.NameCache] = gen.(*ir.Function)
@@ -527,18 +896,31 @@ func (p *Program) GetClassMethods(name string) ([]*FunctionNode, error) {
 	return nil, fmt.Errorf("unable to find methods for class '%s'", name)
 }
 
+// runtimeFunction returns a function of the runtime package, which is an
+// error when the program is compiled without it
+func (p *Program) runtimeFunction(name string) (*ir.Function, error) {
+	fn, err := p.GetFunction(name, FunctionCompilationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if fn == nil {
+		return nil, fmt.Errorf("unable to find runtime function %s, the program is compiled without the runtime", name)
+	}
+	return fn, nil
+}
+
 // NewRuntimeFunctionCall returns an instance of a function call to a runtime funciton
 func (p *Program) NewRuntimeFunctionCall(name string, args ...value.Value) (*ir.InstCall, error) {
-	fn, err := p.GetFunction(name, FunctionCompilationOptions{})
+	fn, err := p.runtimeFunction(name)
 	if err != nil {
 		return nil, err
 	}
-	return p.Compiler.CurrentBlock().NewCall(fn, args...), nil
+	return p.Compiler.CurrentBlock().NewCall(fn, p.cArgs(fn, args)...), nil
 }
 
 // Emit will emit the package as IR to a file then build it into an object file for further usage.
 // This function returns the path to the object file
-func (p *Program) Emit(buildDir string) string {
+func (p *Program) Emit(buildDir string) (string, error) {
 	outPathBase, _ := filepath.Abs(p.Entry)
 
 	outPathBase = path.Join(buildDir, outPathBase)
@@ -547,18 +929,69 @@ func (p *Program) Emit(buildDir string) string {
 
 	baseDir := filepath.Dir(outPathBase)
 
-	os.MkdirAll(baseDir, os.ModePerm)
+	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	// llc compiles the object file from bitcode, which is written without
+	// debug information, and the other formats are emitted from ir files
+	alternateEmission := *arg.EmitASM || *arg.EmitLLVM || *arg.EmitObject
+	if *arg.DirectObject && !*arg.EnableDebug && !alternateEmission {
+		// the source's extension is kept, as c sources beside it are
+		// compiled to the same name with only .o
+		objFileName := fmt.Sprintf("%s%s.o", outPathBase, extension)
+		opts := llc.Options{
+			Target:   p.TargetTripple,
+			Optimize: *arg.Optimize,
+		}
+		var err error
+		log.Timed("Object File Generation", func() {
+			err = llc.Compile(p.bitcodeModule(), objFileName, opts)
+		})
+		if err != nil {
+			return "", fmt.Errorf("unable to compile object file: %s", err)
+		}
+		return objFileName, nil
+	}
+
+	// bitcode is written without debug information, so builds with it
+	// still write the textual ir
+	if *arg.EmitBitcode && !*arg.EnableDebug {
+		bitcodeFileName := fmt.Sprintf("%s.bc", outPathBase)
+		if err := p.writeBitcode(bitcodeFileName); err != nil {
+			return "", fmt.Errorf("unable to write bitcode: %s", err)
+		}
+		return bitcodeFileName, nil
+	}
 
 	llvmFileName := fmt.Sprintf("%s.ll", outPathBase)
 
 	ir := p.String()
 
-	writeErr := ioutil.WriteFile(llvmFileName, []byte(ir), 0666)
-	if writeErr != nil {
-		panic(writeErr)
+	if err := ioutil.WriteFile(llvmFileName, []byte(ir), 0666); err != nil {
+		return "", err
 	}
 
-	return llvmFileName
+	return llvmFileName, nil
+}
+
+// bitcodeModule returns the module with the data layout and target the
+// textual ir is written with
+func (p *Program) bitcodeModule() *ir.Module {
+	m := *p.Compiler.Module
+	m.DataLayout = p.Target.DataLayout
+	m.TargetTriple = p.TargetTripple
+	return &m
+}
+
+// writeBitcode writes the module to a file as llvm bitcode
+func (p *Program) writeBitcode(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return bitcode.Encode(file, p.bitcodeModule())
 }
 
 // String will  the LLVM IR from the package's compiler
@@ -566,7 +999,8 @@ func (p *Program) String() string {
 	ir := &bytes.Buffer{}
 	// We need to build up the IR that will be emitted
 	// so we can track this information later on.
-	fmt.Fprintf(ir, "target datalayout = %q\n", "e-m:o-i64:64-f80:128-n8:16:32:64-S128")
+	fmt.Fprintf(ir, "source_filename = %q\n", util.TrimPath(p.Entry))
+	fmt.Fprintf(ir, "target datalayout = %q\n", p.Target.DataLayout)
 	fmt.Fprintf(ir, "target triple = %q\n", p.TargetTripple)
 
 	// Append the module information
@@ -577,12 +1011,35 @@ func (p *Program) String() string {
 
 var packagedir = "geodepkgs"
 
+// dependencies are the directories or repositories of the packages a
+// project's manifest declares, by the names they are included with
+var dependencies = map[string]string{}
+
+// SetDependencies sets the directories or repositories packages included by
+// name are found in, before the search paths are
+func SetDependencies(deps map[string]string) {
+	dependencies = deps
+}
+
+// AddSearchPath adds a directory packages are searched for in. They are
+// searched in the order they are added, after the ones in GEODE_PATH and
+// before the standard library.
+func (p *Program) AddSearchPath(dir string) {
+	// packages are told apart by their directories, which are absolute
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	p.searchPaths = append(p.searchPaths, dir)
+}
+
 // SearchPaths returns all paths that dependencies could be located in
-func SearchPaths(base string) []string {
+func (p *Program) SearchPaths(base string) []string {
 	sp := make([]string, 0)
 
 	sp = append(sp, base)
-	sp = append(sp, "/usr/local/lib/geodelib")
+	sp = append(sp, util.SearchRoots()...)
+	sp = append(sp, p.searchPaths...)
+	sp = append(sp, util.StdLibDir())
 
 	for base != "/" && base != "." {
 		dir := filepath.Join(base, packagedir)
@@ -593,37 +1050,67 @@ func SearchPaths(base string) []string {
 	return sp
 }
 
-// ResolveDepPath returns the absolute location to a dependency
-func ResolveDepPath(base, filename string) string {
+// ResolveDepPath returns the absolute location to a dependency. Packages
+// in repositories are fetched with the context.
+func (p *Program) ResolveDepPath(ctx context.Context, base, filename string) (string, error) {
 
 	if strings.HasPrefix(filename, "std:") {
 		filename = strings.Replace(filename, "std:", "", -1)
 		// Join up the new filename to the standard library source location
 		base = util.StdLibFile(filename)
-		return filepath.Join(base, filename)
+		return filepath.Join(base, filename), nil
+	}
+
+	if dep, found := dependencies[filename]; found {
+		if !fetch.IsRemote(dep) {
+			return dep, nil
+		}
+		filename = dep
+	}
+	if fetch.IsRemote(filename) {
+		return fetchDependency(ctx, filename)
 	}
 
 	// fmt.Printf("\n\n")
-	searchPaths := append([]string{filepath.Join(base, filename)}, SearchPaths(base)...)
+	searchPaths := append([]string{filepath.Join(base, filename)}, p.SearchPaths(base)...)
 
+	// packages the manifest wants a version of are only found in
+	// versioned directories
+	_, versioned := versionConstraints[filename]
 	for _, sp := range searchPaths {
 		abs := filepath.Join(sp, filename)
 
-		if is, _ := PathIsDir(abs); is {
-			return abs
+		if is, _ := p.PathIsDir(abs); is && !versioned {
+			return abs, nil
+		}
+		dir, err := p.resolveVersion(sp, filename)
+		if err != nil || dir != "" {
+			return dir, err
 		}
 	}
-	return filepath.Join(base, filename)
+	if versioned {
+		return "", p.missingVersion(p.SearchPaths(base), filename)
+	}
+	return filepath.Join(base, filename), nil
 }
 
-// PathIsDir returns if a given path is a directory or not
-func PathIsDir(pth string) (bool, error) {
-	fd, err := os.Open(pth)
+// fetchDependency returns the directory of a package in a repository,
+// which is cloned the first time it is included
+func fetchDependency(ctx context.Context, path string) (string, error) {
+	dir, err := fetch.Fetch(ctx, path)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("failed to fetch %s: %s", path, err)
 	}
-	defer fd.Close()
-	stat, err := fd.Stat()
+	return dir, nil
+}
+
+// PathIsDir returns if a given path is a directory in the sources of the
+// program or not
+func (p *Program) PathIsDir(pth string) (bool, error) {
+	stat, err := p.sources().Stat(pth)
 	if err != nil {
 		return false, err
 	}
@@ -642,6 +1129,19 @@ func NamespaceFromNodes(nodes []Node) (string, error) {
 	return "", fmt.Errorf("nodes have no package name")
 }
 
+// withStaticMembers returns the nodes along with the static members of
+// the classes in them
+func withStaticMembers(nodes []Node) []Node {
+	all := make([]Node, 0, len(nodes))
+	for _, node := range nodes {
+		all = append(all, node)
+		if cls, is := node.(ClassNode); is {
+			all = append(all, cls.staticMembers()...)
+		}
+	}
+	return all
+}
+
 // FilterNodes returns only the nodes that have the type passed in
 func FilterNodes(nodes []Node, t NodeType) []Node {
 	filtered := make([]Node, 0)
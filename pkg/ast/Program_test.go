@@ -0,0 +1,76 @@
+package ast
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProgramSearchPaths(t *testing.T) {
+	rel, err := filepath.Abs("rel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEODE_PATH", strings.Join([]string{"/roots/a", "", "/roots/b", "rel"}, string(filepath.ListSeparator)))
+	t.Setenv("GEODELIB", "/stdlib")
+
+	p := NewProgram()
+	p.AddSearchPath("/added")
+	got := p.SearchPaths("/proj/app")
+	// the directory of the package, GEODE_PATH, the added paths, the
+	// standard library and then the geodepkgs of every parent
+	want := []string{"/proj/app", "/roots/a", "/roots/b", rel, "/added", "/stdlib", "/proj/app/geodepkgs", "/proj/geodepkgs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// A package is included from the first search path that has it
+func TestIncludeSearchOrder(t *testing.T) {
+	which := func(n string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte("is which\n\npub func which int = " + n + ";\n")}
+	}
+	main := &fstest.MapFile{Data: []byte("is main\n\ninclude \"which\"\n\nfunc main int {\n\treturn which:which();\n}\n")}
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"first root", map[string]string{"roots/a": "1", "roots/b": "2", "added": "3", "stdlib": "4"}, "1"},
+		{"second root", map[string]string{"roots/b": "2", "added": "3", "stdlib": "4"}, "2"},
+		{"added", map[string]string{"added": "3", "stdlib": "4", "proj/geodepkgs": "5"}, "3"},
+		{"stdlib", map[string]string{"stdlib": "4", "proj/geodepkgs": "5"}, "4"},
+		{"geodepkgs", map[string]string{"proj/geodepkgs": "5"}, "5"},
+		// the directory of the package comes before all of them
+		{"beside", map[string]string{"proj/app": "6", "roots/a": "1"}, "6"},
+	}
+	t.Setenv("GEODE_PATH", "/roots/a"+string(filepath.ListSeparator)+"/roots/b")
+	t.Setenv("GEODELIB", "/stdlib")
+	for _, test := range tests {
+		sources := fstest.MapFS{"proj/app/main.g": main}
+		for dir, n := range test.files {
+			sources[dir+"/which/which.g"] = which(n)
+		}
+
+		m, diagnostics, err := Compile(context.Background(), Source{Path: "/proj/app/main.g"}, CompileOptions{
+			Sources:     FSSources("/", sources),
+			SearchPaths: []string{"/added"},
+			NoRuntime:   true,
+		})
+		if err != nil {
+			t.Fatalf("%s: %s %v", test.name, err, diagnostics)
+		}
+		var body string
+		for _, f := range m.Funcs {
+			if strings.Contains(f.Name, "which") {
+				body = f.String()
+			}
+		}
+		if !strings.Contains(body, "ret i32 "+test.want) {
+			t.Errorf("%s: which is\n%s\nwant it to return %s", test.name, body, test.want)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package ast
+
+import (
+	"reflect"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// cleanupPromoteAllocas turns the allocas of variables that are only ever
+// loaded and stored into ssa values, like llvm's mem2reg. Loads are
+// replaced by the value stored last on the way to them, and phis are
+// placed where the stores of different paths meet.
+func cleanupPromoteAllocas(fn *ir.Function) bool {
+	allocas := promotableAllocas(fn)
+	if len(allocas) == 0 {
+		return false
+	}
+
+	p := &promotion{
+		allocas: make(map[*ir.InstAlloca]int, len(allocas)),
+		phis:    make(map[*ir.InstPhi]int),
+		replace: make(map[value.Value]value.Value),
+		dom:     newDominators(fn),
+	}
+	for i, alloca := range allocas {
+		p.allocas[alloca] = i
+	}
+	p.placePhis(fn, allocas)
+
+	initial := make([]value.Value, len(allocas))
+	for i, alloca := range allocas {
+		initial[i] = constant.NewUndef(alloca.Elem)
+	}
+	p.rename(fn.Blocks[0], initial)
+
+	p.removeUselessPhis(fn)
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			replaceOperands(inst, p.resolve)
+		}
+		replaceOperands(block.Term, p.resolve)
+	}
+	return true
+}
+
+// promotableAllocas returns the allocas of one value whose address is
+// only loaded from and stored to, with the type it was allocated with
+func promotableAllocas(fn *ir.Function) []*ir.InstAlloca {
+	candidates := make(map[*ir.InstAlloca]bool)
+	order := make([]*ir.InstAlloca, 0)
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstAlloca:
+				if inst.NElems == nil {
+					candidates[inst] = true
+					order = append(order, inst)
+				}
+			case *LLVMRaw, *LLVMIdent:
+				// raw ir can refer to anything by name
+				return nil
+			}
+		}
+	}
+
+	escape := func(operands []value.Value) {
+		for _, op := range operands {
+			if alloca, ok := op.(*ir.InstAlloca); ok {
+				delete(candidates, alloca)
+			}
+		}
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			switch inst := inst.(type) {
+			case *ir.InstLoad:
+				if alloca, ok := inst.Src.(*ir.InstAlloca); ok {
+					if inst.Volatile || inst.Ordering != ir.OrderingNone || !sameLLVMType(inst.Typ, alloca.Elem) {
+						delete(candidates, alloca)
+					}
+				}
+				continue
+			case *ir.InstStore:
+				escape([]value.Value{inst.Src})
+				if alloca, ok := inst.Dst.(*ir.InstAlloca); ok {
+					if inst.Volatile || inst.Ordering != ir.OrderingNone || !sameLLVMType(inst.Src.Type(), alloca.Elem) {
+						delete(candidates, alloca)
+					}
+				}
+				continue
+			}
+			escape(instructionOperands(inst))
+		}
+		escape(instructionOperands(block.Term))
+	}
+
+	allocas := make([]*ir.InstAlloca, 0, len(candidates))
+	for _, alloca := range order {
+		if candidates[alloca] {
+			allocas = append(allocas, alloca)
+		}
+	}
+	return allocas
+}
+
+// sameLLVMType returns if two types are the same to llvm, which doesn't
+// know about the sign of integers
+func sameLLVMType(a, b types.Type) bool {
+	return a.String() == b.String()
+}
+
+// A promotion is the state of promoting the allocas of a function
+type promotion struct {
+	// allocas maps the allocas being promoted to their index
+	allocas map[*ir.InstAlloca]int
+	// phis maps the phis placed for an alloca to its index
+	phis map[*ir.InstPhi]int
+	// placed are the phis in the order they were placed
+	placed []*ir.InstPhi
+	// replace maps the loads and phis that are removed to the value that
+	// replaces them
+	replace map[value.Value]value.Value
+	dom     *dominators
+}
+
+// placePhis places a phi for an alloca at the start of every block in the
+// iterated dominance frontier of the blocks that store to it
+func (p *promotion) placePhis(fn *ir.Function, allocas []*ir.InstAlloca) {
+	stores := make([][]*ir.BasicBlock, len(allocas))
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			if store, ok := inst.(*ir.InstStore); ok {
+				if alloca, ok := store.Dst.(*ir.InstAlloca); ok {
+					if i, promoted := p.allocas[alloca]; promoted {
+						stores[i] = append(stores[i], block)
+					}
+				}
+			}
+		}
+	}
+
+	// the phis of a block, in the order of the allocas
+	placed := make(map[*ir.BasicBlock][]ir.Instruction)
+	for i, alloca := range allocas {
+		hasPhi := make(map[*ir.BasicBlock]bool)
+		work := append([]*ir.BasicBlock{}, stores[i]...)
+		for len(work) > 0 {
+			block := work[len(work)-1]
+			work = work[:len(work)-1]
+			for _, frontier := range p.dom.frontier[block] {
+				if hasPhi[frontier] {
+					continue
+				}
+				hasPhi[frontier] = true
+				phi := &ir.InstPhi{Typ: alloca.Elem, Metadata: make(map[string]*metadata.Metadata)}
+				phi.SetParent(frontier)
+				p.phis[phi] = i
+				p.placed = append(p.placed, phi)
+				placed[frontier] = append(placed[frontier], phi)
+				work = append(work, frontier)
+			}
+		}
+	}
+	for block, phis := range placed {
+		block.Insts = append(phis, block.Insts...)
+	}
+}
+
+// rename walks the dominator tree from a block, removing the loads and
+// stores of the allocas and filling in the phis of the blocks branched to.
+// values holds the value of each alloca at the start of the block.
+func (p *promotion) rename(block *ir.BasicBlock, values []value.Value) {
+	values = append([]value.Value{}, values...)
+	insts := block.Insts[:0]
+	for _, inst := range block.Insts {
+		switch inst := inst.(type) {
+		case *ir.InstPhi:
+			if i, ok := p.phis[inst]; ok {
+				values[i] = inst
+			}
+		case *ir.InstAlloca:
+			if _, ok := p.allocas[inst]; ok {
+				continue
+			}
+		case *ir.InstLoad:
+			if alloca, ok := inst.Src.(*ir.InstAlloca); ok {
+				if i, ok := p.allocas[alloca]; ok {
+					p.replace[inst] = values[i]
+					continue
+				}
+			}
+		case *ir.InstStore:
+			if alloca, ok := inst.Dst.(*ir.InstAlloca); ok {
+				if i, ok := p.allocas[alloca]; ok {
+					values[i] = inst.Src
+					continue
+				}
+			}
+		}
+		insts = append(insts, inst)
+	}
+	block.Insts = insts
+
+	// a block branched to more than once gets an incoming value for every
+	// branch
+	for _, succ := range block.Term.Succs() {
+		for _, inst := range succ.Insts {
+			phi, ok := inst.(*ir.InstPhi)
+			if !ok {
+				break
+			}
+			if i, ok := p.phis[phi]; ok {
+				phi.Incs = append(phi.Incs, ir.NewIncoming(values[i], block))
+			}
+		}
+	}
+
+	for _, child := range p.dom.children[block] {
+		p.rename(child, values)
+	}
+}
+
+// resolve returns the value that replaces a value
+func (p *promotion) resolve(v value.Value) value.Value {
+	for {
+		next, found := p.replace[v]
+		if !found {
+			return v
+		}
+		v = next
+	}
+}
+
+// removeUselessPhis removes the placed phis that only ever have one value,
+// which replaces them, and the ones nothing uses
+func (p *promotion) removeUselessPhis(fn *ir.Function) {
+	for changed := true; changed; {
+		changed = false
+		for _, phi := range p.placed {
+			if _, removed := p.replace[phi]; removed {
+				continue
+			}
+			var only value.Value
+			trivial := true
+			for _, inc := range phi.Incs {
+				x := p.resolve(inc.X)
+				if x == phi || x == only {
+					continue
+				}
+				if only != nil {
+					trivial = false
+					break
+				}
+				only = x
+			}
+			if trivial && only != nil {
+				p.replace[phi] = only
+				changed = true
+			}
+		}
+	}
+
+	// the phis used by other instructions are live, and so are the phis
+	// live phis use
+	live := make(map[*ir.InstPhi]bool)
+	work := make([]*ir.InstPhi, 0)
+	use := func(operands []value.Value) {
+		for _, op := range operands {
+			phi, ok := p.resolve(op).(*ir.InstPhi)
+			if _, placed := p.phis[phi]; ok && placed && !live[phi] {
+				live[phi] = true
+				work = append(work, phi)
+			}
+		}
+	}
+	for _, block := range fn.Blocks {
+		for _, inst := range block.Insts {
+			if phi, ok := inst.(*ir.InstPhi); ok {
+				if _, placed := p.phis[phi]; placed {
+					continue
+				}
+			}
+			use(instructionOperands(inst))
+		}
+		use(instructionOperands(block.Term))
+	}
+	for len(work) > 0 {
+		phi := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, inc := range phi.Incs {
+			use([]value.Value{inc.X})
+		}
+	}
+
+	for _, block := range fn.Blocks {
+		insts := block.Insts[:0]
+		for _, inst := range block.Insts {
+			if phi, ok := inst.(*ir.InstPhi); ok {
+				if _, placed := p.phis[phi]; placed && !live[phi] {
+					continue
+				}
+			}
+			insts = append(insts, inst)
+		}
+		block.Insts = insts
+	}
+}
+
+// replaceOperands replaces the values an instruction uses. Like
+// instructionOperands, it finds them from the instruction's fields.
+func replaceOperands(inst interface{}, replace func(value.Value) value.Value) {
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		switch {
+		case v.Type() == valueType:
+			if !v.IsNil() && v.CanSet() {
+				v.Set(reflect.ValueOf(replace(v.Interface().(value.Value))))
+			}
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		case v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct && !v.IsNil():
+			if _, isBlock := v.Interface().(*ir.BasicBlock); !isBlock {
+				visit(v.Elem())
+			}
+		case v.Kind() == reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).PkgPath == "" {
+					visit(v.Field(i))
+				}
+			}
+		}
+	}
+	visit(reflect.ValueOf(inst).Elem())
+}
+
+// dominators is the dominator tree of a function and the dominance
+// frontier of its blocks
+type dominators struct {
+	idom     map[*ir.BasicBlock]*ir.BasicBlock
+	children map[*ir.BasicBlock][]*ir.BasicBlock
+	frontier map[*ir.BasicBlock][]*ir.BasicBlock
+}
+
+// newDominators finds the dominators of the blocks of a function with the
+// iterative algorithm of Cooper, Harvey and Kennedy. Every block has to be
+// reachable from the entry block.
+func newDominators(fn *ir.Function) *dominators {
+	// blocks in reverse postorder, which the entry block comes first in
+	order := make([]*ir.BasicBlock, 0, len(fn.Blocks))
+	visited := make(map[*ir.BasicBlock]bool)
+	var visit func(block *ir.BasicBlock)
+	visit = func(block *ir.BasicBlock) {
+		visited[block] = true
+		for _, succ := range block.Term.Succs() {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		order = append(order, block)
+	}
+	visit(fn.Blocks[0])
+	index := make(map[*ir.BasicBlock]int, len(order))
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	for i, block := range order {
+		index[block] = i
+	}
+
+	preds := blockPredecessors(fn)
+	entry := order[0]
+	idom := map[*ir.BasicBlock]*ir.BasicBlock{entry: entry}
+	intersect := func(a, b *ir.BasicBlock) *ir.BasicBlock {
+		for a != b {
+			for index[a] > index[b] {
+				a = idom[a]
+			}
+			for index[b] > index[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, block := range order[1:] {
+			var dom *ir.BasicBlock
+			for _, pred := range preds[block] {
+				if idom[pred] == nil {
+					continue
+				}
+				if dom == nil {
+					dom = pred
+				} else {
+					dom = intersect(pred, dom)
+				}
+			}
+			if idom[block] != dom {
+				idom[block] = dom
+				changed = true
+			}
+		}
+	}
+
+	d := &dominators{
+		idom:     idom,
+		children: make(map[*ir.BasicBlock][]*ir.BasicBlock),
+		frontier: make(map[*ir.BasicBlock][]*ir.BasicBlock),
+	}
+	for _, block := range order[1:] {
+		d.children[idom[block]] = append(d.children[idom[block]], block)
+	}
+	for _, block := range order {
+		if len(preds[block]) < 2 {
+			continue
+		}
+		for _, pred := range preds[block] {
+			for runner := pred; runner != idom[block]; runner = idom[runner] {
+				if !containsBlock(d.frontier[runner], block) {
+					d.frontier[runner] = append(d.frontier[runner], block)
+				}
+				if runner == entry {
+					break
+				}
+			}
+		}
+	}
+	return d
+}
+
+func containsBlock(blocks []*ir.BasicBlock, block *ir.BasicBlock) bool {
+	for _, b := range blocks {
+		if b == block {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// rangeFunc returns the runtime's iterator over a range of integers
+const rangeFunc = "__runtime_range"
+
+// RangeNode is a range of integers, like `0..10`, from Start up to but not
+// including End. Its value is the runtime's IntRange iterator, so a range
+// is looped over like any other iterator: `for i in 0..10 { ... }`
+type RangeNode struct {
+	NodeType
+	TokenReference
+
+	Start Node
+	End   Node
+}
+
+func newRangeNode(tok lexer.Token, start, end Node) RangeNode {
+	n := RangeNode{}
+	n.Token = tok
+	n.NodeType = nodeRange
+	n.Start = start
+	n.End = end
+	return n
+}
+
+// NameString implements Node.NameString
+func (n RangeNode) NameString() string { return "RangeNode" }
+
+// MarshalJSON implements json.Marshaler for RangeNode
+func (n RangeNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n RangeNode) String() string {
+	return fmt.Sprintf("%s..%s", n.Start, n.End)
+}
+
+// Codegen implements Node.Codegen for RangeNode
+func (n RangeNode) Codegen(prog *Program) (value.Value, error) {
+	call := FunctionCallNode{}
+	call.Token = n.Token
+	call.NodeType = nodeFunctionCall
+	call.Name = NewIdentNode(rangeFunc)
+	call.Args = []Node{n.Start, n.End}
+	return call.Codegen(prog)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n RangeNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
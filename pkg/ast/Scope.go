@@ -3,6 +3,7 @@ package ast
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/geode-lang/geode/llvm/ir"
 	"github.com/geode-lang/geode/llvm/ir/metadata"
@@ -23,7 +24,12 @@ type Scope struct {
 	Vals        map[string]ScopeItem  `json:"values"`
 	Types       map[string]*ScopeType `json:"types"`
 	PackageName string                `json:"package_name"`
-	DebugInfo   *metadata.Named
+	// DebugInfo is the debug scope of the scope, if it is in a function
+	// with debug information
+	DebugInfo *metadata.Metadata
+	// scopes counts the scopes in the tree, which are indexed in the order
+	// they are made
+	scopes *int
 }
 
 // Add a value to this specific scope
@@ -46,6 +52,10 @@ func (s *Scope) Find(searchPaths []string) (ScopeItem, bool) {
 	return nil, false
 }
 
+// similarNameDistance is how similar a name has to be to an undefined one
+// to be suggested in its place
+const similarNameDistance = 0.85
+
 // GetSimilarName returns the most similar name in the parent scopes
 func (s *Scope) GetSimilarName(name string) (string, float64) {
 	names := s.GetNames()
@@ -54,6 +64,9 @@ func (s *Scope) GetSimilarName(name string) (string, float64) {
 		names = append(names, tmp.GetNames()...)
 		tmp = tmp.Parent
 	}
+	if len(names) == 0 {
+		return "", 0
+	}
 	type info struct {
 		name string
 		dist float64
@@ -132,27 +145,46 @@ func (s *Scope) FindFunctions(needle string) ([]FunctionScopeItem, []GenericTemp
 }
 
 // FindType returns the type stored with a name in this scope
+// that the package of the scope can see
 func (s *Scope) FindType(names ...string) *ScopeType {
+	return s.findType(s.PackageName, names)
+}
+
+func (s *Scope) findType(from string, names []string) *ScopeType {
 	var v *ScopeType
 	var ok bool
 	for _, name := range names {
 
 		v, ok = s.Types[name]
-		if ok {
+		if ok && visibleFrom(v.Visibility, v.Package, from) {
 			return v
 		}
 	}
 	if s.Parent == nil {
 		return nil
 	}
-	return s.Parent.FindType(names...)
+	return s.Parent.findType(from, names)
+}
+
+// FindPrivateType returns a type with one of the names that FindType
+// refused to find because it is private to another package
+func (s *Scope) FindPrivateType(names ...string) *ScopeType {
+	from := s.PackageName
+	for scope := s; scope != nil; scope = scope.Parent {
+		for _, name := range names {
+			if v, ok := scope.Types[name]; ok && !visibleFrom(v.Visibility, v.Package, from) {
+				return v
+			}
+		}
+	}
+	return nil
 }
 
 // FindTypeName returns the geode defined type name
 // for an llvm type representation
 func (s *Scope) FindTypeName(t types.Type) (string, error) {
 	for _, val := range s.Types {
-		if types.Equal(val.Type, t) {
+		if types.Equal(val.Type, t) && !val.Alias {
 			return val.Name, nil
 		}
 	}
@@ -181,13 +213,30 @@ func (s *Scope) InjectPrimitives() {
 	s.RegisterType("int", types.I32, 4)
 	s.RegisterType("long", types.I64, 5)
 
-	s.RegisterType("big", types.NewInt(128), 128)
+	s.RegisterType("u8", types.U8, 2)
+	s.RegisterType("u16", types.U16, 3)
+	s.RegisterType("u32", types.U32, 4)
+	s.RegisterType("u64", types.U64, 5)
+
+	s.RegisterType("i128", types.I128, 6)
+	s.RegisterType("u128", types.U128, 6)
+
 	s.RegisterType("large", types.NewInt(256), 256)
 	s.RegisterType("huge", types.NewInt(512), 512)
 
 	s.RegisterType("float", types.Double, 11)
-	s.RegisterType("string", types.NewPointer(types.I8), 0)
+	s.RegisterType("f32", types.Float, 10)
+	s.RegisterType("string", stringType, 0)
 	s.RegisterType("void", types.Void, 0)
+
+	// a rune is a unicode code point, as decoded from a utf-8 string
+	s.RegisterTypeAlias("rune", "int")
+	// big was the name of i128 before it had one
+	s.RegisterTypeAlias("big", "i128")
+
+	for name, t := range vectorTypes {
+		s.RegisterType(name, t, 0)
+	}
 }
 
 // RegisterType takes information about some type and binds it to this scope
@@ -195,9 +244,29 @@ func (s *Scope) RegisterType(name string, t types.Type, prec int) {
 	s.Types[name] = NewScopeType(name, t, prec)
 }
 
+// SetTypeVisibility records the package that declared a type in this
+// scope, and if other packages can see it
+func (s *Scope) SetTypeVisibility(name string, pkg string, vis Visibility) {
+	t := s.Types[name]
+	t.Package = pkg
+	t.Visibility = vis
+}
+
+// RegisterTypeAlias binds name to the same type as target. Aliases are
+// never picked when naming a type, so int is never reported as rune.
+func (s *Scope) RegisterTypeAlias(name string, target string) {
+	t := s.FindType(target)
+	alias := NewScopeType(name, t.Type, t.Prec)
+	alias.Alias = true
+	s.Types[name] = alias
+}
+
 // SpawnChild takes a parent scope and creates a new variable scope for scoped variable access.
 func (s *Scope) SpawnChild() *Scope {
 	child := NewScope()
+	child.Index = *s.scopes
+	child.scopes = s.scopes
+	*s.scopes++
 	child.Parent = s
 	child.Vals = make(map[string]ScopeItem)
 	child.Types = make(map[string]*ScopeType)
@@ -216,13 +285,11 @@ func (s *Scope) GetRoot() *Scope {
 	return scope
 }
 
-var scopeIndex = 0
-
 // NewScope creates a scope (for use when generating root scopes)
 func NewScope() *Scope {
 	n := &Scope{}
-	n.Index = scopeIndex
-	scopeIndex++
+	n.scopes = new(int)
+	*n.scopes++
 	n.Parent = nil
 	n.Vals = make(map[string]ScopeItem)
 	n.Types = make(map[string]*ScopeType)
@@ -425,7 +492,8 @@ func (item VariableScopeItem) Node() Node {
 	return item.node
 }
 
-var varIndex = 0
+// varIndex numbers the variables, for their mangled names
+var varIndex int32
 
 // NewVariableScopeItem constructs a function scope item
 func NewVariableScopeItem(name string, value value.Value, vis Visibility) VariableScopeItem {
@@ -434,7 +502,7 @@ func NewVariableScopeItem(name string, value value.Value, vis Visibility) Variab
 	item.value = value
 
 	item.vis = vis
-	item.varIndex = varIndex
+	item.varIndex = int(atomic.AddInt32(&varIndex, 1)) - 1
 
 	// Here we need to do something special. This is in order to fix the bug where you cannot define
 	// a variable if it has already been defined in another block in the same function
@@ -447,7 +515,6 @@ func NewVariableScopeItem(name string, value value.Value, vis Visibility) Variab
 	// 	v.Name = fmt.Sprintf("_%s%d", item.name, varIndex)
 	// }
 
-	varIndex++
 	return item
 }
 
@@ -456,6 +523,14 @@ type ScopeType struct {
 	Type types.Type
 	Name string
 	Prec int
+
+	// Alias is set for names that only refer to another type
+	Alias bool
+
+	// Package is the package that declared the type, which is the only
+	// one that can see it if it is private
+	Package    string
+	Visibility Visibility
 }
 
 // NewScopeType constructs a function scope item
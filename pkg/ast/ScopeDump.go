@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// Dump writes a human readable representation of the scope tree to w.
+// Each scope lists the package it was spawned in, the types registered
+// in it, and the values (variables and functions) declared in it along
+// with their types. This is mainly useful for tracking down search path
+// problems like "unable to find type" or "unable to find function".
+func (s *Scope) Dump(w io.Writer) {
+	s.dump(w, 0)
+}
+
+func (s *Scope) dump(w io.Writer, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	pkg := s.PackageName
+	if pkg == "" {
+		pkg = "<none>"
+	}
+	fmt.Fprintf(w, "%sscope %d (package %s)\n", indent, s.Index, pkg)
+
+	typeNames := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	for _, name := range typeNames {
+		fmt.Fprintf(w, "%s  type  %s = %s\n", indent, name, s.Types[name].Type)
+	}
+
+	valNames := make([]string, 0, len(s.Vals))
+	for name := range s.Vals {
+		valNames = append(valNames, name)
+	}
+	sort.Strings(valNames)
+
+	for _, name := range valNames {
+		item := s.Vals[name]
+		switch item.Type() {
+		case ScopeItemFunctionType:
+			fmt.Fprintf(w, "%s  func  %s %s\n", indent, name, scopeItemTypeString(item))
+		case ScopeItemVariableType:
+			fmt.Fprintf(w, "%s  var   %s %s\n", indent, name, scopeItemTypeString(item))
+		default:
+			fmt.Fprintf(w, "%s  item  %s\n", indent, name)
+		}
+	}
+
+	for _, child := range s.Children {
+		child.dump(w, depth+1)
+	}
+}
+
+// scopeItemTypeString returns the type of the value stored in a scope item.
+// Variables are stored as allocations or globals, so the pointer is stripped
+// to show the type the user declared.
+func scopeItemTypeString(item ScopeItem) string {
+	val := item.Value()
+	if val == nil {
+		return "<nil>"
+	}
+
+	switch v := val.(type) {
+	case *ir.Function:
+		return v.Sig.String()
+	case *ir.InstAlloca:
+		return v.Elem.String()
+	case *ir.Global:
+		if ptr, ok := v.Type().(*types.PointerType); ok {
+			return ptr.Elem.String()
+		}
+	}
+	return val.Type().String()
+}
@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// SizeofNode is `sizeof(T)` or `alignof(T)`, the size or alignment of a
+// type in bytes as a long. It is worked out from the data layout of the
+// target while compiling, so it is a constant.
+type SizeofNode struct {
+	NodeType
+	TokenReference
+
+	T     TypeNode
+	Align bool
+}
+
+// NameString implements Node.NameString
+func (n SizeofNode) NameString() string { return "SizeofNode" }
+
+// MarshalJSON implements json.Marshaler for SizeofNode
+func (n SizeofNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n SizeofNode) String() string {
+	if n.Align {
+		return fmt.Sprintf("alignof(%s)", n.T)
+	}
+	return fmt.Sprintf("sizeof(%s)", n.T)
+}
+
+// Value returns the size or alignment the node stands for
+func (n SizeofNode) Value(prog *Program) (int64, error) {
+	t, err := n.T.GetType(prog)
+	if err != nil {
+		return 0, err
+	}
+	if n.Align {
+		return prog.alignOf(t)
+	}
+	return prog.sizeOf(t)
+}
+
+// Codegen implements Node.Codegen for SizeofNode
+func (n SizeofNode) Codegen(prog *Program) (value.Value, error) {
+	val, err := n.Value(prog)
+	if err != nil {
+		return nil, n.Diagnose(err)
+	}
+	return constant.NewInt(val, types.I64), nil
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n SizeofNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
@@ -0,0 +1,157 @@
+package ast
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SourceProvider is where a program reads the code of its packages from,
+// and finds the directories of the packages it includes in. It is like an
+// fs.FS, but with the absolute os paths the compiler works with, so code
+// that isn't on disk, like the unsaved buffers of an editor or a standard
+// library built into a program, can be compiled as if it were. A provider
+// returns an error that is fs.ErrNotExist for a path it doesn't have.
+//
+// Only geode sources are read from the provider. The c headers included
+// with include_c and the c sources linked in are still read from disk by
+// the c toolchain.
+type SourceProvider interface {
+	Stat(path string) (fs.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+	// ReadDir returns the entries of a directory, sorted by name
+	ReadDir(path string) ([]fs.DirEntry, error)
+}
+
+// DiskSources reads sources from disk. Programs without a SourceProvider
+// read from it.
+var DiskSources SourceProvider = diskSources{}
+
+type diskSources struct{}
+
+func (diskSources) Stat(path string) (fs.FileInfo, error)      { return os.Stat(path) }
+func (diskSources) ReadFile(path string) ([]byte, error)       { return os.ReadFile(path) }
+func (diskSources) ReadDir(path string) ([]fs.DirEntry, error) { return os.ReadDir(path) }
+
+// FSSources returns a provider of the files in fsys, as if it were the
+// directory root, like the standard library embedded with go:embed at
+// util.StdLibDir(). Nothing outside of root exists in it.
+func FSSources(root string, fsys fs.FS) SourceProvider {
+	return fsSources{filepath.Clean(root), fsys}
+}
+
+type fsSources struct {
+	root string
+	fsys fs.FS
+}
+
+// name returns the name of a path in the fs.FS
+func (s fsSources) name(op, path string) (string, error) {
+	rel, err := filepath.Rel(s.root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &fs.PathError{Op: op, Path: path, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (s fsSources) Stat(path string) (fs.FileInfo, error) {
+	name, err := s.name("stat", path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(s.fsys, name)
+}
+
+func (s fsSources) ReadFile(path string) ([]byte, error) {
+	name, err := s.name("open", path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (s fsSources) ReadDir(path string) ([]fs.DirEntry, error) {
+	name, err := s.name("open", path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(s.fsys, name)
+}
+
+// OverlaySources returns a provider that reads a path from the first of
+// the providers that has it. The entries of a directory are those of the
+// directory in every provider, so a language server can lay the buffers
+// open in an editor over DiskSources, and have new files that aren't saved
+// yet be parsed with the rest of their package.
+func OverlaySources(providers ...SourceProvider) SourceProvider {
+	return overlaySources(providers)
+}
+
+type overlaySources []SourceProvider
+
+func (o overlaySources) Stat(path string) (fs.FileInfo, error) {
+	err := error(&fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist})
+	for _, provider := range o {
+		var info fs.FileInfo
+		if info, err = provider.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			return info, err
+		}
+	}
+	return nil, err
+}
+
+func (o overlaySources) ReadFile(path string) ([]byte, error) {
+	err := error(&fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist})
+	for _, provider := range o {
+		var data []byte
+		if data, err = provider.ReadFile(path); !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return nil, err
+}
+
+func (o overlaySources) ReadDir(path string) ([]fs.DirEntry, error) {
+	err := error(&fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist})
+	found := false
+	entries := make(map[string]fs.DirEntry)
+	for _, provider := range o {
+		list, dirErr := provider.ReadDir(path)
+		if errors.Is(dirErr, fs.ErrNotExist) {
+			continue
+		}
+		if dirErr != nil {
+			return nil, dirErr
+		}
+		found = true
+		for _, entry := range list {
+			// the entries of the providers before win
+			if _, seen := entries[entry.Name()]; !seen {
+				entries[entry.Name()] = entry
+			}
+		}
+	}
+	if !found {
+		return nil, err
+	}
+
+	list := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name() < list[j].Name()
+	})
+	return list, nil
+}
+
+// sources returns the provider the program reads its sources from
+func (p *Program) sources() SourceProvider {
+	if p.Sources == nil {
+		return DiskSources
+	}
+	return p.Sources
+}
@@ -0,0 +1,143 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// SpawnNode is `spawn f(args)`, which calls f on a new thread and returns
+// the runtime:Thread* it runs on. The arguments are evaluated by the thread
+// that spawns the call and copied into an env the new thread reads them
+// from, so the call sees them as they were when it was spawned. What f
+// returns is thrown away.
+type SpawnNode struct {
+	NodeType
+	TokenReference
+
+	Call Node
+}
+
+// NameString implements Node.NameString
+func (n SpawnNode) NameString() string { return "SpawnNode" }
+
+// MarshalJSON implements json.Marshaler for SpawnNode
+func (n SpawnNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n SpawnNode) String() string {
+	return fmt.Sprintf("spawn %s", n.Call)
+}
+
+// Codegen implements Node.Codegen for SpawnNode
+func (n SpawnNode) Codegen(prog *Program) (value.Value, error) {
+	if prog.NoRuntime {
+		return nil, n.Errorf(ErrInvalid, "spawn starts threads with the runtime, which is disabled by --no-runtime")
+	}
+	call, isCall := n.Call.(FunctionCallNode)
+	if !isCall {
+		return nil, n.Errorf(ErrInvalid, "spawn can only start a function call, given %s", n.Call)
+	}
+	// builtins and function pointers have no function to run
+	if _, isIdent := call.Name.(IdentNode); isIdent && call.calleeNode(prog) == nil {
+		return nil, n.Errorf(ErrInvalid, "spawn can only start a call to a function declared with func, %s isn't one", call.Name)
+	}
+	// and the method an interface or virtual call runs is only known once
+	// it is made
+	if dot, isDot := call.Name.(DotReference); isDot && !dot.isStatic(prog) {
+		dot, err := dot.evaluated(prog)
+		if err != nil {
+			return nil, err
+		}
+		call.Name = dot
+		base := dot.BaseType(prog)
+		if prog.interfaceOf(base) != nil || prog.virtualClassOf(base) != nil {
+			return nil, n.Errorf(ErrInvalid, "spawn can't start a call to a method that is looked up in a vtable, %s", call.Name)
+		}
+	}
+
+	callee, args, err := call.genCallArgs(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := prog.genSpawnEnv(args)
+	if err != nil {
+		return nil, err
+	}
+	run := prog.genSpawnRunner(callee, args)
+	block := prog.Compiler.CurrentBlock()
+	runPtr := block.NewBitCast(run, types.NewPointer(types.I8))
+	return prog.NewRuntimeFunctionCall("__runtime_spawn", runPtr, env)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n SpawnNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
+
+// spawnEnvType is the struct the arguments of a spawned call are copied
+// into
+func spawnEnvType(args []value.Value) *types.StructType {
+	fields := make([]types.Type, len(args))
+	for i, arg := range args {
+		fields[i] = arg.Type()
+	}
+	return types.NewStruct(fields...)
+}
+
+// genSpawnEnv copies the arguments of a spawned call into memory allocated
+// with the collector, returning it as a byte*. A call without arguments has
+// a nil env. With --arc the class instances passed are retained, as the
+// thread may outlive the references the spawning function has to them.
+func (p *Program) genSpawnEnv(args []value.Value) (value.Value, error) {
+	bytePtr := types.NewPointer(types.I8)
+	if len(args) == 0 {
+		return constant.NewNull(bytePtr), nil
+	}
+	envType := spawnEnvType(args)
+	size, err := p.sizeOf(envType)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := p.genAlloc(envType, constant.NewInt(size, types.I32))
+	if err != nil {
+		return nil, err
+	}
+	block := p.Compiler.CurrentBlock()
+	env := block.NewBitCast(buf, types.NewPointer(envType))
+	zero := constant.NewInt(0, types.I32)
+	for i, arg := range args {
+		if err := p.arcRetain(arg); err != nil {
+			return nil, err
+		}
+		block = p.Compiler.CurrentBlock()
+		field := block.NewGetElementPtr(env, zero, constant.NewInt(int64(i), types.I32))
+		block.NewStore(arg, field)
+	}
+	return buf, nil
+}
+
+// genSpawnRunner generates the function a spawned thread runs, which loads
+// the arguments out of its env and calls callee with them
+func (p *Program) genSpawnRunner(callee *ir.Function, args []value.Value) *ir.Function {
+	param := ir.NewParam("env", types.NewPointer(types.I8))
+	run := p.Module.NewFunction(mangleName("__spawn"), types.Void, param)
+	run.Linkage = ir.LinkageInternal
+
+	block := run.NewBlock("entry")
+	loaded := make([]value.Value, len(args))
+	if len(args) > 0 {
+		env := block.NewBitCast(param, types.NewPointer(spawnEnvType(args)))
+		zero := constant.NewInt(0, types.I32)
+		for i := range args {
+			field := block.NewGetElementPtr(env, zero, constant.NewInt(int64(i), types.I32))
+			loaded[i] = block.NewLoad(field)
+		}
+	}
+	block.NewCall(callee, loaded...)
+	block.NewRet(nil)
+	return run
+}
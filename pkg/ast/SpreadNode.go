@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// SpreadNode is a call argument followed by `...`, example: `sum(nums...)`.
+// It expands a slice or an array into the remaining arguments of the call,
+// forwarding whatever is left into the variadic argument of the callee.
+type SpreadNode struct {
+	NodeType
+	TokenReference
+
+	Value Node
+}
+
+// NameString implements Node.NameString
+func (n SpreadNode) NameString() string { return "SpreadNode" }
+
+// MarshalJSON implements json.Marshaler for SpreadNode
+func (n SpreadNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n SpreadNode) String() string {
+	return fmt.Sprintf("%s...", n.Value)
+}
+
+// Codegen implements Node.Codegen for SpreadNode. A spread only has a
+// meaning inside of a call, which handles it before codegen.
+func (n SpreadNode) Codegen(prog *Program) (value.Value, error) {
+	return nil, n.Errorf(ErrInvalid, "spread argument %s can only be passed as the variadic argument of a call", n)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n SpreadNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
@@ -0,0 +1,218 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// A string is its length in bytes and a pointer to its bytes, the struct
+// `{len, data}`. The length is known without looking for the end of the
+// string, so `s.len` and str:len don't loop, and indexing a string is
+// checked against it with --bounds-checks like indexing a slice. The bytes
+// of every string the runtime makes are still followed by a 0.
+//
+// a + b concatenates two strings and a == b compares their bytes, both in
+// the runtime, as does slicing a string with str:slice.
+//
+// A string only converts to the byte* of its bytes on its own when it is
+// passed to a c function, one declared with ... at the end, which takes
+// and returns strings as a char*. Everywhere else `s as byte*` or s.data
+// get the bytes, and `p as string` makes a string of a c string.
+var stringType = newStringType()
+
+func newStringType() *types.StructType {
+	t := types.NewStruct(types.I64, types.NewPointer(types.I8))
+	t.Name = "string"
+	t.Names = []string{"len", "data"}
+	return t
+}
+
+// the fields of a string
+const (
+	stringLenField  = 0
+	stringDataField = 1
+)
+
+// isString returns if a type is string
+func isString(t types.Type) bool {
+	return types.Equal(t, stringType)
+}
+
+// isBytePtr returns if a type is byte*, which is what c calls a string
+func isBytePtr(t types.Type) bool {
+	return types.Equal(t, types.NewPointer(types.I8))
+}
+
+// newString returns the string with some length and bytes
+func (p *Program) newString(data, length value.Value) value.Value {
+	dataConst, isDataConst := data.(constant.Constant)
+	lenConst, isLenConst := length.(constant.Constant)
+	if isDataConst && isLenConst {
+		str := constant.NewStruct(lenConst, dataConst)
+		str.Typ = stringType
+		return str
+	}
+	block := p.Compiler.CurrentBlock()
+	var str value.Value = constant.NewZeroInitializer(stringType)
+	str = block.NewInsertValue(str, length, []int64{stringLenField})
+	return block.NewInsertValue(str, data, []int64{stringDataField})
+}
+
+// stringData returns the pointer to the bytes of a string
+func (p *Program) stringData(str value.Value) value.Value {
+	if c, isConst := str.(*constant.Struct); isConst {
+		return c.Fields[stringDataField]
+	}
+	return p.Compiler.CurrentBlock().NewExtractValue(str, []int64{stringDataField})
+}
+
+// stringLen returns the length of a string
+func (p *Program) stringLen(str value.Value) value.Value {
+	if c, isConst := str.(*constant.Struct); isConst {
+		return c.Fields[stringLenField]
+	}
+	return p.Compiler.CurrentBlock().NewExtractValue(str, []int64{stringLenField})
+}
+
+// genStringCall calls a function of the runtime that works on strings
+func (p *Program) genStringCall(name string, args ...value.Value) (value.Value, error) {
+	if p.NoRuntime {
+		return nil, fmt.Errorf("strings are made by the runtime, which is disabled by --no-runtime")
+	}
+	return p.NewRuntimeFunctionCall(name, args...)
+}
+
+// stringFromC returns the string of a c string, which is empty if it is
+// nil
+func (p *Program) stringFromC(ptr value.Value) (value.Value, error) {
+	return p.genStringCall("__runtime_cstring", ptr)
+}
+
+// genStringOperator generates a + b and the comparisons a == b and a != b
+// of strings, and s == nil and s != nil, returning false if the operands
+// aren't strings
+func (p *Program) genStringOperator(op string, left, right value.Value) (value.Value, bool, error) {
+	if !isString(left.Type()) && !isString(right.Type()) {
+		return nil, false, nil
+	}
+	// a string is nil when its data is, as the error of a Result is
+	if _, isNil := right.(*constant.Null); isNil && isString(left.Type()) && (op == "==" || op == "!=") {
+		pred := ir.IntEQ
+		if op == "!=" {
+			pred = ir.IntNE
+		}
+		data := p.stringData(left)
+		return p.Compiler.CurrentBlock().NewICmp(pred, data, constant.NewNull(data.Type().(*types.PointerType))), true, nil
+	}
+	if !isString(left.Type()) || !isString(right.Type()) {
+		return nil, true, fmt.Errorf("operator %s of a string needs another string, given %s and %s", op, p.typeName(left.Type()), p.typeName(right.Type()))
+	}
+	switch op {
+	case "+":
+		val, err := p.genStringCall("__runtime_str_concat", left, right)
+		return val, true, err
+	case "==", "!=":
+		eq, err := p.genStringCall("__runtime_str_eq", left, right)
+		if err != nil || op == "==" {
+			return eq, true, err
+		}
+		return p.Compiler.CurrentBlock().NewXor(eq, constant.NewInt(1, types.I1)), true, nil
+	}
+	return nil, true, fmt.Errorf("operator %s doesn't work on strings", op)
+}
+
+// stringCast converts a string to a byte* and a byte* to a string for a
+// cast with as, returning false if the cast isn't one of those
+func (p *Program) stringCast(in value.Value, to types.Type) (value.Value, bool, error) {
+	switch {
+	case isString(in.Type()) && types.IsPointer(to):
+		bytes, err := createTypeCast(p, p.stringData(in), to)
+		return bytes, true, err
+	case types.IsPointer(in.Type()) && isString(to):
+		bytes, err := createTypeCast(p, in, types.NewPointer(types.I8))
+		if err != nil {
+			return nil, true, err
+		}
+		str, err := p.stringFromC(bytes)
+		return str, true, err
+	}
+	return nil, false, nil
+}
+
+// The c functions
+
+// cType returns the type a c function takes or returns a value of type t
+// as
+func cType(t types.Type) types.Type {
+	if isString(t) {
+		return types.NewPointer(types.I8)
+	}
+	return t
+}
+
+// declareCFunction records a function declared with ..., whose string
+// arguments and result were declared as char*
+func (p *Program) declareCFunction(fn *ir.Function, ret types.Type) {
+	p.cFunctions[fn] = isString(ret)
+}
+
+// declaredCFunction returns the c function with a symbol that another
+// package already declared, as there can only be one declaration of a
+// symbol in a module. Packages can only declare it with the same types.
+func (p *Program) declaredCFunction(name string, ret, geodeRet types.Type, params []*types.Param, variadic bool) (*ir.Function, error) {
+	for fn, returnsString := range p.cFunctions {
+		if fn.Name != name {
+			continue
+		}
+		same := types.Equal(fn.Sig.Ret, ret) && returnsString == isString(geodeRet) &&
+			fn.Sig.Variadic == variadic && len(fn.Params()) == len(params)
+		for i := 0; same && i < len(params); i++ {
+			same = types.Equal(fn.Params()[i].Type(), params[i].Type())
+		}
+		if !same {
+			return nil, fmt.Errorf("c function %s is declared by more than one package with different types", name)
+		}
+		return fn, nil
+	}
+	return nil, nil
+}
+
+// isCFunction returns if a function is declared with ...
+func (p *Program) isCFunction(fn *ir.Function) bool {
+	_, found := p.cFunctions[fn]
+	return found
+}
+
+// convertsToC reports if a value of type from is passed as an argument of
+// type to of a c function, which are both char* to c
+func convertsToC(from, to types.Type) bool {
+	return (isString(from) && isBytePtr(to)) || (isBytePtr(from) && isString(to))
+}
+
+// cArgs passes the strings given to a c function as their bytes
+func (p *Program) cArgs(fn *ir.Function, args []value.Value) []value.Value {
+	if !p.isCFunction(fn) {
+		return args
+	}
+	converted := make([]value.Value, len(args))
+	for i, arg := range args {
+		if isString(arg.Type()) {
+			arg = p.stringData(arg)
+		}
+		converted[i] = arg
+	}
+	return converted
+}
+
+// cResult returns the result of a call to a c function as the geode type
+// it was declared with
+func (p *Program) cResult(fn *ir.Function, call value.Value) (value.Value, error) {
+	if !p.cFunctions[fn] {
+		return call, nil
+	}
+	return p.stringFromC(call)
+}
@@ -19,6 +19,9 @@ type StringFormatNode struct {
 // NameString implements Node.NameString
 func (n StringFormatNode) NameString() string { return "StringFormatNode" }
 
+// MarshalJSON implements json.Marshaler for StringFormatNode
+func (n StringFormatNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for StringFormatNode
 func (n StringFormatNode) Codegen(prog *Program) (value.Value, error) {
 	str, err := n.Format.Codegen(prog)
@@ -39,7 +42,10 @@ func (n StringFormatNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	res, err := prog.NewRuntimeFunctionCall("__runtime_str_format", vals...)
-	return res, err
+	if err != nil {
+		return nil, err
+	}
+	return prog.stringFromC(res)
 }
 
 // GenAccess implements Accessable.GenAccess
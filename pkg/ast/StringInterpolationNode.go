@@ -0,0 +1,187 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// StringInterpolationNode is a string literal with expressions in it, like
+// `"hello {name}, you are {age}"`. A '{' followed by a name starts an
+// expression that runs to the matching '}'. Any other '{' is part of the
+// string, and `\{` can be used to write a '{' followed by a name.
+//
+// The pieces are concatenated into a new string by the runtime, with each
+// expression formatted as the %v format verb would format it.
+type StringInterpolationNode struct {
+	NodeType
+	TokenReference
+
+	// Parts are the pieces of the string in order. Literal pieces are
+	// StringNodes, the rest are the expressions.
+	Parts []Node
+}
+
+// NameString implements Node.NameString
+func (n StringInterpolationNode) NameString() string { return "StringInterpolationNode" }
+
+// MarshalJSON implements json.Marshaler for StringInterpolationNode
+func (n StringInterpolationNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n StringInterpolationNode) String() string {
+	buff := &bytes.Buffer{}
+	buff.WriteString("\"")
+	for _, part := range n.Parts {
+		if str, isLiteral := part.(StringNode); isLiteral {
+			buff.WriteString(str.Value)
+		} else {
+			fmt.Fprintf(buff, "{%s}", part)
+		}
+	}
+	buff.WriteString("\"")
+	return buff.String()
+}
+
+// formatSpec returns the format string that formats the interpolated
+// string and the expressions it formats
+func (n StringInterpolationNode) formatSpec() (string, []Node) {
+	spec := &bytes.Buffer{}
+	args := make([]Node, 0, len(n.Parts))
+	for _, part := range n.Parts {
+		if str, isLiteral := part.(StringNode); isLiteral {
+			spec.WriteString(strings.Replace(str.Value, "%", "%%", -1))
+			continue
+		}
+		spec.WriteString("%v")
+		args = append(args, part)
+	}
+	return spec.String(), args
+}
+
+// Codegen implements Node.Codegen for StringInterpolationNode
+func (n StringInterpolationNode) Codegen(prog *Program) (value.Value, error) {
+	spec, nodes := n.formatSpec()
+	cspec, args, err := genFormatArgs(prog, spec, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	format := StringNode{}
+	format.Token = n.Token
+	format.NodeType = nodeString
+	format.Value = cspec
+	str, err := format.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	call, err := prog.NewRuntimeFunctionCall("__runtime_str_format", append([]value.Value{str}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return prog.stringFromC(call)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n StringInterpolationNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
+
+// newStringNode builds the node for a string literal token, which is an
+// interpolation if it has expressions in it
+func newStringNode(tok lexer.Token) (Node, error) {
+	raw := tok.Value[1 : len(tok.Value)-1]
+
+	var err error
+	literal := func(s string) StringNode {
+		n := StringNode{}
+		n.Token = tok
+		n.NodeType = nodeString
+		var unescapeErr error
+		n.Value, unescapeErr = UnescapeString(s)
+		if unescapeErr != nil && err == nil {
+			err = newError(tok, ErrSyntax, "%s in string %s", unescapeErr, tok.Value)
+		}
+		return n
+	}
+
+	parts := make([]Node, 0)
+	lit := &bytes.Buffer{}
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		// the braces of \u{1F600} don't start an expression
+		if c == '\\' && strings.HasPrefix(raw[i+1:], "u{") {
+			end := strings.IndexByte(raw[i:], '}')
+			if end < 0 {
+				end = len(raw) - i - 1
+			}
+			lit.WriteString(raw[i : i+end+1])
+			i += end
+			continue
+		}
+		if c == '\\' && i+1 < len(raw) {
+			lit.WriteByte(c)
+			lit.WriteByte(raw[i+1])
+			i++
+			continue
+		}
+		if c != '{' || i+1 >= len(raw) || !isInterpolationStart(raw[i+1]) {
+			lit.WriteByte(c)
+			continue
+		}
+
+		end := i + 1
+		for depth := 1; depth > 0; end++ {
+			if end >= len(raw) {
+				return nil, newError(tok, ErrSyntax, "unclosed '{' in string %s", tok.Value)
+			}
+			switch raw[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+
+		src := raw[i+1 : end-1]
+		p := NewQuickParser(src)
+		expr := p.parseExpression(false)
+		if p.tokenIndex < len(p.tokens) {
+			return nil, newError(tok, ErrSyntax, "invalid expression {%s} in string %s", src, tok.Value)
+		}
+
+		if lit.Len() > 0 {
+			parts = append(parts, literal(lit.String()))
+			lit.Reset()
+		}
+		parts = append(parts, expr)
+		i = end - 1
+	}
+
+	if len(parts) == 0 {
+		n := literal(lit.String())
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	if lit.Len() > 0 {
+		parts = append(parts, literal(lit.String()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n := StringInterpolationNode{}
+	n.Token = tok
+	n.NodeType = nodeStringInterpolation
+	n.Parts = parts
+	return n, nil
+}
+
+func isInterpolationStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
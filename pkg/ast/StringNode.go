@@ -21,27 +21,13 @@ type StringNode struct {
 // NameString implements Node.NameString
 func (n StringNode) NameString() string { return "StringNode" }
 
-var strIndex = 0
+// MarshalJSON implements json.Marshaler for StringNode
+func (n StringNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
 
 // Codegen implements Node.Codegen for StringNode
 func (n StringNode) Codegen(prog *Program) (value.Value, error) {
 
-	var str *ir.Global
-
-	if found, exists := prog.StringDefs[n.Value]; exists {
-		str = found
-	} else {
-		name := fmt.Sprintf(".str.%X", strIndex)
-		strIndex++
-		str = prog.Compiler.Module.NewGlobalDef(name, newCharArray(n.Value))
-		str.IsConst = true
-		str.Immutable()
-		prog.StringDefs[n.Value] = str
-	}
-
-	var val value.Value
-	zero := constant.NewInt(0, types.I32)
-	val = constant.NewGetElementPtr(str, zero, zero)
+	var val value.Value = prog.stringConstant(n.Value)
 
 	if !*arg.DisableStringDataCopy {
 		length := constant.NewInt(int64(len([]byte(n.Value))+1), types.I32)
@@ -52,7 +38,26 @@ func (n StringNode) Codegen(prog *Program) (value.Value, error) {
 		val = v
 	}
 
-	return val, nil
+	return prog.newString(val, constant.NewInt(int64(len(n.Value)), types.I64)), nil
+}
+
+// stringConstant returns a pointer to the constant bytes of a string
+func (p *Program) stringConstant(s string) constant.Constant {
+	var str *ir.Global
+
+	if found, exists := p.StringDefs[s]; exists {
+		str = found
+	} else {
+		name := fmt.Sprintf(".str.%X", p.strIndex)
+		p.strIndex++
+		str = p.Compiler.Module.NewGlobalDef(name, newCharArray(s))
+		str.IsConst = true
+		str.Immutable()
+		p.StringDefs[s] = str
+	}
+
+	zero := constant.NewInt(0, types.I32)
+	return constant.NewGetElementPtr(str, zero, zero)
 }
 
 // GenAccess implements Accessable.GenAccess
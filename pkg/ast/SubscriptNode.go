@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
-	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
 )
@@ -22,32 +21,106 @@ type SubscriptNode struct {
 // NameString implements Node.NameString
 func (n SubscriptNode) NameString() string { return "SubscriptNode" }
 
+// MarshalJSON implements json.Marshaler for SubscriptNode
+func (n SubscriptNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 func (n SubscriptNode) String() string {
 	return fmt.Sprintf("%s[%s]", n.Source, n.Index)
 }
 
-// GenElementPtr returns a generated GetElementPtr for this subscript operation
-func (n SubscriptNode) GenElementPtr(prog *Program) (*ir.InstGetElementPtr, error) {
+// operands generates the value being indexed and the index
+func (n SubscriptNode) operands(prog *Program) (value.Value, value.Value, error) {
 	src, err := n.Source.GenAccess(prog)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	idx, err := n.Index.GenAccess(prog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := prog.checkDereference(src.Type(), n.Source); err != nil {
+		return nil, nil, n.Diagnose(err)
+	}
+	return src, idx, nil
+}
+
+// GenElementPtr returns a generated GetElementPtr for this subscript operation
+func (n SubscriptNode) GenElementPtr(prog *Program) (*ir.InstGetElementPtr, error) {
+	src, idx, err := n.operands(prog)
 	if err != nil {
 		return nil, err
 	}
+	if err := n.checkBounds(prog, src, idx); err != nil {
+		return nil, err
+	}
+	return n.elementPtr(prog, src, idx)
+}
+
+// genElement generates the address of the element, or, when op[] of a class
+// returns the element as a value, the value instead
+func (n SubscriptNode) genElement(prog *Program) (value.Value, value.Value, error) {
+	src, idx, err := n.operands(prog)
+	if err != nil {
+		return nil, nil, err
+	}
+	if val, overloaded, err := genOperatorCall(prog, "[]", src, idx); overloaded || err != nil {
+		return nil, val, err
+	}
+	if err := n.checkBounds(prog, src, idx); err != nil {
+		return nil, nil, err
+	}
+	ptr, err := n.elementPtr(prog, src, idx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ptr, nil, nil
+}
 
+func (n SubscriptNode) elementPtr(prog *Program, src, idx value.Value) (*ir.InstGetElementPtr, error) {
+	// A class's op[] returns a value, there is no element to address
+	if fn, err := prog.findOperatorMethod("[]", src.Type(), idx.Type()); err != nil || fn != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to assign to %s, op[] of %s returns a value", n, src.Type())
+	}
+
+	// Elements of vectors are addressed through the vector they are loaded from
+	if types.IsVector(src.Type()) {
+		load, ok := src.(*ir.InstLoad)
+		if !ok {
+			return nil, fmt.Errorf("unable to assign to an element of a vector that isn't stored in a variable")
+		}
+		return genVectorElementPtr(prog, load, idx)
+	}
+
+	// Slices, strings and vecs are indexed through their data pointer
 	if types.IsSlice(src.Type()) {
-		fmt.Println(src.Type())
-		zero := constant.NewInt(int64(0), types.I64)
-		src = prog.Compiler.CurrentBlock().NewGetElementPtr(src, zero)
+		src = prog.Compiler.CurrentBlock().NewExtractValue(src, []int64{0})
+	} else if isString(src.Type()) {
+		src = prog.stringData(src)
+	} else if isVec(src.Type()) {
+		src = prog.vecData(src)
 	}
 	return prog.Compiler.CurrentBlock().NewGetElementPtr(src, idx), nil
 }
 
 // Codegen implements Node.Codegen for SubscriptNode
 func (n SubscriptNode) Codegen(prog *Program) (value.Value, error) {
-	ptr, err := n.GenElementPtr(prog)
+	src, idx, err := n.operands(prog)
+	if err != nil {
+		return nil, err
+	}
+	if types.IsVector(src.Type()) {
+		return genVectorElement(prog, src, idx)
+	}
+	if val, overloaded, err := genOperatorCall(prog, "[]", src, idx); overloaded || err != nil {
+		return val, err
+	}
+	if err := n.checkBounds(prog, src, idx); err != nil {
+		return nil, err
+	}
+	ptr, err := n.elementPtr(prog, src, idx)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +138,11 @@ func (n SubscriptNode) GenAssign(prog *Program, val value.Value, options ...Assi
 	if err != nil {
 		return nil, err
 	}
+	// elements aren't released when they are replaced, as the memory of a
+	// slice may not have been stored to yet
+	if err := prog.arcRetain(val); err != nil {
+		return nil, err
+	}
 	prog.Compiler.CurrentBlock().NewStore(val, ptr)
 	return val, nil
 }
@@ -74,7 +152,20 @@ func (n SubscriptNode) Type(prog *Program) (types.Type, error) {
 
 	tmpBlock := ir.NewBlock("")
 
-	ptr, err := n.GenElementPtr(prog)
+	src, idx, err := n.operands(prog)
+	if err != nil {
+		return nil, err
+	}
+	if vec, ok := src.Type().(*types.VectorType); ok {
+		return vec.Elem, nil
+	}
+	if fn, err := prog.findOperatorMethod("[]", src.Type(), idx.Type()); err != nil || fn != nil {
+		if err != nil {
+			return nil, err
+		}
+		return fn.Sig.Ret, nil
+	}
+	ptr, err := n.elementPtr(prog, src, idx)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +182,6 @@ func (n SubscriptNode) Alloca(prog *Program) value.Value {
 
 // Load implements Reference.Load
 func (n SubscriptNode) Load(blk *ir.BasicBlock, prog *Program) *ir.InstLoad {
-	ld, _ := n.Codegen(prog)
-	return ld.(*ir.InstLoad)
+	ptr, _ := n.GenElementPtr(prog)
+	return prog.Compiler.CurrentBlock().NewLoad(ptr)
 }
@@ -0,0 +1,135 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// SymbolKind is the kind of declaration a symbol is
+type SymbolKind string
+
+// The kinds of symbols
+const (
+	SymbolFunction  SymbolKind = "function"
+	SymbolMethod    SymbolKind = "method"
+	SymbolClass     SymbolKind = "class"
+	SymbolEnum      SymbolKind = "enum"
+	SymbolUnion     SymbolKind = "union"
+	SymbolInterface SymbolKind = "interface"
+)
+
+// Symbol is a function or type the program declares, for documentation
+// tools and to find what the functions in an object file came from
+type Symbol struct {
+	Kind SymbolKind `json:"kind"`
+	// Name is the qualified name of the symbol, like `io:print` or
+	// `main:Point.norm`. The functions and types of the runtime aren't
+	// qualified.
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Pub     bool   `json:"pub"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	// Signature is the declaration, like `func add(int a, int b) int` or
+	// `class Box<T>`
+	Signature string `json:"signature"`
+	// Compiled are the functions a function was compiled to, one for each
+	// variant of a generic function. Functions that are never called
+	// aren't compiled, so they have none.
+	Compiled []CompiledSymbol `json:"compiled,omitempty"`
+}
+
+// CompiledSymbol is a function of the module a function was compiled to
+type CompiledSymbol struct {
+	// Symbol is the mangled name of the function, as it is in the object
+	// file, or its own name if it isn't mangled, like an @export function
+	Symbol string `json:"symbol"`
+	// Signature is the signature it was compiled with, in geode types,
+	// like `main:add(int, int) int`
+	Signature string `json:"signature"`
+}
+
+// Symbols returns the functions and types the program declares, sorted by
+// their names. The functions have the symbols they were compiled to, so
+// it is called once the program is compiled.
+func (p *Program) Symbols() []*Symbol {
+	symbols := make([]*Symbol, 0, len(p.Functions)+len(p.Classes))
+
+	for name, fn := range p.Functions {
+		kind := SymbolFunction
+		if fn.IsMethod {
+			kind = SymbolMethod
+		}
+		sym := newSymbol(kind, name, fn.Package, fn.Pub, fn.Token, fn.Signature())
+		for mangled, compiled := range fn.Variants {
+			params := make([]string, len(compiled.Sig.Params))
+			for i, param := range compiled.Sig.Params {
+				params[i] = p.typeName(param.Typ)
+			}
+			signature := fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))
+			if ret := compiled.Sig.Ret; !types.Equal(ret, types.Void) {
+				signature += " " + p.typeName(ret)
+			}
+			sym.Compiled = append(sym.Compiled, CompiledSymbol{mangled, signature})
+		}
+		sort.Slice(sym.Compiled, func(i, j int) bool {
+			return sym.Compiled[i].Symbol < sym.Compiled[j].Symbol
+		})
+		symbols = append(symbols, sym)
+	}
+
+	for name, cls := range p.Classes {
+		signature := fmt.Sprintf("class %s", cls.Name)
+		if len(cls.TypeParams) > 0 {
+			signature += fmt.Sprintf("<%s>", strings.Join(cls.TypeParams, ", "))
+		}
+		symbols = append(symbols, newSymbol(SymbolClass, name, cls.Package, cls.Pub, cls.Token, signature))
+	}
+	for name, enum := range p.Enums {
+		symbols = append(symbols, newSymbol(SymbolEnum, name, enum.Package, enum.Pub, enum.Token, fmt.Sprintf("enum %s", enum.Name)))
+	}
+	for name, union := range p.Unions {
+		symbols = append(symbols, newSymbol(SymbolUnion, name, union.Package, union.Pub, union.Token, fmt.Sprintf("union %s", union.Name)))
+	}
+	for name, iface := range p.Interfaces {
+		symbols = append(symbols, newSymbol(SymbolInterface, name, iface.Package, iface.Pub, iface.Token, fmt.Sprintf("interface %s", iface.Name)))
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Name != symbols[j].Name {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return symbols[i].Kind < symbols[j].Kind
+	})
+	return symbols
+}
+
+func newSymbol(kind SymbolKind, name string, pkg *Package, pub bool, tok lexer.Token, signature string) *Symbol {
+	sym := &Symbol{Kind: kind, Name: name, Pub: pub, Signature: signature}
+	if pkg != nil {
+		sym.Package = pkg.Name
+	}
+	if tok.SourcePath() != "" {
+		sym.File = filepath.Clean(tok.SourcePath())
+		sym.Line = tok.Line
+	}
+	return sym
+}
+
+// WriteSymbols writes the symbols of the program to w as json, for
+// --emit-symbols
+func (p *Program) WriteSymbols(w io.Writer) error {
+	data, err := json.MarshalIndent(p.Symbols(), "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,71 @@
+package ast
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const symbolsSource = `is main
+
+class Point {
+	int x
+
+	func norm int {
+		return this.x;
+	}
+}
+
+pub func add(int a, int b) int {
+	return a + b;
+}
+
+func never {}
+
+func main int {
+	Point p;
+	p.x = 1;
+	return add(p.norm(), 2);
+}
+`
+
+func TestSymbols(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	p := NewProgram()
+	p.NoRuntime = true
+	if err := p.ParseText(context.Background(), symbolsSource, "/proj/main.g"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Congeal(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CompileEntrypoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	const file = "/proj/main.g"
+	want := []*Symbol{
+		{SymbolFunction, "main", "main", false, file, 17, "func main() int", []CompiledSymbol{
+			{"main.main", "main() int"},
+		}},
+		{SymbolClass, "main:Point", "main", false, file, 3, "class Point", nil},
+		{SymbolMethod, "main:Point.norm", "main", false, file, 6, "func main:Point.norm(main:Point* this) int", []CompiledSymbol{
+			{"_XN4mainN5PointN4normTPCN4mainN5PointERi32", "main:Point.norm(main:Point*) int"},
+		}},
+		{SymbolFunction, "main:add", "main", true, file, 11, "func add(int a, int b) int", []CompiledSymbol{
+			{"_XN4mainN3addTi32Ti32Ri32", "main:add(int, int) int"},
+		}},
+		// functions that are never called aren't compiled
+		{SymbolFunction, "main:never", "main", false, file, 15, "func never() void", nil},
+	}
+	got := p.Symbols()
+	if len(got) != len(want) {
+		t.Fatalf("got %d symbols, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Target is a platform geode compiles for, found in the target registry by
+// its triple
+type Target struct {
+	// Triple is the llvm target triple, as it was given
+	Triple string
+	// Arch and OS are the architecture and operating system of the triple,
+	// which @arch and @os are checked against
+	Arch string
+	OS   string
+	// DataLayout is the llvm data layout of the target. It is written at
+	// the top of every module and decides the sizes and alignments sizeof
+	// and alignof give.
+	DataLayout string
+	// LinkFlags are the flags binaries for the target link with, for the
+	// libraries the runtime needs from the system
+	LinkFlags []string
+	// RuntimeShim is the c source in the runtime package that is linked in
+	// place of the ones the runtime links, on targets without a c library
+	// for them to use
+	RuntimeShim string
+	// Extension is the extension binaries for the target are given when
+	// their output has none
+	Extension string
+}
+
+// Freestanding returns if the target has no c library, so the runtime
+// links its shim instead
+func (t *Target) Freestanding() bool {
+	return t.RuntimeShim != ""
+}
+
+// targetSpec is an entry of the target registry. Triples are matched by
+// their architecture and operating system, so the vendor and environment
+// don't need their own entries.
+type targetSpec struct {
+	// arch is the architecture, like x86_64
+	arch string
+	// os is the operating system, like linux. The os of a triple only has
+	// to start with it, as darwin19.6.0 does with darwin.
+	os          string
+	dataLayout  string
+	linkFlags   []string
+	runtimeShim string
+	extension   string
+}
+
+// unixLinkFlags are the libraries every unix binary links with. The
+// functions of the program are exported with -rdynamic, so the stack trace
+// of a panic can name them.
+var unixLinkFlags = []string{"-lm", "-lc", "-pthread", "-rdynamic"}
+
+// targets is the target registry. The data layouts are the ones clang uses
+// for each target.
+var targets = []targetSpec{
+	{"x86_64", "linux", "e-m:e-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128", unixLinkFlags, "", ""},
+	{"x86_64", "darwin", "e-m:o-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128", unixLinkFlags, "", ""},
+	{"x86_64", "macos", "e-m:o-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128", unixLinkFlags, "", ""},
+	{"x86_64", "freebsd", "e-m:e-p270:32:32-p271:32:32-p272:64:64-i64:64-f80:128-n8:16:32:64-S128", unixLinkFlags, "", ""},
+	{"i386", "linux", "e-m:e-p:32:32-p270:32:32-p271:32:32-p272:64:64-f64:32:64-f80:32-n8:16:32-S128", unixLinkFlags, "", ""},
+	{"i686", "linux", "e-m:e-p:32:32-p270:32:32-p271:32:32-p272:64:64-f64:32:64-f80:32-n8:16:32-S128", unixLinkFlags, "", ""},
+	{"aarch64", "linux", "e-m:e-i8:8:32-i16:16:32-i64:64-i128:128-n32:64-S128", unixLinkFlags, "", ""},
+	{"aarch64", "darwin", "e-m:o-i64:64-i128:128-n32:64-S128", unixLinkFlags, "", ""},
+	{"arm64", "darwin", "e-m:o-i64:64-i128:128-n32:64-S128", unixLinkFlags, "", ""},
+	{"arm64", "macos", "e-m:o-i64:64-i128:128-n32:64-S128", unixLinkFlags, "", ""},
+	{"armv7", "linux", "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64", unixLinkFlags, "", ""},
+	{"riscv64", "linux", "e-m:e-p:64:64-i64:64-i128:128-n64-S128", unixLinkFlags, "", ""},
+	// wasi has no threads
+	{"wasm32", "wasi", "e-m:e-p:32:32-i64:64-n32:64-S128", []string{"-lm", "-lc"}, "", ""},
+	// wasm32-unknown-unknown has no c library or system at all. The host
+	// calls the exported main and provides the functions the shim imports
+	// from the geode module, see lib/runtime/wasm.c.
+	{"wasm32", "unknown", "e-m:e-p:32:32-i64:64-n32:64-S128", []string{"-nostdlib", "-Wl,--no-entry", "-Wl,--export=main"}, "wasm.c", ".wasm"},
+}
+
+// genericTarget is the target of programs built without a triple, which
+// only the layout of a 64-bit machine is known for
+var genericTarget = targetSpec{dataLayout: "e-m:o-i64:64-f80:128-n8:16:32:64-S128", linkFlags: unixLinkFlags}
+
+// LookupTarget returns the target of a triple from the registry. An empty
+// triple is a generic 64-bit target.
+func LookupTarget(triple string) (*Target, error) {
+	if triple == "" {
+		return genericTarget.target(triple), nil
+	}
+	parts := strings.Split(triple, "-")
+	arch := parts[0]
+	for _, spec := range targets {
+		if spec.arch != arch {
+			continue
+		}
+		// the os is the second part of triples without a vendor, like
+		// wasm32-wasi, and the third of the others. Earlier entries win,
+		// so wasm32-unknown-wasi is wasi and not unknown.
+		for _, os := range parts[1:] {
+			if strings.HasPrefix(os, spec.os) {
+				return spec.target(triple), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown target %q, expected one of %s", triple, strings.Join(TargetNames(), ", "))
+}
+
+// TargetNames returns the architecture and os of every target in the
+// registry
+func TargetNames() []string {
+	names := make([]string, len(targets))
+	for i, spec := range targets {
+		names[i] = spec.arch + "-" + spec.os
+	}
+	return names
+}
+
+func (spec targetSpec) target(triple string) *Target {
+	arch, os := spec.arch, spec.os
+	// the generic target has no architecture or os of its own
+	if arch == "" {
+		arch, os = splitTriple(triple)
+	}
+	return &Target{
+		Triple:      triple,
+		Arch:        arch,
+		OS:          os,
+		DataLayout:  spec.dataLayout,
+		LinkFlags:   append([]string{}, spec.linkFlags...),
+		RuntimeShim: spec.runtimeShim,
+		Extension:   spec.extension,
+	}
+}
+
+// splitTriple returns the architecture and os of a triple that isn't in
+// the registry. The version is trimmed off the os, so x86_64-apple-darwin19
+// is darwin.
+func splitTriple(triple string) (string, string) {
+	if triple == "" {
+		return "", ""
+	}
+	parts := strings.Split(triple, "-")
+	os := ""
+	switch {
+	case len(parts) >= 3:
+		os = parts[2]
+	case len(parts) == 2:
+		os = parts[1]
+	}
+	return parts[0], strings.TrimRight(os, "0123456789.")
+}
+
+// GenericTarget returns a generic 64-bit target with a triple, for
+// triples that aren't in the registry but are still built for
+func GenericTarget(triple string) *Target {
+	return genericTarget.target(triple)
+}
+
+// SetTarget sets the target the program is compiled for, and the data
+// layout sizeof and alignof are worked out with
+func (p *Program) SetTarget(target *Target) error {
+	layout, err := ParseDataLayout(target.DataLayout)
+	if err != nil {
+		return err
+	}
+	p.Target = target
+	p.TargetTripple = target.Triple
+	p.layout = layout
+	return nil
+}
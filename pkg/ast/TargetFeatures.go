@@ -0,0 +1,116 @@
+package ast
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// targetAttribute compiles a function for cpu features the rest of the
+// program isn't compiled for, so a hot path can use them while the binary
+// still runs on machines without them. It takes the names of the features,
+// as in `@target("avx2", "fma")`, and `arch=<cpu>` to compile for a cpu, as
+// in `@target("arch=haswell")`. A feature can be turned off with a leading
+// `-`. Calling such a function on a machine without the features is
+// undefined, so calls to it should be guarded by `cpu_supports`.
+const targetAttribute = "target"
+
+// builtinCPUSupports is the builtin that asks if the machine running the
+// program has a cpu feature. `cpu_supports("avx2")` is true on machines
+// with avx2. The feature must be a literal and one of cpuFeatures.
+const builtinCPUSupports = "cpu_supports"
+
+// cpuFeatures are the features cpu_supports can query. They are named the
+// same in @target.
+var cpuFeatures = []string{
+	"aes", "avx", "avx2", "avx512f", "bmi", "bmi2", "fma",
+	"pclmul", "popcnt", "sse3", "sse4.1", "sse4.2", "ssse3",
+}
+
+var targetFeatureName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// applyTargetAttributes sets the target features and cpu of the llvm
+// function compiled from a function node
+func (n FunctionNode) applyTargetAttributes(fn *ir.Function) error {
+	features := make([]string, 0)
+	cpu := ""
+
+	for _, attr := range n.Attributes {
+		if attr.Name != targetAttribute {
+			continue
+		}
+		if n.External {
+			return fmt.Errorf("external function %s can't have @target, it is compiled elsewhere", n.Name)
+		}
+		if len(attr.Args) == 0 {
+			return fmt.Errorf("@target on function %s needs the features to compile it for", n.Name)
+		}
+
+		for _, arg := range attr.Args {
+			if strings.HasPrefix(arg, "arch=") {
+				cpu = strings.TrimPrefix(arg, "arch=")
+				if cpu == "" {
+					return fmt.Errorf("@target(%q) on function %s needs the name of a cpu", arg, n.Name)
+				}
+				continue
+			}
+
+			sign := "+"
+			if strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+") {
+				sign, arg = arg[:1], arg[1:]
+			}
+			if !targetFeatureName.MatchString(arg) {
+				return fmt.Errorf("invalid target feature %q on function %s", arg, n.Name)
+			}
+			features = append(features, sign+arg)
+		}
+	}
+
+	if len(features) > 0 {
+		fn.Attrs["target-features"] = strings.Join(features, ",")
+	}
+	if cpu != "" {
+		fn.Attrs["target-cpu"] = cpu
+	}
+	return nil
+}
+
+// cpuSupportsBuiltin returns if a call is to cpu_supports. A function
+// declared with the same name always takes priority.
+func cpuSupportsBuiltin(prog *Program, n FunctionCallNode) bool {
+	ident, ok := n.Name.(IdentNode)
+	if !ok || ident.String() != builtinCPUSupports {
+		return false
+	}
+	return prog.LookupFunctionNode(builtinCPUSupports) == nil
+}
+
+// genCPUSupports generates a call to cpu_supports
+func genCPUSupports(prog *Program, n FunctionCallNode) (value.Value, error) {
+	if len(n.Args) != 1 {
+		return nil, n.Errorf(ErrInvalid, "%s takes the name of one cpu feature", builtinCPUSupports)
+	}
+	feature, ok := n.Args[0].(StringNode)
+	if !ok {
+		return nil, n.Args[0].Errorf(ErrConstant, "the feature passed to %s must be a string literal", builtinCPUSupports)
+	}
+	if i := sort.SearchStrings(cpuFeatures, feature.Value); i == len(cpuFeatures) || cpuFeatures[i] != feature.Value {
+		return nil, n.Args[0].Errorf(ErrUndefined, "unknown cpu feature %q, expected one of %s", feature.Value, strings.Join(cpuFeatures, ", "))
+	}
+
+	name, err := feature.Codegen(prog)
+	if err != nil {
+		return nil, err
+	}
+	supported, err := prog.NewRuntimeFunctionCall("__runtime_cpu_supports", name)
+	if err != nil {
+		return nil, err
+	}
+	return prog.Compiler.CurrentBlock().NewICmp(ir.IntNE, supported, constant.NewInt(0, types.I32)), nil
+}
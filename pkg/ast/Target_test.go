@@ -0,0 +1,194 @@
+package ast
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupTarget(t *testing.T) {
+	tests := []struct {
+		triple       string
+		arch, os     string
+		pointerSize  int
+		freestanding bool
+		err          bool
+	}{
+		{"x86_64-pc-linux-gnu", "x86_64", "linux", 64, false, false},
+		// the version of the os is matched by its prefix
+		{"x86_64-apple-darwin19.6.0", "x86_64", "darwin", 64, false, false},
+		{"arm64-apple-macos11", "arm64", "macos", 64, false, false},
+		{"i686-pc-linux-gnu", "i686", "linux", 32, false, false},
+		{"aarch64-unknown-linux-gnu", "aarch64", "linux", 64, false, false},
+		// triples without a vendor
+		{"wasm32-wasi", "wasm32", "wasi", 32, false, false},
+		// wasi comes before unknown in the registry
+		{"wasm32-unknown-wasi", "wasm32", "wasi", 32, false, false},
+		{"wasm32-unknown-unknown", "wasm32", "unknown", 32, true, false},
+		// no triple is a generic 64-bit target
+		{"", "", "", 64, false, false},
+		{"sparc-sun-solaris", "", "", 0, false, true},
+		{"x86_64-pc-haiku", "", "", 0, false, true},
+	}
+	for _, test := range tests {
+		target, err := LookupTarget(test.triple)
+		if test.err {
+			if err == nil {
+				t.Errorf("LookupTarget(%q): got %s-%s, want an error", test.triple, target.Arch, target.OS)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("LookupTarget(%q): %s", test.triple, err)
+			continue
+		}
+		if target.Triple != test.triple || target.Arch != test.arch || target.OS != test.os || target.Freestanding() != test.freestanding {
+			t.Errorf("LookupTarget(%q): got %+v, want %s-%s", test.triple, target, test.arch, test.os)
+		}
+		layout, err := ParseDataLayout(target.DataLayout)
+		if err != nil {
+			t.Errorf("LookupTarget(%q): %s", test.triple, err)
+		} else if layout.PointerSize != test.pointerSize {
+			t.Errorf("LookupTarget(%q): pointers are %d bits, want %d", test.triple, layout.PointerSize, test.pointerSize)
+		}
+	}
+}
+
+func TestSplitTriple(t *testing.T) {
+	tests := []struct{ triple, arch, os string }{
+		{"x86_64-apple-darwin19", "x86_64", "darwin"},
+		{"riscv32-elf", "riscv32", "elf"},
+		{"mips-unknown-linux-gnu", "mips", "linux"},
+		{"avr", "avr", ""},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if arch, os := splitTriple(test.triple); arch != test.arch || os != test.os {
+			t.Errorf("splitTriple(%q) = %s, %s, want %s, %s", test.triple, arch, os, test.arch, test.os)
+		}
+	}
+}
+
+// sizeof is worked out with the data layout of the target
+func TestCompileTarget(t *testing.T) {
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+	source := Source{Path: "/proj/main.g", Text: "is main\n\nfunc main long {\n\treturn sizeof(byte*);\n}\n"}
+
+	tests := []struct {
+		triple string
+		want   string
+	}{
+		{"x86_64-pc-linux-gnu", "ret i64 8"},
+		{"i686-pc-linux-gnu", "ret i64 4"},
+		{"wasm32-unknown-unknown", "ret i64 4"},
+	}
+	for _, test := range tests {
+		target, err := LookupTarget(test.triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, diagnostics, err := Compile(context.Background(), source, CompileOptions{Target: target, NoRuntime: true})
+		if err != nil {
+			t.Fatalf("%s: %s %v", test.triple, err, diagnostics)
+		}
+		var body string
+		for _, f := range m.Funcs {
+			if f.Name == "main.main" {
+				body = f.String()
+			}
+		}
+		if !strings.Contains(body, test.want) {
+			t.Errorf("%s: main is\n%s\nwant %s", test.triple, body, test.want)
+		}
+	}
+}
+
+func TestSelectToolchain(t *testing.T) {
+	tests := []struct {
+		name, target string
+		want         string
+		err          bool
+	}{
+		{"", "", "clang", false},
+		{"", "x86_64-pc-linux-gnu", "clang", false},
+		// wasm targets are built with the wasm toolchain unless one is given
+		{"", "wasm32-unknown-unknown", "wasm", false},
+		{"", "wasm32-wasi", "wasm", false},
+		{"zig", "wasm32-wasi", "zig", false},
+		{"gcc", "", "", true},
+	}
+	for _, test := range tests {
+		toolchain, err := SelectToolchain(test.name, ToolchainOptions{Target: test.target})
+		if test.err != (err != nil) {
+			t.Errorf("SelectToolchain(%q, %q): got error %v", test.name, test.target, err)
+			continue
+		}
+		if err == nil && toolchain.Name() != test.want {
+			t.Errorf("SelectToolchain(%q, %q) = %s, want %s", test.name, test.target, toolchain.Name(), test.want)
+		}
+	}
+	// the wasm toolchain builds for wasi without a target
+	if toolchain, _ := SelectToolchain("wasm", ToolchainOptions{}); toolchain.Target() != "wasm32-wasi" {
+		t.Errorf("the wasm toolchain targets %q, want wasm32-wasi", toolchain.Target())
+	}
+}
+
+// Programs for wasm32-unknown-unknown link the wasm shim in place of the
+// c sources of the runtime, io and mem
+func TestWasmRuntimeShim(t *testing.T) {
+	lib, err := filepath.Abs("../../lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", lib)
+
+	tests := []struct {
+		triple string
+		shim   bool
+	}{
+		{"x86_64-pc-linux-gnu", false},
+		{"wasm32-wasi", false},
+		{"wasm32-unknown-unknown", true},
+	}
+	for _, test := range tests {
+		target, err := LookupTarget(test.triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewProgram()
+		if err := p.SetTarget(target); err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if err := p.ParseDep(ctx, "", "runtime"); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.ParseText(ctx, "is main\n\ninclude \"std:io\"\ninclude \"mem\"\n\nfunc main int {\n\tio:print(\"%d\\n\", mem:bytes_used());\n\treturn 0;\n}\n", "/proj/main.g"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Congeal(ctx); err != nil {
+			t.Fatalf("%s: %s", test.triple, err)
+		}
+
+		shim := filepath.Join(lib, "runtime", "wasm.c")
+		shims := 0
+		for _, linked := range p.CLinkages {
+			switch {
+			case linked == shim:
+				shims++
+			case test.shim:
+				t.Errorf("%s: %s is linked with the shim", test.triple, linked)
+			}
+		}
+		want := 0
+		if test.shim {
+			want = 1
+		}
+		if shims != want {
+			t.Errorf("%s: the shim is linked %d times, want %d, in %v", test.triple, shims, want, p.CLinkages)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package ast
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/llc"
+	"github.com/geode-lang/geode/pkg/util"
+)
+
+// EmitFormat is a format a toolchain can convert the llvm ir of a
+// program into instead of building a binary
+type EmitFormat int
+
+// The formats that can be emitted
+const (
+	EmitAssembly EmitFormat = iota
+	EmitLLVM
+	EmitObject
+)
+
+// Extension returns the file extension of an emitted format
+func (f EmitFormat) Extension() string {
+	switch f {
+	case EmitAssembly:
+		return ".s"
+	case EmitLLVM:
+		return ".ll"
+	}
+	return ".o"
+}
+
+// ToolchainOptions are the options every toolchain is built with
+type ToolchainOptions struct {
+	// Target is the target triple to build for. It is empty when building
+	// for the host.
+	Target string
+	// Optimize is the optimization level, from 0 to 3 or s to optimize
+	// for size
+	Optimize string
+	Debug    bool
+	// Flags are extra flags passed through to the final link
+	Flags []string
+	// Freestanding is set for targets without a c library. c sources are
+	// compiled freestanding and the runtime's libraries aren't linked.
+	Freestanding bool
+	// PathPrefixMap lists directories to replace, and what to replace them
+	// with, in the paths written into the output, like in debug info
+	PathPrefixMap [][2]string
+	// Library is the kind of library LinkLibrary builds. Libraries are
+	// compiled position independent, as even static ones can be linked
+	// into position independent binaries.
+	Library LibraryKind
+}
+
+// Toolchain turns the llvm ir the compiler emits and the c sources that
+// packages link against into a binary. The compiler never runs a command
+// itself, so targets that need a different set of tools only need a new
+// toolchain.
+type Toolchain interface {
+	// Name is the name the toolchain is selected by
+	Name() string
+	// Target is the target triple it builds for, empty for the host
+	Target() string
+	// CompileC compiles a c source file into an object file
+	CompileC(src, obj string) error
+	// CompileCCommand returns the command CompileC runs
+	CompileCCommand(src, obj string) []string
+	// PreprocessC runs the c preprocessor over a c source, writing the
+	// result with the #define lines of every macro it defines kept in it,
+	// which is what include_c parses headers from
+	PreprocessC(src, out string) error
+	// Emit converts an llvm ir file into another format
+	Emit(format EmitFormat, ir, out string) error
+	// Link links llvm ir and object files into a binary
+	Link(inputs []string, output string) error
+	// LinkLibrary builds llvm ir and object files into a library of the
+	// kind in the options
+	LinkLibrary(inputs []string, output string) error
+}
+
+// toolchains are the constructors of every toolchain by name
+var toolchains = map[string]func(ToolchainOptions) Toolchain{
+	"clang": newClangToolchain,
+	"llc":   newLLCToolchain,
+	"zig":   newZigToolchain,
+	"wasm":  newWasmToolchain,
+}
+
+// ToolchainNames returns the names of every toolchain, sorted
+func ToolchainNames() []string {
+	names := make([]string, 0, len(toolchains))
+	for name := range toolchains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectToolchain returns the toolchain with a name. Without a name, the
+// toolchain is picked from the target: wasm targets use the wasm toolchain
+// and everything else uses clang.
+func SelectToolchain(name string, opts ToolchainOptions) (Toolchain, error) {
+	if name == "" {
+		name = "clang"
+		if strings.HasPrefix(opts.Target, "wasm") {
+			name = "wasm"
+		}
+	}
+	constructor, found := toolchains[name]
+	if !found {
+		return nil, fmt.Errorf("unknown toolchain %q, expected one of %s", name, strings.Join(ToolchainNames(), ", "))
+	}
+	return constructor(opts), nil
+}
+
+// runTool runs a command, returning its output in the error if it fails
+func runTool(command []string, args ...string) error {
+	args = append(append([]string{}, command[1:]...), args...)
+	out, err := util.RunCommand(command[0], args...)
+	if err != nil {
+		return fmt.Errorf("failed to run command `%s %s`: `%s`\n\n%s", command[0], strings.Join(args, " "), err, out)
+	}
+	return nil
+}
+
+// compileObjects compiles the llvm ir files among the inputs to object
+// files with a toolchain, returning the inputs with them replaced
+func compileObjects(t Toolchain, inputs []string) ([]string, error) {
+	objects := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		if ext := filepath.Ext(input); ext == ".ll" || ext == ".bc" {
+			obj := strings.TrimSuffix(input, ext) + ".o"
+			if err := t.Emit(EmitObject, input, obj); err != nil {
+				return nil, err
+			}
+			input = obj
+		}
+		objects = append(objects, input)
+	}
+	return objects, nil
+}
+
+// The libraries and flags the runtime needs to link. The system libraries
+// it needs are the link flags of the target.
+var runtimeLinkFlags = []string{"--std=c99", "-lgc", "-DREDIRECT_MALLOC=xmalloc", "-DIGNORE_FREE"}
+
+// wasmTriple is the triple the wasm toolchain builds for by default
+const wasmTriple = "wasm32-wasi"
+
+// ToolchainTriple returns the triple a toolchain builds for when no target
+// is given, or "" if it builds for the one the installed clang targets
+func ToolchainTriple(name string) string {
+	if name == "wasm" {
+		return wasmTriple
+	}
+	return ""
+}
+
+// clangToolchain drives a clang compatible compiler driver, which does
+// every step itself. It is the default toolchain.
+type clangToolchain struct {
+	name      string
+	command   []string
+	linkFlags []string
+	// sysroot is where the headers and libraries of the target are, if
+	// they aren't where the compiler looks by default
+	sysroot string
+	opts    ToolchainOptions
+}
+
+func newClangToolchain(opts ToolchainOptions) Toolchain {
+	return &clangToolchain{
+		name:      "clang",
+		command:   []string{"clang"},
+		linkFlags: runtimeLinkFlags,
+		opts:      opts,
+	}
+}
+
+// newZigToolchain uses `zig cc`, which bundles the libc of every target
+// it supports and so can cross compile without a sysroot
+func newZigToolchain(opts ToolchainOptions) Toolchain {
+	return &clangToolchain{
+		name:      "zig",
+		command:   []string{"zig", "cc"},
+		linkFlags: runtimeLinkFlags,
+		opts:      opts,
+	}
+}
+
+// newWasmToolchain builds for wasm32-wasi with clang. The wasi sysroot
+// is read from WASI_SYSROOT. Freestanding wasm targets need neither.
+func newWasmToolchain(opts ToolchainOptions) Toolchain {
+	if opts.Target == "" {
+		opts.Target = wasmTriple
+	}
+	if opts.Freestanding {
+		return &clangToolchain{
+			name:      "wasm",
+			command:   []string{"clang"},
+			linkFlags: []string{"--std=c99"},
+			opts:      opts,
+		}
+	}
+	flags := append([]string{}, runtimeLinkFlags...)
+	sysroot := os.Getenv("WASI_SYSROOT")
+	if sysroot != "" {
+		flags = append(flags, "--sysroot="+sysroot)
+	}
+	return &clangToolchain{
+		name:      "wasm",
+		command:   []string{"clang"},
+		linkFlags: flags,
+		sysroot:   sysroot,
+		opts:      opts,
+	}
+}
+
+func (t *clangToolchain) Name() string { return t.name }
+
+func (t *clangToolchain) Target() string { return t.opts.Target }
+
+// args returns the flags every command is run with
+func (t *clangToolchain) args() []string {
+	args := make([]string, 0)
+	if t.opts.Optimize != "" && t.opts.Optimize != "0" {
+		args = append(args, "-O"+t.opts.Optimize)
+	}
+	args = append(args, t.prefixMapArgs()...)
+	if t.opts.Library != "" {
+		args = append(args, "-fPIC")
+	}
+	return append(args, t.targetArgs()...)
+}
+
+// prefixMapArgs returns the flags that replace path prefixes in the output
+func (t *clangToolchain) prefixMapArgs() []string {
+	args := make([]string, 0, len(t.opts.PathPrefixMap))
+	for _, prefix := range t.opts.PathPrefixMap {
+		args = append(args, fmt.Sprintf("-ffile-prefix-map=%s=%s", prefix[0], prefix[1]))
+	}
+	return args
+}
+
+// targetArgs returns the flags that select the target, if there is one
+func (t *clangToolchain) targetArgs() []string {
+	if t.opts.Target == "" {
+		return nil
+	}
+	return []string{"-target", t.opts.Target}
+}
+
+func (t *clangToolchain) CompileC(src, obj string) error {
+	command := t.CompileCCommand(src, obj)
+	return runTool(command[:1], command[1:]...)
+}
+
+func (t *clangToolchain) CompileCCommand(src, obj string) []string {
+	command := append([]string{}, t.command...)
+	command = append(command, t.targetArgs()...)
+	command = append(command, t.prefixMapArgs()...)
+	if t.opts.Freestanding {
+		command = append(command, "-ffreestanding")
+	}
+	if t.opts.Library != "" {
+		command = append(command, "-fPIC")
+	}
+	return append(command, "-O3", "--std=c99", "-c", "-o", obj, src)
+}
+
+// PreprocessC preprocesses for the target, so the headers of its c library
+// are the ones included
+func (t *clangToolchain) PreprocessC(src, out string) error {
+	args := t.targetArgs()
+	if t.sysroot != "" {
+		args = append(args, "--sysroot="+t.sysroot)
+	}
+	if t.opts.Freestanding {
+		args = append(args, "-ffreestanding")
+	}
+	return runTool(t.command, append(args, "-E", "-dD", "-o", out, src)...)
+}
+
+func (t *clangToolchain) Emit(format EmitFormat, ir, out string) error {
+	args := t.args()
+	switch format {
+	case EmitAssembly:
+		// We want to only write intel syntax. AT&T Sucks
+		args = append(args, "-S", "-masm=intel", "-Wno-everything")
+	case EmitLLVM:
+		args = append(args, "-S", "-emit-llvm")
+	case EmitObject:
+		args = append(args, "-c")
+	}
+	return runTool(t.command, append(args, "-o", out, ir)...)
+}
+
+func (t *clangToolchain) Link(inputs []string, output string) error {
+	args := append(t.args(), t.linkFlags...)
+	args = append(args, inputs...)
+	if t.opts.Debug {
+		args = append(args, "-g")
+	}
+	args = append(args, "-o", output)
+	args = append(args, t.opts.Flags...)
+	return runTool(t.command, args...)
+}
+
+// LinkLibrary archives the objects of static libraries with ar, and links
+// shared libraries with -shared. Neither links the runtime's libraries, as
+// the program the library is linked into does.
+func (t *clangToolchain) LinkLibrary(inputs []string, output string) error {
+	if t.opts.Library == SharedLibrary {
+		args := append(t.args(), "-shared")
+		args = append(args, inputs...)
+		if t.opts.Debug {
+			args = append(args, "-g")
+		}
+		args = append(args, "-o", output)
+		args = append(args, t.opts.Flags...)
+		return runTool(t.command, args...)
+	}
+
+	objects, err := compileObjects(t, inputs)
+	if err != nil {
+		return err
+	}
+	// ar adds to the archive that is there already
+	os.Remove(output)
+	archiver := []string{"ar"}
+	if t.name == "zig" {
+		archiver = []string{"zig", "ar"}
+	}
+	return runTool(archiver, append([]string{"rcs", output}, objects...)...)
+}
+
+// llcToolchain compiles llvm ir with llc and links with lld, through the
+// clang driver so the c runtime startup files are still found. c sources
+// are compiled by clang.
+type llcToolchain struct {
+	clang *clangToolchain
+	opts  ToolchainOptions
+}
+
+func newLLCToolchain(opts ToolchainOptions) Toolchain {
+	clang := newClangToolchain(opts).(*clangToolchain)
+	clang.name = "llc"
+	clang.linkFlags = append([]string{"-fuse-ld=lld"}, runtimeLinkFlags...)
+	return &llcToolchain{clang, opts}
+}
+
+func (t *llcToolchain) Name() string { return "llc" }
+
+func (t *llcToolchain) Target() string { return t.opts.Target }
+
+func (t *llcToolchain) CompileC(src, obj string) error {
+	return t.clang.CompileC(src, obj)
+}
+
+func (t *llcToolchain) CompileCCommand(src, obj string) []string {
+	return t.clang.CompileCCommand(src, obj)
+}
+
+func (t *llcToolchain) PreprocessC(src, out string) error {
+	return t.clang.PreprocessC(src, out)
+}
+
+// llcArgs returns the flags llc is run with
+func (t *llcToolchain) llcArgs() []string {
+	args := []string{"-O" + llc.CodegenLevel(t.opts.Optimize)}
+	if t.opts.Target != "" {
+		args = append(args, "-mtriple="+t.opts.Target)
+	}
+	if t.opts.Library != "" {
+		args = append(args, "-relocation-model=pic")
+	}
+	return args
+}
+
+// optimize runs the optimization pipeline of opt over an ir file, returning
+// the bitcode file it writes. llc only optimizes the machine code, so
+// without it the ir would be compiled as it was emitted.
+func (t *llcToolchain) optimize(ir string) (string, error) {
+	if !t.optimizing() {
+		return ir, nil
+	}
+	out := strings.TrimSuffix(ir, filepath.Ext(ir)) + ".opt.bc"
+	if err := runTool([]string{"opt"}, "-O"+t.opts.Optimize, "-o", out, ir); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// optimizing reports whether the ir is run through opt's optimizations
+func (t *llcToolchain) optimizing() bool {
+	return t.opts.Optimize != "" && t.opts.Optimize != "0"
+}
+
+func (t *llcToolchain) Emit(format EmitFormat, ir, out string) error {
+	if format == EmitLLVM {
+		// opt without an optimization level writes the ir back out as it is
+		args := []string{"-S", "-o", out, ir}
+		if t.optimizing() {
+			args = append([]string{"-O" + t.opts.Optimize}, args...)
+		}
+		return runTool([]string{"opt"}, args...)
+	}
+	ir, err := t.optimize(ir)
+	if err != nil {
+		return err
+	}
+	if format == EmitObject {
+		return runTool([]string{"llc"}, append(t.llcArgs(), "-filetype=obj", "-o", out, ir)...)
+	}
+	return runTool([]string{"llc"}, append(t.llcArgs(), "-filetype=asm", "-o", out, ir)...)
+}
+
+func (t *llcToolchain) Link(inputs []string, output string) error {
+	objects, err := compileObjects(t, inputs)
+	if err != nil {
+		return err
+	}
+	return t.clang.Link(objects, output)
+}
+
+func (t *llcToolchain) LinkLibrary(inputs []string, output string) error {
+	objects, err := compileObjects(t, inputs)
+	if err != nil {
+		return err
+	}
+	return t.clang.LinkLibrary(objects, output)
+}
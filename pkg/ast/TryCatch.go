@@ -0,0 +1,296 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// TryCatchNode is `try { ... } catch (e Error) { ... }`, which runs the
+// catch block with the error in e when a call in the try block throws one
+// with `throw err`. Errors are runtime:Error, and `throw "msg"` throws one
+// with that message. An error nothing catches panics.
+//
+// Errors unwind the stack like c++ exceptions, see lib/runtime/error.c.
+// The calls in a try block are generated as invokes, whose landing pads
+// continue in the catch block, so a call that doesn't throw costs the same
+// as outside of a try. The withs in the try block are unlocked before the
+// catch block runs. The functions an error unwinds through unlock their
+// withs and run their defers, as if they returned, see cleanupPad.
+type TryCatchNode struct {
+	NodeType
+	TokenReference
+
+	Body BlockNode
+	// Name is the variable the error is caught in, and Type its type
+	Name  string
+	Type  TypeNode
+	Catch BlockNode
+}
+
+// ThrowNode is `throw err`, which throws an Error, or a string as the
+// message of one
+type ThrowNode struct {
+	NodeType
+	TokenReference
+
+	Value Node
+}
+
+// errorClass is the class of the errors that are thrown
+const errorClass = "Error"
+
+// tryBlock is a try block being generated
+type tryBlock struct {
+	// unlocks is the number of withs held when the try started, which
+	// stay held when it catches an error
+	unlocks int
+	// pads are the landing pads of the calls in the try, by the withs held
+	// at the call
+	pads map[cleanupKey]*ir.BasicBlock
+	// exception is where the landing pads store the exception they caught
+	// before continuing in caught
+	exception *ir.InstAlloca
+	caught    *ir.BasicBlock
+}
+
+// NameString implements Node.NameString
+func (n TryCatchNode) NameString() string { return "TryCatchNode" }
+
+// MarshalJSON implements json.Marshaler for TryCatchNode
+func (n TryCatchNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n TryCatchNode) String() string {
+	return fmt.Sprintf("try %s catch (%s %s) %s", n.Body, n.Name, n.Type, n.Catch)
+}
+
+// NameString implements Node.NameString
+func (n ThrowNode) NameString() string { return "ThrowNode" }
+
+// MarshalJSON implements json.Marshaler for ThrowNode
+func (n ThrowNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n ThrowNode) String() string {
+	return fmt.Sprintf("throw %s", n.Value)
+}
+
+// errorType returns the type of the errors that are thrown
+func (p *Program) errorType() types.Type {
+	return p.Scope.FindType(errorClass).Type
+}
+
+// Codegen implements Node.Codegen for TryCatchNode
+func (n TryCatchNode) Codegen(prog *Program) (value.Value, error) {
+	fn := prog.Compiler.CurrentFunc()
+	defers, found := prog.deferred[fn]
+	if !found {
+		return nil, n.Errorf(ErrInvalid, "try can only be used in a function")
+	}
+	errType := prog.errorType()
+	catchType, err := n.Type.GetType(prog)
+	if err != nil {
+		return nil, err
+	}
+	if !types.Equal(catchType, errType) {
+		return nil, n.Errorf(ErrType, "catch catches an %s, given %s", errorClass, n.Type)
+	}
+
+	try := &tryBlock{
+		unlocks:   len(defers.unlocks),
+		pads:      make(map[cleanupKey]*ir.BasicBlock),
+		exception: createBlockAlloca(fn, types.NewPointer(types.I8), "__try.exception"),
+		caught:    ir.NewBlock(mangleName("try.catch")),
+	}
+	prog.tries[fn] = append(prog.tries[fn], try)
+	_, err = n.Body.Codegen(prog)
+	prog.tries[fn] = prog.tries[fn][:len(prog.tries[fn])-1]
+	if err != nil {
+		return nil, err
+	}
+
+	end := fn.NewBlock(mangleName("try.end"))
+	prog.Compiler.CurrentBlock().BranchIfNoTerminator(end)
+
+	fn.AppendBlock(try.caught)
+	err = prog.Compiler.genInBlock(try.caught, func() error {
+		prog.ScopeDown(n.Token)
+		caught := createBlockAlloca(fn, errType, n.Name)
+		exception := try.caught.NewLoad(try.exception)
+		if _, err := prog.NewRuntimeFunctionCall("__runtime_catch", exception, caught); err != nil {
+			return err
+		}
+		prog.Scope.Add(NewVariableScopeItem(n.Name, caught, PrivateVisibility))
+		if _, err := n.Catch.Codegen(prog); err != nil {
+			return err
+		}
+		prog.Compiler.CurrentBlock().BranchIfNoTerminator(end)
+		return prog.ScopeUp()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prog.Compiler.PushBlock(end)
+	return nil, nil
+}
+
+// Codegen implements Node.Codegen for ThrowNode
+func (n ThrowNode) Codegen(prog *Program) (value.Value, error) {
+	ac, isAccessable := n.Value.(Accessable)
+	if !isAccessable {
+		return nil, n.Errorf(ErrInvalid, "%s is not accessable (has no readable value)", n.Value)
+	}
+	val, err := ac.GenAccess(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	errType := prog.errorType()
+	if isString(val.Type()) {
+		val = prog.Compiler.CurrentBlock().NewInsertValue(constant.NewZeroInitializer(errType), val, []int64{0})
+	}
+	if !types.Equal(val.Type(), errType) {
+		return nil, n.Errorf(ErrType, "throw needs an %s or a string, given %s", errorClass, prog.typeName(val.Type()))
+	}
+	thrown := createBlockAlloca(prog.Compiler.CurrentFunc(), errType, "__throw")
+	prog.Compiler.CurrentBlock().NewStore(val, thrown)
+
+	throw, err := prog.runtimeFunction("__runtime_throw")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := prog.genCall(throw, thrown); err != nil {
+		return nil, err
+	}
+	prog.Compiler.CurrentBlock().NewUnreachable()
+	return nil, nil
+}
+
+// genCall calls a function from geode code. In a try block the call is an
+// invoke, which continues in a new block when the function returns, and
+// in the landing pad of the try when it throws. Outside of a try, a call
+// that has withs to unlock or defers to run when it throws is an invoke
+// whose landing pad does that.
+func (p *Program) genCall(callee value.Value, args ...value.Value) (value.Value, error) {
+	block := p.Compiler.CurrentBlock()
+	fn := block.Parent
+	var pad *ir.BasicBlock
+	var err error
+	if tries := p.tries[fn]; len(tries) > 0 {
+		pad, err = p.landingPad(tries[len(tries)-1])
+	} else {
+		pad, err = p.cleanupPad()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pad == nil {
+		return block.NewCall(callee, args...), nil
+	}
+	normal := fn.NewBlock(mangleName("invoke.ok"))
+	invoke := block.NewInvoke(callee, args, normal, pad)
+	p.Compiler.PushBlock(normal)
+	return invoke, nil
+}
+
+// landingPad returns the landing pad of the calls in a try, made with the
+// withs held now. It unlocks the withs the try started, then continues in
+// the catch block.
+func (p *Program) landingPad(try *tryBlock) (*ir.BasicBlock, error) {
+	fn := p.Compiler.CurrentFunc()
+	defers := p.deferred[fn]
+	unlocks := defers.unlocks
+	key := defers.cleanups()
+	if pad, found := try.pads[key]; found {
+		return pad, nil
+	}
+	if err := p.setPersonality(fn); err != nil {
+		return nil, err
+	}
+
+	pad := fn.NewBlock(mangleName("try.pad"))
+	try.pads[key] = pad
+
+	// the unlocks are called outside of the try, as the pad is
+	tries := p.tries[fn]
+	p.tries[fn] = tries[:len(tries)-1]
+	defers.cleaning = true
+	defer func() {
+		p.tries[fn] = tries
+		defers.cleaning = false
+	}()
+
+	err := p.Compiler.genInBlock(pad, func() error {
+		lp := pad.NewLandingPad(exceptionType, constant.NewNull(types.NewPointer(types.I8)))
+		pad.NewStore(pad.NewExtractValue(lp, []int64{0}), try.exception)
+		for i := len(unlocks) - 1; i >= try.unlocks; i-- {
+			if _, err := unlocks[i].Codegen(p); err != nil {
+				return err
+			}
+		}
+		p.Compiler.CurrentBlock().NewBr(try.caught)
+		return nil
+	})
+	return pad, err
+}
+
+// cleanupPad returns the landing pad of a call outside of a try, made with
+// the withs held, the defers deferred and the --arc locals declared now, or
+// nil if there are none. An error the call throws unlocks the withs, runs
+// the deferred expressions and releases the locals, as a return would,
+// then unwinds on to the caller.
+func (p *Program) cleanupPad() (*ir.BasicBlock, error) {
+	fn := p.Compiler.CurrentFunc()
+	defers, found := p.deferred[fn]
+	locals := p.arcLocals()
+	if !found || defers.cleaning || len(defers.unlocks)+len(defers.exprs)+len(locals) == 0 {
+		return nil, nil
+	}
+	key := defers.cleanups()
+	key.locals = len(locals)
+	if pad, found := defers.pads[key]; found {
+		return pad, nil
+	}
+	if err := p.setPersonality(fn); err != nil {
+		return nil, err
+	}
+
+	pad := fn.NewBlock(mangleName("cleanup.pad"))
+	defers.pads[key] = pad
+
+	// an error thrown while cleaning up unwinds on without cleaning up again
+	defers.cleaning = true
+	defer func() { defers.cleaning = false }()
+
+	err := p.Compiler.genInBlock(pad, func() error {
+		lp := pad.NewLandingPad(exceptionType)
+		lp.Cleanup = true
+		if err := p.genDeferred(); err != nil {
+			return err
+		}
+		if err := p.arcRelease(locals); err != nil {
+			return err
+		}
+		p.Compiler.CurrentBlock().NewResume(lp)
+		return nil
+	})
+	return pad, err
+}
+
+// exceptionType is the type of the value of a landing pad, the exception
+// and the selector of the clause that caught it
+var exceptionType = types.NewStruct(types.NewPointer(types.I8), types.I32)
+
+// setPersonality gives a function with landing pads the personality that
+// finds them when an error unwinds through it
+func (p *Program) setPersonality(fn *ir.Function) error {
+	personality, err := p.runtimeFunction("__runtime_personality")
+	if err != nil {
+		return err
+	}
+	fn.Personality = personality
+	return nil
+}
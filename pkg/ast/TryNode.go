@@ -0,0 +1,115 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// TryNode is the ? operator, like `n = parse(s)?`. Its operand is a result:
+// a class with an error field, like runtime:Result<T>. When the error is
+// set the function it is in returns it, otherwise it gives the result's
+// value field, or the whole result if it has none.
+//
+// The function must return a result with the same type of error. If it
+// returns the same type of result, the result is returned as it is.
+type TryNode struct {
+	NodeType
+	TokenReference
+
+	Value Node
+}
+
+// NameString implements Node.NameString
+func (n TryNode) NameString() string { return "TryNode" }
+
+// MarshalJSON implements json.Marshaler for TryNode
+func (n TryNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n TryNode) String() string {
+	return fmt.Sprintf("%s?", n.Value)
+}
+
+// GenAccess implements Accessable.GenAccess
+func (n TryNode) GenAccess(prog *Program) (value.Value, error) {
+	return n.Codegen(prog)
+}
+
+// Codegen implements Node.Codegen for TryNode
+func (n TryNode) Codegen(prog *Program) (value.Value, error) {
+	ac, isAccessable := n.Value.(Accessable)
+	if !isAccessable {
+		return nil, n.Errorf(ErrInvalid, "%s is not accessable (has no readable value)", n.Value)
+	}
+	res, err := ac.GenAccess(prog)
+	if err != nil {
+		return nil, err
+	}
+
+	resType, isStruct := res.Type().(*types.StructType)
+	errIndex := -1
+	if isStruct {
+		errIndex = resType.FieldIndex("error")
+	}
+	if errIndex < 0 {
+		return nil, n.Errorf(ErrType, "? needs a result, a class with an error field, given %s", res.Type())
+	}
+	errType := resType.Fields[errIndex]
+
+	retType := prog.Compiler.CurrentFunc().Sig.Ret
+	retStruct, returnsStruct := retType.(*types.StructType)
+	retIndex := -1
+	if returnsStruct {
+		retIndex = retStruct.FieldIndex("error")
+	}
+	if retIndex < 0 || !types.Equal(retStruct.Fields[retIndex], errType) {
+		return nil, n.Errorf(ErrType, "? can only be used in a function that returns a result with an error of type %s", errType)
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	errVal := block.NewExtractValue(res, []int64{int64(errIndex)})
+
+	var failed value.Value
+	switch t := errType.(type) {
+	case *types.PointerType:
+		failed = block.NewICmp(ir.IntNE, errVal, constant.NewNull(t))
+	case *types.IntType:
+		failed = block.NewICmp(ir.IntNE, errVal, constant.NewInt(0, t))
+	default:
+		if !isString(errType) {
+			return nil, n.Errorf(ErrType, "the error of a result must be a string, a pointer or an integer, given %s", errType)
+		}
+		// a string error is set when it points to bytes, as even "" does
+		data := prog.stringData(errVal)
+		failed = block.NewICmp(ir.IntNE, data, constant.NewNull(data.Type().(*types.PointerType)))
+	}
+
+	parentFunc := block.Parent
+	failBlk := parentFunc.NewBlock(mangleName("try.fail"))
+	okBlk := parentFunc.NewBlock(mangleName("try.ok"))
+	block.NewCondBr(failed, failBlk, okBlk)
+
+	// the error is returned in the function's own result type
+	prog.Compiler.PushBlock(failBlk)
+	var ret value.Value = res
+	if !types.Equal(retType, resType) {
+		ret = failBlk.NewInsertValue(constant.NewZeroInitializer(retType), errVal, []int64{int64(retIndex)})
+	}
+	if err := prog.genDeferred(); err != nil {
+		return nil, err
+	}
+	if err := prog.arcReturn(ret); err != nil {
+		return nil, err
+	}
+	prog.Compiler.CurrentBlock().NewRet(ret)
+	prog.Compiler.PopBlock()
+
+	prog.Compiler.PushBlock(okBlk)
+	if valIndex := resType.FieldIndex("value"); valIndex >= 0 {
+		return okBlk.NewExtractValue(res, []int64{int64(valIndex)}), nil
+	}
+	return res, nil
+}
@@ -26,27 +26,40 @@ type TypeInfoNode struct {
 // NameString implements Node.NameString
 func (n TypeInfoNode) NameString() string { return "TypeInfoNode" }
 
+// MarshalJSON implements json.Marshaler for TypeInfoNode
+func (n TypeInfoNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for TypeInfoNode
 func (n TypeInfoNode) Codegen(prog *Program) (value.Value, error) {
-	found, ok := prog.TypeInfoDefs[n.T.String()]
-	if ok && found.Defined {
-		return found.Global, nil
-	}
-
 	analyzeType, err := n.T.GetType(prog)
 	if err != nil {
 		return nil, err
 	}
 
+	// The info is cached by the type the name resolves to, as a type
+	// parameter's name stands for a different type in every instantiation
+	key := analyzeType.String()
+	found, ok := prog.TypeInfoDefs[key]
+	if ok && found.Defined {
+		return found.Global, nil
+	}
+
 	// allocation was not found, so we make a new global one.
 	typ, _ := n.Type(prog)
 
 	sct := typ.(*types.StructType)
-	globl := prog.Module.NewGlobalDecl(fmt.Sprintf("type_info_%s", n.T), sct)
+	globalName := fmt.Sprintf("type_info_%s", n.T)
+	for _, g := range prog.Module.Globals {
+		if g.Name == globalName {
+			globalName = fmt.Sprintf("type_info_%s.%d", n.T, len(prog.TypeInfoDefs))
+			break
+		}
+	}
+	globl := prog.Module.NewGlobalDecl(globalName, sct)
 
 	globl.Init = constant.NewZeroInitializer(sct)
 
-	prog.TypeInfoDefs[n.T.String()] = &TypeInfoDeclaration{
+	prog.TypeInfoDefs[key] = &TypeInfoDeclaration{
 		Global:  globl,
 		Defined: false,
 	}
@@ -67,7 +80,7 @@ func (n TypeInfoNode) Codegen(prog *Program) (value.Value, error) {
 
 	prog.Compiler.CurrentBlock().NewStore(inst, globl)
 
-	prog.TypeInfoDefs[n.T.String()] = &TypeInfoDeclaration{
+	prog.TypeInfoDefs[key] = &TypeInfoDeclaration{
 		Global:  globl,
 		Defined: true,
 	}
@@ -75,6 +88,40 @@ func (n TypeInfoNode) Codegen(prog *Program) (value.Value, error) {
 	return globl, nil
 }
 
+// typeInfoGlobal returns the type info of a type as a global with a
+// constant value, for when it is needed without running any code
+func (p *Program) typeInfoGlobal(t types.Type, name string) (*ir.Global, error) {
+	sct := p.Scope.FindType("TypeInfo").Type.(*types.StructType)
+
+	fields := make([]constant.Constant, len(sct.Fields))
+	elemptr := constant.NewGetElementPtr(constant.NewNull(types.NewPointer(t)), constant.NewInt(1, types.I32))
+	fields[sct.FieldIndex("size")] = constant.NewPtrToInt(elemptr, sct.Fields[sct.FieldIndex("size")])
+	fields[sct.FieldIndex("name")] = p.newString(p.stringConstant(name), constant.NewInt(int64(len(name)), types.I64)).(constant.Constant)
+	init := constant.NewStruct(fields...)
+	init.Typ = sct
+
+	// an info(T) call compiled before this stores the same values
+	key := t.String()
+	if found, ok := p.TypeInfoDefs[key]; ok {
+		found.Global.Init = init
+		return found.Global, nil
+	}
+
+	globalName := fmt.Sprintf("type_info_%s", name)
+	for _, g := range p.Module.Globals {
+		if g.Name == globalName {
+			globalName = fmt.Sprintf("type_info_%s.%d", name, len(p.TypeInfoDefs))
+			break
+		}
+	}
+	globl := p.Module.NewGlobalDef(globalName, init)
+	p.TypeInfoDefs[key] = &TypeInfoDeclaration{
+		Global:  globl,
+		Defined: true,
+	}
+	return globl, nil
+}
+
 // GenAccess implements Accessable.Access for TypeInfoNode
 func (n TypeInfoNode) GenAccess(prog *Program) (value.Value, error) {
 	return n.Codegen(prog)
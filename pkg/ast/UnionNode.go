@@ -0,0 +1,432 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// UnionVariant is one of the variants of a union, with the fields it holds
+type UnionVariant struct {
+	TokenReference
+
+	Name   string
+	Fields []FunctionArg
+
+	// typ is the struct the fields are laid out as in the payload, made
+	// when the union is defined
+	typ *types.StructType
+}
+
+// UnionNode is a union declaration. A value of a union holds one of its
+// variants, and a hidden tag saying which. Values are made with the
+// constructors of the variants, like `Shape:Circle(1.0)`, or `Shape:Empty`
+// for a variant without fields. The fields can only be read by matching on
+// the value, which binds them to names in the arm of the variant:
+//
+//	match shape {
+//		Shape:Circle(r) { ... }
+//		Shape:Rect(w, h) { ... }
+//		Shape:Empty { ... }
+//	}
+//
+// A union is the llvm struct { i32, payload }, where the i32 is the tag and
+// the payload is an array as big as the largest variant and as aligned as
+// the most aligned one.
+type UnionNode struct {
+	NodeType
+	TokenReference
+
+	Package  *Package
+	Name     string
+	Variants []UnionVariant
+	Pub      bool // exported from its package with pub
+
+	typ *types.StructType
+}
+
+// NameString implements Node.NameString
+func (n UnionNode) NameString() string { return "UnionNode" }
+
+// MarshalJSON implements json.Marshaler for UnionNode
+func (n UnionNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n UnionNode) String() string {
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "union %s {", n.Name)
+	for i, v := range n.Variants {
+		if i > 0 {
+			buff.WriteString(",")
+		}
+		fmt.Fprintf(buff, " %s", v.Name)
+		if len(v.Fields) > 0 {
+			fields := make([]string, len(v.Fields))
+			for j, f := range v.Fields {
+				fields[j] = fmt.Sprintf("%s %s", f.Type, f.Name)
+			}
+			fmt.Fprintf(buff, "(%s)", strings.Join(fields, ", "))
+		}
+	}
+	buff.WriteString(" }")
+	return buff.String()
+}
+
+// Declare registers the union's type as an opaque struct, like a class, so
+// classes and unions can hold each other. It is laid out once the types of
+// the fields of every variant are known.
+func (n UnionNode) Declare(prog *Program) (value.Value, error) {
+	scopeName := n.Name
+	if prog.Package.Name != "runtime" {
+		scopeName = fmt.Sprintf("%s:%s", prog.Scope.PackageName, n.Name)
+	}
+	if _, exists := prog.Unions[scopeName]; exists {
+		return nil, n.Errorf(ErrInvalid, "union %s is declared more than once", n.Name)
+	}
+	if len(n.Variants) == 0 {
+		return nil, n.Errorf(ErrInvalid, "union %s has no variants", n.Name)
+	}
+
+	names := make(map[string]bool, len(n.Variants))
+	for _, v := range n.Variants {
+		if names[v.Name] {
+			return nil, v.Errorf(ErrInvalid, "union %s has two variants named %s", n.Name, v.Name)
+		}
+		names[v.Name] = true
+	}
+
+	structDefn := types.NewStruct()
+	structDefn.Opaque = true
+	structDefn.SetName(fmt.Sprintf("union.%s:%s", prog.Scope.PackageName, n.Name))
+	prog.Module.NewType(n.Name, structDefn)
+
+	n.Package = prog.Package
+	n.typ = structDefn
+	prog.Unions[scopeName] = &n
+	prog.unionTypes[structDefn] = &n
+
+	prog.Scope.GetRoot().RegisterType(scopeName, structDefn, -1)
+	prog.Scope.GetRoot().SetTypeVisibility(scopeName, prog.Package.Name, visibilityOf(n.Pub))
+	return nil, nil
+}
+
+// Codegen implements Node.Codegen for UnionNode. It finds the types of the
+// fields of the variants, which can be classes, so it runs after they are
+// declared.
+func (n UnionNode) Codegen(prog *Program) (value.Value, error) {
+	found, err := prog.FindType(n.Name)
+	if err != nil {
+		return nil, err
+	}
+	union := prog.unionTypes[found.(*types.StructType)]
+
+	for i := range union.Variants {
+		v := &union.Variants[i]
+		fields := make([]types.Type, len(v.Fields))
+		names := make(map[string]bool, len(v.Fields))
+		for j, f := range v.Fields {
+			if names[f.Name] {
+				return nil, v.Errorf(ErrInvalid, "%s:%s has two fields named %s", n.Name, v.Name, f.Name)
+			}
+			names[f.Name] = true
+			fields[j], err = f.Type.GetType(prog)
+			if err != nil {
+				return nil, v.Diagnose(err)
+			}
+		}
+		v.typ = types.NewStruct(fields...)
+	}
+	return nil, nil
+}
+
+// layoutUnions lays out every union, after the types of their variants are
+// found. The order they are laid out in doesn't matter, as a union lays out
+// the unions it holds first.
+func (p *Program) layoutUnions() error {
+	names := make([]string, 0, len(p.Unions))
+	for name := range p.Unions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	visiting := map[*UnionNode]bool{}
+	for _, name := range names {
+		if err := p.layoutUnion(p.Unions[name], visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// layoutUnion fills in the tag and payload of a union
+func (p *Program) layoutUnion(u *UnionNode, visiting map[*UnionNode]bool) error {
+	if !u.typ.Opaque {
+		return nil
+	}
+	if visiting[u] {
+		return u.Errorf(ErrInvalid, "union %s contains itself, a variant can only point to it", u.Name)
+	}
+	visiting[u] = true
+
+	size, align := int64(0), int64(1)
+	for _, v := range u.Variants {
+		for _, field := range v.typ.Fields {
+			if err := p.layoutContainedUnions(field, visiting); err != nil {
+				return err
+			}
+		}
+		vsize, err := p.sizeOf(v.typ)
+		if err != nil {
+			return v.Diagnose(err)
+		}
+		if vsize > size {
+			size = vsize
+		}
+		if valign := p.mustAlignOf(v.typ); valign > align {
+			align = valign
+		}
+	}
+
+	// the payload is made of the integer as wide as the alignment, so it
+	// is aligned like the variant that needs it most
+	payload := types.NewArray(types.NewInt(int(align*8)), alignTo(size, align)/align)
+	u.typ.Fields = []types.Type{types.I32, payload}
+	u.typ.Opaque = false
+	return nil
+}
+
+// layoutContainedUnions lays out the unions a value of a type holds, and
+// not just points to, even through the fields of classes
+func (p *Program) layoutContainedUnions(t types.Type, visiting map[*UnionNode]bool) error {
+	for _, st := range containedStructs(t) {
+		if u := p.unionTypes[st]; u != nil {
+			if err := p.layoutUnion(u, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, field := range st.Fields {
+			if err := p.layoutContainedUnions(field, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupUnion returns the union declared with a name. The unions of the
+// current package are searched first, then the pub unions of every other
+// package.
+func (p *Program) lookupUnion(name string) *UnionNode {
+	if union, found := p.Unions[fmt.Sprintf("%s:%s", p.Scope.PackageName, name)]; found {
+		return union
+	}
+	if union, found := p.Unions[name]; found && p.unionVisible(union) {
+		return union
+	}
+	names := make([]string, 0, len(p.Unions))
+	for scopeName := range p.Unions {
+		names = append(names, scopeName)
+	}
+	sort.Strings(names)
+	for _, scopeName := range names {
+		if _, nm := ParseName(scopeName); nm == name && p.unionVisible(p.Unions[scopeName]) {
+			return p.Unions[scopeName]
+		}
+	}
+	return nil
+}
+
+func (p *Program) unionVisible(union *UnionNode) bool {
+	return visibleFrom(visibilityOf(union.Pub), union.Package.Name, p.Scope.PackageName)
+}
+
+// unionVariant returns the union a name like `Shape:Circle` is a variant
+// of and the variant's tag
+func (p *Program) unionVariant(name string) (*UnionNode, int, bool) {
+	if p.Unions == nil || !strings.Contains(name, separator) {
+		return nil, 0, false
+	}
+	unionName, variant := ParseName(name)
+	union := p.lookupUnion(unionName)
+	if union == nil {
+		return nil, 0, false
+	}
+	for tag, v := range union.Variants {
+		if v.Name == variant {
+			return union, tag, true
+		}
+	}
+	return nil, 0, false
+}
+
+// unionOf returns the union a type is, or nil if it isn't one
+func (p *Program) unionOf(t types.Type) *UnionNode {
+	st, ok := t.(*types.StructType)
+	if !ok {
+		return nil
+	}
+	return p.unionTypes[st]
+}
+
+// unionConstructor returns if a call is to the constructor of a variant of
+// a union, and which
+func unionConstructor(prog *Program, n FunctionCallNode) (*UnionNode, int, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return nil, 0, false
+	}
+	return prog.unionVariant(ident.String())
+}
+
+// genUnionValue makes a value of a union that holds a variant, with its
+// fields set to the values given
+func (p *Program) genUnionValue(u *UnionNode, tag int, args []Node, at Node) (value.Value, error) {
+	v := u.Variants[tag]
+	if len(args) != len(v.Fields) {
+		return nil, at.Errorf(ErrInvalid, "%s:%s takes %d fields, given %d", u.Name, v.Name, len(v.Fields), len(args))
+	}
+
+	block := p.Compiler.CurrentBlock()
+	alloc := createBlockAlloca(block.Parent, u.typ, "")
+	alloc.Align = p.alignment(u.typ, 0)
+	zero := constant.NewInt(0, types.I32)
+	block.NewStore(constant.NewInt(int64(tag), types.I32), block.NewGetElementPtr(alloc, zero, zero))
+
+	if len(args) > 0 {
+		payload := unionPayload(block, alloc, v)
+		for i, arg := range args {
+			val, err := arg.Codegen(p)
+			if err != nil {
+				return nil, err
+			}
+			val, err = createTypeCast(p, val, v.typ.Fields[i])
+			if err != nil {
+				return nil, arg.Diagnose(err)
+			}
+			if err := p.arcRetain(val); err != nil {
+				return nil, err
+			}
+			block = p.Compiler.CurrentBlock()
+			block.NewStore(val, block.NewGetElementPtr(payload, zero, constant.NewInt(int64(i), types.I32)))
+		}
+	}
+	return p.Compiler.CurrentBlock().NewLoad(alloc), nil
+}
+
+// unionPayload returns a pointer to the payload of the union at ptr, as the
+// fields of a variant
+func unionPayload(block *ir.BasicBlock, ptr value.Value, v UnionVariant) value.Value {
+	zero := constant.NewInt(0, types.I32)
+	payload := block.NewGetElementPtr(ptr, zero, constant.NewInt(1, types.I32))
+	return block.NewBitCast(payload, types.NewPointer(v.typ))
+}
+
+// unionArm is an arm of a match on a union. An arm that names a single
+// variant with parentheses binds the fields of the variant to the names in
+// them, with _ skipping a field.
+type unionArm struct {
+	variant  int
+	bindings []Node
+}
+
+// unionCases returns the cases of the switch a match on a union is compiled
+// to, which switches on the tag, and what each arm binds
+func (n MatchNode) unionCases(prog *Program, u *UnionNode) ([]matchCase, []unionArm, error) {
+	cases := make([]matchCase, 0)
+	arms := make([]unionArm, len(n.Arms))
+	covered := make(map[int]bool)
+	for i, arm := range n.Arms {
+		for _, val := range arm.Values {
+			var name string
+			var bindings []Node
+			binds := false
+			switch v := val.(type) {
+			case IdentNode:
+				name = v.Value
+			case FunctionCallNode:
+				ident, ok := v.Name.(IdentNode)
+				if !ok {
+					return nil, nil, val.Errorf(ErrInvalid, "match on union %s takes its variants, like %s:%s", u.Name, u.Name, u.Variants[0].Name)
+				}
+				name = ident.Value
+				bindings = v.Args
+				binds = true
+			default:
+				return nil, nil, val.Errorf(ErrInvalid, "match on union %s takes its variants, like %s:%s", u.Name, u.Name, u.Variants[0].Name)
+			}
+
+			union, tag, found := prog.unionVariant(name)
+			if !found || union != u {
+				return nil, nil, val.Errorf(ErrInvalid, "%s is not a variant of union %s", name, u.Name)
+			}
+			variant := u.Variants[tag]
+			if binds && len(bindings) != len(variant.Fields) {
+				return nil, nil, val.Errorf(ErrInvalid, "%s:%s has %d fields, given %d names for them", u.Name, variant.Name, len(variant.Fields), len(bindings))
+			}
+			if binds && len(arm.Values) > 1 {
+				return nil, nil, val.Errorf(ErrInvalid, "an arm that binds the fields of %s:%s can only match it", u.Name, variant.Name)
+			}
+			if covered[tag] {
+				return nil, nil, val.Errorf(ErrInvalid, "%s:%s is matched by more than one arm", u.Name, variant.Name)
+			}
+			covered[tag] = true
+
+			arms[i] = unionArm{tag, bindings}
+			cases = append(cases, matchCase{ir.NewCase(constant.NewInt(int64(tag), types.I32), nil), i})
+		}
+	}
+
+	if n.Else == nil {
+		missing := make([]string, 0)
+		for tag, v := range u.Variants {
+			if !covered[tag] {
+				missing = append(missing, fmt.Sprintf("%s:%s", u.Name, v.Name))
+			}
+		}
+		if len(missing) > 0 {
+			return nil, nil, n.Errorf(ErrInvalid, "match on union %s doesn't handle %s. Add arms for them or an else arm", u.Name, strings.Join(missing, ", "))
+		}
+	}
+	return cases, arms, nil
+}
+
+// bindUnionFields declares the variables an arm of a match on a union binds
+// the fields of its variant to, in the scope of the arm
+func (p *Program) bindUnionFields(u *UnionNode, arm unionArm, subject value.Value) error {
+	if len(arm.bindings) == 0 {
+		return nil
+	}
+	variant := u.Variants[arm.variant]
+	block := p.Compiler.CurrentBlock()
+	zero := constant.NewInt(0, types.I32)
+	payload := unionPayload(block, subject, variant)
+
+	for i, binding := range arm.bindings {
+		ident, ok := binding.(IdentNode)
+		if !ok {
+			return binding.Errorf(ErrInvalid, "the fields of %s:%s can only be bound to names, given %s", u.Name, variant.Name, binding)
+		}
+		if ident.Value == "_" {
+			continue
+		}
+		typ := variant.typ.Fields[i]
+		field := block.NewLoad(block.NewGetElementPtr(payload, zero, constant.NewInt(int64(i), types.I32)))
+		alloc := createBlockAlloca(block.Parent, typ, ident.Value)
+		alloc.Align = p.alignment(typ, 0)
+		p.arcDeclare(alloc)
+		if err := p.arcStore(field, alloc); err != nil {
+			return err
+		}
+
+		p.warnShadow(ident.Value, ident.Token)
+		p.Scope.Add(NewVariableScopeItem(ident.Value, alloc, PrivateVisibility))
+		p.declareVariable(ident.Value, ident.Token, alloc)
+	}
+	return nil
+}
@@ -5,10 +5,8 @@ import (
 	"fmt"
 
 	"github.com/geode-lang/geode/llvm/ir"
-	"github.com/geode-lang/geode/llvm/ir/constant"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 // VariableDefnNode -
@@ -21,6 +19,10 @@ type VariableDefnNode struct {
 	Name           IdentNode
 	Body           Node
 	NeedsInference bool
+	// Align is the alignment given with @align, or 0
+	Align int
+	// Weak is set on fields of classes marked with @weak, see Arc.go
+	Weak bool
 
 	Package *Package
 }
@@ -28,6 +30,9 @@ type VariableDefnNode struct {
 // NameString implements Node.NameString
 func (n VariableDefnNode) NameString() string { return "VariableDefnNode" }
 
+// MarshalJSON implements json.Marshaler for VariableDefnNode
+func (n VariableDefnNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 // Codegen implements Node.Codegen for VariableDefnNode
 func (n VariableDefnNode) Codegen(prog *Program) (value.Value, error) {
 	var err error
@@ -52,8 +57,7 @@ func (n VariableDefnNode) Codegen(prog *Program) (value.Value, error) {
 			return nil, err
 		}
 		if found == nil {
-			n.SyntaxError()
-			log.Fatal("Unable to find type named %q for variable declaration\n", n.Typ.Name)
+			return nil, n.Errorf(ErrUndefined, "unable to find type named %q for variable declaration", n.Typ.Name)
 		}
 		valType, err = n.Typ.GetType(prog)
 		if err != nil {
@@ -72,6 +76,7 @@ func (n VariableDefnNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	alloc = createBlockAlloca(f, valType, name.String())
+	alloc.Align = prog.alignment(valType, n.Align)
 
 	if !n.NeedsInference {
 		prog.Compiler.PushType(valType)
@@ -85,10 +90,14 @@ func (n VariableDefnNode) Codegen(prog *Program) (value.Value, error) {
 	}
 
 	prog.Compiler.PushType(alloc.Elem)
+	prog.warnShadow(name.String(), n.Token)
 	scItem := NewVariableScopeItem(name.String(), alloc, PrivateVisibility)
 	prog.Scope.Add(scItem)
+	prog.declareVariable(name.String(), n.Token, alloc)
+	prog.arcDeclare(alloc)
 
 	if !n.NeedsInference && val != nil {
+		prog.warnNarrowing(n.Token, val, alloc.Elem)
 		val, err = createTypeCast(prog, val, alloc.Elem)
 		if err != nil {
 			return nil, err
@@ -97,10 +106,15 @@ func (n VariableDefnNode) Codegen(prog *Program) (value.Value, error) {
 
 	// If the value is nil, we need to pull the default value for a given type.
 	if val == nil {
-		val = constant.NewZeroInitializer(alloc.Elem)
+		val, err = prog.classZeroValue(alloc.Elem)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	block.NewStore(val, alloc)
+	if err := prog.arcStore(val, alloc); err != nil {
+		return nil, err
+	}
 
 	return alloc, nil
 }
@@ -112,7 +126,9 @@ func (n VariableDefnNode) GenAssign(prog *Program, val value.Value, options ...A
 	if err != nil {
 		return nil, err
 	}
-	prog.Compiler.CurrentBlock().NewStore(val, alloc)
+	if err := prog.arcStore(val, alloc); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
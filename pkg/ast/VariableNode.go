@@ -24,6 +24,9 @@ type VariableNode struct {
 // NameString implements Node.NameString
 func (n VariableNode) NameString() string { return "VariableNode" }
 
+// MarshalJSON implements json.Marshaler for VariableNode
+func (n VariableNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
 func (n VariableNode) String() string {
 	return n.Name.String()
 }
@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// vec<T> is the builtin growable array of T. It is the runtime class
+// vec<T>, the struct `{len, cap, data}`, whose push and pop methods grow
+// and shrink it. The compiler indexes a vec through its data, checked
+// against its length with --bounds-checks, and a for-each loop iterates
+// over it by index, both like a slice. vecs have nothing to do with the
+// simd vectors in Vector.go.
+const vecClass = "vec"
+
+// the fields of a vec
+const (
+	vecLenField  = 0
+	vecCapField  = 1
+	vecDataField = 2
+)
+
+// isVec returns if a type is an instance of vec<T>
+func isVec(t types.Type) bool {
+	st, isStruct := t.(*types.StructType)
+	return isStruct && len(st.Fields) == 3 && strings.HasPrefix(st.Name, fmt.Sprintf("class.runtime:%sI", vecClass))
+}
+
+// vecData returns the pointer to the elements of a vec
+func (p *Program) vecData(v value.Value) value.Value {
+	return p.Compiler.CurrentBlock().NewExtractValue(v, []int64{vecDataField})
+}
+
+// vecLen returns the number of elements in a vec
+func (p *Program) vecLen(v value.Value) value.Value {
+	return p.Compiler.CurrentBlock().NewExtractValue(v, []int64{vecLenField})
+}
@@ -0,0 +1,227 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// vectorTypes are the simd vector types. A vector holds a fixed number of
+// integers or floats, and arithmetic and comparisons on vectors work on
+// every element at once, lowered to llvm's vector instructions. They are
+// named after their element type and length, an `f32x4` holds four 32 bit
+// floats. A scalar used as a vector is copied into every element, so
+// `v * 2` doubles every element of v.
+var vectorTypes = map[string]*types.VectorType{
+	"i8x16": types.NewVector(types.I8, 16),
+	"i16x8": types.NewVector(types.I16, 8),
+	"i32x4": types.NewVector(types.I32, 4),
+	"i32x8": types.NewVector(types.I32, 8),
+	"i64x2": types.NewVector(types.I64, 2),
+	"i64x4": types.NewVector(types.I64, 4),
+	"f32x4": types.NewVector(types.Float, 4),
+	"f32x8": types.NewVector(types.Float, 8),
+	"f64x2": types.NewVector(types.Double, 2),
+	"f64x4": types.NewVector(types.Double, 4),
+}
+
+// simdNamespace is the namespace of the builtins that build vectors.
+//
+//	simd:splat(x, n)              a vector of n copies of x
+//	simd:shuffle(a, b, i, ...)    a vector of the elements of a and b at
+//	                              the indices, where b's start after a's
+//
+// The length and the indices must be integer literals.
+const simdNamespace = "simd"
+
+// scalarType returns the type of the elements of a vector type, or the
+// type itself for any other type
+func scalarType(t types.Type) types.Type {
+	if vec, ok := t.(*types.VectorType); ok {
+		return vec.Elem
+	}
+	return t
+}
+
+// checkVectorOperands makes sure the operands of a binary operation can be
+// used together if either is a vector. Both must be the same vector type,
+// or the other must be a number that is copied into every element.
+func checkVectorOperands(left, right value.Value) error {
+	lt, rt := left.Type(), right.Type()
+	lvec, rvec := types.IsVector(lt), types.IsVector(rt)
+	switch {
+	case lvec && rvec && !types.Equal(lt, rt):
+		return fmt.Errorf("mismatched vector types %s and %s", lt, rt)
+	case lvec && !rvec && !types.IsNumber(rt):
+		return fmt.Errorf("unable to use %s with vector %s", rt, lt)
+	case rvec && !lvec && !types.IsNumber(lt):
+		return fmt.Errorf("unable to use %s with vector %s", lt, rt)
+	}
+	return nil
+}
+
+// createVectorCast converts a value to a vector type. A scalar is converted
+// to the element type and copied into every element, and a vector of the
+// same length is converted element by element.
+func createVectorCast(prog *Program, in value.Value, to *types.VectorType) (value.Value, error) {
+	from, isVector := in.Type().(*types.VectorType)
+	if !isVector {
+		elem, err := createTypeCast(prog, in, to.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return splat(prog, elem, to.Len), nil
+	}
+	if from.Len != to.Len {
+		return nil, fmt.Errorf("unable to convert vector %s to %s of a different length", from, to)
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	inSize, outSize := typeSize(from.Elem), typeSize(to.Elem)
+	fromInt, toInt := types.IsInt(from.Elem), types.IsInt(to.Elem)
+	switch {
+	case fromInt && toInt && inSize < outSize:
+		return block.NewSExt(in, to), nil
+	case fromInt && toInt:
+		return block.NewTrunc(in, to), nil
+	case fromInt:
+		return block.NewSIToFP(in, to), nil
+	case toInt:
+		return block.NewFPToSI(in, to), nil
+	case inSize < outSize:
+		return block.NewFPExt(in, to), nil
+	}
+	return block.NewFPTrunc(in, to), nil
+}
+
+// splat returns a vector of n copies of a scalar
+func splat(prog *Program, x value.Value, n int64) value.Value {
+	block := prog.Compiler.CurrentBlock()
+	vec := types.NewVector(x.Type(), n)
+	zero := constant.NewInt(0, types.I32)
+	single := block.NewInsertElement(constant.NewUndef(vec), x, zero)
+	return block.NewShuffleVector(single, constant.NewUndef(vec), constant.NewZeroInitializer(types.NewVector(types.I32, n)))
+}
+
+// simdBuiltin returns the name of the simd builtin a call is to, if it is
+// one. A function declared in a package named simd takes priority.
+func simdBuiltin(prog *Program, n FunctionCallNode) (string, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return "", false
+	}
+	namespace, name := ParseName(ident.String())
+	if namespace != simdNamespace {
+		return "", false
+	}
+	_, declared := prog.Functions[ident.String()]
+	return name, !declared
+}
+
+// genSimd generates one of the simd builtins
+func genSimd(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	val, err := genSimdOp(prog, name, n)
+	if err != nil {
+		return nil, n.Errorf(ErrInvalid, "%s:%s: %s", simdNamespace, name, err)
+	}
+	return val, nil
+}
+
+func genSimdOp(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	var operands int
+	switch name {
+	case "splat":
+		if len(n.Args) != 2 {
+			return nil, fmt.Errorf("takes a value and the length of the vector")
+		}
+		operands = 1
+	case "shuffle":
+		if len(n.Args) < 3 {
+			return nil, fmt.Errorf("takes two vectors and the indices of the elements to take from them")
+		}
+		operands = 2
+	default:
+		return nil, fmt.Errorf("unknown simd builtin, expected splat or shuffle")
+	}
+
+	args := make([]value.Value, 0, operands)
+	for _, arg := range n.Args[:operands] {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, fmt.Errorf("argument %s is not accessable (has no readable value)", arg)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, val)
+	}
+
+	literals := make([]int64, 0, len(n.Args)-operands)
+	for _, arg := range n.Args[operands:] {
+		lit, ok := arg.(IntNode)
+		if !ok {
+			return nil, fmt.Errorf("the lengths and indices must be integer literals")
+		}
+		literals = append(literals, lit.Value)
+	}
+
+	if name == "splat" {
+		if !types.IsNumber(args[0].Type()) {
+			return nil, fmt.Errorf("expects an integer or float, given %s", args[0].Type())
+		}
+		if literals[0] <= 0 {
+			return nil, fmt.Errorf("the length of a vector must be positive")
+		}
+		return splat(prog, args[0], literals[0]), nil
+	}
+
+	vec, ok := args[0].Type().(*types.VectorType)
+	if !ok {
+		return nil, fmt.Errorf("expects vectors, given %s", args[0].Type())
+	}
+	if !types.Equal(vec, args[1].Type()) {
+		return nil, fmt.Errorf("expects two vectors of the same type, given %s and %s", vec, args[1].Type())
+	}
+	mask := make([]constant.Constant, len(literals))
+	for i, index := range literals {
+		if index < 0 || index >= 2*vec.Len {
+			return nil, fmt.Errorf("index %d is out of range for two %s", index, vec)
+		}
+		mask[i] = constant.NewInt(index, types.I32)
+	}
+	return prog.Compiler.CurrentBlock().NewShuffleVector(args[0], args[1], constant.NewVector(mask...)), nil
+}
+
+// genVectorElement returns an element of a vector that isn't stored
+// anywhere it can be addressed
+func genVectorElement(prog *Program, vec value.Value, idx value.Value) (value.Value, error) {
+	if err := checkVectorIndex(vec.Type().(*types.VectorType), idx); err != nil {
+		return nil, err
+	}
+	return prog.Compiler.CurrentBlock().NewExtractElement(vec, idx), nil
+}
+
+// genVectorElementPtr returns the address of an element of a vector loaded
+// from memory, so the element can be assigned to
+func genVectorElementPtr(prog *Program, vec *ir.InstLoad, idx value.Value) (*ir.InstGetElementPtr, error) {
+	if err := checkVectorIndex(vec.Type().(*types.VectorType), idx); err != nil {
+		return nil, err
+	}
+	return prog.Compiler.CurrentBlock().NewGetElementPtr(vec.Src, constant.NewInt(0, types.I32), idx), nil
+}
+
+// checkVectorIndex makes sure an index into a vector is an integer, and in
+// range if it is a constant
+func checkVectorIndex(vec *types.VectorType, idx value.Value) error {
+	if !types.IsInt(idx.Type()) {
+		return fmt.Errorf("vectors must be indexed by integers, not %s", idx.Type())
+	}
+	if c, ok := idx.(*constant.Int); ok && (c.X.Sign() < 0 || c.X.Int64() >= vec.Len) {
+		return fmt.Errorf("index %s is out of range for %s", c.X, vec)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package ast
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/semver"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// versionSeparator separates the name of a package from its version in the
+// name of a versioned directory, like geodepkgs/json@1.2.0
+const versionSeparator = "@"
+
+// The versions of the packages that are installed in versioned directories
+var (
+	// versionConstraints are the versions the manifest accepts
+	versionConstraints = map[string]semver.Constraint{}
+	// lockedVersions are the versions the lock file pins
+	lockedVersions = map[string]string{}
+)
+
+// SetDependencyVersions sets the versions the packages in versioned
+// directories have to match, and the ones the lock file pins. Locked
+// versions are used as long as they match and are installed.
+func SetDependencyVersions(constraints map[string]semver.Constraint, locked map[string]string) {
+	versionConstraints = constraints
+	lockedVersions = locked
+}
+
+// ResolvedVersions returns the versions the packages in versioned
+// directories resolved to, by their names
+func (p *Program) ResolvedVersions() map[string]string {
+	return p.resolvedVersions
+}
+
+// installedVersions returns the versions of a package in versioned
+// directories in a search path, by their directories
+func (p *Program) installedVersions(dir, name string) map[string]semver.Version {
+	versions := make(map[string]semver.Version)
+	entries, err := p.sources().ReadDir(dir)
+	if err != nil {
+		return versions
+	}
+	prefix := name + versionSeparator
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		v, err := semver.Parse(strings.TrimPrefix(entry.Name(), prefix))
+		if err != nil {
+			log.Verbose("Ignoring %s: %s\n", filepath.Join(dir, entry.Name()), err)
+			continue
+		}
+		versions[filepath.Join(dir, entry.Name())] = v
+	}
+	return versions
+}
+
+// resolveVersion returns the versioned directory of a package in a search
+// path. It is the one the package already resolved to or the lock file
+// pins if it is there, or else the newest one the manifest accepts. It is
+// "" if no version is installed there.
+func (p *Program) resolveVersion(dir, name string) (string, error) {
+	constraint, constrained := versionConstraints[name]
+	var best string
+	var bestVersion semver.Version
+	for path, v := range p.installedVersions(dir, name) {
+		if constrained && !constraint.Allows(v) {
+			continue
+		}
+		if v.String() == p.resolvedVersions[name] || (p.resolvedVersions[name] == "" && v.String() == lockedVersions[name]) {
+			best, bestVersion = path, v
+			break
+		}
+		if best == "" || v.Compare(bestVersion) > 0 {
+			best, bestVersion = path, v
+		}
+	}
+	if best == "" {
+		return "", nil
+	}
+	if old := p.resolvedVersions[name]; old != "" && old != bestVersion.String() {
+		return "", fmt.Errorf("package %s resolved to both version %s and %s", name, old, bestVersion)
+	}
+	p.resolvedVersions[name] = bestVersion.String()
+	return best, nil
+}
+
+// missingVersion returns the error of a package the manifest wants a
+// version of that isn't installed, with the versions that are in the
+// search paths
+func (p *Program) missingVersion(searchPaths []string, name string) error {
+	versions := []semver.Version{}
+	for _, dir := range searchPaths {
+		for _, v := range p.installedVersions(dir, name) {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no version of package %s is installed, the manifest needs %s", name, versionConstraints[name])
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+	found := make([]string, len(versions))
+	for i, v := range versions {
+		found[i] = v.String()
+	}
+	return fmt.Errorf("no installed version of package %s matches %s, found %s", name, versionConstraints[name], strings.Join(found, ", "))
+}
@@ -0,0 +1,150 @@
+package ast
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/geode-lang/geode/pkg/semver"
+)
+
+// versionedProgram returns a program that reads its packages from the
+// directories in /proj, with no GEODE_PATH and no standard library, and
+// resolves versions with the constraints and locked versions
+func versionedProgram(t *testing.T, dirs []string, constraints map[string]string, locked map[string]string) *Program {
+	t.Helper()
+	t.Setenv("GEODE_PATH", "")
+	t.Setenv("GEODELIB", "/no/stdlib")
+
+	fsys := fstest.MapFS{}
+	for _, dir := range dirs {
+		fsys[dir+"/pkg.g"] = &fstest.MapFile{Data: []byte("is " + filepath.Base(dir) + "\n")}
+	}
+	p := NewProgram()
+	p.Sources = FSSources("/proj", fsys)
+
+	parsed := make(map[string]semver.Constraint)
+	for name, text := range constraints {
+		c, err := semver.ParseConstraint(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed[name] = c
+	}
+	if locked == nil {
+		locked = map[string]string{}
+	}
+	SetDependencyVersions(parsed, locked)
+	t.Cleanup(func() {
+		SetDependencyVersions(map[string]semver.Constraint{}, map[string]string{})
+	})
+	return p
+}
+
+func TestResolveVersion(t *testing.T) {
+	installed := []string{
+		"geodepkgs/http@1.2.0",
+		"geodepkgs/http@1.4.1",
+		"geodepkgs/http@2.0.0-beta",
+		"geodepkgs/http@2.0.0",
+		"geodepkgs/json@0.3.0-beta.2",
+		"geodepkgs/json@0.3.0-beta.10",
+		"geodepkgs/yaml@0.9.0",
+	}
+	tests := []struct {
+		name       string
+		constraint string
+		locked     string
+		want       string
+		err        string
+	}{
+		{"http", "^1.2.0", "", "http@1.4.1", ""},
+		{"http", "^1.2.0", "1.2.0", "http@1.2.0", ""},
+		// a locked version the manifest no longer accepts is replaced
+		{"http", "^1.2.0", "2.0.0", "http@1.4.1", ""},
+		{"http", "^2.0.0", "", "http@2.0.0", ""},
+		{"http", ">=2.0.0-0", "", "http@2.0.0", ""},
+		{"json", ">=0.3.0-beta", "", "json@0.3.0-beta.10", ""},
+		{"yaml", "^1.0.0", "", "", "no installed version of package yaml matches ^1.0.0, found 0.9.0"},
+		{"toml", "^1.0.0", "", "", "no version of package toml is installed"},
+	}
+	for _, test := range tests {
+		var locked map[string]string
+		if test.locked != "" {
+			locked = map[string]string{test.name: test.locked}
+		}
+		p := versionedProgram(t, installed, map[string]string{test.name: test.constraint}, locked)
+		got, err := p.ResolveDepPath(context.Background(), "/proj", test.name)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s %s resolved to %q, %v, want an error with %q", test.name, test.constraint, got, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", test.name, test.constraint, err)
+			continue
+		}
+		if want := filepath.Join("/proj/geodepkgs", test.want); got != want {
+			t.Errorf("%s %s locked at %q resolved to %q, want %q", test.name, test.constraint, test.locked, got, want)
+		}
+		if v := test.name + versionSeparator + p.ResolvedVersions()[test.name]; v != test.want {
+			t.Errorf("%s resolved to version %q, want %q", test.name, v, test.want)
+		}
+	}
+}
+
+func TestResolveVersionOnce(t *testing.T) {
+	p := versionedProgram(t, []string{
+		"geodepkgs/http@1.2.0",
+		"vendor/http@1.4.1",
+	}, map[string]string{"http": "^1.0.0"}, nil)
+	p.AddSearchPath("/proj/vendor")
+
+	// the added search path is searched before geodepkgs
+	got, err := p.ResolveDepPath(context.Background(), "/proj", "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "/proj/vendor/http@1.4.1" {
+		t.Errorf("http resolved to %q, want /proj/vendor/http@1.4.1", got)
+	}
+
+	// an include from a directory where another version is found first
+	// can't resolve the package to it too
+	if _, err := p.ResolveDepPath(context.Background(), "/proj/geodepkgs", "http"); err == nil {
+		t.Errorf("http resolved to a second version without an error")
+	}
+}
+
+func TestSearchPaths(t *testing.T) {
+	p := versionedProgram(t, []string{
+		"shared/log",
+		"shared/util",
+		"vendor/util",
+		"vendor/net",
+		"geodepkgs/net",
+		"geodepkgs/json",
+	}, nil, nil)
+	t.Setenv("GEODE_PATH", "/proj/shared")
+	p.AddSearchPath("/proj/vendor")
+
+	// GEODE_PATH is searched first, then the added paths, then geodepkgs
+	for name, want := range map[string]string{
+		"log":  "/proj/shared/log",
+		"util": "/proj/shared/util",
+		"net":  "/proj/vendor/net",
+		"json": "/proj/geodepkgs/json",
+	} {
+		got, err := p.ResolveDepPath(context.Background(), "/proj", name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s resolved to %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// Functions, classes, enums, unions, interfaces and globals are private to
+// the package that declares them unless they are marked with pub, which comes
+// before any attributes:
+//
+//     pub func open(string path) File* { ... }
+//     pub class File { ... }
+//
+// Methods are visible wherever their class is. The runtime package is
+// visible everywhere, as the compiler calls into it from every package.
+
+// visibilityOf returns the visibility of a declaration
+func visibilityOf(pub bool) Visibility {
+	if pub {
+		return PublicVisibility
+	}
+	return PrivateVisibility
+}
+
+// visibleFrom reports if something declared in package pkg with the
+// visibility vis can be used from the package from
+func visibleFrom(vis Visibility, pkg, from string) bool {
+	return vis == PublicVisibility || pkg == "" || pkg == from || pkg == "runtime"
+}
+
+// parsePubStmt parses a top level statement marked with pub
+func (p *Parser) parsePubStmt() Node {
+	start := p.token
+	p.Next()
+
+	switch n := p.parseTopLevelStmt().(type) {
+	case FunctionNode:
+		n.Pub = true
+		return n
+	case ClassNode:
+		n.Pub = true
+		return n
+	case EnumNode:
+		n.Pub = true
+		return n
+	case UnionNode:
+		n.Pub = true
+		return n
+	case InterfaceNode:
+		n.Pub = true
+		return n
+	case GlobalVariableDeclNode:
+		n.Pub = true
+		return n
+	case excludedDecl:
+		return n
+	default:
+		syntaxFail(start, "only functions, classes, enums, unions, interfaces and globals can be marked pub")
+		return n
+	}
+}
+
+// functionVisible reports if a function can be called from the package
+// being compiled
+func (p *Program) functionVisible(fn *FunctionNode) bool {
+	if fn.IsMethod || fn.Package == nil {
+		return true
+	}
+	return visibleFrom(visibilityOf(fn.Pub), fn.Package.Name, p.Package.Name)
+}
+
+// privateError is the diagnostic for using something private to another
+// package. It is placed at the use by the node it is returned through.
+func privateError(what, name, pkg, from string) error {
+	return newError(lexer.Token{}, ErrVisibility, "package %s can't use %s %s, it isn't marked pub in package %s", from, what, name, pkg)
+}
@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// volatileNamespace is the namespace of the volatile memory accesses. A
+// volatile access is never removed, merged with another or reordered with
+// other volatile accesses by the optimizer, which is what memory mapped io
+// and flags shared with signal handlers need.
+//
+//	volatile:load(p)       the value at p
+//	volatile:store(p, v)   stores v at p
+const volatileNamespace = "volatile"
+
+// volatileBuiltin returns the name of the volatile access a call is to, if
+// it is one. A function declared in a package named volatile takes
+// priority.
+func volatileBuiltin(prog *Program, n FunctionCallNode) (string, bool) {
+	ident, ok := n.Name.(IdentNode)
+	if !ok {
+		return "", false
+	}
+	namespace, name := ParseName(ident.String())
+	if namespace != volatileNamespace {
+		return "", false
+	}
+	_, declared := prog.Functions[ident.String()]
+	return name, !declared
+}
+
+// genVolatile generates one of the volatile builtins
+func genVolatile(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	val, err := genVolatileOp(prog, name, n)
+	if err != nil {
+		return nil, n.Errorf(ErrInvalid, "%s:%s: %s", volatileNamespace, name, err)
+	}
+	return val, nil
+}
+
+func genVolatileOp(prog *Program, name string, n FunctionCallNode) (value.Value, error) {
+	var operands int
+	switch name {
+	case "load":
+		operands = 1
+	case "store":
+		operands = 2
+	default:
+		return nil, fmt.Errorf("unknown volatile access, expected load or store")
+	}
+	if len(n.Args) != operands {
+		return nil, fmt.Errorf("takes %d arguments, given %d", operands, len(n.Args))
+	}
+
+	args := make([]value.Value, 0, operands)
+	for _, arg := range n.Args {
+		ac, isAccessable := arg.(Accessable)
+		if !isAccessable {
+			return nil, fmt.Errorf("argument %s is not accessable (has no readable value)", arg)
+		}
+		val, err := ac.GenAccess(prog)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, val)
+	}
+
+	ptr, ok := args[0].Type().(*types.PointerType)
+	if !ok {
+		return nil, fmt.Errorf("expects a pointer, given %s", args[0].Type())
+	}
+
+	block := prog.Compiler.CurrentBlock()
+	if name == "load" {
+		load := block.NewLoad(args[0])
+		load.Volatile = true
+		return load, nil
+	}
+
+	val, err := createTypeCast(prog, args[1], ptr.Elem)
+	if err != nil {
+		return nil, err
+	}
+	store := block.NewStore(val, args[0])
+	store.Volatile = true
+	return nil, nil
+}
@@ -0,0 +1,285 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// The names of the warnings the compiler gives. They are what @allow
+// takes to suppress a warning.
+const (
+	// WarnUnused is given for local variables that are never read
+	WarnUnused = "unused"
+	// WarnDeprecated is given for syntax that will be removed
+	WarnDeprecated = "deprecated"
+	// WarnShadow is given for local variables declared with the name of a
+	// variable in an enclosing block
+	WarnShadow = "shadow"
+	// WarnNarrowing is given for values converted without a cast to a type
+	// that can't hold all of them, as a long assigned to an int
+	WarnNarrowing = "narrowing"
+)
+
+var warningNames = []string{WarnDeprecated, WarnNarrowing, WarnShadow, WarnUnused}
+
+// defaultWarnings are the warnings that are given unless -W turns them
+// off. narrowing is off unless it is turned on.
+var defaultWarnings = []string{WarnDeprecated, WarnShadow, WarnUnused}
+
+// SetWarningFlags applies -W flags to the program in order. -W<name> turns
+// on a warning, -Wno-<name> turns it off, -Wall turns on all of them and
+// -Werror makes them errors.
+func (p *Program) SetWarningFlags(flags []string) error {
+	for _, flag := range flags {
+		on := !strings.HasPrefix(flag, "no-")
+		name := strings.TrimPrefix(flag, "no-")
+		switch {
+		case name == "error":
+			p.warningsAreErrors = on
+		case name == "all":
+			for _, name := range warningNames {
+				p.enabledWarnings[name] = on
+			}
+		default:
+			if i := sort.SearchStrings(warningNames, name); i == len(warningNames) || warningNames[i] != name {
+				return fmt.Errorf("unknown warning %q in -W%s, expected one of all, error, %s", name, flag, strings.Join(warningNames, ", "))
+			}
+			p.enabledWarnings[name] = on
+		}
+	}
+	return nil
+}
+
+// allowAttribute is the attribute that suppresses warnings. It takes the
+// names of the warnings to suppress, as in `@allow(unused)`. Before a
+// function it applies to the function, before a statement to the
+// statement, and before `is` to the whole file.
+const allowAttribute = "allow"
+
+// Warning is a problem in a program that doesn't stop it from compiling
+type Warning struct {
+	Name    string
+	Token   lexer.Token
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s [%s]", w.Token.FileInfo(), w.Message, w.Name)
+}
+
+// warningSuppression is the range of a file an @allow applies to
+type warningSuppression struct {
+	path       string
+	start, end int
+	names      []string
+}
+
+func (s warningSuppression) suppresses(w Warning) bool {
+	if w.Token.SourcePath() != s.path || w.Token.Pos < s.start || w.Token.Pos >= s.end {
+		return false
+	}
+	for _, name := range s.names {
+		if name == w.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// warningLog holds the warnings given in a program and the suppressions
+// found while parsing it. The parsers of a program share the log of it.
+// Function bodies are parsed as they are compiled, so neither is complete
+// until the whole program is.
+type warningLog struct {
+	warnings     []Warning
+	suppressions []warningSuppression
+}
+
+// warn gives a warning about the code at a token
+func (l *warningLog) warn(tok lexer.Token, name string, format string, args ...interface{}) {
+	// code the compiler generates itself has no source to warn about
+	if tok.SourcePath() == "" {
+		return
+	}
+	l.warnings = append(l.warnings, Warning{name, tok, fmt.Sprintf(format, args...)})
+}
+
+// allow registers the @allow attributes in a list of attributes as
+// applying to the tokens from start up to and including end. end can be
+// nil for the rest of the file.
+func (l *warningLog) allow(attrs Attributes, start lexer.Token, end *lexer.Token) {
+	for _, attr := range attrs {
+		if attr.Name != allowAttribute {
+			continue
+		}
+		if len(attr.Args) == 0 {
+			syntaxFail(start, "@allow needs the names of the warnings to allow, one of %s", strings.Join(warningNames, ", "))
+		}
+		for _, name := range attr.Args {
+			if i := sort.SearchStrings(warningNames, name); i == len(warningNames) || warningNames[i] != name {
+				syntaxFail(start, "Unknown warning %q in @allow, expected one of %s", name, strings.Join(warningNames, ", "))
+			}
+		}
+
+		s := warningSuppression{path: start.SourcePath(), start: start.Pos, end: math.MaxInt32, names: attr.Args}
+		if end != nil {
+			s.end = end.EndPos
+		}
+		l.suppressions = append(l.suppressions, s)
+	}
+}
+
+func (l *warningLog) suppressed(w Warning) bool {
+	for _, s := range l.suppressions {
+		if s.suppresses(w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Warn gives a warning about the code at a token
+func (p *Program) Warn(tok lexer.Token, name string, format string, args ...interface{}) {
+	p.warnings.warn(tok, name, format, args...)
+}
+
+// onlyAllowAttributes makes sure a list of attributes only holds @allow,
+// for declarations and statements no other attribute applies to
+func onlyAllowAttributes(attrs Attributes, tok lexer.Token, what string) {
+	for _, attr := range attrs {
+		if attr.Name != allowAttribute {
+			syntaxFail(tok, "Only @allow can be attached to %s, not %s", what, attr)
+		}
+	}
+}
+
+// declaredVariable is a local variable declared in the program, kept to
+// warn about it if it is never read
+type declaredVariable struct {
+	name  string
+	token lexer.Token
+	alloc *ir.InstAlloca
+}
+
+// declareVariable records the declaration of a local variable
+func (p *Program) declareVariable(name string, tok lexer.Token, alloc *ir.InstAlloca) {
+	p.debugDeclare(name, 0, tok, alloc)
+	// names starting with an underscore are unused on purpose, and the
+	// compiler's own variables start with one too
+	if strings.HasPrefix(name, "_") || tok.SourcePath() == "" {
+		return
+	}
+	p.declaredVariables = append(p.declaredVariables, declaredVariable{name, tok, alloc})
+}
+
+// warnShadow warns if a local variable being declared has the name of a
+// variable in a block around the one it is declared in. Globals can be
+// shadowed without a warning.
+func (p *Program) warnShadow(name string, tok lexer.Token) {
+	if strings.HasPrefix(name, "_") || tok.SourcePath() == "" || p.Scope.Parent == nil {
+		return
+	}
+	item, found := p.Scope.Parent.Find([]string{name})
+	if !found {
+		return
+	}
+	if v, is := item.(VariableScopeItem); is {
+		if _, local := v.Value().(*ir.InstAlloca); local {
+			p.Warn(tok, WarnShadow, "variable %s shadows a variable declared in an enclosing block", name)
+		}
+	}
+}
+
+// warnNarrowing warns if converting a value to a type without a cast
+// loses part of it. Constants are only narrowed if they don't fit.
+func (p *Program) warnNarrowing(tok lexer.Token, val value.Value, to types.Type) {
+	from := val.Type()
+	narrows := false
+	switch {
+	case types.IsInt(from) && types.IsInt(to):
+		if c, is := val.(*constant.Int); is {
+			narrows = !intFits(c.X, to.(*types.IntType).Size)
+		} else {
+			narrows = to.(*types.IntType).Size < from.(*types.IntType).Size
+		}
+	case types.IsFloat(from) && types.IsInt(to):
+		narrows = true
+	case types.IsFloat(from) && types.IsFloat(to):
+		_, isConstant := val.(*constant.Float)
+		narrows = !isConstant && to.(*types.FloatType).Kind.Size() < from.(*types.FloatType).Kind.Size()
+	}
+	if narrows {
+		p.Warn(tok, WarnNarrowing, "implicit conversion from %s to %s can lose data, use a cast", p.typeName(from), p.typeName(to))
+	}
+}
+
+// typeName returns the geode name of a type, or its llvm name if it has
+// none
+func (p *Program) typeName(t types.Type) string {
+	if name, err := p.Scope.FindTypeName(t); err == nil {
+		return name
+	}
+	if st, isStruct := t.(*types.StructType); isStruct && !st.Identified() && len(st.Names) == len(st.Fields) {
+		return p.anonymousStructName(st)
+	}
+	if ptr, isPointer := t.(*types.PointerType); isPointer {
+		return p.typeName(ptr.Elem) + "*"
+	}
+	return t.String()
+}
+
+// intFits reports if an integer can be held in an integer of some number
+// of bits, signed or unsigned, as a literal 255 can be a byte
+func intFits(x *big.Int, bits int) bool {
+	if x.Sign() >= 0 {
+		return x.BitLen() <= bits
+	}
+	// -2^(bits-1) is the least signed value
+	min := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	return x.CmpAbs(min) <= 0
+}
+
+// useVariable records that a variable is read
+func (p *Program) useVariable(alloc *ir.InstAlloca) {
+	p.usedVariables[alloc] = true
+}
+
+// Warnings returns every warning given while compiling the program that
+// isn't suppressed, once each, ordered by where they are in the source
+func (p *Program) Warnings() []Warning {
+	all := append([]Warning{}, p.warnings.warnings...)
+	for _, v := range p.declaredVariables {
+		if !p.usedVariables[v.alloc] {
+			all = append(all, Warning{WarnUnused, v.token, fmt.Sprintf("variable %s is never used", v.name)})
+		}
+	}
+
+	// functions compiled more than once give their warnings more than once
+	seen := make(map[string]bool)
+	result := make([]Warning, 0, len(all))
+	for _, w := range all {
+		if seen[w.String()] || !p.enabledWarnings[w.Name] || p.warnings.suppressed(w) {
+			continue
+		}
+		seen[w.String()] = true
+		result = append(result, w)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].Token, result[j].Token
+		if a.SourcePath() != b.SourcePath() {
+			return a.SourcePath() < b.SourcePath()
+		}
+		return a.Pos < b.Pos
+	})
+	return result
+}
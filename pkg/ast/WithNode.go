@@ -0,0 +1,102 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// WithNode is `with m { ... }`, which holds a lock, like a sync:Mutex, while
+// its block runs. It calls m.lock() before the block and m.unlock() after
+// it, as if the unlock were deferred to the end of the block, so it is also
+// unlocked by a return or a ? out of the block.
+//
+// The lock is held through its address, which is taken once, so a lock
+// stored in a variable is held rather than a copy of it. A pointer to a
+// lock is held through the pointer.
+type WithNode struct {
+	NodeType
+	TokenReference
+
+	Index int
+	Lock  Node
+	Body  BlockNode
+}
+
+// the methods a lock is held with
+const (
+	withLockMethod   = "lock"
+	withUnlockMethod = "unlock"
+)
+
+// NameString implements Node.NameString
+func (n WithNode) NameString() string { return "WithNode" }
+
+// MarshalJSON implements json.Marshaler for WithNode
+func (n WithNode) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+
+func (n WithNode) String() string {
+	return fmt.Sprintf("with %s %s", n.Lock, n.Body)
+}
+
+// Codegen implements Node.Codegen for WithNode
+func (n WithNode) Codegen(prog *Program) (value.Value, error) {
+	defers, found := prog.deferred[prog.Compiler.CurrentFunc()]
+	if !found {
+		return nil, n.Errorf(ErrInvalid, "with can only be used in a function")
+	}
+	lock, isRef := n.Lock.(Reference)
+	if !isRef {
+		return nil, n.Errorf(ErrInvalid, "with needs a lock stored in a variable, given %s", n.Lock)
+	}
+
+	prog.ScopeDown(n.Token)
+
+	addr := lock.Alloca(prog)
+	if addr == nil {
+		return nil, n.Lock.Errorf(ErrInvalid, "unable to find the lock %s", n.Lock)
+	}
+	ptr := addr
+	if types.IsPointer(addr.Type().(*types.PointerType).Elem) {
+		ptr = prog.Compiler.CurrentBlock().NewLoad(addr)
+	}
+	name := fmt.Sprintf("__with.%d", n.Index)
+	held := createBlockAlloca(prog.Compiler.CurrentFunc(), ptr.Type(), name)
+	prog.Compiler.CurrentBlock().NewStore(ptr, held)
+	prog.Scope.Add(NewVariableScopeItem(name, held, PrivateVisibility))
+
+	if _, err := n.method(name, withLockMethod).Codegen(prog); err != nil {
+		return nil, err
+	}
+	unlock := n.method(name, withUnlockMethod)
+	defers.unlocks = append(defers.unlocks, unlock)
+
+	if _, err := n.Body.Codegen(prog); err != nil {
+		return nil, err
+	}
+
+	defers.unlocks = defers.unlocks[:len(defers.unlocks)-1]
+	if prog.Compiler.CurrentBlock().Term == nil {
+		if _, err := unlock.Codegen(prog); err != nil {
+			return nil, err
+		}
+	}
+	return nil, prog.ScopeUp()
+}
+
+// method returns a call to a method of the lock stored in the hidden
+// variable name
+func (n WithNode) method(name, method string) FunctionCallNode {
+	dot := DotReference{}
+	dot.Token = n.Token
+	dot.NodeType = nodeDot
+	dot.Base = NewIdentNode(name)
+	dot.Field = NewIdentNode(method)
+
+	call := FunctionCallNode{}
+	call.Token = n.Token
+	call.NodeType = nodeFunctionCall
+	call.Name = dot
+	return call
+}
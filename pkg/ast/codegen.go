@@ -6,10 +6,8 @@ import (
 
 	"github.com/geode-lang/geode/llvm/ir"
 	"github.com/geode-lang/geode/llvm/ir/constant"
-	"github.com/geode-lang/geode/llvm/ir/metadata"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/llvm/ir/value"
-	"github.com/geode-lang/geode/pkg/arg"
 )
 
 // A global number to indicate which `name index` we are on. This way,
@@ -46,15 +44,24 @@ func (n IfNode) Codegen(prog *Program) (value.Value, error) {
 	if err != nil {
 		return nil, err
 	}
+	checked := predicate
+	if n.Let != "" && !types.IsPointer(checked.Type()) {
+		return nil, n.Errorf(ErrType, "if let checks a pointer, given %s", checked.Type())
+	}
 	zero := constant.NewInt(0, types.I32)
 	// The name of the blocks is prefixed because
 	namePrefix := fmt.Sprintf("if.%d.", n.Index)
 	parentBlock := prog.Compiler.CurrentBlock()
-	c, err := createTypeCast(prog, predicate, types.I32)
-	if err != nil {
-		return nil, err
+	if ptr, isPointer := predicate.Type().(*types.PointerType); isPointer {
+		// a pointer is true when it isn't nil
+		predicate = parentBlock.NewICmp(ir.IntNE, predicate, constant.NewNull(ptr))
+	} else {
+		c, err := createTypeCast(prog, predicate, types.I32)
+		if err != nil {
+			return nil, err
+		}
+		predicate = parentBlock.NewICmp(ir.IntNE, zero, c)
 	}
-	predicate = parentBlock.NewICmp(ir.IntNE, zero, c)
 	parentFunc := parentBlock.Parent
 
 	var thenGenBlk *ir.BasicBlock
@@ -62,19 +69,29 @@ func (n IfNode) Codegen(prog *Program) (value.Value, error) {
 
 	thenBlk := parentFunc.NewBlock(mangleName(namePrefix + "then"))
 
-	prog.Compiler.genInBlock(thenBlk, func() error {
+	err = prog.Compiler.genInBlock(thenBlk, func() error {
+		if n.Let != "" {
+			prog.ScopeDown(n.Token)
+			n.genLetBinding(prog, checked)
+		}
 		gen, gerr := n.Then.Codegen(prog)
 		if gerr != nil {
 			return gerr
 		}
 		thenGenBlk = gen.(*ir.BasicBlock)
+		if n.Let != "" {
+			return prog.ScopeUp()
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	elseBlk := parentFunc.NewBlock(mangleName(namePrefix + "else"))
 	var elseGenBlk *ir.BasicBlock
 
-	prog.Compiler.genInBlock(elseBlk, func() error {
+	err = prog.Compiler.genInBlock(elseBlk, func() error {
 		// We only want to construct the else block if there is one.
 		if n.Else != nil {
 			gen, gerr := n.Else.Codegen(prog)
@@ -85,6 +102,9 @@ func (n IfNode) Codegen(prog *Program) (value.Value, error) {
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	endBlk = parentFunc.NewBlock(mangleName(namePrefix + "end"))
 	prog.Compiler.PushBlock(endBlk)
@@ -106,6 +126,9 @@ func (n IfNode) Codegen(prog *Program) (value.Value, error) {
 
 // Codegen implements Node.Codegen for CharNode
 func (n CharNode) Codegen(prog *Program) (value.Value, error) {
+	if n.Rune {
+		return constant.NewInt(int64(n.Value), types.I32), nil
+	}
 	return constant.NewInt(int64(n.Value), types.I8), nil
 }
 
@@ -117,13 +140,21 @@ func (n CharNode) GenAccess(prog *Program) (value.Value, error) {
 // Codegen implements Node.Codegen for UnaryNode
 func (n UnaryNode) Codegen(prog *Program) (value.Value, error) {
 
+	if n.Operator == "<-" {
+		return genChanMethod(prog, n.TokenReference, n.Operand, chanRecvMethod)
+	}
+
 	// handle reference operation
 	if n.Operator == "&" {
 
 		node, ok := n.Operand.(Reference)
 		if !ok {
-			n.SyntaxError()
-			return nil, fmt.Errorf("'&' operator called on non-addressable operand")
+			return nil, n.Errorf(ErrInvalid, "'&' operator called on non-addressable operand")
+		}
+
+		// The address of a function that isn't shadowed by a variable
+		if ident, isIdent := n.Operand.(IdentNode); isIdent && ident.Alloca(prog) == nil {
+			return genFunctionReference(prog, ident)
 		}
 
 		return node.Alloca(prog), nil
@@ -134,12 +165,15 @@ func (n UnaryNode) Codegen(prog *Program) (value.Value, error) {
 		return nil, err
 	}
 	if operandValue == nil {
-		n.Operand.SyntaxError()
-		return nil, fmt.Errorf("nil operand")
+		return nil, n.Operand.Errorf(ErrInvalid, "nil operand")
 	}
 
 	if n.Operator == "-" {
 
+		if folded, ok := foldConstantValues("-", constant.NewInt(0, types.I64), operandValue, operandValue.Type()); ok {
+			return folded, nil
+		}
+
 		if types.IsFloat(operandValue.Type()) {
 			return prog.Compiler.CurrentBlock().NewFSub(constant.NewFloat(0, types.Double), operandValue), nil
 		} else if types.IsInt(operandValue.Type()) {
@@ -157,6 +191,13 @@ func (n UnaryNode) Codegen(prog *Program) (value.Value, error) {
 			return nil, fmt.Errorf("unable to '!' (not) type %q", operandValue.Type())
 		}
 
+		if val, ok := constantNumber(operandValue); ok {
+			if val.(int64) == 0 {
+				return constant.NewInt(1, types.I32), nil
+			}
+			return constant.NewInt(0, types.I32), nil
+		}
+
 		opVal, _ := createTypeCast(prog, operandValue, types.I1)
 
 		eq := prog.Compiler.CurrentBlock().NewICmp(ir.IntNE, opVal, constant.False)
@@ -167,15 +208,33 @@ func (n UnaryNode) Codegen(prog *Program) (value.Value, error) {
 
 	}
 
+	// the bitwise not flips every bit, which is xor with all of them set
+	if n.Operator == "~" {
+		t := operandValue.Type()
+		if !types.IsInt(scalarType(t)) {
+			return nil, n.Errorf(ErrType, "operator ~ only works on integers, not %s", t)
+		}
+		if val, ok := constantNumber(operandValue); ok {
+			return wrapConstant(constant.NewInt(^val.(int64), t.(*types.IntType))), nil
+		}
+		ones, err := createTypeCast(prog, constant.NewInt(-1, types.I64), t)
+		if err != nil {
+			return nil, err
+		}
+		return prog.Compiler.CurrentBlock().NewXor(operandValue, ones), nil
+	}
+
 	// handle dereference operation
 	if n.Operator == "*" {
 
 		// fmt.Println(prog.Compiler.CurrentFunc())
 		if types.IsPointer(operandValue.Type()) {
+			if err := prog.checkDereference(operandValue.Type(), n.Operand); err != nil {
+				return nil, n.Diagnose(err)
+			}
 			return prog.Compiler.CurrentBlock().NewLoad(operandValue), nil
 		}
-		n.SyntaxError()
-		return nil, fmt.Errorf("attempt to dereference a non-pointer variable")
+		return nil, n.Errorf(ErrType, "attempt to dereference a non-pointer variable")
 	}
 
 	return operandValue, nil
@@ -195,19 +254,28 @@ func (n WhileNode) Codegen(prog *Program) (value.Value, error) {
 
 	parentFunc := parentBlock.Parent
 	startblock := parentFunc.NewBlock(mangleName(namePrefix + "start"))
-	prog.Compiler.PushBlock(startblock)
-	predicate, err := n.If.Codegen(prog)
+
+	// the condition may branch, so the loop branches from the block it
+	// ends in
+	var predicate value.Value
+	var condEnd *ir.BasicBlock
+	err := prog.Compiler.genInBlock(startblock, func() error {
+		cond, err := n.If.Codegen(prog)
+		if err != nil {
+			return err
+		}
+		c, err := createTypeCast(prog, cond, types.I1)
+		if err != nil {
+			return err
+		}
+		condEnd = prog.Compiler.CurrentBlock()
+		predicate = condEnd.NewICmp(ir.IntEQ, constant.NewInt(1, types.I1), c)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	one := constant.NewInt(1, types.I1)
-	prog.Compiler.PopBlock()
 	parentBlock.BranchIfNoTerminator(startblock)
-	c, err := createTypeCast(prog, predicate, types.I1)
-	if err != nil {
-		return nil, err
-	}
-	predicate = startblock.NewICmp(ir.IntEQ, one, c)
 
 	var endBlk *ir.BasicBlock
 
@@ -229,7 +297,7 @@ func (n WhileNode) Codegen(prog *Program) (value.Value, error) {
 	bodyBlk.BranchIfNoTerminator(startblock)
 	bodyGenBlk.BranchIfNoTerminator(startblock)
 
-	startblock.NewCondBr(predicate, bodyBlk, endBlk)
+	condEnd.NewCondBr(predicate, bodyBlk, endBlk)
 
 	// branchIfNoTerminator(c.CurrentBlock(), endBlk)
 
@@ -247,6 +315,16 @@ func typeSize(t types.Type) int {
 	return -1
 }
 
+// roundFloat rounds a float constant to the precision of its type, as llvm
+// rejects constants an f32 can't hold exactly
+func roundFloat(c *constant.Float) *constant.Float {
+	if c.Typ.Kind == types.FloatKindIEEE_32 {
+		f, _ := c.X.Float32()
+		c.X.SetFloat64(float64(f))
+	}
+	return c
+}
+
 func typesAreLooselyEqual(a, b types.Type) bool {
 	return types.IsNumber(a) && types.IsNumber(b)
 }
@@ -264,6 +342,10 @@ func createTypeCast(prog *Program, in value.Value, to types.Type) (value.Value,
 	inSize := typeSize(inType)
 	outSize := typeSize(to)
 
+	if err := prog.checkOptionalCast(in, to); err != nil {
+		return nil, err
+	}
+
 	// If the cast would not change the type, just return the in value, nil
 	if types.Equal(inType, to) {
 		return in, nil
@@ -276,27 +358,45 @@ func createTypeCast(prog *Program, in value.Value, to types.Type) (value.Value,
 
 	if c, ok := in.(*constant.Float); ok && types.IsFloat(to) {
 		c.Typ = to.(*types.FloatType)
-		return c, nil
+		return roundFloat(c), nil
 	}
 
 	if types.Equal(to, types.Void) {
 		return nil, nil
 	}
 
+	if iface := prog.interfaceOf(to); iface != nil {
+		return prog.genInterfaceValue(in, iface)
+	}
+
+	if vec, ok := to.(*types.VectorType); ok {
+		return createVectorCast(prog, in, vec)
+	}
+
 	if types.IsPointer(inType) && types.IsPointer(to) {
 		return prog.Compiler.CurrentBlock().NewBitCast(in, to), nil
 	}
 
 	if fromFloat && toInt {
+		if types.IsUnsigned(to) {
+			return prog.Compiler.CurrentBlock().NewFPToUI(in, to), nil
+		}
 		return prog.Compiler.CurrentBlock().NewFPToSI(in, to), nil
 	}
 
 	if fromInt && toFloat {
+		if types.IsUnsigned(inType) {
+			return prog.Compiler.CurrentBlock().NewUIToFP(in, to), nil
+		}
 		return prog.Compiler.CurrentBlock().NewSIToFP(in, to), nil
 	}
 
 	if fromInt && toInt {
 		if inSize < outSize {
+			// unsigned values are widened with zeros, not their sign bit
+			if types.IsUnsigned(inType) {
+				return prog.Compiler.CurrentBlock().NewZExt(in, to), nil
+			}
 			return prog.Compiler.CurrentBlock().NewSExt(in, to), nil
 		}
 		if inSize == outSize {
@@ -328,7 +428,11 @@ func createTypeCast(prog *Program, in value.Value, to types.Type) (value.Value,
 		return prog.Compiler.CurrentBlock().NewIntToPtr(in, to), nil
 	}
 
-	return nil, fmt.Errorf("Failed to typecast type %s to %s", inType.String(), to)
+	if convertsToC(inType, to) {
+		return nil, fmt.Errorf("a string converts to a byte* and back only with as, as in `s as byte*` or `p as string`")
+	}
+
+	return nil, fmt.Errorf("Failed to typecast type %s to %s", prog.typeName(inType), prog.typeName(to))
 }
 
 // Codegen implements Node.Codegen for ReturnNode
@@ -346,27 +450,30 @@ func (n ReturnNode) Codegen(prog *Program) (value.Value, error) {
 			}
 			given := retVal.Type()
 			expected := prog.Compiler.CurrentFunc().Sig.Ret
+			// exported functions return strings to c as a char*
+			if prog.isCFunction(prog.Compiler.CurrentFunc()) && isString(given) && isBytePtr(expected) {
+				retVal = prog.stringData(retVal)
+				given = expected
+			}
+			// an optional pointer is the same type as the plain one
+			if err := prog.checkOptionalCast(retVal, expected); err != nil {
+				return nil, n.Diagnose(err)
+			}
 			if !types.Equal(given, expected) {
-				if !(types.IsInt(given) && types.IsInt(expected)) {
-					n.SyntaxError()
+				// the elements of f32 vectors are the only floats
+				// that aren't already a float
+				if !(types.IsInt(given) && types.IsInt(expected)) && !(types.IsFloat(given) && types.IsFloat(expected)) && !prog.convertsToInterface(given, expected) && !prog.convertsToOptional(given, expected) {
 					fnName, err := UnmangleFunctionName(prog.Compiler.CurrentFunc().Name)
 					if err != nil {
 
 						return nil, err
 					}
-					expectedName, err := prog.Scope.FindTypeName(expected)
-					if err != nil {
-
-						return nil, err
-					}
-					givenName, err := prog.Scope.FindTypeName(given)
-					if err != nil {
+					expectedName := prog.typeName(expected)
+					givenName := prog.typeName(given)
 
-						return nil, err
-					}
-
-					return nil, fmt.Errorf("incorrect return value for function %s. expected: %s (%s). given: %s (%s)", fnName, expectedName, expected, givenName, given)
+					return nil, n.Errorf(ErrType, "incorrect return value for function %s. expected: %s (%s). given: %s (%s)", fnName, expectedName, expected, givenName, given)
 				}
+				prog.warnNarrowing(n.Token, retVal, expected)
 				retVal, err = createTypeCast(prog, retVal, prog.Compiler.CurrentFunc().Sig.Ret)
 				if err != nil {
 
@@ -379,17 +486,20 @@ func (n ReturnNode) Codegen(prog *Program) (value.Value, error) {
 		}
 	}
 
-	ret := prog.Compiler.CurrentBlock().NewRet(retVal)
-
-	if *arg.EnableDebug {
-		md := &metadata.Metadata{}
-		md.Add(metadata.NewRaw(n.Token.DILocation(prog.Scope.DebugInfo)))
-		ret.Metadata["dbg"] = md
+	if err := prog.genDeferred(); err != nil {
+		return nil, err
+	}
+	if err := prog.arcReturn(retVal); err != nil {
+		return nil, err
 	}
 
+	prog.Compiler.CurrentBlock().NewRet(retVal)
+
 	return retVal, nil
 }
 
+// newCharArray returns the bytes of a string and its terminating zero. The
+// string is utf-8, as escapes like \u{e9} are encoded when it is unescaped.
 func newCharArray(s string) *constant.Array {
 	var bs []constant.Constant
 	for i := 0; i < len(s); i++ {
@@ -12,15 +12,21 @@ func (p *Parser) parseBinaryOpRHS(exprPrec int, lhs Node) Node {
 		if !isBinaryOp || p.token.Is(lexer.TokSemiColon) {
 			return lhs
 		}
+		// a <- that starts a line is a receive that starts a statement, not
+		// a send of what came before
+		if isChanRecv(p.token) && p.token.Line > p.Peek(-1).Line {
+			return lhs
+		}
 
 		if tokenPrec < exprPrec {
 			return lhs
 		}
-		binOp := p.token.Value
+		opToken := p.token
+		binOp := opToken.Value
 		p.Next()
 
 		// right hand sides will never have a declaration, so pass false
-		rhs := p.parseUnary(false)
+		rhs := p.parseCasts(p.parseUnary(false))
 		if rhs == nil {
 			return nil
 		}
@@ -32,8 +38,12 @@ func (p *Parser) parseBinaryOpRHS(exprPrec int, lhs Node) Node {
 				return nil
 			}
 		}
+		if binOp == ".." {
+			lhs = newRangeNode(opToken, lhs, rhs)
+			continue
+		}
 		n := BinaryNode{}
-		n.TokenReference.Token = p.token
+		n.TokenReference.Token = opToken
 		n.NodeType = nodeBinary
 		n.OP = binOp
 		n.Left = lhs
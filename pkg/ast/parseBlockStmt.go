@@ -2,7 +2,6 @@ package ast
 
 import (
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 var blkidx = 0
@@ -17,45 +16,83 @@ func (p *Parser) parseBlockStmt() BlockNode {
 	for {
 		p.globTerminator()
 
-		if p.token.Is(lexer.TokReturn) {
-			blk.Nodes = append(blk.Nodes, p.parseReturnStmt())
-			continue
+		// If the block is over.
+		if p.token.Is(lexer.TokRightCurly) {
+			break
+		}
+		if p.token.Type <= 0 {
+			syntaxFail(blk.Token, "This block is never closed")
 		}
 
-		if p.token.Is(lexer.TokIdent, lexer.TokType) {
-			node := p.parseExpression(true)
+		node, failed := p.parseRecovering(p.parseStatement, p.skipStatement)
+		if !failed {
 			blk.Nodes = append(blk.Nodes, node)
-			continue
 		}
+	}
+	p.Next()
 
-		if p.token.Is(lexer.TokIf) {
-			blk.Nodes = append(blk.Nodes, p.parseIfStmt())
-			continue
-		}
+	blkidx++
 
-		if p.token.Is(lexer.TokWhile) {
-			blk.Nodes = append(blk.Nodes, p.parseWhileStmt())
-			continue
-		}
+	return blk
+}
 
-		if p.token.Is(lexer.TokFor) {
-			blk.Nodes = append(blk.Nodes, p.parseForStmt())
-			continue
+// parseStatement parses a single statement in a block. A statement can
+// have @allow attached to suppress warnings in it, and a variable
+// declaration can have @align.
+func (p *Parser) parseStatement() Node {
+	switch {
+	case p.token.Is(lexer.TokAttribute):
+		start := p.token
+		align, attrs := parseAlignAttribute(p.parseAttributes(), start)
+		onlyAllowAttributes(attrs, start, "statements")
+		node := p.parseStatement()
+		end := p.Peek(-1)
+		p.warnings.allow(attrs, start, &end)
+		if align != 0 {
+			node = alignVariable(node, align, start)
 		}
+		return node
 
-		// If the block is over.
-		if p.token.Is(lexer.TokRightCurly) {
-			break
-		}
+	case p.token.Is(lexer.TokReturn):
+		return p.parseReturnStmt()
 
-		p.token.SyntaxError()
-		log.Fatal("Unknown token in block statement\n")
-	}
-	p.Next()
+	case p.token.Is(lexer.TokDefer):
+		return p.parseDeferStmt()
 
-	blkidx++
+	case p.token.Is(lexer.TokWith):
+		return p.parseWithStmt()
 
-	return blk
+	case p.token.Is(lexer.TokTry):
+		return p.parseTryStmt()
+
+	case p.token.Is(lexer.TokThrow):
+		return p.parseThrowStmt()
+
+	case p.token.Is(lexer.TokIdent, lexer.TokType):
+		return p.parseExpression(true)
+
+	// a declaration of a variable of an anonymous struct type
+	case p.token.Is(lexer.TokLeftCurly) && p.atType():
+		return p.parseExpression(true)
+
+	case isIncDec(p.token), p.token.Is(lexer.TokSpawn), isChanRecv(p.token):
+		return p.parseExpression(false)
+
+	case p.token.Is(lexer.TokIf):
+		return p.parseIfStmt()
+
+	case p.token.Is(lexer.TokMatch):
+		return p.parseMatchStmt()
+
+	case p.token.Is(lexer.TokWhile):
+		return p.parseWhileStmt()
+
+	case p.token.Is(lexer.TokFor):
+		return p.parseForStmt()
+	}
+
+	syntaxFail(p.token, "Unknown token in block statement")
+	return nil
 }
 
 // forkBlockParser returns a new, forked parser that only has a subset of tokens that
@@ -73,6 +110,9 @@ func (p *Parser) forkBlockParser() *Parser {
 	for nesting != 0 {
 		offset++
 		tok := p.Next()
+		if p.tokenIndex >= len(p.tokens) {
+			syntaxFail(p.tokens[index], "Unclosed block, the '{' has no matching '}'")
+		}
 		if tok.Is(lexer.TokLeftCurly) {
 			nesting++
 		} else if tok.Is(lexer.TokRightCurly) {
@@ -2,6 +2,16 @@ package ast
 
 import "github.com/geode-lang/geode/pkg/lexer"
 
+// parseCasts wraps an operand of a binary expression in the casts after
+// it, if any. Casts bind tighter than binary operators and looser than
+// unary ones, so `-a as long + b` is `((-a) as long) + b`
+func (p *Parser) parseCasts(n Node) Node {
+	for n != nil && p.token.Is(lexer.TokAs) {
+		n = p.parseCastExpr(n)
+	}
+	return n
+}
+
 func (p *Parser) parseCastExpr(source Node) Node {
 	p.requires(lexer.TokAs)
 	n := CastNode{}
@@ -9,6 +19,10 @@ func (p *Parser) parseCastExpr(source Node) Node {
 	n.NodeType = nodeCast
 	n.Source = source
 	p.Next()
+	if p.token.Is(lexer.TokOper) && p.token.Value == "!" {
+		n.Checked = true
+		p.Next()
+	}
 	n.Type = p.parseType()
 
 	return n
@@ -4,7 +4,6 @@ import (
 	"strings"
 
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 func (p *Parser) parseClassDefn() Node {
@@ -16,14 +15,32 @@ func (p *Parser) parseClassDefn() Node {
 	p.Next()
 
 	if !p.token.Is(lexer.TokType) {
-		p.token.SyntaxError()
-		log.Fatal("Class names must be capitalized. Use %q instead\n", strings.Title(p.token.Value))
+		syntaxFail(p.token, "Class names must be capitalized. Use %q instead", strings.Title(p.token.Value))
 	}
 	n.Name = p.token.Value
 
 	p.Context().ClassNames[n.Name] = p.token
 
 	p.Next()
+	if p.token.Is(lexer.TokOper) && p.token.Value == "<" {
+		n.TypeParams = p.parseTypeParams()
+	}
+
+	// the interfaces the class implements, like `class Square is Shape`
+	if p.token.Is(lexer.TokNamespace) {
+		p.Next()
+		for {
+			if !p.token.Is(lexer.TokType) {
+				syntaxFail(p.token, "Expected the name of an interface class %s implements", n.Name)
+			}
+			n.Implements = append(n.Implements, p.token.Value)
+			p.Next()
+			if !p.token.Is(lexer.TokComma) {
+				break
+			}
+			p.Next()
+		}
+	}
 	nodes := p.parseClassBody()
 	n.Variables = make([]VariableDefnNode, 0)
 	n.Methods = make([]FunctionNode, 0)
@@ -34,7 +51,13 @@ func (p *Parser) parseClassDefn() Node {
 			n.Variables = append(n.Variables, node.(VariableDefnNode))
 		case nodeFunction:
 			fn := node.(FunctionNode)
-			n.Methods = append(n.Methods, fn)
+			if fn.IsMethod {
+				n.Methods = append(n.Methods, fn)
+			} else {
+				n.StaticMethods = append(n.StaticMethods, fn)
+			}
+		case nodeGlobalDecl:
+			n.StaticFields = append(n.StaticFields, node.(GlobalVariableDeclNode))
 		}
 	}
 
@@ -52,6 +75,18 @@ func (p *Parser) parseClassBody() []Node {
 	p.Next()
 
 	for {
+		// static members belong to the class, not to its instances. Static
+		// fields are globals, so they can be given a value
+		if p.token.Is(lexer.TokStatic) {
+			p.Next()
+			if p.token.Is(lexer.TokFuncDefn) {
+				nodes = append(nodes, p.parseFunctionNode())
+			} else {
+				nodes = append(nodes, p.parseGlobalVariableDecl())
+			}
+			continue
+		}
+
 		if p.token.Is(lexer.TokFuncDefn) {
 			fn := p.parseFunctionNode()
 			fn.IsMethod = true
@@ -59,6 +94,23 @@ func (p *Parser) parseClassBody() []Node {
 			continue
 		}
 
+		// fields can be marked @weak, which only matters with --arc
+		if p.token.Is(lexer.TokAttribute) {
+			tok := p.token
+			weak, attrs := parseWeakAttribute(p.parseAttributes(), tok)
+			if len(attrs) > 0 {
+				syntaxFail(tok, "Only @weak can be attached to fields, not %s", attrs[0])
+			}
+			if !p.atType() {
+				syntaxFail(tok, "@weak can only be attached to fields")
+			}
+			field := p.parseVariableDefn(false)
+			field.Weak = weak
+			nodes = append(nodes, field)
+			p.globTerminator()
+			continue
+		}
+
 		if p.atType() {
 			// No initializer is allowed in class variable defns
 			nodes = append(nodes, p.parseVariableDefn(false))
@@ -0,0 +1,13 @@
+package ast
+
+func (p *Parser) parseDeferStmt() DeferNode {
+	n := DeferNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeDefer
+	p.Next()
+
+	n.Expr = p.parseExpression(false)
+
+	p.globTerminator()
+	return n
+}
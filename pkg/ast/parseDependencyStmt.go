@@ -11,8 +11,11 @@ func (p *Parser) parseDependencyStmt() Node {
 	d.TokenReference.Token = p.token
 	d.NodeType = nodeDependency
 	p.requires(lexer.TokDependency)
-	if p.token.Value == "link" {
+	switch p.token.Value {
+	case "link":
 		d.CLinkage = true
+	case "include_c":
+		d.CHeader = true
 	}
 	p.Next()
 
@@ -25,5 +25,6 @@ func (p *Parser) parseDotExpr(base Reference) Reference {
 
 // QuickParseExpression takes a stream of tokens and lexes them into a single node
 func QuickParseExpression(src string) Node {
+	defer exitOnSyntaxError()
 	return NewQuickParser(src).parseExpression(true)
 }
@@ -0,0 +1,58 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// parseEnumDefn parses an enum declaration. Members are separated by
+// commas or newlines, and can be given a value with `=`.
+//
+//	enum Color {
+//		Red
+//		Green = 5
+//		Blue
+//	}
+func (p *Parser) parseEnumDefn() Node {
+	p.requires(lexer.TokEnumDefn)
+	n := EnumNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeEnum
+
+	p.Next()
+
+	if !p.token.Is(lexer.TokType) || strings.Contains(p.token.Value, ":") {
+		syntaxFail(p.token, "Enum names must be capitalized. Use %q instead", strings.Title(p.token.Value))
+	}
+	n.Name = p.token.Value
+	p.Next()
+
+	if !p.token.Is(lexer.TokLeftCurly) {
+		syntaxFail(p.token, "Expected the members of enum %s", n.Name)
+	}
+	p.Next()
+
+	for !p.token.Is(lexer.TokRightCurly) {
+		if !p.token.Is(lexer.TokType, lexer.TokIdent) || strings.Contains(p.token.Value, ":") {
+			syntaxFail(p.token, "Invalid member in enum %s", n.Name)
+		}
+		member := EnumMember{}
+		member.Token = p.token
+		member.Name = p.token.Value
+		p.Next()
+
+		if p.token.Is(lexer.TokOper) && p.token.Value == "=" {
+			p.Next()
+			member.Value = p.parseExpression(false)
+		}
+		n.Members = append(n.Members, member)
+
+		if p.token.Is(lexer.TokComma) {
+			p.Next()
+		}
+	}
+	p.Next()
+
+	return n
+}
@@ -5,13 +5,10 @@ import (
 )
 
 func (p *Parser) parseExpression(allowdecl bool) Node {
-	lhs := p.parseUnary(allowdecl)
+	lhs := p.parseCasts(p.parseUnary(allowdecl))
 	if lhs == nil {
 		return nil
 	}
-	if p.token.Is(lexer.TokAs) {
-		return p.parseCastExpr(lhs)
-	}
 	defer p.globTerminator()
 	return p.parseBinaryOpRHS(-100, lhs)
 }
@@ -25,7 +22,7 @@ func (p *Parser) parseCompoundExpression(allowdecl bool) (ExpComponent, error) {
 
 	switch p.token.Type {
 
-	case lexer.TokIdent, lexer.TokType:
+	case lexer.TokIdent, lexer.TokType, lexer.TokLeftCurly:
 		err = p.parseIdentifierComponent(chain, allowdecl)
 	case lexer.TokNumber:
 		err = p.parseNumberComponent(chain)
@@ -37,10 +34,16 @@ func (p *Parser) parseCompoundExpression(allowdecl bool) (ExpComponent, error) {
 		err = p.parseParenthesisComponent(chain)
 	case lexer.TokBool:
 		err = p.parseBooleanComponent(chain)
+	case lexer.TokNil:
+		err = p.parseNilComponent(chain)
 	case lexer.TokChar:
 		err = p.parseCharComponent(chain)
 	case lexer.TokInfo:
 		err = p.parseTypeInfoComponent(chain)
+	case lexer.TokSizeof:
+		err = p.parseSizeofComponent(chain)
+	case lexer.TokNew:
+		err = p.parseNewComponent(chain)
 	default:
 		return nil, p.Errorf("Failed to parse expression: %s", p.token.FileInfo())
 	}
@@ -108,7 +111,7 @@ func (p *Parser) parseIdentDeclComponent(base *BaseComponent) error {
 	n := &IdentDeclComponent{}
 	n.token = p.token
 
-	if !p.token.Is(lexer.TokType) {
+	if !p.token.Is(lexer.TokType, lexer.TokLeftCurly) {
 		return p.Errorf("parser not at type")
 	}
 
@@ -147,6 +150,15 @@ func (p *Parser) parseCallComponent(base *BaseComponent) error {
 			if arg == nil {
 				return p.Errorf("invalid call syntax")
 			}
+
+			if p.token.Is(lexer.TokElipsis) {
+				spread := SpreadNode{}
+				spread.NodeType = nodeSpread
+				spread.Token = p.token
+				spread.Value = arg
+				arg = spread
+				p.Next()
+			}
 			n.Args = append(n.Args, arg)
 		}
 	}
@@ -289,8 +301,7 @@ func (p *Parser) parseParenthesisComponent(base *BaseComponent) error {
 	n.Value = p.parseExpression(false)
 
 	if !p.token.Is(lexer.TokRightParen) {
-		p.token.SyntaxError()
-		return p.Errorf("invalid parenthesis syntax")
+		syntaxFail(p.token, "Unclosed parenthesis, expected ')' but found %q", p.token.Value)
 	}
 
 	p.Next()
@@ -319,6 +330,23 @@ func (p *Parser) parseBooleanComponent(base *BaseComponent) error {
 	return nil
 }
 
+// =========================== parseNilComponent ===========================
+
+func (p *Parser) parseNilComponent(base *BaseComponent) error {
+	n := &NilComponent{}
+	n.token = p.token
+
+	if !p.token.Is(lexer.TokNil) {
+		return p.Errorf("parseNilComponent expects nil")
+	}
+
+	p.Next()
+
+	base.Add(n)
+
+	return nil
+}
+
 // =========================== parseCharComponent ===========================
 
 func (p *Parser) parseCharComponent(base *BaseComponent) error {
@@ -368,3 +396,59 @@ func (p *Parser) parseTypeInfoComponent(base *BaseComponent) error {
 
 	return nil
 }
+
+// =========================== parseSizeofComponent ===========================
+
+func (p *Parser) parseSizeofComponent(base *BaseComponent) error {
+	n := &SizeofComponent{}
+	n.token = p.token
+	n.Align = p.token.Value == "alignof"
+
+	p.Next()
+
+	if !p.token.Is(lexer.TokLeftParen) {
+		return p.Errorf("invalid call to %s", n.token.Value)
+	}
+	p.Next()
+
+	n.Type = p.parseType()
+
+	if !p.token.Is(lexer.TokRightParen) {
+		return p.Errorf("invalid call to %s", n.token.Value)
+	}
+
+	p.Next()
+	base.Add(n)
+	return nil
+}
+
+// =========================== parseNewComponent ===========================
+
+func (p *Parser) parseNewComponent(base *BaseComponent) error {
+	n := &NewComponent{}
+	n.token = p.token
+
+	p.Next()
+
+	if !p.token.Is(lexer.TokLeftParen) {
+		return p.Errorf("invalid call to new")
+	}
+	p.Next()
+
+	n.Type = p.parseType()
+
+	if !p.token.Is(lexer.TokRightParen) {
+		return p.Errorf("invalid call to new")
+	}
+
+	p.Next()
+	base.Add(n)
+
+	fork := p.Fork()
+	err := fork.parseOperatorComponent(base)
+	if err == nil {
+		p.Join(fork)
+	}
+
+	return nil
+}
@@ -15,6 +15,10 @@ func (p *Parser) parseForStmt() Node {
 	forStmtIndex++
 	p.Next()
 
+	if p.atForEach() {
+		return p.parseForEachStmt(n)
+	}
+
 	n.Init = p.parseExpression(true)
 	n.Cond = p.parseExpression(false)
 	n.Step = p.parseExpression(false)
@@ -22,3 +26,53 @@ func (p *Parser) parseForStmt() Node {
 
 	return n
 }
+
+// atForEach returns if the parser is at the head of a for-each loop,
+// ex: `int x in`, `let x in` or `x in`
+func (p *Parser) atForEach() bool {
+	if p.token.Is(lexer.TokIdent) && p.Peek(1).Is(lexer.TokIn) {
+		return true
+	}
+	offset := 1
+	if p.token.Is(lexer.TokType) {
+		if !p.atType() {
+			return false
+		}
+		for !p.Peek(offset).Is(lexer.TokIdent) {
+			offset++
+		}
+	} else if !p.token.Is(lexer.TokLet) {
+		return false
+	}
+	return p.Peek(offset).Is(lexer.TokIdent) && p.Peek(offset+1).Is(lexer.TokIn)
+}
+
+// parseForEachStmt parses the rest of a for-each loop, after the `for`
+func (p *Parser) parseForEachStmt(loop ForNode) Node {
+	n := ForEachNode{}
+	n.TokenReference = loop.TokenReference
+	n.NodeType = nodeForEach
+	n.Index = loop.Index
+
+	n.Elem.Token = p.token
+	n.Elem.NodeType = nodeVariableDecl
+	if p.token.Is(lexer.TokLet) {
+		n.Elem.NeedsInference = true
+		p.Next()
+	} else if p.token.Is(lexer.TokIdent) {
+		// `for x in` is the same as `for let x in`
+		n.Elem.NeedsInference = true
+	} else {
+		n.Elem.Typ = p.parseType()
+	}
+	n.Elem.Name = NewIdentNode(p.token.Value)
+	p.Next()
+
+	p.requires(lexer.TokIn)
+	p.Next()
+
+	n.Source = p.parseExpression(false)
+	n.Body = p.parseBlockStmt()
+
+	return n
+}
@@ -2,10 +2,48 @@ package ast
 
 import (
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 func (p *Parser) parseFunctionNode() FunctionNode {
+	fn := p.parseFunctionHeader()
+
+	if p.token.Is(lexer.TokLeftCurly) {
+		fn.BodyParser = p.forkBlockParser()
+	} else if p.token.Is(lexer.TokRightArrow, lexer.TokOper) {
+
+		if p.token.Is(lexer.TokOper) && p.token.Value != "=" {
+			syntaxFail(p.token, "unexpected token %q in function declaration", p.token.Value)
+		}
+
+		if p.token.Is(lexer.TokRightArrow) {
+			p.warnings.warn(p.token, WarnDeprecated, "use of an arrow function will be removed, replace '->' with '='")
+		}
+		fn.Body = BlockNode{}
+		fn.Body.NodeType = nodeBlock
+		fn.Body.Nodes = make([]Node, 0)
+		fn.ImplicitReturn = true
+		p.Next()
+
+		implReturnValue := p.parseExpression(false)
+		implReturn := ReturnNode{}
+		implReturn.Value = implReturnValue
+		fn.Body.Nodes = []Node{implReturn}
+		p.globTerminator()
+	} else if p.token.Is(lexer.TokElipsis) {
+		fn.External = true
+		// External functions should not be mangled
+		fn.Nomangle = true
+		p.Next()
+	} else {
+		syntaxFail(p.token, "Expected the body of function %s", fn.Name)
+	}
+
+	return fn
+}
+
+// parseFunctionHeader parses the declaration of a function up to its
+// body: the name, type parameters, arguments and return type
+func (p *Parser) parseFunctionHeader() FunctionNode {
 	// func, pure, etc...
 	declarationKeyword := p.token.Value
 
@@ -29,6 +67,11 @@ func (p *Parser) parseFunctionNode() FunctionNode {
 	}
 
 	rawNameString, _ := p.parseName()
+
+	// Operator overloads, like `func op+(Vec* other) Vec*`
+	if rawNameString == "op" && p.token.Is(lexer.TokOper, lexer.TokLeftBrace) {
+		rawNameString = p.parseOperatorName()
+	}
 	fn.Name = NewIdentNode(rawNameString)
 
 	// The main function should never be mangled
@@ -36,19 +79,23 @@ func (p *Parser) parseFunctionNode() FunctionNode {
 		fn.Nomangle = true
 	}
 
+	// Type parameters, like `func max<T>(T a, T b) T`
+	if p.token.Is(lexer.TokOper) && p.token.Value == "<" {
+		fn.TypeParams = p.parseTypeParams()
+	}
+
 	if p.token.Type == lexer.TokLeftParen {
 		p.Next()
 
 		for {
 
 			// Parse a function argument
-			if p.token.Is(lexer.TokIdent, lexer.TokType) {
+			if p.token.Is(lexer.TokIdent, lexer.TokType, lexer.TokLeftCurly) {
 
 				typ := p.parseType()
 
 				if !p.token.Is(lexer.TokIdent) {
-					p.token.SyntaxError()
-					log.Fatal("invalid function argument\n")
+					syntaxFail(p.token, "invalid function argument")
 				}
 
 				for p.token.Is(lexer.TokIdent) {
@@ -61,6 +108,18 @@ func (p *Parser) parseFunctionNode() FunctionNode {
 
 			}
 
+			// A named argument followed by `...` packs the trailing
+			// arguments of a call into a slice
+			if p.token.Is(lexer.TokElipsis) && len(fn.Args) > 0 && p.Peek(-1).Is(lexer.TokIdent) {
+				last := &fn.Args[len(fn.Args)-1]
+				last.Variadic = true
+				last.Type.Modifiers = append(append([]TypeModifier{}, last.Type.Modifiers...), ModifierSlice)
+				p.Next()
+				if !p.token.Is(lexer.TokRightParen) {
+					syntaxFail(p.token, "a variadic argument must be the last argument of a function")
+				}
+			}
+
 			if p.token.Is(lexer.TokElipsis) {
 				fn.Variadic = true
 				// Variadic functions are external, or should be. This means they shouldn't be mangled
@@ -93,39 +152,7 @@ func (p *Parser) parseFunctionNode() FunctionNode {
 	}
 	// fmt.Println(p.token.Value)
 
-	if p.token.Is(lexer.TokLeftCurly) {
-		fn.BodyParser = p.forkBlockParser()
-	} else if p.token.Is(lexer.TokRightArrow, lexer.TokOper) {
-
-		if p.token.Is(lexer.TokOper) && p.token.Value != "=" {
-			p.token.SyntaxError()
-			log.Fatal("unexpected token %q in function declaration\n", p.token.Value)
-		}
-
-		if p.token.Is(lexer.TokRightArrow) {
-			log.Deprecated("Use of an arrow function will be removed. Replace '->' with '=' (%s)\n", p.token.FileInfo())
-		}
-		fn.Body = BlockNode{}
-		fn.Body.NodeType = nodeBlock
-		fn.Body.Nodes = make([]Node, 0)
-		fn.ImplicitReturn = true
-		p.Next()
-
-		implReturnValue := p.parseExpression(false)
-		implReturn := ReturnNode{}
-		implReturn.Value = implReturnValue
-		fn.Body.Nodes = []Node{implReturn}
-		p.globTerminator()
-	} else if p.token.Is(lexer.TokElipsis) {
-		fn.External = true
-		// External functions should not be mangled
-		fn.Nomangle = true
-		p.Next()
-	} else {
-		p.token.SyntaxError()
-		log.Fatal("")
-	}
-
+	fn.HasUnknownType = len(fn.TypeParams) > 0
 	for _, arg := range fn.Args {
 		if arg.Type.Unknown {
 			fn.HasUnknownType = true
@@ -137,5 +164,6 @@ func (p *Parser) parseFunctionNode() FunctionNode {
 
 // QuickParseFunction takes a stream of tokens and lexes them into a single node
 func QuickParseFunction(src string) Node {
+	defer exitOnSyntaxError()
 	return NewQuickParser(src).parseFunctionNode()
 }
@@ -2,7 +2,6 @@ package ast
 
 import (
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 func (p *Parser) parseGlobalVariableDecl() GlobalVariableDeclNode {
@@ -20,13 +19,11 @@ func (p *Parser) parseGlobalVariableDecl() GlobalVariableDeclNode {
 		} else if p.token.Is(lexer.TokOper) && p.token.Value == "=" {
 
 		} else {
-			n.SyntaxError()
-			log.Fatal("Invalid Global variable declaration\n")
+			syntaxFail(n.Token, "Invalid Global variable declaration")
 		}
 
 	} else {
-		p.token.SyntaxError()
-		log.Fatal("Invalid Global variable declaration\n")
+		syntaxFail(p.token, "Invalid Global variable declaration")
 	}
 
 	if p.token.Is(lexer.TokOper) && p.token.Value == "=" {
@@ -44,3 +41,37 @@ func (p *Parser) parseGlobalVariableDecl() GlobalVariableDeclNode {
 
 	return n
 }
+
+// parseConstDecl parses a constant, like `const PI := 3.14159` or
+// `const float PI = 3.14159`. Constants are globals whose value is
+// computed at compile time and that can never be assigned to.
+func (p *Parser) parseConstDecl() GlobalVariableDeclNode {
+	p.Next()
+
+	n := GlobalVariableDeclNode{}
+	n.NodeType = nodeGlobalDecl
+	n.Token = p.token
+	n.Const = true
+
+	// the name of a constant is often capitalized, so it can be lexed as a type
+	isName := func(t lexer.Token) bool {
+		return t.Is(lexer.TokIdent) || t.Is(lexer.TokType)
+	}
+
+	if !isName(p.token) || !p.Peek(1).Is(lexer.TokOper) || p.Peek(1).Value != ":=" {
+		n.Type = p.parseType()
+	}
+	if !isName(p.token) {
+		syntaxFail(p.token, "Invalid const declaration")
+	}
+	n.Name = NewIdentNode(p.token.Value)
+	p.Next()
+
+	if !p.token.Is(lexer.TokOper) || (p.token.Value != "=" && p.token.Value != ":=") {
+		syntaxFail(p.token, "const %s must be given a value", n.Name)
+	}
+	p.Next()
+	n.Body = p.parseExpression(false)
+	p.globTerminator()
+	return n
+}
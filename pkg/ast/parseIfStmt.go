@@ -16,6 +16,18 @@ func (p *Parser) parseIfStmt() Node {
 
 	p.Next()
 
+	// `if let node = maybe { ... }` checks an optional pointer
+	if p.token.Is(lexer.TokLet) {
+		p.Next()
+		p.requires(lexer.TokIdent)
+		n.Let = p.token.Value
+		p.Next()
+		if !p.token.Is(lexer.TokOper) || p.token.Value != "=" {
+			syntaxFail(p.token, "expected '=' after the name in an if let")
+		}
+		p.Next()
+	}
+
 	n.If = p.parseExpression(false)
 
 	p.requires(lexer.TokLeftCurly)
@@ -2,7 +2,6 @@ package ast
 
 import (
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 func (p *Parser) parseSubscriptExpr(source Accessable) Node {
@@ -16,8 +15,7 @@ func (p *Parser) parseSubscriptExpr(source Accessable) Node {
 	if indexAc, isAccessable := index.(Accessable); isAccessable {
 		subN.Index = indexAc
 	} else {
-		index.SyntaxError()
-		log.Fatal("Unable to index by an expression that isn't an accessable value\n")
+		syntaxFail(p.token, "Unable to index by an expression that isn't an accessable value")
 	}
 	p.requires(lexer.TokRightBrace)
 	p.Next()
@@ -0,0 +1,50 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// parseInterfaceDefn parses an interface declaration. The methods of an
+// interface are declared without a body.
+//
+//	interface Shape {
+//		func area float;
+//		func scale(float by);
+//	}
+func (p *Parser) parseInterfaceDefn() Node {
+	p.requires(lexer.TokInterfaceDefn)
+	n := InterfaceNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeInterface
+
+	p.Next()
+
+	if !p.token.Is(lexer.TokType) || strings.Contains(p.token.Value, ":") {
+		syntaxFail(p.token, "Interface names must be capitalized. Use %q instead", strings.Title(p.token.Value))
+	}
+	n.Name = p.token.Value
+	p.Next()
+
+	if !p.token.Is(lexer.TokLeftCurly) {
+		syntaxFail(p.token, "Expected the methods of interface %s", n.Name)
+	}
+	p.Next()
+
+	for !p.token.Is(lexer.TokRightCurly) {
+		if !p.token.Is(lexer.TokFuncDefn) {
+			syntaxFail(p.token, "Interface %s can only declare methods", n.Name)
+		}
+		fn := p.parseFunctionHeader()
+		fn.IsMethod = true
+		if len(fn.TypeParams) > 0 || fn.HasUnknownType {
+			syntaxFail(fn.Token, "Method %s of interface %s can't be generic", fn.Name, n.Name)
+		}
+		n.Methods = append(n.Methods, fn)
+		p.globTerminator()
+	}
+	p.Next()
+
+	return n
+}
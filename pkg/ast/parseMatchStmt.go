@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+var matchStmtIndex = 0
+
+// parseMatchStmt parses a match statement. Every arm is a list of values
+// separated by commas and the block run when one of them is equal to the
+// matched value. The block after an `else` arm runs when none are.
+//
+//	match x {
+//		1, 2 { ... }
+//		3 { ... }
+//		else { ... }
+//	}
+func (p *Parser) parseMatchStmt() Node {
+	p.requires(lexer.TokMatch)
+	n := MatchNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeMatch
+	n.Index = matchStmtIndex
+	matchStmtIndex++
+	p.Next()
+
+	n.Value = p.parseExpression(false)
+	p.requires(lexer.TokLeftCurly)
+	p.Next()
+
+	for {
+		p.globTerminator()
+		if p.token.Is(lexer.TokRightCurly) {
+			break
+		}
+
+		if p.token.Is(lexer.TokElse) {
+			if n.Else != nil {
+				syntaxFail(p.token, "A match statement can only have one else arm")
+			}
+			p.Next()
+			p.requires(lexer.TokLeftCurly)
+			n.Else = p.parseBlockStmt()
+			continue
+		}
+
+		arm := MatchArm{}
+		arm.Token = p.token
+		for {
+			arm.Values = append(arm.Values, p.parseExpression(false))
+			if !p.token.Is(lexer.TokComma) {
+				break
+			}
+			p.Next()
+		}
+		if !p.token.Is(lexer.TokLeftCurly) {
+			syntaxFail(p.token, "Expected the block of a match arm after its values")
+		}
+		arm.Body = p.parseBlockStmt()
+		n.Arms = append(n.Arms, arm)
+	}
+	p.Next()
+
+	return n
+}
@@ -2,6 +2,8 @@ package ast
 
 import (
 	"fmt"
+	"math/big"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -10,7 +12,10 @@ import (
 
 func (p *Parser) parseNumericExpr() Node {
 
-	n, _ := GetNumberNodeFromString(p.token.Value)
+	n, err := GetNumberNodeFromString(p.token.Value)
+	if err != nil {
+		syntaxFail(p.token, "%s", err)
+	}
 	p.Next()
 
 	return n
@@ -30,22 +35,39 @@ func inferNumberType(str string) (types.Type, interface{}) {
 	return nil, nil
 }
 
-// GetNumberNodeFromString returns the number node for a string
+// literalSuffixes are the types a number can be given with a suffix, as in
+// 10u8 or 1.5f. Without one an integer is a long and a float is a float.
+var literalSuffixes = map[string]types.Type{
+	"i8":   types.I8,
+	"i16":  types.I16,
+	"i32":  types.I32,
+	"i64":  types.I64,
+	"i128": types.I128,
+	"u8":   types.U8,
+	"u16":  types.U16,
+	"u32":  types.U32,
+	"u64":  types.U64,
+	"u128": types.U128,
+	"f":    types.Float,
+	"f32":  types.Float,
+	"f64":  types.Double,
+}
+
+// GetNumberNodeFromString returns the number node for a string. Digits can
+// be separated with underscores, as in 1_000_000 or 0xFF_FF.
 func GetNumberNodeFromString(str string) (Node, error) {
+	str = strings.Replace(str, "_", "", -1)
+	if digits, suffix := splitLiteralSuffix(str); suffix != "" {
+		return newSuffixedNumber(digits, suffix)
+	}
+
 	t, val := inferNumberType(str)
 	// Parse Hex Literals
 	if strings.Contains(str, "x") {
 		if !strings.Contains(str, "0x") {
 			return nil, fmt.Errorf("hex Literal must be of the following format: 0x___")
 		}
-		n := IntNode{}
-		n.NodeType = nodeInt
-		parsed, e := strconv.ParseInt(strings.TrimPrefix(str, "0x"), 16, 64)
-		if e != nil {
-			return nil, fmt.Errorf("error decoding hex token")
-		}
-		n.Value = parsed
-		return n, nil
+		return newIntNode(strings.TrimPrefix(str, "0x"), 16)
 	}
 
 	// Parse Binary Literals
@@ -53,14 +75,7 @@ func GetNumberNodeFromString(str string) (Node, error) {
 		if !strings.Contains(str, "0b") {
 			return nil, fmt.Errorf("binary Literal must be of the following format: 0b___")
 		} else {
-			n := IntNode{}
-			n.NodeType = nodeInt
-			parsed, e := strconv.ParseInt(strings.TrimPrefix(str, "0b"), 2, 64)
-			if e != nil {
-				return nil, fmt.Errorf("error decoding binary token")
-			}
-			n.Value = parsed
-			return n, nil
+			return newIntNode(strings.TrimPrefix(str, "0b"), 2)
 		}
 	}
 
@@ -71,6 +86,11 @@ func GetNumberNodeFromString(str string) (Node, error) {
 		return n, nil
 	}
 
+	// integers too big for a long are 128 bit, instead of becoming floats
+	if strings.Trim(str, "0123456789") == "" {
+		return newIntNode(str, 10)
+	}
+
 	if types.Equal(t, types.Double) {
 		n := FloatNode{}
 		n.NodeType = nodeFloat
@@ -86,3 +106,77 @@ func GetNumberNodeFromString(str string) (Node, error) {
 	}
 	return nil, fmt.Errorf("unable to parse number to node")
 }
+
+// newIntNode parses an integer literal in a base. Literals too big for a
+// long are kept whole, as long as they fit in 128 bits.
+func newIntNode(digits string, base int) (IntNode, error) {
+	n := IntNode{}
+	n.NodeType = nodeInt
+	parsed, err := strconv.ParseInt(digits, base, 64)
+	if err == nil {
+		n.Value = parsed
+		return n, nil
+	}
+	b, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return n, fmt.Errorf("%s is not a base %d integer", digits, base)
+	}
+	if b.BitLen() > 128 {
+		return n, fmt.Errorf("integer literal %s is too big, the largest integers are 128 bits", digits)
+	}
+	n.Big = b
+	return n, nil
+}
+
+// splitLiteralSuffix splits the type suffix off a number. The 'f' of a
+// float suffix is a digit of a hex number, so hex numbers can only have
+// integer suffixes.
+func splitLiteralSuffix(str string) (string, string) {
+	cut := strings.IndexAny(str, "ui")
+	if cut < 0 && !strings.Contains(str, "x") {
+		cut = strings.IndexByte(str, 'f')
+	}
+	if cut < 0 {
+		return str, ""
+	}
+	return str[:cut], str[cut:]
+}
+
+// newSuffixedNumber returns the node of a number with a type suffix
+func newSuffixedNumber(digits string, suffix string) (Node, error) {
+	t, ok := literalSuffixes[suffix]
+	if !ok {
+		names := make([]string, 0, len(literalSuffixes))
+		for name := range literalSuffixes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown suffix %s on number %s, expected one of %s", suffix, digits, strings.Join(names, ", "))
+	}
+
+	if ft, isFloat := t.(*types.FloatType); isFloat {
+		val, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s is not a float", digits, suffix)
+		}
+		n := FloatNode{}
+		n.NodeType = nodeFloat
+		n.Value = val
+		n.Type = ft
+		return n, nil
+	}
+
+	node, err := GetNumberNodeFromString(digits)
+	if err != nil {
+		return nil, err
+	}
+	n, isInt := node.(IntNode)
+	if !isInt {
+		return nil, fmt.Errorf("%s%s is not an integer", digits, suffix)
+	}
+	n.Type = t.(*types.IntType)
+	if !n.fits(n.Type) {
+		return nil, fmt.Errorf("%s doesn't fit in a %s", digits, suffix)
+	}
+	return n, nil
+}
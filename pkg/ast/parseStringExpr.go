@@ -3,8 +3,11 @@ package ast
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
 
-	"github.com/geode-lang/geode/pkg/util/log"
+	"github.com/geode-lang/geode/pkg/lexer"
 )
 
 const (
@@ -21,7 +24,9 @@ func isHex(r rune) bool {
 }
 
 // UnescapeString UTF-8 string
-// e.g. convert "\u0e27\u0e23\u0e0d\u0e32" to "วรญา"
+// e.g. convert "\u0e27\u0e23\u0e0d\u0e32" to "วรญา". A code point can also
+// be written with any number of hex digits in braces, as in \u{1F600}, and
+// is encoded as utf-8. \x escapes are single bytes.
 func UnescapeString(s string) (string, error) {
 	// out := make([]rune, 0)
 	buff := bytes.NewBufferString("")
@@ -39,11 +44,25 @@ func UnescapeString(s string) (string, error) {
 		'\'': 0x27,
 		'"':  0x22,
 		'?':  0x3F,
+		'{':  0x7B,
 	}
 
 	for i := 0; i < len(sr); i++ {
 		if sr[i] == '\\' {
 			i++
+			if i >= len(sr) {
+				return "", fmt.Errorf("unfinished escape at the end of %q", s)
+			}
+
+			if sr[i] == 'u' {
+				r, next, err := unescapeRune(sr, i+1)
+				if err != nil {
+					return "", err
+				}
+				buff.WriteRune(r)
+				i = next - 1
+				continue
+			}
 
 			if sr[i] == 'x' {
 				i++
@@ -62,7 +81,7 @@ func UnescapeString(s string) (string, error) {
 
 			esc, ok := escapes[sr[i]]
 			if !ok {
-				log.Fatal("Unknown escape: '\\%c'\n", sr[i])
+				return "", fmt.Errorf("unknown escape '\\%c'", sr[i])
 			}
 			buff.WriteRune(esc)
 		} else {
@@ -72,29 +91,75 @@ func UnescapeString(s string) (string, error) {
 	return buff.String(), nil
 }
 
-func (p *Parser) parseStringExpr() Node {
-	n := StringNode{}
-	n.TokenReference.Token = p.token
-	n.NodeType = nodeString
+// unescapeRune decodes the code point of a \u escape whose digits start at
+// sr[i], returning it and the index after the escape
+func unescapeRune(sr []rune, i int) (rune, int, error) {
+	var digits string
+	end := i
+	if i < len(sr) && sr[i] == '{' {
+		for end = i + 1; end < len(sr) && sr[end] != '}'; end++ {
+		}
+		if end >= len(sr) {
+			return 0, 0, fmt.Errorf("unclosed '{' in escape \\u%s", string(sr[i:]))
+		}
+		digits = string(sr[i+1 : end])
+		end++
+	} else {
+		for end = i; end < len(sr) && end < i+4 && isHex(sr[end]); end++ {
+		}
+		digits = string(sr[i:end])
+		if len(digits) != 4 {
+			return 0, 0, fmt.Errorf("escape \\u%s needs 4 hex digits, or the digits in braces as in \\u{1F600}", digits)
+		}
+	}
 
-	val := p.token.Value[1 : len(p.token.Value)-1]
-	escaped, _ := UnescapeString(val)
+	code, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) == 0 || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+		return 0, 0, fmt.Errorf("escape \\u{%s} is not a unicode code point", digits)
+	}
+	return rune(code), end, nil
+}
 
-	n.Value = escaped
+func (p *Parser) parseStringExpr() Node {
+	n, err := newStringNode(p.token)
+	if err != nil {
+		syntaxFail(p.token, "%s", err)
+	}
 	p.Next()
 	return n
 }
 
 func (p *Parser) parseCharExpr() Node {
-	n := CharNode{}
+	n, err := newCharNode(p.token)
+	if err != nil {
+		syntaxFail(p.token, "%s", err)
+	}
+	p.Next()
+	return n
+}
 
-	n.TokenReference.Token = p.token
-	n.NodeType = nodeChar
+// newCharNode builds the node for a char literal token. A char that is one
+// byte, like 'a' or '\xff', is a byte, any other, like 'é' or '\u{1F600}',
+// is a rune.
+func newCharNode(tok lexer.Token) (Node, error) {
+	val, err := UnescapeString(tok.Value[1 : len(tok.Value)-1])
+	if err != nil {
+		return nil, newError(tok, ErrSyntax, "%s in char %s", err, tok.Value)
+	}
 
-	val := p.token.Value[1 : len(p.token.Value)-1]
+	n := CharNode{}
+	n.Token = tok
+	n.NodeType = nodeChar
+	if len(val) == 1 {
+		n.Value = rune(val[0])
+		return n, nil
+	}
 
-	escaped, _ := UnescapeString(val)
-	n.Value = []rune(escaped)[0]
-	p.Next()
-	return n
+	r, size := utf8.DecodeRuneInString(val)
+	if size == 0 || size != len(val) {
+		return nil, newError(tok, ErrSyntax, "char %s must be one character", tok.Value)
+	}
+	n.Value = r
+	n.Rune = true
+	return n, nil
 }
@@ -0,0 +1,13 @@
+package ast
+
+func (p *Parser) parseThrowStmt() ThrowNode {
+	n := ThrowNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeThrow
+	p.Next()
+
+	n.Value = p.parseExpression(false)
+
+	p.globTerminator()
+	return n
+}
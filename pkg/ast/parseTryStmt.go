@@ -0,0 +1,31 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+func (p *Parser) parseTryStmt() Node {
+	p.requires(lexer.TokTry)
+	n := TryCatchNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeTryCatch
+	p.Next()
+
+	p.requires(lexer.TokLeftCurly)
+	n.Body = p.parseBlockStmt()
+
+	p.requires(lexer.TokCatch)
+	p.Next()
+	p.requires(lexer.TokLeftParen)
+	p.Next()
+	p.requires(lexer.TokIdent)
+	n.Name = p.token.Value
+	p.Next()
+	n.Type = p.parseType()
+	p.requires(lexer.TokRightParen)
+	p.Next()
+
+	p.requires(lexer.TokLeftCurly)
+	n.Catch = p.parseBlockStmt()
+	return n
+}
@@ -1,8 +1,10 @@
 package ast
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/geode-lang/geode/pkg/lexer"
-	"github.com/geode-lang/geode/pkg/util/log"
 )
 
 var typeOperators = []string{"*", "?"}
@@ -18,13 +20,31 @@ func validTypeInfoTokens(t lexer.Token) bool {
 }
 
 func (p *Parser) atType() bool {
-	if !p.token.Is(lexer.TokType) {
+	offset := 1
+	switch {
+	case p.token.Is(lexer.TokLeftCurly):
+		offset = p.skipStructType(0)
+		if offset < 0 {
+			return false
+		}
+	case !p.token.Is(lexer.TokType):
 		return false
+	case p.Peek(offset).Is(lexer.TokOper) && p.Peek(offset).Value == "<":
+		offset = p.skipTypeArgs(offset)
+		if offset < 0 {
+			return false
+		}
 	}
-
-	offset := 1
-	for validTypeInfoTokens(p.Peek(offset)) {
-		offset++
+	for {
+		if validTypeInfoTokens(p.Peek(offset)) {
+			offset++
+			continue
+		}
+		if p.Peek(offset).Is(lexer.TokLeftBrace) && p.Peek(offset+1).Is(lexer.TokRightBrace) {
+			offset += 2
+			continue
+		}
+		break
 	}
 
 	if p.Peek(offset).Type == lexer.TokIdent {
@@ -37,18 +57,34 @@ func (p *Parser) atType() bool {
 // parseType returns a
 
 func (p *Parser) parseType() (t TypeNode) {
-	p.requires(lexer.TokType)
-
-	t.Name, _ = p.parseName()
+	if p.token.Is(lexer.TokLeftCurly) {
+		t.Name = p.parseStructType()
+	} else {
+		p.requires(lexer.TokType)
+		t.Name, _ = p.parseName()
+	}
 
 	t.Modifiers = make([]TypeModifier, 0)
+
+	// the type arguments of a generic class, like `List<int>`, are part
+	// of the type's name
+	if p.token.Is(lexer.TokOper) && p.token.Value == "<" {
+		t.Name = p.parseTypeArgs(t.Name)
+	}
 	// p.Next()
 
 	for {
 
+		// a ? after a pointer makes the pointer optional
+		if p.token.Is(lexer.TokQuestionMark) && len(t.Modifiers) > 0 && t.Modifiers[len(t.Modifiers)-1] == ModifierPointer {
+			t.Modifiers = append(t.Modifiers, ModifierOptional)
+			p.Next()
+			continue
+		}
+
 		if p.token.Is(lexer.TokQuestionMark) {
 			if t.Unknown {
-				log.Fatal("Multiple Unknown Type operators for %q used.\n", t.Name)
+				syntaxFail(p.token, "Multiple Unknown Type operators for %q used.", t.Name)
 			}
 
 			t.Unknown = true
@@ -68,13 +104,12 @@ func (p *Parser) parseType() (t TypeNode) {
 			continue
 		}
 		// handle slice type definition `T[]` for some T
-		// if p.token.Is(lexer.TokLeftBrace) {
-		// 	p.Next()
-		// 	p.requires(lexer.TokRightBrace)
-		// 	t.Modifiers = append(t.Modifiers, ModifierSlice)
-		// 	p.Next()
-		// 	continue
-		// }
+		if p.token.Is(lexer.TokLeftBrace) && p.Peek(1).Is(lexer.TokRightBrace) {
+			p.Next()
+			t.Modifiers = append(t.Modifiers, ModifierSlice)
+			p.Next()
+			continue
+		}
 
 		break
 
@@ -82,3 +117,168 @@ func (p *Parser) parseType() (t TypeNode) {
 
 	return t
 }
+
+// parseStructType parses an anonymous struct type, like `{int x, int y}`,
+// and returns its name. The name spells out the fields, so anonymous
+// structs with the same fields have the same name.
+func (p *Parser) parseStructType() string {
+	fields := make([]string, 0)
+	for _, field := range p.parseStructFields() {
+		fields = append(fields, fmt.Sprintf("%s %s", field.Type, field.Name))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+// parseStructFields parses the fields of an anonymous struct type, which
+// are declared like the arguments of a function
+func (p *Parser) parseStructFields() []FunctionArg {
+	p.requires(lexer.TokLeftCurly)
+	p.Next()
+	fields := make([]FunctionArg, 0)
+	for !p.token.Is(lexer.TokRightCurly) {
+		if !p.token.Is(lexer.TokType, lexer.TokLeftCurly) {
+			syntaxFail(p.token, "Expected the type of a field of an anonymous struct")
+		}
+		field := FunctionArg{}
+		field.Type = p.parseType()
+		if !p.token.Is(lexer.TokIdent) {
+			syntaxFail(p.token, "Expected the name of a field of an anonymous struct")
+		}
+		field.Name = p.token.Value
+		p.Next()
+		fields = append(fields, field)
+
+		if p.token.Is(lexer.TokComma) {
+			p.Next()
+		} else if !p.token.Is(lexer.TokRightCurly) {
+			syntaxFail(p.token, "Expected a ',' or '}' after field %s of an anonymous struct", field.Name)
+		}
+	}
+	if len(fields) == 0 {
+		syntaxFail(p.token, "An anonymous struct must have fields")
+	}
+	p.Next()
+	return fields
+}
+
+// skipStructType returns the offset of the token after the anonymous
+// struct type that starts at offset, or -1 if the tokens can't be one
+func (p *Parser) skipStructType(offset int) int {
+	if !p.Peek(offset+1).Is(lexer.TokType, lexer.TokLeftCurly) {
+		return -1
+	}
+	depth := 0
+	for {
+		tok := p.Peek(offset)
+		switch {
+		case tok.Is(lexer.TokLeftCurly):
+			depth++
+		case tok.Is(lexer.TokRightCurly):
+			depth--
+		case tok.Is(lexer.TokOper):
+			for _, c := range tok.Value {
+				if c != '<' && c != '>' && c != '*' && c != '?' {
+					return -1
+				}
+			}
+		case tok.Is(lexer.TokType, lexer.TokIdent, lexer.TokComma, lexer.TokQuestionMark, lexer.TokLeftBrace, lexer.TokRightBrace):
+		default:
+			return -1
+		}
+		offset++
+		if depth == 0 {
+			return offset
+		}
+	}
+}
+
+// skipTypeArgs returns the offset of the token after the type arguments
+// that start at offset, or -1 if the tokens can't be type arguments
+func (p *Parser) skipTypeArgs(offset int) int {
+	depth := 0
+	for {
+		tok := p.Peek(offset)
+		switch {
+		case tok.Is(lexer.TokOper):
+			// a closing '>' can be lexed together with the modifiers of
+			// the generic type, like `List<int>*`
+			for _, c := range tok.Value {
+				switch c {
+				case '<':
+					depth++
+				case '>':
+					depth--
+				case '*', '?':
+				default:
+					return -1
+				}
+			}
+		case tok.Is(lexer.TokType, lexer.TokComma, lexer.TokLeftBrace, lexer.TokRightBrace):
+		default:
+			return -1
+		}
+		offset++
+		if depth == 0 {
+			return offset
+		}
+	}
+}
+
+// parseTypeArgs parses the type arguments after the name of a generic
+// class and returns the full name of the type, like `List<int*>`
+func (p *Parser) parseTypeArgs(name string) string {
+	p.Next()
+	args := make([]string, 0)
+	for {
+		if !p.token.Is(lexer.TokType) {
+			syntaxFail(p.token, "invalid type argument %q", p.token.Value)
+		}
+		args = append(args, p.parseType().String())
+
+		if p.token.Is(lexer.TokComma) {
+			p.Next()
+			continue
+		}
+		if p.token.Is(lexer.TokOper) && strings.HasPrefix(p.token.Value, ">") {
+			// `>>` and `>*` are lexed as one operator, so only the
+			// first '>' is consumed
+			if len(p.token.Value) > 1 {
+				p.token.Value = p.token.Value[1:]
+			} else {
+				p.Next()
+			}
+			break
+		}
+		syntaxFail(p.token, "expected ',' or '>' after type argument, got %q", p.token.Value)
+	}
+	return fmt.Sprintf("%s<%s>", name, strings.Join(args, ", "))
+}
+
+// parseTypeParams parses the names of the type parameters of a generic
+// function or class, like `<K, V>`
+func (p *Parser) parseTypeParams() []string {
+	p.Next()
+	params := make([]string, 0)
+	for {
+		if !p.token.Is(lexer.TokType) {
+			syntaxFail(p.token, "invalid type parameter %q, type parameters are capitalized type names", p.token.Value)
+		}
+		for _, param := range params {
+			if param == p.token.Value {
+				syntaxFail(p.token, "type parameter %s is declared more than once", p.token.Value)
+			}
+		}
+		params = append(params, p.token.Value)
+		p.Next()
+
+		if p.token.Is(lexer.TokComma) {
+			p.Next()
+			continue
+		}
+		if p.token.Is(lexer.TokOper) && p.token.Value == ">" {
+			p.Next()
+			return params
+		}
+		syntaxFail(p.token, "expected ',' or '>' after type parameter, got %q", p.token.Value)
+	}
+}
@@ -13,10 +13,37 @@ func (p *Parser) parseUnary(allowdecl bool) Node {
 		"*": true,
 		"-": true,
 		"!": true,
+		"~": true,
+		// receiving from a chan
+		"<-": true,
 	}
 
-	// parse the "as"
-	if p.token.Is(lexer.TokAs) {
+	// a prefix ++ or -- gives the new value
+	if isIncDec(p.token) {
+		inc := IncDecNode{}
+		inc.Token = startTok
+		inc.NodeType = nodeIncDec
+		inc.Op = p.token.Value
+		inc.Prefix = true
+		p.Next()
+		inc.Operand = p.parseUnary(allowdecl)
+		if inc.Operand == nil {
+			return nil
+		}
+		return inc
+	}
+
+	// spawn starts the call after it on a new thread
+	if p.token.Is(lexer.TokSpawn) {
+		spawn := SpawnNode{}
+		spawn.Token = startTok
+		spawn.NodeType = nodeSpawn
+		p.Next()
+		spawn.Call = p.parseUnary(false)
+		if spawn.Call == nil {
+			return nil
+		}
+		return spawn
 	}
 
 	// _, isBinaryOp := p.binaryOpPrecedence[p.token.Value]
@@ -25,8 +52,13 @@ func (p *Parser) parseUnary(allowdecl bool) Node {
 	if !isPtrOp {
 		chain, _ := p.parseCompoundExpression(allowdecl)
 		if chain != nil {
-			n, _ := chain.ConstructNode(nil)
-			return n
+			n, err := chain.ConstructNode(nil)
+			// mistakes in literals, like a bad escape in a string, are
+			// syntax errors wherever the literal is
+			if d, isDiagnostic := err.(*Diagnostic); isDiagnostic {
+				panic(syntaxBail{d})
+			}
+			return p.parseIncDec(p.parseTry(n))
 		}
 		return nil
 
@@ -65,3 +97,16 @@ func (p *Parser) parseUnary(allowdecl bool) Node {
 
 	return nil
 }
+
+// parseTry wraps an expression in the ? operators after it, if any
+func (p *Parser) parseTry(n Node) Node {
+	for n != nil && p.token.Is(lexer.TokQuestionMark) {
+		try := TryNode{}
+		try.TokenReference.Token = p.token
+		try.NodeType = nodeTry
+		try.Value = n
+		n = try
+		p.Next()
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// parseUnionDefn parses a union declaration. Variants are separated by
+// commas or newlines, and list the fields they hold in parentheses, like
+// the arguments of a function.
+//
+//	union Shape {
+//		Circle(float radius)
+//		Rect(float w, float h)
+//		Empty
+//	}
+func (p *Parser) parseUnionDefn() Node {
+	p.requires(lexer.TokUnionDefn)
+	n := UnionNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeUnion
+
+	p.Next()
+
+	if !p.token.Is(lexer.TokType) || strings.Contains(p.token.Value, ":") {
+		syntaxFail(p.token, "Union names must be capitalized. Use %q instead", strings.Title(p.token.Value))
+	}
+	n.Name = p.token.Value
+	p.Next()
+
+	if !p.token.Is(lexer.TokLeftCurly) {
+		syntaxFail(p.token, "Expected the variants of union %s", n.Name)
+	}
+	p.Next()
+
+	for !p.token.Is(lexer.TokRightCurly) {
+		if !p.token.Is(lexer.TokType, lexer.TokIdent) || strings.Contains(p.token.Value, ":") {
+			syntaxFail(p.token, "Invalid variant in union %s", n.Name)
+		}
+		variant := UnionVariant{}
+		variant.Token = p.token
+		variant.Name = p.token.Value
+		p.Next()
+
+		if p.token.Is(lexer.TokLeftParen) {
+			p.Next()
+			for !p.token.Is(lexer.TokRightParen) {
+				if !p.token.Is(lexer.TokType) {
+					syntaxFail(p.token, "Expected the type of a field of %s:%s", n.Name, variant.Name)
+				}
+				field := FunctionArg{}
+				field.Type = p.parseType()
+				if !p.token.Is(lexer.TokIdent) {
+					syntaxFail(p.token, "Expected the name of a field of %s:%s", n.Name, variant.Name)
+				}
+				field.Name = p.token.Value
+				p.Next()
+				variant.Fields = append(variant.Fields, field)
+
+				if p.token.Is(lexer.TokComma) {
+					p.Next()
+				} else if !p.token.Is(lexer.TokRightParen) {
+					syntaxFail(p.token, "Expected a ',' or ')' after field %s of %s:%s", field.Name, n.Name, variant.Name)
+				}
+			}
+			p.Next()
+		}
+		n.Variants = append(n.Variants, variant)
+
+		if p.token.Is(lexer.TokComma) {
+			p.Next()
+		}
+	}
+	p.Next()
+
+	return n
+}
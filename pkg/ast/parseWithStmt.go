@@ -0,0 +1,23 @@
+package ast
+
+import (
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+var withStmtIndex = 0
+
+func (p *Parser) parseWithStmt() Node {
+	p.requires(lexer.TokWith)
+	n := WithNode{}
+	n.TokenReference.Token = p.token
+	n.NodeType = nodeWith
+	n.Index = withStmtIndex
+	withStmtIndex++
+	p.Next()
+
+	n.Lock = p.parseExpression(false)
+	p.requires(lexer.TokLeftCurly)
+
+	n.Body = p.parseBlockStmt()
+	return n
+}
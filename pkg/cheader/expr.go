@@ -0,0 +1,284 @@
+package cheader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// value is the value of an integer constant expression
+type value struct {
+	v        int64
+	unsigned bool
+}
+
+func boolValue(b bool) value {
+	if b {
+		return value{v: 1}
+	}
+	return value{}
+}
+
+// evaluate evaluates an integer constant expression, expanding the macros
+// in it. It fails for expressions that aren't constant, or whose value
+// isn't an integer.
+func (p *parser) evaluate(tokens []token) (v value, err error) {
+	saved, savedPos := p.tokens, p.pos
+	p.tokens = append(p.expand(tokens, nil), token{kind: tokEOF})
+	p.pos = 0
+	defer func() {
+		p.tokens, p.pos = saved, savedPos
+		if r := recover(); r != nil {
+			syntax, isSyntax := r.(syntaxError)
+			if !isSyntax {
+				panic(r)
+			}
+			err = fmt.Errorf("%s", syntax.msg)
+		}
+	}()
+
+	if p.at(tokEOF) {
+		p.fail("expected an expression")
+	}
+	v = p.conditional()
+	if !p.at(tokEOF) {
+		p.fail("unexpected %q in a constant expression", p.peek().text)
+	}
+	return v, nil
+}
+
+// expand replaces the object-like macros in some tokens with their bodies.
+// A macro isn't expanded inside its own body, like in the preprocessor.
+func (p *parser) expand(tokens []token, expanding map[string]bool) []token {
+	expanded := make([]token, 0, len(tokens))
+	for _, tok := range tokens {
+		m, isMacro := p.macros[tok.text]
+		if tok.kind != tokIdent || !isMacro || m.funcLike || expanding[tok.text] {
+			expanded = append(expanded, tok)
+			continue
+		}
+		inner := map[string]bool{tok.text: true}
+		for name := range expanding {
+			inner[name] = true
+		}
+		expanded = append(expanded, p.expand(m.body, inner)...)
+	}
+	return expanded
+}
+
+// the binary operators by precedence, loosest first
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<", ">", "<=", ">="},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) conditional() value {
+	cond := p.binary(0)
+	if !p.accept("?") {
+		return cond
+	}
+	then := p.conditional()
+	p.expect(":")
+	otherwise := p.conditional()
+	if cond.v != 0 {
+		return then
+	}
+	return otherwise
+}
+
+func (p *parser) binary(level int) value {
+	if level == len(binaryPrecedence) {
+		return p.unary()
+	}
+	lhs := p.binary(level + 1)
+	for {
+		op := ""
+		for _, candidate := range binaryPrecedence[level] {
+			if p.peek().kind == tokPunct && p.peek().text == candidate {
+				op = candidate
+			}
+		}
+		if op == "" {
+			return lhs
+		}
+		p.next()
+		lhs = p.apply(op, lhs, p.binary(level+1))
+	}
+}
+
+// apply applies a binary operator, with c's rules for unsigned operands
+func (p *parser) apply(op string, a, b value) value {
+	unsigned := a.unsigned || b.unsigned
+	ua, ub := uint64(a.v), uint64(b.v)
+	switch op {
+	case "||":
+		return boolValue(a.v != 0 || b.v != 0)
+	case "&&":
+		return boolValue(a.v != 0 && b.v != 0)
+	case "==":
+		return boolValue(a.v == b.v)
+	case "!=":
+		return boolValue(a.v != b.v)
+	case "<", ">", "<=", ">=":
+		less, greater := a.v < b.v, a.v > b.v
+		if unsigned {
+			less, greater = ua < ub, ua > ub
+		}
+		switch op {
+		case "<":
+			return boolValue(less)
+		case ">":
+			return boolValue(greater)
+		case "<=":
+			return boolValue(!greater)
+		}
+		return boolValue(!less)
+	case "<<":
+		return value{a.v << ub, a.unsigned}
+	case ">>":
+		if a.unsigned {
+			return value{int64(ua >> ub), true}
+		}
+		return value{a.v >> ub, false}
+	case "/", "%":
+		if b.v == 0 {
+			p.fail("division by zero")
+		}
+		if unsigned {
+			if op == "/" {
+				return value{int64(ua / ub), true}
+			}
+			return value{int64(ua % ub), true}
+		}
+		if op == "/" {
+			return value{a.v / b.v, false}
+		}
+		return value{a.v % b.v, false}
+	}
+	v := value{unsigned: unsigned}
+	switch op {
+	case "|":
+		v.v = a.v | b.v
+	case "^":
+		v.v = a.v ^ b.v
+	case "&":
+		v.v = a.v & b.v
+	case "+":
+		v.v = a.v + b.v
+	case "-":
+		v.v = a.v - b.v
+	case "*":
+		v.v = a.v * b.v
+	}
+	return v
+}
+
+func (p *parser) unary() value {
+	switch tok := p.peek(); {
+	case tok.is("+"):
+		p.next()
+		return p.unary()
+	case tok.is("-"):
+		p.next()
+		v := p.unary()
+		return value{-v.v, v.unsigned}
+	case tok.is("~"):
+		p.next()
+		v := p.unary()
+		return value{^v.v, v.unsigned}
+	case tok.is("!"):
+		p.next()
+		return boolValue(p.unary().v == 0)
+	case tok.is("(") && p.startsType(p.peekAt(1)):
+		p.next()
+		s := p.specifiers()
+		_, t := p.declarator(s.t)
+		p.expect(")")
+		return p.cast(p.unary(), t)
+	case tok.is("("):
+		p.next()
+		v := p.conditional()
+		p.expect(")")
+		return v
+	case tok.kind == tokNumber:
+		p.next()
+		return p.number(tok.text)
+	case tok.kind == tokChar:
+		p.next()
+		return p.char(tok.text)
+	case tok.kind == tokIdent:
+		if c, isEnum := p.enums[tok.text]; isEnum {
+			p.next()
+			return value{c.Value, c.Unsigned}
+		}
+		p.fail("%q isn't a constant", tok.text)
+	}
+	p.fail("unexpected %q in a constant expression", p.peek().text)
+	return value{}
+}
+
+// the widths of the integer types casts truncate to. long is left at 64
+// bits, as the width of the target's isn't known.
+var castBits = map[Kind]uint{
+	Bool: 1, Char: 8, SChar: 8, UChar: 8, Short: 16, UShort: 16, Int: 32, UInt: 32, Enum: 32,
+}
+
+// cast converts a value to an integer type
+func (p *parser) cast(v value, t *Type) value {
+	if !t.Kind.Integer() {
+		p.fail("cast to %s in a constant expression", t)
+	}
+	v.unsigned = t.Kind == UChar || t.Kind == UShort || t.Kind == UInt || t.Kind == ULong || t.Kind == ULongLong || t.Kind == UInt128
+	bits, truncates := castBits[t.Kind]
+	switch {
+	case t.Kind == Bool:
+		return boolValue(v.v != 0)
+	case !truncates:
+	case v.unsigned:
+		v.v = int64(uint64(v.v) & (1<<bits - 1))
+	default:
+		v.v = v.v << (64 - bits) >> (64 - bits)
+	}
+	return v
+}
+
+// number parses an integer literal
+func (p *parser) number(text string) value {
+	digits := strings.TrimRight(text, "uUlL")
+	suffix := strings.ToLower(text[len(digits):])
+	if strings.HasPrefix(digits, "0") && len(digits) > 1 && !strings.ContainsAny(digits[1:2], "xXbB") {
+		digits = "0o" + digits[1:]
+	}
+	n, err := strconv.ParseUint(digits, 0, 64)
+	if err != nil {
+		p.fail("%q isn't an integer", text)
+	}
+	return value{int64(n), strings.Contains(suffix, "u") || n > 1<<63-1}
+}
+
+// char parses a character literal, whose value is an int
+func (p *parser) char(text string) value {
+	text = text[strings.IndexByte(text, '\''):]
+	r, _, tail, err := strconv.UnquoteChar(text[1:len(text)-1], '\'')
+	if err != nil || tail != "" {
+		// the octal escapes shorter than 3 digits c allows, like '\0'
+		octal := strings.TrimPrefix(text[1:len(text)-1], "\\")
+		n, octalErr := strconv.ParseUint(octal, 8, 8)
+		if octalErr != nil || len(octal) == len(text)-2 {
+			p.fail("invalid character %s", text)
+		}
+		return value{v: int64(n)}
+	}
+	if r > 0x7f && !strings.HasPrefix(text, "'\\") {
+		p.fail("multibyte character %s", text)
+	}
+	return value{v: int64(int8(r))}
+}
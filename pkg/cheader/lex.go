@@ -0,0 +1,197 @@
+package cheader
+
+import (
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokChar
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	file string
+}
+
+func (t token) is(text string) bool {
+	return t.kind == tokPunct && t.text == text || t.kind == tokIdent && t.text == text
+}
+
+// macro is an object-like macro. Function-like macros are kept too, with
+// no body, so they hide the macros they replace.
+type macro struct {
+	name     string
+	body     []token
+	funcLike bool
+	file     string
+}
+
+// the punctuators longer than one character, longest first
+var punctuators = []string{
+	"...", "<<=", ">>=",
+	"->", "++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"*=", "/=", "%=", "+=", "-=", "&=", "^=", "|=", "##",
+}
+
+// lex splits preprocessed c into tokens, handling the directives left in
+// it: line markers set the file the tokens after them are in, and #define
+// and #undef keep track of the macros defined.
+func (p *parser) lex(src string) {
+	file, main := "", ""
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			p.tokens = append(p.tokens, lexLine(line, file)...)
+			continue
+		}
+		directive := lexLine(trimmed[1:], file)
+		if len(directive) == 0 {
+			continue
+		}
+		if directive[0].is("line") {
+			directive = directive[1:]
+		}
+		switch {
+		case directive[0].kind == tokNumber && len(directive) > 1 && directive[1].kind == tokString:
+			file = unquote(directive[1].text)
+			// the first marker is the source that included the header, and
+			// the ones in angle brackets are the compiler's own
+			if main == "" {
+				main = file
+			} else if file != main && !strings.HasPrefix(file, "<") && !p.files[file] {
+				p.files[file] = true
+				p.header.Files = append(p.header.Files, file)
+			}
+		case directive[0].is("define") && len(directive) > 1:
+			m := &macro{name: directive[1].text, file: file}
+			// a function-like macro has its ( right after the name
+			rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(trimmed[1:]), "define"))
+			if strings.HasPrefix(strings.TrimPrefix(rest, m.name), "(") {
+				m.funcLike = true
+			} else {
+				m.body = directive[2:]
+			}
+			if _, defined := p.macros[m.name]; !defined {
+				p.macroOrder = append(p.macroOrder, m.name)
+			}
+			p.macros[m.name] = m
+		case directive[0].is("undef") && len(directive) > 1:
+			delete(p.macros, directive[1].text)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, file: file})
+}
+
+// lexLine splits one line of c into tokens
+func lexLine(line, file string) []token {
+	tokens := make([]token, 0)
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return tokens
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			end := strings.Index(line[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case isIdentStart(c):
+			start := i
+			for i < len(line) && isIdentChar(line[i]) {
+				i++
+			}
+			// prefixed string and char literals, like L"" and u8''
+			if i < len(line) && (line[i] == '"' || line[i] == '\'') && isLiteralPrefix(line[start:i]) {
+				end := quoted(line, i)
+				tokens = append(tokens, token{literalKind(line[i]), line[start:end], file})
+				i = end
+				continue
+			}
+			tokens = append(tokens, token{tokIdent, line[start:i], file})
+		case isDigit(c) || c == '.' && i+1 < len(line) && isDigit(line[i+1]):
+			start := i
+			for i < len(line) {
+				if (line[i] == '+' || line[i] == '-') && strings.ContainsAny(line[i-1:i], "eEpP") {
+					i++
+					continue
+				}
+				if !isIdentChar(line[i]) && line[i] != '.' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, token{tokNumber, line[start:i], file})
+		case c == '"' || c == '\'':
+			end := quoted(line, i)
+			tokens = append(tokens, token{literalKind(c), line[i:end], file})
+			i = end
+		default:
+			text := line[i : i+1]
+			for _, punct := range punctuators {
+				if strings.HasPrefix(line[i:], punct) {
+					text = punct
+					break
+				}
+			}
+			tokens = append(tokens, token{tokPunct, text, file})
+			i += len(text)
+		}
+	}
+	return tokens
+}
+
+// quoted returns the end of the string or char literal starting at i
+func quoted(line string, i int) int {
+	quote := line[i]
+	for i++; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+		} else if line[i] == quote {
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+func literalKind(quote byte) tokenKind {
+	if quote == '"' {
+		return tokString
+	}
+	return tokChar
+}
+
+func isLiteralPrefix(s string) bool {
+	return s == "L" || s == "u" || s == "U" || s == "u8"
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// unquote returns the contents of a string literal
+func unquote(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return strings.Trim(s, "\"")
+}
@@ -0,0 +1,655 @@
+package cheader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse parses the output of `cc -E -dD` for a header into its
+// declarations
+func Parse(src string) *Header {
+	p := &parser{
+		header:  &Header{Typedefs: make(map[string]*Type)},
+		files:   make(map[string]bool),
+		macros:  make(map[string]*macro),
+		records: make(map[string]*Record),
+		enums:   make(map[string]*Constant),
+		funcs:   make(map[string]*Function),
+	}
+	p.lex(src)
+	for !p.at(tokEOF) {
+		p.externalDeclaration()
+	}
+	p.defineMacros()
+	return p.header
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	header *Header
+	// files are the headers included, macros the macros defined at the
+	// end of the header and macroOrder the order they were first defined
+	files      map[string]bool
+	macros     map[string]*macro
+	macroOrder []string
+	// records are the structs and unions by their kind and tag, enums the
+	// enum constants by name and funcs the functions by name
+	records map[string]*Record
+	enums   map[string]*Constant
+	funcs   map[string]*Function
+}
+
+// syntaxError is what the parser panics with when it finds a declaration
+// it can't parse. The declaration is skipped.
+type syntaxError struct {
+	msg string
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	panic(syntaxError{fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) at(kind tokenKind) bool { return p.peek().kind == kind }
+
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) {
+	if !p.accept(text) {
+		p.fail("expected %q, found %q", text, p.peek().text)
+	}
+}
+
+// skipBalanced skips the bracketed tokens starting at the opening bracket
+// the parser is at
+func (p *parser) skipBalanced() {
+	depth := 0
+	for !p.at(tokEOF) {
+		tok := p.next()
+		if tok.kind != tokPunct {
+			continue
+		}
+		switch tok.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// skipUntil skips tokens until one of the stops outside of brackets,
+// leaving the parser at it
+func (p *parser) skipUntil(stops ...string) {
+	for !p.at(tokEOF) {
+		tok := p.peek()
+		if tok.kind == tokPunct {
+			for _, stop := range stops {
+				if tok.text == stop {
+					return
+				}
+			}
+			if tok.text == "(" || tok.text == "[" || tok.text == "{" {
+				p.skipBalanced()
+				continue
+			}
+		}
+		p.next()
+	}
+}
+
+// collect returns the tokens until one of the stops outside of brackets
+func (p *parser) collect(stops ...string) []token {
+	start := p.pos
+	p.skipUntil(stops...)
+	return p.tokens[start:p.pos]
+}
+
+// externalDeclaration parses a declaration at the top level of the
+// header, skipping it if it can't be parsed
+func (p *parser) externalDeclaration() {
+	start := p.pos
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isSyntax := r.(syntaxError); !isSyntax {
+				panic(r)
+			}
+			p.pos = start
+			p.skipUntil(";")
+			p.accept(";")
+		}
+	}()
+
+	if p.accept(";") {
+		return
+	}
+	if p.accept("_Static_assert") || p.accept("static_assert") || p.accept("asm") || p.accept("__asm__") || p.accept("__asm") {
+		p.skipBalanced()
+		p.accept(";")
+		return
+	}
+	s := p.specifiers()
+	if p.accept(";") {
+		return
+	}
+	for {
+		name, t := p.declarator(s.t)
+		label := p.attributes()
+		switch {
+		case name == "":
+			p.fail("expected a name to declare")
+		case s.typedef:
+			p.header.Typedefs[name] = t
+			if t.Kind == Struct || t.Kind == Union {
+				t.Record.Typedefs = append(t.Record.Typedefs, name)
+			}
+		case t.Kind == Func && !s.static:
+			// a function keeps the type it was first declared with, but a
+			// later declaration can still rename it
+			if fn, declared := p.funcs[name]; declared {
+				if label != "" {
+					fn.Label = label
+				}
+				break
+			}
+			fn := &Function{Name: name, Type: t, File: p.tokens[start].file, Label: label}
+			p.funcs[name] = fn
+			p.header.Functions = append(p.header.Functions, fn)
+		}
+		if t.Kind == Func && p.peek().is("{") {
+			p.skipBalanced()
+			return
+		}
+		if p.accept("=") {
+			p.skipUntil(",", ";")
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(";")
+}
+
+// attributes skips the attributes and asm labels at the parser, returning
+// the label, if there was one
+func (p *parser) attributes() string {
+	label := ""
+	for {
+		switch tok := p.peek(); {
+		case tok.is("__attribute__") || tok.is("__attribute") || tok.is("__declspec"):
+			p.next()
+			p.skipBalanced()
+		case tok.is("asm") || tok.is("__asm__") || tok.is("__asm"):
+			p.next()
+			p.expect("(")
+			for p.at(tokString) {
+				label += unquote(p.next().text)
+			}
+			p.expect(")")
+		default:
+			return label
+		}
+	}
+}
+
+// specifiers are the storage class and type a declaration starts with
+type specifiers struct {
+	typedef bool
+	static  bool
+	t       *Type
+}
+
+// the words that qualify a type without changing it
+var qualifiers = map[string]bool{
+	"const": true, "__const": true, "__const__": true,
+	"volatile": true, "__volatile": true, "__volatile__": true,
+	"restrict": true, "__restrict": true, "__restrict__": true,
+	"_Nonnull": true, "_Nullable": true, "_Null_unspecified": true,
+	"__extension__": true, "inline": true, "__inline": true, "__inline__": true,
+	"_Noreturn": true, "__forceinline": true, "__cdecl": true, "__stdcall": true,
+	"extern": true, "auto": true, "register": true, "_Thread_local": true, "__thread": true,
+}
+
+// the words a type is built from
+var typeWords = map[string]bool{
+	"void": true, "char": true, "short": true, "int": true, "long": true,
+	"float": true, "double": true, "signed": true, "__signed": true, "__signed__": true,
+	"unsigned": true, "_Bool": true, "bool": true, "_Complex": true, "__complex__": true,
+	"__int128": true, "__int128_t": true, "__uint128_t": true, "__builtin_va_list": true,
+	"__float128": true, "__float80": true, "__ibm128": true, "__fp16": true, "__bf16": true,
+}
+
+// startsType returns if a token starts a type name
+func (p *parser) startsType(tok token) bool {
+	if tok.kind != tokIdent {
+		return false
+	}
+	_, isTypedef := p.header.Typedefs[tok.text]
+	return isTypedef || typeWords[tok.text] || qualifiers[tok.text] || isUnsupportedFloat(tok.text) ||
+		tok.text == "struct" || tok.text == "union" || tok.text == "enum" ||
+		tok.text == "typeof" || tok.text == "__typeof__" || tok.text == "__typeof" || tok.text == "_Atomic"
+}
+
+// isUnsupportedFloat returns if a word is one of the floating point types
+// of a particular width, which geode has no equivalent of
+func isUnsupportedFloat(word string) bool {
+	return strings.HasPrefix(word, "_Float") || strings.HasPrefix(word, "_Decimal")
+}
+
+// specifiers parses the storage class, qualifiers and type that start a
+// declaration
+func (p *parser) specifiers() specifiers {
+	s := specifiers{}
+	words := make(map[string]int)
+	isConst := false
+	for {
+		tok := p.peek()
+		if tok.kind != tokIdent {
+			break
+		}
+		word := tok.text
+		if strings.HasPrefix(word, "__signed") {
+			word = "signed"
+		}
+		switch {
+		case word == "typedef":
+			s.typedef = true
+		case word == "static":
+			s.static = true
+		case qualifiers[word]:
+			isConst = isConst || strings.HasPrefix(word, "__const") || word == "const"
+		case word == "__attribute__" || word == "__attribute" || word == "__declspec":
+			p.attributes()
+			continue
+		case typeWords[word] || isUnsupportedFloat(word):
+			words[word]++
+		case word == "struct" || word == "union":
+			if s.t != nil {
+				p.fail("more than one type")
+			}
+			p.next()
+			s.t = p.record(word == "union")
+			continue
+		case word == "enum":
+			if s.t != nil {
+				p.fail("more than one type")
+			}
+			p.next()
+			s.t = p.enum()
+			continue
+		case word == "typeof" || word == "__typeof__" || word == "__typeof" || word == "_Atomic" && p.peekAt(1).is("("):
+			p.next()
+			p.skipBalanced()
+			s.t = &Type{Kind: Unsupported, Spelling: word}
+			continue
+		case word == "_Atomic":
+		default:
+			typedef, isTypedef := p.header.Typedefs[word]
+			if !isTypedef || s.t != nil || len(words) > 0 {
+				return p.finishSpecifiers(s, words, isConst)
+			}
+			t := *typedef
+			t.Typedef = word
+			s.t = &t
+		}
+		p.next()
+	}
+	return p.finishSpecifiers(s, words, isConst)
+}
+
+// finishSpecifiers builds the type of the words in the specifiers of a
+// declaration
+func (p *parser) finishSpecifiers(s specifiers, words map[string]int, isConst bool) specifiers {
+	if s.t != nil && len(words) > 0 {
+		p.fail("more than one type")
+	}
+	if s.t == nil {
+		s.t = wordsType(words)
+		if s.t == nil {
+			p.fail("expected a type, found %q", p.peek().text)
+		}
+	}
+	if isConst {
+		t := *s.t
+		t.Const = true
+		s.t = &t
+	}
+	return s
+}
+
+// wordsType returns the type the words of a type are, or nil if there are
+// none
+func wordsType(words map[string]int) *Type {
+	unsigned := words["unsigned"] > 0
+	pick := func(signed, unsignedKind Kind) *Type {
+		if unsigned {
+			return &Type{Kind: unsignedKind}
+		}
+		return &Type{Kind: signed}
+	}
+	for word := range words {
+		if isUnsupportedFloat(word) || word == "__float128" || word == "__float80" || word == "__ibm128" || word == "__fp16" || word == "__bf16" {
+			return &Type{Kind: Unsupported, Spelling: word}
+		}
+	}
+	switch {
+	case words["_Complex"] > 0 || words["__complex__"] > 0:
+		return &Type{Kind: Unsupported, Spelling: "_Complex"}
+	case words["__builtin_va_list"] > 0:
+		return &Type{Kind: Unsupported, Spelling: "va_list"}
+	case words["void"] > 0:
+		return &Type{Kind: Void}
+	case words["_Bool"] > 0 || words["bool"] > 0:
+		return &Type{Kind: Bool}
+	case words["char"] > 0:
+		if words["signed"] > 0 {
+			return &Type{Kind: SChar}
+		}
+		return pick(Char, UChar)
+	case words["short"] > 0:
+		return pick(Short, UShort)
+	case words["double"] > 0 && words["long"] > 0:
+		return &Type{Kind: LongDouble}
+	case words["double"] > 0:
+		return &Type{Kind: Double}
+	case words["float"] > 0:
+		return &Type{Kind: Float}
+	case words["__int128"] > 0 || words["__int128_t"] > 0:
+		return pick(Int128, UInt128)
+	case words["__uint128_t"] > 0:
+		return &Type{Kind: UInt128}
+	case words["long"] > 1:
+		return pick(LongLong, ULongLong)
+	case words["long"] > 0:
+		return pick(Long, ULong)
+	case words["int"] > 0 || words["signed"] > 0 || unsigned:
+		return pick(Int, UInt)
+	}
+	return nil
+}
+
+// record parses the tag and body of a struct or union after its keyword
+func (p *parser) record(union bool) *Type {
+	p.attributes()
+	kind := Struct
+	if union {
+		kind = Union
+	}
+	tag := ""
+	if p.at(tokIdent) {
+		tag = p.next().text
+	}
+	p.attributes()
+	if tag == "" && !p.peek().is("{") {
+		p.fail("expected a struct tag or body")
+	}
+
+	key := fmt.Sprintf("%s %s", kind, tag)
+	rec, found := p.records[key]
+	if !found || tag == "" {
+		rec = &Record{Tag: tag, Union: union, File: p.peek().file}
+		p.header.Records = append(p.header.Records, rec)
+		if tag != "" {
+			p.records[key] = rec
+		}
+	}
+	if p.accept("{") {
+		rec.Fields = p.fields()
+		rec.Defined = true
+		rec.File = p.tokens[p.pos-1].file
+		p.attributes()
+	}
+	return &Type{Kind: kind, Record: rec}
+}
+
+// fields parses the fields of a struct or union, up to its closing brace
+func (p *parser) fields() []Field {
+	fields := make([]Field, 0)
+	for !p.accept("}") {
+		if p.at(tokEOF) {
+			p.fail("unexpected end of the header in a struct")
+		}
+		if p.accept(";") {
+			continue
+		}
+		if p.accept("_Static_assert") || p.accept("static_assert") {
+			p.skipBalanced()
+			p.expect(";")
+			continue
+		}
+		s := p.specifiers()
+		// an anonymous struct or union, whose fields are fields of this one
+		if p.accept(";") {
+			fields = append(fields, Field{Type: s.t, Bits: -1})
+			continue
+		}
+		for {
+			field := Field{Bits: -1}
+			if !p.peek().is(":") {
+				field.Name, field.Type = p.declarator(s.t)
+			} else {
+				field.Type = s.t
+			}
+			if p.accept(":") {
+				bits, err := p.evaluate(p.collect(",", ";", "}"))
+				if err != nil {
+					p.fail("%s", err)
+				}
+				field.Bits = bits.v
+			}
+			p.attributes()
+			fields = append(fields, field)
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(";")
+	}
+	return fields
+}
+
+// enum parses the tag and body of an enum after its keyword, defining its
+// constants
+func (p *parser) enum() *Type {
+	p.attributes()
+	if p.at(tokIdent) {
+		p.next()
+	}
+	p.attributes()
+	// the type of the constants, in c23 and as an extension
+	if p.accept(":") {
+		p.specifiers()
+	}
+	if !p.accept("{") {
+		return &Type{Kind: Enum}
+	}
+	next := value{}
+	for !p.accept("}") {
+		if !p.at(tokIdent) {
+			p.fail("expected an enum constant, found %q", p.peek().text)
+		}
+		tok := p.next()
+		p.attributes()
+		if p.accept("=") {
+			v, err := p.evaluate(p.collect(",", "}"))
+			if err != nil {
+				p.fail("%s", err)
+			}
+			next = v
+		}
+		c := &Constant{Name: tok.text, Value: next.v, Unsigned: next.unsigned, File: tok.file}
+		p.enums[c.Name] = c
+		p.header.Constants = append(p.header.Constants, c)
+		next.v++
+		if !p.accept(",") {
+			p.expect("}")
+			break
+		}
+	}
+	p.attributes()
+	return &Type{Kind: Enum}
+}
+
+// declarator parses a declarator of the type base, returning the name it
+// declares and its type. Abstract declarators, like those of unnamed
+// parameters, declare an empty name.
+func (p *parser) declarator(base *Type) (string, *Type) {
+	p.attributes()
+	for p.accept("*") || p.accept("^") {
+		base = &Type{Kind: Pointer, Elem: base}
+		for p.at(tokIdent) && (qualifiers[p.peek().text] || p.peek().is("_Atomic")) {
+			if tok := p.next(); strings.HasPrefix(tok.text, "__const") || tok.text == "const" {
+				base.Const = true
+			}
+		}
+		p.attributes()
+	}
+
+	if p.peek().is("(") && p.nestedDeclarator() {
+		// the declarator in the brackets applies to the type made by what
+		// follows them, as in `int (*f)(int)`, so they are parsed after it
+		p.next()
+		inner := p.pos
+		p.pos--
+		p.skipBalanced()
+		t := p.suffixes(base)
+		end := p.pos
+		p.pos = inner
+		name, t := p.declarator(t)
+		p.expect(")")
+		p.pos = end
+		return name, t
+	}
+
+	name := ""
+	if p.at(tokIdent) && !p.startsType(p.peek()) {
+		name = p.next().text
+	}
+	p.attributes()
+	return name, p.suffixes(base)
+}
+
+// nestedDeclarator returns if the ( the parser is at starts a declarator
+// in brackets, rather than the parameters of a function
+func (p *parser) nestedDeclarator() bool {
+	tok := p.peekAt(1)
+	switch {
+	case tok.is("*") || tok.is("^") || tok.is("(") || tok.is("["):
+		return true
+	case tok.is("__attribute__") || tok.is("__attribute"):
+		return true
+	case tok.kind == tokIdent:
+		return !p.startsType(tok)
+	}
+	return false
+}
+
+// suffixes parses the array lengths and function parameters after the
+// name of a declarator
+func (p *parser) suffixes(base *Type) *Type {
+	switch {
+	case p.accept("["):
+		for p.accept("static") || p.at(tokIdent) && qualifiers[p.peek().text] {
+			p.next()
+		}
+		length := int64(-1)
+		if p.accept("*") {
+			p.expect("]")
+		} else if !p.accept("]") {
+			// the length of a parameter can be another parameter, and so
+			// isn't always constant
+			if v, err := p.evaluate(p.collect("]")); err == nil {
+				length = v.v
+			}
+			p.expect("]")
+		}
+		return &Type{Kind: Array, Elem: p.suffixes(base), Len: length}
+	case p.accept("("):
+		t := &Type{Kind: Func}
+		t.Params, t.Variadic = p.params()
+		t.Elem = p.suffixes(base)
+		return t
+	}
+	return base
+}
+
+// params parses the parameters of a function, up to the closing bracket
+func (p *parser) params() ([]Param, bool) {
+	params := make([]Param, 0)
+	if p.accept(")") {
+		return params, false
+	}
+	if p.peek().is("void") && p.peekAt(1).is(")") {
+		p.next()
+		p.next()
+		return params, false
+	}
+	for {
+		if p.accept("...") {
+			p.expect(")")
+			return params, true
+		}
+		s := p.specifiers()
+		name, t := p.declarator(s.t)
+		p.attributes()
+		// arrays and functions are passed as pointers to them
+		switch t.Kind {
+		case Array:
+			t = &Type{Kind: Pointer, Elem: t.Elem, Const: t.Const}
+		case Func:
+			t = &Type{Kind: Pointer, Elem: t}
+		}
+		params = append(params, Param{name, t})
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(")")
+	return params, false
+}
+
+// defineMacros adds the integer valued macros defined in the headers to
+// their constants. Macros that are something other than an integer, like
+// strings and expressions that call functions, are left out.
+func (p *parser) defineMacros() {
+	for _, name := range p.macroOrder {
+		m, defined := p.macros[name]
+		if !defined || m.funcLike || len(m.body) == 0 || strings.HasPrefix(name, "_") || !p.files[m.file] {
+			continue
+		}
+		if _, isEnum := p.enums[name]; isEnum {
+			continue
+		}
+		v, err := p.evaluate(m.body)
+		if err != nil {
+			continue
+		}
+		p.header.Constants = append(p.header.Constants, &Constant{Name: name, Value: v.v, Unsigned: v.unsigned, File: m.file})
+	}
+}
@@ -0,0 +1,188 @@
+// Package cheader parses the declarations of preprocessed c headers, so
+// geode can generate bindings to a c library from its headers instead of
+// them being written out by hand.
+//
+// The input is the output of `cc -E -dD`, which keeps the #define lines
+// of the macros defined while preprocessing, and the line markers saying
+// which file every declaration came from. Only declarations are parsed:
+// the bodies of inline functions are skipped, as are declarations the
+// parser doesn't understand, so an unusual declaration in a header loses
+// only itself.
+package cheader
+
+import "fmt"
+
+// Kind is the kind of a c type
+type Kind int
+
+// The kinds of c types
+const (
+	Void Kind = iota
+	Bool
+	Char
+	SChar
+	UChar
+	Short
+	UShort
+	Int
+	UInt
+	Long
+	ULong
+	LongLong
+	ULongLong
+	Int128
+	UInt128
+	Float
+	Double
+	LongDouble
+	Pointer
+	Array
+	Func
+	Struct
+	Union
+	Enum
+	// Unsupported is every type geode has no equivalent of, like _Complex,
+	// va_list and typeof
+	Unsupported
+)
+
+var kindNames = map[Kind]string{
+	Void:        "void",
+	Bool:        "_Bool",
+	Char:        "char",
+	SChar:       "signed char",
+	UChar:       "unsigned char",
+	Short:       "short",
+	UShort:      "unsigned short",
+	Int:         "int",
+	UInt:        "unsigned int",
+	Long:        "long",
+	ULong:       "unsigned long",
+	LongLong:    "long long",
+	ULongLong:   "unsigned long long",
+	Int128:      "__int128",
+	UInt128:     "unsigned __int128",
+	Float:       "float",
+	Double:      "double",
+	LongDouble:  "long double",
+	Pointer:     "pointer",
+	Array:       "array",
+	Func:        "function",
+	Struct:      "struct",
+	Union:       "union",
+	Enum:        "enum",
+	Unsupported: "unsupported",
+}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Integer returns if a kind is an integer type
+func (k Kind) Integer() bool {
+	return k >= Bool && k <= UInt128 || k == Enum
+}
+
+// Type is a c type
+type Type struct {
+	Kind  Kind
+	Const bool
+	// Elem is what a pointer points to, the element of an array and what
+	// a function returns
+	Elem *Type
+	// Len is the length of an array, or -1 if it isn't given
+	Len int64
+	// Params are the parameters of a function. Variadic functions take
+	// more arguments after them.
+	Params   []Param
+	Variadic bool
+	// Record is the struct or union of a struct or union type
+	Record *Record
+	// Typedef is the name of the typedef the type was named by, if it was
+	Typedef string
+	// Spelling is what an unsupported type was written as
+	Spelling string
+}
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case Pointer:
+		return t.Elem.String() + "*"
+	case Array:
+		if t.Len < 0 {
+			return t.Elem.String() + "[]"
+		}
+		return fmt.Sprintf("%s[%d]", t.Elem, t.Len)
+	case Func:
+		return fmt.Sprintf("%s(...)", t.Elem)
+	case Struct, Union:
+		return fmt.Sprintf("%s %s", t.Kind, t.Record.Tag)
+	case Unsupported:
+		return t.Spelling
+	}
+	return t.Kind.String()
+}
+
+// Param is a parameter of a function. Parameters declared without a name
+// have an empty one.
+type Param struct {
+	Name string
+	Type *Type
+}
+
+// Record is a struct or union. Every struct type with the same tag shares
+// a record, which is filled in once its body is declared.
+type Record struct {
+	Tag   string
+	Union bool
+	// Defined is set once the body of the record is declared. A record
+	// that is only ever declared is opaque.
+	Defined bool
+	Fields  []Field
+	// Typedefs are the typedefs naming the record, which a record without
+	// a tag is only known by
+	Typedefs []string
+	// File is the header the record was declared in
+	File string
+}
+
+// Field is a field of a record
+type Field struct {
+	Name string
+	Type *Type
+	// Bits is the width of a bitfield, or -1 if the field isn't one
+	Bits int64
+}
+
+// Function is a function declared by a header
+type Function struct {
+	Name string
+	Type *Type
+	File string
+	// Label is the symbol the function was renamed to with an asm label,
+	// if it was
+	Label string
+}
+
+// Constant is an integer constant, declared by an enum or #define
+type Constant struct {
+	Name  string
+	Value int64
+	// Unsigned is set for constants whose value is unsigned
+	Unsigned bool
+	File     string
+}
+
+// Header is every declaration in a preprocessed header
+type Header struct {
+	Functions []*Function
+	// Records are the structs and unions, in the order they were first
+	// named
+	Records []*Record
+	// Constants are the enum constants and the integer valued macros
+	// defined in the headers, in the order they were defined
+	Constants []*Constant
+	// Typedefs are the types named by typedefs
+	Typedefs map[string]*Type
+	// Files are the headers that were included, in the order they were
+	// first included
+	Files []string
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/ast"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// CompileCommand is an entry of a compilation database. The fields are
+// those of clang's compile_commands.json.
+type CompileCommand struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Arguments []string `json:"arguments"`
+	Output    string   `json:"output"`
+}
+
+// CompDB prints the compilation database of a context. Every geode source
+// is compiled by the same `geode build` command, and every c source by the
+// command the toolchain compiles it with.
+func (c *Context) CompDB(buildDir string) {
+	program := c.Parse()
+	toolchain := program.Toolchain
+
+	dir, err := os.Getwd()
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	output, _ := filepath.Abs(c.binaryPath())
+
+	db := make([]CompileCommand, 0, len(program.ParsedFiles)+len(program.CLinkages))
+	build := c.buildCommand(toolchain)
+	for _, file := range program.ParsedFiles {
+		db = append(db, CompileCommand{dir, file, build, output})
+	}
+	for _, file := range program.CLinkages {
+		obj := ast.CObjectPath(buildDir, file)
+		db = append(db, CompileCommand{dir, file, toolchain.CompileCCommand(file, obj), obj})
+	}
+
+	out, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	fmt.Println(string(out))
+}
+
+// buildCommand returns the `geode build` command that builds a context
+// with the flags this compiler was run with that change the output
+func (c *Context) buildCommand(toolchain ast.Toolchain) []string {
+	command := []string{"geode", "build"}
+
+	target := toolchain.Target()
+	if target == "" {
+		target = c.Target.Triple
+	}
+	command = append(command, "--target", target, "--toolchain", toolchain.Name())
+	if *arg.Optimize != "0" {
+		command = append(command, "-O", *arg.Optimize)
+	}
+
+	flags := []struct {
+		name string
+		set  bool
+	}{
+		{"--no-runtime", *arg.DisableRuntime},
+		{"--debug-alloc", *arg.DebugAlloc},
+		{"--no-dynamic-strings", *arg.DisableStringDataCopy},
+		{"--opaque-pointers", *arg.OpaquePointers},
+		{"--bitcode", *arg.EmitBitcode},
+		{"--direct-obj", *arg.DirectObject},
+		{"--trimpath", *arg.TrimPath},
+		{"--coverage", *arg.Coverage},
+		{"--bounds-checks", *arg.BoundsChecks},
+		{"--arc", *arg.ARC},
+		{"--debug", *arg.EnableDebug},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			command = append(command, flag.name)
+		}
+	}
+	if *arg.ClangFlags != "" {
+		command = append(command, "--clang-flags", *arg.ClangFlags)
+	}
+	if *arg.Library != "" {
+		command = append(command, "--lib", *arg.Library)
+	}
+	for _, dir := range *arg.SearchPaths {
+		command = append(command, "-I", dir)
+	}
+
+	return append(command, "-o", c.Output, c.Input)
+}
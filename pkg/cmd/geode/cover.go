@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// coverageHeader is the first line of the counts a program built with
+// --coverage writes
+const coverageHeader = "geode coverage"
+
+// CoverCMD prints how many of the lines of each file a program built with
+// --coverage ran, from the counts it wrote when it exited. With --annotate,
+// every file is printed with the number of times each line ran beside it,
+// like gcov does.
+func CoverCMD() {
+	files, err := readCoverage(*arg.CoverCounts)
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lines := files[name]
+		if *arg.CoverAnnotate {
+			if err := annotateCoverage(name, lines); err != nil {
+				log.Fatal("%s\n", err)
+			}
+			continue
+		}
+		ran := 0
+		for _, count := range lines {
+			if count > 0 {
+				ran++
+			}
+		}
+		fmt.Printf("%s: %d of %d lines ran (%.1f%%)\n", name, ran, len(lines), 100*float64(ran)/float64(len(lines)))
+	}
+}
+
+// readCoverage reads the counts a program wrote into the number of times
+// each line of each file ran. Lines that are in more than one basic block,
+// like the header of a for loop, ran as many times as the block that ran
+// the most.
+func readCoverage(path string) (map[string]map[int]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	files := make(map[string]map[int]int64)
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() || scanner.Text() != coverageHeader {
+		return nil, fmt.Errorf("%s is not written by a program built with --coverage", path)
+	}
+	for n := 2; scanner.Scan(); n++ {
+		pos, count, err := parseCoverageLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, n, err)
+		}
+		sep := strings.LastIndex(pos, ":")
+		line, err := strconv.Atoi(pos[sep+1:])
+		if sep < 0 || err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid position %q", path, n, pos)
+		}
+		name := pos[:sep]
+		if files[name] == nil {
+			files[name] = make(map[int]int64)
+		}
+		if old, found := files[name][line]; !found || count > old {
+			files[name][line] = count
+		}
+	}
+	return files, scanner.Err()
+}
+
+// parseCoverageLine splits a line of counts into the position and count
+func parseCoverageLine(text string) (string, int64, error) {
+	sep := strings.LastIndex(text, " ")
+	if sep < 0 {
+		return "", 0, fmt.Errorf("expected a position and a count, not %q", text)
+	}
+	count, err := strconv.ParseInt(text[sep+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid count %q", text[sep+1:])
+	}
+	return text[:sep], count, nil
+}
+
+// annotateCoverage prints a file with the number of times each line ran.
+// Lines that never ran are marked with #####, and lines with no code in
+// them with -.
+func annotateCoverage(name string, lines map[int]int64) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Printf("%9s:%5d:Source:%s\n", "-", 0, name)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		mark := "-"
+		if count, found := lines[n]; found {
+			mark = strconv.FormatInt(count, 10)
+			if count == 0 {
+				mark = "#####"
+			}
+		}
+		fmt.Printf("%9s:%5d:%s\n", mark, n, scanner.Text())
+	}
+	return scanner.Err()
+}
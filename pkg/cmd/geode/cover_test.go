@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCoverageLine(t *testing.T) {
+	tests := []struct {
+		text  string
+		pos   string
+		count int64
+		err   bool
+	}{
+		{"main.g:4 1", "main.g:4", 1, false},
+		// paths can have spaces in them
+		{"my project/main.g:12 300", "my project/main.g:12", 300, false},
+		{"main.g:4", "", 0, true},
+		{"main.g:4 many", "", 0, true},
+	}
+	for _, test := range tests {
+		pos, count, err := parseCoverageLine(test.text)
+		if (err != nil) != test.err {
+			t.Errorf("parseCoverageLine(%q): got error %v", test.text, err)
+			continue
+		}
+		if pos != test.pos || count != test.count {
+			t.Errorf("parseCoverageLine(%q) = %q, %d, want %q, %d", test.text, pos, count, test.pos, test.count)
+		}
+	}
+}
+
+func TestReadCoverage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want map[string]map[int]int64
+		err  string
+	}{
+		{
+			"counts",
+			"geode coverage\nmain.g:4 1\nmain.g:5 1\nmain.g:5 4\nlib/util.g:2 0\n",
+			// a line in more than one block ran as often as the one that ran most
+			map[string]map[int]int64{"main.g": {4: 1, 5: 4}, "lib/util.g": {2: 0}},
+			"",
+		},
+		{"empty", "geode coverage\n", map[string]map[int]int64{}, ""},
+		{"no header", "main.g:4 1\n", nil, "is not written by a program built with --coverage"},
+		{"no line", "geode coverage\nmain.g 1\n", nil, `:2: invalid position "main.g"`},
+		{"no count", "geode coverage\nmain.g:4 1\nmain.g:5\n", nil, `:3: expected a position and a count, not "main.g:5"`},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "geode.cov")
+		if err := os.WriteFile(path, []byte(test.text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readCoverage(path)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want one with %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/mangle"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// DemangleCMD prints the geode signature of every symbol passed to it. With
+// no symbols, it works like c++filt instead: stdin is copied to stdout with
+// every mangled name replaced, so linker errors and profiles can be piped
+// through it.
+func DemangleCMD() {
+	symbols := *arg.DemangleSymbols
+	if len(symbols) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			fmt.Println(mangle.DemangleText(scanner.Text()))
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatal("Failed to read stdin: %s\n", err)
+		}
+		return
+	}
+
+	failed := false
+	for _, sym := range symbols {
+		s, err := mangle.Demangle(sym)
+		if err != nil {
+			log.Error("%s\n", err)
+			failed = true
+			continue
+		}
+		fmt.Println(s)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"syscall"
@@ -15,7 +17,6 @@ import (
 	"github.com/geode-lang/geode/pkg/info"
 	"github.com/geode-lang/geode/pkg/pkg"
 	"github.com/geode-lang/geode/pkg/util"
-	"github.com/geode-lang/geode/pkg/util/color"
 	"github.com/geode-lang/geode/pkg/util/log"
 )
 
@@ -40,36 +41,44 @@ func main() {
 	buildDir := path.Join(home, ".geode/build/")
 
 	log.PrintVerbose = *arg.PrintVerbose
-
-	clangVersion, clangError := util.RunCommand("clang", "-v")
-	if clangError != nil {
-		log.Fatal("Unable to find a clang install in your path. Please install clang and add it to your path\n")
+	applyManifest(command)
+	util.TrimPaths = *arg.TrimPath
+	if util.TrimPaths {
+		useReproducibleTimestamps()
+	}
+	// demangling and coverage reports don't need a compiler, so they
+	// shouldn't need clang
+	switch command {
+	case arg.DemangleCMD.FullCommand():
+		DemangleCMD()
+		os.Exit(0)
+	case arg.CoverCMD.FullCommand():
+		CoverCMD()
+		os.Exit(0)
 	}
 
-	clangVersionLines := strings.Split(string(clangVersion), "\n")
-	targetTripple := ""
-
-	for _, line := range clangVersionLines {
-		if strings.HasPrefix(line, "Target: ") {
-			targetTripple = strings.Replace(line, "Target: ", "", 1)
-		}
+	if *arg.Library != "" && command != arg.BuildCMD.FullCommand() {
+		log.Fatal("--lib can only be used with geode build\n")
 	}
 
-	log.Verbose("Clang Version: %s\n", clangVersion)
+	target := resolveTarget()
+	if *arg.Coverage && target.Freestanding() {
+		log.Fatal("--coverage needs a c library to write the counts, which %s doesn't have\n", target.Triple)
+	}
 	log.Verbose("Building to %s...\n", buildDir)
 
 	switch command {
 	case arg.BuildCMD.FullCommand():
 		log.Timed("Compilation", func() {
 			context := NewContext(*arg.BuildInput, *arg.BuildOutput)
-			context.TargetTripple = targetTripple
+			context.Target = target
 			context.Build(buildDir)
 		})
 
 	case arg.RunCMD.FullCommand():
 		out := path.Join(buildDir, "a.out")
 		context := NewContext(*arg.RunInput, out)
-		context.TargetTripple = targetTripple
+		context.Target = target
 		context.Build(buildDir)
 		context.Run(*arg.RunArgs, buildDir)
 
@@ -90,11 +99,17 @@ func main() {
 		pkg.HandleCommand()
 		os.Exit(0)
 
+	case arg.CompDBCMD.FullCommand():
+		context := NewContext(*arg.CompDBInput, *arg.BuildOutput)
+		context.Target = target
+		context.CompDB(buildDir)
+
 	case arg.InfoCMD.FullCommand():
 		log.Timed("information gathering", func() {
 			context := NewContext(*arg.InfoInput, "/tmp/geodeinfooutput")
 			*arg.DisableEmission = true
-			context.TargetTripple = targetTripple
+			info.Enabled = true
+			context.Target = target
 			context.Build(buildDir)
 			info.DumpJSON()
 		})
@@ -108,9 +123,12 @@ func main() {
 
 // Context contains information for this compilation
 type Context struct {
-	Input         string
-	Output        string
-	TargetTripple string
+	Input  string
+	Output string
+	// Target is the platform the context is built for
+	Target *ast.Target
+	// Library is the library the context is built into with --lib
+	Library *ast.Library
 }
 
 // NewContext constructs a new context and returns a pointer to it
@@ -124,13 +142,35 @@ func NewContext(in string, out string) *Context {
 	return res
 }
 
-// Build some context into a binary file
-func (c *Context) Build(buildDir string) {
-
+// Parse parses the sources of a context and everything they depend on
+// into a new program
+func (c *Context) Parse() *ast.Program {
 	program := ast.NewProgram()
+	// the target decides what the runtime links, so it is set first
+	if err := program.SetTarget(c.Target); err != nil {
+		log.Fatal("Invalid target %q: %s\n", c.Target.Triple, err)
+	}
+	// the toolchain is picked first too, as the c headers included with
+	// include_c are preprocessed by it
+	toolchain, err := ast.SelectToolchain(*arg.Toolchain, c.toolchainOptions())
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	program.Toolchain = toolchain
+	program.OpaquePointers = *arg.OpaquePointers
+	program.NoRuntime = *arg.DisableRuntime
+	if err := program.SetWarningFlags(*arg.Warnings); err != nil {
+		log.Fatal("%s\n", err)
+	}
 
-	if !*arg.DisableRuntime {
-		program.ParseDep("", "runtime")
+	for _, dir := range *arg.SearchPaths {
+		program.AddSearchPath(dir)
+	}
+
+	if !program.NoRuntime {
+		if err := program.ParseDep(context.Background(), "", "runtime"); err != nil {
+			program.Fail(err)
+		}
 	}
 
 	program.Entry = c.Input
@@ -140,33 +180,81 @@ func (c *Context) Build(buildDir string) {
 		os.Exit(-1)
 	}
 
-	program.ParsePath(c.Input)
-	program.TargetTripple = c.TargetTripple
+	if err := program.ParsePath(context.Background(), c.Input); err != nil {
+		program.Fail(err)
+	}
+	writeLock(program)
+	return program
+}
 
-	_, err := program.Congeal()
-	if err != nil {
-		log.Fatal("%s\n", err)
+// Build some context into a binary file
+func (c *Context) Build(buildDir string) {
+	program := c.Parse()
+
+	if *arg.EmitAST {
+		if err := program.WriteAST(os.Stdout); err != nil {
+			log.Fatal("Failed to write the ast: %s\n", err)
+		}
+		os.Exit(0)
 	}
 
-	options := ast.FunctionCompilationOptions{}
-	main, err := program.GetFunction("main", options)
+	_, err := program.Congeal(context.Background())
 	if err != nil {
-		fmt.Println(color.Red("Failed to Compile"))
-		fmt.Println(err)
-		os.Exit(1)
+		program.Fail(err)
+	}
+
+	// libraries are run by the program they are linked into
+	if *arg.Library == "" {
+		main, err := program.CompileEntrypoint()
+		if err != nil {
+			program.Fail(err)
+		}
+		if main == nil {
+			log.Fatal("No function `main` found in compilation.\n")
+		}
 	}
-	if main == nil {
-		log.Fatal("No function `main` found in compilation.\n")
+
+	if err := program.CompileExports(); err != nil {
+		program.Fail(err)
+	}
+
+	if *arg.Library != "" {
+		c.Library, err = program.CompileLibrary(ast.LibraryKind(*arg.Library))
+		if err != nil {
+			program.Fail(err)
+		}
+	}
+
+	if err := program.CompileCoverage(); err != nil {
+		program.Fail(err)
+	}
+
+	if err := program.CheckNils(); err != nil {
+		program.Fail(err)
+	}
+
+	// with -Werror the warnings are errors
+	if program.ReportDiagnostics() > 0 {
+		os.Exit(1)
 	}
 
 	// virt := vm.New(program.Module)
 
 	// virt.RunFunctionName("main")
 
+	program.Cleanup()
+
 	if *arg.ShowLLVM {
 		fmt.Println(program)
 	}
 
+	if *arg.EmitSymbols {
+		if err := program.WriteSymbols(os.Stdout); err != nil {
+			log.Fatal("Failed to write the symbols: %s\n", err)
+		}
+		os.Exit(0)
+	}
+
 	// // Construct a linker object
 	target := ast.BinaryTarget
 	if *arg.EmitASM {
@@ -176,10 +264,19 @@ func (c *Context) Build(buildDir string) {
 	linker := ast.NewLinker(*arg.BuildOutput)
 	linker.SetTarget(target)
 	linker.SetBuildDir(buildDir)
-	linker.SetOutput(c.Output)
-	linker.SetOptimize(*arg.Optimize)
+	linker.SetOutput(c.binaryPath())
+	linker.SetLibrary(c.Library != nil)
 
-	for _, clink := range program.CLinkages {
+	linker.SetToolchain(program.Toolchain)
+
+	linkages := program.CLinkages
+	if c.Library != nil {
+		linkages = c.Library.Linkages(program)
+		if err := c.Library.WriteInterface(c.libraryDir(), c.Target); err != nil {
+			log.Fatal("Failed to write the library interface: %s\n", err)
+		}
+	}
+	for _, clink := range linkages {
 		linker.AddObject(clink)
 	}
 
@@ -187,10 +284,126 @@ func (c *Context) Build(buildDir string) {
 		fmt.Println(program.Scope)
 	}
 
-	linker.AddObject(program.Emit(buildDir))
+	if *arg.DumpScopes {
+		program.Scope.GetRoot().Dump(os.Stdout)
+	}
+
+	if *arg.EmitDeps {
+		if err := program.WriteDepFile(c.Output+".d", c.binaryPath()); err != nil {
+			log.Fatal("Failed to write dependency file: %s\n", err)
+		}
+	}
+
+	obj, err := program.Emit(buildDir)
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	linker.AddObject(obj)
 	log.Timed("Linking", func() {
-		linker.Run()
+		err = linker.Run()
 	})
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+}
+
+// binaryPath returns the path the binary is linked to, which is given the
+// extension of the target if the output has none. Libraries are written
+// into their directory.
+func (c *Context) binaryPath() string {
+	if c.Library != nil {
+		return filepath.Join(c.libraryDir(), c.Library.FileName(c.Target))
+	}
+	if filepath.Ext(c.Output) == "" {
+		return c.Output + c.Target.Extension
+	}
+	return c.Output
+}
+
+// libraryDir returns the directory a library and its interface are written
+// to, which is named after its package unless an output is given
+func (c *Context) libraryDir() string {
+	if c.Output == arg.DefaultOutput {
+		return "lib" + c.Library.Name()
+	}
+	return c.Output
+}
+
+// resolveTarget returns the target to build for. Without --target, it is
+// the default of the toolchain, or the one the installed clang targets.
+// Triples clang targets that aren't in the registry are built for as a
+// generic 64-bit target, but ones given with --target have to be known.
+func resolveTarget() *ast.Target {
+	triple := *arg.Target
+	if triple == "" {
+		triple = ast.ToolchainTriple(*arg.Toolchain)
+	}
+	if triple != "" {
+		target, err := ast.LookupTarget(triple)
+		if err != nil {
+			log.Fatal("%s\n", err)
+		}
+		return target
+	}
+
+	clangVersion, clangError := util.RunCommand("clang", "-v")
+	if clangError != nil {
+		log.Fatal("Unable to find a clang install in your path. Please install clang and add it to your path\n")
+	}
+
+	clangVersionLines := strings.Split(string(clangVersion), "\n")
+
+	for _, line := range clangVersionLines {
+		if strings.HasPrefix(line, "Target: ") {
+			triple = strings.Replace(line, "Target: ", "", 1)
+		}
+	}
+
+	log.Verbose("Clang Version: %s\n", clangVersion)
+
+	target, err := ast.LookupTarget(triple)
+	if err != nil {
+		log.Verbose("%s, building for a generic 64-bit target\n", err)
+		return ast.GenericTarget(triple)
+	}
+	return target
+}
+
+// toolchainOptions returns the options to build the toolchain with from
+// the command line flags and the target
+func (c *Context) toolchainOptions() ast.ToolchainOptions {
+	flags := append([]string{}, c.Target.LinkFlags...)
+	if *arg.ClangFlags != "" {
+		flags = append(flags, strings.Split(*arg.ClangFlags, " ")...)
+	}
+	if project != nil {
+		flags = append(flags, project.LinkFlags...)
+	}
+	opts := ast.ToolchainOptions{
+		Target:       *arg.Target,
+		Optimize:     *arg.Optimize,
+		Debug:        *arg.EnableDebug,
+		Flags:        flags,
+		Freestanding: c.Target.Freestanding(),
+		Library:      ast.LibraryKind(*arg.Library),
+	}
+	if util.TrimPaths {
+		opts.PathPrefixMap = util.PathPrefixMap()
+	}
+	return opts
+}
+
+// useReproducibleTimestamps makes the tools the compiler runs write fixed
+// timestamps instead of the current time. SOURCE_DATE_EPOCH is what c's
+// __DATE__ and __TIME__ expand to, and ZERO_AR_DATE keeps the modification
+// times of object files out of archives and the debug maps of the darwin
+// linker. Values the user set themselves are kept.
+func useReproducibleTimestamps() {
+	for name, value := range map[string]string{"SOURCE_DATE_EPOCH": "0", "ZERO_AR_DATE": "1"} {
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+		}
+	}
 }
 
 // Run a context with a given set of arguments
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/geode-lang/geode/pkg/arg"
+	"github.com/geode-lang/geode/pkg/ast"
+	"github.com/geode-lang/geode/pkg/manifest"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// project is the manifest of the project being built, if it has one
+var project *manifest.Manifest
+
+// applyManifest reads the manifest of the project the input of a command
+// is in, and fills in the flags that weren't given from it. Flags on the
+// command line always win over the manifest.
+func applyManifest(command string) {
+	var input *string
+	switch command {
+	case arg.BuildCMD.FullCommand():
+		input = arg.BuildInput
+	case arg.RunCMD.FullCommand():
+		input = arg.RunInput
+	case arg.CompDBCMD.FullCommand():
+		input = arg.CompDBInput
+	case arg.InfoCMD.FullCommand():
+		input = arg.InfoInput
+	default:
+		return
+	}
+
+	dir := *input
+	if dir == "" {
+		dir = "."
+	} else if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	m, err := manifest.Find(dir)
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	if m == nil {
+		return
+	}
+	project = m
+	log.Verbose("Using the manifest of %s in %s\n", m.Name, m.Dir)
+
+	if *input == "" || *input == "." {
+		*input = m.EntryPath()
+	}
+	if *arg.BuildOutput == arg.DefaultOutput && m.OutputPath() != "" {
+		*arg.BuildOutput = m.OutputPath()
+	}
+	if *arg.Target == "" {
+		*arg.Target = m.Target
+	}
+	if *arg.Toolchain == "" {
+		*arg.Toolchain = m.Toolchain
+	}
+	if *arg.Optimize == "0" && m.Optimize != "" {
+		*arg.Optimize = m.Optimize
+	}
+	if *arg.Library == "" && command == arg.BuildCMD.FullCommand() {
+		*arg.Library = m.Lib
+	}
+	// warnings given with -W come after the manifest's, so they override
+	*arg.Warnings = append(append([]string{}, m.Warnings...), *arg.Warnings...)
+	ast.SetDependencies(m.DependencyPaths())
+
+	lock, err := m.ReadLock()
+	if err != nil {
+		log.Fatal("%s\n", err)
+	}
+	ast.SetDependencyVersions(m.DependencyVersions(), lock.Versions)
+}
+
+// writeLock locks the versions the dependencies of the project resolved to,
+// once every package is parsed
+func writeLock(program *ast.Program) {
+	if project == nil {
+		return
+	}
+	if err := project.WriteLock(program.ResolvedVersions()); err != nil {
+		log.Fatal("Failed to write the lock file: %s\n", err)
+	}
+}
@@ -29,11 +29,20 @@ import (
 
 // TestJob -
 type TestJob struct {
-	Name, sourcefile                         string
-	CompilerArgs, RunArgs                    []string
-	compilerError, RunStatus, CompilerStatus int
-	Input                                    string
-	compilerOutput, RunOutput                string
+	Name, sourcefile          string
+	CompilerArgs, RunArgs     []string
+	RunStatus, CompilerStatus int
+	Input                     string
+	// CompilerOutput is a part of what the compiler prints, like the error
+	// of a test that fails to compile
+	CompilerOutput, RunOutput string
+	// CompilerOutputs are more parts of what the compiler prints, for tests
+	// of more than one error
+	CompilerOutputs []string
+	// RunOutputs are parts of what the program prints, for output that
+	// changes from run to run, like addresses. RunOutput is only compared
+	// to all of it when there are none.
+	RunOutputs []string
 }
 
 type testResult struct {
@@ -83,7 +92,11 @@ func RunTests(testDirectory string) int {
 
 			dirs = append(dirs, file)
 		} else if strings.HasSuffix(file, ".g") {
-			files[dir] = append(files[dir], file)
+			// the packages a test includes are in directories of it with
+			// no test.toml
+			if _, err := os.Stat(filepath.Join(filepath.Dir(path), "test.toml")); err == nil {
+				files[dir] = append(files[dir], file)
+			}
 		}
 		return nil
 	})
@@ -115,8 +128,6 @@ func RunTests(testDirectory string) int {
 
 	results := make(chan testResult, len(jobs))
 
-	testRunCount := 0
-
 	util.RunCommand("geode", "clean")
 
 	go func() {
@@ -143,10 +154,12 @@ func RunTests(testDirectory string) int {
 			}
 			res.compilerOutput = outBuf.String()
 
+			// programs that fail to compile aren't run
 			if res.compilerError != 0 {
-				results <- res
 				res.RunStatus = -1
-				return
+				res.timetaken = time.Since(start)
+				results <- res
+				continue
 			}
 
 			// Run the test program
@@ -172,12 +185,8 @@ func RunTests(testDirectory string) int {
 
 			res.timetaken = elapsed
 			results <- res
-			testRunCount++
-
-			if testRunCount == len(jobs) {
-				close(results)
-			}
 		}
+		close(results)
 	}()
 
 	// Check results
@@ -194,14 +203,24 @@ func RunTests(testDirectory string) int {
 
 		// Check build errors
 
-		if (res.TestJob.compilerError == -1 && res.compilerError != res.TestJob.CompilerStatus) || (res.compilerError == res.TestJob.compilerError) {
+		if res.compilerError == res.TestJob.CompilerStatus {
 		} else {
 			fmt.Fprintf(errBuf, "CompilerStatus:\n")
-			fmt.Fprintf(errBuf, "Expected: %d\n", res.TestJob.compilerError)
-			fmt.Fprintf(errBuf, "Got:      %d\n", res.TestJob.CompilerStatus)
+			fmt.Fprintf(errBuf, "Expected: %d\n", res.TestJob.CompilerStatus)
+			fmt.Fprintf(errBuf, "Got:      %d\n", res.compilerError)
+			fmt.Fprintf(errBuf, "%s\n", res.compilerOutput)
 			failure = true
 		}
 
+		for _, expected := range append([]string{res.TestJob.CompilerOutput}, res.TestJob.CompilerOutputs...) {
+			if !strings.Contains(res.compilerOutput, expected) {
+				fmt.Fprintf(errBuf, "CompilerOutput:\n")
+				fmt.Fprintf(errBuf, "Expected: %q\n", expected)
+				fmt.Fprintf(errBuf, "Got:      %q\n", res.compilerOutput)
+				failure = true
+			}
+		}
+
 		// Check run errors
 		if res.RunStatus == res.TestJob.RunStatus {
 		} else {
@@ -211,8 +230,17 @@ func RunTests(testDirectory string) int {
 			failure = true
 		}
 
+		for _, expected := range res.TestJob.RunOutputs {
+			if !strings.Contains(res.RunOutput, expected) {
+				fmt.Fprintf(errBuf, "RunOutput:\n")
+				fmt.Fprintf(errBuf, "Expected: %q\n", expected)
+				fmt.Fprintf(errBuf, "Got:      %q\n", res.RunOutput)
+				failure = true
+			}
+		}
+
 		// Check run errors
-		if res.RunOutput == res.TestJob.RunOutput {
+		if len(res.TestJob.RunOutputs) > 0 || res.RunOutput == res.TestJob.RunOutput {
 		} else {
 
 			dmp := diffmatchpatch.New()
@@ -273,6 +301,10 @@ func runCommand(out io.Writer, input string, cmd string, args []string) (int, er
 	if err := command.Wait(); err != nil {
 		if exiterr, ok := err.(*exec.ExitError); ok {
 			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+				// a program killed by a signal has the status a shell gives it
+				if status.Signaled() {
+					return 128 + int(status.Signal()), nil
+				}
 				return status.ExitStatus(), nil
 			}
 		} else {
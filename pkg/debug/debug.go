@@ -0,0 +1,293 @@
+// Package debug builds the DWARF debug information of a module as llvm
+// metadata, which llvm turns into the debug sections gdb and lldb read.
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/geode-lang/geode/llvm/ir"
+	"github.com/geode-lang/geode/llvm/ir/constant"
+	"github.com/geode-lang/geode/llvm/ir/metadata"
+	"github.com/geode-lang/geode/llvm/ir/types"
+	"github.com/geode-lang/geode/llvm/ir/value"
+)
+
+// FileInfo is a position in a source file, and the debug scope it is in
+type FileInfo struct {
+	Line   int
+	Column int
+	Path   string
+	Scope  *metadata.Metadata
+}
+
+// Member is a field of a struct type
+type Member struct {
+	Name string
+	Type *metadata.Metadata
+	// Size and Offset are in bits
+	Size   int64
+	Offset int64
+}
+
+// Builder adds the debug information of a program to its module. Every
+// node is numbered in the order it is made and appended to the module.
+type Builder struct {
+	module    *ir.Module
+	unit      *metadata.Metadata
+	files     map[string]*metadata.Metadata
+	locations map[FileInfo]*metadata.Metadata
+	positions map[*metadata.Metadata]FileInfo
+	basics    map[string]*metadata.Metadata
+	declare   *ir.Function
+}
+
+// NewBuilder returns a builder for the debug information of a module,
+// whose compile unit is the file entry
+func NewBuilder(module *ir.Module, entry, producer string) *Builder {
+	b := &Builder{
+		module:    module,
+		files:     make(map[string]*metadata.Metadata),
+		locations: make(map[FileInfo]*metadata.Metadata),
+		positions: make(map[*metadata.Metadata]FileInfo),
+		basics:    make(map[string]*metadata.Metadata),
+	}
+
+	// there is no dwarf language for geode, and c is the closest to it
+	unit := metadata.NewSpecialized("DICompileUnit",
+		field("language", "DW_LANG_C99"),
+		field("file", b.File(entry).Ident()),
+		field("producer", quote(producer)),
+		field("isOptimized", "false"),
+		field("runtimeVersion", "0"),
+		field("emissionKind", "FullDebug"))
+	unit.Distinct = true
+	b.unit = b.add(unit)
+
+	flag := func(behavior int64, name string, val int64) *metadata.Metadata {
+		return b.add(&metadata.Metadata{Nodes: []metadata.Node{
+			constant.NewInt(behavior, types.I32),
+			&metadata.String{Val: name},
+			constant.NewInt(val, types.I32),
+		}})
+	}
+	// 7 takes the highest version of the modules being linked, and 2 warns
+	// if they differ
+	flags := []*metadata.Metadata{
+		flag(7, "Dwarf Version", 4),
+		flag(2, "Debug Info Version", 3),
+	}
+	module.NamedMetadata = append(module.NamedMetadata,
+		&metadata.Named{Name: "llvm.dbg.cu", Metadata: []*metadata.Metadata{b.unit}},
+		&metadata.Named{Name: "llvm.module.flags", Metadata: flags})
+	return b
+}
+
+// add numbers a node and appends it to the module
+func (b *Builder) add(md *metadata.Metadata) *metadata.Metadata {
+	md.ID = strconv.Itoa(len(b.module.Metadata))
+	b.module.Metadata = append(b.module.Metadata, md)
+	return md
+}
+
+// File returns the node of a source file
+func (b *Builder) File(path string) *metadata.Metadata {
+	if file, found := b.files[path]; found {
+		return file
+	}
+	file := b.add(metadata.NewSpecialized("DIFile",
+		field("filename", quote(filepath.Base(path))),
+		field("directory", quote(filepath.Dir(path)))))
+	b.files[path] = file
+	return file
+}
+
+// Subprogram returns the node of a function defined at a position, which
+// is the scope of everything in its body. It is attached to the function.
+func (b *Builder) Subprogram(fn *ir.Function, name string, pos FileInfo, ret *metadata.Metadata, params []*metadata.Metadata) *metadata.Metadata {
+	file := b.File(pos.Path)
+	signature := b.add(metadata.NewSpecialized("DISubroutineType",
+		field("types", tuple(append([]*metadata.Metadata{ret}, params...)))))
+	sp := metadata.NewSpecialized("DISubprogram",
+		field("name", quote(name)),
+		field("linkageName", quote(fn.Name)),
+		field("scope", file.Ident()),
+		field("file", file.Ident()),
+		field("line", strconv.Itoa(pos.Line)),
+		field("type", signature.Ident()),
+		field("scopeLine", strconv.Itoa(pos.Line)),
+		field("flags", "DIFlagPrototyped"),
+		field("spFlags", "DISPFlagDefinition"),
+		field("unit", b.unit.Ident()))
+	sp.Distinct = true
+	b.add(sp)
+	fn.Metadata["dbg"] = sp
+	return sp
+}
+
+// LexicalBlock returns the node of a block starting at a position, inside
+// the scope of the position
+func (b *Builder) LexicalBlock(pos FileInfo) *metadata.Metadata {
+	block := metadata.NewSpecialized("DILexicalBlock",
+		field("scope", pos.Scope.Ident()),
+		field("file", b.File(pos.Path).Ident()),
+		field("line", strconv.Itoa(pos.Line)),
+		field("column", strconv.Itoa(pos.Column)))
+	block.Distinct = true
+	return b.add(block)
+}
+
+// Location returns the node of a position, which instructions are
+// attached to with !dbg
+func (b *Builder) Location(pos FileInfo) *metadata.Metadata {
+	if loc, found := b.locations[pos]; found {
+		return loc
+	}
+	loc := b.add(metadata.NewSpecialized("DILocation",
+		field("line", strconv.Itoa(pos.Line)),
+		field("column", strconv.Itoa(pos.Column)),
+		field("scope", pos.Scope.Ident())))
+	b.locations[pos] = loc
+	b.positions[loc] = pos
+	return loc
+}
+
+// Position returns the position a location node was made for, if it was
+// made by the builder
+func (b *Builder) Position(loc *metadata.Metadata) (FileInfo, bool) {
+	pos, found := b.positions[loc]
+	return pos, found
+}
+
+// BasicType returns the node of a scalar type. The encoding is a dwarf
+// base type encoding, like DW_ATE_signed.
+func (b *Builder) BasicType(name string, bits int64, encoding string) *metadata.Metadata {
+	key := fmt.Sprintf("%s %d %s", name, bits, encoding)
+	if basic, found := b.basics[key]; found {
+		return basic
+	}
+	basic := b.add(metadata.NewSpecialized("DIBasicType",
+		field("name", quote(name)),
+		field("size", strconv.FormatInt(bits, 10)),
+		field("encoding", encoding)))
+	b.basics[key] = basic
+	return basic
+}
+
+// PointerType returns the node of a pointer. It points to anything until
+// what it points to is set with SetPointee, so structs that point to
+// themselves can refer to the node.
+func (b *Builder) PointerType(bits int64) *metadata.Metadata {
+	return b.add(metadata.NewSpecialized("DIDerivedType",
+		field("tag", "DW_TAG_pointer_type"),
+		field("size", strconv.FormatInt(bits, 10)),
+		field("baseType", "null")))
+}
+
+// SetPointee sets the type a pointer made by PointerType points to, or
+// anything if it is nil
+func (b *Builder) SetPointee(ptr, elem *metadata.Metadata) {
+	ptr.Fields[len(ptr.Fields)-1].Val = ident(elem)
+}
+
+// ArrayType returns the node of an array of count elements
+func (b *Builder) ArrayType(elem *metadata.Metadata, count, bits int64) *metadata.Metadata {
+	return b.add(metadata.NewSpecialized("DICompositeType",
+		field("tag", "DW_TAG_array_type"),
+		field("baseType", ident(elem)),
+		field("size", strconv.FormatInt(bits, 10)),
+		field("elements", fmt.Sprintf("!{!DISubrange(count: %d)}", count))))
+}
+
+// StructType returns the node of a struct. Its members are set with
+// SetMembers, so structs that point to themselves can refer to the node.
+func (b *Builder) StructType(name string, bits, align int64) *metadata.Metadata {
+	st := metadata.NewSpecialized("DICompositeType",
+		field("tag", "DW_TAG_structure_type"),
+		field("name", quote(name)),
+		field("size", strconv.FormatInt(bits, 10)),
+		field("align", strconv.FormatInt(align, 10)),
+		field("elements", "!{}"))
+	st.Distinct = true
+	return b.add(st)
+}
+
+// SetMembers sets the fields of a struct made by StructType
+func (b *Builder) SetMembers(st *metadata.Metadata, members []Member) {
+	elements := make([]*metadata.Metadata, len(members))
+	for i, m := range members {
+		elements[i] = b.add(metadata.NewSpecialized("DIDerivedType",
+			field("tag", "DW_TAG_member"),
+			field("name", quote(m.Name)),
+			field("scope", st.Ident()),
+			field("baseType", ident(m.Type)),
+			field("size", strconv.FormatInt(m.Size, 10)),
+			field("offset", strconv.FormatInt(m.Offset, 10))))
+	}
+	st.Fields[len(st.Fields)-1].Val = tuple(elements)
+}
+
+// LocalVariable returns the node of a variable declared at a position.
+// Parameters are numbered from 1 by arg, which is 0 for other variables.
+func (b *Builder) LocalVariable(name string, arg int, pos FileInfo, typ *metadata.Metadata) *metadata.Metadata {
+	fields := []metadata.Field{field("name", quote(name))}
+	if arg > 0 {
+		fields = append(fields, field("arg", strconv.Itoa(arg)))
+	}
+	fields = append(fields,
+		field("scope", pos.Scope.Ident()),
+		field("file", b.File(pos.Path).Ident()),
+		field("line", strconv.Itoa(pos.Line)),
+		field("type", ident(typ)))
+	return b.add(metadata.NewSpecialized("DILocalVariable", fields...))
+}
+
+// Declare appends a call to llvm.dbg.declare to a block, which tells the
+// debugger that a variable lives in the memory an alloca points to
+func (b *Builder) Declare(block *ir.BasicBlock, alloca value.Value, variable, loc *metadata.Metadata) *ir.InstCall {
+	if b.declare == nil {
+		b.declare = b.module.NewFunction("llvm.dbg.declare", types.Void,
+			ir.NewParam("", types.Metadata),
+			ir.NewParam("", types.Metadata),
+			ir.NewParam("", types.Metadata))
+	}
+	call := block.NewCall(b.declare,
+		&metadata.Value{X: alloca},
+		variable,
+		metadata.NewSpecialized("DIExpression"))
+	call.Metadata["dbg"] = loc
+	return call
+}
+
+func field(name, val string) metadata.Field {
+	return metadata.Field{Name: name, Val: val}
+}
+
+// quote returns a metadata string
+func quote(s string) string {
+	return (&metadata.String{Val: s}).Ident()[1:]
+}
+
+// ident returns the reference to a node, or null for nil
+func ident(md *metadata.Metadata) string {
+	if md == nil {
+		return "null"
+	}
+	return md.Ident()
+}
+
+// tuple returns a tuple of references to nodes
+func tuple(nodes []*metadata.Metadata) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString("!{")
+	for i, md := range nodes {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(ident(md))
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
@@ -0,0 +1,126 @@
+// Package fetch downloads the packages programs include from git
+// repositories, like include "github.com/user/lib". Repositories are cloned
+// into the cache directory once and built from there, so only the first
+// build needs the network. A ref can follow the path after an @, as in
+// "github.com/user/lib@v1.2.0", and a path after the repository includes a
+// package in it, as in "github.com/user/lib/json".
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/geode-lang/geode/pkg/util"
+	"github.com/geode-lang/geode/pkg/util/log"
+)
+
+// Remote is a package in a git repository
+type Remote struct {
+	// Repo is the repository without its scheme, like github.com/user/lib
+	Repo string
+	// Ref is the branch or tag that is cloned, the default branch if empty
+	Ref string
+	// Dir is the directory of the package in the repository
+	Dir string
+}
+
+// IsRemote returns if an include names a package in a repository. Those
+// start with a host, which has a dot in it, and its owner and repository.
+func IsRemote(path string) bool {
+	parts := strings.Split(path, "/")
+	return len(parts) >= 3 && !strings.HasPrefix(path, ".") && strings.Contains(parts[0], ".")
+}
+
+// Parse splits an include of a package in a repository into its parts
+func Parse(path string) (*Remote, error) {
+	if !IsRemote(path) {
+		return nil, fmt.Errorf("%q is not a repository, expected host/owner/repository", path)
+	}
+	r := &Remote{}
+	if at := strings.LastIndex(path, "@"); at >= 0 {
+		r.Ref = path[at+1:]
+		path = path[:at]
+		if r.Ref == "" {
+			return nil, fmt.Errorf("%q has an empty ref after @", path)
+		}
+	}
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, fmt.Errorf("invalid repository path %q", path)
+		}
+	}
+	r.Repo = strings.Join(parts[:3], "/")
+	r.Dir = filepath.Join(parts[3:]...)
+	return r, nil
+}
+
+// cacheDir returns the directory repositories are cloned into the src
+// directory of
+var cacheDir = util.GetCacheDir
+
+// URL returns the url the repository is cloned from
+func (r *Remote) URL() string {
+	return "https://" + r.Repo
+}
+
+// CacheDir returns the directory the repository is cloned into
+func (r *Remote) CacheDir() string {
+	ref := r.Ref
+	if ref == "" {
+		ref = "default"
+	}
+	return filepath.Join(cacheDir(), "src", r.Repo+"@"+ref)
+}
+
+// Fetch returns the directory of the package an include names, cloning its
+// repository into the cache first if it isn't there. Remove the cache
+// directory of a repository to fetch it again. A clone is stopped when the
+// context is done.
+func Fetch(ctx context.Context, path string) (string, error) {
+	r, err := Parse(path)
+	if err != nil {
+		return "", err
+	}
+	dir := r.CacheDir()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := r.clone(ctx, dir); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, r.Dir), nil
+}
+
+// clone clones the repository into a directory. It is cloned next to the
+// directory first and moved into it once it is done, so a clone that is
+// stopped half way, or cancelled, isn't mistaken for the repository.
+func (r *Remote) clone(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), ".clone")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if r.Ref != "" {
+		args = append(args, "--branch", r.Ref)
+	}
+	args = append(args, r.URL(), tmp)
+	log.Verbose("git %s\n", strings.Join(args, " "))
+	out, err := exec.CommandContext(ctx, "git", args...).CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clone %s: %s\n%s", r.URL(), err, out)
+	}
+	return os.Rename(tmp, dir)
+}
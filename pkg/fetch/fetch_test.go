@@ -0,0 +1,76 @@
+package fetch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path   string
+		remote bool
+		want   Remote
+		err    bool
+	}{
+		{"github.com/user/lib", true, Remote{"github.com/user/lib", "", ""}, false},
+		{"github.com/user/lib@v1.2.0", true, Remote{"github.com/user/lib", "v1.2.0", ""}, false},
+		{"github.com/user/lib/json", true, Remote{"github.com/user/lib", "", "json"}, false},
+		{"gitlab.com/user/lib/enc/json@main", true, Remote{"gitlab.com/user/lib", "main", "enc/json"}, false},
+		{"github.com/user/lib@", true, Remote{}, true},
+		{"github.com/user/../lib", true, Remote{}, true},
+		{"github.com/user//lib", true, Remote{}, true},
+		// packages on disk and in the standard library aren't remote
+		{"io", false, Remote{}, true},
+		{"vendor/user/lib", false, Remote{}, true},
+		{"./lib.d/user/lib", false, Remote{}, true},
+		{"github.com/user", false, Remote{}, true},
+	}
+	for _, test := range tests {
+		if got := IsRemote(test.path); got != test.remote {
+			t.Errorf("IsRemote(%q) = %v, want %v", test.path, got, test.remote)
+		}
+		r, err := Parse(test.path)
+		if (err != nil) != test.err {
+			t.Errorf("Parse(%q): got error %v", test.path, err)
+			continue
+		}
+		if err == nil && *r != test.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.path, *r, test.want)
+		}
+	}
+}
+
+// Repositories in the cache are built from there without cloning them
+func TestFetch(t *testing.T) {
+	cache := t.TempDir()
+	defer func(old func() string) { cacheDir = old }(cacheDir)
+	cacheDir = func() string { return cache }
+
+	cloned := filepath.Join(cache, "src", "example.com", "user", "lib@v1")
+	if err := os.MkdirAll(filepath.Join(cloned, "json"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := Fetch(context.Background(), "example.com/user/lib/json@v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(cloned, "json"); dir != want {
+		t.Errorf("got %s, want %s", dir, want)
+	}
+
+	// a clone that is stopped leaves nothing in the cache
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Fetch(ctx, "example.com/user/other"); err != context.Canceled {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(cache, "src", "example.com", "user"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("the cache has %d repositories, want the 1 that was there", len(entries))
+	}
+}
@@ -17,17 +17,27 @@ type context struct {
 // global info context
 var gic *context
 
+// Enabled is if tokens and nodes are recorded, which only the info command
+// shows
+var Enabled = false
+
 func init() {
 	gic = &context{}
 }
 
 // AddToken adds a token to the info context
 func AddToken(t Item) {
+	if !Enabled {
+		return
+	}
 	gic.tokens = append(gic.tokens, t)
 }
 
 // AddNode adds a node to the info context
 func AddNode(n Item) {
+	if !Enabled {
+		return
+	}
 	gic.nodes = append(gic.nodes, n)
 }
 
@@ -13,41 +13,67 @@ import (
 )
 
 var tokenTypeOverrides = map[string]TokenType{
-	"return":  TokReturn,
-	"if":      TokIf,
-	"else":    TokElse,
-	"for":     TokFor,
-	"while":   TokWhile,
-	"func":    TokFuncDefn,
-	"let":     TokLet,
-	"class":   TokClassDefn,
-	"include": TokDependency,
-	"link":    TokDependency,
-	"is":      TokNamespace,
-	"info":    TokInfo,
-	"as":      TokAs,
-	"true":    TokBool,
-	"false":   TokBool,
-	"nil":     TokNil,
-	"(":       TokLeftParen,
-	")":       TokRightParen,
-	"{":       TokLeftCurly,
-	"}":       TokRightCurly,
-	"[":       TokLeftBrace,
-	"]":       TokRightBrace,
-	"->":      TokRightArrow,
-	";":       TokSemiColon,
-	":":       TokNamespaceAccess,
-	"...":     TokElipsis,
-	".":       TokDot,
-	"?":       TokQuestionMark,
-
-	"<-": TokOper,
-	":=": TokOper,
-	"+=": TokOper,
-	"-=": TokOper,
-	"*=": TokOper,
-	"/=": TokOper,
+	"return":    TokReturn,
+	"defer":     TokDefer,
+	"with":      TokWith,
+	"try":       TokTry,
+	"catch":     TokCatch,
+	"throw":     TokThrow,
+	"pub":       TokPub,
+	"const":     TokConst,
+	"static":    TokStatic,
+	"if":        TokIf,
+	"else":      TokElse,
+	"match":     TokMatch,
+	"for":       TokFor,
+	"while":     TokWhile,
+	"func":      TokFuncDefn,
+	"let":       TokLet,
+	"class":     TokClassDefn,
+	"enum":      TokEnumDefn,
+	"union":     TokUnionDefn,
+	"interface": TokInterfaceDefn,
+	"include":   TokDependency,
+	"link":      TokDependency,
+	"include_c": TokDependency,
+	"is":        TokNamespace,
+	"info":      TokInfo,
+	"sizeof":    TokSizeof,
+	"alignof":   TokSizeof,
+	"new":       TokNew,
+	"spawn":     TokSpawn,
+	"as":        TokAs,
+	"true":      TokBool,
+	"false":     TokBool,
+	"nil":       TokNil,
+	"in":        TokIn,
+	"(":         TokLeftParen,
+	")":         TokRightParen,
+	"{":         TokLeftCurly,
+	"}":         TokRightCurly,
+	"[":         TokLeftBrace,
+	"]":         TokRightBrace,
+	"->":        TokRightArrow,
+	";":         TokSemiColon,
+	":":         TokNamespaceAccess,
+	"...":       TokElipsis,
+	".":         TokDot,
+	"?":         TokQuestionMark,
+
+	"<-":  TokOper,
+	":=":  TokOper,
+	"+=":  TokOper,
+	"-=":  TokOper,
+	"*=":  TokOper,
+	"/=":  TokOper,
+	"%=":  TokOper,
+	"<<=": TokOper,
+	">>=": TokOper,
+	"&=":  TokOper,
+	"|=":  TokOper,
+	"^=":  TokOper,
+	"++":  TokOper,
+	"--":  TokOper,
 }
 
 var tokenAliasOverrides = map[string]string{
@@ -63,7 +89,31 @@ var tokenAliasOverrides = map[string]string{
 }
 
 var defaultTypeNames = [...]string{
-	"bool", "byte", "short", "int", "long", "big", "large", "huge", "float", "string", "void",
+	"bool", "byte", "short", "int", "long", "rune", "i128", "big", "large", "huge", "float", "f32", "string", "void",
+	"u8", "u16", "u32", "u64", "u128",
+	"i8x16", "i16x8", "i32x4", "i32x8", "i64x2", "i64x4", "f32x4", "f32x8", "f64x2", "f64x4",
+}
+
+// genericTypeNames are the builtin generic types. They are only types when
+// their type arguments follow them, as in `vec<int>`, so the names can
+// still be used for other things
+var genericTypeNames = [...]string{
+	"vec",
+	"chan",
+}
+
+// IsReserved returns if a name is a keyword or the name of a builtin type,
+// which can't name anything else
+func IsReserved(name string) bool {
+	if _, keyword := tokenTypeOverrides[name]; keyword {
+		return true
+	}
+	for _, t := range defaultTypeNames {
+		if t == name {
+			return true
+		}
+	}
+	return false
 }
 
 func getTokenValueAlias(value string) string {
@@ -143,6 +193,13 @@ func (l *Lexer) emit(typ TokenType) {
 				}
 			}
 
+			for _, t := range genericTypeNames {
+				if t == tok.Value && strings.HasPrefix(l.input[l.pos:], "<") {
+					typ = TokType
+					break
+				}
+			}
+
 			if unicode.IsUpper(runes[0]) {
 				typ = TokType
 			}
@@ -253,12 +310,23 @@ func lexTopLevel(l *Lexer) stateFn {
 	case r == eof:
 		return nil
 
+	// a '-' only starts a number if a digit follows it, so `-abc` and
+	// `--x` aren't lexed as hex numbers
+	case r == '-' && !unicode.IsDigit(l.peek()):
+		l.backup()
+		return lexOperator
+
 	case strings.IndexRune("-0123456789", r) >= 0:
 		l.backup()
 		return lexNumber
 
 	case r == ':':
-		// l.backup()
+		// := is an operator, any other ':' is a symbol
+		if l.peek() == '=' {
+			l.next()
+			l.emit(TokOper)
+			return lexTopLevel
+		}
 		return lexSymbol
 
 	case isAlphaNumeric(r):
@@ -267,6 +335,8 @@ func lexTopLevel(l *Lexer) stateFn {
 
 	case r == '#':
 		return lexComment
+	case r == '@':
+		return lexAttribute
 
 	case isSpace(r):
 		l.backup()
@@ -323,9 +393,18 @@ func lexTopLevel(l *Lexer) stateFn {
 	return l.fatal("unrecognized character: %#U\n", r)
 }
 
-// fatal is just a statefn wrapper around log.Fatal
+// fatal ends the lexing with an error token at what couldn't be lexed,
+// whose value is the message. The parser reports it as a syntax error.
 func (l *Lexer) fatal(format string, args ...interface{}) stateFn {
-	log.Fatal(format, args...)
+	l.tokens = append(l.tokens, Token{
+		source: l.source,
+		Type:   TokError,
+		Value:  strings.TrimSpace(fmt.Sprintf(format, args...)),
+		Pos:    l.start,
+		EndPos: l.pos,
+		Line:   l.line,
+		Column: l.col,
+	})
 	return nil
 }
 
@@ -338,7 +417,7 @@ func lexIdentifer(l *Lexer) stateFn {
 				sColonCount++
 			}
 			if sColonCount > 1 {
-				l.emit(TokError)
+				return l.fatal("too many ':' in identifier %s", l.value())
 			}
 			// absorb
 		default:
@@ -364,9 +443,23 @@ func lexSymbol(l *Lexer) stateFn {
 	}
 }
 
+// lexAttribute lexes a declaration attribute like `@comptime`.
+// The value of the emitted token includes the leading '@'
+func lexAttribute(l *Lexer) stateFn {
+	l.acceptRunPredicate(isAlphaNumeric)
+	l.emit(TokAttribute)
+	return lexTopLevel
+}
+
 func lexNumber(l *Lexer) stateFn {
-	l.acceptRun("-0123456789.xabcdefABCDEF")
-	l.next()
+	l.acceptRunPredicate(func(r rune) bool {
+		// a number ends before a `..`, so `0..10` is a range
+		if r == '.' && l.peek() == '.' {
+			return false
+		}
+		// with underscores between digits and suffixes like u8 and f32
+		return strings.IndexRune("-0123456789._xabcdefABCDEFui", r) >= 0
+	})
 	// There is a chance that the numeric expression lexer will
 	// parse only a + or a - since it gets handled first in the list
 	// so if it is only a minus,
@@ -410,7 +503,7 @@ func lexOperator(l *Lexer) stateFn {
 	// of the maxiumum repeats of tokens. They will be a list
 	// of valid tokens in the language as repeats that aren't in
 	// this list must be invalid
-	finalRuns := []string{"...", "*", "*=", "&&"}
+	finalRuns := []string{"...", "*", "*=", "&&", "~"}
 
 	l.acceptRunPredicate(func(c rune) bool {
 		for _, run := range finalRuns {
@@ -471,7 +564,7 @@ func lexCharLiteral(l *Lexer) stateFn {
 // Helper Functions
 //
 
-const operators = "&\\*+-/%:!=<>≤≥≠.←|&^?"
+const operators = "&\\*+-/%:!=<>≤≥≠.←|&^?~"
 
 func isOperator(r rune) bool {
 	return strings.IndexRune(operators, r) >= 0
@@ -6,10 +6,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/geode-lang/geode/llvm/ir/metadata"
 	"github.com/geode-lang/geode/llvm/ir/types"
 	"github.com/geode-lang/geode/pkg/debug"
+	"github.com/geode-lang/geode/pkg/util"
 	"github.com/geode-lang/geode/pkg/util/color"
 )
 
@@ -45,6 +47,72 @@ func (t Token) String() string {
 	return fmt.Sprintf("%s(%q)", t.Type.String(), t.Value)
 }
 
+// SourcePath returns the path of the file a token is from, or an empty
+// string if it was made by the compiler
+func (t Token) SourcePath() string {
+	if t.source == nil {
+		return ""
+	}
+	return t.source.Path
+}
+
+// StartsLine reports if nothing, not even indentation, comes before a
+// token on its line
+func (t Token) StartsLine() bool {
+	if t.source == nil || t.Pos == 0 {
+		return t.Pos == 0
+	}
+	src := t.source.String()
+	return t.Pos <= len(src) && src[t.Pos-1] == '\n'
+}
+
+// Excerpt returns the line of source a token is on, with tabs expanded to
+// four spaces, and the columns of the line the token covers. A token that
+// runs onto the next line covers the rest of its first one.
+func (t Token) Excerpt() (line string, start, end int) {
+	if t.source == nil {
+		return "", 0, 0
+	}
+	src := t.source.String()
+	if t.Pos > len(src) {
+		return "", 0, 0
+	}
+	lineStart := strings.LastIndex(src[:t.Pos], "\n") + 1
+	lineEnd := len(src)
+	if i := strings.IndexByte(src[t.Pos:], '\n'); i >= 0 {
+		lineEnd = t.Pos + i
+	}
+	spanEnd := t.EndPos
+	if spanEnd > lineEnd || spanEnd < t.Pos {
+		spanEnd = lineEnd
+	}
+
+	width := func(s string) int {
+		return utf8.RuneCountInString(s) + 3*strings.Count(s, "\t")
+	}
+	line = strings.Replace(src[lineStart:lineEnd], "\t", "    ", -1)
+	start = width(src[lineStart:t.Pos])
+	end = start + width(src[t.Pos:spanEnd])
+	if end == start {
+		end = start + 1
+	}
+	return line, start, end
+}
+
+// StartColumn returns the column a token starts at on its line, counting
+// from 1 in runes, with a tab counting as one
+func (t Token) StartColumn() int {
+	if t.source == nil {
+		return 0
+	}
+	src := t.source.String()
+	if t.Pos > len(src) {
+		return 0
+	}
+	lineStart := strings.LastIndex(src[:t.Pos], "\n") + 1
+	return utf8.RuneCountInString(src[lineStart:t.Pos]) + 1
+}
+
 // FileInfo returns the file address of a token
 func (t Token) FileInfo() string {
 	p := filepath.Clean(t.source.Path)
@@ -104,18 +172,12 @@ func (t Token) InferType() (types.Type, interface{}) {
 	return nil, nil
 }
 
-// DebugFileInfo returns the debug.FileInfo for this token
-func (t *Token) DebugFileInfo() *debug.FileInfo {
-	info := &debug.FileInfo{}
-	info.Column = t.Column
-	info.Line = t.Line
-	info.Path = t.source.Path
-	return info
-}
-
-// DILocation returns the string DILocation for debugging of this token
-func (t *Token) DILocation(scope *metadata.Named) string {
-	info := t.DebugFileInfo()
-	info.Scope = scope
-	return debug.DILocation(info)
+// DebugFileInfo returns the position of this token in a debug scope
+func (t Token) DebugFileInfo(scope *metadata.Metadata) debug.FileInfo {
+	return debug.FileInfo{
+		Line:   t.Line,
+		Column: t.StartColumn(),
+		Path:   util.TrimPath(t.source.Path),
+		Scope:  scope,
+	}
 }
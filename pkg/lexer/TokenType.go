@@ -53,6 +53,9 @@ const (
 	TokLeftArrow
 
 	TokInfo
+	TokSizeof
+	TokNew
+	TokSpawn
 
 	TokCompoundAssignment
 
@@ -62,13 +65,26 @@ const (
 	TokWhile
 	TokIf
 	TokElse
+	TokMatch
 	TokReturn
+	TokDefer
+	TokWith
+	TokTry
+	TokCatch
+	TokThrow
+	TokPub
+	TokConst
+	TokStatic
 	TokFuncDefn
 	TokClassDefn
+	TokEnumDefn
+	TokUnionDefn
+	TokInterfaceDefn
 	TokNamespace
 	TokLet
 	TokAs
 	TokNil
+	TokIn
 
 	TokDependency
 
@@ -80,4 +96,6 @@ const (
 	TokSymbol
 
 	TokComment
+
+	TokAttribute
 )
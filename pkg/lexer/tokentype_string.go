@@ -4,9 +4,9 @@ package lexer
 
 import "strconv"
 
-const _TokenType_name = "TokErrorTokNoEmitTokWhitespaceTokCharTokStringTokNumberTokBoolTokDotTokElipsisTokOperTokNamespaceAccessTokOperatorStartTokStarTokPlusTokMinusTokDivTokExpTokLTTokLTETokGTTokGTETokOperatorEndTokSemiColonTokDefereferenceTokReferenceTokAssignmentTokEqualityTokRightParenTokLeftParenTokRightCurlyTokLeftCurlyTokRightBraceTokLeftBraceTokRightArrowTokLeftArrowTokInfoTokCompoundAssignmentTokQuestionMarkTokForTokWhileTokIfTokElseTokReturnTokFuncDefnTokClassDefnTokNamespaceTokLetTokAsTokNilTokDependencyTokTypeTokCommaTokIdentTokSymbolTokComment"
+const _TokenType_name = "TokErrorTokNoEmitTokWhitespaceTokCharTokStringTokNumberTokBoolTokDotTokElipsisTokOperTokNamespaceAccessTokOperatorStartTokStarTokPlusTokMinusTokDivTokExpTokLTTokLTETokGTTokGTETokOperatorEndTokSemiColonTokDefereferenceTokReferenceTokAssignmentTokEqualityTokRightParenTokLeftParenTokRightCurlyTokLeftCurlyTokRightBraceTokLeftBraceTokRightArrowTokLeftArrowTokInfoTokSizeofTokNewTokSpawnTokCompoundAssignmentTokQuestionMarkTokForTokWhileTokIfTokElseTokMatchTokReturnTokDeferTokWithTokTryTokCatchTokThrowTokPubTokConstTokStaticTokFuncDefnTokClassDefnTokEnumDefnTokUnionDefnTokInterfaceDefnTokNamespaceTokLetTokAsTokNilTokInTokDependencyTokTypeTokCommaTokIdentTokSymbolTokCommentTokAttribute"
 
-var _TokenType_index = [...]uint16{0, 8, 17, 30, 37, 46, 55, 62, 68, 78, 85, 103, 119, 126, 133, 141, 147, 153, 158, 164, 169, 175, 189, 201, 217, 229, 242, 253, 266, 278, 291, 303, 316, 328, 341, 353, 360, 381, 396, 402, 410, 415, 422, 431, 442, 454, 466, 472, 477, 483, 496, 503, 511, 519, 528, 538}
+var _TokenType_index = [...]uint16{0, 8, 17, 30, 37, 46, 55, 62, 68, 78, 85, 103, 119, 126, 133, 141, 147, 153, 158, 164, 169, 175, 189, 201, 217, 229, 242, 253, 266, 278, 291, 303, 316, 328, 341, 353, 360, 369, 375, 383, 404, 419, 425, 433, 438, 445, 453, 462, 470, 477, 483, 491, 499, 505, 513, 522, 533, 545, 556, 568, 584, 596, 602, 607, 613, 618, 631, 638, 646, 654, 663, 673, 685}
 
 func (i TokenType) String() string {
 	if i < 0 || i >= TokenType(len(_TokenType_index)-1) {
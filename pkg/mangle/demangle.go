@@ -0,0 +1,352 @@
+package mangle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Symbol is a demangled function or global variable
+type Symbol struct {
+	// Variable is true if the symbol is a global variable
+	Variable bool
+	// Path is the package, then the class for methods, then the name
+	Path []string
+	// Params and Return are the types of a function, written the way they
+	// are in geode source
+	Params []string
+	Return string
+}
+
+// Package returns the package the symbol is in
+func (s Symbol) Package() string {
+	if len(s.Path) < 2 {
+		return ""
+	}
+	return s.Path[0]
+}
+
+// Name returns the qualified geode name of the symbol, like `main:add` or
+// `main:Person.greet` for methods
+func (s Symbol) Name() string {
+	if len(s.Path) < 2 {
+		return strings.Join(s.Path, "")
+	}
+	return s.Path[0] + ":" + strings.Join(s.Path[1:], ".")
+}
+
+// String returns the symbol as a geode signature, like
+// `main:add(int, long) long`
+func (s Symbol) String() string {
+	if s.Variable {
+		return s.Name()
+	}
+	sig := fmt.Sprintf("%s(%s)", s.Name(), strings.Join(s.Params, ", "))
+	if s.Return != "void" {
+		sig += " " + s.Return
+	}
+	return sig
+}
+
+// IsMangled returns if a symbol looks like a mangled name
+func IsMangled(sym string) bool {
+	return strings.HasPrefix(sym, FunctionPrefix+"N") || strings.HasPrefix(sym, VariablePrefix+"N")
+}
+
+// Demangle parses a mangled function or variable name
+func Demangle(sym string) (Symbol, error) {
+	d := &demangler{sym: sym}
+	s, err := d.symbol()
+	if err != nil {
+		return Symbol{}, fmt.Errorf("invalid mangled name %q: %s", sym, err)
+	}
+	return s, nil
+}
+
+// symbolPattern matches anything that could be a mangled name in text
+var symbolPattern = regexp.MustCompile(`_[XV]N(?:[0-9A-Za-z_]|[^\x00-\x7f])+`)
+
+// DemangleText replaces every mangled name in some text, like the output
+// of a linker or a profiler, with its geode signature. Anything that
+// doesn't demangle is left as it is.
+func DemangleText(text string) string {
+	return symbolPattern.ReplaceAllStringFunc(text, func(sym string) string {
+		// the pattern is greedy, so drop characters from the end until the
+		// rest is a symbol
+		for end := len(sym); end > 2; end-- {
+			if s, err := Demangle(sym[:end]); err == nil {
+				return s.String() + sym[end:]
+			}
+		}
+		return sym
+	})
+}
+
+type demangler struct {
+	sym string
+	pos int
+}
+
+func (d *demangler) peek() byte {
+	if d.pos >= len(d.sym) {
+		return 0
+	}
+	return d.sym[d.pos]
+}
+
+func (d *demangler) next() byte {
+	c := d.peek()
+	d.pos++
+	return c
+}
+
+func (d *demangler) expect(c byte) error {
+	if got := d.next(); got != c {
+		return fmt.Errorf("expected %q at %d, found %q", c, d.pos-1, got)
+	}
+	return nil
+}
+
+func (d *demangler) symbol() (Symbol, error) {
+	s := Symbol{}
+	switch {
+	case strings.HasPrefix(d.sym, FunctionPrefix):
+	case strings.HasPrefix(d.sym, VariablePrefix):
+		s.Variable = true
+	default:
+		return s, fmt.Errorf("unknown prefix")
+	}
+	d.pos = 2
+
+	path, err := d.path()
+	if err != nil {
+		return s, err
+	}
+	s.Path = path
+
+	if s.Variable {
+		if d.pos != len(d.sym) {
+			return s, fmt.Errorf("unexpected %q at %d", d.peek(), d.pos)
+		}
+		return s, nil
+	}
+
+	s.Params = make([]string, 0)
+	for d.peek() == 'T' {
+		d.pos++
+		t, err := d.typ()
+		if err != nil {
+			return s, err
+		}
+		s.Params = append(s.Params, t)
+	}
+	if err := d.expect('R'); err != nil {
+		return s, err
+	}
+	ret, err := d.typ()
+	if err != nil {
+		return s, err
+	}
+	s.Return = ret
+	if d.pos != len(d.sym) {
+		return s, fmt.Errorf("unexpected %q at %d", d.peek(), d.pos)
+	}
+	return s, nil
+}
+
+// path reads the names of a path, of which there has to be at least one
+func (d *demangler) path() ([]string, error) {
+	path := make([]string, 0)
+	for d.peek() == 'N' {
+		d.pos++
+		ident, err := d.ident()
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, ident)
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("missing name at %d", d.pos)
+	}
+	return path, nil
+}
+
+// number reads a decimal number
+func (d *demangler) number() (int, error) {
+	start := d.pos
+	for d.peek() >= '0' && d.peek() <= '9' {
+		d.pos++
+	}
+	if start == d.pos {
+		return 0, fmt.Errorf("expected a number at %d", start)
+	}
+	return strconv.Atoi(d.sym[start:d.pos])
+}
+
+func (d *demangler) ident() (string, error) {
+	n, err := d.number()
+	if err != nil {
+		return "", err
+	}
+	// the underscore after the length of an ident that starts with a digit
+	// or an underscore
+	if d.peek() == '_' {
+		d.pos++
+	}
+	if n == 0 || d.pos+n > len(d.sym) {
+		return "", fmt.Errorf("bad identifier length %d at %d", n, d.pos)
+	}
+	ident := d.sym[d.pos : d.pos+n]
+	d.pos += n
+	return ident, nil
+}
+
+// intNames are the geode names of the integer types by size
+var intNames = map[int]string{
+	1:   "bool",
+	8:   "byte",
+	16:  "short",
+	32:  "int",
+	64:  "long",
+	128: "i128",
+	256: "large",
+	512: "huge",
+}
+
+// typ reads a type, returning it written as it would be in geode
+func (d *demangler) typ() (string, error) {
+	start := d.pos
+	switch d.next() {
+	case 'v':
+		return "void", nil
+
+	case 'i':
+		bits, err := d.number()
+		if err != nil {
+			return "", err
+		}
+		if name, ok := intNames[bits]; ok {
+			return name, nil
+		}
+		return fmt.Sprintf("i%d", bits), nil
+
+	case 'f':
+		bits, err := d.number()
+		if err != nil {
+			return "", err
+		}
+		if bits == 64 {
+			return "float", nil
+		}
+		return fmt.Sprintf("f%d", bits), nil
+
+	case 'P':
+		// strings are the class runtime:string, so a pointer to bytes is
+		// only ever a byte*
+		elem, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		return elem + "*", nil
+
+	case 'S':
+		elem, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		return elem + "[]", nil
+
+	case 'A':
+		n, err := d.number()
+		if err != nil {
+			return "", err
+		}
+		if err := d.expect('_'); err != nil {
+			return "", err
+		}
+		elem, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s[%d]", elem, n), nil
+
+	case 'D':
+		n, err := d.number()
+		if err != nil {
+			return "", err
+		}
+		if err := d.expect('_'); err != nil {
+			return "", err
+		}
+		// vectors are named after the llvm type of their elements, which
+		// is how the element is mangled
+		kind := d.next()
+		if kind != 'i' && kind != 'f' {
+			return "", fmt.Errorf("invalid vector element at %d", d.pos-1)
+		}
+		bits, err := d.number()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%c%dx%d", kind, bits, n), nil
+
+	case 'C':
+		path, err := d.path()
+		if err != nil {
+			return "", err
+		}
+		if err := d.expect('E'); err != nil {
+			return "", err
+		}
+		return Symbol{Path: path}.Name(), nil
+
+	case 'L':
+		fields, err := d.typeList()
+		if err != nil {
+			return "", err
+		}
+		return "{" + strings.Join(fields, ", ") + "}", nil
+
+	case 'F':
+		ret, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		params := make([]string, 0)
+		for d.peek() != 'E' && d.peek() != 'V' {
+			param, err := d.typ()
+			if err != nil {
+				return "", err
+			}
+			params = append(params, param)
+		}
+		if d.peek() == 'V' {
+			d.pos++
+			params = append(params, "...")
+		}
+		if err := d.expect('E'); err != nil {
+			return "", err
+		}
+		sig := fmt.Sprintf("func(%s)", strings.Join(params, ", "))
+		if ret != "void" {
+			sig += " " + ret
+		}
+		return sig, nil
+	}
+	return "", fmt.Errorf("unknown type at %d", start)
+}
+
+// typeList reads types until an 'E'
+func (d *demangler) typeList() ([]string, error) {
+	list := make([]string, 0)
+	for d.peek() != 'E' {
+		t, err := d.typ()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	d.pos++
+	return list, nil
+}
@@ -0,0 +1,176 @@
+// Package mangle implements the scheme geode uses to give functions and
+// global variables unique symbol names, and the demangler that turns those
+// symbols back into geode signatures.
+//
+// Functions are mangled with their package, name, parameter types and
+// return type, so every variant of a function compiled for different
+// argument types gets its own symbol. Global variables are mangled with
+// their package and name only.
+//
+// The grammar of a mangled name is:
+//
+//	function  = "_X" path { "T" type } "R" type
+//	variable  = "_V" path
+//	path      = "N" ident { "N" ident }     package, then class, then name
+//	ident     = length [ "_" ] chars        length is in decimal bytes
+//	instance  = name "I" type { type } "E"  a generic class instance, as an ident
+//
+//	type      = "v"                         void
+//	          | "i" bits                    integer, bool is i1
+//	          | "f" bits                    floating point
+//	          | "P" type                    pointer
+//	          | "S" type                    slice
+//	          | "A" length "_" type         array
+//	          | "D" length "_" type         simd vector
+//	          | "C" path "E"                class or other named struct
+//	          | "L" { type } "E"            struct literal
+//	          | "F" type { type } [ "V" ] "E"  function, return type first
+//
+// For example `func add(int a, long b) long` in package main is mangled
+// as `_XN4mainN3addTi32Ti64Ri64`, and the method `done` of the class
+// `runtime:Runes` as `_XN7runtimeN5RunesN4doneTPCN7runtimeN5RunesERi1`.
+//
+// An ident that starts with a digit or an underscore, like the `0` of the
+// package init function `main:init.0`, has an underscore between its length
+// and its chars, so its first chars aren't read as part of its length:
+// `_XN4mainN4initN1_0Rv`.
+//
+// The names of code that only uses ascii names only contain letters, digits
+// and underscores, so they never need quoting in llvm ir or escaping in a
+// linker's output. Names in other scripts are kept as their utf-8 bytes,
+// which llvm ir quotes, and their length counts those bytes rather than
+// runes: `func größe(int x) int` is `_XN4mainN7größeTi32Ri32`. The scheme only depends on the structure of types,
+// never on how llvm prints them, so symbols stay the same with opaque
+// pointers.
+package mangle
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+// The prefixes of mangled names. c++ uses _Z, an equally random letter
+// works fine.
+const (
+	FunctionPrefix = "_X"
+	VariablePrefix = "_V"
+)
+
+// classPrefix prefixes the llvm names of the struct types of classes
+const classPrefix = "class."
+
+// Function mangles a function. path is the package followed by the
+// class for methods, then the name of the function.
+func Function(path []string, params []types.Type, ret types.Type) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(FunctionPrefix)
+	writePath(buf, path)
+	for _, param := range params {
+		buf.WriteString("T")
+		writeType(buf, param)
+	}
+	buf.WriteString("R")
+	writeType(buf, ret)
+	return buf.String()
+}
+
+// Variable mangles a global variable
+func Variable(path []string) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(VariablePrefix)
+	writePath(buf, path)
+	return buf.String()
+}
+
+// Instance names the instance of a generic class with some type
+// arguments, like `ListIi32E` for `List<int>`. The name is used as the
+// class's ident, so the methods of an instance are mangled like those of
+// any other class.
+func Instance(name string, args []types.Type) string {
+	buf := &bytes.Buffer{}
+	buf.WriteString(name)
+	buf.WriteString("I")
+	for _, arg := range args {
+		writeType(buf, arg)
+	}
+	buf.WriteString("E")
+	return buf.String()
+}
+
+// SplitName splits a qualified geode name, like `main:Person.greet`, into
+// the path Function and Variable expect
+func SplitName(name string) []string {
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return r == ':' || r == '.'
+	})
+}
+
+func writePath(buf *bytes.Buffer, path []string) {
+	for _, part := range path {
+		buf.WriteString("N")
+		writeIdent(buf, part)
+	}
+}
+
+func writeIdent(buf *bytes.Buffer, ident string) {
+	fmt.Fprintf(buf, "%d", len(ident))
+	if ident != "" && (ident[0] == '_' || ident[0] >= '0' && ident[0] <= '9') {
+		buf.WriteString("_")
+	}
+	buf.WriteString(ident)
+}
+
+func writeType(buf *bytes.Buffer, t types.Type) {
+	switch t := t.(type) {
+	case *types.VoidType:
+		buf.WriteString("v")
+	case *types.IntType:
+		fmt.Fprintf(buf, "i%d", t.Size)
+	case *types.FloatType:
+		fmt.Fprintf(buf, "f%d", t.ByteCount()*8)
+	case *types.PointerType:
+		buf.WriteString("P")
+		writeType(buf, t.Elem)
+	case *types.SliceType:
+		buf.WriteString("S")
+		writeType(buf, t.Elem)
+	case *types.ArrayType:
+		fmt.Fprintf(buf, "A%d_", t.Len)
+		writeType(buf, t.Elem)
+	case *types.VectorType:
+		fmt.Fprintf(buf, "D%d_", t.Len)
+		writeType(buf, t.Elem)
+	case *types.StructType:
+		if t.Name != "" {
+			writeNamed(buf, SplitName(strings.TrimPrefix(t.Name, classPrefix)))
+			return
+		}
+		buf.WriteString("L")
+		for _, field := range t.Fields {
+			writeType(buf, field)
+		}
+		buf.WriteString("E")
+	case *types.FuncType:
+		buf.WriteString("F")
+		writeType(buf, t.Ret)
+		for _, param := range t.Params {
+			writeType(buf, param.Typ)
+		}
+		if t.Variadic {
+			buf.WriteString("V")
+		}
+		buf.WriteString("E")
+	default:
+		// anything else is mangled by its name, which is still unique
+		writeNamed(buf, []string{t.String()})
+	}
+}
+
+func writeNamed(buf *bytes.Buffer, path []string) {
+	buf.WriteString("C")
+	writePath(buf, path)
+	buf.WriteString("E")
+}
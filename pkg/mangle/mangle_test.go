@@ -0,0 +1,73 @@
+package mangle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/geode-lang/geode/llvm/ir/types"
+)
+
+func TestFunctionRoundTrip(t *testing.T) {
+	str := types.NewStruct()
+	str.Name = "class.runtime:string"
+	point := types.NewStruct()
+	point.Name = "class.main:Point"
+
+	tests := []struct {
+		path   []string
+		params []types.Type
+		ret    types.Type
+		want   string
+	}{
+		{[]string{"main", "add"}, []types.Type{types.I32, types.I64}, types.I64, "main:add(int, long) long"},
+		// the ident of init.0 starts with a digit
+		{[]string{"main", "init", "0"}, nil, types.Void, "main:init.0()"},
+		{[]string{"main", "_hidden", "10x"}, []types.Type{types.I8}, types.Void, "main:_hidden.10x(byte)"},
+		{[]string{"c", "puts"}, []types.Type{types.NewPointer(types.I8), types.NewPointer(types.I16)}, types.I32, "c:puts(byte*, short*) int"},
+		{[]string{"main", "Point", "norm"}, []types.Type{types.NewPointer(point)}, types.Double, "main:Point.norm(main:Point*) float"},
+		{[]string{"main", "größe"}, []types.Type{str}, types.I1, "main:größe(runtime:string) bool"},
+	}
+	for _, test := range tests {
+		sym := Function(test.path, test.params, test.ret)
+		got, err := Demangle(sym)
+		if err != nil {
+			t.Errorf("Demangle(%q): %v", sym, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Path, test.path) || got.String() != test.want {
+			t.Errorf("Demangle(%q) = %v %q, want %v %q", sym, got.Path, got, test.path, test.want)
+		}
+	}
+}
+
+func TestVariableRoundTrip(t *testing.T) {
+	for _, path := range [][]string{
+		{"main", "counter"},
+		{"main", "0"},
+		{"main", "__with", "3"},
+		{"main", "名前"},
+	} {
+		sym := Variable(path)
+		got, err := Demangle(sym)
+		if err != nil {
+			t.Errorf("Demangle(%q): %v", sym, err)
+			continue
+		}
+		if !got.Variable || !reflect.DeepEqual(got.Path, path) {
+			t.Errorf("Demangle(%q) = %+v, want the variable %v", sym, got, path)
+		}
+	}
+}
+
+func TestDigitIdent(t *testing.T) {
+	if got, want := Function([]string{"main", "init", "0"}, nil, types.Void), "_XN4mainN4initN1_0Rv"; got != want {
+		t.Errorf("Function(main:init.0) = %q, want %q", got, want)
+	}
+}
+
+func TestDemangleText(t *testing.T) {
+	text := "undefined reference to `" + Function([]string{"main", "init", "0"}, nil, types.Void) + "'"
+	if got, want := DemangleText(text), "undefined reference to `main:init.0()'"; got != want {
+		t.Errorf("DemangleText = %q, want %q", got, want)
+	}
+}
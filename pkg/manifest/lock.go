@@ -0,0 +1,66 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LockFileName is the name of the file the versions of a project's
+// dependencies are locked in, next to its manifest
+const LockFileName = "geode.lock"
+
+// Lock is the versions the versioned dependencies of a project resolved to.
+// Builds use the locked versions as long as they still match the manifest
+// and are installed, so the project is built from the same packages until
+// the lock is removed.
+type Lock struct {
+	// Versions are the versions of the dependencies by their names
+	Versions map[string]string `toml:"versions"`
+}
+
+// LockPath returns the path of the lock file of the project
+func (m *Manifest) LockPath() string {
+	return filepath.Join(m.Dir, LockFileName)
+}
+
+// ReadLock reads the lock file of the project. Projects that haven't been
+// built yet have an empty one.
+func (m *Manifest) ReadLock() (*Lock, error) {
+	lock := &Lock{}
+	if _, err := toml.DecodeFile(m.LockPath(), lock); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %s", m.LockPath(), err)
+	}
+	if lock.Versions == nil {
+		lock.Versions = make(map[string]string)
+	}
+	return lock, nil
+}
+
+// WriteLock writes the versions dependencies resolved to into the lock file
+// of the project, if they changed. Nothing is written for projects with no
+// versioned dependencies.
+func (m *Manifest) WriteLock(versions map[string]string) error {
+	old, err := m.ReadLock()
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(old.Versions, versions) {
+		return nil
+	}
+	if len(versions) == 0 {
+		return os.Remove(m.LockPath())
+	}
+	buff := &bytes.Buffer{}
+	fmt.Fprintf(buff, "# generated by geode build, do not edit.\n")
+	fmt.Fprintf(buff, "# remove it to resolve every dependency again.\n\n")
+	if err := toml.NewEncoder(buff).Encode(&Lock{Versions: versions}); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.LockPath(), buff.Bytes(), 0644)
+}
@@ -0,0 +1,60 @@
+package manifest
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	m := &Manifest{Name: "server", Dir: t.TempDir()}
+
+	lock, err := m.ReadLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Versions) != 0 {
+		t.Errorf("ReadLock() without a lock file = %v, want no versions", lock.Versions)
+	}
+
+	versions := map[string]string{"http": "1.4.1", "json": "0.3.0"}
+	if err := m.WriteLock(versions); err != nil {
+		t.Fatal(err)
+	}
+	if lock, err = m.ReadLock(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lock.Versions, versions) {
+		t.Errorf("ReadLock() = %v, want %v", lock.Versions, versions)
+	}
+
+	// the lock file isn't written again when nothing changed
+	info, err := os.Stat(m.LockPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteLock(map[string]string{"json": "0.3.0", "http": "1.4.1"}); err != nil {
+		t.Errorf("WriteLock() of the locked versions: %v", err)
+	}
+	if after, _ := os.Stat(m.LockPath()); !after.ModTime().Equal(info.ModTime()) {
+		t.Errorf("WriteLock() of the locked versions wrote the lock file")
+	}
+
+	// it is removed when there is nothing left to lock
+	if err := m.WriteLock(map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.LockPath()); !os.IsNotExist(err) {
+		t.Errorf("lock file without versions wasn't removed: %v", err)
+	}
+}
+
+func TestReadLockInvalid(t *testing.T) {
+	m := &Manifest{Name: "server", Dir: t.TempDir()}
+	if err := os.WriteFile(m.LockPath(), []byte("[versions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ReadLock(); err == nil {
+		t.Errorf("ReadLock() of an invalid lock file succeeded")
+	}
+}
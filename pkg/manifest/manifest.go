@@ -0,0 +1,186 @@
+// Package manifest reads geode.toml, the manifest of a project. It declares
+// how the project is built, so `geode build` in its directory needs no
+// flags:
+//
+//	name = "server"
+//	entry = "src"
+//	optimize = "2"
+//	link-flags = ["-lssl"]
+//
+//	[dependencies]
+//	json = "../json"
+//	http = "^1.2.0"
+//	yaml = "github.com/user/yaml@v0.3.0"
+//
+// Paths in the manifest are relative to the directory it is in. A
+// dependency is the path of its package, a git repository to fetch it from,
+// or a version constraint, which is resolved from the versioned directories
+// in geodepkgs, like geodepkgs/http@1.4.1. The versions they resolve to are
+// kept in geode.lock.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/geode-lang/geode/pkg/fetch"
+	"github.com/geode-lang/geode/pkg/semver"
+)
+
+// FileName is the name of the manifest of a project
+const FileName = "geode.toml"
+
+// Manifest is the build configuration of a project
+type Manifest struct {
+	// Name is the name of the project, which its binary is named after
+	Name string `toml:"name"`
+	// Entry is the file or directory the project is built from, the
+	// directory of the manifest by default
+	Entry string `toml:"entry"`
+	// Output is the binary the project is built into, or the directory its
+	// library is written to
+	Output string `toml:"output"`
+	// Target is the target triple to build for
+	Target string `toml:"target"`
+	// Toolchain is the toolchain to build with
+	Toolchain string `toml:"toolchain"`
+	// Optimize is the optimization level, from 0 to 3 or s
+	Optimize string `toml:"optimize"`
+	// Lib builds the project as a static or shared library
+	Lib string `toml:"lib"`
+	// LinkFlags are passed to the final link, like libraries to link with
+	LinkFlags []string `toml:"link-flags"`
+	// Warnings turn warnings on and off like -W does
+	Warnings []string `toml:"warnings"`
+	// Dependencies are the paths or version constraints of the packages the
+	// project includes by name
+	Dependencies map[string]string `toml:"dependencies"`
+
+	// Dir is the directory the manifest was read from
+	Dir string `toml:"-"`
+}
+
+// Find reads the manifest of the project a directory is in, searching it
+// and the directories above it. It returns nil if there is none.
+func Find(dir string) (*Manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a manifest file and checks that it is valid
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{}
+	if _, err := toml.DecodeFile(path, m); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	m.Dir = filepath.Dir(path)
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return m, nil
+}
+
+func (m *Manifest) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("the project has no name")
+	}
+	switch m.Optimize {
+	case "", "0", "1", "2", "3", "s":
+	default:
+		return fmt.Errorf("invalid optimization level %q, expected 0, 1, 2, 3 or s", m.Optimize)
+	}
+	switch m.Lib {
+	case "", "static", "shared":
+	default:
+		return fmt.Errorf("invalid library kind %q, expected static or shared", m.Lib)
+	}
+	for name, dep := range m.Dependencies {
+		if dep == "" {
+			return fmt.Errorf("dependency %s has no path or version", name)
+		}
+		if IsPath(dep) {
+			continue
+		}
+		if _, err := semver.ParseConstraint(dep); err != nil {
+			return fmt.Errorf("dependency %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// Path returns a path in the manifest relative to the working directory
+func (m *Manifest) Path(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(m.Dir, path)
+}
+
+// EntryPath returns the path the project is built from
+func (m *Manifest) EntryPath() string {
+	if m.Entry == "" {
+		return m.Dir
+	}
+	return m.Path(m.Entry)
+}
+
+// OutputPath returns the path the project is built into. Binaries are named
+// after the project by default, and libraries are left to --lib to name.
+func (m *Manifest) OutputPath() string {
+	if m.Output == "" && m.Lib == "" {
+		return m.Path(m.Name)
+	}
+	return m.Path(m.Output)
+}
+
+// IsPath returns if a dependency is given by its path or repository rather
+// than a version. Paths start with . or / or have a / in them, which
+// versions never do.
+func IsPath(dep string) bool {
+	return strings.HasPrefix(dep, ".") || strings.Contains(dep, "/")
+}
+
+// DependencyPaths returns the paths of the dependencies given by path, by
+// their names. Repositories are kept as they are, to be fetched when they
+// are included.
+func (m *Manifest) DependencyPaths() map[string]string {
+	paths := make(map[string]string)
+	for name, dep := range m.Dependencies {
+		switch {
+		case fetch.IsRemote(dep):
+			paths[name] = dep
+		case IsPath(dep):
+			paths[name] = m.Path(dep)
+		}
+	}
+	return paths
+}
+
+// DependencyVersions returns the version constraints of the dependencies
+// given by version, by their names
+func (m *Manifest) DependencyVersions() map[string]semver.Constraint {
+	versions := make(map[string]semver.Constraint)
+	for name, dep := range m.Dependencies {
+		if !IsPath(dep) {
+			// validate already parsed it
+			versions[name], _ = semver.ParseConstraint(dep)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,95 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeManifest writes a geode.toml into a new directory and returns its
+// path
+func writeManifest(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeManifest(t, `
+name = "server"
+entry = "src"
+optimize = "2"
+link-flags = ["-lssl"]
+
+[dependencies]
+json = "../json"
+http = "^1.2.0"
+yaml = "github.com/user/yaml@v0.3.0"
+`)
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(path)
+	if m.Name != "server" || m.Optimize != "2" || !reflect.DeepEqual(m.LinkFlags, []string{"-lssl"}) {
+		t.Errorf("Load = %+v", m)
+	}
+	if got, want := m.EntryPath(), filepath.Join(dir, "src"); got != want {
+		t.Errorf("EntryPath() = %q, want %q", got, want)
+	}
+	if got, want := m.OutputPath(), filepath.Join(dir, "server"); got != want {
+		t.Errorf("OutputPath() = %q, want %q", got, want)
+	}
+
+	wantPaths := map[string]string{
+		"json": filepath.Join(filepath.Dir(dir), "json"),
+		"yaml": "github.com/user/yaml@v0.3.0",
+	}
+	if got := m.DependencyPaths(); !reflect.DeepEqual(got, wantPaths) {
+		t.Errorf("DependencyPaths() = %v, want %v", got, wantPaths)
+	}
+	versions := m.DependencyVersions()
+	if len(versions) != 1 || versions["http"].String() != "^1.2.0" {
+		t.Errorf("DependencyVersions() = %v, want http ^1.2.0", versions)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{`entry = "src"`, "the project has no name"},
+		{"name = \"a\"\noptimize = \"4\"", "invalid optimization level"},
+		{"name = \"a\"\nlib = \"dynamic\"", "invalid library kind"},
+		{"name = \"a\"\n[dependencies]\nhttp = \"\"", "dependency http has no path or version"},
+		{"name = \"a\"\n[dependencies]\nhttp = \"^one\"", "dependency http"},
+		{"name = [", ""},
+	}
+	for _, test := range tests {
+		_, err := Load(writeManifest(t, test.text))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Load(%q) = %v, want an error with %q", test.text, err, test.err)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	path := writeManifest(t, `name = "server"`)
+	sub := filepath.Join(filepath.Dir(path), "src", "net")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Find(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m.Dir != filepath.Dir(path) {
+		t.Errorf("Find(%q) = %+v, want the manifest in %s", sub, m, filepath.Dir(path))
+	}
+}
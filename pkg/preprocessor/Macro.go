@@ -1,14 +1,133 @@
 package preprocessor
 
-// Macro stores information for a single string replacement macro
+import (
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// Macro stores information for a single token replacement macro
 type Macro struct {
-	Name  string
-	Args  []string
-	Body  string
+	Name string
+	// Args are the names of the parameters of the macro, or nil if it is
+	// used without parentheses
+	Args []string
+	Body string
+
+	body  []lexer.Token
 	state *State
 }
 
-// Expand the macro into it's resulting string
-func (m *Macro) Expand(args []string) {
+// expand expands the use of the macro at tokens[at], returning what it
+// expands to and the index of the first token after the use. Macros used
+// in the expansion are expanded too, except the ones already being
+// expanded, so a macro can't expand to itself forever.
+func (m *Macro) expand(tokens []lexer.Token, at int, expanding []string) ([]lexer.Token, int) {
+	use := tokens[at]
+	next := at + 1
+
+	var args [][]lexer.Token
+	if m.Args != nil {
+		// like in c, the name of a macro with parameters is left alone
+		// when it isn't called
+		if next >= len(tokens) || !tokens[next].Is(lexer.TokLeftParen) {
+			return []lexer.Token{use}, next
+		}
+		var ok bool
+		args, next, ok = m.arguments(tokens, next)
+		if !ok {
+			return nil, next
+		}
+	}
+
+	out := make([]lexer.Token, 0, len(m.body))
+	for _, b := range m.body {
+		if i := m.param(b); i >= 0 {
+			out = append(out, args[i]...)
+			continue
+		}
+		t := use
+		t.Type = b.Type
+		t.Value = b.Value
+		out = append(out, t)
+	}
+
+	expanding = append(expanding, m.Name)
+	return m.state.rescan(out, expanding), next
+}
+
+// arguments parses the arguments of a call to the macro, starting at its
+// '('. Commas inside parentheses, brackets and braces don't split them.
+func (m *Macro) arguments(tokens []lexer.Token, open int) ([][]lexer.Token, int, bool) {
+	args := [][]lexer.Token{}
+	current := []lexer.Token{}
+	depth := 0
+
+	for i := open + 1; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.Is(lexer.TokComment):
+			continue
+		case tok.Is(lexer.TokLeftParen, lexer.TokLeftBrace, lexer.TokLeftCurly):
+			depth++
+		case tok.Is(lexer.TokRightParen, lexer.TokRightBrace, lexer.TokRightCurly):
+			if depth == 0 {
+				if len(current) > 0 || len(args) > 0 {
+					args = append(args, current)
+				}
+				if len(args) != len(m.Args) {
+					m.state.errorf(tokens[open-1], "Macro %s takes %d arguments, not %d", m.Name, len(m.Args), len(args))
+					return nil, i + 1, false
+				}
+				return args, i + 1, true
+			}
+			depth--
+		case tok.Is(lexer.TokComma) && depth == 0:
+			args = append(args, current)
+			current = []lexer.Token{}
+			continue
+		}
+		current = append(current, tok)
+	}
+
+	m.state.errorf(tokens[open-1], "The call to macro %s is missing a ')'", m.Name)
+	return nil, len(tokens), false
+}
+
+// param returns the index of the parameter a token of the body names, or
+// -1 if it doesn't name one
+func (m *Macro) param(tok lexer.Token) int {
+	if !tok.Is(lexer.TokIdent, lexer.TokType) {
+		return -1
+	}
+	for i, name := range m.Args {
+		if name == tok.Value {
+			return i
+		}
+	}
+	return -1
+}
+
+// rescan expands the macros in the tokens a macro expanded to
+func (pp *State) rescan(tokens []lexer.Token, expanding []string) []lexer.Token {
+	out := make([]lexer.Token, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		m, found := pp.Macros[tok.Value]
+		if !found || !tok.Is(lexer.TokIdent, lexer.TokType) || contains(expanding, tok.Value) {
+			out = append(out, tok)
+			continue
+		}
+		expanded, next := m.expand(tokens, i, expanding)
+		out = append(out, expanded...)
+		i = next - 1
+	}
+	return out
+}
 
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
@@ -1,100 +1,180 @@
 package preprocessor
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"strings"
+
+	"github.com/geode-lang/geode/pkg/lexer"
+)
+
+// The directives the preprocessor understands. They start with a '#', so
+// they are comments to the lexer and only mean something here.
+//
+//	#define NAME tokens        replaces NAME with the tokens
+//	#define NAME(a, b) tokens  replaces NAME(x, y) with the tokens, with a
+//	                           and b replaced by x and y
+//	#undef NAME                forgets NAME
+//
+// A macro is defined from the line it is on to the end of the file, or
+// the #undef of it. The body of a macro is the rest of the line.
+const (
+	directiveDefine = "#define"
+	directiveUndef  = "#undef"
 )
 
-// State -
+// Error is a mistake in a directive or the use of a macro
+type Error struct {
+	// Token is where the mistake is
+	Token   lexer.Token
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// State is the macros of a file as it is preprocessed
 type State struct {
-	Reader *bufio.Reader
-	Result io.Writer
 	Macros map[string]*Macro
+	errors []*Error
 }
 
 // New creates a preprocessor State
 func New() *State {
 	pp := &State{}
+	pp.Macros = make(map[string]*Macro)
 	return pp
 }
 
-// Run the preprocessor on a file
-func (pp *State) Run(source string) (string, error) {
-	val := pp.Process(source)
-	return val, nil
-}
-
-// Process a source string
-func (pp *State) Process(source string) string {
-
-	w := newWalker(source)
-
-	for true {
-		ln := w.next()
-		ln = strings.TrimLeft(ln, " \t")
-		if w.eof() {
-			break
-		}
-		if len(ln) == 0 {
+// Run the preprocessor on the tokens of a file, returning them with the
+// directives taken out and the macros expanded, and the mistakes found. The
+// tokens a macro expands to are placed at its use, so errors in them point
+// at the code that used the macro.
+func (pp *State) Run(tokens []lexer.Token) ([]lexer.Token, []*Error) {
+	pp.errors = nil
+	out := make([]lexer.Token, 0, len(tokens))
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		if tok.Is(lexer.TokComment) {
+			if !pp.directive(tok) {
+				out = append(out, tok)
+			}
 			continue
 		}
 
-		if ln[0] == '@' {
-			w.drop()
+		m, found := pp.Macros[tok.Value]
+		if !found || !tok.Is(lexer.TokIdent, lexer.TokType) {
+			out = append(out, tok)
 			continue
 		}
-		fmt.Println(ln)
+
+		expanded, next := m.expand(tokens, i, nil)
+		out = append(out, expanded...)
+		i = next - 1
+	}
+
+	return out, pp.errors
+}
+
+func (pp *State) errorf(tok lexer.Token, format string, args ...interface{}) {
+	pp.errors = append(pp.errors, &Error{tok, fmt.Sprintf(format, args...)})
+}
+
+// directive runs the directive in a comment, if it has one, and returns if
+// it did
+func (pp *State) directive(tok lexer.Token) bool {
+	fields := strings.Fields(tok.Value)
+	if len(fields) == 0 {
+		return false
 	}
 
-	return strings.Join(w.lines, "\n")
+	switch fields[0] {
+	case directiveDefine:
+		pp.define(tok, strings.TrimSpace(strings.TrimPrefix(tok.Value, directiveDefine)))
+		return true
+	case directiveUndef:
+		if len(fields) != 2 {
+			pp.errorf(tok, "#undef takes the name of a macro")
+			return true
+		}
+		delete(pp.Macros, fields[1])
+		return true
+	}
+	return false
 }
 
-func (pp *State) parsemacro(w *walker) *Macro {
+// define parses the rest of a #define line into a macro
+func (pp *State) define(tok lexer.Token, rest string) {
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !isNameRune(r)
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	name := rest[:end]
+	if name == "" {
+		pp.errorf(tok, "#define needs the name of the macro to define")
+		return
+	}
+	rest = rest[end:]
+
+	var params []string
+	// parameters come right after the name, so `#define A (1)` is the
+	// constant (1) and not a macro that takes an argument called 1
+	if strings.HasPrefix(rest, "(") {
+		close := strings.IndexByte(rest, ')')
+		if close < 0 {
+			pp.errorf(tok, "The parameters of macro %s are missing a ')'", name)
+			return
+		}
+		params = []string{}
+		for _, param := range strings.Split(rest[1:close], ",") {
+			param = strings.TrimSpace(param)
+			if param == "" && close == 1 {
+				break
+			}
+			if param == "" || strings.IndexFunc(param, func(r rune) bool { return !isNameRune(r) }) >= 0 {
+				pp.errorf(tok, "Invalid parameter %q of macro %s", param, name)
+				return
+			}
+			params = append(params, param)
+		}
+		rest = rest[close+1:]
+	}
 
-	return nil
+	if _, found := pp.Macros[name]; found {
+		pp.errorf(tok, "Macro %s is already defined", name)
+		return
+	}
+	pp.NewMacro(name, params, strings.TrimSpace(rest))
 }
 
-// NewMacro creates a new macro and adds it to the state
-func (pp *State) NewMacro(name string, args []string, body string) *Macro {
+// NewMacro creates a new macro and adds it to the state. A macro without
+// parameters has nil params, one called with no arguments has none.
+func (pp *State) NewMacro(name string, params []string, body string) *Macro {
 	m := &Macro{}
 	m.Name = name
-	m.Args = args
+	m.Args = params
 	m.Body = body
+	m.body = significant(lexer.QuickLex(body))
 	m.state = pp
 	pp.Macros[name] = m
 	return m
 }
 
-type walker struct {
-	lines []string
-	index int
-}
-
-func newWalker(s string) *walker {
-	w := &walker{}
-	w.lines = strings.Split(s, "\n")
-	w.index = -1
-	return w
-}
-
-func (w *walker) next() string {
-	w.index++
-	if w.index < len(w.lines) {
-		return w.lines[w.index]
+// significant returns the tokens that aren't whitespace or comments
+func significant(tokens []lexer.Token) []lexer.Token {
+	out := make([]lexer.Token, 0, len(tokens))
+	for _, t := range tokens {
+		if !t.Is(lexer.TokWhitespace, lexer.TokComment) {
+			out = append(out, t)
+		}
 	}
-	return ""
-}
-
-// drop the currently indexed line
-func (w *walker) drop() string {
-	ln := w.lines[w.index]
-	w.lines = append(w.lines[:w.index], w.lines[w.index+1:]...)
-	w.index--
-	return ln
+	return out
 }
 
-func (w *walker) eof() bool {
-	return w.index >= len(w.lines)
+func isNameRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
 }
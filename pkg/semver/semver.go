@@ -0,0 +1,216 @@
+// Package semver parses semantic versions and the constraints dependencies
+// put on them, like ^1.2.0 or >=1.0, <2.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, major.minor.patch with an optional
+// pre-release after a dash
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// Parse parses a version. The minor and patch numbers can be left off, and
+// a leading v is allowed, so 1, v1.2 and 1.2.0-beta are all versions.
+func Parse(s string) (Version, error) {
+	v := Version{}
+	text := strings.TrimPrefix(s, "v")
+	if dash := strings.Index(text, "-"); dash >= 0 {
+		v.Pre = text[dash+1:]
+		text = text[:dash]
+		if v.Pre == "" {
+			return v, fmt.Errorf("invalid version %q, empty pre-release", s)
+		}
+	}
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q, expected major.minor.patch", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q, %q is not a number", s, part)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if the version is older than, the same as or
+// newer than another. Pre-releases are older than the release they are of,
+// and are compared by their dot separated identifiers, as with comparePre.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// comparePre compares two pre-releases identifier by identifier. Numeric
+// identifiers compare as numbers, so beta.2 is older than beta.10, and are
+// older than the others, which compare as text. A pre-release that runs
+// out of identifiers first is the older one.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xErr := strconv.Atoi(as[i])
+		y, yErr := strconv.Atoi(bs[i])
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return sign(x - y)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		case as[i] != bs[i]:
+			return sign(strings.Compare(as[i], bs[i]))
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// comparator is a single comparison a version has to pass, like >=1.2.0
+type comparator struct {
+	op string
+	v  Version
+}
+
+func (c comparator) allows(v Version) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// Constraint is the set of versions a dependency accepts. Its comparisons
+// are separated by commas or spaces and all have to pass.
+//
+// A version on its own or with a ^ accepts the versions that are
+// compatible with it, up to the next major version, or the next minor one
+// before 1.0.0. A ~ accepts the versions up to the next minor version. The
+// pre-releases of the version they stop at aren't accepted either, as
+// 2.0.0-beta isn't compatible with 1.2.0. =, >, >=, < and <= compare as
+// they read, and * accepts every version.
+type Constraint struct {
+	text        string
+	comparators []comparator
+}
+
+// ParseConstraint parses a constraint
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{text: s}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return c, fmt.Errorf("empty version constraint")
+	}
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "*" {
+			continue
+		}
+		rest := strings.TrimLeft(fields[i], "=<>^~")
+		op := fields[i][:len(fields[i])-len(rest)]
+		// the operator can be apart from its version, as in >= 1.2
+		if rest == "" && i+1 < len(fields) {
+			i++
+			rest = fields[i]
+		}
+		v, err := Parse(rest)
+		if err != nil {
+			return c, err
+		}
+		switch op {
+		case "", "^":
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", caretLimit(v)})
+		case "~":
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", firstPre(Version{Major: v.Major, Minor: v.Minor + 1})})
+		case "=", ">", ">=", "<", "<=":
+			c.comparators = append(c.comparators, comparator{op, v})
+		default:
+			return c, fmt.Errorf("invalid version constraint %q, unknown operator %q", s, op)
+		}
+	}
+	return c, nil
+}
+
+// caretLimit returns the first version that isn't compatible with one
+func caretLimit(v Version) Version {
+	if v.Major > 0 {
+		return firstPre(Version{Major: v.Major + 1})
+	}
+	if v.Minor > 0 {
+		return firstPre(Version{Minor: v.Minor + 1})
+	}
+	return firstPre(Version{Patch: v.Patch + 1})
+}
+
+// firstPre returns the oldest pre-release of a version, which is older
+// than every other one of it
+func firstPre(v Version) Version {
+	v.Pre = "0"
+	return v
+}
+
+// Allows returns if a version passes the constraint
+func (c Constraint) Allows(v Version) bool {
+	for _, comp := range c.comparators {
+		if !comp.allows(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Constraint) String() string {
+	return c.text
+}
@@ -0,0 +1,112 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"1", Version{Major: 1}},
+		{"v1.2", Version{Major: 1, Minor: 2}},
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2.0-beta.2", Version{Major: 1, Minor: 2, Pre: "beta.2"}},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "1.2.3.4", "1.x", "-1", "1.2.0-"} {
+		if v, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, v)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// each version is older than the next
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-0",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.10",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"2.0.0",
+	}
+	for i, a := range ordered {
+		for j, b := range ordered {
+			want := 0
+			if i < j {
+				want = -1
+			} else if i > j {
+				want = 1
+			}
+			if got := mustParse(t, a).Compare(mustParse(t, b)); got != want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		denied     []string
+	}{
+		{"^1.2.0", []string{"1.2.0", "1.2.5", "1.9.0"}, []string{"1.1.9", "1.2.0-beta", "2.0.0-beta", "2.0.0-0", "2.0.0"}},
+		{"1.2.0", []string{"1.2.0", "1.9.0"}, []string{"2.0.0-rc.1", "2.0.0"}},
+		{"^0.3.1", []string{"0.3.1", "0.3.9"}, []string{"0.3.0", "0.4.0-beta", "0.4.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4-beta", "0.0.4"}},
+		{"~1.2.0", []string{"1.2.0", "1.2.9"}, []string{"1.3.0-beta", "1.3.0"}},
+		{">= 1.0, <2", []string{"1.0.0", "1.9.9"}, []string{"0.9.0", "2.0.0"}},
+		{"=1.4.1", []string{"1.4.1"}, []string{"1.4.0", "1.4.2"}},
+		{"*", []string{"0.0.1", "1.0.0-beta", "9.0.0"}, nil},
+	}
+	for _, test := range tests {
+		c, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", test.constraint, err)
+			continue
+		}
+		for _, v := range test.allowed {
+			if !c.Allows(mustParse(t, v)) {
+				t.Errorf("%q doesn't allow %s", test.constraint, v)
+			}
+		}
+		for _, v := range test.denied {
+			if c.Allows(mustParse(t, v)) {
+				t.Errorf("%q allows %s", test.constraint, v)
+			}
+		}
+	}
+
+	for _, in := range []string{"", "!1.0", "^x"} {
+		if _, err := ParseConstraint(in); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return v
+}
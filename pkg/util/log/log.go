@@ -48,6 +48,12 @@ func Deprecated(format string, args ...interface{}) {
 	log(tolog)
 }
 
+// Warning -
+func Warning(format string, args ...interface{}) {
+	tolog := color.Yellow("[warning] ") + fmt.Sprintf(format, args...)
+	log(tolog)
+}
+
 // Syntax -
 func Syntax(format string, args ...interface{}) {
 	tolog := color.Yellow("[syntax] ") + fmt.Sprintf(format, args...)
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TrimPaths is set when absolute paths should be kept out of everything the
+// compiler emits, so the same sources build the same binary wherever they
+// are checked out
+var TrimPaths bool
+
+// trimmedStdLibDir is what the standard library directory is replaced with
+// in trimmed paths
+const trimmedStdLibDir = "geodelib"
+
+// PathPrefixMap returns the directories that are replaced in trimmed paths
+// and what they are replaced with, most specific first. The standard
+// library is replaced with `geodelib` and the working directory with `.`.
+func PathPrefixMap() [][2]string {
+	prefixes := make([][2]string, 0, 2)
+	if lib, err := filepath.Abs(StdLibDir()); err == nil {
+		prefixes = append(prefixes, [2]string{lib, trimmedStdLibDir})
+	}
+	if wd, err := os.Getwd(); err == nil {
+		prefixes = append(prefixes, [2]string{wd, "."})
+	}
+	// when one directory is inside the other, the inner one has to win
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i][0]) > len(prefixes[j][0])
+	})
+	return prefixes
+}
+
+// TrimPath returns the path as it should appear in emitted code. Without
+// TrimPaths it is returned as it is. Otherwise paths in the standard
+// library or the working directory are made relative to them, and any
+// other path is reduced to its file name.
+func TrimPath(p string) string {
+	if !TrimPaths {
+		return p
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return filepath.Base(p)
+	}
+	for _, prefix := range PathPrefixMap() {
+		if rel, err := filepath.Rel(prefix[0], abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(filepath.Join(prefix[1], rel))
+		}
+	}
+	return filepath.Base(abs)
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
@@ -58,13 +59,53 @@ func BashCmd(command string) (string, error) {
 	return RunCommandStr("bash", "-c", fmt.Sprintf("\"%s\"", command))
 }
 
-// StdLibDir returns the stdlib directory path
+// DefaultStdLibDir is where make install puts the standard library
+const DefaultStdLibDir = "/usr/local/lib/geodelib"
+
+// SearchRoots returns the directories in GEODE_PATH, which packages are
+// searched for in. They are separated like the directories in PATH.
+func SearchRoots() []string {
+	roots := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv("GEODE_PATH")) {
+		if dir == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		roots = append(roots, dir)
+	}
+	return roots
+}
+
+var stdLibDir string
+
+// StdLibDir returns the stdlib directory path. It is GEODELIB if that is
+// set, or else the first directory with the runtime in it out of the ones
+// in GEODE_PATH, the lib/geodelib next to the directory of the compiler and
+// the default install location. Installs that aren't in /usr/local, like
+// per-user and nix ones, are found without any configuration that way.
 func StdLibDir() string {
-	libpath := os.Getenv("GEODELIB")
-	if libpath == "" {
-		libpath = "/usr/local/lib/geodelib"
+	if libpath := os.Getenv("GEODELIB"); libpath != "" {
+		return libpath
+	}
+	if stdLibDir != "" {
+		return stdLibDir
+	}
+	candidates := SearchRoots()
+	if exe, err := os.Executable(); err == nil {
+		if exe, err = filepath.EvalSymlinks(exe); err == nil {
+			candidates = append(candidates, filepath.Join(filepath.Dir(exe), "..", "lib", "geodelib"))
+		}
+	}
+	stdLibDir = DefaultStdLibDir
+	for _, dir := range candidates {
+		if info, err := os.Stat(filepath.Join(dir, "runtime")); err == nil && info.IsDir() {
+			stdLibDir = filepath.Clean(dir)
+			break
+		}
 	}
-	return libpath
+	return stdLibDir
 }
 
 // StdLibFile takes a path in the stdlib and
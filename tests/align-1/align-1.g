@@ -0,0 +1,38 @@
+# align 1
+is main
+
+include "std:mem"
+
+@align(64)
+class Line {
+	long a
+	long b
+}
+
+@align(32)
+int counter = 1
+
+func main int {
+	byte pad = 1
+	@align(32) f32x8 v = 2
+	@align(128) long big_aligned
+
+	Line l
+
+	# the addresses are checked as bytes
+	byte* pv = &v
+	byte* pbig = &big_aligned
+	byte* pl = &l
+	byte* pcounter = &counter
+	println("%v %v %v", mem:is_aligned(pv, 32), mem:is_aligned(pbig, 128), mem:is_aligned(pl, 64))
+	println("%v", mem:is_aligned(pcounter, 32))
+
+	byte* heap = mem:aligned(100, 256)
+	heap[99] = 7
+	println("%v %v", mem:is_aligned(heap, 256), mem:size(heap))
+
+	heap = mem:resize(heap, 1000)
+	println("%v %v %d", mem:is_aligned(heap, 256), mem:size(heap), heap[99])
+	mem:free(heap)
+	return pad + counter - 2
+}
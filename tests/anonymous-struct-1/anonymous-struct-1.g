@@ -0,0 +1,40 @@
+is main
+
+class Line {
+	{int x, int y} from
+	{int x, int y} to
+}
+
+func length({int x, int y} a, {int x, int y} b) int {
+	int dx = b.x - a.x
+	int dy = b.y - a.y
+	return dx * dx + dy * dy
+}
+
+func move({int x, int y}* p, int by) {
+	p.x += by
+	p.y += by
+}
+
+func main int {
+	{int x, int y} a
+	a.x = 1
+	a.y = 2
+	{int x, int y} b = a
+	move(&b, 3)
+
+	Line l
+	l.from = a
+	l.to = b
+
+	# fields can be of any type, even another anonymous struct
+	{string name, {float w, float h} size, Line* line} box
+	box.name = "box"
+	box.size.w = 1.5
+	box.size.h = 2.0
+	box.line = &l
+
+	println("%d %d %d %d", b.x, b.y, length(l.from, l.to), box.line.to.y)
+	println("%s %.1f %d", box.name, box.size.w * box.size.h, sizeof({int x, int y}))
+	return 0
+}
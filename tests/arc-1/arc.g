@@ -0,0 +1,58 @@
+is main
+include "mem"
+
+class Node {
+	int value
+	Node* next
+	@weak Node* prev
+}
+
+class Pair {
+	Node* left
+	Node* right
+}
+
+func make(int value) Node* {
+	n = new(Node)
+	n.value = value
+	return n
+}
+
+# a list of nodes linked both ways, which the weak links back don't keep
+# alive
+func chain(int count) Node* {
+	head = make(0)
+	tail = head
+	for i = 1; i < count; i += 1 {
+		n = make(i)
+		n.prev = tail
+		tail.next = n
+		tail = n
+	}
+	return head
+}
+
+func main int {
+	list = chain(1000)
+	println("%d %d", mem:arc_objects(), list.next.next.prev.value)
+
+	# the list goes with its head
+	list = make(7)
+	println("%d %d", mem:arc_objects(), list.value)
+
+	p = new(Pair)
+	p.left = list
+	p.right = make(8)
+	list = make(9)
+	println("%d %d %d", mem:arc_objects(), p.left.value, p.right.value)
+
+	# and the pair takes what only it points to with it
+	p = new(Pair)
+	println("%d %d", mem:arc_objects(), list.value)
+
+	# results that nothing holds go once the statement is done with them
+	make(1)
+	int v = make(4).value
+	println("%d %d %d", mem:arc_objects(), v, make(5).next == nil)
+	return 0
+}
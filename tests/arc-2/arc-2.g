@@ -0,0 +1,36 @@
+# arc 2
+is main
+
+include "std:io"
+include "mem"
+
+class Node {
+	int value
+}
+
+func fail(int x) int {
+	n = new(Node)
+	n.value = x
+	if x < 0 {
+		throw "negative"
+	}
+	return n.value
+}
+
+# the error unwinds through the function, which releases its locals on
+# the way
+func through(int x) int {
+	kept = new(Node)
+	kept.value = x
+	return fail(x) + kept.value
+}
+
+func main int {
+	println("%d %d", through(2), mem:arc_objects())
+	try {
+		through(-1)
+	} catch (e Error) {
+		println("caught %s %d", e.message, mem:arc_objects())
+	}
+	return 0
+}
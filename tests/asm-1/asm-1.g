@@ -0,0 +1,42 @@
+# asm 1
+is main
+
+include "std:io"
+
+@arch(x86_64)
+func add(long a, long b) long {
+	long sum = 0
+	asm("addq $2, $0", ["=r"(sum)], ["0"(a), "r"(b)], [])
+	return sum
+}
+
+@arch("!x86_64")
+func add(long a, long b) long {
+	return a + b
+}
+
+@os(linux)
+@arch(x86_64)
+func write(string msg, long n) long {
+	return asm("syscall", ["=\{rax}"(n)], ["\{rax}"(1), "\{rdi}"(1), "\{rsi}"(msg.data), "\{rdx}"(n)], ["rcx", "r11", "memory"])
+}
+
+@os("!linux")
+func write(string msg, long n) long {
+	io:print("%s", msg)
+	return n
+}
+
+@os(linux)
+@arch("!x86_64")
+func write(string msg, long n) long {
+	io:print("%s", msg)
+	return n
+}
+
+func main int {
+	long written = write("hello\n", 6)
+	println("%d", written)
+	println("%d", add(40, 2))
+	return 0
+}
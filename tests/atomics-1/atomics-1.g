@@ -0,0 +1,16 @@
+# atomics 1
+is main
+
+func main int {
+	int counter = 0
+	int* p = &counter
+	atomic:store(p, 5, "release")
+	int old = atomic:fetch_add(p, 3)
+	atomic:fetch_sub(p, 1, "relaxed")
+	atomic:fence("seq_cst")
+	int swapped = atomic:exchange(p, 20, "acq_rel")
+	bool ok = atomic:compare_exchange(p, 20, 42, "acq_rel")
+	bool bad = atomic:compare_exchange(p, 20, 99)
+	println("%d %d %t %t %d", old, swapped, ok, bad, atomic:load(p, "acquire"))
+	return 0
+}
@@ -0,0 +1,44 @@
+# name bitwise 1
+is main
+
+# flags are packed into the bits of an int
+const READ := 1 << 0
+const WRITE := 1 << 1
+const EXEC := 1 << 2
+const ALL := READ | WRITE | EXEC
+
+func popcount(u32 x) int {
+	count = 0
+	while x != 0 {
+		count += x & 1
+		x >>= 1
+	}
+	return count
+}
+
+func main int {
+	int mode = READ | EXEC
+	println("%d %d", mode, ALL)
+
+	# & binds tighter than the comparison, so no parentheses are needed
+	println("%v %v", mode & WRITE == 0, mode & EXEC != 0)
+
+	mode |= WRITE
+	mode &= ~READ
+	println("%d", mode)
+	mode ^= ALL
+	println("%d", mode)
+
+	# | is looser than ^, which is looser than &
+	println("%d", 1 | 6 ^ 3 & 5)
+
+	byte b = 0x0f
+	println("%d %d", ~b, ~0)
+	u32 every = ~0
+	println("%d %d", popcount(0xff), popcount(every))
+
+	# shifts are tighter than &
+	long word = 0x1234
+	println("%d %d", word >> 8 & 0xff, word & 0xff)
+	return 0
+}
@@ -0,0 +1,25 @@
+is main
+include "io"
+
+func sum(int xs...) int {
+	total = 0
+	for i = 0; i < xs.len; i += 1 {
+		total += xs[i]
+	}
+	return total
+}
+
+func get(byte i, int xs...) int = xs[i]
+
+func set(long i, int xs...) int {
+	xs[i] = 7
+	return xs[i]
+}
+
+func main int {
+	io:print("%d %d %d\n", sum(1, 2, 3, 4), get(2, 5, 6, 7), set(1, 8, 9))
+	int* raw = [5, 6]
+	io:print("%d\n", raw[1])
+	io:print("%d\n", get(3, 5, 6, 7))
+	return 0
+}
@@ -0,0 +1,14 @@
+is main
+include "io"
+
+func main int {
+	for int i = 0; i < 3; i += 1 {
+		io:print("line %d\n", i);
+		log("logged");
+	}
+	io:print("flushed");
+	io:flush();
+	werr("\nto stderr\n");
+	io:print("written at exit\n");
+	return 0;
+}
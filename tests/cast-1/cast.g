@@ -0,0 +1,38 @@
+# name cast 1
+is main
+include "io"
+
+class Animal {
+	int legs
+	func name string {
+		return "animal";
+	}
+}
+
+class Dog is Animal {
+	int good
+	func name string {
+		return "dog";
+	}
+}
+
+class Cat is Animal {
+}
+
+func main int {
+	long wide = 300;
+	byte b = wide as byte;
+	io:print("%d %d\n", b, 3.7 as int);
+	Dog d;
+	d.good = 9;
+	Animal* a = &d;
+	Dog* back = a as! Dog*;
+	io:print("%d %s\n", back.good, back.name());
+	Animal* same = a as! Animal*;
+	io:print("%s %d\n", same.name(), info(Dog).size);
+	Cat c;
+	a = &c;
+	back = a as! Dog*;
+	io:print("unreachable\n");
+	return 0;
+}
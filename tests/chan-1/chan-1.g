@@ -0,0 +1,58 @@
+# name chan 1
+is main
+
+# squares the numbers it receives until jobs is closed
+func worker(chan<int> jobs, chan<int> results) {
+	int n = 0
+	while jobs.recv_ok(&n) {
+		results <- n * n
+	}
+	results <- -1
+}
+
+func produce(chan<string> out, chan<int> done) {
+	out <- "ping"
+	out <- "pong"
+	<-done
+	out.close()
+}
+
+func main int {
+	chan<int> jobs
+	jobs.open(10)
+	chan<int> results
+	results.open(0)
+	for int i = 0; i < 3; i += 1 {
+		spawn worker(jobs, results)
+	}
+	for int i = 1; i <= 10; i += 1 {
+		jobs <- i
+	}
+	jobs.close()
+
+	# the workers each send -1 once they are done
+	int sum = 0
+	int finished = 0
+	while finished < 3 {
+		int r = <-results
+		if r < 0 {
+			finished += 1
+		} else {
+			sum += r
+		}
+	}
+	println("%d", sum)
+
+	# sends to a chan with no room wait for the receiver
+	chan<string> words
+	words.open(0)
+	chan<int> done
+	done.open(0)
+	Thread* t = spawn produce(words, done)
+	println("%s %s", <-words, <-words)
+	done <- 1
+	t.join()
+	string last
+	println("%t %d", words.recv_ok(&last), last.len)
+	return 0
+}
@@ -0,0 +1,32 @@
+is main
+
+# Tree holds a Pair by value and points to a Node, both declared after it
+class Tree {
+	Node* root
+	Pair size
+}
+
+# Node and Tree point to each other
+class Node {
+	Tree* tree
+	Node* left
+	int value
+}
+
+class Pair {
+	long a
+	long b
+}
+
+func main int {
+	Tree t;
+	Node n;
+	n.tree = &t;
+	n.value = 7;
+	t.root = &n;
+	t.size.a = 1;
+	t.size.b = 2;
+	println("%d %d", t.root.value, n.tree.size.b)
+	println("%d %d", sizeof(Tree), sizeof(Node))
+	return 0
+}
@@ -0,0 +1,28 @@
+is main
+include "mem"
+
+class Box {
+	int value
+	func get int {
+		return this.value
+	}
+}
+
+int calls = 0
+
+func make(int value) Box* {
+	calls += 1
+	b = new(Box)
+	b.value = value
+	return b
+}
+
+func main int {
+	# the call a field is accessed on only runs once
+	int v = make(4).value
+	w = make(5).get()
+	make(6).value = 3
+	make(7).value += 1
+	println("%d %d", v + w, calls)
+	return 0
+}
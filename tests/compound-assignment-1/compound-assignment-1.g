@@ -0,0 +1,43 @@
+# name compound assignment 1
+is main
+include "io"
+
+@comptime
+func sum(int n) int {
+	int total = 0;
+	for int i = 0; i < n; i++ {
+		total += i;
+	}
+	total <<= 1;
+	return total;
+}
+
+int counter = 0
+
+func main int {
+	int x = 10;
+	x %= 4;
+	io:print("%d\n", x);
+	x <<= 3;
+	io:print("%d\n", x);
+	x >>= 2;
+	io:print("%d\n", x);
+	int a = x++;
+	int b = ++x;
+	io:print("%d %d %d\n", a, b, x);
+	x--;
+	--x;
+	io:print("%d\n", x);
+	int* arr = [1, 2, 3];
+	arr[1]++;
+	arr[2] -= 5;
+	io:print("%d %d\n", arr[1], arr[2]);
+	for int i = 0; i < 3; i++ {
+		counter++;
+	}
+	io:print("%d %d\n", counter, sum(5));
+	float f = 1.5;
+	f++;
+	io:print("%.1f\n", f);
+	return 0;
+}
@@ -0,0 +1,26 @@
+is main
+include "io"
+
+@comptime
+func fact(int n) int {
+	if n < 2 {
+		return 1;
+	}
+	return n * fact(n - 1);
+}
+
+@comptime
+func sum(int n) int {
+	total = 0
+	for int i = 1; i <= n; i += 1 {
+		total += i
+	}
+	return total
+}
+
+int table = fact(5);
+
+func main int {
+	io:print("%d %d", table, sum(10))
+	return fact(4)
+}
@@ -0,0 +1,37 @@
+# comptime 2
+is main
+
+# djb2, the same hash str:hash uses
+@comptime
+func hash(string s) long {
+	long h = 5381
+	for int i = 0; s[i] != 0; i++ {
+		h = h * 33 + s[i]
+	}
+	return h
+}
+
+@comptime
+func method_name(int code) string {
+	if code == 1 {
+		return "GET"
+	}
+	return "POST"
+}
+
+long get_hash = hash("GET")
+
+func route(long h) int {
+	match h {
+		hash("GET") { return 1 }
+		hash("POST") { return 2 }
+	}
+	return 0
+}
+
+func main int {
+	println("%d", get_hash)
+	println("%d %d", route(hash("POST")), route(get_hash))
+	println("%s", method_name(1))
+	return 0
+}
@@ -0,0 +1,12 @@
+is main
+
+@comptime
+func sq(int x) int {
+	return x * x
+}
+
+# calls with arguments only known at runtime are compiled as normal calls
+func main(int argc, byte** argv) int {
+	println("%d %d", sq(3), sq(argc + 2))
+	return sq(argc)
+}
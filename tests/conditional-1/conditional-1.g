@@ -0,0 +1,29 @@
+# conditional 1
+is main
+
+@os(linux)
+func known_os int {
+	return 1
+}
+
+@os(darwin, macos)
+func known_os int {
+	return 1
+}
+
+@os("!linux", "!darwin", "!macos")
+func known_os int {
+	return 0
+}
+
+@arch(wasm32)
+int pointer_bits = 32
+
+@arch("!wasm32")
+int pointer_bits = 64
+
+func main int {
+	println("%d", known_os())
+	println("%d", pointer_bits)
+	return 0
+}
@@ -0,0 +1,24 @@
+# name const 1
+is main
+
+include "io"
+
+# constants are computed at compile time, so they can build on each other
+const PI := 3.14159
+const TAU := PI * 2
+const WIDTH := 4
+const AREA := WIDTH * WIDTH + 1
+const int MASK = (1 << WIDTH) - 1
+const NEGATIVE := -WIDTH * AREA
+
+func main int {
+	io:print("%.5f %.5f\n", PI, TAU);
+	io:print("%d %d %d\n", AREA, MASK, NEGATIVE);
+
+	# constant expressions are folded too
+	int cells = WIDTH * 3 + 2 % 2;
+	byte* grid = xmalloc(AREA * 2);
+	grid[0] = 1;
+	io:print("%d %d %d\n", cells, !0, grid[0]);
+	return 0;
+}
@@ -0,0 +1,12 @@
+# debug alloc 1
+is main
+
+include "mem"
+
+# the debug allocator aborts on the second free of a block
+func main int {
+	byte* block = xmalloc(16);
+	mem:free(block);
+	mem:free(block);
+	return 0;
+}
@@ -0,0 +1,39 @@
+# defer 1
+is main
+
+include "std:io"
+
+func step(string name) {
+	println("%s", name);
+}
+
+func check(int n) int {
+	step("enter");
+	defer step("first");
+	count = n * 2;
+	defer println("second %d", count);
+	if n > 2 {
+		count = 0;
+		return n;
+	}
+	while n < 2 {
+		if n == 1 {
+			return 100;
+		}
+		n += 1;
+	}
+	return count;
+}
+
+func run {
+	defer step("run done");
+	step("running");
+}
+
+func main int {
+	println("%d", check(5));
+	println("%d", check(1));
+	println("%d", check(2));
+	run();
+	return 0;
+}
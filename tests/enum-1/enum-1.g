@@ -0,0 +1,51 @@
+# enum 1
+is main
+
+enum Color {
+	Red
+	Green = 5
+	Blue
+}
+
+enum Dir { North, East, South, West }
+
+class Pixel {
+	Color color
+	int x
+}
+
+func name(Color c) string {
+	match c {
+		Color:Red {
+			return "red"
+		}
+		Color:Green {
+			return "green"
+		}
+		Color:Blue {
+			return "blue"
+		}
+	}
+	return "unknown"
+}
+
+func turn(Dir d) Dir {
+	match d {
+		Dir:West {
+			return Dir:North
+		}
+		else {
+			return d + 1
+		}
+	}
+	return d
+}
+
+func main int {
+	Pixel p
+	p.color = Color:Blue
+	Dir d = Dir:South
+	d = turn(turn(d))
+	println("%d %d %d %s %s %d", Color:Red, Color:Green, p.color, name(p.color), name(Color:Red), d)
+	return 0
+}
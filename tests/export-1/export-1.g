@@ -0,0 +1,25 @@
+is main
+
+link "export.c"
+include "std:io"
+
+# exported functions get the symbol they are given, so c can declare them
+@export("geode_add")
+func add(int a, int b) int = a + b;
+
+# strings are passed to and returned from c as a char*
+@export("geode_greet")
+func greet(string name) string {
+	return "hello " + name;
+}
+
+@export("geode_len")
+func length(string s) long = s.len;
+
+func call_geode ...
+
+func main int {
+	call_geode();
+	io:print("%d %s %d\n", add(1, 2), greet("geode"), length("abc"));
+	return 0;
+}
@@ -0,0 +1,11 @@
+#include <stdio.h>
+
+int geode_add(int a, int b);
+const char *geode_greet(const char *name);
+long geode_len(const char *s);
+
+void call_geode(void) {
+	printf("%d\n", geode_add(40, 2));
+	printf("%s\n", geode_greet("c"));
+	printf("%ld\n", geode_len("four"));
+}
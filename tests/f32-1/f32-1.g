@@ -0,0 +1,23 @@
+# f32 1
+is main
+
+include "std:io"
+
+func half(f32 x) f32 {
+	return x / 2
+}
+
+func main int {
+	f32 a = 0.1
+	float b = a
+	io:print("%.10f %.10f\n", a, b)
+
+	f32 c = half(3)
+	float d = c + 0.25
+	io:print("%.2f %.2f %d\n", c, d, sizeof(f32))
+
+	f32 e = d * 2.0
+	int i = e
+	io:print("%.1f %d\n", e, i)
+	return 0
+}
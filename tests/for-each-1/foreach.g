@@ -0,0 +1,33 @@
+is main
+include "io"
+
+# Range iterates over the integers [cur, end)
+class Range {
+	int cur;
+	int end;
+	func done bool {
+		return this.cur >= this.end;
+	}
+	func next int {
+		this.cur += 1;
+		return this.cur - 1;
+	}
+}
+
+func main int {
+	Range r;
+	r.cur = 2;
+	r.end = 5;
+	for int x in r {
+		io:print("%d ", x);
+	}
+	for let x in r {
+		io:print("%d ", x * 10);
+	}
+	Range* p = &r;
+	for int x in p {
+		io:print("%d ", x);
+	}
+	io:print("%d\n", r.cur);
+	return 0;
+}
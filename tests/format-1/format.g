@@ -0,0 +1,14 @@
+is main
+
+func main int {
+	long n = 5000000000;
+	byte b = 200;
+	int neg = -3;
+	float f = 1.5;
+	bool yes = true;
+
+	string s = format("%s=%v", "x", 42);
+	println("%d %d %u %x %c %.2f %t %v %s", n, neg, b, 255, 'A', f, yes, 2.25, s);
+	println("%5d|%-4v|%%", 7, neg);
+	return 0;
+}
@@ -0,0 +1,29 @@
+# generics 1
+is main
+
+include "std:io"
+
+func max<T>(T a, T b) T {
+	if a > b {
+		return a;
+	}
+	return b;
+}
+
+func first<T>(T* items) T = items[0];
+
+func size<T>(T val) long = info(T).size;
+
+func main int {
+	int a = 3;
+	int b = 7;
+	float x = 2.5;
+	float y = 1.5;
+	io:print("%d %.1f ", max(a, b), max(x, y));
+	io:print("%.1f ", max(a, x));
+
+	long* nums = [9, 8, 7];
+	io:print("%d ", first(nums));
+	io:print("%d %d\n", size(a), size(x));
+	return 0;
+}
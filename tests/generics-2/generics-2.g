@@ -0,0 +1,48 @@
+# generics 2
+is main
+
+include "std:io"
+
+class Pair<K, V> {
+	K key;
+	V value;
+
+	func describe {
+		io:print("%d=%.1f\n", this.key, this.value);
+	}
+}
+
+class Node<T> {
+	T value;
+	Node<T>* next;
+
+	func get T = this.value;
+}
+
+func sum(Node<int>* n, int count) int {
+	int total = 0;
+	for int i = 0; i < count; i += 1 {
+		total += n.get();
+		n = n.next;
+	}
+	return total;
+}
+
+func main int {
+	Pair<int, float> p;
+	p.key = 3;
+	p.value = 1.5;
+	p.describe();
+
+	Node<int> a;
+	Node<int> b;
+	a.value = 4;
+	a.next = &b;
+	b.value = 5;
+	io:print("%d\n", sum(&a, 2));
+
+	Node<float> f;
+	f.value = 2.5;
+	io:print("%.1f %d %d\n", f.get(), info(Pair<int, int>).size, info(Node<float>).size);
+	return 0;
+}
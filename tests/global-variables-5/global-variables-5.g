@@ -0,0 +1,15 @@
+# global variables 5
+is main
+
+int limit = 4 * 2;
+int counter = 1;
+
+func bump {
+	counter += 1;
+}
+
+func main int {
+	bump();
+	bump();
+	return counter + limit;
+}
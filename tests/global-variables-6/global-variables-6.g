@@ -0,0 +1,23 @@
+# global variables 6
+is main
+
+# initializers that don't fit their type wrap, as they would in a local
+byte wrapped = 300;
+u8 all = -1;
+short half = 40000;
+u8 top = 200;
+
+func main int {
+	byte local = 300
+	println("%d %d", wrapped, local)
+
+	long wide = all
+	u8 minus = -1
+	long localWide = minus
+	println("%d %d", wide, localWide)
+
+	println("%d", half)
+	long fromTop = top
+	println("%d %v", fromTop, top > all)
+	return 0
+}
@@ -0,0 +1,23 @@
+is main
+
+include_c "stdlib.h"
+include_c "string.h"
+include_c "time.h"
+include_c "stdio.h"
+
+func main int {
+	stdio:printf("%d %d %d\n", stdlib:abs(-4), stdio:EOF, stdio:SEEK_END);
+
+	byte* buf = stdlib:malloc(32);
+	cstring:strcpy(buf, "hello");
+	cstring:strcat(buf, " world");
+	stdio:puts(buf);
+	stdio:printf("%ld\n", cstring:strlen(buf));
+	stdlib:free(buf);
+
+	time:Tm t;
+	t.tm_year = 100;
+	t.tm_mday = 29;
+	stdio:printf("%d %d\n", t.tm_year, t.tm_mday);
+	return 0;
+}
@@ -0,0 +1,55 @@
+# name inheritance 1
+is main
+include "io"
+
+class Animal {
+	int legs
+	func speak int {
+		io:print("...\n");
+		return 0;
+	}
+	func describe {
+		io:print("%d legs: ", this.legs);
+		this.speak();
+	}
+}
+
+class Dog is Animal {
+	int good
+	func speak int {
+		io:print("woof %d\n", this.good);
+		return 1;
+	}
+}
+
+class Puppy is Dog {
+	func speak int {
+		io:print("yip\n");
+		return 2;
+	}
+}
+
+func talk(Animal* a) int {
+	return a.speak();
+}
+
+Dog stray;
+
+func main int {
+	Animal a;
+	a.legs = 2;
+	Dog d;
+	d.legs = 4;
+	d.good = 10;
+	Puppy p;
+	p.legs = 3;
+	a.describe();
+	d.describe();
+	p.describe();
+	io:print("%d %d %d\n", talk(&a), talk(&d), talk(&p));
+	Animal* up = &p;
+	io:print("%d %d\n", up.legs, up.speak());
+	stray.good = 7;
+	stray.speak();
+	return 0;
+}
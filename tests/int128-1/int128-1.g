@@ -0,0 +1,29 @@
+# int128 1
+is main
+
+func print128(u128 x) {
+	u64 high = x >> 64
+	u64 low = x
+	println("%016x%016x", high, low)
+}
+
+func main int {
+	u128 max = 0xffffffffffffffffffffffffffffffff
+	print128(max)
+
+	i128 a = 170141183460469231731687303715884105727
+	i128 b = a / 1000000000000000000000
+	long q = b
+	println("%d", q)
+
+	long most = 9223372036854775807
+	i128 product = most
+	product = product * 4 + 3
+	print128(product)
+	long rem = product % 10
+	println("%d %d", rem, sizeof(i128))
+
+	u128 wrapped = max + 2
+	print128(wrapped)
+	return 0
+}
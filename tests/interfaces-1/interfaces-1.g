@@ -0,0 +1,51 @@
+# interfaces 1
+is main
+
+include "std:io"
+
+interface Shape {
+	func area float;
+	func scale(float by);
+	func name string;
+}
+
+class Square is Shape {
+	float side;
+
+	func area float = this.side * this.side;
+	func scale(float by) {
+		this.side = this.side * by;
+	}
+	func name string = "square";
+}
+
+class Rect is Shape {
+	float w;
+	float h;
+
+	func area float = this.w * this.h;
+	func scale(float by) {
+		this.w = this.w * by;
+		this.h = this.h * by;
+	}
+	func name string = "rect";
+}
+
+func describe(Shape s) {
+	io:print("%s %.1f\n", s.name(), s.area());
+}
+
+func main int {
+	Square sq;
+	sq.side = 2.0;
+	Rect r;
+	r.w = 2.0;
+	r.h = 3.0;
+
+	Shape s = &sq;
+	s.scale(1.5);
+	describe(s);
+	describe(&r);
+	io:print("%.1f\n", sq.side);
+	return 0;
+}
@@ -0,0 +1,39 @@
+# interfaces 2
+is main
+
+include "std:io"
+
+interface Speaker {
+	func speak int;
+}
+
+class Animal is Speaker {
+	int legs;
+	func speak int {
+		io:print("...\n");
+		return 0;
+	}
+}
+
+class Dog is Animal {
+	func speak int {
+		io:print("woof\n");
+		return 1;
+	}
+}
+
+func say(Speaker s) int {
+	return s.speak();
+}
+
+func main int {
+	Animal a;
+	Dog d;
+	# a dog is a speaker through the animal it extends
+	total = say(&a) + say(&d);
+	# and is called as a dog through a pointer to an animal
+	Animal* up = &d;
+	total = total + say(up);
+	io:print("%d\n", total);
+	return 0;
+}
@@ -0,0 +1,16 @@
+# interpolation 1
+is main
+
+include "std:io"
+
+func greet(string name, int age) string {
+	return "hello {name}, you are {age}"
+}
+
+func main int {
+	name = "geode"
+	age = 3
+	println("%s", greet(name, age + 1))
+	println("{name} is {age > 2} {1.5} {} \{name} 100%")
+	return 0
+}
@@ -0,0 +1,19 @@
+# intrinsics 1
+is main
+
+include "std:io"
+include "std:math"
+
+func main int {
+	byte* buf = "hello world" as byte*
+	byte* copy = raw_copy(buf, 12)
+	llvm:memset(copy, 72, 1)
+	long mask = 255
+	io:print("%s %d %d %d\n", copy, llvm:ctpop(mask), llvm:ctlz(1), llvm:ctpop(7))
+	float x = 2.0
+	io:print("%.3f %.1f %.1f\n", llvm:sqrt(x), llvm:fma(x, 3.0, 1), math:sqrt(16.0))
+	if llvm:expect(mask > 3, 1) {
+		io:print("likely\n")
+	}
+	return 0
+}
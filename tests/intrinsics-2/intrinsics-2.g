@@ -0,0 +1,22 @@
+# intrinsics 2
+is main
+
+include "std:io"
+include "std:math"
+include "std:mem"
+
+func main int {
+	byte* buf = "abcdef" as byte*
+	byte* copy = raw_copy(buf, 7)
+	intrinsics:memmove(&copy[1], copy, 4)
+	mem:set(copy, 1, 90)
+	io:print("%s\n", copy)
+
+	int x = 40
+	io:print("%d %d %x\n", intrinsics:cttz(x), intrinsics:ctlz(x), intrinsics:bswap(x))
+
+	float f = -2.5
+	io:print("%.1f %.1f %.1f %.1f\n", math:floor(f), math:ceil(f), math:fabs(f), intrinsics:round(f))
+	io:print("%.1f %.1f %.1f\n", intrinsics:pow(2.0, 10.0), intrinsics:copysign(3.0, f), intrinsics:maxnum(f, 1.5))
+	return 0
+}
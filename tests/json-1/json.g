@@ -0,0 +1,28 @@
+is main
+include "json"
+
+func main int {
+	json:Value* doc = json:parse(" {\"name\": \"geode\", \"version\": 0.5, \"tags\": [\"lang\", \"llvm\"], \"stars\": 12, \"ok\": true, \"none\": null, \"esc\": \"a\\nb\\u00e9\\ud83d\\ude00\"} ");
+	if !json:valid(doc) {
+		println("parse failed: %s", json:error());
+		return 1;
+	}
+	println("%s %g %d %t", json:as_string(json:get(doc, "name")), json:as_number(json:get(doc, "version")), json:as_int(json:get(doc, "stars")), json:as_bool(json:get(doc, "ok")));
+	json:Value* tags = json:get(doc, "tags");
+	for long i = 0; i < json:len(tags); i += 1 {
+		println("tag %d %s", i, json:as_string(json:at(tags, i)));
+	}
+	println("%t %t %d", json:is_null(json:get(doc, "none")), json:has(doc, "missing"), json:kind(json:get(doc, "missing")));
+	println("%s", json:encode(doc));
+
+	json:Value* out = json:new_object();
+	json:set(out, "list", json:new_array());
+	json:push(json:get(out, "list"), json:new_number(1.5));
+	json:push(json:get(out, "list"), json:new_bool(false));
+	json:set(out, "msg", json:new_string("say \"hi\""));
+	println("%s", json:encode_indent(out, 2));
+
+	json:Value* bad = json:parse("[1, 2,]");
+	println("%t %s", json:valid(bad), json:error());
+	return 0;
+}
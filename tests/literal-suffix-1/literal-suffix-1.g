@@ -0,0 +1,21 @@
+# literal suffix 1
+is main
+
+func main int {
+	long million = 1_000_000
+	println("%d %d %d", million, 0xFF_FF, 0b1010_1010)
+
+	println("%d %d", 200u8 + 100u8, 100i8 + 100i8)
+	println("%.1f %.1f", 0.1f * 10, 2f64)
+
+	u8 small = 250u8
+	small = small + 10u8
+	println("%d", small)
+
+	f32 third = 1.0f / 3.0f
+	println("%.7f", third)
+
+	u64 all = 0xFFFF_FFFF_FFFF_FFFFu64
+	println("%u %x", all / 2u64, all)
+	return 0
+}
@@ -0,0 +1,6 @@
+is main
+
+func main int {
+	int x = 300u8
+	return x
+}
@@ -0,0 +1,49 @@
+# name logic 1
+is main
+
+int calls = 0
+
+func touch(bool result) bool {
+	calls += 1
+	return result
+}
+
+# the right side of && and || only runs when the left side doesn't decide
+func main int {
+	println("%v %v %d", touch(false) && touch(true), touch(true) || touch(false), calls)
+	println("%v %v %d", touch(true) && touch(false), touch(false) || touch(true), calls)
+
+	# dividing by zero would crash, so it is checked first
+	int d = 0
+	println("%v", d != 0 && 10 / d > 1)
+	d = 4
+	println("%v", d != 0 && 10 / d > 1)
+	d = 0
+	println("%v", d == 0 || 10 / d > 1)
+
+	# numbers and pointers are true when they aren't zero or nil
+	int zero = 0
+	float half = 0.5
+	string name = "geode"
+	println("%v %v %v", zero || half, name && 7, zero && touch(true))
+	println("%d", calls)
+
+	# the left side of a loop condition guards the right
+	int* nums = [3, 5, 11, 2]
+	i = 0
+	sum = 0
+	while i < 4 && nums[i] < 10 {
+		sum += nums[i]
+		i += 1
+	}
+	println("%d %d", sum, i)
+
+	int found = -1
+	for int j = 0; j < 4 && found < 0; j += 1 {
+		if nums[j] == 11 || nums[j] == 12 {
+			found = j
+		}
+	}
+	println("%d", found)
+	return 0
+}
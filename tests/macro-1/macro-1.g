@@ -0,0 +1,29 @@
+# macro 1
+is main
+
+#define SIZE 8
+#define SQUARE(x) ((x) * (x))
+#define MAX(a, b) pick(a > b, a, b)
+#define AREA(w, h) SQUARE(w) + SQUARE(h)
+
+func pick(bool c, int a, int b) int {
+	if c {
+		return a
+	}
+	return b
+}
+
+func main int {
+	int* values = [0, 0, 0, 0, 0, 0, 0, 0]
+	for int i = 0; i < SIZE; i++ {
+		values[i] = SQUARE(i + 1)
+	}
+	println("%d %d", values[0], values[SIZE - 1])
+	println("%d", MAX(SQUARE(3), 7))
+	println("%d", AREA(2, 3))
+
+#undef SIZE
+#define SIZE 3
+	println("%d", SIZE)
+	return 0
+}
@@ -0,0 +1,8 @@
+is main
+include "args"
+
+func main(string[] argv) int {
+	string[] all = args:all();
+	println("%d %d %d [%s] [%s]", argv.len, all.len, args:count(), args:get(1), argv[2]);
+	return argv.len + 10;
+}
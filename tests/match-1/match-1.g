@@ -0,0 +1,73 @@
+# match 1
+is main
+
+include "std:io"
+
+func name(int day) string {
+	match day {
+		0, 6 {
+			return "weekend"
+		}
+		1 {
+			return "monday"
+		}
+		2 + 1 {
+			return "wednesday"
+		}
+		else {
+			return "weekday"
+		}
+	}
+	return "unreachable"
+}
+
+func sign(float x) int {
+	int s = 0
+	match x {
+		0.0 {
+			s = 0
+		}
+		else {
+			s = 1
+			if x < 0 {
+				s = -1
+			}
+		}
+	}
+	return s
+}
+
+@comptime
+func fib(int n) int {
+	match n {
+		0, 1 {
+			return n
+		}
+	}
+	return fib(n - 1) + fib(n - 2)
+}
+
+func main int {
+	for i = 0; i < 7; i += 1 {
+		io:print("%s ", name(i))
+	}
+	io:print("\n")
+
+	int limit = 3
+	int count = 0
+	for i = 0; i < 6; i += 1 {
+		# the arm values aren't constants, so they are compared in order
+		match i {
+			limit, limit + 1 {
+				count += 10
+			}
+			limit - 1 {
+				count += 1
+			}
+		}
+	}
+
+	int f = fib(10)
+	println("%d %d %d %d %d", sign(-2.5), sign(0.0), sign(3.0), count, f)
+	return 0
+}
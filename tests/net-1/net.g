@@ -0,0 +1,35 @@
+is main
+include "mem"
+include "net"
+
+func main int {
+	int server = net:listen("127.0.0.1", 0);
+	int port = net:port(server);
+	int client = net:dial("127.0.0.1", port);
+	int conn = net:accept(server);
+
+	net:write_string(client, "ping");
+	println("server got %s", net:read_string(conn, 64));
+
+	byte[] reply = mem:bytes(4);
+	reply[0] = 'p';
+	reply[1] = 'o';
+	reply[2] = 'n';
+	reply[3] = 'g';
+	net:write(conn, reply);
+	byte[] buf = mem:bytes(16);
+	long n = net:read(client, buf);
+	println("client got %d bytes", n);
+
+	net:close(conn);
+	println("after close %d", net:read(client, buf));
+	net:close(client);
+	net:close(server);
+
+	int udp = net:listen_udp("127.0.0.1", 0);
+	int peer = net:dial_udp("127.0.0.1", net:port(udp));
+	net:write_string(peer, "datagram");
+	println("udp got %s", net:read_string(udp, 64));
+
+	return 3;
+}
@@ -0,0 +1,45 @@
+is main
+include "mem"
+
+class Node {
+	int value
+	Node* next
+}
+
+class Point {
+	float x
+	float y
+}
+
+func push(Node* head, int value) Node* {
+	n = new(Node)
+	n.value = value
+	n.next = head
+	return n
+}
+
+func sum(Node* n, int count) int {
+	total = 0
+	for i = 0; i < count; i += 1 {
+		total += n.value
+		n = n.next
+	}
+	return total
+}
+
+func main int {
+	Node* list = new(Node)
+	for i = 1; i <= 100000; i += 1 {
+		list = push(list, i % 10)
+	}
+	println("%d", sum(list, 100000))
+
+	# every point is garbage as soon as the next one is made
+	for i = 0; i < 100000; i += 1 {
+		p = new(Point)
+		p.x = 1.5
+	}
+	p = new(Point)
+	println("%.1f %.1f %d", p.x, p.y, new(long)[0])
+	return 0
+}
@@ -0,0 +1,25 @@
+is main
+
+# the next of the last node is never set
+class Node {
+	int value
+	Node* next
+}
+
+func node(int value) Node* {
+	Node* n = xmalloc(info(Node).size)
+	n.value = value
+	return n
+}
+
+func second(Node* n) int {
+	return n.next.value
+}
+
+func main int {
+	list = node(1)
+	list.next = node(2)
+	println("%d", second(list))
+	println("%d", second(list.next))
+	return 0
+}
@@ -0,0 +1,37 @@
+# operators 1
+is main
+
+include "std:io"
+
+class Vec {
+	int x;
+	int y;
+
+	func op+(Vec* o) Vec* = vec(this.x + o.x, this.y + o.y);
+	func op*(int k) Vec* = vec(this.x * k, this.y * k);
+	func op==(Vec* o) bool = this.x == o.x && this.y == o.y;
+	func op[](int i) int {
+		if i == 0 {
+			return this.x;
+		}
+		return this.y;
+	}
+}
+
+func vec(int x, int y) Vec* {
+	Vec* v = xmalloc(info(Vec).size);
+	v.x = x;
+	v.y = y;
+	return v;
+}
+
+func main int {
+	a = vec(1, 2);
+	b = vec(3, 4);
+	c = a + b * 2;
+	println("%d %d %d %d", c.x, c.y, c[0], c[1]);
+	println("%t %t", a == vec(1, 2), a == b);
+	v = c[1];
+	println("%d", v);
+	return 0;
+}
@@ -0,0 +1,16 @@
+# optimize 1
+is main
+
+func sum(int* xs, int n) int {
+	int total = 0
+	for int i = 0; i < n; i = i + 1 {
+		total += xs[i]
+	}
+	return total
+}
+
+func main int {
+	int* xs = [1, 2, 3, 4, 5]
+	println("%d", sum(xs, 5))
+	return 0
+}
@@ -0,0 +1,44 @@
+# optional 1
+is main
+
+include "std:io"
+
+class Node {
+	int value;
+	Node*? next;
+}
+
+func node(int value, Node*? next) Node* {
+	Node* n = xmalloc(info(Node).size);
+	n.value = value;
+	n.next = next;
+	return n;
+}
+
+func find(Node* list, int value) Node*? {
+	Node*? cur = list;
+	while cur != nil {
+		if let n = cur {
+			if n.value == value {
+				return n;
+			}
+			cur = n.next;
+		}
+	}
+	return nil;
+}
+
+func show(Node*? maybe) {
+	if let n = maybe {
+		println("found %d", n.value);
+	} else {
+		println("not found");
+	}
+}
+
+func main int {
+	list = node(1, node(2, node(3, nil)));
+	show(find(list, 2));
+	show(find(list, 5));
+	return 0;
+}
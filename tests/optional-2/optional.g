@@ -0,0 +1,15 @@
+is main
+
+class Node {
+	int value;
+}
+
+func get(Node*? m) Node* {
+	return m
+}
+
+func main int {
+	Node*? m = nil
+	Node* n = get(m)
+	return n.value
+}
@@ -0,0 +1,19 @@
+is main
+include "io"
+
+int counter = 1
+
+func init {
+	counter = counter * 10
+	io:print("first init\n")
+}
+
+func init {
+	counter = counter + 2
+	io:print("second init\n")
+}
+
+func main int {
+	io:print("main %d\n", counter)
+	return counter
+}
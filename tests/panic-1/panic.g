@@ -0,0 +1,32 @@
+is main
+
+class Account {
+	int balance
+}
+
+func withdraw(Account* a, int amount) int {
+	if amount > a.balance {
+		panic("insufficient funds")
+	}
+	a.balance -= amount
+	return a.balance
+}
+
+func sign(int x) int {
+	if x > 0 {
+		return 1
+	}
+	if x < 0 {
+		return -1
+	}
+	panic("zero has no sign")
+}
+
+func main int {
+	Account a
+	a.balance = 100
+	println("%d %d", sign(-4), withdraw(&a, 30))
+	println("%d", withdraw(&a, 80))
+	println("unreachable")
+	return 0
+}
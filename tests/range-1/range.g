@@ -0,0 +1,45 @@
+# name range 1
+is main
+include "io"
+
+func sum(long[] xs) long {
+	long total = 0;
+	for x in xs {
+		total += x;
+	}
+	return total;
+}
+
+func main int {
+	for i in 0..5 {
+		io:print("%d ", i);
+	}
+	io:print("\n");
+
+	int n = 3;
+	long total = 0;
+	for int i in n..n * 3 {
+		total += i;
+	}
+	io:print("%d\n", total);
+
+	r = 7..10;
+	for i in r {
+		io:print("%d ", i);
+	}
+	for i in 4..2 {
+		io:print("never ");
+	}
+	io:print("\n");
+
+	for int x in [10, 20, 30] {
+		io:print("%d ", x);
+	}
+	io:print("\n");
+
+	long[] s;
+	s.data = [4, 5, 6];
+	s.len = 3;
+	io:print("%d\n", sum(s));
+	return 0;
+}
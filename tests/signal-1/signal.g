@@ -0,0 +1,18 @@
+is main
+include "io"
+include "signal"
+
+int count = 0;
+
+func on_usr1(int sig) {
+	count += 1;
+	println("got usr1 %d", count);
+}
+
+func main int {
+	signal:notify(signal:sigusr1, &on_usr1);
+	signal:raise(signal:sigusr1);
+	signal:raise(signal:sigusr1);
+	println("%d", signal:sigint);
+	return count;
+}
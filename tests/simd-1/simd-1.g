@@ -0,0 +1,26 @@
+# simd 1
+is main
+
+func dot(f32x4 a, f32x4 b) float {
+	f32x4 m = a * b
+	return m[0] + m[1] + m[2] + m[3]
+}
+
+func main int {
+	f32x4 a = 1.5
+	a[1] = 2
+	a[2] = 3
+	a[3] = 4
+	f32x4 b = a * 2 + 1
+	println("%v %v %v %v", b[0], b[1], b[2], b[3])
+	println("%v", dot(a, b))
+
+	i32x4 x = simd:splat(3, 4)
+	x[0] = 10
+	i32x4 y = x - 1
+	i32x4 s = simd:shuffle(x, y, 0, 4, 1, 7)
+	println("%d %d %d %d", s[0], s[1], s[2], s[3])
+	i32x4 z = x + y
+	println("%d %t", z[0], z[1] == 5)
+	return 0
+}
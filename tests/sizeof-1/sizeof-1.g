@@ -0,0 +1,35 @@
+# name sizeof 1
+is main
+
+include "io"
+include "encoding"
+
+class Padded {
+	byte tag
+	long value
+}
+
+@align(32)
+class Wide {
+	int a
+}
+
+# sizes are known while compiling, so they can be constants
+const LONG_SIZE := sizeof(long)
+const TABLE_SIZE := sizeof(Padded) * 4
+
+func main int {
+	io:print("%d %d %d %d %d\n", sizeof(byte), sizeof(short), sizeof(int), LONG_SIZE, sizeof(float))
+	io:print("%d %d\n", sizeof(string), sizeof(int[]))
+	io:print("%d %d %d\n", sizeof(Padded), alignof(Padded), TABLE_SIZE)
+	io:print("%d %d\n", sizeof(Wide), alignof(Wide))
+
+	# array literals are allocated with the size of their elements
+	long* nums = [1, 2, 3]
+	io:print("%d\n", nums[2])
+
+	# the encoding package reads as many bytes as a T takes up
+	short half = 4660
+	io:print("%s\n", encoding:hex(half))
+	return 0
+}
@@ -0,0 +1,37 @@
+# name spawn 1
+is main
+
+# each worker writes its square into its own element, so they don't race
+func square(int* out, int n) {
+	out[n] = n * n
+}
+
+class Counter {
+	int* hits
+
+	func count(int n) {
+		this.hits[n] += 1
+	}
+}
+
+func main int {
+	int* out = xmalloc(8 * info(int).size)
+	vec<Thread*> threads
+	for int i = 0; i < 8; i += 1 {
+		threads.push(spawn square(out, i))
+	}
+	for Thread* t in threads {
+		t.join()
+	}
+	for int i = 0; i < 8; i += 1 {
+		println("%d", out[i])
+	}
+
+	# methods are spawned with the instance they are called on
+	Counter c
+	c.hits = xmalloc(2 * info(int).size)
+	Thread* t = spawn c.count(1)
+	t.join()
+	println("%d %d", c.hits[0], c.hits[1])
+	return 0
+}
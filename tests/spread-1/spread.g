@@ -0,0 +1,15 @@
+is main
+include "io"
+
+func add3(int a, int b, int c) int = a * 100 + b * 10 + c
+
+func first(int head, int rest...) int = head * 10 + rest.len
+
+func pass(int[] xs) int = add3(xs...)
+
+func collect(int nums...) int = pass(nums)
+
+func main int {
+	io:print("%d %d %d\n", add3([1, 2, 3]...), first([4, 5, 6]...), collect(7, 8, 9))
+	return 0
+}
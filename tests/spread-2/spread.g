@@ -0,0 +1,9 @@
+is main
+
+func add3(int a, int b, int c) int = a + b + c
+
+func collect(int nums...) int = add3(nums...)
+
+func main int {
+	return collect(1, 2)
+}
@@ -0,0 +1,10 @@
+is main
+
+func add3(int a, int b, int c) int {
+	return a + b + c
+}
+
+func main int {
+	int* p = [1, 2]
+	return add3(p...)
+}
@@ -0,0 +1,29 @@
+# ssa 1
+is main
+
+func collatz(int n) int {
+	int steps = 0
+	while n != 1 {
+		if n % 2 == 0 {
+			n = n / 2
+		} else {
+			n = 3 * n + 1
+		}
+		steps = steps + 1
+	}
+	return steps
+}
+
+func main int {
+	int longest = 0
+	int start = 0
+	for int i = 1; i < 30; i = i + 1 {
+		int steps = collatz(i)
+		if steps > longest {
+			longest = steps
+			start = i
+		}
+	}
+	println("%d %d", start, longest)
+	return 0
+}
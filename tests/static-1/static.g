@@ -0,0 +1,41 @@
+# name static 1
+is main
+include "io"
+
+class Counter {
+	int value
+	static int created = 0
+	static int limit = 3;
+
+	static func make(int start) Counter {
+		Counter c;
+		c.value = start;
+		Counter.created += 1;
+		return c;
+	}
+
+	static func full bool {
+		return Counter.created >= Counter.limit;
+	}
+
+	func bump {
+		this.value++;
+	}
+}
+
+func main int {
+	Counter a = Counter.make(5);
+	a.bump();
+	Counter b = Counter.make(1);
+	io:print("%d %d %d\n", a.value, b.value, Counter.created);
+	if !Counter.full() {
+		io:print("not full\n");
+	}
+	Counter.make(0);
+	if Counter.full() {
+		io:print("full\n");
+	}
+	Counter.limit = 10;
+	io:print("%d\n", Counter.limit);
+	return 0;
+}
@@ -0,0 +1,30 @@
+# name string 1
+is main
+
+include "str"
+
+# c functions take and return strings as a char*
+func strchr(string s, int c) string ...
+
+func main int {
+	string s = "hello"
+	string w = s + ", " + "world"
+	println("%s %d %d", w, w.len, str:len(w))
+	println("%t %t %t", s == "hello", s != "hello", w == s)
+	println("%s|%s", str:slice(w, 7, 12), str:slice(w, 0, 0))
+	println("%c %c", w[0], w[w.len - 1])
+
+	# a string converts to and from the bytes of a c string with as
+	byte* bytes = s as byte*
+	string back = bytes as string
+	println("%s %d %t", back, back.len, back == s)
+
+	println("%s", strchr(w, 'w'))
+	println("%t", strchr(w, 'z') == nil)
+
+	string* parts = str:split("a,bc,,d", ",")
+	for int i = 0; parts[i] != nil; i += 1 {
+		println("%s %d", parts[i], parts[i].len)
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+# name sync 1
+is main
+
+include "sync"
+
+class Counter {
+	sync:Mutex mu
+	sync:Cond done
+	int n
+	int finished
+}
+
+func bump(Counter* c, int times) {
+	for int i = 0; i < times; i += 1 {
+		with c.mu {
+			c.n += 1
+		}
+	}
+	with c.mu {
+		c.finished += 1
+		c.done.signal()
+	}
+}
+
+# a return out of a with unlocks the mutex
+func read(Counter* c) int {
+	with c.mu {
+		return c.n
+	}
+}
+
+func main int {
+	Counter* c = new(Counter)
+	for int i = 0; i < 4; i += 1 {
+		spawn bump(c, 1000)
+	}
+	with c.mu {
+		while c.finished < 4 {
+			c.done.wait(&c.mu)
+		}
+	}
+	println("%d", read(c))
+
+	sync:Mutex mu
+	println("%t %t", mu.try_lock(), mu.try_lock())
+	mu.unlock()
+	with mu {
+		println("held")
+	}
+	println("%t", mu.try_lock())
+	return 0
+}
@@ -0,0 +1,20 @@
+# syntax errors 1
+is main
+
+func first int {
+	int a = (1 + ;
+	return a
+}
+
+func second int {
+	return 2 +* 3
+}
+
+func third int {
+	int c = ]
+	return c
+}
+
+func main int {
+	return first() + second() + third()
+}
@@ -0,0 +1,34 @@
+# target features 1
+is main
+
+include "std:io"
+
+@target("avx2", "fma")
+func sum_avx2(int* xs, int n) int {
+	int s = 0
+	for int i = 0; i < n; i += 1 {
+		s += xs[i]
+	}
+	return s
+}
+
+@target("arch=x86-64")
+func sum(int* xs, int n) int {
+	int s = 0
+	for int i = 0; i < n; i += 1 {
+		s += xs[i]
+	}
+	return s
+}
+
+func main int {
+	int* xs = [1, 2, 3, 4]
+	int total = 0
+	if cpu_supports("avx2") {
+		total = sum_avx2(xs, 4)
+	} else {
+		total = sum(xs, 4)
+	}
+	io:print("%d\n", total)
+	return 0
+}
@@ -0,0 +1,40 @@
+# try 1
+is main
+
+include "std:io"
+
+func parse(int x) Result<int> {
+	Result<int> r;
+	if x < 0 {
+		r.error = "negative";
+		return r;
+	}
+	r.value = x * 2;
+	return r;
+}
+
+func half(int x) Result<float> {
+	defer println("half %d done", x);
+	n = parse(x)?;
+	Result<float> r;
+	r.value = n / 4.0;
+	return r;
+}
+
+func sum(int a, int b) Result<int> {
+	Result<int> r;
+	r.value = parse(a)? + parse(b)?;
+	return r;
+}
+
+func main int {
+	h = half(3);
+	println("%.1f %t", h.value, h.error == nil);
+	h = half(-1);
+	println("%s", h.error);
+	s = sum(1, 2);
+	println("%d", s.value);
+	s = sum(1, -2);
+	println("%s", s.error);
+	return 0;
+}
@@ -0,0 +1,79 @@
+# try catch 1
+is main
+
+include "std:io"
+include "sync"
+
+func check(int x) int {
+	if x < 0 {
+		throw "negative"
+	}
+	return x * 2
+}
+
+func nested(int x) int {
+	defer println("nested %d", x)
+	return check(x) + 1
+}
+
+func invalid(string why) {
+	Error e
+	e.message = why
+	throw e
+}
+
+# an error thrown in the catch is caught by the try outside of it
+func rethrow {
+	try {
+		try {
+			invalid("inner")
+		} catch (e Error) {
+			println("caught %s", e.message)
+			throw "outer"
+		}
+		println("unreachable")
+	} catch (e Error) {
+		println("caught %s again", e.message)
+	}
+}
+
+func main int {
+	try {
+		println("%d", nested(4))
+		println("%d", nested(-1))
+		println("unreachable")
+	} catch (e Error) {
+		println("caught %s", e.message)
+	}
+
+	try {
+		invalid("invalid")
+	} catch (err Error) {
+		println("caught %s", err.message)
+	}
+
+	rethrow()
+
+	# the mutex is unlocked before the catch runs
+	sync:Mutex mu
+	try {
+		with mu {
+			check(-2)
+		}
+	} catch (e Error) {
+		with mu {
+			println("locked again after %s", e.message)
+		}
+	}
+
+	int total = 0
+	for int i = -2; i < 3; i += 1 {
+		try {
+			total += check(i)
+		} catch (e Error) {
+			total += 100
+		}
+	}
+	println("%d", total)
+	return 0
+}
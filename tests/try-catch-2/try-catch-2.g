@@ -0,0 +1,72 @@
+# try catch 2
+is main
+
+include "std:io"
+include "sync"
+
+sync:Mutex mu
+int calls = 0
+
+# the error unwinds out of the with, which unlocks mu on the way
+func locked(int x) int {
+	with mu {
+		calls += 1
+		if x < 0 {
+			throw "negative"
+		}
+	}
+	return x
+}
+
+# and through functions that call one that throws
+func deferred(int x) int {
+	defer println("deferred %d", x)
+	with mu {
+		println("holding")
+	}
+	return locked(x) * 2
+}
+
+sync:Mutex mu2
+
+func outer(int x) int {
+	defer println("outer %d", x)
+	with mu2 {
+		return inner(x)
+	}
+}
+
+func inner(int x) int {
+	defer println("inner %d", x)
+	return deferred(x) + 1
+}
+
+func main int {
+	try {
+		locked(-1)
+	} catch (e Error) {
+		println("caught %s", e.message)
+	}
+	with mu {
+		println("locked after %s", "throw")
+	}
+
+	try {
+		println("%d", deferred(3))
+		deferred(-3)
+	} catch (e Error) {
+		println("caught %s", e.message)
+	}
+
+	try {
+		outer(-5)
+	} catch (e Error) {
+		println("caught %s", e.message)
+	}
+	with mu {
+		with mu2 {
+			println("%d calls", calls)
+		}
+	}
+	return 0
+}
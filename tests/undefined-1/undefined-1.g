@@ -0,0 +1,9 @@
+# undefined 1
+is main
+
+include "std:io"
+
+func main int {
+	int count = 1;
+	return count + nothere;
+}
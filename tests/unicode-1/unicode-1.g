@@ -0,0 +1,26 @@
+# unicode 1
+is main
+
+include "std:str"
+
+func main int {
+	string cafe = "caf\u{e9} ét\u{E9}"
+	println("%s %d %d", cafe, str:len(cafe), str:rune_count(cafe))
+
+	string smile = "\u{1F600}"
+	println("%s %d", smile, str:len(smile))
+
+	rune e = 'é'
+	rune face = '\u{1F600}'
+	byte a = 'a'
+	println("%d %d %d %d", e, face, a, sizeof(rune))
+	println("%s%s", str:from_rune(face), str:from_rune('\u{263A}'))
+
+	for rune r in "h\u{e9}!" {
+		if r == 'é' {
+			println("found %d", r)
+		}
+	}
+	println("%d", '\xff' == 255u8)
+	return 0
+}
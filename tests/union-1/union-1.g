@@ -0,0 +1,76 @@
+is main
+
+union Shape {
+	Circle(float radius)
+	Rect(float w, float h)
+	Empty
+}
+
+# a union can hold a class, and a class can hold a union
+class Point {
+	int x
+	int y
+}
+
+union Event {
+	Click(Point at, int button)
+	Key(byte code)
+	Quit
+}
+
+class Entry {
+	Event event
+	long time
+}
+
+func area(Shape s) float {
+	match s {
+		Shape:Circle(r) {
+			return 3.0 * r * r
+		}
+		Shape:Rect(w, h) {
+			return w * h
+		}
+		Shape:Empty {
+			return 0.0
+		}
+	}
+	return -1.0
+}
+
+func describe(Event e) int {
+	int code = 0
+	match e {
+		Event:Click(at, _) {
+			code = at.x * 100 + at.y
+		}
+		Event:Key(c) {
+			code = c
+		}
+		else {
+			code = -1
+		}
+	}
+	return code
+}
+
+func main int {
+	Shape s = Shape:Circle(2.0)
+	println("%.1f", area(s))
+	s = Shape:Rect(3, 4.5)
+	println("%.1f", area(s))
+	s = Shape:Empty
+	println("%.1f", area(s))
+
+	Point p
+	p.x = 3
+	p.y = 7
+	Entry entry
+	entry.event = Event:Click(p, 1)
+	entry.time = 10
+	println("%d %d %d", describe(entry.event), describe(Event:Key(65)), describe(Event:Quit))
+
+	# the payload is as big as the largest variant
+	println("%d %d %d", sizeof(Shape), sizeof(Event), sizeof(Entry))
+	return 0
+}
@@ -0,0 +1,32 @@
+# name unsigned 1
+is main
+
+func half(u32 x) u32 = x / 2
+
+func main int {
+	# 200 doesn't fit in a signed byte, so it would compare as -56
+	u8 b = 200
+	u8 limit = 100
+	println("%v", b > limit)
+
+	# the top bit of an unsigned value isn't a sign
+	u32 top = 0
+	top = top - 2
+	u32 two = 2
+	println("%v %v", top > two, top / two == 2147483647)
+	println("%d %d", top % 10, half(top))
+
+	# widening fills with zeros, not the sign
+	long wide = b
+	u64 also = top
+	println("%d %v", wide, also == 4294967294)
+
+	# mixing with a signed value of the same width is unsigned, as in c
+	int neg = -1
+	println("%v %v", neg < two, two > neg)
+
+	float f = b
+	u8 back = f
+	println("%.1f %v", f, back == b)
+	return 0
+}
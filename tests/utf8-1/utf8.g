@@ -0,0 +1,17 @@
+is main
+
+include "str"
+
+func main int {
+	string s = "héllo, 世界 😀";
+	for rune r in s {
+		println("%x", r);
+	}
+	for let r in "ab\xff" {
+		println("%d", r);
+	}
+	println("%d bytes %d runes", str:len(s), str:rune_count(s));
+	println("%t %t", str:valid(s), str:valid("ab\xff"));
+	println("%x %s%s", str:rune_at(s, 1), str:from_rune(19990), str:from_rune(0x1F600));
+	return 0;
+}
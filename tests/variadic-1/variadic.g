@@ -0,0 +1,21 @@
+is main
+include "io"
+
+func sum(int nums...) int {
+	total = 0
+	for int i = 0; i < nums.len; i += 1 {
+		total += nums[i]
+	}
+	return total
+}
+
+func forward(int nums...) int {
+	return sum(nums...)
+}
+
+func count(string label, float vals...) long = vals.len
+
+func main int {
+	io:print("%d %d %d %d\n", sum(), sum(1, 2, 3), forward(4, 5, 6), count("x", 1.5, 2))
+	return sum(10, 20)
+}
@@ -0,0 +1,32 @@
+# name vec 1
+is main
+
+func sum(vec<int> v) int {
+	int total = 0
+	for int x in v {
+		total += x
+	}
+	return total
+}
+
+func main int {
+	vec<int> v
+	for int i = 0; i < 10; i += 1 {
+		v.push(i * i)
+	}
+	println("%d %d %d", v.len, v[3], sum(v))
+
+	# elements are assigned through the vec's data, which copies share
+	v[0] = 100
+	println("%d %d", v.pop(), v.pop())
+	println("%d %d", v.len, v[0])
+
+	vec<string> words
+	words.push("grow")
+	words.push("able")
+	for let w in words {
+		println("%s", w)
+	}
+	println("%s", words[0] + words[1])
+	return 0
+}
@@ -0,0 +1,24 @@
+is main
+
+link "visibility.c"
+include "std:io"
+
+# exported functions are compiled even when nothing calls them
+@export
+func square(int x) int = x * x;
+
+@export
+func cube(int x) int = x * x * x;
+
+# hidden functions can only be called from inside the binary
+@hidden
+func twice(int x) int = x * 2;
+
+func exposed(string name) int ...
+
+func main int {
+	io:print("%d %d\n", cube(3), twice(21));
+	# square is kept though nothing calls it, and twice isn't visible
+	io:print("%d %d %d\n", exposed("_XN4mainN6squareTi32Ri32"), exposed("_XN4mainN4cubeTi32Ri32"), exposed("_XN4mainN5twiceTi32Ri32"));
+	return 0;
+}
@@ -0,0 +1,7 @@
+#define _GNU_SOURCE
+#include <dlfcn.h>
+#include <stddef.h>
+
+// whether a symbol can be found from outside of the code it is in, as
+// the binary is linked with -rdynamic
+int exposed(const char *name) { return dlsym(RTLD_DEFAULT, name) != NULL; }
@@ -0,0 +1,32 @@
+# name visibility 2
+is main
+
+include "io"
+include "str"
+
+# pub exports a declaration from its package. Everything in main is
+# visible to main either way.
+pub class Counter {
+	int count
+
+	func bump int {
+		this.count = this.count + 1
+		return this.count
+	}
+}
+
+pub int start = 40
+int step = 1
+
+func twice(Counter* c) int {
+	c.bump()
+	return c.bump()
+}
+
+pub func main int {
+	Counter* c = xmalloc(info(Counter).size);
+	c.count = start;
+	io:print("%d\n", twice(c) + step);
+	io:print("%d\n", str:len("geode"));
+	return 0;
+}
@@ -0,0 +1,9 @@
+is other
+
+func secret int {
+	return 3;
+}
+
+pub func shown int {
+	return secret() + 1;
+}
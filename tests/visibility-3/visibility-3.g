@@ -0,0 +1,9 @@
+# name visibility 3
+is main
+
+include "other"
+
+# secret isn't pub, so only package other can call it
+func main int {
+	return other:shown() + other:secret();
+}
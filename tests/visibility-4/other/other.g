@@ -0,0 +1,4 @@
+is other
+
+int hidden = 3;
+pub int shown = 4;
@@ -0,0 +1,9 @@
+# name visibility 4
+is main
+
+include "other"
+
+# hidden isn't pub, so only package other can use it
+func main int {
+	return other:shown + other:hidden;
+}
@@ -0,0 +1,19 @@
+# volatile 1
+is main
+
+include "std:mem"
+
+int flag = 0
+
+func main int {
+	int* f = &flag
+	for i = 0; i < 10; i += 1 {
+		volatile:store(f, volatile:load(f) + i)
+	}
+
+	byte* buf = mem:get(3)
+	buf[2] = 3
+	volatile:store(&buf[1], 40)
+	println("%d %d", volatile:load(f), volatile:load(&buf[1]) + buf[2])
+	return 0
+}
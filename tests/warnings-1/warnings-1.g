@@ -0,0 +1,20 @@
+# warnings 1
+@allow(deprecated)
+is main
+
+include "std:io"
+
+func double(int x) int -> x * 2
+
+@allow(unused)
+func ignored int {
+	int a = 1
+	return 2
+}
+
+func main int {
+	@allow(unused) int b = 3
+	_c = 4
+	io:print("%d %d\n", double(5), ignored())
+	return 0
+}
@@ -0,0 +1,19 @@
+# warnings 2
+is main
+
+include "std:io"
+
+func main int {
+	long wide = 5000000000
+	# a cast says the narrowing is on purpose
+	int small = wide as int
+	# constants that fit don't narrow
+	byte b = 255
+	int x = 3
+	if x > 2 {
+		@allow(shadow) int x = 4
+		io:print("%d %d %d ", small, b as int & 255, x)
+	}
+	io:print("%d\n", x)
+	return 0
+}